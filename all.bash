@@ -32,9 +32,11 @@ verify_header() {
     do
         # Allow for the copyright header to start on either of the first two
         # lines, to accommodate conventions for CSS and HTML.
+        # Generated files, like those from protoc-gen-go, are exempt.
         line="$(head -3 $FILE)"
         if [[ ! $line == *"The Go Authors. All rights reserved."* ]] &&
-         [[ ! $line == "// DO NOT EDIT. This file was copied from" ]]; then
+         [[ ! $line == "// DO NOT EDIT. This file was copied from" ]] &&
+         [[ ! $line == "// Code generated "*"DO NOT EDIT."* ]]; then
               err "missing license header: $FILE"
         fi
     done
@@ -6,11 +6,15 @@ package main
 import (
 	"bufio"
 	"context"
+	"encoding/base64"
 	"flag"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	cloudtasks "cloud.google.com/go/cloudtasks/apiv2"
@@ -18,19 +22,29 @@ import (
 	"contrib.go.opencensus.io/integrations/ocsql"
 	"github.com/go-redis/redis/v7"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/blob"
+	"golang.org/x/pkgsite/internal/cache"
 	"golang.org/x/pkgsite/internal/config"
 	"golang.org/x/pkgsite/internal/database"
 	"golang.org/x/pkgsite/internal/dcensus"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/fetch"
 	"golang.org/x/pkgsite/internal/frontend"
+	"golang.org/x/pkgsite/internal/grpcapi"
+	"golang.org/x/pkgsite/internal/grpcapi/pkgsitepb"
+	"golang.org/x/pkgsite/internal/licenses"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/middleware"
 	"golang.org/x/pkgsite/internal/postgres"
 	"golang.org/x/pkgsite/internal/proxy"
 	"golang.org/x/pkgsite/internal/proxydatasource"
 	"golang.org/x/pkgsite/internal/queue"
+	"golang.org/x/pkgsite/internal/shutdown"
 	"golang.org/x/pkgsite/internal/source"
+	"golang.org/x/pkgsite/internal/sumdb"
+	"golang.org/x/pkgsite/internal/webhook"
+	"google.golang.org/grpc"
 )
 
 var (
@@ -38,19 +52,37 @@ var (
 	staticPath     = flag.String("static", "content/static", "path to folder containing static files served")
 	thirdPartyPath = flag.String("third_party", "third_party", "path to folder containing third-party libraries")
 	devMode        = flag.Bool("dev", false, "enable developer mode (reload templates on each page load, serve non-minified JS/CSS, etc.)")
-	proxyURL       = flag.String("proxy_url", "https://proxy.golang.org", "Uses the module proxy referred to by this URL "+
-		"for direct proxy mode and frontend fetches")
+	proxyURL       = flag.String("proxy_url", "https://proxy.golang.org", "Uses the module proxy referred to by this URL, "+
+		"or the list of proxies in GOPROXY syntax, for direct proxy mode and frontend fetches")
 	directProxy = flag.Bool("direct_proxy", false, "if set to true, uses the module proxy referred to by this URL "+
 		"as a direct backend, bypassing the database")
+	baseURL = flag.String("base_url", "", "absolute URL at which the frontend is reachable, such as https://example.com/pkgsite; "+
+		"overrides GO_DISCOVERY_BASE_URL")
+	readOnly = flag.Bool("read_only", false, "if set to true, never write to the database, for serving from a read replica; "+
+		"endpoints that would write respond with 405")
+	templateOverlay = flag.String("template_overlay", "", "path to folder containing templates, laid out like the static folder's html folder, "+
+		"that override the default templates with the same names")
+)
+
+const (
+	// shutdownTimeout is how long to wait for in-flight requests to finish
+	// when the server is shutting down.
+	shutdownTimeout = 30 * time.Second
+	// shutdownHookTimeout is how long each shutdown hook may run.
+	shutdownHookTimeout = 10 * time.Second
 )
 
 func main() {
 	flag.Parse()
 	ctx := context.Background()
+	var hooks shutdown.Hooks
 	cfg, err := config.Init(ctx)
 	if err != nil {
 		log.Fatal(ctx, err)
 	}
+	if err := log.SetLevel(cfg.LogLevel); err != nil {
+		log.Fatal(ctx, err)
+	}
 	cfg.Dump(os.Stderr)
 	if cfg.UseProfiler {
 		if err := profiler.Start(profiler.Config{}); err != nil {
@@ -66,6 +98,17 @@ func main() {
 	if err != nil {
 		log.Fatal(ctx, err)
 	}
+	sumdbClient, err := sumdb.New(cfg.GOSUMDB, cfg.GONOSUMDB)
+	if err != nil {
+		log.Fatal(ctx, err)
+	}
+	fetch.SetChecksumDB(sumdbClient)
+	licenseOpts := licenses.DefaultDetectOptions()
+	licenseOpts.PartialScanSize = cfg.LicensePartialScanSize
+	licenseOpts.ExcludeDirs = cfg.LicenseExcludeDirs
+	if err := fetch.SetLicenseDetectOptions(licenseOpts); err != nil {
+		log.Fatal(ctx, err)
+	}
 	if *directProxy {
 		ds = proxydatasource.New(proxyClient)
 		exp = internal.NewLocalExperimentSource(readLocalExperiments(ctx))
@@ -79,12 +122,45 @@ func main() {
 		if err != nil {
 			log.Fatal(ctx, err)
 		}
-		db := postgres.New(ddb)
-		defer db.Close()
+		var db *postgres.DB
+		opts := postgres.ReadCacheOptions{Size: cfg.ReadCacheSize, TTL: cfg.ReadCacheTTL}
+		if cfg.ReadCacheRedisHost != "" {
+			opts.Shared = cache.NewRedis(redis.NewClient(&redis.Options{
+				Addr: cfg.ReadCacheRedisHost + ":" + cfg.ReadCacheRedisPort,
+			}))
+		}
+		if opts.Size > 0 || opts.Shared != nil {
+			db = postgres.NewWithReadCache(ddb, opts)
+		} else {
+			db = postgres.New(ddb)
+		}
+		docStore, err := blob.Open(ctx, cfg.DocumentationBucket, cfg.DocumentationDir)
+		if err != nil {
+			log.Fatal(ctx, err)
+		}
+		if docStore != nil {
+			db.SetDocumentationStore(docStore, cfg.DocumentationStoreMinSize)
+		}
+		// Modules fetched by the frontend notify subscribers too.
+		dispatcher := webhook.NewDispatcher(db)
+		db.SetInsertHook(dispatcher.ModuleInserted)
+		db.SetLicenseAllowlist(cfg.LicenseAllowlist)
+		if err := db.CheckSchemaVersion(ctx, postgres.SchemaVersion); err != nil {
+			log.Fatal(ctx, err)
+		}
+		hooks.Register("db", func(context.Context) error { return db.Close() })
+		// Registered after the database, so that it runs first.
+		hooks.Register("webhooks", dispatcher.Shutdown)
+		if cfg.GRPCPort != "" {
+			gs := serveGRPC(ctx, cfg.GRPCPort, db)
+			hooks.Register("grpc", func(context.Context) error { gs.GracefulStop(); return nil })
+		}
 		ds = db
 		exp = db
-		sourceClient := source.NewClient(config.SourceTimeout)
-		fetchQueue = newQueue(ctx, cfg, proxyClient, sourceClient, db)
+		if !*readOnly {
+			sourceClient := source.NewClient(config.SourceTimeout)
+			fetchQueue = newQueue(ctx, cfg, proxyClient, sourceClient, db)
+		}
 	}
 	var haClient *redis.Client
 	if cfg.RedisHAHost != "" {
@@ -92,6 +168,30 @@ func main() {
 			Addr: cfg.RedisHAHost + ":" + cfg.RedisHAPort,
 		})
 	}
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatal(ctx, err)
+	}
+	cfg.Quota = quotaSettings(ctx, "Quota", cfg.Quota, trustedProxies)
+	cfg.FetchQuota = quotaSettings(ctx, "FetchQuota", cfg.FetchQuota, trustedProxies)
+	base := cfg.BaseURL
+	if *baseURL != "" {
+		base = *baseURL
+	}
+	var pageTokenKey []byte
+	if cfg.PageTokenKey == "" {
+		// Each instance would sign page tokens with its own random key, and
+		// reject those of every other instance.
+		if cfg.OnAppEngine() {
+			log.Fatal(ctx, "GO_DISCOVERY_PAGE_TOKEN_KEY must be set on App Engine")
+		}
+		log.Error(ctx, "GO_DISCOVERY_PAGE_TOKEN_KEY is not set: using a random key, so /api/search page tokens will not work on any other frontend instance")
+	} else {
+		pageTokenKey, err = base64.StdEncoding.DecodeString(cfg.PageTokenKey)
+		if err != nil {
+			log.Fatalf(ctx, "decoding GO_DISCOVERY_PAGE_TOKEN_KEY: %v", err)
+		}
+	}
 	server, err := frontend.NewServer(frontend.ServerConfig{
 		DataSource:           ds,
 		Queue:                fetchQueue,
@@ -101,18 +201,27 @@ func main() {
 		ThirdPartyPath:       *thirdPartyPath,
 		DevMode:              *devMode,
 		AppVersionLabel:      cfg.AppVersionLabel(),
+		PopularCacheTTL:      cfg.PopularCacheTTL,
+		BaseURL:              base,
+		ReadOnly:             *readOnly,
+		TemplateOverlayPath:  *templateOverlay,
+		RobotsTxtPath:        cfg.RobotsTxtPath,
+		FaviconPath:          cfg.FaviconPath,
+		LogoPath:             cfg.LogoPath,
+		FetchQuota:           middleware.Quota(cfg.FetchQuota, trustedProxies),
+		PageTokenKey:         pageTokenKey,
 	})
 	if err != nil {
 		log.Fatalf(ctx, "frontend.NewServer: %v", err)
 	}
 	router := dcensus.NewRouter(frontend.TagRoute)
-	var cacheClient *redis.Client
+	var pageCache cache.Cache
 	if cfg.RedisCacheHost != "" {
-		cacheClient = redis.NewClient(&redis.Options{
+		pageCache = cache.NewRedis(redis.NewClient(&redis.Options{
 			Addr: cfg.RedisCacheHost + ":" + cfg.RedisCachePort,
-		})
+		}))
 	}
-	server.Install(router.Handle, cacheClient)
+	server.Install(router.Handle, pageCache)
 	views := append(dcensus.ServerViews,
 		postgres.SearchLatencyDistribution,
 		postgres.SearchResponseCount,
@@ -125,6 +234,8 @@ func main() {
 	if err := dcensus.Init(cfg, views...); err != nil {
 		log.Fatal(ctx, err)
 	}
+	hooks.Register("metrics", func(context.Context) error { dcensus.FlushMetrics(); return nil })
+	hooks.Register("traces", func(context.Context) error { dcensus.FlushTraces(); return nil })
 	// We are not currently forwarding any ports on AppEngine, so serving debug
 	// information is broken.
 	if !cfg.OnAppEngine() {
@@ -145,8 +256,9 @@ func main() {
 	}
 	mw := middleware.Chain(
 		middleware.RequestLog(requestLogger),
-		middleware.AcceptMethods(http.MethodGet), // accept only GETs
-		middleware.Quota(cfg.Quota),
+		// Accept only GETs, except for GraphQL queries, which may be POSTed.
+		middleware.AcceptPathMethods(map[string][]string{"/graphql": {http.MethodPost}}, http.MethodGet),
+		middleware.Quota(cfg.Quota, trustedProxies),
 		middleware.GodocURL(),                          // potentially redirects so should be early in chain
 		middleware.SecureHeaders(),                     // must come before any caching for nonces to work
 		middleware.LatestVersion(server.LatestVersion), // must come before caching for version badge to work
@@ -155,8 +267,44 @@ func main() {
 		middleware.Experiment(experimenter),
 	)
 	addr := cfg.HostAddr("localhost:8080")
+	srv := &http.Server{Addr: addr, Handler: mw(router)}
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		log.Infof(ctx, "received signal %v; shutting down", <-sig)
+		sctx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(sctx); err != nil {
+			log.Errorf(ctx, "srv.Shutdown: %v", err)
+		}
+	}()
 	log.Infof(ctx, "Listening on addr %s", addr)
-	log.Fatal(ctx, http.ListenAndServe(addr, mw(router)))
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(ctx, err)
+	}
+	// ListenAndServe returns as soon as Shutdown is called, so wait for
+	// in-flight requests to finish before running the hooks.
+	<-shutdownDone
+	// Run logs each hook that fails, so there is nothing more to do with the
+	// error.
+	_ = hooks.Run(ctx, shutdownHookTimeout)
+}
+
+// quotaSettings returns qs, in record-only mode if there are no trusted
+// proxies. Without them the quota middleware limits each client by the address
+// the request came from, which behind a load balancer such as App Engine's is
+// the same for every client, so enforcing the quota would limit all clients
+// together.
+func quotaSettings(ctx context.Context, name string, qs config.QuotaSettings, tp middleware.TrustedProxies) config.QuotaSettings {
+	if len(tp) > 0 || qs.RecordOnly == nil || *qs.RecordOnly {
+		return qs
+	}
+	log.Errorf(ctx, "GO_DISCOVERY_TRUSTED_PROXIES is not set: %s will not be enforced, only recorded", name)
+	recordOnly := true
+	qs.RecordOnly = &recordOnly
+	return qs
 }
 
 func newQueue(ctx context.Context, cfg *config.Config, proxyClient *proxy.Client, sourceClient *source.Client, db *postgres.DB) queue.Queue {
@@ -186,6 +334,24 @@ func newQueue(ctx context.Context, cfg *config.Config, proxyClient *proxy.Client
 	return queue.NewGCP(cfg, client, queueName)
 }
 
+// serveGRPC starts serving the gRPC API for db on port, and returns the
+// server.
+func serveGRPC(ctx context.Context, port string, db *postgres.DB) *grpc.Server {
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		log.Fatal(ctx, err)
+	}
+	gs := grpc.NewServer(grpc.UnaryInterceptor(grpcapi.UnaryErrorInterceptor))
+	pkgsitepb.RegisterPkgsiteServer(gs, grpcapi.NewServer(db))
+	go func() {
+		log.Infof(ctx, "Serving gRPC on port %s", port)
+		if err := gs.Serve(lis); err != nil {
+			log.Errorf(ctx, "gRPC server: %v", err)
+		}
+	}()
+	return gs
+}
+
 // openDB opens a connection to a database with the given driver, using connection info from
 // the given config.
 // It first tries the main connection info (DBConnInfo), and if that fails, it uses backup
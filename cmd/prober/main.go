@@ -220,6 +220,9 @@ func main() {
 	if err != nil {
 		log.Fatal(ctx, err)
 	}
+	if err := log.SetLevel(cfg.LogLevel); err != nil {
+		log.Fatal(ctx, err)
+	}
 	cfg.Dump(os.Stderr)
 
 	if _, err := log.UseStackdriver(ctx, cfg, "prober-log"); err != nil {
@@ -24,6 +24,9 @@ func main() {
 	if err != nil {
 		log.Fatal(ctx, err)
 	}
+	if err := log.SetLevel(cfg.LogLevel); err != nil {
+		log.Fatal(ctx, err)
+	}
 	cfg.Dump(os.Stderr)
 	if cfg.OnAppEngine() {
 		_, err := log.UseStackdriver(ctx, cfg, "teeproxy-log")
@@ -12,21 +12,28 @@ import (
 	"flag"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	cloudtasks "cloud.google.com/go/cloudtasks/apiv2"
 	"cloud.google.com/go/errorreporting"
 	"cloud.google.com/go/profiler"
 	"github.com/go-redis/redis/v7"
+	"golang.org/x/pkgsite/internal/blob"
 	"golang.org/x/pkgsite/internal/config"
 	"golang.org/x/pkgsite/internal/database"
 	"golang.org/x/pkgsite/internal/dcensus"
 	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/fetch"
 	"golang.org/x/pkgsite/internal/index"
+	"golang.org/x/pkgsite/internal/licenses"
 	"golang.org/x/pkgsite/internal/queue"
 	"golang.org/x/pkgsite/internal/source"
+	"golang.org/x/pkgsite/internal/sumdb"
+	"golang.org/x/pkgsite/internal/webhook"
 	"golang.org/x/pkgsite/internal/worker"
 
 	"golang.org/x/pkgsite/internal/log"
@@ -44,6 +51,15 @@ var (
 	staticPath = flag.String("static", "content/static", "path to folder containing static files served")
 )
 
+// shutdownTimeout is how long to wait for in-flight requests and webhook
+// notifications to finish when the worker is shutting down.
+const shutdownTimeout = 30 * time.Second
+
+// maxRequestBodySize is the maximum size of a request body. The worker's
+// endpoints, such as /reprocess and /fetch, take their arguments from the URL,
+// so any body they are sent is small.
+const maxRequestBodySize = 1 << 20
+
 func main() {
 	flag.Parse()
 
@@ -53,6 +69,9 @@ func main() {
 	if err != nil {
 		log.Fatal(ctx, err)
 	}
+	if err := log.SetLevel(cfg.LogLevel); err != nil {
+		log.Fatal(ctx, err)
+	}
 	cfg.Dump(os.Stderr)
 
 	if cfg.UseProfiler {
@@ -74,6 +93,19 @@ func main() {
 	}
 	db := postgres.New(ddb)
 	defer db.Close()
+	docStore, err := blob.Open(ctx, cfg.DocumentationBucket, cfg.DocumentationDir)
+	if err != nil {
+		log.Fatal(ctx, err)
+	}
+	if docStore != nil {
+		db.SetDocumentationStore(docStore, cfg.DocumentationStoreMinSize)
+	}
+	dispatcher := webhook.NewDispatcher(db)
+	db.SetInsertHook(dispatcher.ModuleInserted)
+	db.SetLicenseAllowlist(cfg.LicenseAllowlist)
+	if err := db.CheckSchemaVersion(ctx, postgres.SchemaVersion); err != nil {
+		log.Fatal(ctx, err)
+	}
 
 	populateExcluded(ctx, db)
 
@@ -85,6 +117,17 @@ func main() {
 	if err != nil {
 		log.Fatal(ctx, err)
 	}
+	sumdbClient, err := sumdb.New(cfg.GOSUMDB, cfg.GONOSUMDB)
+	if err != nil {
+		log.Fatal(ctx, err)
+	}
+	fetch.SetChecksumDB(sumdbClient)
+	licenseOpts := licenses.DefaultDetectOptions()
+	licenseOpts.PartialScanSize = cfg.LicensePartialScanSize
+	licenseOpts.ExcludeDirs = cfg.LicenseExcludeDirs
+	if err := fetch.SetLicenseDetectOptions(licenseOpts); err != nil {
+		log.Fatal(ctx, err)
+	}
 	sourceClient := source.NewClient(config.SourceTimeout)
 	fetchQueue := newQueue(ctx, cfg, proxyClient, sourceClient, db)
 	reportingClient := reportingClient(ctx, cfg)
@@ -109,6 +152,7 @@ func main() {
 	server.Install(router.Handle)
 
 	views := append(dcensus.ClientViews, dcensus.ServerViews...)
+	views = append(views, worker.IndexingLagViews...)
 	if err := dcensus.Init(cfg, views...); err != nil {
 		log.Fatal(ctx, err)
 	}
@@ -135,13 +179,37 @@ func main() {
 	mw := middleware.Chain(
 		middleware.RequestLog(requestLogger),
 		middleware.Timeout(time.Duration(handlerTimeout)*time.Minute),
+		middleware.MaxBodySize(maxRequestBodySize),
 		middleware.Experiment(experimenter),
 	)
 	http.Handle("/", mw(router))
 
 	addr := cfg.HostAddr("localhost:8000")
+	srv := &http.Server{Addr: addr}
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		log.Infof(ctx, "received signal %v; shutting down", <-sig)
+		sctx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(sctx); err != nil {
+			log.Errorf(ctx, "srv.Shutdown: %v", err)
+		}
+		// Modules inserted by the requests that just finished may still be
+		// notifying subscribers.
+		if err := dispatcher.Shutdown(sctx); err != nil {
+			log.Errorf(ctx, "dispatcher.Shutdown: %v", err)
+		}
+	}()
 	log.Infof(ctx, "Listening on addr %s", addr)
-	log.Fatal(ctx, http.ListenAndServe(addr, nil))
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(ctx, err)
+	}
+	// ListenAndServe returns as soon as Shutdown is called, so wait for
+	// in-flight requests and notifications before closing the database.
+	<-shutdownDone
 }
 
 func newQueue(ctx context.Context, cfg *config.Config, proxyClient *proxy.Client, sourceClient *source.Client, db *postgres.DB) queue.Queue {
@@ -0,0 +1,49 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package blob defines an interface for storing large values, like rendered
+// documentation, outside the database, and implementations of it.
+package blob
+
+import (
+	"context"
+
+	"cloud.google.com/go/storage"
+)
+
+// A Store holds values under slash-separated keys, like
+// "documentation/example.com/m@v1.0.0/example.com/m/pkg.html", until they are
+// deleted.
+//
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the value for key. If key is not present, the error wraps
+	// derrors.NotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Put stores val under key, replacing any previous value.
+	Put(ctx context.Context, key string, val []byte) error
+	// Delete removes key, if it is present.
+	Delete(ctx context.Context, key string) error
+}
+
+// Open returns a GCS for bucket if it is not empty, and otherwise a Dir for
+// dir if it is not empty. It returns nil if both are empty.
+func Open(ctx context.Context, bucket, dir string) (Store, error) {
+	switch {
+	case bucket != "":
+		client, err := storage.NewClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return NewGCS(client, bucket), nil
+	case dir != "":
+		d, err := NewDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	default:
+		return nil, nil
+	}
+}
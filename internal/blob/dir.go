@@ -0,0 +1,94 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// Dir is a Store that keeps each value in a file under a directory of the
+// local file system. It is meant for development.
+type Dir struct {
+	dir string
+}
+
+// NewDir returns a Dir that stores values under dir, creating it if
+// necessary.
+func NewDir(dir string) (*Dir, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Dir{dir: dir}, nil
+}
+
+// file returns the name of the file for key. Keys that are not clean relative
+// paths are rejected, so that no key refers to a file outside the directory.
+func (d *Dir) file(key string) (string, error) {
+	if key == "" || path.IsAbs(key) || path.Clean(key) != key || key == ".." || strings.HasPrefix(key, "../") {
+		return "", fmt.Errorf("invalid key %q: %w", key, derrors.InvalidArgument)
+	}
+	return filepath.Join(d.dir, filepath.FromSlash(key)), nil
+}
+
+// Get implements Store.Get.
+func (d *Dir) Get(ctx context.Context, key string) (_ []byte, err error) {
+	defer derrors.Wrap(&err, "Dir.Get(%q)", key)
+	f, err := d.file(key)
+	if err != nil {
+		return nil, err
+	}
+	val, err := ioutil.ReadFile(f)
+	if os.IsNotExist(err) {
+		return nil, derrors.NotFound
+	}
+	return val, err
+}
+
+// Put implements Store.Put. The value is written to a temporary file that is
+// then renamed, so that Get never sees a partial value.
+func (d *Dir) Put(ctx context.Context, key string, val []byte) (err error) {
+	defer derrors.Wrap(&err, "Dir.Put(%q)", key)
+	f, err := d.file(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(f), ".put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly after the rename
+	if _, err := tmp.Write(val); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f)
+}
+
+// Delete implements Store.Delete.
+func (d *Dir) Delete(ctx context.Context, key string) (err error) {
+	defer derrors.Wrap(&err, "Dir.Delete(%q)", key)
+	f, err := d.file(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package blob
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+func TestDir(t *testing.T) {
+	ctx := context.Background()
+	tmp, err := ioutil.TempDir("", "blob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	d, err := NewDir(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		key   = "documentation/example.com/m@v1.0.0/example.com/m.html"
+		inner = "documentation/example.com/m@v1.0.0/example.com/m/pkg.html"
+	)
+	if _, err := d.Get(ctx, key); !errors.Is(err, derrors.NotFound) {
+		t.Fatalf("Get before Put: got error %v, want NotFound", err)
+	}
+	for _, k := range []string{key, inner} {
+		if err := d.Put(ctx, k, []byte("v1 "+k)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.Put(ctx, key, []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]string{key: "v2", inner: "v1 " + inner} {
+		got, err := d.Get(ctx, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("Get(%q) = %q, want %q", k, got, want)
+		}
+	}
+	if err := d.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Get(ctx, key); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("Get after Delete: got error %v, want NotFound", err)
+	}
+	// Deleting a missing key is not an error.
+	if err := d.Delete(ctx, key); err != nil {
+		t.Errorf("second Delete: %v", err)
+	}
+}
+
+func TestDirInvalidKeys(t *testing.T) {
+	d := &Dir{dir: "unused"}
+	for _, key := range []string{"", "/abs", "..", "../escape", "a/../../escape", "a//b", "./a"} {
+		if err := d.Put(context.Background(), key, nil); !errors.Is(err, derrors.InvalidArgument) {
+			t.Errorf("Put(%q): got error %v, want InvalidArgument", key, err)
+		}
+	}
+}
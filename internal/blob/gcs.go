@@ -0,0 +1,59 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package blob
+
+import (
+	"context"
+	"io/ioutil"
+
+	"cloud.google.com/go/storage"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// GCS is a Store that keeps each value in an object of a Google Cloud Storage
+// bucket, named by its key.
+type GCS struct {
+	bucket *storage.BucketHandle
+}
+
+// NewGCS returns a GCS that stores values in the named bucket.
+func NewGCS(client *storage.Client, bucket string) *GCS {
+	return &GCS{bucket: client.Bucket(bucket)}
+}
+
+// Get implements Store.Get.
+func (g *GCS) Get(ctx context.Context, key string) (_ []byte, err error) {
+	defer derrors.Wrap(&err, "GCS.Get(%q)", key)
+	r, err := g.bucket.Object(key).NewReader(ctx)
+	if err == storage.ErrObjectNotExist {
+		return nil, derrors.NotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// Put implements Store.Put.
+func (g *GCS) Put(ctx context.Context, key string, val []byte) (err error) {
+	defer derrors.Wrap(&err, "GCS.Put(%q)", key)
+	w := g.bucket.Object(key).NewWriter(ctx)
+	if _, err := w.Write(val); err != nil {
+		w.Close()
+		return err
+	}
+	// The object is created when the writer is closed.
+	return w.Close()
+}
+
+// Delete implements Store.Delete.
+func (g *GCS) Delete(ctx context.Context, key string) (err error) {
+	defer derrors.Wrap(&err, "GCS.Delete(%q)", key)
+	if err := g.bucket.Object(key).Delete(ctx); err != nil && err != storage.ErrObjectNotExist {
+		return err
+	}
+	return nil
+}
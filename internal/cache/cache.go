@@ -0,0 +1,37 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cache defines an interface for caching encoded values, and
+// implementations of it.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// A Cache holds encoded values for a limited time.
+//
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value for key, and whether it was present.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores val under key for at most ttl.
+	Set(ctx context.Context, key string, val []byte, ttl time.Duration) error
+	// Delete removes key, if it is present.
+	Delete(ctx context.Context, key string) error
+}
+
+// Noop is a Cache that holds nothing. It is useful for disabling caching
+// without checking for a nil Cache.
+type Noop struct{}
+
+// Get implements Cache.Get. It always reports that key is not present.
+func (Noop) Get(context.Context, string) ([]byte, bool, error) { return nil, false, nil }
+
+// Set implements Cache.Set. It does nothing.
+func (Noop) Set(context.Context, string, []byte, time.Duration) error { return nil }
+
+// Delete implements Cache.Delete. It does nothing.
+func (Noop) Delete(context.Context, string) error { return nil }
@@ -0,0 +1,90 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
+)
+
+// testCache checks the behavior common to all Cache implementations.
+// fastForward advances the cache's clock.
+func testCache(t *testing.T, c Cache, fastForward func(time.Duration)) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, ok, err := c.Get(ctx, "a"); ok || err != nil {
+		t.Fatalf("Get of missing key: got %t, %v; want false, nil", ok, err)
+	}
+	for _, k := range []string{"a", "b"} {
+		if err := c.Set(ctx, k, []byte("value "+k), time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, ok, err := c.Get(ctx, "a")
+	if err != nil || !ok || string(got) != "value a" {
+		t.Fatalf("Get(a): got %q, %t, %v; want %q, true, nil", got, ok, err, "value a")
+	}
+	if err := c.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := c.Get(ctx, "a"); ok {
+		t.Error("Get(a) after Delete: got true, want false")
+	}
+	if err := c.Delete(ctx, "missing"); err != nil {
+		t.Errorf("Delete of missing key: %v", err)
+	}
+	fastForward(2 * time.Minute)
+	if _, ok, _ := c.Get(ctx, "b"); ok {
+		t.Error("Get(b) after TTL: got true, want false")
+	}
+}
+
+func TestMemory(t *testing.T) {
+	now := time.Now()
+	m := NewMemory(10)
+	m.now = func() time.Time { return now }
+	testCache(t, m, func(d time.Duration) { now = now.Add(d) })
+}
+
+func TestMemoryEviction(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(2)
+	for _, k := range []string{"a", "b", "c"} {
+		if err := m.Set(ctx, k, []byte(k), time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok, _ := m.Get(ctx, "a"); ok {
+		t.Error("Get(a): got true, want false")
+	}
+	if _, ok, _ := m.Get(ctx, "c"); !ok {
+		t.Error("Get(c): got false, want true")
+	}
+}
+
+func TestRedis(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	testCache(t, NewRedis(redis.NewClient(&redis.Options{Addr: s.Addr()})), s.FastForward)
+}
+
+func TestNoop(t *testing.T) {
+	ctx := context.Background()
+	var c Cache = Noop{}
+	if err := c.Set(ctx, "a", []byte("a"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := c.Get(ctx, "a"); ok || err != nil {
+		t.Errorf("Get(a): got %t, %v; want false, nil", ok, err)
+	}
+}
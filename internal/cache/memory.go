@@ -0,0 +1,68 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/golang/groupcache/lru"
+)
+
+// Memory is a Cache that holds values in process memory, evicting the least
+// recently used value when it is full.
+type Memory struct {
+	now func() time.Time // for testing
+
+	mu  sync.Mutex
+	lru *lru.Cache
+}
+
+type memoryEntry struct {
+	val     []byte
+	expires time.Time
+}
+
+// NewMemory returns a Memory that holds up to size values. If size is zero,
+// there is no limit.
+func NewMemory(size int) *Memory {
+	return &Memory{now: time.Now, lru: lru.New(size)}
+}
+
+// Get implements Cache.Get.
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.lru.Get(key)
+	if !ok {
+		return nil, false, nil
+	}
+	e := v.(memoryEntry)
+	if m.now().After(e.expires) {
+		m.lru.Remove(key)
+		return nil, false, nil
+	}
+	return e.val, true, nil
+}
+
+// Set implements Cache.Set. The Memory keeps a copy of val.
+func (m *Memory) Set(_ context.Context, key string, val []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lru.Add(key, memoryEntry{
+		val:     append([]byte(nil), val...),
+		expires: m.now().Add(ttl),
+	})
+	return nil
+}
+
+// Delete implements Cache.Delete.
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lru.Remove(key)
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// Redis is a Cache backed by a redis server.
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis returns a Redis that uses client.
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{client: client}
+}
+
+// Get implements Cache.Get.
+func (r *Redis) Get(ctx context.Context, key string) (_ []byte, _ bool, err error) {
+	defer derrors.Wrap(&err, "Redis.Get(ctx, %q)", key)
+	val, err := r.client.WithContext(ctx).Get(key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return val, true, nil
+}
+
+// Set implements Cache.Set.
+func (r *Redis) Set(ctx context.Context, key string, val []byte, ttl time.Duration) (err error) {
+	defer derrors.Wrap(&err, "Redis.Set(ctx, %q, val, %s)", key, ttl)
+	return r.client.WithContext(ctx).Set(key, val, ttl).Err()
+}
+
+// Delete implements Cache.Delete.
+func (r *Redis) Delete(ctx context.Context, key string) (err error) {
+	defer derrors.Wrap(&err, "Redis.Delete(ctx, %q)", key)
+	return r.client.WithContext(ctx).Del(key).Err()
+}
@@ -18,6 +18,7 @@ import (
 	"math/rand"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -61,12 +62,24 @@ func ValidateAppVersion(appVersion string) error {
 // Config holds shared configuration values used in instantiating our server
 // components.
 type Config struct {
-	// Discovery environment variables
+	// Discovery environment variables. ProxyURL may be a list of module
+	// proxies with the syntax of GOPROXY; see proxy.New.
 	ProxyURL, IndexURL string
 
+	// GOSUMDB is the checksum database that the modules downloaded from the
+	// proxy are verified against, and GONOSUMDB the module path patterns
+	// that are not verified, with the syntax of the environment variables of
+	// the go command; see sumdb.New. A GOSUMDB of "off" disables
+	// verification.
+	GOSUMDB, GONOSUMDB string
+
 	// Ports used for hosting. 'DebugPort' is used for serving HTTP debug pages.
 	Port, DebugPort string
 
+	// GRPCPort is the port on which the frontend serves its gRPC API. If it is
+	// empty, the gRPC API is not served.
+	GRPCPort string
+
 	// AppEngine identifiers
 	ProjectID, ServiceID, VersionID, ZoneID, InstanceID, LocationID string
 
@@ -92,6 +105,13 @@ type Config struct {
 	DBSecondaryHost                          string // DB host to use if first one is down
 	DBPassword                               string `json:"-"`
 
+	// DBSSLMode is the sslmode of database connections: one of "disable",
+	// "require", "verify-ca" and "verify-full". If empty, it is "disable". The
+	// verifying modes need DBSSLRootCert, the path to the root certificate of
+	// the server. DBSSLCert and DBSSLKey are the paths to an optional client
+	// certificate and its key.
+	DBSSLMode, DBSSLRootCert, DBSSLCert, DBSSLKey string
+
 	// Configuration for redis page cache.
 	RedisCacheHost, RedisCachePort string
 
@@ -99,10 +119,78 @@ type Config struct {
 	// cache instance as it has different availability requirements.
 	RedisHAHost, RedisHAPort string
 
+	// PopularCacheTTL is how long the popular packages page is cached.
+	// Imported-by counts change slowly, so this can be long.
+	PopularCacheTTL time.Duration
+
 	// UseProfiler specifies whether to enable Stackdriver Profiler.
 	UseProfiler bool
 
+	// TrustedProxies is a list of CIDRs of proxies that are trusted to set the
+	// X-Forwarded-For header. See middleware.ParseTrustedProxies.
+	TrustedProxies []string
+
+	// ReadCacheSize is the number of database read results that the frontend
+	// caches in memory, for specific module versions. If zero, reads are not
+	// cached. ReadCacheTTL is how long each result is cached.
+	ReadCacheSize int
+	ReadCacheTTL  time.Duration
+
+	// Configuration for the redis instance that caches database read results
+	// across frontend instances. If ReadCacheRedisHost is empty, results are
+	// only cached in memory. The TTL is ReadCacheTTL.
+	ReadCacheRedisHost, ReadCacheRedisPort string
+
+	// DocumentationBucket, if non-empty, is the Cloud Storage bucket in which
+	// package documentation HTML that is at least DocumentationStoreMinSize
+	// bytes long is kept, instead of in the database. DocumentationDir is a
+	// local directory used in the same way, for development. It is ignored if
+	// DocumentationBucket is set. See blob.Open.
+	DocumentationBucket, DocumentationDir string
+	DocumentationStoreMinSize             int
+
+	// BaseURL is the absolute URL at which the frontend is reachable, for when
+	// it runs behind a reverse proxy. If empty, it is derived from each request.
+	BaseURL string
+
+	// RobotsTxtPath, if non-empty, is the path to a file whose contents the
+	// frontend serves as /robots.txt, in place of its default crawl policy.
+	RobotsTxtPath string
+
+	// FaviconPath and LogoPath, if non-empty, are the paths to image files
+	// that replace the Go favicon and logos of the frontend.
+	FaviconPath, LogoPath string
+
+	// LogLevel is the lowest severity that is logged, such as "info" or
+	// "error". If empty, everything is logged. See log.SetLevel.
+	LogLevel string
+
+	// LicenseAllowlist, if non-empty, is the list of license types, such as
+	// "MIT", of the modules that are made searchable when they are inserted.
+	// See postgres.DB.SetLicenseAllowlist.
+	LicenseAllowlist []string
+
+	// LicensePartialScanSize, if non-zero, is the number of bytes at the
+	// start of an oversized license file that are classified. See
+	// licenses.DetectOptions.PartialScanSize.
+	LicensePartialScanSize uint64
+
+	// LicenseExcludeDirs lists patterns for the directories of a module whose
+	// license files are ignored, like "testdata". See
+	// licenses.DetectOptions.ExcludeDirs.
+	LicenseExcludeDirs []string
+
+	// PageTokenKey is the base64 encoding of the key that the frontend signs
+	// the page tokens of its search API with. It must be set on App Engine.
+	// Elsewhere, if it is empty, each frontend instance uses a random key.
+	// See frontend.ServerConfig.PageTokenKey.
+	PageTokenKey string
+
 	Quota QuotaSettings
+
+	// FetchQuota limits how often each client can ask the frontend to fetch
+	// a module that is not yet in the database.
+	FetchQuota QuotaSettings
 }
 
 // AppVersionLabel returns the version label for the current instance.  This is
@@ -161,8 +249,75 @@ func (c *Config) dbConnInfo(host string) string {
 	// Set the statement_timeout config parameter for this session.
 	// See https://www.postgresql.org/docs/current/runtime-config-client.html.
 	timeoutOption := fmt.Sprintf("-c statement_timeout=%d", StatementTimeout/time.Millisecond)
-	return fmt.Sprintf("user='%s' password='%s' host='%s' port=%s dbname='%s' sslmode=disable options='%s'",
-		c.DBUser, c.DBPassword, host, c.DBPort, c.DBName, timeoutOption)
+	sslMode := c.DBSSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	info := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=%s options=%s",
+		quoteConnValue(c.DBUser), quoteConnValue(c.DBPassword), quoteConnValue(host),
+		quoteConnValue(c.DBPort), quoteConnValue(c.DBName), quoteConnValue(sslMode), quoteConnValue(timeoutOption))
+	for _, p := range []struct{ key, value string }{
+		{"sslrootcert", c.DBSSLRootCert},
+		{"sslcert", c.DBSSLCert},
+		{"sslkey", c.DBSSLKey},
+	} {
+		if p.value != "" {
+			info += fmt.Sprintf(" %s=%s", p.key, quoteConnValue(p.value))
+		}
+	}
+	return info
+}
+
+// quoteConnValue quotes v for use as a value in a PostgreSQL key-value
+// connection string, so that it may contain spaces, quotes and backslashes.
+func quoteConnValue(v string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+// validateDB reports problems with the database configuration, such as
+// missing values, that would otherwise only be detected when connecting.
+func (c *Config) validateDB() error {
+	var errs []string
+	if c.DBHost == "" {
+		errs = append(errs, "GO_DISCOVERY_DATABASE_HOST is empty")
+	}
+	if c.DBUser == "" {
+		errs = append(errs, "GO_DISCOVERY_DATABASE_USER is empty")
+	}
+	if c.DBName == "" {
+		errs = append(errs, "GO_DISCOVERY_DATABASE_NAME is empty")
+	}
+	if p, err := strconv.Atoi(c.DBPort); err != nil || p <= 0 || p > 65535 {
+		errs = append(errs, fmt.Sprintf("GO_DISCOVERY_DATABASE_PORT %q is not a valid port", c.DBPort))
+	}
+	switch c.DBSSLMode {
+	case "", "disable", "require":
+	case "verify-ca", "verify-full":
+		if c.DBSSLRootCert == "" {
+			errs = append(errs, fmt.Sprintf("GO_DISCOVERY_DATABASE_SSLROOTCERT must be set for sslmode %q", c.DBSSLMode))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("GO_DISCOVERY_DATABASE_SSLMODE %q is not one of disable, require, verify-ca and verify-full", c.DBSSLMode))
+	}
+	if (c.DBSSLCert == "") != (c.DBSSLKey == "") {
+		errs = append(errs, "GO_DISCOVERY_DATABASE_SSLCERT and GO_DISCOVERY_DATABASE_SSLKEY must be set together")
+	}
+	for _, f := range []struct{ env, path string }{
+		{"GO_DISCOVERY_DATABASE_SSLROOTCERT", c.DBSSLRootCert},
+		{"GO_DISCOVERY_DATABASE_SSLCERT", c.DBSSLCert},
+		{"GO_DISCOVERY_DATABASE_SSLKEY", c.DBSSLKey},
+	} {
+		if f.path == "" {
+			continue
+		}
+		if _, err := os.Stat(f.path); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", f.env, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid database configuration: %s", strings.Join(errs, "; "))
+	}
+	return nil
 }
 
 // HostAddr returns the network on which to serve the primary HTTP service.
@@ -188,6 +343,7 @@ type configOverride struct {
 	DBSecondaryHost string
 	DBName          string
 	Quota           QuotaSettings
+	FetchQuota      QuotaSettings
 }
 
 // QuotaSettings is config for internal/middleware/quota.go
@@ -213,9 +369,12 @@ func Init(ctx context.Context) (_ *Config, err error) {
 	// from envvars and others from remote services.
 	cfg := &Config{
 		IndexURL:  GetEnv("GO_MODULE_INDEX_URL", "https://index.golang.org/index"),
-		ProxyURL:  GetEnv("GO_MODULE_PROXY_URL", "https://proxy.golang.org"),
+		ProxyURL:  GetEnv("GO_MODULE_PROXY_URL", GetEnv("GOPROXY", "https://proxy.golang.org")),
+		GOSUMDB:   GetEnv("GO_DISCOVERY_GOSUMDB", GetEnv("GOSUMDB", "sum.golang.org")),
+		GONOSUMDB: GetEnv("GO_DISCOVERY_GONOSUMDB", GetEnv("GONOSUMDB", os.Getenv("GOPRIVATE"))),
 		Port:      os.Getenv("PORT"),
 		DebugPort: os.Getenv("DEBUG_PORT"),
+		GRPCPort:  os.Getenv("GRPC_PORT"),
 		// Resolve AppEngine identifiers
 		ProjectID:    os.Getenv("GOOGLE_CLOUD_PROJECT"),
 		ServiceID:    os.Getenv("GAE_SERVICE"),
@@ -233,6 +392,9 @@ func Init(ctx context.Context) (_ *Config, err error) {
 		DBUser:               GetEnv("GO_DISCOVERY_DATABASE_USER", "postgres"),
 		DBPassword:           os.Getenv("GO_DISCOVERY_DATABASE_PASSWORD"),
 		DBSecondaryHost:      chooseOne(os.Getenv("GO_DISCOVERY_DATABASE_SECONDARY_HOST")),
+		DBSSLRootCert:        os.Getenv("GO_DISCOVERY_DATABASE_SSLROOTCERT"),
+		DBSSLCert:            os.Getenv("GO_DISCOVERY_DATABASE_SSLCERT"),
+		DBSSLKey:             os.Getenv("GO_DISCOVERY_DATABASE_SSLKEY"),
 		DBPort:               GetEnv("GO_DISCOVERY_DATABASE_PORT", "5432"),
 		DBName:               GetEnv("GO_DISCOVERY_DATABASE_NAME", "discovery-db"),
 		DBSecret:             os.Getenv("GO_DISCOVERY_DATABASE_SECRET"),
@@ -240,6 +402,10 @@ func Init(ctx context.Context) (_ *Config, err error) {
 		RedisCachePort:       GetEnv("GO_DISCOVERY_REDIS_PORT", "6379"),
 		RedisHAHost:          os.Getenv("GO_DISCOVERY_REDIS_HA_HOST"),
 		RedisHAPort:          GetEnv("GO_DISCOVERY_REDIS_HA_PORT", "6379"),
+		ReadCacheRedisHost:   os.Getenv("GO_DISCOVERY_READ_CACHE_REDIS_HOST"),
+		ReadCacheRedisPort:   GetEnv("GO_DISCOVERY_READ_CACHE_REDIS_PORT", "6379"),
+		DocumentationBucket:  os.Getenv("GO_DISCOVERY_DOCUMENTATION_BUCKET"),
+		DocumentationDir:     os.Getenv("GO_DISCOVERY_DOCUMENTATION_DIR"),
 		Quota: QuotaSettings{
 			QPS:          10,
 			Burst:        20,
@@ -247,7 +413,42 @@ func Init(ctx context.Context) (_ *Config, err error) {
 			RecordOnly:   func() *bool { t := true; return &t }(),
 			AcceptedURLs: parseCommaList(GetEnv("GO_DISCOVERY_ACCEPTED_LIST", "")),
 		},
-		UseProfiler: os.Getenv("GO_DISCOVERY_USE_PROFILER") == "TRUE",
+		FetchQuota: QuotaSettings{
+			QPS:        1,
+			Burst:      5,
+			MaxEntries: 1000,
+			RecordOnly: func() *bool { f := false; return &f }(),
+		},
+		UseProfiler:        os.Getenv("GO_DISCOVERY_USE_PROFILER") == "TRUE",
+		TrustedProxies:     parseCommaList(os.Getenv("GO_DISCOVERY_TRUSTED_PROXIES")),
+		BaseURL:            os.Getenv("GO_DISCOVERY_BASE_URL"),
+		RobotsTxtPath:      os.Getenv("GO_DISCOVERY_ROBOTS_TXT_PATH"),
+		FaviconPath:        os.Getenv("GO_DISCOVERY_FAVICON_PATH"),
+		LogoPath:           os.Getenv("GO_DISCOVERY_LOGO_PATH"),
+		LogLevel:           os.Getenv("GO_DISCOVERY_LOG_LEVEL"),
+		LicenseAllowlist:   parseCommaList(os.Getenv("GO_DISCOVERY_LICENSE_ALLOWLIST")),
+		LicenseExcludeDirs: parseCommaList(os.Getenv("GO_DISCOVERY_LICENSE_EXCLUDE_DIRS")),
+		PageTokenKey:       os.Getenv("GO_DISCOVERY_PAGE_TOKEN_KEY"),
+	}
+	cfg.PopularCacheTTL, err = time.ParseDuration(GetEnv("GO_DISCOVERY_POPULAR_CACHE_TTL", "24h"))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse GO_DISCOVERY_POPULAR_CACHE_TTL: %v", err)
+	}
+	cfg.ReadCacheSize, err = strconv.Atoi(GetEnv("GO_DISCOVERY_READ_CACHE_SIZE", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse GO_DISCOVERY_READ_CACHE_SIZE: %v", err)
+	}
+	cfg.ReadCacheTTL, err = time.ParseDuration(GetEnv("GO_DISCOVERY_READ_CACHE_TTL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse GO_DISCOVERY_READ_CACHE_TTL: %v", err)
+	}
+	cfg.DocumentationStoreMinSize, err = strconv.Atoi(GetEnv("GO_DISCOVERY_DOCUMENTATION_STORE_MIN_SIZE", "100000"))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse GO_DISCOVERY_DOCUMENTATION_STORE_MIN_SIZE: %v", err)
+	}
+	cfg.LicensePartialScanSize, err = strconv.ParseUint(GetEnv("GO_DISCOVERY_LICENSE_PARTIAL_SCAN_SIZE", "0"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse GO_DISCOVERY_LICENSE_PARTIAL_SCAN_SIZE: %v", err)
 	}
 	cfg.AppMonitoredResource = &mrpb.MonitoredResource{
 		Type: "gae_app",
@@ -267,9 +468,12 @@ func Init(ctx context.Context) (_ *Config, err error) {
 		}
 		cfg.ZoneID = zone
 	}
-	if cfg.DBHost == "" {
-		panic("DBHost is empty; impossible")
+	// Connections are only unencrypted by default during local development.
+	defaultSSLMode := "disable"
+	if cfg.OnAppEngine() {
+		defaultSSLMode = "require"
 	}
+	cfg.DBSSLMode = GetEnv("GO_DISCOVERY_DATABASE_SSLMODE", defaultSSLMode)
 	if cfg.DBSecret != "" {
 		var err error
 		cfg.DBPassword, err = secrets.Get(ctx, cfg.DBSecret)
@@ -292,6 +496,9 @@ func Init(ctx context.Context) (_ *Config, err error) {
 			processOverrides(cfg, overrideBytes)
 		}
 	}
+	if err := cfg.validateDB(); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
 
@@ -324,6 +531,10 @@ func processOverrides(cfg *Config, bytes []byte) {
 	overrideInt("Quota.Burst", &cfg.Quota.Burst, ov.Quota.Burst)
 	overrideInt("Quota.MaxEntries", &cfg.Quota.MaxEntries, ov.Quota.MaxEntries)
 	overrideBool("Quota.RecordOnly", &cfg.Quota.RecordOnly, ov.Quota.RecordOnly)
+	overrideInt("FetchQuota.QPS", &cfg.FetchQuota.QPS, ov.FetchQuota.QPS)
+	overrideInt("FetchQuota.Burst", &cfg.FetchQuota.Burst, ov.FetchQuota.Burst)
+	overrideInt("FetchQuota.MaxEntries", &cfg.FetchQuota.MaxEntries, ov.FetchQuota.MaxEntries)
+	overrideBool("FetchQuota.RecordOnly", &cfg.FetchQuota.RecordOnly, ov.FetchQuota.RecordOnly)
 }
 
 func overrideString(name string, field *string, val string) {
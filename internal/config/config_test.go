@@ -5,7 +5,11 @@
 package config
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -30,6 +34,118 @@ func TestValidateAppVersion(t *testing.T) {
 	}
 }
 
+func TestDBConnInfo(t *testing.T) {
+	const options = ` sslmode='disable' options='-c statement_timeout=600000'`
+	for _, test := range []struct {
+		name     string
+		password string
+		want     string
+	}{
+		{
+			name:     "simple",
+			password: "pw",
+			want:     `user='u' password='pw' host='h' port='5432' dbname='db'` + options,
+		},
+		{
+			name:     "empty",
+			password: "",
+			want:     `user='u' password='' host='h' port='5432' dbname='db'` + options,
+		},
+		{
+			name:     "spaces",
+			password: "a b  c",
+			want:     `user='u' password='a b  c' host='h' port='5432' dbname='db'` + options,
+		},
+		{
+			name:     "quotes",
+			password: `it's "q"`,
+			want:     `user='u' password='it\'s "q"' host='h' port='5432' dbname='db'` + options,
+		},
+		{
+			name:     "backslashes",
+			password: `a\'b\`,
+			want:     `user='u' password='a\\\'b\\' host='h' port='5432' dbname='db'` + options,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Config{DBUser: "u", DBPassword: test.password, DBHost: "h", DBPort: "5432", DBName: "db"}
+			if got := c.DBConnInfo(); got != test.want {
+				t.Errorf("got  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDBConnInfoSSL(t *testing.T) {
+	c := &Config{
+		DBUser:        "u",
+		DBHost:        "h",
+		DBPort:        "5432",
+		DBName:        "db",
+		DBSSLMode:     "verify-full",
+		DBSSLRootCert: "/certs/server ca.pem",
+		DBSSLCert:     "/certs/client.pem",
+		DBSSLKey:      "/certs/client.key",
+	}
+	want := `user='u' password='' host='h' port='5432' dbname='db' sslmode='verify-full'` +
+		` options='-c statement_timeout=600000'` +
+		` sslrootcert='/certs/server ca.pem' sslcert='/certs/client.pem' sslkey='/certs/client.key'`
+	if got := c.DBConnInfo(); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestValidateDB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cert := filepath.Join(dir, "cert.pem")
+	if err := ioutil.WriteFile(cert, []byte("cert"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	valid := Config{DBUser: "u", DBHost: "h", DBPort: "5432", DBName: "db"}
+	if err := valid.validateDB(); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+	verifying := valid
+	verifying.DBSSLMode = "verify-full"
+	verifying.DBSSLRootCert = cert
+	verifying.DBSSLCert = cert
+	verifying.DBSSLKey = cert
+	if err := verifying.validateDB(); err != nil {
+		t.Errorf("verifying config: %v", err)
+	}
+	for _, test := range []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{"no host", func(c *Config) { c.DBHost = "" }, "GO_DISCOVERY_DATABASE_HOST"},
+		{"no user", func(c *Config) { c.DBUser = "" }, "GO_DISCOVERY_DATABASE_USER"},
+		{"no name", func(c *Config) { c.DBName = "" }, "GO_DISCOVERY_DATABASE_NAME"},
+		{"bad port", func(c *Config) { c.DBPort = "pg" }, "GO_DISCOVERY_DATABASE_PORT"},
+		{"port out of range", func(c *Config) { c.DBPort = "70000" }, "GO_DISCOVERY_DATABASE_PORT"},
+		{"bad sslmode", func(c *Config) { c.DBSSLMode = "verify" }, "GO_DISCOVERY_DATABASE_SSLMODE"},
+		{"verify without root cert", func(c *Config) { c.DBSSLMode = "verify-ca" }, "GO_DISCOVERY_DATABASE_SSLROOTCERT"},
+		{"missing root cert", func(c *Config) {
+			c.DBSSLMode = "verify-full"
+			c.DBSSLRootCert = filepath.Join(dir, "missing.pem")
+		}, "GO_DISCOVERY_DATABASE_SSLROOTCERT"},
+		{"cert without key", func(c *Config) { c.DBSSLCert = cert }, "GO_DISCOVERY_DATABASE_SSLKEY"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c := valid
+			test.modify(&c)
+			err := c.validateDB()
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got %v, want error mentioning %s", err, test.want)
+			}
+		})
+	}
+}
+
 func TestChooseOne(t *testing.T) {
 	tests := []struct {
 		configVar   string
@@ -56,22 +172,26 @@ func TestProcessOverrides(t *testing.T) {
 	tr := true
 	f := false
 	cfg := Config{
-		DBHost: "origHost",
-		DBName: "origName",
-		Quota:  QuotaSettings{QPS: 1, Burst: 2, MaxEntries: 3, RecordOnly: &tr},
+		DBHost:     "origHost",
+		DBName:     "origName",
+		Quota:      QuotaSettings{QPS: 1, Burst: 2, MaxEntries: 3, RecordOnly: &tr},
+		FetchQuota: QuotaSettings{QPS: 1, Burst: 5, MaxEntries: 3, RecordOnly: &f},
 	}
 	ov := `
         DBHost: newHost
         Quota:
            MaxEntries: 17
            RecordOnly: false
+        FetchQuota:
+           Burst: 2
     `
 	processOverrides(&cfg, []byte(ov))
 	got := cfg
 	want := Config{
-		DBHost: "newHost",
-		DBName: "origName",
-		Quota:  QuotaSettings{QPS: 1, Burst: 2, MaxEntries: 17, RecordOnly: &f},
+		DBHost:     "newHost",
+		DBName:     "origName",
+		Quota:      QuotaSettings{QPS: 1, Burst: 2, MaxEntries: 17, RecordOnly: &f},
+		FetchQuota: QuotaSettings{QPS: 1, Burst: 2, MaxEntries: 3, RecordOnly: &f},
 	}
 	if diff := cmp.Diff(want, got); diff != "" {
 		t.Errorf("mismatch (-want, +got):\n%s", diff)
@@ -62,7 +62,9 @@ func (db *DB) InTransaction() bool {
 	return db.tx != nil
 }
 
-var passwordRegexp = regexp.MustCompile(`password=\S+`)
+// passwordRegexp matches the password in a connection string, which may be
+// quoted and contain escaped quotes.
+var passwordRegexp = regexp.MustCompile(`password=('(\\.|[^'\\])*'|\S+)`)
 
 func redactPassword(dbinfo string) string {
 	return passwordRegexp.ReplaceAllLiteralString(dbinfo, "password=REDACTED")
@@ -17,6 +17,8 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal/config"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/testing/dbtest"
 )
@@ -267,6 +269,70 @@ func TestBuildUpsertConflictAction(t *testing.T) {
 	}
 }
 
+func TestRedactPassword(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{"user=u password=pw host=h", "user=u password=REDACTED host=h"},
+		{"user='u' password='a b' host='h'", "user='u' password=REDACTED host='h'"},
+		{`user='u' password='it\'s \\' host='h'`, "user='u' password=REDACTED host='h'"},
+		{"postgres://h/db?user=u&password=pw", "postgres://h/db?user=u&password=REDACTED"},
+	} {
+		if got := redactPassword(test.in); got != test.want {
+			t.Errorf("redactPassword(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+// TestOpenAwkwardPasswords checks that connection strings built by
+// config.Config.DBConnInfo can be parsed and used to connect when the password
+// contains characters that need quoting.
+func TestOpenAwkwardPasswords(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	const role = "awkward_password_test"
+	dropRole := func() {
+		if _, err := testDB.Exec(ctx, "DROP ROLE IF EXISTS "+role); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer dropRole()
+	for _, password := range []string{
+		"with space",
+		"it's",
+		`back\slash`,
+		`\'`,
+		`a "b" = 'c' \d`,
+	} {
+		t.Run(password, func(t *testing.T) {
+			dropRole()
+			if _, err := testDB.Exec(ctx, fmt.Sprintf("CREATE ROLE %s LOGIN PASSWORD %s", role, pq.QuoteLiteral(password))); err != nil {
+				t.Fatal(err)
+			}
+			cfg := &config.Config{
+				DBUser:     role,
+				DBPassword: password,
+				DBHost:     config.GetEnv("GO_DISCOVERY_DATABASE_TEST_HOST", "localhost"),
+				DBPort:     config.GetEnv("GO_DISCOVERY_DATABASE_TEST_PORT", "5432"),
+				DBName:     "discovery_postgres_test",
+			}
+			db, err := Open("postgres", cfg.DBConnInfo(), "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			var user string
+			if err := db.QueryRow(ctx, "SELECT current_user").Scan(&user); err != nil {
+				t.Fatal(err)
+			}
+			if user != role {
+				t.Errorf("connected as %q, want %q", user, role)
+			}
+		})
+	}
+}
+
 func TestDBAfterTransactFails(t *testing.T) {
 	ctx := context.Background()
 	var tx *DB
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"contrib.go.opencensus.io/exporter/prometheus"
@@ -142,6 +143,39 @@ func exportToStackdriver(ctx context.Context, cfg *config.Config) {
 		log.Fatalf(ctx, "error creating trace exporter: %v", err)
 	}
 	trace.RegisterExporter(traceExporter)
+
+	exportersMu.Lock()
+	stackdriverViewExporter = viewExporter
+	stackdriverTraceExporter = traceExporter
+	exportersMu.Unlock()
+}
+
+var (
+	exportersMu              sync.Mutex
+	stackdriverViewExporter  *stackdriver.Exporter
+	stackdriverTraceExporter *stackdriver.Exporter
+)
+
+// FlushMetrics waits for buffered metrics to be uploaded to StackDriver. It
+// does nothing if Init did not configure exporting to StackDriver.
+func FlushMetrics() {
+	exportersMu.Lock()
+	e := stackdriverViewExporter
+	exportersMu.Unlock()
+	if e != nil {
+		e.Flush()
+	}
+}
+
+// FlushTraces waits for buffered trace spans to be uploaded to StackDriver. It
+// does nothing if Init did not configure exporting to StackDriver.
+func FlushTraces() {
+	exportersMu.Lock()
+	e := stackdriverTraceExporter
+	exportersMu.Unlock()
+	if e != nil {
+		e.Flush()
+	}
 }
 
 // NewViewExporter creates a StackDriver exporter for stats.
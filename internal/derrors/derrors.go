@@ -21,6 +21,10 @@ var (
 
 	// NotFound indicates that a requested entity was not found (HTTP 404).
 	NotFound = errors.New("not found")
+	// Gone indicates that a requested entity is no longer available, such as
+	// a module version that the module proxy does not serve (HTTP 410). It is
+	// a kind of NotFound: errors.Is(Gone, NotFound) is true.
+	Gone error = goneError{}
 	// InvalidArgument indicates that the input into the request is invalid in
 	// some way (HTTP 400).
 	InvalidArgument = errors.New("invalid argument")
@@ -33,6 +37,25 @@ var (
 	// from the path specified in the go.mod file.
 	AlternativeModule = errors.New("alternative module")
 
+	// ChecksumMismatch indicates that the hash of the go.mod file or the zip
+	// of a module, as served by the module proxy, does not match the one
+	// recorded in the checksum database. Such a module may have been tampered
+	// with, so it is not inserted. (See sumdb.Client.Verify.)
+	ChecksumMismatch = errors.New("checksum mismatch")
+
+	// LicenseNotAllowed indicates that a module was inserted, but is not
+	// searchable because its licenses are not on the license allowlist of the
+	// deployment. It is only used for the status of the module version.
+	// (See postgres.DB.SetLicenseAllowlist.)
+	LicenseNotAllowed = errors.New("license not allowed")
+
+	// ProxyError indicates that no module proxy could serve a request, for a
+	// reason other than the module or version not existing, such as a proxy
+	// being unreachable or responding with a server error. It has no HTTP
+	// status of its own, so that such requests are retried like other
+	// internal errors.
+	ProxyError = errors.New("proxy error")
+
 	// Unknown indicates that the error has unknown semantics.
 	Unknown = errors.New("unknown")
 
@@ -71,6 +94,10 @@ var (
 	// ReprocessHasIncompletePackages indicates that the module to be reprocessed
 	// previously had a status of 290.
 	ReprocessHasIncompletePackages = errors.New("reprocess has incomplete packages")
+	// ReprocessLicenseNotAllowed indicates that the module to be reprocessed
+	// previously had a status of 291. Reprocessing applies the current
+	// license allowlist.
+	ReprocessLicenseNotAllowed = errors.New("reprocess license not allowed")
 	// ReprocessBadModule indicates that the module to be reprocessed
 	// previously had a status of derrors.BadModule.
 	ReprocessBadModule = errors.New("reprocess bad module")
@@ -79,25 +106,40 @@ var (
 	ReprocessAlternative = errors.New("reprocess alternative module")
 )
 
+// goneError is the type of Gone.
+type goneError struct{}
+
+func (goneError) Error() string { return "gone" }
+
+// Is reports whether target is NotFound, so that a Gone error is also a
+// NotFound error.
+func (goneError) Is(target error) bool { return target == NotFound }
+
 var httpCodes = []struct {
 	err  error
 	code int
 }{
+	// Gone comes before NotFound, because a Gone error is also a NotFound
+	// error.
+	{Gone, http.StatusGone},
 	{NotFound, http.StatusNotFound},
 	{InvalidArgument, http.StatusBadRequest},
 	{Excluded, http.StatusForbidden},
 
 	// Since the following aren't HTTP statuses, pick unused codes.
 	{HasIncompletePackages, 290},
+	{LicenseNotAllowed, 291},
 	{DBModuleInsertInvalid, 480},
 	{BadModule, 490},
 	{AlternativeModule, 491},
+	{ChecksumMismatch, 492},
 
 	// 52x errors represents modules that need to be reprocessed, and the
 	// previous status code the module had. Note that the status code
 	// matters for determining reprocessing order.
 	{ReprocessStatusOK, 520},
 	{ReprocessHasIncompletePackages, 521},
+	{ReprocessLicenseNotAllowed, 522},
 	{ReprocessBadModule, 540},
 	{ReprocessAlternative, 541},
 
@@ -155,6 +197,8 @@ func ToReprocessStatus(status int) int {
 		return ToHTTPStatus(ReprocessStatusOK)
 	case ToHTTPStatus(HasIncompletePackages):
 		return ToHTTPStatus(ReprocessHasIncompletePackages)
+	case ToHTTPStatus(LicenseNotAllowed):
+		return ToHTTPStatus(ReprocessLicenseNotAllowed)
 	case ToHTTPStatus(BadModule):
 		return ToHTTPStatus(ReprocessBadModule)
 	case ToHTTPStatus(AlternativeModule):
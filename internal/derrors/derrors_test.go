@@ -50,8 +50,11 @@ func TestToHTTPStatus(t *testing.T) {
 		{nil, http.StatusOK},
 		{InvalidArgument, http.StatusBadRequest},
 		{NotFound, http.StatusNotFound},
+		{Gone, http.StatusGone},
+		{fmt.Errorf("wrapping: %w", Gone), http.StatusGone},
 		{BadModule, 490},
 		{AlternativeModule, 491},
+		{ChecksumMismatch, 492},
 		{Unknown, http.StatusInternalServerError},
 		{fmt.Errorf("wrapping: %w", NotFound), http.StatusNotFound},
 		{io.ErrUnexpectedEOF, http.StatusInternalServerError},
@@ -99,3 +102,15 @@ func TestWrap(t *testing.T) {
 		t.Errorf("Unwrap: got %#v, want %#v", got, orig)
 	}
 }
+
+func TestGone(t *testing.T) {
+	if !errors.Is(Gone, NotFound) {
+		t.Error("errors.Is(Gone, NotFound) = false, want true")
+	}
+	if errors.Is(NotFound, Gone) {
+		t.Error("errors.Is(NotFound, Gone) = true, want false")
+	}
+	if got := FromHTTPStatus(http.StatusGone, ""); got != Gone {
+		t.Errorf("FromHTTPStatus(410, \"\") = %v, want %v", got, Gone)
+	}
+}
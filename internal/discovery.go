@@ -6,6 +6,7 @@ package internal
 
 import (
 	"path"
+	"strings"
 	"time"
 
 	"golang.org/x/mod/module"
@@ -30,6 +31,11 @@ const (
 	UnknownModulePath = "unknownModulePath"
 )
 
+// MaxImportsPerPackage is the maximum number of imports of a package.
+// Fetching a module with a package that exceeds it fails, and
+// postgres.DB.InsertModule rejects the module.
+const MaxImportsPerPackage = 1000
+
 // ModuleInfo holds metadata associated with a module.
 type ModuleInfo struct {
 	ModulePath        string
@@ -38,9 +44,23 @@ type ModuleInfo struct {
 	VersionType       version.Type
 	IsRedistributable bool
 	HasGoMod          bool // whether the module zip has a go.mod file
+	// MinGoVersion is the Go version in the go directive of the module's
+	// go.mod file, like "1.18". It is empty if the module has no go.mod file
+	// or the file has no go directive.
+	MinGoVersion string
+	// LicenseExpression describes the licenses at the root of the module,
+	// like "Apache-2.0 OR MIT". See
+	// licenses.Detector.ModuleLicenseExpression.
+	LicenseExpression string
 	SourceInfo        *source.Info
 }
 
+// IsUnstable reports whether the module version has major version zero, at
+// which the module makes no stability guarantees.
+func (mi *ModuleInfo) IsUnstable() bool {
+	return version.IsUnstable(mi.Version)
+}
+
 // LegacyModuleInfo holds metadata associated with a module.
 type LegacyModuleInfo struct {
 	ModuleInfo
@@ -99,10 +119,42 @@ type Module struct {
 	// that may be contained in nested subdirectories.
 	Licenses    []*licenses.License
 	Directories []*DirectoryNew
+	// Files holds the paths of all files in this module version, relative to
+	// the module root, in sorted order.
+	Files []string
+	// VendoredModules holds the modules listed in the vendor/modules.txt file
+	// at the root of this module version, in the order they are listed.
+	VendoredModules []*VendoredModule
+	// Changelog is the CHANGELOG, HISTORY or CHANGES file at the root of this
+	// module version, or nil if there is none.
+	Changelog *Changelog
+	// GoMod holds the contents of the go.mod file at the root of this module
+	// version, or nil if it has none. InsertModule reads MinGoVersion from
+	// it; it is not stored itself.
+	GoMod []byte
 
 	LegacyPackages []*LegacyPackage
 }
 
+// A Changelog is a file that describes the changes in each version of a
+// module.
+type Changelog struct {
+	// Filepath is the path of the file, relative to the module root.
+	Filepath string
+	Contents string
+}
+
+// A VendoredModule is a module whose packages are copied into the vendor
+// directory of another module.
+type VendoredModule struct {
+	ModulePath string
+	// Version is empty if the module is replaced by a directory.
+	Version string
+	// Replacement is the module path and version, or the directory, that
+	// replaces the module. It is empty if the module is not replaced.
+	Replacement string
+}
+
 // VersionedDirectory is a DirectoryNew along with its corresponding module
 // information.
 type VersionedDirectory struct {
@@ -119,6 +171,35 @@ type DirectoryMeta struct {
 	Licenses          []*licenses.Metadata // metadata of applicable licenses
 }
 
+// IsInternal reports whether the directory is an internal directory, or is
+// inside one.
+func (d *DirectoryMeta) IsInternal() bool {
+	return IsInternalPath(d.Path)
+}
+
+// IsInternalPath reports whether the package or directory path contains an
+// "internal" element. Such packages can only be imported by packages in the
+// tree rooted at the parent of the internal directory.
+func IsInternalPath(p string) bool {
+	_, ok := InternalRoot(p)
+	return ok
+}
+
+// InternalRoot returns the root of the tree of packages that may import the
+// package with the given path, and true, if the path is internal. If the path
+// contains more than one "internal" element, the last one is the most
+// restrictive, so its parent is returned. The root of an internal standard
+// library package such as "internal/cpu" is the empty string.
+func InternalRoot(p string) (string, bool) {
+	elems := strings.Split(p, "/")
+	for i := len(elems) - 1; i >= 0; i-- {
+		if elems[i] == "internal" {
+			return strings.Join(elems[:i], "/"), true
+		}
+	}
+	return "", false
+}
+
 // DirectoryNew represents a folder in a module version, and the contents of that folder.
 // It will replace LegacyDirectory once everything has been migrated.
 type DirectoryNew struct {
@@ -137,6 +218,37 @@ type PackageNew struct {
 	Imports       []string
 }
 
+// Kind returns the kind of the package.
+func (p *PackageNew) Kind() PackageKind {
+	return PackageKindForName(p.Name)
+}
+
+// IsInternal reports whether the package is an internal package.
+func (p *PackageNew) IsInternal() bool {
+	return IsInternalPath(p.Path)
+}
+
+// PackageKind describes how a package is meant to be consumed.
+type PackageKind string
+
+const (
+	// PackageKindLibrary is the kind of packages that are imported by other
+	// packages.
+	PackageKindLibrary PackageKind = "library"
+	// PackageKindCommand is the kind of main packages, which build into
+	// executables and are installed rather than imported.
+	PackageKindCommand PackageKind = "command"
+)
+
+// PackageKindForName returns the kind of a package with the given package
+// name.
+func PackageKindForName(name string) PackageKind {
+	if name == "main" {
+		return PackageKindCommand
+	}
+	return PackageKindLibrary
+}
+
 // Documentation is the rendered documentation for a given package
 // for a specific GOOS and GOARCH.
 type Documentation struct {
@@ -280,6 +392,12 @@ type LegacyDirectory struct {
 	Packages []*LegacyPackage
 }
 
+// IsInternal reports whether the directory is an internal directory, or is
+// inside one.
+func (d *LegacyDirectory) IsInternal() bool {
+	return IsInternalPath(d.Path)
+}
+
 // A LegacyPackage is a group of one or more Go source files with the same
 // package header. LegacyPackages are part of a module.
 type LegacyPackage struct {
@@ -288,8 +406,24 @@ type LegacyPackage struct {
 	Synopsis          string
 	IsRedistributable bool
 	Licenses          []*licenses.Metadata // metadata of applicable licenses
-	Imports           []string
+	// Imports is the union of the package's imports over all the build
+	// contexts that fetch considers.
+	Imports []string
+	// ImportBuildContexts maps each import that is not present in every
+	// build context in which the package builds to the build contexts, as
+	// "GOOS/GOARCH", in which it is. Other imports are absent.
+	ImportBuildContexts map[string][]string
+	// StdlibOnly reports whether the package imports only standard library
+	// packages, or nothing. InsertModule sets it from Imports.
+	StdlibOnly        bool
 	DocumentationHTML string
+	// DocumentationText is the documentation as plain text, in the format of
+	// "go doc -all".
+	DocumentationText string
+	// Signatures are the normalized signatures of the exported functions and
+	// methods of the package, like "func(Context, int) error", sorted and
+	// without duplicates. See fetch.NormalizeSignature.
+	Signatures []string
 	// The values of the GOOS and GOARCH environment variables used to parse the
 	// package.
 	GOOS   string
@@ -300,9 +434,78 @@ type LegacyPackage struct {
 	V1Path string
 }
 
+// Kind returns the kind of the package.
+func (p *LegacyPackage) Kind() PackageKind {
+	return PackageKindForName(p.Name)
+}
+
+// IsInternal reports whether the package is an internal package.
+func (p *LegacyPackage) IsInternal() bool {
+	return IsInternalPath(p.Path)
+}
+
+// VersionedPackage identifies a module version that contains the package with
+// Path. Since a package path can be served by more than one module (for
+// example, after a module is renamed), there may be several.
+type VersionedPackage struct {
+	ModuleInfo
+	Path string
+
+	// IsCanonical reports whether ModulePath is the module's canonical path.
+	// It is false if a later version of the module was found to have an
+	// alternative module path in its go.mod file.
+	IsCanonical bool
+	// CanonicalModulePath is the module path from that go.mod file, if
+	// IsCanonical is false.
+	CanonicalModulePath string
+}
+
 // LegacyVersionedPackage is a LegacyPackage along with its corresponding module
 // information.
 type LegacyVersionedPackage struct {
 	LegacyPackage
 	LegacyModuleInfo
 }
+
+// WebhookSubscription is a request to be notified when a version of a module
+// whose path matches ModulePathPattern is inserted.
+type WebhookSubscription struct {
+	ID int
+	// ModulePathPattern is a module path, which matches only itself, or a
+	// path followed by "/...", which matches the path and every module path
+	// that it is a prefix of.
+	ModulePathPattern string
+	CallbackURL       string
+	// Secret is the key for the HMAC signature of each notification.
+	Secret    string
+	CreatedAt time.Time
+}
+
+// DependencyTree is the graph of the packages that a package imports, directly
+// or indirectly.
+type DependencyTree struct {
+	// Root is the package whose dependencies the tree holds. It is also the
+	// first element of Packages.
+	Root *Dependency
+	// Packages holds each package in the tree once, sorted by depth and then
+	// by path.
+	Packages []*Dependency
+}
+
+// Dependency is a package in a DependencyTree.
+type Dependency struct {
+	Path string
+	// ModulePath and Version identify the module version of the package whose
+	// imports are in the tree. They are empty if the tree has none of its
+	// imports: because it is at the maximum depth, it is not in the database,
+	// or it imports nothing.
+	ModulePath string
+	Version    string
+	// Depth is the number of imports in the shortest chain from the root to
+	// the package; it is 0 for the root.
+	Depth int
+	// Imports are the paths of the packages that the package imports, sorted.
+	// Some may have a depth no greater than the package's own, as when they
+	// close an import cycle.
+	Imports []string
+}
@@ -53,3 +53,40 @@ func TestV1Path(t *testing.T) {
 		}
 	}
 }
+
+func TestPackageKindForName(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		want PackageKind
+	}{
+		{"main", PackageKindCommand},
+		{"foo", PackageKindLibrary},
+		{"main_test", PackageKindLibrary},
+		{"", PackageKindLibrary},
+	} {
+		if got := PackageKindForName(test.name); got != test.want {
+			t.Errorf("PackageKindForName(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestInternalRoot(t *testing.T) {
+	for _, test := range []struct {
+		path     string
+		wantRoot string
+		wantOK   bool
+	}{
+		{"github.com/a/b", "", false},
+		{"github.com/a/internalish", "", false},
+		{"github.com/a/internal", "github.com/a", true},
+		{"github.com/a/internal/ffoo", "github.com/a", true},
+		{"github.com/a/internal/b/internal/c", "github.com/a/internal/b", true},
+		{"internal/cpu", "", true},
+		{"cmd/go/internal/load", "cmd/go", true},
+	} {
+		root, ok := InternalRoot(test.path)
+		if root != test.wantRoot || ok != test.wantOK {
+			t.Errorf("InternalRoot(%q) = %q, %t; want %q, %t", test.path, root, ok, test.wantRoot, test.wantOK)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"archive/zip"
+	"path"
+	"strings"
+
+	"golang.org/x/pkgsite/internal"
+)
+
+// changelogNames are the base names of changelog files, without extensions,
+// in order of preference.
+var changelogNames = []string{"CHANGELOG", "HISTORY", "CHANGES"}
+
+const changelogTooLargeReplacement = "The changelog is too large to display."
+
+// extractChangelogFromZip returns the changelog file at the root of the module
+// zip r, or nil if there is none. Only files at the root are considered, so
+// those in vendor directories are never used. If there are several, it
+// prefers them in the order of changelogNames, and a Markdown file to one
+// without an extension. The contents of a file larger than maxChangelogSize
+// are replaced by a message.
+func extractChangelogFromZip(modulePath, resolvedVersion string, r *zip.Reader) (*internal.Changelog, error) {
+	prefix := moduleVersionDir(modulePath, resolvedVersion) + "/"
+	var (
+		best     *zip.File
+		bestRank int
+	)
+	for _, f := range r.File {
+		name := strings.TrimPrefix(f.Name, prefix)
+		if len(name) == len(f.Name) || strings.Contains(name, "/") {
+			continue
+		}
+		if rank := changelogRank(name); rank >= 0 && (best == nil || rank < bestRank) {
+			best, bestRank = f, rank
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+	cl := &internal.Changelog{Filepath: strings.TrimPrefix(best.Name, prefix)}
+	if best.UncompressedSize64 > maxChangelogSize {
+		cl.Contents = changelogTooLargeReplacement
+		return cl, nil
+	}
+	c, err := readZipFile(best)
+	if err != nil {
+		return nil, err
+	}
+	cl.Contents = string(c)
+	return cl, nil
+}
+
+// changelogRank returns the preference of file as a changelog, where lower is
+// better, or -1 if it is not a changelog. A changelog is named like an element
+// of changelogNames, with an optional ".md" extension, in any case.
+func changelogRank(file string) int {
+	base, ext := file, ""
+	if e := path.Ext(file); strings.EqualFold(e, ".md") {
+		base, ext = strings.TrimSuffix(file, e), e
+	}
+	for i, n := range changelogNames {
+		if strings.EqualFold(base, n) {
+			if ext == "" {
+				return 2*i + 1
+			}
+			return 2 * i
+		}
+	}
+	return -1
+}
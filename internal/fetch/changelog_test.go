@@ -0,0 +1,88 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/testing/testhelper"
+)
+
+func TestExtractChangelogFromZip(t *testing.T) {
+	const (
+		modulePath = "github.com/my/module"
+		version    = "v1.0.0"
+	)
+	for _, test := range []struct {
+		name  string
+		files map[string]string
+		want  *internal.Changelog
+	}{
+		{
+			name: "markdown",
+			files: map[string]string{
+				"CHANGELOG.md": "# v1.0.0\n",
+				"README.md":    "readme",
+			},
+			want: &internal.Changelog{Filepath: "CHANGELOG.md", Contents: "# v1.0.0\n"},
+		},
+		{
+			name:  "no extension, any case",
+			files: map[string]string{"History": "v1.0.0: first"},
+			want:  &internal.Changelog{Filepath: "History", Contents: "v1.0.0: first"},
+		},
+		{
+			name: "preference",
+			files: map[string]string{
+				"CHANGES.md":   "changes",
+				"HISTORY":      "history",
+				"HISTORY.md":   "history.md",
+				"changelog.go": "package changelog",
+			},
+			want: &internal.Changelog{Filepath: "HISTORY.md", Contents: "history.md"},
+		},
+		{
+			name: "only at the root",
+			files: map[string]string{
+				"foo/CHANGELOG.md":                   "foo",
+				"vendor/golang.org/x/text/CHANGELOG": "text",
+				"CHANGELOG.txt":                      "txt",
+			},
+			want: nil,
+		},
+		{
+			name:  "too large",
+			files: map[string]string{"CHANGELOG.md": strings.Repeat("x", maxChangelogSize+1)},
+			want:  &internal.Changelog{Filepath: "CHANGELOG.md", Contents: changelogTooLargeReplacement},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			files := map[string]string{}
+			for name, contents := range test.files {
+				files[moduleVersionDir(modulePath, version)+"/"+name] = contents
+			}
+			zipBytes, err := testhelper.ZipContents(files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reader, err := zip.NewReader(bytes.NewReader(zipBytes), int64(len(zipBytes)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := extractChangelogFromZip(modulePath, version, reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"golang.org/x/mod/semver"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/proxy"
+)
+
+// DiscoverVersions returns the versions of modulePath that the module proxy
+// knows about, in increasing semver order. It combines the proxy's list of
+// tagged versions with the version reported by its @latest endpoint, which is
+// a pseudo-version if the module has no tagged versions.
+//
+// The proxy's list is unordered and may contain duplicates or invalid
+// versions; the result contains each valid version once.
+func DiscoverVersions(ctx context.Context, modulePath string, proxyClient *proxy.Client) (_ []string, err error) {
+	defer derrors.Wrap(&err, "DiscoverVersions(ctx, %q)", modulePath)
+
+	listed, err := proxyClient.ListVersions(ctx, modulePath)
+	if err != nil && !errors.Is(err, derrors.NotFound) {
+		return nil, err
+	}
+	info, err := proxyClient.GetInfo(ctx, modulePath, internal.LatestVersion)
+	if err != nil && (len(listed) == 0 || !errors.Is(err, derrors.NotFound)) {
+		return nil, err
+	}
+	if info != nil {
+		listed = append(listed, info.Version)
+	}
+
+	seen := map[string]bool{}
+	var versions []string
+	for _, v := range listed {
+		if !semver.IsValid(v) || seen[v] {
+			continue
+		}
+		seen[v] = true
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return semver.Compare(versions[i], versions[j]) < 0
+	})
+	return versions, nil
+}
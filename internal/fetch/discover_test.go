@@ -0,0 +1,68 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/proxy"
+)
+
+func TestDiscoverVersions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	const pseudo = "v0.0.0-20200101000000-0123456789ab"
+	mux := http.NewServeMux()
+	serve := func(path, body string) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, body)
+		})
+	}
+	// The list is out of order, and has a duplicate and a bad line.
+	serve("/example.com/tagged/@v/list", "v1.10.0\nv1.2.0\nbad\nv1.2.0\nv1.9.0-pre\n")
+	serve("/example.com/tagged/@latest", `{"Version": "v1.10.0"}`)
+	// A module with no tags only has a pseudo-version at @latest.
+	serve("/example.com/untagged/@v/list", "")
+	serve("/example.com/untagged/@latest", fmt.Sprintf(`{"Version": %q}`, pseudo))
+	// @latest may name a newer version than the list.
+	serve("/example.com/newer/@v/list", "v1.0.0\n")
+	serve("/example.com/newer/@latest", `{"Version": "v1.1.0"}`)
+	// The list may be served even if @latest is not.
+	serve("/example.com/nolatest/@v/list", "v0.2.0\nv0.1.0\n")
+
+	proxyClient, teardownProxy := proxy.TestProxyServer(t, mux)
+	defer teardownProxy()
+
+	for _, test := range []struct {
+		modulePath string
+		want       []string
+	}{
+		{"example.com/tagged", []string{"v1.2.0", "v1.9.0-pre", "v1.10.0"}},
+		{"example.com/untagged", []string{pseudo}},
+		{"example.com/newer", []string{"v1.0.0", "v1.1.0"}},
+		{"example.com/nolatest", []string{"v0.1.0", "v0.2.0"}},
+	} {
+		t.Run(test.modulePath, func(t *testing.T) {
+			got, err := DiscoverVersions(ctx, test.modulePath, proxyClient)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := DiscoverVersions(ctx, "example.com/missing", proxyClient); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("DiscoverVersions for a missing module: got error %v, want %v", err, derrors.NotFound)
+	}
+}
@@ -135,6 +135,18 @@ func Render(fset *token.FileSet, p *doc.Package, opt RenderOptions) (string, err
 	return buf.B.String(), nil
 }
 
+// isDeprecated reports whether the doc comment doc marks its declaration as
+// deprecated, by having a paragraph that begins with "Deprecated: ", as
+// described at https://golang.org/wiki/Deprecated.
+func isDeprecated(doc string) bool {
+	for _, para := range strings.Split(doc, "\n\n") {
+		if strings.HasPrefix(strings.TrimLeft(para, "\n"), "Deprecated: ") {
+			return true
+		}
+	}
+	return false
+}
+
 // fileLinkHTML returns an HTML-formatted file name linked to the source URL.
 // If link is the empty string, the file name is not linked.
 func fileLinkHTML(name, link string) template.HTML {
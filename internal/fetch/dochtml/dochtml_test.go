@@ -44,6 +44,57 @@ func TestRender(t *testing.T) {
 	})
 }
 
+func TestRenderDeprecated(t *testing.T) {
+	fset, d := mustLoadPackage("deprecated")
+	rawDoc, err := Render(fset, d, RenderOptions{
+		FileLinkFunc:   func(string) string { return "file" },
+		SourceLinkFunc: func(ast.Node) string { return "src" },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	htmlDoc, err := html.Parse(strings.NewReader(rawDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Collect the ids of the headers, and the targets of the index links,
+	// that are marked as deprecated.
+	var got []string
+	walk(htmlDoc, func(n *html.Node) {
+		if !strings.Contains(" "+attr(n, "class")+" ", " Documentation-deprecated ") {
+			return
+		}
+		if id := attr(n, "id"); id != "" {
+			got = append(got, id)
+		} else {
+			got = append(got, attr(n, "href"))
+		}
+	})
+	want := []string{"#Old", "#T.M", "Old", "T.M"}
+	if diff := cmp.Diff(want, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("deprecated declarations mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestIsDeprecated(t *testing.T) {
+	for _, test := range []struct {
+		doc  string
+		want bool
+	}{
+		{"", false},
+		{"F does something.\n", false},
+		{"Deprecated: Use G.\n", true},
+		{"F does something.\n\nDeprecated: Use G.\n", true},
+		{"F does something.\nDeprecated: not a paragraph.\n", false},
+		{"F replaces E, which is deprecated.\n", false},
+		{"F does something.\n\nDeprecated:Use G.\n", false},
+	} {
+		if got := isDeprecated(test.doc); got != test.want {
+			t.Errorf("isDeprecated(%q) = %t, want %t", test.doc, got, test.want)
+		}
+	}
+}
+
 func TestFileLinkHTML(t *testing.T) {
 	for _, test := range []struct {
 		name string
@@ -33,6 +33,7 @@ var htmlPackage = template.Must(template.New("package").Funcs(
 		"file_link":             func() string { return "" },
 		"source_link":           func() string { return "" },
 		"play_url":              func(*doc.Example) string { return "" },
+		"is_deprecated":         isDeprecated,
 	},
 ).Parse(`{{- "" -}}
 {{- if or .Doc .Consts .Vars .Funcs .Types .Examples.List -}}
@@ -151,21 +152,21 @@ var htmlPackage = template.Must(template.New("package").Funcs(
 
 			{{- range .Funcs -}}
 			<li class="Documentation-indexFunction">
-				<a href="#{{.Name}}">{{render_synopsis .Decl}}</a>
+				<a href="#{{.Name}}"{{if is_deprecated .Doc}} class="Documentation-deprecated"{{end}}>{{render_synopsis .Decl}}</a>
 			</li>{{"\n"}}
 			{{- end -}}
 
 			{{- range .Types -}}
 				{{- $tname := .Name -}}
-				<li class="Documentation-indexType"><a href="#{{$tname}}">type {{$tname}}</a></li>{{"\n"}}
+				<li class="Documentation-indexType"><a href="#{{$tname}}"{{if is_deprecated .Doc}} class="Documentation-deprecated"{{end}}>type {{$tname}}</a></li>{{"\n"}}
 				{{- with .Funcs -}}
 					<li><ul class="Documentation-indexTypeFunctions">{{"\n" -}}
-					{{range .}}<li><a href="#{{.Name}}">{{render_synopsis .Decl}}</a></li>{{"\n"}}{{end}}
+					{{range .}}<li><a href="#{{.Name}}"{{if is_deprecated .Doc}} class="Documentation-deprecated"{{end}}>{{render_synopsis .Decl}}</a></li>{{"\n"}}{{end}}
 					</ul></li>{{"\n" -}}
 				{{- end -}}
 				{{- with .Methods -}}
 					<li><ul class="Documentation-indexTypeMethods">{{"\n" -}}
-					{{range .}}<li><a href="#{{$tname}}.{{.Name}}">{{render_synopsis .Decl}}</a></li>{{"\n"}}{{end}}
+					{{range .}}<li><a href="#{{$tname}}.{{.Name}}"{{if is_deprecated .Doc}} class="Documentation-deprecated"{{end}}>{{render_synopsis .Decl}}</a></li>{{"\n"}}{{end}}
 					</ul></li>{{"\n" -}}
 				{{- end -}}
 			{{- end -}}
@@ -224,7 +225,7 @@ var htmlPackage = template.Must(template.New("package").Funcs(
 	<section class="Documentation-functions">
 		{{- range .Funcs -}}
 		<div class="Documentation-function">
-			<h3 id="{{.Name}}" data-kind="function" class="Documentation-functionHeader">func {{source_link .Name .Decl}} <a href="#{{.Name}}">¶</a></h3>{{"\n"}}
+			<h3 id="{{.Name}}" data-kind="function" class="Documentation-functionHeader{{if is_deprecated .Doc}} Documentation-deprecated{{end}}">func {{source_link .Name .Decl}} <a href="#{{.Name}}">¶</a></h3>{{"\n"}}
 			{{- $out := render_decl .Doc .Decl -}}
 			{{- $out.Decl -}}
 			{{- $out.Doc -}}
@@ -240,7 +241,7 @@ var htmlPackage = template.Must(template.New("package").Funcs(
 		{{- range .Types -}}
 		<div class="Documentation-type">
 			{{- $tname := .Name -}}
-			<h3 id="{{.Name}}" data-kind="type" class="Documentation-typeHeader">type {{source_link .Name .Decl}} <a href="#{{.Name}}">¶</a></h3>{{"\n"}}
+			<h3 id="{{.Name}}" data-kind="type" class="Documentation-typeHeader{{if is_deprecated .Doc}} Documentation-deprecated{{end}}">type {{source_link .Name .Decl}} <a href="#{{.Name}}">¶</a></h3>{{"\n"}}
 			{{- $out := render_decl .Doc .Decl -}}
 			{{- $out.Decl -}}
 			{{- $out.Doc -}}
@@ -267,7 +268,7 @@ var htmlPackage = template.Must(template.New("package").Funcs(
 
 			{{- range .Funcs -}}
 			<div class="Documentation-typeFunc">
-				<h3 id="{{.Name}}" data-kind="function" class="Documentation-typeFuncHeader">func {{source_link .Name .Decl}} <a href="#{{.Name}}">¶</a></h3>{{"\n"}}
+				<h3 id="{{.Name}}" data-kind="function" class="Documentation-typeFuncHeader{{if is_deprecated .Doc}} Documentation-deprecated{{end}}">func {{source_link .Name .Decl}} <a href="#{{.Name}}">¶</a></h3>{{"\n"}}
 				{{- $out := render_decl .Doc .Decl -}}
 				{{- $out.Decl -}}
 				{{- $out.Doc -}}
@@ -279,7 +280,7 @@ var htmlPackage = template.Must(template.New("package").Funcs(
 			{{- range .Methods -}}
 			<div class="Documentation-typeMethod">
 				{{- $name := (printf "%s.%s" $tname .Name) -}}
-				<h3 id="{{$name}}" data-kind="method" class="Documentation-typeMethodHeader">func ({{.Recv}}) {{source_link .Name .Decl}} <a href="#{{$name}}">¶</a></h3>{{"\n"}}
+				<h3 id="{{$name}}" data-kind="method" class="Documentation-typeMethodHeader{{if is_deprecated .Doc}} Documentation-deprecated{{end}}">func ({{.Recv}}) {{source_link .Name .Decl}} <a href="#{{$name}}">¶</a></h3>{{"\n"}}
 				{{- $out := render_decl .Doc .Decl -}}
 				{{- $out.Decl -}}
 				{{- $out.Doc -}}
@@ -0,0 +1,25 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package deprecated has deprecated and current declarations.
+package deprecated
+
+// Old does something.
+//
+// Deprecated: Use New instead.
+func Old() {}
+
+// New does something. It replaces Old, which is deprecated.
+func New() {}
+
+// T is a type.
+type T int
+
+// M does something.
+//
+// Deprecated: M is not needed.
+func (T) M() {}
+
+// N does something.
+func (T) N() {}
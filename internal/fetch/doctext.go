@@ -0,0 +1,188 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	godoc "go/doc"
+	"go/format"
+	"go/token"
+
+	"golang.org/x/pkgsite/internal/fetch/internal/doc"
+)
+
+const (
+	// docTextIndent is the indentation of the documentation of each
+	// declaration, as in the output of "go doc".
+	docTextIndent = "    "
+
+	// docTextWidth is the width at which comments are wrapped.
+	docTextWidth = 80
+
+	docTextTooLargeReplacement = "Documentation is too large to display.\n"
+)
+
+// renderDocText renders the documentation of d as plain text, in the format
+// of "go doc -all": the package clause and comment, followed by the
+// constants, variables, functions and types of the package, each declaration
+// followed by its indented comment. importPath is the path shown in the
+// package clause.
+func renderDocText(fset *token.FileSet, d *doc.Package, importPath string) (string, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "package %s // import %q\n\n", d.Name, importPath)
+	godoc.ToText(&buf, d.Doc, "", docTextIndent, docTextWidth)
+	newlines(&buf, 1)
+
+	hdr := ""
+	printHdr := func(s string) {
+		if hdr != s {
+			fmt.Fprintf(&buf, "\n%s\n\n", s)
+			hdr = s
+		}
+	}
+	emit := func(comment string, node ast.Node) error {
+		if err := format.Node(&buf, fset, node); err != nil {
+			return err
+		}
+		newlines(&buf, 1)
+		if comment != "" {
+			godoc.ToText(&buf, comment, docTextIndent, docTextIndent+"\t", docTextWidth-len(docTextIndent))
+			// A blank line separates a comment from the next declaration.
+			newlines(&buf, 2)
+		}
+		return nil
+	}
+
+	for _, v := range d.Consts {
+		printHdr("CONSTANTS")
+		if err := emit(v.Doc, v.Decl); err != nil {
+			return "", err
+		}
+	}
+	for _, v := range d.Vars {
+		printHdr("VARIABLES")
+		if err := emit(v.Doc, v.Decl); err != nil {
+			return "", err
+		}
+	}
+	for _, f := range d.Funcs {
+		printHdr("FUNCTIONS")
+		if err := emit(f.Doc, f.Decl); err != nil {
+			return "", err
+		}
+	}
+	for _, t := range d.Types {
+		printHdr("TYPES")
+		if err := emit(t.Doc, noteUnexported(t.Decl)); err != nil {
+			return "", err
+		}
+		newlines(&buf, 2)
+		for _, v := range t.Consts {
+			if err := emit(v.Doc, v.Decl); err != nil {
+				return "", err
+			}
+		}
+		for _, v := range t.Vars {
+			if err := emit(v.Doc, v.Decl); err != nil {
+				return "", err
+			}
+		}
+		for _, f := range t.Funcs {
+			if err := emit(f.Doc, f.Decl); err != nil {
+				return "", err
+			}
+		}
+		for _, f := range t.Methods {
+			if err := emit(f.Doc, f.Decl); err != nil {
+				return "", err
+			}
+		}
+		newlines(&buf, 2)
+	}
+	// End with exactly one newline.
+	return string(bytes.TrimRight(buf.Bytes(), "\n")) + "\n", nil
+}
+
+// noteUnexported returns decl, or a copy of it in which each struct or
+// interface type that had unexported fields or methods removed ends with the
+// comment that "go doc" uses for them, instead of the one go/printer adds.
+// decl itself is not modified, because the HTML documentation is rendered
+// from it too.
+func noteUnexported(decl *ast.GenDecl) *ast.GenDecl {
+	var specs []ast.Spec
+	for i, spec := range decl.Specs {
+		ts, ok := spec.(*ast.TypeSpec)
+		if !ok {
+			continue
+		}
+		var (
+			fields *ast.FieldList
+			what   string
+		)
+		switch t := ts.Type.(type) {
+		case *ast.StructType:
+			if t.Incomplete {
+				fields, what = t.Fields, "fields"
+			}
+		case *ast.InterfaceType:
+			if t.Incomplete {
+				fields, what = t.Methods, "methods"
+			}
+		}
+		if fields == nil {
+			continue
+		}
+		noted := &ast.FieldList{
+			Opening: fields.Opening,
+			List: append(fields.List[:len(fields.List):len(fields.List)], &ast.Field{
+				// The printer treats this as a field with a named type. Its
+				// position, just before the closing brace, puts the comment at
+				// the end.
+				Type: &ast.Ident{NamePos: fields.Closing - 1},
+				Comment: &ast.CommentGroup{
+					List: []*ast.Comment{{Text: fmt.Sprintf("// Has unexported %s.\n", what)}},
+				},
+			}),
+			Closing: fields.Closing,
+		}
+		tsCopy := *ts
+		switch t := ts.Type.(type) {
+		case *ast.StructType:
+			tCopy := *t
+			tCopy.Fields = noted
+			tCopy.Incomplete = false
+			tsCopy.Type = &tCopy
+		case *ast.InterfaceType:
+			tCopy := *t
+			tCopy.Methods = noted
+			tCopy.Incomplete = false
+			tsCopy.Type = &tCopy
+		}
+		if specs == nil {
+			specs = append([]ast.Spec(nil), decl.Specs...)
+		}
+		specs[i] = &tsCopy
+	}
+	if specs == nil {
+		return decl
+	}
+	declCopy := *decl
+	declCopy.Specs = specs
+	return &declCopy
+}
+
+// newlines makes sure buf ends with at least n newlines, unless it is empty.
+func newlines(buf *bytes.Buffer, n int) {
+	b := buf.Bytes()
+	if len(b) == 0 {
+		return
+	}
+	have := len(b) - len(bytes.TrimRight(b, "\n"))
+	for ; have < n; have++ {
+		buf.WriteByte('\n')
+	}
+}
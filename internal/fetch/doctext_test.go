@@ -0,0 +1,131 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"go/ast"
+	"go/token"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/fetch/internal/doc"
+)
+
+func TestRenderDocText(t *testing.T) {
+	const src = `// Package p is a sample package for testing
+// the plain-text documentation.
+//
+// It has a second paragraph.
+package p
+
+import "errors"
+
+// Version is the version of p.
+const Version = "v1.0.0"
+
+// Errors returned by the package.
+var (
+	// ErrBad is returned for bad input.
+	ErrBad = errors.New("bad")
+	ErrWorse = errors.New("worse")
+)
+
+// Kind is a kind of thing.
+type Kind int
+
+// The kinds.
+const (
+	KindA Kind = iota
+	KindB
+)
+
+// Thing is a thing.
+type Thing struct {
+	// Name is the name of the thing.
+	Name string
+	size int
+}
+
+// NewThing returns a new Thing.
+func NewThing(name string) *Thing { return &Thing{Name: name} }
+
+// Size returns the size of t.
+func (t *Thing) Size() int { return t.size }
+
+func (t *Thing) String() string { return t.Name }
+
+// Do does something.
+func Do() error { return nil }
+`
+
+	// want is the output of "go doc -all" for src.
+	const want = `package p // import "example.com/p"
+
+Package p is a sample package for testing the plain-text documentation.
+
+It has a second paragraph.
+
+CONSTANTS
+
+const Version = "v1.0.0"
+    Version is the version of p.
+
+
+VARIABLES
+
+var (
+	// ErrBad is returned for bad input.
+	ErrBad   = errors.New("bad")
+	ErrWorse = errors.New("worse")
+)
+    Errors returned by the package.
+
+
+FUNCTIONS
+
+func Do() error
+    Do does something.
+
+
+TYPES
+
+type Kind int
+    Kind is a kind of thing.
+
+const (
+	KindA Kind = iota
+	KindB
+)
+    The kinds.
+
+type Thing struct {
+	// Name is the name of the thing.
+	Name string
+	// Has unexported fields.
+}
+    Thing is a thing.
+
+func NewThing(name string) *Thing
+    NewThing returns a new Thing.
+
+func (t *Thing) Size() int
+    Size returns the size of t.
+
+func (t *Thing) String() string
+`
+
+	fset := token.NewFileSet()
+	d, err := doc.NewFromFiles(fset, []*ast.File{mustParse(fset, "p.go", src)}, "example.com/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := renderDocText(fset, d, "example.com/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"runtime"
 	"runtime/debug"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -40,6 +41,7 @@ import (
 	"golang.org/x/pkgsite/internal/proxy"
 	"golang.org/x/pkgsite/internal/source"
 	"golang.org/x/pkgsite/internal/stdlib"
+	"golang.org/x/pkgsite/internal/sumdb"
 	"golang.org/x/pkgsite/internal/version"
 )
 
@@ -48,6 +50,38 @@ var (
 	errMalformedZip             = errors.New("module zip is malformed")
 )
 
+// checksumDB is the checksum database that FetchModule verifies modules
+// against. If it is nil, modules are not verified.
+var checksumDB *sumdb.Client
+
+// SetChecksumDB sets the checksum database that FetchModule verifies the
+// go.mod file and zip of each module from the proxy against, before
+// processing them. If they do not match, the module is not processed, and the
+// error of the FetchResult wraps derrors.ChecksumMismatch. If c is nil, which
+// is the default, modules are not verified.
+//
+// SetChecksumDB must be called before FetchModule is used.
+func SetChecksumDB(c *sumdb.Client) {
+	checksumDB = c
+}
+
+// licenseDetectOptions control the detection of the licenses of the modules
+// that FetchModule processes.
+var licenseDetectOptions = licenses.DefaultDetectOptions()
+
+// SetLicenseDetectOptions sets the options that FetchModule detects licenses
+// with, in place of licenses.DefaultDetectOptions. It returns an error if
+// opts is invalid.
+//
+// SetLicenseDetectOptions must be called before FetchModule is used.
+func SetLicenseDetectOptions(opts licenses.DetectOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	licenseDetectOptions = opts
+	return nil
+}
+
 type FetchResult struct {
 	ModulePath           string
 	RequestedVersion     string
@@ -124,6 +158,10 @@ func FetchModule(ctx context.Context, modulePath, requestedVersion string, proxy
 			fr.Error = err
 			return fr
 		}
+		if err := checksumDB.Verify(modulePath, fr.ResolvedVersion, goModBytes, zipReader); err != nil {
+			fr.Error = err
+			return fr
+		}
 	}
 	versionType, err := version.ParseType(fr.ResolvedVersion)
 	if err != nil {
@@ -166,7 +204,10 @@ func processZipFile(ctx context.Context, modulePath string, versionType version.
 	logf := func(format string, args ...interface{}) {
 		log.Infof(ctx, format, args...)
 	}
-	d := licenses.NewDetector(modulePath, resolvedVersion, zipReader, logf)
+	d, err := licenses.NewDetectorWithOptions(modulePath, resolvedVersion, zipReader, logf, licenseDetectOptions)
+	if err != nil {
+		return nil, nil, err
+	}
 	allLicenses := d.AllLicenses()
 	packages, packageVersionStates, err := extractPackagesFromZip(ctx, modulePath, resolvedVersion, zipReader, d, sourceInfo)
 	if errors.Is(err, errModuleContainsNoPackages) || errors.Is(err, errMalformedZip) {
@@ -176,6 +217,21 @@ func processZipFile(ctx context.Context, modulePath string, versionType version.
 		return nil, nil, fmt.Errorf("extractPackagesFromZip(%q, %q, zipReader, %v): %v", modulePath, resolvedVersion, allLicenses, err)
 	}
 	hasGoMod := zipContainsFilename(zipReader, path.Join(moduleVersionDir(modulePath, resolvedVersion), "go.mod"))
+	goMod, err := extractGoModFromZip(modulePath, resolvedVersion, zipReader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("extractGoModFromZip(%q, %q, zipReader): %v", modulePath, resolvedVersion, err)
+	}
+	vendored, err := extractVendoredModulesFromZip(modulePath, resolvedVersion, zipReader)
+	if err != nil {
+		// The vendored modules are informational, so a modules.txt file that
+		// cannot be read does not prevent the module from being processed.
+		log.Infof(ctx, "extractVendoredModulesFromZip(%q, %q, zipReader): %v", modulePath, resolvedVersion, err)
+		vendored = nil
+	}
+	changelog, err := extractChangelogFromZip(modulePath, resolvedVersion, zipReader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("extractChangelogFromZip(%q, %q, zipReader): %v", modulePath, resolvedVersion, err)
+	}
 
 	var readmeFilePath, readmeContents string
 	for _, r := range readmes {
@@ -195,14 +251,19 @@ func processZipFile(ctx context.Context, modulePath string, versionType version.
 				VersionType:       versionType,
 				IsRedistributable: d.ModuleIsRedistributable(),
 				HasGoMod:          hasGoMod,
+				LicenseExpression: d.ModuleLicenseExpression(),
 				SourceInfo:        sourceInfo,
 			},
 			LegacyReadmeFilePath: readmeFilePath,
 			LegacyReadmeContents: readmeContents,
 		},
-		LegacyPackages: packages,
-		Licenses:       allLicenses,
-		Directories:    moduleDirectories(modulePath, packages, readmes, d),
+		LegacyPackages:  packages,
+		Licenses:        allLicenses,
+		Directories:     moduleDirectories(modulePath, packages, readmes, d),
+		Files:           moduleFiles(modulePath, resolvedVersion, zipReader),
+		VendoredModules: vendored,
+		Changelog:       changelog,
+		GoMod:           goMod,
 	}, packageVersionStates, nil
 }
 
@@ -461,6 +522,45 @@ func isVendored(importPath string) bool {
 		strings.Contains(importPath, "/vendor/")
 }
 
+// moduleFiles returns the paths of the files in the module zip r, relative to
+// the module root, in sorted order.
+func moduleFiles(modulePath, resolvedVersion string, r *zip.Reader) []string {
+	prefix := moduleVersionDir(modulePath, resolvedVersion) + "/"
+	var files []string
+	for _, f := range r.File {
+		if strings.HasSuffix(f.Name, "/") || !strings.HasPrefix(f.Name, prefix) {
+			continue
+		}
+		files = append(files, strings.TrimPrefix(f.Name, prefix))
+	}
+	sort.Strings(files)
+	return files
+}
+
+// extractGoModFromZip returns the contents of the go.mod file at the root of
+// the module zip r, or nil if there is no such file.
+func extractGoModFromZip(modulePath, resolvedVersion string, r *zip.Reader) ([]byte, error) {
+	name := moduleVersionDir(modulePath, resolvedVersion) + "/go.mod"
+	for _, f := range r.File {
+		if f.Name != name {
+			continue
+		}
+		if f.UncompressedSize64 > MaxFileSize {
+			return nil, fmt.Errorf("file size %d exceeds max limit %d", f.UncompressedSize64, MaxFileSize)
+		}
+		c, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if c == nil {
+			// Distinguish an empty go.mod file from a missing one.
+			c = []byte{}
+		}
+		return c, nil
+	}
+	return nil, nil
+}
+
 // zipContainsFilename reports whether there is a file with the given name in the zip.
 func zipContainsFilename(r *zip.Reader, name string) bool {
 	for _, f := range r.File {
@@ -507,12 +607,82 @@ func loadPackage(ctx context.Context, zipGoFiles []*zip.File, innerPath, moduleP
 			return nil, err
 		}
 		if pkg != nil {
+			imports, contexts, ierr := buildContextImports(zipGoFiles)
+			if ierr != nil {
+				return nil, ierr
+			}
+			if len(imports) > internal.MaxImportsPerPackage {
+				return nil, fmt.Errorf("%d imports found package %q; exceeds limit %d for MaxImportsPerPackage", len(imports), pkg.Path, internal.MaxImportsPerPackage)
+			}
+			pkg.Imports = imports
+			pkg.ImportBuildContexts = contexts
 			return pkg, err
 		}
 	}
 	return nil, nil
 }
 
+// buildContextImports returns the union of the imports of the non-test .go
+// files in zipGoFiles over all the build contexts in goEnvs, sorted.
+// The package documentation is computed for a single build context, but
+// platform-specific files can import packages that the files of that context
+// do not, and those imports matter for the imports and imported-by pages.
+//
+// The returned map has an entry for each import that is not present in
+// every build context that matches at least one non-test file. Its value lists
+// those build contexts, as "GOOS/GOARCH", in the order of goEnvs.
+func buildContextImports(zipGoFiles []*zip.File) (imports []string, contexts map[string][]string, err error) {
+	defer derrors.Wrap(&err, "buildContextImports(zipGoFiles)")
+	var (
+		fset        = token.NewFileSet()
+		importEnvs  = map[string][]string{}
+		numContexts int
+	)
+	for _, env := range goEnvs {
+		files, err := matchingFiles(env.GOOS, env.GOARCH, zipGoFiles)
+		if err != nil {
+			return nil, nil, err
+		}
+		envImports := map[string]bool{}
+		matched := false
+		for name, b := range files {
+			if strings.HasSuffix(name, "_test.go") {
+				continue
+			}
+			matched = true
+			pf, err := parser.ParseFile(fset, name, b, parser.ImportsOnly)
+			if err != nil {
+				return nil, nil, &BadPackageError{Err: err}
+			}
+			for _, spec := range pf.Imports {
+				p, err := strconv.Unquote(spec.Path.Value)
+				if err != nil {
+					return nil, nil, &BadPackageError{Err: err}
+				}
+				envImports[p] = true
+			}
+		}
+		if !matched {
+			continue
+		}
+		numContexts++
+		for p := range envImports {
+			importEnvs[p] = append(importEnvs[p], env.GOOS+"/"+env.GOARCH)
+		}
+	}
+	for p, envs := range importEnvs {
+		imports = append(imports, p)
+		if len(envs) < numContexts {
+			if contexts == nil {
+				contexts = map[string][]string{}
+			}
+			contexts[p] = envs
+		}
+	}
+	sort.Strings(imports)
+	return imports, contexts, nil
+}
+
 // httpPost allows package fetch tests to stub out playground URL fetches.
 var httpPost = http.Post
 
@@ -613,8 +783,21 @@ func loadPackageWithBuildContext(ctx context.Context, goos, goarch string, zipGo
 	}
 
 	// Process package imports.
-	if len(d.Imports) > maxImportsPerPackage {
-		return nil, fmt.Errorf("%d imports found package %q; exceeds limit %d for maxImportsPerPackage", len(d.Imports), importPath, maxImportsPerPackage)
+	if len(d.Imports) > internal.MaxImportsPerPackage {
+		return nil, fmt.Errorf("%d imports found package %q; exceeds limit %d for MaxImportsPerPackage", len(d.Imports), importPath, internal.MaxImportsPerPackage)
+	}
+
+	// Render plain-text documentation.
+	clausePath := importPath
+	if modulePath == stdlib.ModulePath {
+		clausePath = innerPath
+	}
+	docText, err := renderDocText(fset, d, clausePath)
+	if err != nil {
+		return nil, fmt.Errorf("renderDocText: %v", err)
+	}
+	if len(docText) > MaxDocumentationHTML {
+		docText = docTextTooLargeReplacement
 	}
 
 	// Render documentation HTML.
@@ -684,6 +867,8 @@ func loadPackageWithBuildContext(ctx context.Context, goos, goarch string, zipGo
 		V1Path:            v1path,
 		Imports:           d.Imports,
 		DocumentationHTML: docHTML,
+		DocumentationText: docText,
+		Signatures:        signatures(d),
 		GOOS:              goos,
 		GOARCH:            goarch,
 	}, err
@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
@@ -16,6 +17,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -25,9 +27,11 @@ import (
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/experiment"
 	"golang.org/x/pkgsite/internal/fetch/internal/doc"
+	"golang.org/x/pkgsite/internal/licenses"
 	"golang.org/x/pkgsite/internal/proxy"
 	"golang.org/x/pkgsite/internal/source"
 	"golang.org/x/pkgsite/internal/stdlib"
+	"golang.org/x/pkgsite/internal/sumdb"
 	"golang.org/x/pkgsite/internal/testing/sample"
 	"golang.org/x/pkgsite/internal/testing/testhelper"
 )
@@ -99,8 +103,14 @@ func TestFetchModule(t *testing.T) {
 			sortFetchResult(fr)
 			sortFetchResult(got)
 			opts := []cmp.Option{
-				cmpopts.IgnoreFields(internal.LegacyPackage{}, "DocumentationHTML"),
+				// DocumentationText is tested by TestRenderDocText, Signatures
+				// by TestSignatures, and ImportBuildContexts by
+				// TestBuildContextImports.
+				cmpopts.IgnoreFields(internal.LegacyPackage{}, "DocumentationHTML", "DocumentationText", "Signatures", "ImportBuildContexts"),
 				cmpopts.IgnoreFields(internal.Documentation{}, "HTML"),
+				// Files is tested by TestModuleFiles, and GoMod by
+				// TestExtractGoModFromZip.
+				cmpopts.IgnoreFields(internal.Module{}, "Files", "GoMod"),
 				cmpopts.IgnoreFields(internal.PackageVersionState{}, "Error"),
 				cmp.AllowUnexported(source.Info{}),
 				cmpopts.EquateEmpty(),
@@ -151,6 +161,177 @@ func TestFetchModule_Errors(t *testing.T) {
 	}
 }
 
+func TestFetchModule_LicenseExpression(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	const modulePath = "github.com/dual/license"
+	proxyClient, teardownProxy := proxy.SetupTestProxy(t, []*proxy.TestModule{{
+		ModulePath: modulePath,
+		Files: map[string]string{
+			"p/p.go":      "// Package p is dual licensed.\npackage p\n",
+			"LICENSE-MIT": testhelper.MITLicense,
+			"LICENSE-BSD": testhelper.BSD0License,
+		},
+	}})
+	defer teardownProxy()
+
+	got := FetchModule(ctx, modulePath, "v1.0.0", proxyClient, source.NewClient(sourceTimeout))
+	if got.Error != nil {
+		t.Fatal(got.Error)
+	}
+	if want := "BSD-0-Clause OR MIT"; got.Module.LicenseExpression != want {
+		t.Errorf("got LicenseExpression %q, want %q", got.Module.LicenseExpression, want)
+	}
+}
+
+func TestFetchModule_PartialLicenseScan(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	const (
+		modulePath = "github.com/partial/scan"
+		scanSize   = 4096
+	)
+	// The MIT license is followed by a changelog that dilutes it.
+	header := testhelper.MITLicense + strings.Repeat("\n", scanSize-len(testhelper.MITLicense))
+	changelog := strings.Repeat("v1.2.3: Fix the frobnicator when the widget is not yet initialized.\n", 300)
+	proxyClient, teardownProxy := proxy.SetupTestProxy(t, []*proxy.TestModule{{
+		ModulePath: modulePath,
+		Files: map[string]string{
+			"p/p.go":  "// Package p has a long license file.\npackage p\n",
+			"LICENSE": header + changelog,
+		},
+	}})
+	defer teardownProxy()
+
+	fetchLicense := func() *licenses.License {
+		t.Helper()
+		got := FetchModule(ctx, modulePath, "v1.0.0", proxyClient, source.NewClient(sourceTimeout))
+		if got.Error != nil {
+			t.Fatal(got.Error)
+		}
+		if len(got.Module.Licenses) != 1 {
+			t.Fatalf("got %d licenses, want 1", len(got.Module.Licenses))
+		}
+		return got.Module.Licenses[0]
+	}
+
+	if lic := fetchLicense(); !cmp.Equal(lic.Types, []string{"UNKNOWN"}) || lic.PartialScan {
+		t.Errorf("default options: got types %v, PartialScan %t; want unknown, false", lic.Types, lic.PartialScan)
+	}
+
+	opts := licenses.DefaultDetectOptions()
+	opts.PartialScanSize = scanSize
+	if err := SetLicenseDetectOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	defer SetLicenseDetectOptions(licenses.DefaultDetectOptions())
+	if lic := fetchLicense(); !cmp.Equal(lic.Types, []string{"MIT"}) || !lic.PartialScan {
+		t.Errorf("partial scan: got types %v, PartialScan %t; want [MIT], true", lic.Types, lic.PartialScan)
+	}
+
+	opts.PartialScanSize = opts.MaxLicenseSize
+	if err := SetLicenseDetectOptions(opts); err == nil {
+		t.Error("SetLicenseDetectOptions with invalid options: got nil error")
+	}
+}
+
+func TestFetchModule_ExcludeLicenseDirs(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	const modulePath = "github.com/exclude/dirs"
+	proxyClient, teardownProxy := proxy.SetupTestProxy(t, []*proxy.TestModule{{
+		ModulePath: modulePath,
+		Files: map[string]string{
+			"p/p.go":                  "// Package p has test data.\npackage p\n",
+			"LICENSE":                 testhelper.MITLicense,
+			"p/testdata/LICENSE":      testhelper.BSD0License,
+			"examples/LICENSE":        testhelper.BSD0License,
+			"examples/hello/hello.go": "package main\n",
+		},
+	}})
+	defer teardownProxy()
+
+	opts := licenses.DefaultDetectOptions()
+	opts.ExcludeDirs = []string{"testdata", "examples/"}
+	if err := SetLicenseDetectOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	defer SetLicenseDetectOptions(licenses.DefaultDetectOptions())
+	got := FetchModule(ctx, modulePath, "v1.0.0", proxyClient, source.NewClient(sourceTimeout))
+	if got.Error != nil {
+		t.Fatal(got.Error)
+	}
+	var gotPaths []string
+	for _, l := range got.Module.Licenses {
+		gotPaths = append(gotPaths, l.FilePath)
+	}
+	if diff := cmp.Diff([]string{"LICENSE"}, gotPaths); diff != "" {
+		t.Errorf("license paths mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFetchModule_Checksum(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer SetChecksumDB(nil)
+
+	const version = "v1.0.0"
+	modulePath := moduleOnePackage.mod.ModulePath
+	proxyClient, teardownProxy := proxy.SetupTestProxy(t, []*proxy.TestModule{{
+		ModulePath: modulePath,
+		Files:      moduleOnePackage.mod.Files,
+	}})
+	defer teardownProxy()
+	sourceClient := source.NewClient(sourceTimeout)
+
+	goMod, err := proxyClient.GetMod(ctx, modulePath, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modHash, err := sumdb.HashGoMod(goMod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zipReader, err := proxyClient.GetZip(ctx, modulePath, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zipHash, err := sumdb.HashZip(zipReader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name    string
+		zipHash string
+		wantErr error
+	}{
+		{name: "match", zipHash: zipHash},
+		{name: "mismatch", zipHash: "h1:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=", wantErr: derrors.ChecksumMismatch},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c, teardown := sumdb.SetupTestClient(t, map[string]string{
+				modulePath + "@" + version: fmt.Sprintf("%[1]s %[2]s %[3]s\n%[1]s %[2]s/go.mod %[4]s\n", modulePath, version, test.zipHash, modHash),
+			}, "")
+			defer teardown()
+			SetChecksumDB(c)
+
+			got := FetchModule(ctx, modulePath, version, proxyClient, sourceClient)
+			if !errors.Is(got.Error, test.wantErr) || (test.wantErr == nil && got.Error != nil) {
+				t.Fatalf("FetchModule(ctx, %q, %q, proxyClient, sourceClient): %v; wantErr = %v", modulePath, version, got.Error, test.wantErr)
+			}
+			if test.wantErr != nil {
+				if want := derrors.ToHTTPStatus(test.wantErr); got.Status != want {
+					t.Errorf("got status %d, want %d", got.Status, want)
+				}
+				if got.Module != nil {
+					t.Errorf("got module %s@%s, want none", got.Module.ModulePath, got.Module.Version)
+				}
+			}
+		})
+	}
+}
+
 func TestExtractReadmesFromZip(t *testing.T) {
 	stdlib.UseTestData = true
 
@@ -240,6 +421,62 @@ func TestExtractReadmesFromZip(t *testing.T) {
 	}
 }
 
+func TestExtractGoModFromZip(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	const (
+		modulePath = "github.com/my/module"
+		goMod      = "module " + modulePath + "\n\ngo 1.18\n"
+	)
+	proxyClient, teardownProxy := proxy.SetupTestProxy(t, []*proxy.TestModule{{
+		ModulePath: modulePath,
+		Files: map[string]string{
+			"go.mod":     goMod,
+			"foo/go.mod": "module " + modulePath + "/foo",
+			"foo/foo.go": "package foo",
+		},
+	}})
+	defer teardownProxy()
+	reader, err := proxyClient.GetZip(ctx, modulePath, "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := extractGoModFromZip(modulePath, "v1.0.0", reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != goMod {
+		t.Errorf("got %q, want %q", got, goMod)
+	}
+}
+
+func TestModuleFiles(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	const modulePath = "github.com/my/module"
+	proxyClient, teardownProxy := proxy.SetupTestProxy(t, []*proxy.TestModule{{
+		ModulePath: modulePath,
+		Files: map[string]string{
+			"go.mod":     "module " + modulePath,
+			"README.md":  "README FILE FOR TESTING.",
+			"foo/foo.go": "package foo",
+			"foo/bar/x":  "x",
+		},
+	}})
+	defer teardownProxy()
+	reader, err := proxyClient.GetZip(ctx, modulePath, "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := moduleFiles(modulePath, "v1.0.0", reader)
+	want := []string{"README.md", "foo/bar/x", "foo/foo.go", "go.mod"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestIsReadme(t *testing.T) {
 	for _, test := range []struct {
 		name, file string
@@ -379,6 +616,68 @@ func TestMatchingFiles(t *testing.T) {
 	}
 }
 
+func TestBuildContextImports(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		contents     map[string]string
+		wantImports  []string
+		wantContexts map[string][]string
+	}{
+		{
+			name: "same imports everywhere",
+			contents: map[string]string{
+				"p/p.go":      "package p\n\nimport \"fmt\"",
+				"p/p_test.go": "package p\n\nimport \"testing\"",
+			},
+			wantImports: []string{"fmt"},
+		},
+		{
+			name: "platform-specific imports",
+			contents: map[string]string{
+				"p/p.go":         "package p\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)",
+				"p/p_windows.go": "package p\n\nimport \"golang.org/x/sys/windows\"",
+				"p/p_unix.go":    "// +build linux darwin\n\npackage p\n\nimport \"golang.org/x/sys/unix\"",
+				"p/p_wasm.go":    "package p\n\nimport \"syscall/js\"",
+			},
+			wantImports: []string{"fmt", "golang.org/x/sys/unix", "golang.org/x/sys/windows", "os", "syscall/js"},
+			wantContexts: map[string][]string{
+				"golang.org/x/sys/unix":    {"linux/amd64", "darwin/amd64", "linux/js"},
+				"golang.org/x/sys/windows": {"windows/amd64"},
+				"syscall/js":               {"js/wasm"},
+			},
+		},
+		{
+			name: "contexts without files are not counted",
+			contents: map[string]string{
+				"p/p.go":      "// +build js,wasm\n\npackage p\n\nimport \"syscall/js\"",
+				"p/p_test.go": "package p\n\nimport \"testing\"",
+			},
+			wantImports: []string{"syscall/js"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := testhelper.ZipContents(test.contents)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotImports, gotContexts, err := buildContextImports(r.File)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.wantImports, gotImports); diff != "" {
+				t.Errorf("imports mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.wantContexts, gotContexts); diff != "" {
+				t.Errorf("contexts mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func mustParse(fset *token.FileSet, filename, src string) *ast.File {
 	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
 	if err != nil {
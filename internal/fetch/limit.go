@@ -7,12 +7,15 @@ package fetch
 // Limits for discovery worker.
 const (
 	maxPackagesPerModule = 10000
-	maxImportsPerPackage = 1000
 
 	// MaxFileSize is the maximum filesize that is allowed for reading.
 	// The fetch process should fail if it encounters a file exceeding
 	// this limit.
 	MaxFileSize = 30 * megabyte
+
+	// maxChangelogSize is the maximum size of a changelog file whose contents
+	// are stored.
+	maxChangelogSize = megabyte
 )
 
 // MaxDocumentationHTML is a limit on the rendered documentation HTML size.
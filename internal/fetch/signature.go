@@ -0,0 +1,198 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/fetch/internal/doc"
+)
+
+// signatures returns the normalized signatures of the exported functions of
+// d and of the methods of its exported types, sorted and without duplicates.
+// See NormalizeSignature for the normal form. Unexported declarations are
+// skipped even if d includes them, as it does for the builtin package.
+func signatures(d *doc.Package) []string {
+	seen := map[string]bool{}
+	add := func(fs []*doc.Func) {
+		for _, f := range fs {
+			if ast.IsExported(f.Name) && f.Decl != nil {
+				seen[formatSignature(f.Decl.Type)] = true
+			}
+		}
+	}
+	add(d.Funcs)
+	for _, t := range d.Types {
+		if !ast.IsExported(t.Name) {
+			continue
+		}
+		add(t.Funcs)
+		add(t.Methods)
+	}
+	var sigs []string
+	for s := range seen {
+		sigs = append(sigs, s)
+	}
+	sort.Strings(sigs)
+	return sigs
+}
+
+// NormalizeSignature parses sig, a function type like
+// "func(ctx context.Context, n int) error", and returns it in the normal form
+// in which the signatures of packages are indexed: without parameter and
+// result names, package qualifiers or extra whitespace, as in
+// "func(Context, int) error".
+func NormalizeSignature(sig string) (_ string, err error) {
+	defer derrors.Wrap(&err, "NormalizeSignature(%q)", sig)
+	expr, err := parser.ParseExpr(sig)
+	if err != nil {
+		return "", fmt.Errorf("%v: %w", err, derrors.InvalidArgument)
+	}
+	ft, ok := expr.(*ast.FuncType)
+	if !ok {
+		return "", fmt.Errorf("not a function type: %w", derrors.InvalidArgument)
+	}
+	return formatSignature(ft), nil
+}
+
+// formatSignature returns the normalized form of ft.
+func formatSignature(ft *ast.FuncType) string {
+	var b strings.Builder
+	b.WriteString("func")
+	writeSignature(&b, ft)
+	return b.String()
+}
+
+// writeSignature writes the normalized parameters and results of ft to b.
+func writeSignature(b *strings.Builder, ft *ast.FuncType) {
+	b.WriteByte('(')
+	writeTypeList(b, ft.Params)
+	b.WriteByte(')')
+	switch n := ft.Results.NumFields(); {
+	case n == 1:
+		b.WriteByte(' ')
+		writeType(b, ft.Results.List[0].Type)
+	case n > 1:
+		b.WriteString(" (")
+		writeTypeList(b, ft.Results)
+		b.WriteByte(')')
+	}
+}
+
+// writeTypeList writes the types of the fields of fl to b, separated by
+// commas. A field that declares several names, like "a, b int", contributes
+// its type once per name.
+func writeTypeList(b *strings.Builder, fl *ast.FieldList) {
+	if fl == nil {
+		return
+	}
+	first := true
+	for _, f := range fl.List {
+		n := len(f.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			if !first {
+				b.WriteString(", ")
+			}
+			first = false
+			writeType(b, f.Type)
+		}
+	}
+}
+
+// writeType writes the normalized form of the type expression e to b.
+func writeType(b *strings.Builder, e ast.Expr) {
+	switch e := e.(type) {
+	case *ast.Ident:
+		b.WriteString(e.Name)
+	case *ast.SelectorExpr:
+		// Drop the package qualifier.
+		b.WriteString(e.Sel.Name)
+	case *ast.ParenExpr:
+		writeType(b, e.X)
+	case *ast.StarExpr:
+		b.WriteByte('*')
+		writeType(b, e.X)
+	case *ast.Ellipsis:
+		b.WriteString("...")
+		writeType(b, e.Elt)
+	case *ast.ArrayType:
+		b.WriteByte('[')
+		if e.Len != nil {
+			b.WriteString(types.ExprString(e.Len))
+		}
+		b.WriteByte(']')
+		writeType(b, e.Elt)
+	case *ast.MapType:
+		b.WriteString("map[")
+		writeType(b, e.Key)
+		b.WriteByte(']')
+		writeType(b, e.Value)
+	case *ast.ChanType:
+		switch e.Dir {
+		case ast.SEND:
+			b.WriteString("chan<- ")
+		case ast.RECV:
+			b.WriteString("<-chan ")
+		default:
+			b.WriteString("chan ")
+		}
+		writeType(b, e.Value)
+	case *ast.FuncType:
+		b.WriteString("func")
+		writeSignature(b, e)
+	case *ast.InterfaceType:
+		b.WriteString("interface{")
+		writeFields(b, e.Methods, func(f *ast.Field) {
+			if ft, ok := f.Type.(*ast.FuncType); ok {
+				writeSignature(b, ft)
+			} else {
+				// An embedded interface.
+				writeType(b, f.Type)
+			}
+		})
+		b.WriteByte('}')
+	case *ast.StructType:
+		b.WriteString("struct{")
+		writeFields(b, e.Fields, func(f *ast.Field) {
+			if len(f.Names) > 0 {
+				b.WriteByte(' ')
+			}
+			writeType(b, f.Type)
+		})
+		b.WriteByte('}')
+	default:
+		b.WriteString(types.ExprString(e))
+	}
+}
+
+// writeFields writes the fields of the struct or interface type fl to b,
+// separated by semicolons. Each field is written as its names, separated by
+// commas, followed by what writeField writes.
+func writeFields(b *strings.Builder, fl *ast.FieldList, writeField func(*ast.Field)) {
+	if fl == nil {
+		return
+	}
+	for i, f := range fl.List {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		for j, name := range f.Names {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(name.Name)
+		}
+		writeField(f)
+	}
+}
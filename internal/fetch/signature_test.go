@@ -0,0 +1,107 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"errors"
+	"go/ast"
+	"go/token"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/fetch/internal/doc"
+)
+
+func TestSignatures(t *testing.T) {
+	const src = `package p
+
+import (
+	"context"
+	"io"
+)
+
+type Client struct{}
+
+func NewClient(ctx context.Context, addr string) (*Client, error) { return nil, nil }
+
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) { return nil, nil }
+
+func (c *Client) Put(ctx context.Context, key, value string) error { return nil }
+
+func (c *Client) Close() error { return nil }
+
+func (c *Client) reset() {}
+
+func Copy(dst io.Writer, src io.Reader) (written int64, err error) { return 0, nil }
+
+func Walk(root string, fn func(path string, info interface{ Name() string }) error) error { return nil }
+
+func Watch(ctx context.Context) <-chan map[string][]*Client { return nil }
+
+func Printf(format string, args ...interface{}) {}
+
+func helper(ctx context.Context) error { return nil }
+
+type server struct{}
+
+func (server) Serve() error { return nil }
+`
+	want := []string{
+		"func() error",
+		"func(Context) <-chan map[string][]*Client",
+		"func(Context, string) (*Client, error)",
+		"func(Context, string) ([]byte, error)",
+		"func(Context, string, string) error",
+		"func(Writer, Reader) (int64, error)",
+		"func(string, ...interface{})",
+		"func(string, func(string, interface{Name() string}) error) error",
+	}
+
+	fset := token.NewFileSet()
+	d, err := doc.NewFromFiles(fset, []*ast.File{mustParse(fset, "p.go", src)}, "example.com/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, signatures(d)); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNormalizeSignature(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{"func()", "func()"},
+		{"func(ctx context.Context) error", "func(Context) error"},
+		{"func(context.Context) error", "func(Context) error"},
+		{"func(Context) error", "func(Context) error"},
+		{"func (  ctx  context.Context )   error", "func(Context) error"},
+		{"func(a, b int) (n int, err error)", "func(int, int) (int, error)"},
+		{"func(r *http.Request) (error)", "func(*Request) error"},
+		{"func(m map[string]*foo.Bar) []chan<- int", "func(map[string]*Bar) []chan<- int"},
+		{"func(f func(x io.Reader) error, opts ...Option)", "func(func(Reader) error, ...Option)"},
+		{"func(s struct{ A, B int }) [4]byte", "func(struct{A, B int}) [4]byte"},
+	} {
+		got, err := NormalizeSignature(test.in)
+		if err != nil {
+			t.Fatalf("NormalizeSignature(%q): %v", test.in, err)
+		}
+		if got != test.want {
+			t.Errorf("NormalizeSignature(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+
+	for _, in := range []string{
+		"",
+		"context.Context",
+		"func(",
+		"func() {}",
+	} {
+		if _, err := NormalizeSignature(in); !errors.Is(err, derrors.InvalidArgument) {
+			t.Errorf("NormalizeSignature(%q): %v, want InvalidArgument", in, err)
+		}
+	}
+}
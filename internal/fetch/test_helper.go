@@ -57,6 +57,9 @@ func cleanFetchResult(fr *FetchResult, detector *licenses.Detector) *FetchResult
 			d.IsRedistributable = isRedist
 		}
 	}
+	if fr.Module.LicenseExpression == "" {
+		fr.Module.LicenseExpression = detector.ModuleLicenseExpression()
+	}
 
 	shouldSetPVS := (fr.PackageVersionStates == nil)
 	for _, dir := range fr.Module.Directories {
@@ -113,7 +116,11 @@ func licenseDetector(ctx context.Context, t *testing.T, modulePath, version stri
 	logf := func(format string, args ...interface{}) {
 		log.Infof(ctx, format, args...)
 	}
-	return licenses.NewDetector(modulePath, version, zipReader, logf)
+	d, err := licenses.NewDetectorWithOptions(modulePath, version, zipReader, logf, licenseDetectOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
 }
 
 func sortFetchResult(fr *FetchResult) {
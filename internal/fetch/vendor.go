@@ -0,0 +1,69 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"archive/zip"
+	"fmt"
+	"strings"
+
+	"golang.org/x/pkgsite/internal"
+)
+
+// extractVendoredModulesFromZip returns the modules listed in the
+// vendor/modules.txt file at the root of the module zip r. It returns nil if
+// there is no such file.
+func extractVendoredModulesFromZip(modulePath, resolvedVersion string, r *zip.Reader) ([]*internal.VendoredModule, error) {
+	name := moduleVersionDir(modulePath, resolvedVersion) + "/vendor/modules.txt"
+	for _, zipFile := range r.File {
+		if zipFile.Name != name {
+			continue
+		}
+		if zipFile.UncompressedSize64 > MaxFileSize {
+			return nil, fmt.Errorf("file size %d exceeds max limit %d", zipFile.UncompressedSize64, MaxFileSize)
+		}
+		c, err := readZipFile(zipFile)
+		if err != nil {
+			return nil, err
+		}
+		return parseVendoredModules(string(c)), nil
+	}
+	return nil, nil
+}
+
+// parseVendoredModules returns the modules listed in contents, the contents
+// of a vendor/modules.txt file as written by "go mod vendor". Each module is
+// on a line of the form
+//
+//	# path [version] [=> replacement]
+//
+// Lines that start with "##" annotate the module before them, and other lines
+// list its packages; both are ignored, as are malformed module lines.
+func parseVendoredModules(contents string) []*internal.VendoredModule {
+	var mods []*internal.VendoredModule
+	for _, line := range strings.Split(contents, "\n") {
+		if !strings.HasPrefix(line, "# ") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "# "))
+		var replacement string
+		for i, f := range fields {
+			if f == "=>" {
+				replacement = strings.Join(fields[i+1:], " ")
+				fields = fields[:i]
+				break
+			}
+		}
+		if len(fields) == 0 || len(fields) > 2 {
+			continue
+		}
+		vm := &internal.VendoredModule{ModulePath: fields[0], Replacement: replacement}
+		if len(fields) == 2 {
+			vm.Version = fields[1]
+		}
+		mods = append(mods, vm)
+	}
+	return mods
+}
@@ -0,0 +1,129 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/proxy"
+	"golang.org/x/pkgsite/internal/source"
+)
+
+func TestExtractVendoredModulesFromZip(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, test := range []struct {
+		modulePath string
+		files      map[string]string
+		want       []*internal.VendoredModule
+	}{
+		{
+			modulePath: "github.com/my/vendoring",
+			files: map[string]string{
+				"go.mod": "module github.com/my/vendoring\n\nrequire golang.org/x/text v0.3.0\n",
+				"foo.go": "package foo\n\nimport _ \"golang.org/x/text/language\"\n",
+				"vendor/modules.txt": `# golang.org/x/text v0.3.0
+## explicit
+golang.org/x/text/language
+golang.org/x/text/internal/tag
+`,
+				"vendor/golang.org/x/text/language/language.go": "package language",
+				"vendor/golang.org/x/text/internal/tag/tag.go":  "package tag",
+			},
+			want: []*internal.VendoredModule{{ModulePath: "golang.org/x/text", Version: "v0.3.0"}},
+		},
+		{
+			// Only the vendor directory at the module root counts.
+			modulePath: "github.com/my/nested",
+			files: map[string]string{
+				"foo/vendor/modules.txt": "# golang.org/x/text v0.3.0\n",
+				"foo/foo.go":             "package foo",
+			},
+			want: nil,
+		},
+	} {
+		t.Run(test.modulePath, func(t *testing.T) {
+			proxyClient, teardownProxy := proxy.SetupTestProxy(t, []*proxy.TestModule{
+				{ModulePath: test.modulePath, Files: test.files}})
+			defer teardownProxy()
+			reader, err := proxyClient.GetZip(ctx, test.modulePath, "v1.0.0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := extractVendoredModulesFromZip(test.modulePath, "v1.0.0", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFetchModuleBadVendoredModules(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, test := range []struct {
+		name       string
+		modulesTxt string
+	}{
+		{"too large", "# golang.org/x/text v0.3.0\n" + strings.Repeat("golang.org/x/text/language\n", MaxFileSize/27+1)},
+		{"malformed", "\x00\xff\n# \n# a b c d\n#golang.org/x/text\n"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			const modulePath = "github.com/bad/vendoring"
+			proxyClient, teardownProxy := proxy.SetupTestProxy(t, []*proxy.TestModule{{
+				ModulePath: modulePath,
+				Files: map[string]string{
+					"foo/foo.go":         "// Package foo vendors its dependencies.\npackage foo\n",
+					"vendor/modules.txt": test.modulesTxt,
+				},
+			}})
+			defer teardownProxy()
+			got := FetchModule(ctx, modulePath, "v1.0.0", proxyClient, source.NewClient(sourceTimeout))
+			if got.Error != nil {
+				t.Fatal(got.Error)
+			}
+			if got.Module.VendoredModules != nil {
+				t.Errorf("got vendored modules %v, want none", got.Module.VendoredModules)
+			}
+			if len(got.Module.LegacyPackages) != 1 {
+				t.Errorf("got %d packages, want 1", len(got.Module.LegacyPackages))
+			}
+		})
+	}
+}
+
+func TestParseVendoredModules(t *testing.T) {
+	contents := `# example.com/a v1.0.0
+## explicit
+example.com/a
+example.com/a/b
+# example.com/b v1.1.0 => example.com/fork/b v1.1.1
+example.com/b
+# example.com/c v0.1.0 => ../c
+example.com/c
+# example.com/d => ./d
+## explicit
+# malformed line here
+`
+	want := []*internal.VendoredModule{
+		{ModulePath: "example.com/a", Version: "v1.0.0"},
+		{ModulePath: "example.com/b", Version: "v1.1.0", Replacement: "example.com/fork/b v1.1.1"},
+		{ModulePath: "example.com/c", Version: "v0.1.0", Replacement: "../c"},
+		{ModulePath: "example.com/d", Replacement: "./d"},
+	}
+	got := parseVendoredModules(contents)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// staticAssetCacheControl is the Cache-Control header for fingerprinted
+	// static assets. Their contents never change, because a change gives them
+	// a new name.
+	staticAssetCacheControl = "public, max-age=31536000, immutable"
+
+	// staleAssetCacheControl is the Cache-Control header for a request for a
+	// static file by a fingerprint that is not the file's current one, which
+	// is answered with the current contents. The response must not be cached
+	// for long, since the name may belong to other contents.
+	staleAssetCacheControl = "public, max-age=300"
+
+	// brandingCacheControl is the Cache-Control header for /favicon.ico and
+	// the configured logo, whose names cannot be fingerprinted.
+	brandingCacheControl = "public, max-age=86400"
+)
+
+// staticAssets maps the logical names of the files served under /static/,
+// like "css/stylesheet.css", to fingerprinted names that include a hash of
+// their contents, like "css/stylesheet.0123456789.css", so that browsers can
+// cache them indefinitely while a deploy that changes a file still gets the
+// new version to them.
+//
+// A nil *staticAssets fingerprints nothing.
+type staticAssets struct {
+	fingerprinted map[string]string // logical name to fingerprinted name
+	logical       map[string]string // fingerprinted name to logical name
+	hashes        map[string]string // logical name to content hash
+}
+
+// loadStaticAssets fingerprints the files in dir, the static directory,
+// except for templates.
+func loadStaticAssets(dir string) (*staticAssets, error) {
+	a := &staticAssets{
+		fingerprinted: map[string]string{},
+		logical:       map[string]string{},
+		hashes:        map[string]string{},
+	}
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if p == filepath.Join(dir, "html") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		contents, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(contents)
+		a.add(filepath.ToSlash(rel), hex.EncodeToString(sum[:])[:10])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// add records that the file with the given logical name has the given hash.
+func (a *staticAssets) add(name, hash string) {
+	ext := path.Ext(name)
+	fp := strings.TrimSuffix(name, ext) + "." + hash + ext
+	a.fingerprinted[name] = fp
+	a.logical[fp] = name
+	a.hashes[name] = hash
+}
+
+// url returns the URL path at which to reference the static file with the
+// given logical name. It is the "static" template function. Files that were
+// not fingerprinted are referenced by their logical name.
+func (a *staticAssets) url(name string) string {
+	if a != nil {
+		if fp, ok := a.fingerprinted[name]; ok {
+			return "/static/" + fp
+		}
+	}
+	return "/static/" + name
+}
+
+// handler returns a handler that serves the files in dir, the static
+// directory, for request paths from which the "/static/" prefix has been
+// removed. Fingerprinted names are served with the contents of the
+// corresponding file, and with headers that let them be cached indefinitely.
+//
+// During a deploy, a page rendered by an instance with different static files
+// can reference a fingerprint that this instance does not have. Such a name
+// is served with the current contents of the file, and with a short
+// Cache-Control. Other paths are served as by http.FileServer.
+func (a *staticAssets) handler(dir string) http.Handler {
+	fs := http.FileServer(http.Dir(dir))
+	if a == nil {
+		return fs
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := strings.TrimPrefix(r.URL.Path, "/")
+		name, ok := a.logical[p]
+		if ok {
+			w.Header().Set("Cache-Control", staticAssetCacheControl)
+			// http.FileServer honors If-None-Match using this ETag.
+			w.Header().Set("ETag", `"`+a.hashes[name]+`"`)
+		} else {
+			name, ok = stripFingerprint(p)
+			if _, known := a.fingerprinted[name]; !ok || !known {
+				fs.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Cache-Control", staleAssetCacheControl)
+		}
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = name
+		fs.ServeHTTP(w, r2)
+	})
+}
+
+// fingerprintRegexp matches the hash that add puts in a fingerprinted name.
+var fingerprintRegexp = regexp.MustCompile(`^\.[0-9a-f]{10}$`)
+
+// stripFingerprint returns the logical name for p, a name that has the form
+// of a fingerprinted name, or false if p does not have that form.
+func stripFingerprint(p string) (string, bool) {
+	ext := path.Ext(p)
+	base := strings.TrimSuffix(p, ext)
+	if fingerprintRegexp.MatchString(ext) {
+		// A name without an extension, like "LICENSE.0123456789".
+		return base, true
+	}
+	hash := path.Ext(base)
+	if !fingerprintRegexp.MatchString(hash) {
+		return "", false
+	}
+	return strings.TrimSuffix(base, hash) + ext, true
+}
@@ -0,0 +1,158 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestStaticAssets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "static")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, contents := range map[string]string{
+		"css/site.css":   "body {}",
+		"img/logo.svg":   "<svg></svg>",
+		"html/base.tmpl": "{{.}}",
+	} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a, err := loadStaticAssets(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cssURL := a.url("css/site.css")
+	if !regexp.MustCompile(`^/static/css/site\.[0-9a-f]{10}\.css$`).MatchString(cssURL) {
+		t.Errorf("url(css/site.css) = %q, want a fingerprinted name", cssURL)
+	}
+	if got, want := a.url("html/base.tmpl"), "/static/html/base.tmpl"; got != want {
+		t.Errorf("url(html/base.tmpl) = %q, want %q", got, want)
+	}
+	if got, want := a.url("js/missing.js"), "/static/js/missing.js"; got != want {
+		t.Errorf("url(js/missing.js) = %q, want %q", got, want)
+	}
+	var nilAssets *staticAssets
+	if got, want := nilAssets.url("css/site.css"), "/static/css/site.css"; got != want {
+		t.Errorf("nil url(css/site.css) = %q, want %q", got, want)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/static/", http.StripPrefix("/static/", a.handler(dir)))
+	serve := func(urlPath string, header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", urlPath, nil)
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(cssURL, nil)
+	if w.Code != http.StatusOK || w.Body.String() != "body {}" {
+		t.Fatalf("%s: got status %d, body %q; want 200 with the file's contents", cssURL, w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Cache-Control"); got != staticAssetCacheControl {
+		t.Errorf("%s: Cache-Control = %q, want %q", cssURL, got, staticAssetCacheControl)
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatalf("%s: no ETag", cssURL)
+	}
+	if w := serve(cssURL, http.Header{"If-None-Match": {etag}}); w.Code != http.StatusNotModified {
+		t.Errorf("%s with If-None-Match: got status %d, want %d", cssURL, w.Code, http.StatusNotModified)
+	}
+
+	w = serve("/static/css/site.css", nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("logical name: got status %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "" {
+		t.Errorf("logical name: Cache-Control = %q, want none", got)
+	}
+
+	// A fingerprint from another deploy gets the current contents, briefly
+	// cached.
+	w = serve("/static/css/site.0000000000.css", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "body {}" {
+		t.Errorf("stale fingerprint: got status %d, body %q; want 200 with the file's contents", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Cache-Control"); got != staleAssetCacheControl {
+		t.Errorf("stale fingerprint: Cache-Control = %q, want %q", got, staleAssetCacheControl)
+	}
+	if got := w.Header().Get("ETag"); got != "" {
+		t.Errorf("stale fingerprint: ETag = %q, want none", got)
+	}
+	for _, p := range []string{
+		"/static/css/missing.0000000000.css",
+		"/static/html/base.0000000000.tmpl",
+	} {
+		if w := serve(p, nil); w.Code != http.StatusNotFound {
+			t.Errorf("%s: got status %d, want %d", p, w.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestStripFingerprint(t *testing.T) {
+	for _, test := range []struct {
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{"css/site.0123456789.css", "css/site.css", true},
+		{"LICENSE.abcdef0123", "LICENSE", true},
+		{"js/a.b.0123456789.js", "js/a.b.js", true},
+		{"css/site.css", "", false},
+		{"css/site.012345678.css", "", false},
+		{"css/site.0123456789X.css", "", false},
+		{"css/site.ABCDEF0123.css", "", false},
+	} {
+		got, ok := stripFingerprint(test.in)
+		if got != test.want || ok != test.wantOK {
+			t.Errorf("stripFingerprint(%q) = %q, %t; want %q, %t", test.in, got, ok, test.want, test.wantOK)
+		}
+	}
+}
+
+func TestPagesUseFingerprintedAssets(t *testing.T) {
+	for _, test := range []struct {
+		devMode bool
+		want    string
+	}{
+		{false, `href="/static/css/stylesheet\.[0-9a-f]{10}\.css"`},
+		{true, `href="/static/css/stylesheet\.css"`},
+	} {
+		s, err := NewServer(ServerConfig{
+			StaticPath:     "../../content/static",
+			ThirdPartyPath: "../../third_party",
+			DevMode:        test.devMode,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		mux := http.NewServeMux()
+		s.Install(mux.Handle, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/search-help", nil))
+		if !regexp.MustCompile(test.want).MatchString(w.Body.String()) {
+			t.Errorf("devMode=%t: /search-help does not match %s", test.devMode, test.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// logoURLPath is the URL path at which a configured logo is served.
+const logoURLPath = "/logo"
+
+// A brandingImage is an image, like a favicon or a logo, that replaces one of
+// the Go images of the site. Its contents are read once, when the server
+// starts.
+type brandingImage struct {
+	// name is the base name of the file, whose extension determines the
+	// Content-Type that the image is served with.
+	name     string
+	modTime  time.Time
+	contents []byte
+}
+
+// readBrandingImage reads the image in the file at path.
+func readBrandingImage(path string) (*brandingImage, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &brandingImage{
+		name:     filepath.Base(path),
+		modTime:  info.ModTime(),
+		contents: contents,
+	}, nil
+}
+
+// serve serves the image. If the extension of its name has no known MIME
+// type, the Content-Type is detected from its contents.
+func (b *brandingImage) serve(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", brandingCacheControl)
+	http.ServeContent(w, r, b.name, b.modTime, bytes.NewReader(b.contents))
+}
+
+// handleFavicon serves /favicon.ico: the configured favicon, or else the one
+// in the static directory.
+func (s *Server) handleFavicon(w http.ResponseWriter, r *http.Request) {
+	if s.favicon != nil {
+		s.favicon.serve(w, r)
+		return
+	}
+	w.Header().Set("Cache-Control", brandingCacheControl)
+	http.ServeFile(w, r, filepath.Join(s.staticPath, "img", "favicon.ico"))
+}
+
+// handleLogo serves the configured logo at logoURLPath. Without one, the
+// pages show the Go logos in the static directory instead.
+func (s *Server) handleLogo(w http.ResponseWriter, r *http.Request) error {
+	if s.logo == nil {
+		return &serverError{status: http.StatusNotFound}
+	}
+	s.logo.serve(w, r)
+	return nil
+}
+
+// brandingLogoURL returns the URL path of logo, the configured logo, or the
+// empty string if it is nil.
+func brandingLogoURL(logo *brandingImage) string {
+	if logo == nil {
+		return ""
+	}
+	return logoURLPath
+}
@@ -0,0 +1,41 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"html/template"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// ChangelogDetails contains the changelog of a module.
+type ChangelogDetails struct {
+	ModulePath string
+
+	// Changelog is the rendered changelog, or empty if the module has none.
+	Changelog template.HTML
+
+	// ChangelogSource is the location of the changelog file, shown below it.
+	ChangelogSource string
+}
+
+// fetchChangelogDetails fetches the changelog of the module version mi from
+// the database and returns a ChangelogDetails. The changelog is rendered like
+// a README: as sanitized Markdown if it is a Markdown file, and as
+// preformatted text otherwise.
+func fetchChangelogDetails(ctx context.Context, db *postgres.DB, mi *internal.ModuleInfo) (*ChangelogDetails, error) {
+	cl, err := db.GetChangelog(ctx, mi.ModulePath, mi.Version)
+	if err != nil {
+		return nil, err
+	}
+	details := &ChangelogDetails{ModulePath: mi.ModulePath}
+	if cl != nil {
+		details.Changelog = readmeHTML(ctx, mi, &internal.Readme{Filepath: cl.Filepath, Contents: cl.Contents})
+		details.ChangelogSource = fileSource(mi.ModulePath, mi.Version, cl.Filepath)
+	}
+	return details, nil
+}
@@ -5,10 +5,8 @@
 package frontend
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"io"
 	"net/http"
 	"sort"
 	"strings"
@@ -40,13 +38,11 @@ func (s *Server) handleAutoCompletion(w http.ResponseWriter, r *http.Request) {
 		// array.
 		completions = []*complete.Completion{}
 	}
-	response, err := json.Marshal(completions)
-	if err != nil {
-		log.Errorf(ctx, "error marshalling completion: json.Marshal: %v", err)
-	}
+	// Encode directly to w rather than buffering the response. Once encoding
+	// has started the status has been sent, so an error can only be logged.
 	w.Header().Set("Content-Type", "application/json")
-	if _, err := io.Copy(w, bytes.NewReader(response)); err != nil {
-		log.Errorf(ctx, "Error copying json buffer to ResponseWriter: %v", err)
+	if err := json.NewEncoder(w).Encode(completions); err != nil {
+		log.Errorf(ctx, "error encoding completions: %v", err)
 	}
 }
 
@@ -5,10 +5,15 @@
 package frontend
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"golang.org/x/mod/module"
@@ -30,6 +35,7 @@ type DetailsPage struct {
 	Details        interface{}
 	Header         interface{}
 	Breadcrumb     breadcrumb
+	ImportPath     importPath
 	Tabs           []TabSettings
 
 	// PageType is either "mod", "dir", or "pkg" depending on the details
@@ -37,6 +43,153 @@ type DetailsPage struct {
 	PageType string
 }
 
+// detailsJSON is the JSON form of a DetailsPage, served to clients that prefer
+// application/json (see prefersJSON).
+type detailsJSON struct {
+	Title    string
+	PageType string
+	Tab      string
+	Header   interface{}
+	// Details is omitted if it cannot be shown, for example because the
+	// package is not redistributable.
+	Details interface{} `json:",omitempty"`
+}
+
+// serveDetailsPage serves page as HTML using the template for its tab, or as
+// JSON if the request prefers it. The details handlers build the page the same
+// way for both, so the two formats have the same content.
+func (s *Server) serveDetailsPage(ctx context.Context, w http.ResponseWriter, r *http.Request, page *DetailsPage) {
+	if isFragmentRequest(r) {
+		s.serveDetailsFragmentPage(ctx, w, page)
+		return
+	}
+	w.Header().Add("Vary", "Accept")
+	if !prefersJSON(r) {
+		s.servePage(ctx, w, page.Settings.TemplateName, page)
+		return
+	}
+	dj := detailsJSON{
+		Title:    page.Title,
+		PageType: page.PageType,
+		Tab:      page.Settings.Name,
+		Header:   page.Header,
+	}
+	if page.CanShowDetails {
+		dj.Details = page.Details
+	}
+	data, err := json.Marshal(dj)
+	if err != nil {
+		log.Errorf(ctx, "json.Marshal(%+v): %v", dj, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(data); err != nil {
+		log.Errorf(ctx, "Error writing JSON for %q: %v", r.URL.Path, err)
+	}
+}
+
+// fragmentPathPrefix is the prefix of the paths of details page fragments,
+// which hold just the content of one tab of a details page, for clients that
+// load tabs on demand. The rest of the path and the query are those of the
+// page: "/fragment/<module-path>?tab=imports" is the content of the imports
+// tab of "/<module-path>?tab=imports".
+const fragmentPathPrefix = "/fragment"
+
+// fragmentKey is the type of the context key that marks requests for details
+// page fragments.
+type fragmentKey struct{}
+
+// isFragmentRequest reports whether r is a request for a details page
+// fragment, as passed on by serveDetailsFragment.
+func isFragmentRequest(r *http.Request) bool {
+	v, _ := r.Context().Value(fragmentKey{}).(bool)
+	return v
+}
+
+// serveDetailsFragment handles requests for details page fragments (see
+// fragmentPathPrefix). It builds the page in the same way as serveDetails,
+// and serves only the content of its tab. Redirects stay within the fragment
+// paths.
+func (s *Server) serveDetailsFragment(w http.ResponseWriter, r *http.Request) error {
+	urlPath := strings.TrimPrefix(r.URL.Path, fragmentPathPrefix)
+	if urlPath == "" || urlPath == "/" {
+		return &serverError{status: http.StatusNotFound}
+	}
+	r = r.WithContext(context.WithValue(r.Context(), fragmentKey{}, true))
+	u := *r.URL
+	u.Path = urlPath
+	u.RawPath = ""
+	r.URL = &u
+	return s.serveDetails(w, r)
+}
+
+// serveDetailsFragmentPage serves the content of the tab of page, rendered
+// with the same templates as the full page.
+func (s *Server) serveDetailsFragmentPage(ctx context.Context, w http.ResponseWriter, page *DetailsPage) {
+	var buf bytes.Buffer
+	tmpl, err := s.findTemplate(page.Settings.TemplateName)
+	if err == nil {
+		err = tmpl.ExecuteTemplate(&buf, "details_tab", page)
+	}
+	if err != nil {
+		log.Errorf(ctx, "rendering fragment of %q: %v", page.Settings.TemplateName, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := io.Copy(w, &buf); err != nil {
+		log.Errorf(ctx, "Error copying fragment of %q to ResponseWriter: %v", page.Settings.TemplateName, err)
+	}
+}
+
+// prefersJSON reports whether the Accept header of r gives a higher quality
+// to application/json than to text/html. Each type gets the quality of the
+// most specific media range that matches it, so "application/json, */*;q=0.8"
+// prefers JSON, while a browser's "text/html, */*" or a bare "*/*" prefers
+// HTML. Ties go to HTML.
+func prefersJSON(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return false
+	}
+	// quality returns the quality of the media type typ/subtype, and -1 if no
+	// media range matches it.
+	quality := func(typ, subtype string) float64 {
+		q, specificity := -1.0, -1
+		for _, mr := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mr))
+			if err != nil {
+				continue
+			}
+			var spec int
+			switch mediaType {
+			case typ + "/" + subtype:
+				spec = 2
+			case typ + "/*":
+				spec = 1
+			case "*/*":
+				spec = 0
+			default:
+				continue
+			}
+			if spec <= specificity {
+				continue
+			}
+			mq := 1.0
+			if v, ok := params["q"]; ok {
+				if mq, err = strconv.ParseFloat(v, 64); err != nil {
+					continue
+				}
+			}
+			q, specificity = mq, spec
+		}
+		return q
+	}
+	qjson := quality("application", "json")
+	return qjson > 0 && qjson > quality("text", "html")
+}
+
 // serveDetails handles requests for package/directory/module details pages. It
 // expects paths of the form "[/mod]/<module-path>[@<version>?tab=<tab>]".
 // stdlib module pages are handled at "/std", and requests to "/mod/std" will
@@ -54,7 +207,7 @@ func (s *Server) serveDetails(w http.ResponseWriter, r *http.Request) (err error
 	}
 	if r.URL.Path == "/mod/std" {
 		// The stdlib module page is hosted at "/std".
-		http.Redirect(w, r, "/std", http.StatusMovedPermanently)
+		s.redirect(w, r, "/std", http.StatusMovedPermanently)
 		return nil
 	}
 
@@ -67,10 +220,24 @@ func (s *Server) serveDetails(w http.ResponseWriter, r *http.Request) (err error
 		urlPath = strings.TrimPrefix(r.URL.Path, "/mod")
 		isModule = true
 	}
+	if unescaped, ok := unescapeDetailsURLPath(urlPath); ok {
+		// The path uses the proxy's "!" encoding for uppercase letters, as in
+		// github.com/!sirupsen/logrus. Redirect to the decoded path, which is
+		// the one we display.
+		if isModule {
+			unescaped = "/mod" + unescaped
+		}
+		if r.URL.RawQuery != "" {
+			unescaped += "?" + r.URL.RawQuery
+		}
+		s.redirect(w, r, unescaped, http.StatusMovedPermanently)
+		return nil
+	}
 
 	// Parse the fullPath, modulePath and requestedVersion, based on whether
 	// the path is in the stdlib. If unable to parse these elements, return
-	// http.StatusBadRequest.
+	// http.StatusBadRequest, so that a malformed path is not reported as one
+	// that we don't have.
 	if parts := strings.SplitN(strings.TrimPrefix(urlPath, "/"), "@", 2); stdlib.Contains(parts[0]) {
 		fullPath, requestedVersion, err = parseStdLibURLPath(urlPath)
 		modulePath = stdlib.ModulePath
@@ -78,10 +245,7 @@ func (s *Server) serveDetails(w http.ResponseWriter, r *http.Request) (err error
 		fullPath, modulePath, requestedVersion, err = parseDetailsURLPath(urlPath)
 	}
 	if err != nil {
-		return &serverError{
-			status: http.StatusBadRequest,
-			err:    err,
-		}
+		return invalidPathError(strings.TrimPrefix(urlPath, "/"), err)
 	}
 
 	ctx := r.Context()
@@ -104,7 +268,7 @@ func (s *Server) serveDetails(w http.ResponseWriter, r *http.Request) (err error
 				log.Error(ctx, err)
 			}
 			if path != "" {
-				http.Redirect(w, r, path, http.StatusFound)
+				s.redirect(w, r, "/"+path, http.StatusFound)
 				return nil
 			}
 			pathType := "package"
@@ -114,7 +278,7 @@ func (s *Server) serveDetails(w http.ResponseWriter, r *http.Request) (err error
 			return pathNotFoundError(ctx, pathType, fullPath, requestedVersion)
 		}
 	}
-	if isActivePathAtMaster(ctx) && requestedVersion == internal.MasterVersion {
+	if isActivePathAtMaster(ctx) && requestedVersion == internal.MasterVersion && !s.readOnly {
 		// Since path@master is a moving target, we don't want it to be stale.
 		// As a result, we enqueue every request of path@master to the frontend
 		// task queue, which will initiate a fetch request depending on the
@@ -135,6 +299,37 @@ func (s *Server) serveDetails(w http.ResponseWriter, r *http.Request) (err error
 	return s.legacyServePackagePage(w, r, fullPath, modulePath, requestedVersion)
 }
 
+// unescapeDetailsURLPath decodes a details URL path whose path or version is
+// escaped as in the module proxy protocol, where each uppercase letter is
+// replaced by a '!' followed by the corresponding lowercase letter. It
+// returns the decoded URL path and true, or "" and false if urlPath contains
+// no '!' or is not validly escaped.
+func unescapeDetailsURLPath(urlPath string) (string, bool) {
+	if !strings.Contains(urlPath, "!") {
+		return "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(urlPath, "/"), "@", 2)
+	p, err := module.UnescapePath(parts[0])
+	if err != nil {
+		return "", false
+	}
+	unescaped := "/" + p
+	if len(parts) == 2 {
+		vParts := strings.SplitN(parts[1], "/", 2)
+		v, err := module.UnescapeVersion(vParts[0])
+		if err != nil {
+			return "", false
+		}
+		vParts[0] = v
+		unescaped += "@" + strings.Join(vParts, "/")
+	}
+	if strings.Contains(unescaped, "!") {
+		// A '!' after the version.
+		return "", false
+	}
+	return unescaped, true
+}
+
 // parseDetailsURLPath parses a URL path that refers (or may refer) to something
 // in the Go ecosystem.
 //
@@ -182,7 +377,7 @@ func parseDetailsURLPath(urlPath string) (fullPath, modulePath, version string,
 		version = endParts[0]
 		// You cannot explicitly write "latest" for the version.
 		if version == internal.LatestVersion {
-			return "", "", "", fmt.Errorf("invalid version: %q", version)
+			return "", "", "", fmt.Errorf("invalid version: %q: %w", version, derrors.InvalidArgument)
 		}
 		if suffix == "" {
 			// "@version" occurred at the end of the path; we don't know the module path.
@@ -198,7 +393,7 @@ func parseDetailsURLPath(urlPath string) (fullPath, modulePath, version string,
 	// The full path must be a valid import path (that is, package path), even if it denotes
 	// a module, directory or collection.
 	if err := module.CheckImportPath(fullPath); err != nil {
-		return "", "", "", fmt.Errorf("malformed path %q: %v", fullPath, err)
+		return "", "", "", fmt.Errorf("malformed path %q: %v: %w", fullPath, err, derrors.InvalidArgument)
 	}
 
 	// If the full path is (or could be) in the standard library, change the
@@ -206,13 +401,36 @@ func parseDetailsURLPath(urlPath string) (fullPath, modulePath, version string,
 	// like "net@go1.14/http". That says that the module is "net", and it isn't.
 	if stdlib.Contains(fullPath) {
 		if modulePath != internal.UnknownModulePath {
-			return "", "", "", fmt.Errorf("non-final version in standard library path %q", urlPath)
+			return "", "", "", fmt.Errorf("non-final version in standard library path %q: %w", urlPath, derrors.InvalidArgument)
 		}
 		modulePath = stdlib.ModulePath
+	} else if modulePath != internal.UnknownModulePath {
+		// A module path, unlike an import path, must begin with a domain name.
+		if err := module.CheckPath(modulePath); err != nil {
+			return "", "", "", fmt.Errorf("malformed module path %q: %v: %w", modulePath, err, derrors.InvalidArgument)
+		}
 	}
 	return fullPath, modulePath, version, nil
 }
 
+// invalidPathError returns a 400 error for urlPath, which could not be parsed
+// because of err.
+func invalidPathError(urlPath string, err error) error {
+	return &serverError{
+		status: http.StatusBadRequest,
+		err:    err,
+		epage: &errorPage{
+			messageTemplate: `
+				<h3 class="Error-message">{{.}} is not a valid path.</h3>
+				<p class="Error-message">
+				  Paths look like example.com/module/package, optionally followed by
+				  a version, as in example.com/module@v1.2.3/package.
+				</p>`,
+			MessageData: urlPath,
+		},
+	}
+}
+
 // validatePathAndVersion verifies that the requested path and version are
 // acceptable. The given path may be a module or package path.
 func validatePathAndVersion(ctx context.Context, ds internal.DataSource, fullPath, requestedVersion string) error {
@@ -351,7 +569,7 @@ func parseStdLibURLPath(urlPath string) (path, version string, err error) {
 	parts := strings.SplitN(urlPath, "@", 2)
 	path = strings.TrimSuffix(strings.TrimPrefix(parts[0], "/"), "/")
 	if err := module.CheckImportPath(path); err != nil {
-		return "", "", err
+		return "", "", fmt.Errorf("malformed path %q: %v: %w", path, err, derrors.InvalidArgument)
 	}
 
 	if len(parts) == 1 {
@@ -359,7 +577,7 @@ func parseStdLibURLPath(urlPath string) (path, version string, err error) {
 	}
 	version = stdlib.VersionForTag(parts[1])
 	if version == "" {
-		return "", "", fmt.Errorf("invalid Go tag for url: %q", urlPath)
+		return "", "", fmt.Errorf("invalid Go tag for url: %q: %w", urlPath, derrors.InvalidArgument)
 	}
 	return path, version, nil
 }
@@ -6,11 +6,14 @@ package frontend
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/stdlib"
 )
 
@@ -81,6 +84,16 @@ func TestParseDetailsURLPath(t *testing.T) {
 			url:     "/net@go1.14/http",
 			wantErr: true,
 		},
+		{
+			name:    "malformed path",
+			url:     "/github.com/hashicorp/vault/.api",
+			wantErr: true,
+		},
+		{
+			name:    "malformed module path",
+			url:     "/hashicorp/vault@v1.0.3/api",
+			wantErr: true,
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -93,6 +106,9 @@ func TestParseDetailsURLPath(t *testing.T) {
 			if (err != nil) != tc.wantErr {
 				t.Fatalf("parseDetailsURLPath(%q) error = (%v); want error %t)", u, err, tc.wantErr)
 			}
+			if err != nil && !errors.Is(err, derrors.InvalidArgument) {
+				t.Fatalf("parseDetailsURLPath(%q) error = %v; want an InvalidArgument error", u, err)
+			}
 			if !tc.wantErr && (tc.wantModulePath != gotModule || tc.wantVersion != gotVersion || tc.wantFullPath != gotPkg) {
 				t.Fatalf("parseDetailsURLPath(%q): %q, %q, %q, %v; want = %q, %q, %q, want err %t",
 					u, gotPkg, gotModule, gotVersion, err, tc.wantFullPath, tc.wantModulePath, tc.wantVersion, tc.wantErr)
@@ -101,6 +117,29 @@ func TestParseDetailsURLPath(t *testing.T) {
 	}
 }
 
+func TestUnescapeDetailsURLPath(t *testing.T) {
+	for _, test := range []struct {
+		urlPath string
+		want    string
+		wantOK  bool
+	}{
+		{"/github.com/sirupsen/logrus", "", false},
+		{"/github.com/!sirupsen/logrus", "/github.com/Sirupsen/logrus", true},
+		{"/github.com/!sirupsen/logrus/hooks", "/github.com/Sirupsen/logrus/hooks", true},
+		{"/github.com/!sirupsen/logrus@v1.0.6", "/github.com/Sirupsen/logrus@v1.0.6", true},
+		{"/github.com/!sirupsen/logrus@v1.0.6/hooks", "/github.com/Sirupsen/logrus@v1.0.6/hooks", true},
+		{"/github.com/!mixed/!case@v1.0.0-!r!c1", "/github.com/Mixed/Case@v1.0.0-RC1", true},
+		{"/github.com/!Sirupsen/logrus", "", false},
+		{"/github.com/!!sirupsen/logrus", "", false},
+		{"/github.com/!sirupsen/logrus@v1.0.6/!hooks", "", false},
+	} {
+		got, ok := unescapeDetailsURLPath(test.urlPath)
+		if got != test.want || ok != test.wantOK {
+			t.Errorf("unescapeDetailsURLPath(%q) = %q, %t; want %q, %t", test.urlPath, got, ok, test.want, test.wantOK)
+		}
+	}
+}
+
 func TestValidatePathAndVersion(t *testing.T) {
 	tests := []struct {
 		path, version string
@@ -129,3 +168,37 @@ func TestValidatePathAndVersion(t *testing.T) {
 type fakeDataSource struct {
 	internal.DataSource
 }
+
+func TestPrefersJSON(t *testing.T) {
+	for _, test := range []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"text/html", false},
+		{"application/json", true},
+		{"application/json; charset=utf-8", true},
+		{"application/*", true},
+		// Both have quality 1, and ties go to HTML.
+		{"application/json, */*", false},
+		{"application/json, text/javascript, */*; q=0.01", true},
+		{"application/json, text/html", false},
+		{"text/html, application/json;q=0.9", false},
+		{"text/html;q=0.5, application/json", true},
+		{"application/json;q=0", false},
+		{"application/json;q=0, */*", false},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", false},
+		{"application/json, */*;q=0.8", true},
+		{"text/*;q=0.5, application/json;q=0.6", true},
+		{"bad type, application/json", true},
+	} {
+		r := httptest.NewRequest("GET", "/github.com/a/b", nil)
+		if test.accept != "" {
+			r.Header.Set("Accept", test.accept)
+		}
+		if got := prefersJSON(r); got != test.want {
+			t.Errorf("prefersJSON with Accept %q = %t, want %t", test.accept, got, test.want)
+		}
+	}
+}
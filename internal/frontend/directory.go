@@ -64,12 +64,13 @@ func (s *Server) legacyServeDirectoryPage(ctx context.Context, w http.ResponseWr
 		Settings:       settings,
 		Header:         header,
 		Breadcrumb:     breadcrumbPath(dbDir.Path, dbDir.ModulePath, linkVersion(dbDir.Version, dbDir.ModulePath)),
+		ImportPath:     newImportPath(dbDir.Path, dbDir.ModulePath),
 		Details:        details,
 		CanShowDetails: true,
 		Tabs:           directoryTabSettings,
 		PageType:       "dir",
 	}
-	s.servePage(ctx, w, settings.TemplateName, page)
+	s.serveDetailsPage(ctx, w, r, page)
 	return nil
 }
 
@@ -0,0 +1,211 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/version"
+)
+
+const (
+	// defaultFeedEntries is the number of entries in a module feed if the
+	// request has no "limit" query parameter.
+	defaultFeedEntries = 25
+
+	// maxFeedEntries is the maximum number of entries in a module feed.
+	maxFeedEntries = 100
+)
+
+// majorVersionRegexp matches the values of the "major" query parameter of a
+// module feed.
+var majorVersionRegexp = regexp.MustCompile(`^v[0-9]+$`)
+
+// atomFeed is an Atom feed, as described in RFC 4287.
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID      string   `xml:"id"`
+	Title   string   `xml:"title"`
+	Updated string   `xml:"updated"`
+	Link    atomLink `xml:"link"`
+	Summary string   `xml:"summary"`
+}
+
+// handleModuleFeed serves an Atom feed of the versions of a module, newest
+// first. It handles paths of the form "/feed/<module-path>". The "major"
+// query parameter, like "v2", restricts the feed to versions with that major
+// version, including those of other modules in the series (see
+// feedVersions), and the "limit" query parameter sets the maximum number of
+// entries.
+func (s *Server) handleModuleFeed(w http.ResponseWriter, r *http.Request) error {
+	modulePath := strings.Trim(strings.TrimPrefix(r.URL.Path, "/feed/"), "/")
+	if modulePath == "" {
+		return &serverError{status: http.StatusBadRequest, err: fmt.Errorf("missing module path in %q", r.URL.Path)}
+	}
+	major := r.FormValue("major")
+	if major != "" && !majorVersionRegexp.MatchString(major) {
+		return &serverError{status: http.StatusBadRequest, err: fmt.Errorf("invalid major version %q", major)}
+	}
+	limit := defaultFeedEntries
+	if l := r.FormValue("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 {
+			return &serverError{status: http.StatusBadRequest, err: fmt.Errorf("invalid limit %q", l)}
+		}
+		if limit > maxFeedEntries {
+			limit = maxFeedEntries
+		}
+	}
+
+	ctx := r.Context()
+	if err := validatePathAndVersion(ctx, s.ds, modulePath, internal.LatestVersion); err != nil {
+		return err
+	}
+	versions, err := s.feedVersions(ctx, modulePath, major)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		return &serverError{status: http.StatusNotFound, err: fmt.Errorf("no versions of module %q", modulePath)}
+	}
+
+	feed := s.moduleFeed(r, modulePath, versions, major, limit)
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// feedVersions returns the versions for the feed of modulePath, sorted newest
+// first. As on the versions tab, they are the tagged versions, or the
+// pseudo-versions if there are no tagged versions.
+//
+// If major is not empty and the data source is the database, they are the
+// versions of the series of modulePath with that major version, so that the
+// feed for v2 of example.com/m has the versions of example.com/m/v2 as well as
+// the +incompatible versions of example.com/m. If the series has no such
+// versions, the versions of modulePath are returned, and the feed is empty.
+func (s *Server) feedVersions(ctx context.Context, modulePath, major string) (_ []*internal.ModuleInfo, err error) {
+	defer derrors.Wrap(&err, "feedVersions(ctx, %q, %q)", modulePath, major)
+
+	if db, ok := s.ds.(*postgres.DB); ok && major != "" {
+		series, err := db.GetSeries(ctx, internal.SeriesPathForModule(modulePath))
+		if err != nil && !errors.Is(err, derrors.NotFound) {
+			return nil, err
+		}
+		for _, sm := range series {
+			if sm.Major != major {
+				continue
+			}
+			var tagged, pseudo []*internal.ModuleInfo
+			for _, mi := range sm.Versions {
+				if mi.VersionType == version.TypePseudo {
+					pseudo = append(pseudo, mi)
+				} else {
+					tagged = append(tagged, mi)
+				}
+			}
+			if len(tagged) == 0 {
+				return pseudo, nil
+			}
+			return tagged, nil
+		}
+	}
+
+	versions, err := s.ds.GetTaggedVersionsForModule(ctx, modulePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return s.ds.GetPseudoVersionsForModule(ctx, modulePath)
+	}
+	return versions, nil
+}
+
+// moduleFeed returns the feed for the given versions of modulePath, which
+// are sorted newest first. It holds at most limit entries, for the versions
+// with the given major version, or for all versions if major is empty.
+func (s *Server) moduleFeed(r *http.Request, modulePath string, versions []*internal.ModuleInfo, major string, limit int) *atomFeed {
+	self := s.absoluteURL(r, "/feed/"+modulePath)
+	title := "Versions of " + modulePath
+	if major != "" {
+		self += "?major=" + major
+		title += " (" + major + ")"
+	}
+	feed := &atomFeed{
+		ID:     self,
+		Title:  title,
+		Author: atomAuthor{Name: modulePath},
+		Links: []atomLink{
+			{Rel: "self", Href: self},
+			{Rel: "alternate", Href: s.absoluteURL(r, constructModuleURL(modulePath, internal.LatestVersion))},
+		},
+	}
+	var updated time.Time
+	for _, mi := range versions {
+		if len(feed.Entries) == limit {
+			break
+		}
+		if major != "" && semver.Major(mi.Version) != major {
+			continue
+		}
+		if mi.CommitTime.After(updated) {
+			updated = mi.CommitTime
+		}
+		link := s.absoluteURL(r, constructModuleURL(mi.ModulePath, linkVersion(mi.Version, mi.ModulePath)))
+		display := displayVersion(mi.Version, mi.ModulePath)
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:      link,
+			Title:   mi.ModulePath + " " + display,
+			Updated: mi.CommitTime.UTC().Format(time.RFC3339),
+			Link:    atomLink{Href: link},
+			Summary: fmt.Sprintf("%s %s, committed %s.", mi.ModulePath, display, mi.CommitTime.UTC().Format("Jan 2, 2006")),
+		})
+	}
+	if updated.IsZero() {
+		// No version has the requested major version.
+		updated = versions[0].CommitTime
+	}
+	feed.Updated = updated.UTC().Format(time.RFC3339)
+	return feed
+}
@@ -0,0 +1,203 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+// feedDataSource serves the tagged versions of one module.
+type feedDataSource struct {
+	internal.DataSource // unimplemented methods panic
+
+	versions []*internal.ModuleInfo
+}
+
+func (ds *feedDataSource) GetTaggedVersionsForModule(_ context.Context, modulePath string) ([]*internal.ModuleInfo, error) {
+	if modulePath != "example.com/mod" {
+		return nil, nil
+	}
+	return ds.versions, nil
+}
+
+func (ds *feedDataSource) GetPseudoVersionsForModule(context.Context, string) ([]*internal.ModuleInfo, error) {
+	return nil, nil
+}
+
+func TestModuleFeed(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2020, 6, d, 12, 0, 0, 0, time.UTC) }
+	ds := &feedDataSource{versions: []*internal.ModuleInfo{
+		{ModulePath: "example.com/mod", Version: "v2.0.0+incompatible", CommitTime: day(4)},
+		{ModulePath: "example.com/mod", Version: "v1.1.0", CommitTime: day(3)},
+		{ModulePath: "example.com/mod", Version: "v1.0.0", CommitTime: day(2)},
+		{ModulePath: "example.com/mod", Version: "v0.1.0", CommitTime: day(1)},
+	}}
+	s := &Server{ds: ds}
+
+	for _, test := range []struct {
+		name        string
+		url         string
+		wantStatus  int
+		wantTitle   string
+		wantUpdated string
+		wantEntries []string // titles
+	}{
+		{
+			name:        "all versions",
+			url:         "/feed/example.com/mod",
+			wantStatus:  http.StatusOK,
+			wantTitle:   "Versions of example.com/mod",
+			wantUpdated: "2020-06-04T12:00:00Z",
+			wantEntries: []string{
+				"example.com/mod v2.0.0+incompatible",
+				"example.com/mod v1.1.0",
+				"example.com/mod v1.0.0",
+				"example.com/mod v0.1.0",
+			},
+		},
+		{
+			name:        "major version",
+			url:         "/feed/example.com/mod?major=v1",
+			wantStatus:  http.StatusOK,
+			wantTitle:   "Versions of example.com/mod (v1)",
+			wantUpdated: "2020-06-03T12:00:00Z",
+			wantEntries: []string{"example.com/mod v1.1.0", "example.com/mod v1.0.0"},
+		},
+		{
+			name:        "limit",
+			url:         "/feed/example.com/mod?limit=1",
+			wantStatus:  http.StatusOK,
+			wantTitle:   "Versions of example.com/mod",
+			wantUpdated: "2020-06-04T12:00:00Z",
+			wantEntries: []string{"example.com/mod v2.0.0+incompatible"},
+		},
+		{
+			name:        "no versions with major version",
+			url:         "/feed/example.com/mod?major=v3",
+			wantStatus:  http.StatusOK,
+			wantTitle:   "Versions of example.com/mod (v3)",
+			wantUpdated: "2020-06-04T12:00:00Z",
+		},
+		{name: "unknown module", url: "/feed/example.com/other", wantStatus: http.StatusNotFound},
+		{name: "no module", url: "/feed/", wantStatus: http.StatusBadRequest},
+		{name: "bad major", url: "/feed/example.com/mod?major=2", wantStatus: http.StatusBadRequest},
+		{name: "bad limit", url: "/feed/example.com/mod?limit=0", wantStatus: http.StatusBadRequest},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "https://pkg.example"+test.url, nil)
+			if err := s.handleModuleFeed(w, r); err != nil {
+				var serr *serverError
+				if !errors.As(err, &serr) || serr.status != test.wantStatus {
+					t.Fatalf("got error %v, want status %d", err, test.wantStatus)
+				}
+				return
+			}
+			if w.Code != test.wantStatus {
+				t.Fatalf("got status %d, want %d", w.Code, test.wantStatus)
+			}
+			if got, want := w.Header().Get("Content-Type"), "application/atom+xml; charset=utf-8"; got != want {
+				t.Errorf("Content-Type = %q, want %q", got, want)
+			}
+			var feed atomFeed
+			if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+				t.Fatal(err)
+			}
+			if feed.Title != test.wantTitle || feed.Updated != test.wantUpdated {
+				t.Errorf("got title %q, updated %q; want %q, %q", feed.Title, feed.Updated, test.wantTitle, test.wantUpdated)
+			}
+			var titles []string
+			for _, e := range feed.Entries {
+				titles = append(titles, e.Title)
+			}
+			if diff := cmp.Diff(test.wantEntries, titles); diff != "" {
+				t.Errorf("entries mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModuleFeedEntry(t *testing.T) {
+	s := &Server{}
+	r := httptest.NewRequest("GET", "http://pkg.example/feed/example.com/mod", nil)
+	commitTime := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	feed := s.moduleFeed(r, "example.com/mod", []*internal.ModuleInfo{
+		{ModulePath: "example.com/mod", Version: "v1.0.0", CommitTime: commitTime},
+	}, "", defaultFeedEntries)
+	want := []atomEntry{{
+		ID:      "http://pkg.example/mod/example.com/mod@v1.0.0",
+		Title:   "example.com/mod v1.0.0",
+		Updated: "2020-06-01T12:00:00Z",
+		Link:    atomLink{Href: "http://pkg.example/mod/example.com/mod@v1.0.0"},
+		Summary: "example.com/mod v1.0.0, committed Jun 1, 2020.",
+	}}
+	if diff := cmp.Diff(want, feed.Entries); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	wantLinks := []atomLink{
+		{Rel: "self", Href: "http://pkg.example/feed/example.com/mod"},
+		{Rel: "alternate", Href: "http://pkg.example/mod/example.com/mod"},
+	}
+	if diff := cmp.Diff(wantLinks, feed.Links); diff != "" {
+		t.Errorf("links mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModuleFeedSeries(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	for _, m := range []*internal.Module{
+		sample.Module("example.com/mod", "v1.0.0", "pkg"),
+		sample.Module("example.com/mod/v2", "v2.0.0", "pkg"),
+		sample.Module("example.com/mod/v2", "v2.1.0", "pkg"),
+	} {
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &Server{ds: testDB}
+
+	for _, test := range []struct {
+		url         string
+		wantEntries []string // titles
+	}{
+		{"/feed/example.com/mod?major=v2", []string{"example.com/mod/v2 v2.1.0", "example.com/mod/v2 v2.0.0"}},
+		{"/feed/example.com/mod/v2?major=v2", []string{"example.com/mod/v2 v2.1.0", "example.com/mod/v2 v2.0.0"}},
+		{"/feed/example.com/mod/v2?major=v1", []string{"example.com/mod v1.0.0"}},
+		{"/feed/example.com/mod?major=v3", nil},
+	} {
+		t.Run(test.url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "https://pkg.example"+test.url, nil)
+			if err := s.handleModuleFeed(w, r); err != nil {
+				t.Fatal(err)
+			}
+			var feed atomFeed
+			if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+				t.Fatal(err)
+			}
+			var titles []string
+			for _, e := range feed.Entries {
+				titles = append(titles, e.Title)
+			}
+			if diff := cmp.Diff(test.wantEntries, titles); diff != "" {
+				t.Errorf("entries mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -32,9 +32,14 @@ import (
 
 var (
 	// errModuleDoesNotExist indicates that we have attempted to fetch the
-	// module, and the proxy returned a status 404/410. There is a row for
+	// module, and the proxy returned a status 404. There is a row for
 	// this module version in version_map.
 	errModuleDoesNotExist = errors.New("module does not exist")
+	// errModuleRemoved indicates that we have attempted to fetch the
+	// module, and the proxy returned a status 410, meaning that it does not
+	// serve the module version. There is a row for this module version in
+	// version_map.
+	errModuleRemoved = errors.New("module was removed")
 	// errPathDoesNotExistInModule indicates that a module for the path prefix
 	// exists, but within that module version, this fullPath could not be found.
 	errPathDoesNotExistInModule = errors.New("path does not exist in module")
@@ -176,7 +181,7 @@ func (s *Server) fetchAndPoll(parentCtx context.Context, modulePath, fullPath, r
 		return http.StatusRequestTimeout, statusToResponseText[http.StatusRequestTimeout]
 	}
 
-	var moduleMatchingPathPrefix string
+	var moduleMatchingPathPrefix, removedModule string
 	for _, fr := range results {
 		// Results are in order of longest module path first. Once an
 		// appropriate result is found, return. Otherwise, look at the next path.
@@ -195,6 +200,9 @@ func (s *Server) fetchAndPoll(parentCtx context.Context, modulePath, fullPath, r
 			// does in older versions of github.com/hashicorp/vault.
 			moduleMatchingPathPrefix = fr.modulePath
 		}
+		if errors.Is(fr.err, errModuleRemoved) && removedModule == "" {
+			removedModule = fr.modulePath
+		}
 	}
 	if moduleMatchingPathPrefix != "" {
 		return http.StatusNotFound,
@@ -206,6 +214,9 @@ func (s *Server) fetchAndPoll(parentCtx context.Context, modulePath, fullPath, r
 	if requestedVersion != internal.LatestVersion {
 		p = fullPath + "@" + requestedVersion
 	}
+	if removedModule != "" {
+		return http.StatusGone, fmt.Sprintf("%q could not be found, because module %q is not available from the module proxy.", p, removedModule)
+	}
 	return http.StatusNotFound, fmt.Sprintf("%q could not be found.", p)
 }
 
@@ -315,9 +326,13 @@ func checkForPath(ctx context.Context, db *postgres.DB, fullPath, modulePath, re
 	}
 	switch fr.status {
 	case http.StatusNotFound:
-		// The version_map indicates that the proxy returned a 404/410.
+		// The version_map indicates that the proxy returned a 404.
 		fr.err = errModuleDoesNotExist
 		return fr
+	case http.StatusGone:
+		// The version_map indicates that the proxy returned a 410.
+		fr.err = errModuleRemoved
+		return fr
 	case derrors.ToHTTPStatus(derrors.AlternativeModule):
 		// The row indicates that the provided module path did not match the
 		// module path returned by a request to
@@ -445,6 +460,11 @@ func FetchAndUpdateState(ctx context.Context, modulePath, requestedVersion strin
 			return http.StatusInternalServerError, err
 		}
 		log.Infof(ctx, "FetchAndUpdateState(%q, %q): db.InsertModule succeeded", modulePath, requestedVersion)
+		if fr.Status == http.StatusOK && !db.LicensesAllowed(fr.Module) {
+			// The module was inserted, but is not searchable. Other statuses,
+			// such as derrors.HasIncompletePackages, are kept.
+			fr.Status = derrors.ToHTTPStatus(derrors.LicenseNotAllowed)
+		}
 	}
 	var errMsg string
 	if fr.Error != nil {
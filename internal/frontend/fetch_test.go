@@ -7,13 +7,16 @@ package frontend
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"testing"
 	"time"
 
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/config"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/middleware"
 	"golang.org/x/pkgsite/internal/proxy"
 	"golang.org/x/pkgsite/internal/testing/sample"
 	"golang.org/x/pkgsite/internal/testing/testhelper"
@@ -151,6 +154,7 @@ func TestFetchPathAlreadyExists(t *testing.T) {
 	for _, status := range []int{
 		http.StatusOK,
 		http.StatusNotFound,
+		http.StatusGone,
 		derrors.ToHTTPStatus(derrors.AlternativeModule),
 	} {
 		t.Run(strconv.Itoa(status), func(t *testing.T) {
@@ -181,3 +185,43 @@ func TestFetchPathAlreadyExists(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchQuota(t *testing.T) {
+	ctx := experiment.NewContext(context.Background(), experiment.NewSet(map[string]bool{
+		internal.ExperimentFrontendFetch: true,
+	}))
+	f := false
+	// The requests come from httptest's default remote address, which is
+	// trusted to set X-Forwarded-For.
+	tp, err := middleware.ParseTrustedProxies([]string{"192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		fetchQuota: middleware.Quota(config.QuotaSettings{QPS: 1, Burst: 1, MaxEntries: 10, RecordOnly: &f}, tp),
+	}
+	mux := http.NewServeMux()
+	s.Install(mux.Handle, nil)
+
+	fetch := func(ip string) int {
+		r := httptest.NewRequest("GET", "/fetch/"+testModulePath+"@master", nil).WithContext(ctx)
+		r.Header.Set("X-Forwarded-For", ip)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w.Code
+	}
+	// The server has no database, so requests that get past the quota are
+	// forbidden.
+	for _, test := range []struct {
+		ip   string
+		want int
+	}{
+		{"1.2.3.4", http.StatusForbidden},
+		{"1.2.3.4", http.StatusTooManyRequests},
+		{"5.6.7.8", http.StatusForbidden},
+	} {
+		if got := fetch(test.ip); got != test.want {
+			t.Errorf("fetch from %s: got status %d, want %d", test.ip, got, test.want)
+		}
+	}
+}
@@ -25,6 +25,10 @@ type Package struct {
 	PathAfterDirectory string // for display only; used only for directory
 	Synopsis           string
 	IsRedistributable  bool
+	Kind               internal.PackageKind
+	InstallCommand     string // go get or go install command; empty if none applies
+	IsInternal         bool
+	InternalRoot       string // for internal packages, the root of the tree that may import it
 	URL                string // relative to this site
 	LatestURL          string // link with latest-version placeholder, relative to this site
 	Licenses           []LicenseMetadata
@@ -36,10 +40,13 @@ type Module struct {
 	LinkVersion       string
 	ModulePath        string
 	CommitTime        string
+	CommitTimestamp   time.Time // for absoluteTime and rfc3339Time in templates
 	IsRedistributable bool
 	URL               string // relative to this site
 	LatestURL         string // link with latest-version placeholder, relative to this site
 	Licenses          []LicenseMetadata
+	MinGoVersion      string // from the go directive of the go.mod file; empty if none
+	IsUnstable        bool   // whether the version is a v0 version
 }
 
 // legacyCreatePackage returns a *Package based on the fields of the specified
@@ -67,10 +74,15 @@ func legacyCreatePackage(pkg *internal.LegacyPackage, mi *internal.ModuleInfo, l
 	if latestRequested {
 		urlVersion = internal.LatestVersion
 	}
+	internalRoot, isInternal := internal.InternalRoot(pkg.Path)
 	return &Package{
 		Path:              pkg.Path,
 		Synopsis:          pkg.Synopsis,
 		IsRedistributable: pkg.IsRedistributable,
+		Kind:              pkg.Kind(),
+		InstallCommand:    installCommand(pkg.Path, mi.ModulePath, mi.Version, pkg.Kind(), latestRequested),
+		IsInternal:        isInternal,
+		InternalRoot:      internalRoot,
 		Licenses:          transformLicenseMetadata(pkg.Licenses),
 		Module:            *m,
 		URL:               constructPackageURL(pkg.Path, mi.ModulePath, urlVersion),
@@ -103,10 +115,15 @@ func createPackageNew(vdir *internal.VersionedDirectory, latestRequested bool) (
 	if latestRequested {
 		urlVersion = internal.LatestVersion
 	}
+	internalRoot, isInternal := internal.InternalRoot(vdir.Path)
 	return &Package{
 		Path:              vdir.Path,
 		Synopsis:          vdir.Package.Documentation.Synopsis,
 		IsRedistributable: vdir.DirectoryNew.IsRedistributable,
+		Kind:              vdir.Package.Kind(),
+		InstallCommand:    installCommand(vdir.Path, vdir.ModulePath, vdir.Version, vdir.Package.Kind(), latestRequested),
+		IsInternal:        isInternal,
+		InternalRoot:      internalRoot,
 		Licenses:          transformLicenseMetadata(vdir.Licenses),
 		Module:            *m,
 		URL:               constructPackageURL(vdir.Path, vdir.ModulePath, urlVersion),
@@ -114,6 +131,35 @@ func createPackageNew(vdir *internal.VersionedDirectory, latestRequested bool) (
 	}, nil
 }
 
+// installCommand returns the go command that installs the package with the
+// given path, if it is a command, or that adds its module as a dependency
+// otherwise. The version is omitted if latestRequested is true, so that the go
+// command resolves the latest version itself.
+//
+// Standard library packages cannot be fetched with the go command, so it
+// returns the empty string for them, except for commands, which are installed
+// from the local Go distribution. It also returns the empty string for
+// internal libraries, since no other module can import them.
+func installCommand(pkgPath, modulePath, version string, kind internal.PackageKind, latestRequested bool) string {
+	if kind == internal.PackageKindLibrary && internal.IsInternalPath(pkgPath) {
+		return ""
+	}
+	if modulePath == stdlib.ModulePath {
+		if kind == internal.PackageKindCommand {
+			return "go install " + pkgPath
+		}
+		return ""
+	}
+	suffix := ""
+	if !latestRequested {
+		suffix = "@" + version
+	}
+	if kind == internal.PackageKindCommand {
+		return "go install " + pkgPath + suffix
+	}
+	return "go get " + modulePath + suffix
+}
+
 // createModule returns a *Module based on the fields of the specified
 // versionInfo.
 //
@@ -130,10 +176,13 @@ func createModule(mi *internal.ModuleInfo, licmetas []*licenses.Metadata, latest
 		LinkVersion:       linkVersion(mi.Version, mi.ModulePath),
 		ModulePath:        mi.ModulePath,
 		CommitTime:        elapsedTime(mi.CommitTime),
+		CommitTimestamp:   mi.CommitTime,
 		IsRedistributable: mi.IsRedistributable,
 		Licenses:          transformLicenseMetadata(licmetas),
 		URL:               constructModuleURL(mi.ModulePath, urlVersion),
 		LatestURL:         constructModuleURL(mi.ModulePath, middleware.LatestVersionPlaceholder),
+		MinGoVersion:      mi.MinGoVersion,
+		IsUnstable:        mi.IsUnstable(),
 	}
 }
 
@@ -286,6 +335,39 @@ func breadcrumbPath(pkgPath, modPath, version string) breadcrumb {
 	return b
 }
 
+// importPath is the data for the "import_path" template, which displays the
+// path to import on a details page, with a control to copy it to the
+// clipboard.
+type importPath struct {
+	// Path is the import path of the package or directory, or the module path
+	// on a module page. It is empty for the standard library module, which
+	// cannot be imported.
+	Path string
+	// ModulePath is the canonical path of the module containing Path,
+	// including any major-version suffix.
+	ModulePath string
+}
+
+// newImportPath returns the importPath for pkgPath, which may be a package
+// path, a directory path or modPath itself. If pkgPath is in the module's
+// series but lacks modPath's major-version suffix, as a v1 path would, the
+// suffix is added, so that the result is the path to import at the module's
+// version.
+//
+// See TestNewImportPath for examples.
+func newImportPath(pkgPath, modPath string) importPath {
+	if pkgPath == stdlib.ModulePath {
+		return importPath{ModulePath: modPath}
+	}
+	if modPath != stdlib.ModulePath && pkgPath != modPath && !strings.HasPrefix(pkgPath, modPath+"/") {
+		series := internal.SeriesPathForModule(modPath)
+		if pkgPath == series || strings.HasPrefix(pkgPath, series+"/") {
+			pkgPath = modPath + strings.TrimPrefix(pkgPath, series)
+		}
+	}
+	return importPath{Path: pkgPath, ModulePath: modPath}
+}
+
 // moduleHTMLTitle constructs the <title> contents, for tabs in the browser.
 func moduleHTMLTitle(modulePath string) string {
 	if modulePath == stdlib.ModulePath {
@@ -328,3 +410,16 @@ func elapsedTime(date time.Time) string {
 
 	return date.Format("Jan _2, 2006")
 }
+
+// absoluteTime formats t as an exact date and time in UTC. Pages show it in
+// a <time> element with the class js-relativeTime, whose text is replaced by
+// a description of t relative to the current time in the browser. The pages
+// are cached, so a relative time computed on the server would go stale.
+func absoluteTime(t time.Time) string {
+	return t.UTC().Format("Jan _2, 2006, 15:04 UTC")
+}
+
+// rfc3339Time formats t for the datetime attribute of a <time> element.
+func rfc3339Time(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
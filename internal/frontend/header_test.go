@@ -20,11 +20,13 @@ func samplePackage(mutators ...func(*Package)) *Package {
 		Path:              sample.PackagePath,
 		Synopsis:          sample.Synopsis,
 		IsRedistributable: true,
+		Kind:              internal.PackageKindLibrary,
 		Licenses:          transformLicenseMetadata(sample.LicenseMetadata),
 		Module: Module{
 			DisplayVersion:    sample.VersionString,
 			LinkVersion:       sample.VersionString,
 			CommitTime:        "0 hours ago",
+			CommitTimestamp:   sample.CommitTime,
 			ModulePath:        sample.ModulePath,
 			IsRedistributable: true,
 			Licenses:          transformLicenseMetadata(sample.LicenseMetadata),
@@ -33,6 +35,7 @@ func samplePackage(mutators ...func(*Package)) *Package {
 	for _, mut := range mutators {
 		mut(p)
 	}
+	p.InstallCommand = installCommand(p.Path, p.ModulePath, p.LinkVersion, p.Kind, false)
 	p.URL = constructPackageURL(p.Path, p.ModulePath, p.LinkVersion)
 	p.Module.URL = constructModuleURL(p.ModulePath, p.LinkVersion)
 	p.LatestURL = constructPackageURL(p.Path, p.ModulePath, middleware.LatestVersionPlaceholder)
@@ -90,6 +93,16 @@ func TestElapsedTime(t *testing.T) {
 	}
 }
 
+func TestTimeFormats(t *testing.T) {
+	tm := time.Date(2020, time.June, 5, 16, 4, 5, 0, time.FixedZone("EST", -5*60*60))
+	if got, want := absoluteTime(tm), "Jun  5, 2020, 21:04 UTC"; got != want {
+		t.Errorf("absoluteTime(%v) = %q, want %q", tm, got, want)
+	}
+	if got, want := rfc3339Time(tm), "2020-06-05T21:04:05Z"; got != want {
+		t.Errorf("rfc3339Time(%v) = %q, want %q", tm, got, want)
+	}
+}
+
 func TestCreatePackageHeader(t *testing.T) {
 	vpkg := func(modulePath, suffix, name string) *internal.LegacyVersionedPackage {
 		vp := &internal.LegacyVersionedPackage{
@@ -115,7 +128,42 @@ func TestCreatePackageHeader(t *testing.T) {
 		{
 			label:   "command package",
 			pkg:     vpkg(sample.ModulePath, sample.Suffix, "main"),
-			wantPkg: samplePackage(),
+			wantPkg: samplePackage(func(p *Package) {
+				p.Kind = internal.PackageKindCommand
+			}),
+		},
+		{
+			label: "internal package",
+			pkg:   vpkg(sample.ModulePath, "internal/foo", ""),
+			wantPkg: samplePackage(func(p *Package) {
+				p.Path = sample.ModulePath + "/internal/foo"
+				p.IsInternal = true
+				p.InternalRoot = sample.ModulePath
+			}),
+		},
+		{
+			label: "minimum Go version",
+			pkg: func() *internal.LegacyVersionedPackage {
+				vp := vpkg(sample.ModulePath, sample.Suffix, "")
+				vp.MinGoVersion = "1.18"
+				return vp
+			}(),
+			wantPkg: samplePackage(func(p *Package) {
+				p.MinGoVersion = "1.18"
+			}),
+		},
+		{
+			label: "v0 module",
+			pkg: func() *internal.LegacyVersionedPackage {
+				vp := vpkg(sample.ModulePath, sample.Suffix, "")
+				vp.Version = "v0.3.1"
+				return vp
+			}(),
+			wantPkg: samplePackage(func(p *Package) {
+				p.DisplayVersion = "v0.3.1"
+				p.LinkVersion = "v0.3.1"
+				p.IsUnstable = true
+			}),
 		},
 		{
 			label: "v2 command",
@@ -123,6 +171,7 @@ func TestCreatePackageHeader(t *testing.T) {
 			wantPkg: samplePackage(func(p *Package) {
 				p.Path = "pa.th/to/foo/v2/bar"
 				p.ModulePath = "pa.th/to/foo/v2"
+				p.Kind = internal.PackageKindCommand
 			}),
 		},
 		{
@@ -131,6 +180,7 @@ func TestCreatePackageHeader(t *testing.T) {
 			wantPkg: samplePackage(func(p *Package) {
 				p.Path = "pa.th/to/foo/v1"
 				p.ModulePath = "pa.th/to/foo/v1"
+				p.Kind = internal.PackageKindCommand
 			}),
 		},
 	} {
@@ -146,6 +196,30 @@ func TestCreatePackageHeader(t *testing.T) {
 	}
 }
 
+func TestInstallCommand(t *testing.T) {
+	for _, test := range []struct {
+		pkgPath, modulePath, version string
+		kind                         internal.PackageKind
+		latestRequested              bool
+		want                         string
+	}{
+		{"a.com/m/p", "a.com/m", "v1.2.3", internal.PackageKindLibrary, false, "go get a.com/m@v1.2.3"},
+		{"a.com/m/p", "a.com/m", "v1.2.3", internal.PackageKindLibrary, true, "go get a.com/m"},
+		{"a.com/m/cmd/c", "a.com/m", "v1.2.3", internal.PackageKindCommand, false, "go install a.com/m/cmd/c@v1.2.3"},
+		{"a.com/m/cmd/c", "a.com/m", "v1.2.3", internal.PackageKindCommand, true, "go install a.com/m/cmd/c"},
+		{"a.com/m/internal/p", "a.com/m", "v1.2.3", internal.PackageKindLibrary, false, ""},
+		{"a.com/m/internal/cmd/c", "a.com/m", "v1.2.3", internal.PackageKindCommand, false, "go install a.com/m/internal/cmd/c@v1.2.3"},
+		{"fmt", "std", "v1.13.0", internal.PackageKindLibrary, false, ""},
+		{"cmd/go", "std", "v1.13.0", internal.PackageKindCommand, false, "go install cmd/go"},
+	} {
+		got := installCommand(test.pkgPath, test.modulePath, test.version, test.kind, test.latestRequested)
+		if got != test.want {
+			t.Errorf("installCommand(%q, %q, %q, %q, %t) = %q, want %q",
+				test.pkgPath, test.modulePath, test.version, test.kind, test.latestRequested, got, test.want)
+		}
+	}
+}
+
 func TestBreadcrumbPath(t *testing.T) {
 	for _, test := range []struct {
 		pkgPath, modPath, version string
@@ -226,3 +300,27 @@ func TestBreadcrumbPath(t *testing.T) {
 		})
 	}
 }
+
+func TestNewImportPath(t *testing.T) {
+	for _, test := range []struct {
+		pkgPath, modPath string
+		want             importPath
+	}{
+		{"a.com/m/p", "a.com/m", importPath{"a.com/m/p", "a.com/m"}},
+		{"a.com/m", "a.com/m", importPath{"a.com/m", "a.com/m"}},
+		{"a.com/m/v2/p", "a.com/m/v2", importPath{"a.com/m/v2/p", "a.com/m/v2"}},
+		{"a.com/m/v2", "a.com/m/v2", importPath{"a.com/m/v2", "a.com/m/v2"}},
+		// A v1 path gets the module's major-version suffix.
+		{"a.com/m/p", "a.com/m/v2", importPath{"a.com/m/v2/p", "a.com/m/v2"}},
+		{"a.com/m", "a.com/m/v3", importPath{"a.com/m/v3", "a.com/m/v3"}},
+		{"gopkg.in/yaml/p", "gopkg.in/yaml.v2", importPath{"gopkg.in/yaml.v2/p", "gopkg.in/yaml.v2"}},
+		// A path outside the module's series is unchanged.
+		{"a.com/mm/p", "a.com/m/v2", importPath{"a.com/mm/p", "a.com/m/v2"}},
+		{"encoding/json", "std", importPath{"encoding/json", "std"}},
+		{"std", "std", importPath{"", "std"}},
+	} {
+		if got := newImportPath(test.pkgPath, test.modPath); got != test.want {
+			t.Errorf("newImportPath(%q, %q) = %+v, want %+v", test.pkgPath, test.modPath, got, test.want)
+		}
+	}
+}
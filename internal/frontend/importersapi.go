@@ -0,0 +1,80 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// importersAPIPathPrefix is the prefix of the paths of /api/importers.
+const importersAPIPathPrefix = "/api/importers/"
+
+// handleImportersAPI serves the paths of all the packages that import a
+// package as a JSON array of strings, in order of path. It handles endpoint
+// /api/importers/<package path>.
+//
+// A popular package can have a very large number of importers, so they are
+// streamed from the database to the response instead of being collected
+// first. Once the first importer has been written the status has been sent,
+// so an error after that can only be logged, and the client sees a truncated
+// JSON array.
+func (s *Server) handleImportersAPI(w http.ResponseWriter, r *http.Request) {
+	db, ok := s.ds.(*postgres.DB)
+	if !ok {
+		// The proxydatasource does not support imports.
+		s.serveSearchAPIError(w, r, proxydatasourceNotSupportedErr())
+		return
+	}
+	pkgPath := strings.Trim(strings.TrimPrefix(r.URL.Path, importersAPIPathPrefix), "/")
+	if pkgPath == "" {
+		s.serveSearchAPIError(w, r, fmt.Errorf("missing package path: %w", derrors.InvalidArgument))
+		return
+	}
+	ctx := r.Context()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	err := streamJSONArray(w, func(emit func(interface{}) error) error {
+		return db.ForEachImporter(ctx, pkgPath, func(path string) error {
+			return emit(path)
+		})
+	})
+	if err != nil {
+		log.Errorf(ctx, "streaming importers of %q: %v", pkgPath, err)
+	}
+}
+
+// streamJSONArray writes a JSON array to w, with an element for each value
+// that produce passes to emit. Each element is encoded and written as soon as
+// it is emitted. If produce or writing fails, streamJSONArray returns the
+// error without closing the array, so that the output is not valid JSON.
+func streamJSONArray(w io.Writer, produce func(emit func(interface{}) error) error) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	first := true
+	err := produce(func(v interface{}) error {
+		if !first {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		first = false
+		return enc.Encode(v)
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "]\n")
+	return err
+}
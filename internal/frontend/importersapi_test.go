@@ -0,0 +1,149 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestStreamJSONArray(t *testing.T) {
+	const n = 200000
+	path := func(i int) string { return fmt.Sprintf("example.com/importer%d/pkg", i) }
+
+	var buf bytes.Buffer
+	err := streamJSONArray(&buf, func(emit func(interface{}) error) error {
+		for i := 0; i < n; i++ {
+			// Every element is written before the next one is produced.
+			before := buf.Len()
+			if err := emit(path(i)); err != nil {
+				return err
+			}
+			if buf.Len() <= before {
+				return fmt.Errorf("element %d was not written when it was emitted", i)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != n {
+		t.Fatalf("got %d elements, want %d", len(got), n)
+	}
+	for i, p := range got {
+		if p != path(i) {
+			t.Fatalf("element %d: got %q, want %q", i, p, path(i))
+		}
+	}
+
+	// No elements make an empty array, not null.
+	buf.Reset()
+	if err := streamJSONArray(&buf, func(func(interface{}) error) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "[]\n"; got != want {
+		t.Errorf("no elements: got %q, want %q", got, want)
+	}
+
+	// An error in the middle of the stream leaves the array unterminated.
+	buf.Reset()
+	errProduce := errors.New("produce failed")
+	err = streamJSONArray(&buf, func(emit func(interface{}) error) error {
+		for i := 0; i < 10; i++ {
+			if err := emit(path(i)); err != nil {
+				return err
+			}
+		}
+		return errProduce
+	})
+	if !errors.Is(err, errProduce) {
+		t.Errorf("got error %v, want %v", err, errProduce)
+	}
+	if json.Valid(buf.Bytes()) {
+		t.Errorf("output after an error is valid JSON: %s", buf.Bytes())
+	}
+}
+
+func TestImportersAPI(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	const (
+		importedModule = "github.com/imported/mod"
+		nImporters     = 100
+	)
+	imported := sample.Module(importedModule, "v1.0.0", "pkg")
+	if err := testDB.InsertModule(ctx, imported); err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	for i := 0; i < nImporters; i++ {
+		m := sample.Module(fmt.Sprintf("github.com/importer%03d/mod", i), "v1.0.0", "pkg")
+		m.LegacyPackages[0].Imports = []string{importedModule + "/pkg"}
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, m.LegacyPackages[0].Path)
+	}
+
+	s, err := NewServer(ServerConfig{
+		DataSource:     testDB,
+		StaticPath:     "../../content/static",
+		ThirdPartyPath: "../../third_party",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	s.Install(mux.Handle, nil)
+	get := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		return w
+	}
+
+	for _, test := range []struct {
+		url  string
+		want []string
+	}{
+		{"/api/importers/" + importedModule + "/pkg", want},
+		{"/api/importers/" + want[0], []string{}},
+	} {
+		w := get(test.url)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: got status %d, want %d; body: %s", test.url, w.Code, http.StatusOK, w.Body)
+		}
+		if got, want := w.Header().Get("Content-Type"), "application/json"; got != want {
+			t.Errorf("%s: got Content-Type %q, want %q", test.url, got, want)
+		}
+		var got []string
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("%s: %v", test.url, err)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%s: mismatch (-want +got):\n%s", test.url, diff)
+		}
+	}
+
+	if w := get("/api/importers/"); w.Code != http.StatusBadRequest {
+		t.Errorf("/api/importers/: got status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -62,38 +62,55 @@ func fetchImportsDetails(ctx context.Context, ds internal.DataSource, pkgPath, m
 type ImportedByDetails struct {
 	ModulePath string
 
-	// ImportedBy is the collection of packages that import the
-	// given package and are not part of the same module.
+	// ImportedBy is the collection of packages on the current page that
+	// import the given package and are not part of the same module.
 	// They are organized into a tree of sections by prefix.
 	ImportedBy []*Section
 
-	Total        int  // number of packages in ImportedBy
+	Pagination   pagination
+	Total        int  // number of packages that import the given package
 	TotalIsExact bool // if false, then there may be more than Total
 }
 
-const importedByLimit = 20001
+const (
+	// defaultImportedByLimit is the number of importers shown on a page.
+	defaultImportedByLimit = 100
 
-// etchImportedByDetails fetches importers for the package version specified by
-// path and version from the database and returns a ImportedByDetails.
-func fetchImportedByDetails(ctx context.Context, db *postgres.DB, pkgPath, modulePath string) (*ImportedByDetails, error) {
-	importedBy, err := db.GetImportedBy(ctx, pkgPath, modulePath, importedByLimit)
+	// importedByLimit is the maximum number of importers counted. It is one
+	// more than the maximum total that is displayed.
+	importedByLimit = 20001
+)
+
+// fetchImportedByDetails fetches the page of importers described by pageParams
+// for the package specified by pkgPath from the database and returns a
+// ImportedByDetails.
+func fetchImportedByDetails(ctx context.Context, db *postgres.DB, pkgPath, modulePath string, pageParams paginationParams) (*ImportedByDetails, error) {
+	total, err := db.CountImporters(ctx, pkgPath, importedByLimit)
 	if err != nil {
 		return nil, err
 	}
-	// If we reached the query limit, then we don't know the total.
+	// If we reached the count limit, then we don't know the total.
 	// Say so, and show one less than the limit.
-	// For example, if the limit is 101 and we get 101 results, then we'll
-	// say there are more than 100, and show the first 100.
+	// For example, if the limit is 101 and we count 101 importers, then we'll
+	// say there are more than 100.
 	totalIsExact := true
-	if len(importedBy) == importedByLimit {
-		importedBy = importedBy[:len(importedBy)-1]
+	if total == importedByLimit {
+		total--
 		totalIsExact = false
 	}
+	var importedBy []string
+	if pageParams.offset() < total {
+		importedBy, err = db.GetImporters(ctx, pkgPath, pageParams.limit, pageParams.offset())
+		if err != nil {
+			return nil, err
+		}
+	}
 	sections := Sections(importedBy, nextPrefixAccount)
 	return &ImportedByDetails{
 		ModulePath:   modulePath,
 		ImportedBy:   sections,
-		Total:        len(importedBy),
+		Pagination:   newPagination(pageParams, len(importedBy), total),
+		Total:        total,
 		TotalIsExact: totalIsExact,
 	}, nil
 }
@@ -6,10 +6,12 @@ package frontend
 
 import (
 	"context"
+	"net/http/httptest"
 	"path"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/postgres"
 	"golang.org/x/pkgsite/internal/testing/sample"
@@ -115,15 +117,21 @@ func TestFetchImportedByDetails(t *testing.T) {
 	}
 
 	for _, tc := range []struct {
+		name        string
 		pkg         *internal.LegacyPackage
+		url         string
 		wantDetails *ImportedByDetails
 	}{
 		{
+			name:        pkg3.Path,
 			pkg:         pkg3,
+			url:         "/",
 			wantDetails: &ImportedByDetails{TotalIsExact: true},
 		},
 		{
-			pkg: pkg2,
+			name: pkg2.Path,
+			pkg:  pkg2,
+			url:  "/",
 			wantDetails: &ImportedByDetails{
 				ImportedBy:   []*Section{{Prefix: pkg3.Path, NumLines: 0}},
 				Total:        1,
@@ -131,7 +139,9 @@ func TestFetchImportedByDetails(t *testing.T) {
 			},
 		},
 		{
-			pkg: pkg1,
+			name: pkg1.Path,
+			pkg:  pkg1,
+			url:  "/",
 			wantDetails: &ImportedByDetails{
 				ImportedBy: []*Section{
 					{Prefix: pkg2.Path, NumLines: 0},
@@ -141,19 +151,36 @@ func TestFetchImportedByDetails(t *testing.T) {
 				TotalIsExact: true,
 			},
 		},
+		{
+			name: pkg1.Path + " second page",
+			pkg:  pkg1,
+			url:  "/?page=2&limit=1",
+			wantDetails: &ImportedByDetails{
+				ImportedBy:   []*Section{{Prefix: pkg3.Path, NumLines: 0}},
+				Total:        2,
+				TotalIsExact: true,
+			},
+		},
+		{
+			name:        pkg1.Path + " past last page",
+			pkg:         pkg1,
+			url:         "/?page=3&limit=1",
+			wantDetails: &ImportedByDetails{Total: 2, TotalIsExact: true},
+		},
 	} {
-		t.Run(tc.pkg.Path, func(t *testing.T) {
+		t.Run(tc.name, func(t *testing.T) {
 			otherVersion := newModule(path.Dir(tc.pkg.Path), tc.pkg)
 			otherVersion.Version = "v1.0.5"
 			vp := firstVersionedPackage(otherVersion)
-			got, err := fetchImportedByDetails(ctx, testDB, vp.Path, vp.ModulePath)
+			params := newPaginationParams(httptest.NewRequest("GET", tc.url, nil), defaultImportedByLimit)
+			got, err := fetchImportedByDetails(ctx, testDB, vp.Path, vp.ModulePath, params)
 			if err != nil {
 				t.Fatalf("fetchImportedByDetails(ctx, db, %q) = %v err = %v, want %v",
 					tc.pkg.Path, got, err, tc.wantDetails)
 			}
 
 			tc.wantDetails.ModulePath = vp.LegacyModuleInfo.ModulePath
-			if diff := cmp.Diff(tc.wantDetails, got); diff != "" {
+			if diff := cmp.Diff(tc.wantDetails, got, cmpopts.IgnoreFields(ImportedByDetails{}, "Pagination")); diff != "" {
 				t.Errorf("fetchImportedByDetails(ctx, db, %q) mismatch (-want +got):\n%s", tc.pkg.Path, diff)
 			}
 		})
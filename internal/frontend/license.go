@@ -5,11 +5,21 @@
 package frontend
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"net/url"
+	"path"
+	"strings"
 
+	"golang.org/x/mod/semver"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/licenses"
+	"golang.org/x/pkgsite/internal/postgres"
 )
 
 // License contains information used for a single license section.
@@ -17,6 +27,12 @@ type License struct {
 	*licenses.License
 	Anchor string
 	Source string
+	// PublicDomain reports whether the file dedicates the code to the public
+	// domain.
+	PublicDomain bool
+	// Proprietary reports whether the file reserves all rights without
+	// granting an open-source license.
+	Proprietary bool
 }
 
 // LicensesDetails contains license information for a package or module.
@@ -29,6 +45,11 @@ type LicensesDetails struct {
 type LicenseMetadata struct {
 	Type   string
 	Anchor string
+	// PublicDomain reports whether Type is a public-domain dedication.
+	PublicDomain bool
+	// Proprietary reports whether Type reserves all rights without granting
+	// an open-source license.
+	Proprietary bool
 }
 
 // fetchPackageLicensesDetails fetches license data for the package version specified by
@@ -44,15 +65,17 @@ func fetchPackageLicensesDetails(ctx context.Context, ds internal.DataSource, pk
 // transformLicenses transforms licenses.License into a License
 // by adding an anchor field.
 func transformLicenses(modulePath, version string, dbLicenses []*licenses.License) []License {
-	licenses := make([]License, len(dbLicenses))
+	lics := make([]License, len(dbLicenses))
 	for i, l := range dbLicenses {
-		licenses[i] = License{
-			Anchor:  licenseAnchor(l.FilePath),
-			License: l,
-			Source:  fileSource(modulePath, version, l.FilePath),
+		lics[i] = License{
+			Anchor:       licenseAnchor(l.FilePath),
+			License:      l,
+			Source:       fileSource(modulePath, version, l.FilePath),
+			PublicDomain: l.Category() == licenses.PublicDomainEquivalent,
+			Proprietary:  l.Category() == licenses.Proprietary,
 		}
 	}
-	return licenses
+	return lics
 }
 
 // transformLicenseMetadata transforms licenses.Metadata into a LicenseMetadata
@@ -63,8 +86,10 @@ func transformLicenseMetadata(dbLicenses []*licenses.Metadata) []LicenseMetadata
 		anchor := licenseAnchor(l.FilePath)
 		for _, typ := range l.Types {
 			mds = append(mds, LicenseMetadata{
-				Type:   typ,
-				Anchor: anchor,
+				Type:         typ,
+				Anchor:       anchor,
+				PublicDomain: licenses.Category(typ) == licenses.PublicDomainEquivalent,
+				Proprietary:  licenses.Category(typ) == licenses.Proprietary,
 			})
 		}
 	}
@@ -85,3 +110,127 @@ func licensesToMetadatas(lics []*licenses.License) []*licenses.Metadata {
 	}
 	return ms
 }
+
+// maxLicenseBundleSize is the maximum total size of the license contents served
+// by handleLicenseBundle.
+const maxLicenseBundleSize = 50 * 1024 * 1024
+
+// handleLicenseBundle serves all the license files of a module version as a
+// single download. It handles paths of the form
+// "/license-bundle/<module-path>@<version>". The bundle is a zip file, unless
+// the "format" query parameter is "text", in which case the license files are
+// concatenated.
+func (s *Server) handleLicenseBundle(w http.ResponseWriter, r *http.Request) (err error) {
+	db, ok := s.ds.(*postgres.DB)
+	if !ok {
+		// The proxydatasource does not support license bundles.
+		return proxydatasourceNotSupportedErr()
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/license-bundle/"), "@", 2)
+	if len(parts) != 2 || parts[0] == "" || !semver.IsValid(parts[1]) {
+		return &serverError{
+			status: http.StatusBadRequest,
+			err:    fmt.Errorf("invalid license bundle path %q", r.URL.Path),
+		}
+	}
+	modulePath, version := parts[0], parts[1]
+	format := r.FormValue("format")
+	if format != "" && format != "zip" && format != "text" {
+		return &serverError{
+			status: http.StatusBadRequest,
+			err:    fmt.Errorf("invalid license bundle format %q", format),
+		}
+	}
+
+	ctx := r.Context()
+	if err := validatePathAndVersion(ctx, s.ds, modulePath, version); err != nil {
+		return err
+	}
+	lics, err := db.GetLicenseContents(ctx, modulePath, version)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return &serverError{status: http.StatusNotFound, err: err}
+		}
+		return err
+	}
+	if err := checkLicenseBundleSize(lics, maxLicenseBundleSize); err != nil {
+		return fmt.Errorf("license bundle for %s@%s: %w", modulePath, version, err)
+	}
+
+	// Build the bundle before writing anything, so an error can still be
+	// served as an error page.
+	var (
+		buf         bytes.Buffer
+		contentType string
+		filename    = fmt.Sprintf("%s-%s-licenses", path.Base(modulePath), version)
+	)
+	if format == "text" {
+		contentType = "text/plain; charset=utf-8"
+		filename += ".txt"
+		writeLicensesText(&buf, lics)
+	} else {
+		contentType = "application/zip"
+		filename += ".zip"
+		if err := writeLicensesZip(&buf, modulePath+"@"+version, lics); err != nil {
+			return err
+		}
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// checkLicenseBundleSize returns a serverError with status 413 (Request Entity
+// Too Large) if the total size of the contents of lics is more than max
+// bytes. The bundle cannot be served, and asking again will not help.
+func checkLicenseBundleSize(lics []*licenses.License, max int) error {
+	size := 0
+	for _, l := range lics {
+		size += len(l.Contents)
+	}
+	if size <= max {
+		return nil
+	}
+	return &serverError{
+		status: http.StatusRequestEntityTooLarge,
+		err:    fmt.Errorf("license contents are %d bytes; max is %d", size, max),
+		epage: &errorPage{
+			messageTemplate: `<h3 class="Error-message">The licenses of this module are too large to download as a bundle.</h3>`,
+		},
+	}
+}
+
+// writeLicensesText writes the contents of each license to buf, each preceded
+// by a line with its file path.
+func writeLicensesText(buf *bytes.Buffer, lics []*licenses.License) {
+	for i, l := range lics {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(buf, "==> %s <==\n", l.FilePath)
+		buf.Write(l.Contents)
+		if !bytes.HasSuffix(l.Contents, []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+	}
+}
+
+// writeLicensesZip writes a zip file to buf containing each license, at its
+// file path under the directory dir.
+func writeLicensesZip(buf *bytes.Buffer, dir string, lics []*licenses.License) (err error) {
+	defer derrors.Wrap(&err, "writeLicensesZip(buf, %q, lics)", dir)
+
+	zw := zip.NewWriter(buf)
+	for _, l := range lics {
+		f, err := zw.Create(path.Join(dir, l.FilePath))
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(l.Contents); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
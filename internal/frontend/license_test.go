@@ -0,0 +1,90 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/licenses"
+)
+
+var bundleLicenses = []*licenses.License{
+	{Metadata: &licenses.Metadata{FilePath: "foo/LICENSE"}, Contents: []byte("foo license\n")},
+	{Metadata: &licenses.Metadata{FilePath: "LICENSE"}, Contents: []byte("top license")},
+}
+
+func TestCheckLicenseBundleSize(t *testing.T) {
+	// The contents of bundleLicenses are 23 bytes.
+	if err := checkLicenseBundleSize(bundleLicenses, 23); err != nil {
+		t.Errorf("checkLicenseBundleSize(lics, 23): %v", err)
+	}
+	err := checkLicenseBundleSize(bundleLicenses, 22)
+	var serr *serverError
+	if !errors.As(err, &serr) || serr.status != http.StatusRequestEntityTooLarge {
+		t.Errorf("checkLicenseBundleSize(lics, 22): got error %v, want status %d", err, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestWriteLicensesText(t *testing.T) {
+	var buf bytes.Buffer
+	writeLicensesText(&buf, bundleLicenses)
+	want := "==> foo/LICENSE <==\nfoo license\n\n==> LICENSE <==\ntop license\n"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("writeLicensesText mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteLicensesZip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeLicensesZip(&buf, "example.com/m@v1.0.0", bundleLicenses); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[f.Name] = string(contents)
+	}
+	want := map[string]string{
+		"example.com/m@v1.0.0/foo/LICENSE": "foo license\n",
+		"example.com/m@v1.0.0/LICENSE":     "top license",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("writeLicensesZip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTransformLicenseMetadata(t *testing.T) {
+	got := transformLicenseMetadata([]*licenses.Metadata{
+		{Types: []string{"MIT"}, FilePath: "LICENSE"},
+		{Types: []string{"Unlicense"}, FilePath: "UNLICENSE"},
+		{Types: []string{"PROPRIETARY"}, FilePath: "sub/LICENSE"},
+	})
+	want := []LicenseMetadata{
+		{Type: "MIT", Anchor: "LICENSE"},
+		{Type: "Unlicense", Anchor: "UNLICENSE", PublicDomain: true},
+		{Type: "PROPRIETARY", Anchor: "sub%2FLICENSE", Proprietary: true},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("transformLicenseMetadata mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -67,7 +67,7 @@ func (s *Server) legacyServeModulePageWithModule(ctx context.Context, w http.Res
 		var err error
 		details, err = fetchDetailsForModule(ctx, r, tab, s.ds, mi, licenses)
 		if err != nil {
-			return fmt.Errorf("error fetching page for %q: %v", tab, err)
+			return fmt.Errorf("error fetching page for %q: %w", tab, err)
 		}
 	}
 	page := &DetailsPage{
@@ -81,6 +81,6 @@ func (s *Server) legacyServeModulePageWithModule(ctx context.Context, w http.Res
 		Tabs:           moduleTabSettings,
 		PageType:       "mod",
 	}
-	s.servePage(ctx, w, settings.TemplateName, page)
+	s.serveDetailsPage(ctx, w, r, page)
 	return nil
 }
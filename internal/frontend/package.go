@@ -21,7 +21,7 @@ import (
 // handlePackageDetailsRedirect redirects all redirects to "/pkg" to "/".
 func (s *Server) handlePackageDetailsRedirect(w http.ResponseWriter, r *http.Request) {
 	urlPath := strings.TrimPrefix(r.URL.Path, "/pkg")
-	http.Redirect(w, r, urlPath, http.StatusMovedPermanently)
+	s.redirect(w, r, urlPath, http.StatusMovedPermanently)
 }
 
 // legacyServePackagePage serves details pages for the package with import path
@@ -63,7 +63,7 @@ func (s *Server) legacyServePackagePage(w http.ResponseWriter, r *http.Request,
 	if !errors.Is(err, derrors.NotFound) {
 		// The only error we expect is NotFound, so serve an 500 here, otherwise
 		// whatever response we resolve below might be inconsistent or misleading.
-		return fmt.Errorf("checking for directory: %v", err)
+		return fmt.Errorf("checking for directory: %w", err)
 	}
 	_, err = s.ds.LegacyGetPackage(ctx, pkgPath, modulePath, internal.LatestVersion)
 	if err == nil {
@@ -90,7 +90,7 @@ func (s *Server) legacyServePackagePageWithPackage(ctx context.Context, w http.R
 	}()
 	pkgHeader, err := legacyCreatePackage(&pkg.LegacyPackage, &pkg.ModuleInfo, requestedVersion == internal.LatestVersion)
 	if err != nil {
-		return fmt.Errorf("creating package header for %s@%s: %v", pkg.Path, pkg.Version, err)
+		return fmt.Errorf("creating package header for %s@%s: %w", pkg.Path, pkg.Version, err)
 	}
 
 	tab := r.FormValue("tab")
@@ -102,7 +102,7 @@ func (s *Server) legacyServePackagePageWithPackage(ctx context.Context, w http.R
 		} else {
 			tab = "overview"
 		}
-		http.Redirect(w, r, fmt.Sprintf(r.URL.Path+"?tab=%s", tab), http.StatusFound)
+		s.redirect(w, r, fmt.Sprintf(r.URL.Path+"?tab=%s", tab), http.StatusFound)
 		return nil
 	}
 	canShowDetails := pkg.LegacyPackage.IsRedistributable || settings.AlwaysShowDetails
@@ -112,7 +112,7 @@ func (s *Server) legacyServePackagePageWithPackage(ctx context.Context, w http.R
 		var err error
 		details, err = fetchDetailsForPackage(ctx, r, tab, s.ds, pkg)
 		if err != nil {
-			return fmt.Errorf("fetching page for %q: %v", tab, err)
+			return fmt.Errorf("fetching page for %q: %w", tab, err)
 		}
 	}
 	page := &DetailsPage{
@@ -122,12 +122,13 @@ func (s *Server) legacyServePackagePageWithPackage(ctx context.Context, w http.R
 		Header:   pkgHeader,
 		Breadcrumb: breadcrumbPath(pkgHeader.Path, pkgHeader.Module.ModulePath,
 			pkgHeader.Module.LinkVersion),
+		ImportPath:     newImportPath(pkgHeader.Path, pkgHeader.Module.ModulePath),
 		Details:        details,
 		CanShowDetails: canShowDetails,
 		Tabs:           packageTabSettings,
 		PageType:       "pkg",
 	}
-	s.servePage(ctx, w, settings.TemplateName, page)
+	s.serveDetailsPage(ctx, w, r, page)
 	return nil
 }
 
@@ -169,6 +170,11 @@ func (s *Server) servePackagePageNew(w http.ResponseWriter, r *http.Request, ful
 	if err != nil {
 		return err
 	}
+	if vdir.Readme != nil {
+		// Show the directory's own README instead of the module's.
+		dir.LegacyReadmeFilePath = vdir.Readme.Filepath
+		dir.LegacyReadmeContents = vdir.Readme.Contents
+	}
 	return s.legacyServeDirectoryPage(ctx, w, r, dir, inVersion)
 }
 
@@ -198,7 +204,7 @@ func (s *Server) servePackagePageWithVersionedDirectory(ctx context.Context,
 	w http.ResponseWriter, r *http.Request, vdir *internal.VersionedDirectory, requestedVersion string) error {
 	pkgHeader, err := createPackageNew(vdir, requestedVersion == internal.LatestVersion)
 	if err != nil {
-		return fmt.Errorf("creating package header for %s@%s: %v", vdir.Path, vdir.Version, err)
+		return fmt.Errorf("creating package header for %s@%s: %w", vdir.Path, vdir.Version, err)
 	}
 
 	tab := r.FormValue("tab")
@@ -210,7 +216,7 @@ func (s *Server) servePackagePageWithVersionedDirectory(ctx context.Context,
 		} else {
 			tab = "overview"
 		}
-		http.Redirect(w, r, fmt.Sprintf(r.URL.Path+"?tab=%s", tab), http.StatusFound)
+		s.redirect(w, r, fmt.Sprintf(r.URL.Path+"?tab=%s", tab), http.StatusFound)
 		return nil
 	}
 	canShowDetails := vdir.DirectoryNew.IsRedistributable || settings.AlwaysShowDetails
@@ -220,7 +226,7 @@ func (s *Server) servePackagePageWithVersionedDirectory(ctx context.Context,
 		var err error
 		details, err = fetchDetailsForVersionedDirectory(ctx, r, tab, s.ds, vdir)
 		if err != nil {
-			return fmt.Errorf("fetching page for %q: %v", tab, err)
+			return fmt.Errorf("fetching page for %q: %w", tab, err)
 		}
 	}
 	page := &DetailsPage{
@@ -230,11 +236,12 @@ func (s *Server) servePackagePageWithVersionedDirectory(ctx context.Context,
 		Header:   pkgHeader,
 		Breadcrumb: breadcrumbPath(pkgHeader.Path, pkgHeader.Module.ModulePath,
 			pkgHeader.Module.LinkVersion),
+		ImportPath:     newImportPath(pkgHeader.Path, pkgHeader.Module.ModulePath),
 		Details:        details,
 		CanShowDetails: canShowDetails,
 		Tabs:           packageTabSettings,
 		PageType:       "pkg",
 	}
-	s.servePage(ctx, w, settings.TemplateName, page)
+	s.serveDetailsPage(ctx, w, r, page)
 	return nil
 }
@@ -0,0 +1,91 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/mod/semver"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// handlePackageDoc serves the documentation of a package, for editors and
+// other tools. It handles paths of the form "/package-doc/<path>" and
+// "/package-doc/<path>@<version>"; without a version, it serves the latest
+// one. The documentation is plain text in the format of "go doc -all", unless
+// the "format" query parameter is "html", in which case it is the HTML shown
+// on the package page.
+func (s *Server) handlePackageDoc(w http.ResponseWriter, r *http.Request) (err error) {
+	db, ok := s.ds.(*postgres.DB)
+	if !ok {
+		// The proxydatasource does not support package documentation.
+		return proxydatasourceNotSupportedErr()
+	}
+
+	pkgPath, version := strings.TrimPrefix(r.URL.Path, "/package-doc/"), internal.LatestVersion
+	if i := strings.IndexByte(pkgPath, '@'); i >= 0 {
+		pkgPath, version = pkgPath[:i], pkgPath[i+1:]
+		if !semver.IsValid(version) {
+			return &serverError{
+				status: http.StatusBadRequest,
+				err:    fmt.Errorf("invalid package documentation path %q", r.URL.Path),
+			}
+		}
+	}
+	if pkgPath == "" {
+		return &serverError{
+			status: http.StatusBadRequest,
+			err:    fmt.Errorf("invalid package documentation path %q", r.URL.Path),
+		}
+	}
+	format := r.FormValue("format")
+	switch format {
+	case "":
+		format = postgres.DocFormatText
+	case postgres.DocFormatText, postgres.DocFormatHTML:
+	default:
+		return &serverError{
+			status: http.StatusBadRequest,
+			err:    fmt.Errorf("invalid package documentation format %q", format),
+		}
+	}
+
+	ctx := r.Context()
+	if err := validatePathAndVersion(ctx, s.ds, pkgPath, version); err != nil {
+		return err
+	}
+	modulePath, version, isPackage, err := db.GetPathInfo(ctx, pkgPath, internal.UnknownModulePath, version)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return &serverError{status: http.StatusNotFound, err: err}
+		}
+		return err
+	}
+	if !isPackage {
+		return &serverError{
+			status: http.StatusNotFound,
+			err:    fmt.Errorf("%s@%s is not a package", pkgPath, version),
+		}
+	}
+	doc, err := db.GetPackageDoc(ctx, pkgPath, modulePath, version, format)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return &serverError{status: http.StatusNotFound, err: err}
+		}
+		return err
+	}
+	contentType := "text/plain; charset=utf-8"
+	if format == postgres.DocFormatHTML {
+		contentType = "text/html; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	_, err = w.Write([]byte(doc))
+	return err
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// A validator is page data that can check that the handler that built it set
+// the fields its template requires. A template silently renders a missing
+// field as empty, so renderPage validates pages before executing their
+// templates.
+type validator interface {
+	validate() error
+}
+
+// missingFieldsError returns an error for the page type typ listing missing,
+// the names of the required fields that are not set, or nil if there are
+// none.
+func missingFieldsError(typ string, missing []string) error {
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s: missing required fields: %s", typ, strings.Join(missing, ", "))
+}
+
+// isNil reports whether v is nil or holds a nil pointer, map, slice or other
+// value of a nillable kind. Pages hold values like a *Package in interface{}
+// fields, which compare as non-nil to nil even when the pointer is nil.
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// validate reports whether p was created by newBasePage. Its fields may
+// otherwise be empty; for example, the home page has no title.
+func (p basePage) validate() error {
+	if p.CanonicalURL == "" {
+		return fmt.Errorf("basePage: not set by newBasePage")
+	}
+	return nil
+}
+
+func (p *DetailsPage) validate() error {
+	if err := p.basePage.validate(); err != nil {
+		return err
+	}
+	var missing []string
+	if p.HTMLTitle == "" {
+		missing = append(missing, "HTMLTitle")
+	}
+	if p.Title == "" {
+		missing = append(missing, "Title")
+	}
+	if p.PageType == "" {
+		missing = append(missing, "PageType")
+	}
+	if p.Settings.TemplateName == "" {
+		missing = append(missing, "Settings")
+	}
+	if isNil(p.Header) {
+		missing = append(missing, "Header")
+	}
+	if len(p.Tabs) == 0 {
+		missing = append(missing, "Tabs")
+	}
+	if p.CanShowDetails && isNil(p.Details) {
+		missing = append(missing, "Details")
+	}
+	return missingFieldsError("DetailsPage", missing)
+}
+
+func (p *SearchPage) validate() error {
+	if err := p.basePage.validate(); err != nil {
+		return err
+	}
+	var missing []string
+	if p.HTMLTitle == "" {
+		missing = append(missing, "HTMLTitle")
+	}
+	if p.Pagination.baseURL == nil {
+		missing = append(missing, "Pagination")
+	}
+	return missingFieldsError("SearchPage", missing)
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidatePageData(t *testing.T) {
+	s := &Server{}
+	r := httptest.NewRequest("GET", "/github.com/a/b", nil)
+	base := s.newBasePage(r, "b")
+	details := func(modify func(*DetailsPage)) *DetailsPage {
+		p := &DetailsPage{
+			basePage:       base,
+			Title:          "package b",
+			Settings:       packageTabLookup["doc"],
+			Header:         &Package{},
+			Details:        &DocumentationDetails{},
+			CanShowDetails: true,
+			Tabs:           packageTabSettings,
+			PageType:       "pkg",
+		}
+		if modify != nil {
+			modify(p)
+		}
+		return p
+	}
+	for _, test := range []struct {
+		name        string
+		page        validator
+		wantMissing string // empty if valid
+	}{
+		{"base page", base, ""},
+		{"zero base page", basePage{}, "newBasePage"},
+		{"details page", details(nil), ""},
+		{"details page without base page", details(func(p *DetailsPage) { p.basePage = basePage{} }), "newBasePage"},
+		{"details page without header", details(func(p *DetailsPage) { p.Header = nil }), "Header"},
+		{"details page with a nil header", details(func(p *DetailsPage) { p.Header = (*Package)(nil) }), "Header"},
+		{"details page without title and tabs", details(func(p *DetailsPage) { p.Title = ""; p.Tabs = nil }), "Title, Tabs"},
+		{"hidden details", details(func(p *DetailsPage) { p.Details = nil; p.CanShowDetails = false }), ""},
+		{"missing details", details(func(p *DetailsPage) { p.Details = nil }), "Details"},
+		{"nil details", details(func(p *DetailsPage) { p.Details = (*DocumentationDetails)(nil) }), "Details"},
+		{"search page", &SearchPage{basePage: base, Pagination: newPagination(newPaginationParams(r, 10), 0, 0)}, ""},
+		{"search page without pagination", &SearchPage{basePage: base}, "Pagination"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.page.validate()
+			if test.wantMissing == "" {
+				if err != nil {
+					t.Errorf("got error %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantMissing) {
+				t.Errorf("got error %v, want one mentioning %q", err, test.wantMissing)
+			}
+		})
+	}
+}
+
+func TestRenderPageValidates(t *testing.T) {
+	for _, devMode := range []bool{false, true} {
+		s, err := NewServer(ServerConfig{
+			StaticPath:     "../../content/static",
+			ThirdPartyPath: "../../third_party",
+			DevMode:        devMode,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = s.renderPage(context.Background(), "index.tmpl", basePage{})
+		if got := err != nil; got != devMode {
+			t.Errorf("devMode=%t: renderPage with a zero basePage: got error %v, want error: %t", devMode, err, devMode)
+		}
+	}
+}
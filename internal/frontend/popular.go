@@ -0,0 +1,74 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+const defaultPopularLimit = 20
+
+// PopularPage contains the data that the popular template needs to populate.
+type PopularPage struct {
+	basePage
+	Pagination pagination
+	Packages   []*SearchResult
+}
+
+// fetchPopularPage fetches the most imported packages from the database and
+// returns a PopularPage.
+func fetchPopularPage(ctx context.Context, db *postgres.DB, pageParams paginationParams) (*PopularPage, error) {
+	// Fetch one more package than needed to determine whether there is a next
+	// page.
+	dbresults, err := db.GetPopularPackages(ctx, pageParams.limit+1, pageParams.offset())
+	if err != nil {
+		return nil, err
+	}
+	total := pageParams.offset() + len(dbresults)
+	if len(dbresults) > pageParams.limit {
+		dbresults = dbresults[:pageParams.limit]
+	}
+
+	var pkgs []*SearchResult
+	for _, r := range dbresults {
+		pkgs = append(pkgs, &SearchResult{
+			Name:           r.Name,
+			PackagePath:    r.PackagePath,
+			ModulePath:     r.ModulePath,
+			Synopsis:       r.Synopsis,
+			DisplayVersion: displayVersion(r.Version, r.ModulePath),
+			Licenses:       r.Licenses,
+			CommitTime:     elapsedTime(r.CommitTime),
+			NumImportedBy:  r.NumImportedBy,
+		})
+	}
+	return &PopularPage{
+		Packages:   pkgs,
+		Pagination: newPagination(pageParams, len(pkgs), total),
+	}, nil
+}
+
+// servePopular applies database data to the popular template. Handles
+// endpoint /popular.
+func (s *Server) servePopular(w http.ResponseWriter, r *http.Request) error {
+	db, ok := s.ds.(*postgres.DB)
+	if !ok {
+		// The proxydatasource does not support the popular page.
+		return proxydatasourceNotSupportedErr()
+	}
+
+	ctx := r.Context()
+	page, err := fetchPopularPage(ctx, db, newPaginationParams(r, defaultPopularLimit))
+	if err != nil {
+		return fmt.Errorf("fetchPopularPage(ctx, db): %v", err)
+	}
+	page.basePage = s.newBasePage(r, "Popular Packages")
+	s.servePage(ctx, w, "popular.tmpl", page)
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+const defaultRecentLimit = 20
+
+// RecentPage contains the data that the recent template needs to populate.
+type RecentPage struct {
+	basePage
+	Pagination pagination
+	Modules    []*RecentModule
+}
+
+// RecentModule contains the data needed to display a single recently
+// published module.
+type RecentModule struct {
+	ModulePath     string
+	DisplayVersion string
+	CommitTime     string
+}
+
+// fetchRecentPage fetches the most recently published modules from the
+// database and returns a RecentPage.
+func fetchRecentPage(ctx context.Context, db *postgres.DB, pageParams paginationParams) (*RecentPage, error) {
+	// Fetch one more module than needed to determine whether there is a next
+	// page, without counting the entire modules table.
+	dbresults, err := db.GetRecentModules(ctx, pageParams.limit+1, pageParams.offset())
+	if err != nil {
+		return nil, err
+	}
+	total := pageParams.offset() + len(dbresults)
+	if len(dbresults) > pageParams.limit {
+		dbresults = dbresults[:pageParams.limit]
+	}
+
+	var modules []*RecentModule
+	for _, mi := range dbresults {
+		modules = append(modules, &RecentModule{
+			ModulePath:     mi.ModulePath,
+			DisplayVersion: displayVersion(mi.Version, mi.ModulePath),
+			CommitTime:     elapsedTime(mi.CommitTime),
+		})
+	}
+	return &RecentPage{
+		Modules:    modules,
+		Pagination: newPagination(pageParams, len(modules), total),
+	}, nil
+}
+
+// serveRecent applies database data to the recent template. Handles endpoint
+// /recent.
+func (s *Server) serveRecent(w http.ResponseWriter, r *http.Request) error {
+	db, ok := s.ds.(*postgres.DB)
+	if !ok {
+		// The proxydatasource does not support the recent page.
+		return proxydatasourceNotSupportedErr()
+	}
+
+	ctx := r.Context()
+	page, err := fetchRecentPage(ctx, db, newPaginationParams(r, defaultRecentLimit))
+	if err != nil {
+		return fmt.Errorf("fetchRecentPage(ctx, db): %v", err)
+	}
+	page.basePage = s.newBasePage(r, "Recently Published Modules")
+	s.servePage(ctx, w, "recent.tmpl", page)
+	return nil
+}
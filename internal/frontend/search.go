@@ -11,12 +11,17 @@ import (
 	"math"
 	"net/http"
 	"path"
+	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/fetch"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/searchquery"
+	"golang.org/x/sync/errgroup"
 )
 
 const defaultSearchLimit = 10
@@ -27,6 +32,12 @@ type SearchPage struct {
 	basePage
 	Pagination pagination
 	Results    []*SearchResult
+	// Kind is the package kind that results are restricted to, or empty if
+	// packages of all kinds are shown.
+	Kind internal.PackageKind
+	// StdlibOnly reports whether results are restricted to packages that
+	// import only standard library packages.
+	StdlibOnly bool
 }
 
 // SearchResult contains data needed to display a single search result.
@@ -35,19 +46,60 @@ type SearchResult struct {
 	PackagePath    string
 	ModulePath     string
 	Synopsis       string
+	Version        string
 	DisplayVersion string
 	Licenses       []string
 	CommitTime     string
 	NumImportedBy  uint64
 	Approximate    bool
+	IsCommand      bool
+	// Score is the rank of the result; results are sorted by it, in
+	// descending order.
+	Score float64
 }
 
 // fetchSearchPage fetches data matching the search query from the database and
-// returns a SearchPage.
-func fetchSearchPage(ctx context.Context, db *postgres.DB, query string, pageParams paginationParams) (*SearchPage, error) {
-	dbresults, err := db.Search(ctx, query, pageParams.limit, pageParams.offset())
-	if err != nil {
-		return nil, err
+// returns a SearchPage. Only packages that pass filter are returned.
+//
+// For an unfiltered search, the number of results used for pagination comes
+// from searchResultCount, which is run concurrently with the search.
+func fetchSearchPage(ctx context.Context, db *postgres.DB, query string, filter postgres.SearchFilter, pageParams paginationParams) (*SearchPage, error) {
+	var (
+		dbresults   []*internal.SearchResult
+		numResults  int
+		approximate bool
+	)
+	if filter == (postgres.SearchFilter{}) {
+		g, gctx := errgroup.WithContext(ctx)
+		g.Go(func() error {
+			var err error
+			dbresults, err = db.Search(gctx, query, pageParams.limit, pageParams.offset())
+			return err
+		})
+		g.Go(func() error {
+			var err error
+			numResults, approximate, err = searchResultCount(gctx, db, query, filter)
+			return err
+		})
+		if err := g.Wait(); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		dbresults, err = db.SearchFiltered(ctx, query, filter, pageParams.limit, pageParams.offset())
+		if err != nil {
+			return nil, err
+		}
+		if len(dbresults) > 0 {
+			numResults = int(dbresults[0].NumResults)
+			approximate = dbresults[0].Approximate
+		}
+	}
+	if approximate {
+		// 128 hyperloglog buckets correspond to a standard error of 10%.
+		// http://algo.inria.fr/flajolet/Publications/FlFuGaMe07.pdf
+		// Planner estimates are rounded the same way.
+		numResults = approximateNumber(numResults, 0.1)
 	}
 
 	var results []*SearchResult
@@ -57,35 +109,53 @@ func fetchSearchPage(ctx context.Context, db *postgres.DB, query string, pagePar
 			PackagePath:    r.PackagePath,
 			ModulePath:     r.ModulePath,
 			Synopsis:       r.Synopsis,
+			Version:        r.Version,
 			DisplayVersion: displayVersion(r.Version, r.ModulePath),
 			Licenses:       r.Licenses,
 			CommitTime:     elapsedTime(r.CommitTime),
 			NumImportedBy:  r.NumImportedBy,
+			IsCommand:      internal.PackageKindForName(r.Name) == internal.PackageKindCommand,
+			Score:          r.Score,
 		})
 	}
 
-	var (
-		numResults  int
-		approximate bool
-	)
-	if len(dbresults) > 0 {
-		numResults = int(dbresults[0].NumResults)
-		if dbresults[0].Approximate {
-			// 128 buckets corresponds to a standard error of 10%.
-			// http://algo.inria.fr/flajolet/Publications/FlFuGaMe07.pdf
-			numResults = approximateNumber(numResults, 0.1)
-			approximate = true
-		}
-	}
-
 	pgs := newPagination(pageParams, len(results), numResults)
 	pgs.Approximate = approximate
 	return &SearchPage{
 		Results:    results,
 		Pagination: pgs,
+		Kind:       filter.Kind,
+		StdlibOnly: filter.StdlibOnly,
 	}, nil
 }
 
+// exactCountTimeout is how long searchResultCount waits for an exact count
+// before falling back to the query planner's estimate. It is a variable for
+// testing.
+var exactCountTimeout = 500 * time.Millisecond
+
+// searchResultCount returns the number of search results for query and
+// filter, and reports whether it is approximate. If counting the results
+// takes longer than exactCountTimeout, it returns the query planner's estimate
+// instead.
+func searchResultCount(ctx context.Context, db *postgres.DB, query string, filter postgres.SearchFilter) (_ int, approximate bool, err error) {
+	countCtx, cancel := context.WithTimeout(ctx, exactCountTimeout)
+	defer cancel()
+	count, err := db.CountSearchResults(countCtx, query, filter)
+	if err == nil {
+		return count, false, nil
+	}
+	if ctx.Err() != nil || countCtx.Err() != context.DeadlineExceeded {
+		return 0, false, err
+	}
+	log.Debugf(ctx, "exact count for %q timed out, using the planner estimate", query)
+	count, err = db.EstimateSearchResults(ctx, query, filter)
+	if err != nil {
+		return 0, false, err
+	}
+	return count, true, nil
+}
+
 // approximateNumber returns an approximation of the estimate, calibrated by
 // the statistical estimate of standard error.
 // i.e., a number that isn't misleading when we say '1-10 of approximately N
@@ -112,17 +182,27 @@ func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 	query := searchQuery(r)
 	if query == "" {
-		http.Redirect(w, r, "/", http.StatusFound)
+		s.redirect(w, r, "/", http.StatusFound)
 		return nil
 	}
 
-	if path := searchRequestRedirectPath(ctx, s.ds, query); path != "" {
-		http.Redirect(w, r, path, http.StatusFound)
-		return nil
+	if err := checkSearchQuery(query, s.maxSearchQueryLength); err != nil {
+		return err
+	}
+	text, _, hasSignature := searchquery.SplitSignature(query)
+	if !hasSignature {
+		if path := searchRequestRedirectPath(ctx, s.ds, query); path != "" {
+			s.redirect(w, r, path, http.StatusFound)
+			return nil
+		}
 	}
-	page, err := fetchSearchPage(ctx, db, query, newPaginationParams(r, defaultSearchLimit))
+	filter, err := searchFilter(r)
 	if err != nil {
-		return fmt.Errorf("fetchSearchPage(ctx, db, %q): %v", query, err)
+		return err
+	}
+	page, err := fetchSearchPage(ctx, db, text, filter, newPaginationParams(r, defaultSearchLimit))
+	if err != nil {
+		return fmt.Errorf("fetchSearchPage(ctx, db, %q, %+v): %w", text, filter, err)
 	}
 	page.basePage = s.newBasePage(r, query)
 	s.servePage(ctx, w, "search.tmpl", page)
@@ -180,5 +260,79 @@ func searchRequestRedirectPath(ctx context.Context, ds internal.DataSource, quer
 
 // searchQuery extracts a search query from the request.
 func searchQuery(r *http.Request) string {
-	return strings.TrimSpace(r.FormValue("q"))
+	return strings.Join(strings.Fields(r.FormValue("q")), " ")
+}
+
+// checkSearchQuery returns a serverError with status 400 if query is not a
+// valid search query of at most maxLength characters (see searchquery.Check).
+func checkSearchQuery(query string, maxLength int) error {
+	err := searchquery.Check(query, maxLength)
+	var qerr *searchquery.Error
+	if !errors.As(err, &qerr) {
+		return err
+	}
+	serr := &serverError{status: http.StatusBadRequest, err: err}
+	switch qerr.Kind {
+	case searchquery.TooLong:
+		serr.epage = &errorPage{
+			messageTemplate: `<h3 class="Error-message">Search queries can have at most {{.}} characters.</h3>`,
+			MessageData:     qerr.MaxLength,
+		}
+	case searchquery.InvalidSignature:
+		serr.epage = &errorPage{
+			messageTemplate: `<h3 class="Error-message">Invalid signature {{.}}: it must be a function type, like func(io.Reader) error. See <a href="/search-help">Search help</a>.</h3>`,
+			MessageData:     strconv.Quote(qerr.Signature),
+		}
+	default:
+		serr.epage = &errorPage{
+			messageTemplate: `<h3 class="Error-message">Invalid search query: {{.}}. See <a href="/search-help">Search help</a>.</h3>`,
+			MessageData:     qerr.Err.Error(),
+		}
+	}
+	return serr
+}
+
+// searchFilter extracts the filter for search results from the request, from
+// its "kind" and "deps" parameters.
+func searchFilter(r *http.Request) (postgres.SearchFilter, error) {
+	kind, err := searchKind(r)
+	if err != nil {
+		return postgres.SearchFilter{}, err
+	}
+	stdlibOnly, err := searchStdlibOnly(r)
+	if err != nil {
+		return postgres.SearchFilter{}, err
+	}
+	filter := postgres.SearchFilter{Kind: kind, StdlibOnly: stdlibOnly}
+	if _, sig, ok := searchquery.SplitSignature(searchQuery(r)); ok {
+		filter.Signature, err = fetch.NormalizeSignature(sig)
+		if err != nil {
+			return postgres.SearchFilter{}, err
+		}
+	}
+	return filter, nil
+}
+
+// searchKind extracts the package kind to filter search results by from the
+// request. It returns the empty kind if results should not be filtered.
+func searchKind(r *http.Request) (internal.PackageKind, error) {
+	switch k := internal.PackageKind(r.FormValue("kind")); k {
+	case "", internal.PackageKindCommand, internal.PackageKindLibrary:
+		return k, nil
+	default:
+		return "", fmt.Errorf("unknown package kind %q: %w", k, derrors.InvalidArgument)
+	}
+}
+
+// searchStdlibOnly reports whether the request restricts search results to
+// packages that import only standard library packages, with "deps=stdlib".
+func searchStdlibOnly(r *http.Request) (bool, error) {
+	switch d := r.FormValue("deps"); d {
+	case "":
+		return false, nil
+	case "stdlib":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown dependency filter %q: %w", d, derrors.InvalidArgument)
+	}
 }
@@ -6,6 +6,11 @@ package frontend
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -92,6 +97,7 @@ func TestFetchSearchPage(t *testing.T) {
 						PackagePath:    moduleBar.LegacyPackages[0].Path,
 						ModulePath:     moduleBar.ModulePath,
 						Synopsis:       moduleBar.LegacyPackages[0].Synopsis,
+						Version:        moduleBar.Version,
 						DisplayVersion: moduleBar.Version,
 						Licenses:       []string{"MIT"},
 						CommitTime:     elapsedTime(moduleBar.CommitTime),
@@ -120,6 +126,7 @@ func TestFetchSearchPage(t *testing.T) {
 						PackagePath:    moduleFoo.LegacyPackages[0].Path,
 						ModulePath:     moduleFoo.ModulePath,
 						Synopsis:       moduleFoo.LegacyPackages[0].Synopsis,
+						Version:        moduleFoo.Version,
 						DisplayVersion: moduleFoo.Version,
 						Licenses:       []string{"MIT"},
 						CommitTime:     elapsedTime(moduleFoo.CommitTime),
@@ -138,7 +145,7 @@ func TestFetchSearchPage(t *testing.T) {
 				}
 			}
 
-			got, err := fetchSearchPage(ctx, testDB, tc.query, paginationParams{limit: 20, page: 1})
+			got, err := fetchSearchPage(ctx, testDB, tc.query, postgres.SearchFilter{}, paginationParams{limit: 20, page: 1})
 			if err != nil {
 				t.Fatalf("fetchSearchPage(db, %q): %v", tc.query, err)
 			}
@@ -147,6 +154,7 @@ func TestFetchSearchPage(t *testing.T) {
 				cmp.AllowUnexported(SearchPage{}, pagination{}),
 				cmpopts.IgnoreFields(licenses.Metadata{}, "FilePath"),
 				cmpopts.IgnoreFields(pagination{}, "Approximate"),
+				cmpopts.IgnoreFields(SearchResult{}, "Score"),
 			}
 			if diff := cmp.Diff(tc.wantSearchPage, got, opts...); diff != "" {
 				t.Errorf("fetchSearchPage(db, %q) mismatch (-want +got):\n%s", tc.query, diff)
@@ -227,3 +235,73 @@ func testSearchRequestRedirectPath(t *testing.T, experimentNames ...string) {
 		})
 	}
 }
+
+func TestSearchQuery(t *testing.T) {
+	for _, test := range []struct {
+		q, want string
+	}{
+		{"", ""},
+		{"  \t ", ""},
+		{"foo", "foo"},
+		{"  foo  bar\t\nbaz ", "foo bar baz"},
+	} {
+		r := httptest.NewRequest("GET", "/search?q="+url.QueryEscape(test.q), nil)
+		if got := searchQuery(r); got != test.want {
+			t.Errorf("searchQuery(%q) = %q, want %q", test.q, got, test.want)
+		}
+	}
+}
+
+func TestSearchStdlibOnly(t *testing.T) {
+	for _, test := range []struct {
+		deps    string
+		want    bool
+		wantErr bool
+	}{
+		{"", false, false},
+		{"stdlib", true, false},
+		{"none", false, true},
+	} {
+		r := httptest.NewRequest("GET", "/search?q=foo&deps="+test.deps, nil)
+		got, err := searchStdlibOnly(r)
+		if (err != nil) != test.wantErr || got != test.want {
+			t.Errorf("searchStdlibOnly(deps=%q) = %t, %v; want %t, error %t", test.deps, got, err, test.want, test.wantErr)
+		}
+	}
+}
+
+func TestCheckSearchQuery(t *testing.T) {
+	const max = 40
+	for _, test := range []struct {
+		query string
+		ok    bool
+	}{
+		{"", true},
+		{strings.Repeat("a", max), true},
+		{strings.Repeat("é", max), true},
+		{strings.Repeat("a", max+1), false},
+		{`"a b"`, true},
+		{`a -b`, true},
+		{`a OR b`, true},
+		{`"a`, false},
+		{`-a`, false},
+		{`signature:func(io.Reader) error`, true},
+		{`json signature:func([]byte) error`, true},
+		{`-a signature:func()`, false},
+		{`signature:`, false},
+		{`signature:io.Reader`, false},
+		{`signature:func(`, false},
+	} {
+		err := checkSearchQuery(test.query, max)
+		if test.ok {
+			if err != nil {
+				t.Errorf("checkSearchQuery(%q, %d): got error %v, want nil", test.query, max, err)
+			}
+			continue
+		}
+		var serr *serverError
+		if !errors.As(err, &serr) || serr.status != http.StatusBadRequest {
+			t.Errorf("checkSearchQuery(%q, %d): got error %v, want status %d", test.query, max, err, http.StatusBadRequest)
+		}
+	}
+}
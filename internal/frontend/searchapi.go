@@ -0,0 +1,215 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/pagetoken"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/searchquery"
+)
+
+const (
+	// maxSearchAPILimit is the maximum number of results on a page of
+	// /api/search.
+	maxSearchAPILimit = 100
+
+	// searchAPICacheControl is the Cache-Control header of successful
+	// responses from /api/search. Search results change as modules are
+	// inserted, so they are only cached briefly.
+	searchAPICacheControl = "public, max-age=300"
+)
+
+// searchAPIResponse is the JSON response of /api/search.
+type searchAPIResponse struct {
+	Query      string
+	Results    []*searchAPIResult
+	Pagination searchAPIPagination
+}
+
+// searchAPIResult is a single result of /api/search.
+type searchAPIResult struct {
+	Path       string
+	ModulePath string
+	Synopsis   string
+	Version    string
+	// Licenses are the types of the licenses of the package, like "MIT".
+	Licenses []string
+	Score    float64
+}
+
+// searchAPIPagination describes the page of results of /api/search.
+type searchAPIPagination struct {
+	// Page is the number of this page, starting at 1, for display.
+	Page  int
+	Limit int
+	// ResultCount is the number of results on this page, and TotalCount the
+	// number of results of the query, which is an estimate if Approximate is
+	// true.
+	ResultCount int
+	TotalCount  int
+	Approximate bool
+	// NextPageToken is the value of the "pageToken" parameter for the next
+	// page, or empty if this is the last. It should be passed with the same
+	// query, filters and limit, and is otherwise opaque.
+	NextPageToken string
+}
+
+// searchAPIError is the JSON response of /api/search for a request that
+// fails.
+type searchAPIError struct {
+	Error string
+}
+
+// handleSearchAPI serves the results of a search as JSON, for clients like
+// single-page apps and editor plugins. It handles endpoint
+// /api/search?q=<query>, with the same optional "kind", "deps" and "limit"
+// parameters as /search. Instead of a page number, a page after the first is
+// requested with the "pageToken" parameter, set to the NextPageToken of the
+// previous page. Unlike /search, it does not redirect a query that is a
+// package path to its page, and it rejects invalid parameters with 400 Bad
+// Request instead of using their defaults.
+//
+// Its responses are not stored in the page cache, which keeps only response
+// bodies; instead, they are marked as cacheable for a short time.
+func (s *Server) handleSearchAPI(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.searchAPI(r)
+	if err != nil {
+		s.serveSearchAPIError(w, r, err)
+		return
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		s.serveSearchAPIError(w, r, fmt.Errorf("json.Marshal: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", searchAPICacheControl)
+	if _, err := w.Write(data); err != nil {
+		log.Errorf(r.Context(), "Error writing JSON for %q: %v", r.URL.Path, err)
+	}
+}
+
+// searchAPI returns the response of /api/search for r.
+func (s *Server) searchAPI(r *http.Request) (*searchAPIResponse, error) {
+	db, ok := s.ds.(*postgres.DB)
+	if !ok {
+		// The proxydatasource does not support search.
+		return nil, proxydatasourceNotSupportedErr()
+	}
+	query := searchQuery(r)
+	if query == "" {
+		return nil, &serverError{
+			status: http.StatusBadRequest,
+			err:    errors.New(`missing search query "q"`),
+		}
+	}
+	if err := checkSearchQuery(query, s.maxSearchQueryLength); err != nil {
+		return nil, err
+	}
+	filter, err := searchFilter(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.FormValue("page") != "" {
+		return nil, fmt.Errorf(`unsupported parameter "page"; use "pageToken": %w`, derrors.InvalidArgument)
+	}
+	if v := r.FormValue("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err != nil || n < 1 {
+			return nil, fmt.Errorf("%q is not a positive integer: %q: %w", "limit", v, derrors.InvalidArgument)
+		}
+	}
+	pageParams := newPaginationParams(r, defaultSearchLimit)
+	if pageParams.limit > maxSearchAPILimit {
+		return nil, fmt.Errorf("limit %d is more than the maximum of %d: %w", pageParams.limit, maxSearchAPILimit, derrors.InvalidArgument)
+	}
+	if token := r.FormValue("pageToken"); token != "" {
+		c, err := s.pageTokens.Decode(token)
+		if err != nil {
+			return nil, err
+		}
+		// Tokens are only issued for the offsets of pages, so an offset
+		// that is not at a page boundary is from a request with a
+		// different limit.
+		if c.Direction != pagetoken.Forward || c.Offset%pageParams.limit != 0 {
+			return nil, fmt.Errorf("page token is not for limit %d: %w", pageParams.limit, derrors.InvalidArgument)
+		}
+		pageParams.page = c.Offset/pageParams.limit + 1
+	}
+	text, _, _ := searchquery.SplitSignature(query)
+	page, err := fetchSearchPage(r.Context(), db, text, filter, pageParams)
+	if err != nil {
+		return nil, fmt.Errorf("fetchSearchPage(ctx, db, %q, %+v): %w", text, filter, err)
+	}
+
+	resp := &searchAPIResponse{
+		Query: query,
+		// An empty page of results is an empty JSON array, not null.
+		Results: []*searchAPIResult{},
+		Pagination: searchAPIPagination{
+			Page:        page.Pagination.Page,
+			Limit:       pageParams.limit,
+			ResultCount: page.Pagination.ResultCount,
+			TotalCount:  page.Pagination.TotalCount,
+			Approximate: page.Pagination.Approximate,
+		},
+	}
+	if next := page.Pagination.NextPage; next != 0 {
+		resp.Pagination.NextPageToken, err = s.pageTokens.Encode(pagetoken.Cursor{Offset: offset(next, pageParams.limit)})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, sr := range page.Results {
+		resp.Results = append(resp.Results, &searchAPIResult{
+			Path:       sr.PackagePath,
+			ModulePath: sr.ModulePath,
+			Synopsis:   sr.Synopsis,
+			Version:    sr.Version,
+			Licenses:   sr.Licenses,
+			Score:      sr.Score,
+		})
+	}
+	return resp, nil
+}
+
+// serveSearchAPIError serves err as a searchAPIError, with the status that
+// serveError would use. The details of internal errors are only logged.
+func (s *Server) serveSearchAPIError(w http.ResponseWriter, r *http.Request, err error) {
+	status := errorStatus(err)
+	var serr *serverError
+	if errors.As(err, &serr) {
+		status = serr.status
+	}
+	msg := http.StatusText(status)
+	if status == http.StatusInternalServerError {
+		log.Error(r.Context(), err)
+	} else {
+		log.Infof(r.Context(), "returning %d (%s) for error %v", status, msg, err)
+		if serr != nil && serr.err != nil {
+			msg = serr.err.Error()
+		} else if serr == nil {
+			msg = err.Error()
+		}
+	}
+	data, merr := json.Marshal(searchAPIError{Error: msg})
+	if merr != nil {
+		log.Errorf(r.Context(), "json.Marshal: %v", merr)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(data); err != nil {
+		log.Errorf(r.Context(), "Error writing JSON for %q: %v", r.URL.Path, err)
+	}
+}
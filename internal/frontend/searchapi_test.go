@@ -0,0 +1,184 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal/pagetoken"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestSearchAPI(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	m := sample.Module("github.com/mod/foo", "v1.0.0", "foo")
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewServer(ServerConfig{
+		DataSource:     testDB,
+		StaticPath:     "../../content/static",
+		ThirdPartyPath: "../../third_party",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	s.Install(mux.Handle, nil)
+	get := func(t *testing.T, url string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		if got, want := w.Header().Get("Content-Type"), "application/json"; got != want {
+			t.Errorf("%s: got Content-Type %q, want %q", url, got, want)
+		}
+		return w
+	}
+
+	t.Run("results", func(t *testing.T) {
+		const url = "/api/search?q=foo"
+		w := get(t, url)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: got status %d, want %d; body: %s", url, w.Code, http.StatusOK, w.Body)
+		}
+		if got, want := w.Header().Get("Cache-Control"), searchAPICacheControl; got != want {
+			t.Errorf("%s: got Cache-Control %q, want %q", url, got, want)
+		}
+		var got searchAPIResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		want := searchAPIResponse{
+			Query: "foo",
+			Results: []*searchAPIResult{{
+				Path:       "github.com/mod/foo/foo",
+				ModulePath: "github.com/mod/foo",
+				Synopsis:   sample.Synopsis,
+				Version:    "v1.0.0",
+				Licenses:   []string{"MIT"},
+			}},
+			Pagination: searchAPIPagination{
+				Page:        1,
+				Limit:       defaultSearchLimit,
+				ResultCount: 1,
+				TotalCount:  1,
+			},
+		}
+		if diff := cmp.Diff(want, got,
+			cmpopts.IgnoreFields(searchAPIResult{}, "Score"),
+			cmpopts.IgnoreFields(searchAPIPagination{}, "Approximate")); diff != "" {
+			t.Errorf("%s mismatch (-want +got):\n%s", url, diff)
+		}
+		if got.Results[0].Score <= 0 {
+			t.Errorf("%s: got score %f, want a positive score", url, got.Results[0].Score)
+		}
+	})
+
+	t.Run("page tokens", func(t *testing.T) {
+		m := sample.Module("github.com/mod/paged", "v1.0.0", "a", "b", "c")
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+		var (
+			got   []string
+			token string
+		)
+		for page := 1; ; page++ {
+			url := "/api/search?q=paged&limit=2"
+			if token != "" {
+				url += "&pageToken=" + token
+			}
+			w := get(t, url)
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s: got status %d, want %d; body: %s", url, w.Code, http.StatusOK, w.Body)
+			}
+			var resp searchAPIResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Pagination.Page != page {
+				t.Errorf("%s: got page %d, want %d", url, resp.Pagination.Page, page)
+			}
+			for _, r := range resp.Results {
+				got = append(got, r.Path)
+			}
+			token = resp.Pagination.NextPageToken
+			if token == "" {
+				break
+			}
+			if page > 2 {
+				t.Fatalf("%s: got a next page token after page %d", url, page)
+			}
+		}
+		sort.Strings(got)
+		want := []string{"github.com/mod/paged/a", "github.com/mod/paged/b", "github.com/mod/paged/c"}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("paged results mismatch (-want +got):\n%s", diff)
+		}
+
+		// A token for one limit is rejected with another.
+		token, err := s.pageTokens.Encode(pagetoken.Cursor{Offset: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w := get(t, "/api/search?q=paged&limit=3&pageToken="+token); w.Code != http.StatusBadRequest {
+			t.Errorf("token with a different limit: got status %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("no results", func(t *testing.T) {
+		const url = "/api/search?q=nothing"
+		w := get(t, url)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: got status %d, want %d", url, w.Code, http.StatusOK)
+		}
+		var got searchAPIResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Results == nil || len(got.Results) != 0 {
+			t.Errorf("%s: got results %v, want an empty array", url, got.Results)
+		}
+	})
+
+	for _, url := range []string{
+		"/api/search",
+		"/api/search?q=%20",
+		`/api/search?q=%22foo`,
+		"/api/search?q=-foo",
+		"/api/search?q=foo&kind=bogus",
+		"/api/search?q=foo&deps=bogus",
+		"/api/search?q=foo&page=2",
+		"/api/search?q=foo&pageToken=bogus",
+		"/api/search?q=foo&limit=-1",
+		"/api/search?q=foo&limit=1000",
+		"/api/search?q=signature%3Aio.Reader",
+	} {
+		t.Run(url, func(t *testing.T) {
+			w := get(t, url)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("got status %d, want %d", w.Code, http.StatusBadRequest)
+			}
+			var got searchAPIError
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Error == "" {
+				t.Error("got empty error message")
+			}
+		})
+	}
+}
@@ -11,7 +11,10 @@ import (
 	"fmt"
 	"html/template"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -19,12 +22,16 @@ import (
 
 	"github.com/go-redis/redis/v7"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/cache"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/graphql"
 	"golang.org/x/pkgsite/internal/licenses"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/middleware"
+	"golang.org/x/pkgsite/internal/pagetoken"
 	"golang.org/x/pkgsite/internal/queue"
+	"golang.org/x/pkgsite/internal/searchquery"
 )
 
 // Server can be installed to serve the go discovery frontend.
@@ -38,9 +45,35 @@ type Server struct {
 	staticPath           string
 	thirdPartyPath       string
 	templateDir          string
+	templateOverlayDir   string
 	devMode              bool
 	errorPage            []byte
 	appVersionLabel      string
+	popularCacheTTL      time.Duration
+	// maxSearchQueryLength is the maximum number of characters in a search
+	// query.
+	maxSearchQueryLength int
+	// baseURL, if non-nil, is the URL at which the server is reachable, for
+	// when it runs behind a reverse proxy at a different host or sub-path.
+	baseURL *url.URL
+	// readOnly reports whether the server must not write to the database or
+	// schedule fetches.
+	readOnly bool
+	// robotsTxt is served as /robots.txt.
+	robotsTxt []byte
+	// favicon, if non-nil, is served as /favicon.ico, and logo, if non-nil,
+	// replaces the Go logos on the pages.
+	favicon, logo *brandingImage
+	// assets holds the fingerprinted names of static files. It is nil in dev
+	// mode, where static files may change while the server runs.
+	assets *staticAssets
+	// fetchQuota, if non-nil, limits the rate at which each client can
+	// request fetches of modules that are not in the database.
+	fetchQuota middleware.Middleware
+	// graphql serves the GraphQL API at /graphql.
+	graphql http.Handler
+	// pageTokens signs the page tokens of /api/search.
+	pageTokens *pagetoken.Signer
 
 	mu        sync.Mutex // Protects all fields below
 	templates map[string]*template.Template
@@ -56,13 +89,87 @@ type ServerConfig struct {
 	ThirdPartyPath       string
 	DevMode              bool
 	AppVersionLabel      string
+	// PopularCacheTTL is how long the popular packages page is cached. If
+	// zero, longTTL is used.
+	PopularCacheTTL time.Duration
+	// MaxSearchQueryLength is the maximum number of characters in a search
+	// query, after surrounding whitespace is removed and runs of whitespace
+	// are collapsed. Longer queries are rejected with 400 Bad Request. If
+	// zero, searchquery.DefaultMaxLength is used.
+	MaxSearchQueryLength int
+	// BaseURL is the absolute URL at which the server is reachable, such as
+	// "https://example.com/pkgsite". It is used to construct absolute URLs
+	// and redirects. If empty, absolute URLs are derived from the request.
+	BaseURL string
+	// TemplateOverlayPath, if non-empty, is a directory of templates laid
+	// out like StaticPath/html that override the templates there by file
+	// name, so that the site can be customized without changing the base
+	// templates. For example, a helper template that defines "site_header"
+	// replaces the site header on every page. See parsePageTemplates.
+	//
+	// Inline scripts in overlay templates are blocked by the
+	// Content-Security-Policy, which only allows the hashes of the base
+	// templates' scripts.
+	TemplateOverlayPath string
+	// ReadOnly, if true, makes the server safe to run against a read replica.
+	// Endpoints that write to the database, directly or by scheduling a
+	// fetch, respond with 405 Method Not Allowed, and Queue may be nil.
+	ReadOnly bool
+	// RobotsTxtPath, if non-empty, is the path to a file to serve as
+	// /robots.txt. If empty, defaultRobotsTxt is served.
+	RobotsTxtPath string
+	// FaviconPath, if non-empty, is the path to an image file to serve as
+	// /favicon.ico, in place of the one in StaticPath. Its Content-Type is
+	// determined by its extension, or else by its contents.
+	FaviconPath string
+	// LogoPath, if non-empty, is the path to an image file that replaces the
+	// Go logos in the site header and on the home page, so that a deployment
+	// can be rebranded without changing the templates. It is served at
+	// logoURLPath, in the same way as FaviconPath.
+	LogoPath string
+	// FetchQuota, if non-nil, wraps the /fetch/ endpoint to limit how often
+	// each client can request that a missing module be fetched, usually with
+	// middleware.Quota. If nil, fetch requests are not rate-limited.
+	FetchQuota middleware.Middleware
+	// PageTokenKey is the key that the page tokens of /api/search are signed
+	// with. Servers that share a load balancer must use the same key. If nil,
+	// a random key is used, and the tokens are only valid for this server;
+	// that is only suitable for a server running alone, as in development.
+	PageTokenKey []byte
 }
 
 // NewServer creates a new Server for the given database and template directory.
 func NewServer(scfg ServerConfig) (_ *Server, err error) {
 	defer derrors.Wrap(&err, "NewServer(...)")
+	var assets *staticAssets
+	if !scfg.DevMode {
+		assets, err = loadStaticAssets(scfg.StaticPath)
+		if err != nil {
+			return nil, fmt.Errorf("error fingerprinting static files: %v", err)
+		}
+	}
+	var favicon, logo *brandingImage
+	if scfg.FaviconPath != "" {
+		favicon, err = readBrandingImage(scfg.FaviconPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading favicon: %v", err)
+		}
+	}
+	if scfg.LogoPath != "" {
+		logo, err = readBrandingImage(scfg.LogoPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading logo: %v", err)
+		}
+	}
+	pageTokenKey := scfg.PageTokenKey
+	if pageTokenKey == nil {
+		pageTokenKey, err = pagetoken.NewKey()
+		if err != nil {
+			return nil, fmt.Errorf("generating a page token key: %v", err)
+		}
+	}
 	templateDir := filepath.Join(scfg.StaticPath, "html")
-	ts, err := parsePageTemplates(templateDir)
+	ts, err := parsePageTemplates(templateDir, scfg.TemplateOverlayPath, assets, brandingLogoURL(logo))
 	if err != nil {
 		return nil, fmt.Errorf("error parsing templates: %v", err)
 	}
@@ -73,10 +180,47 @@ func NewServer(scfg ServerConfig) (_ *Server, err error) {
 		staticPath:           scfg.StaticPath,
 		thirdPartyPath:       scfg.ThirdPartyPath,
 		templateDir:          templateDir,
+		templateOverlayDir:   scfg.TemplateOverlayPath,
 		devMode:              scfg.DevMode,
 		templates:            ts,
 		taskIDChangeInterval: scfg.TaskIDChangeInterval,
 		appVersionLabel:      scfg.AppVersionLabel,
+		popularCacheTTL:      scfg.PopularCacheTTL,
+		maxSearchQueryLength: scfg.MaxSearchQueryLength,
+		readOnly:             scfg.ReadOnly,
+		robotsTxt:            []byte(defaultRobotsTxt),
+		assets:               assets,
+		favicon:              favicon,
+		logo:                 logo,
+		fetchQuota:           scfg.FetchQuota,
+		pageTokens:           pagetoken.NewSigner(pageTokenKey),
+	}
+	s.graphql, err = graphql.NewHandler(s.ds)
+	if err != nil {
+		return nil, err
+	}
+	if s.popularCacheTTL == 0 {
+		s.popularCacheTTL = longTTL
+	}
+	if s.maxSearchQueryLength == 0 {
+		s.maxSearchQueryLength = searchquery.DefaultMaxLength
+	}
+	if scfg.RobotsTxtPath != "" {
+		s.robotsTxt, err = ioutil.ReadFile(scfg.RobotsTxtPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading robots.txt: %v", err)
+		}
+	}
+	if scfg.BaseURL != "" {
+		u, err := url.Parse(scfg.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base URL %q: %v", scfg.BaseURL, err)
+		}
+		if !u.IsAbs() || u.Host == "" {
+			return nil, fmt.Errorf("base URL %q is not an absolute URL", scfg.BaseURL)
+		}
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		s.baseURL = u
 	}
 	errorPageBytes, err := s.renderErrorPage(context.Background(), http.StatusInternalServerError, "error.tmpl", nil)
 	if err != nil {
@@ -87,35 +231,100 @@ func NewServer(scfg ServerConfig) (_ *Server, err error) {
 }
 
 // Install registers server routes using the given handler registration func.
-func (s *Server) Install(handle func(string, http.Handler), redisClient *redis.Client) {
+// If pageCache is non-nil, rendered pages are cached in it.
+func (s *Server) Install(handle func(string, http.Handler), pageCache cache.Cache) {
 	var (
-		detailHandler http.Handler = s.errorHandler(s.serveDetails)
-		searchHandler http.Handler = s.errorHandler(s.serveSearch)
+		detailHandler   http.Handler = s.errorHandler(s.serveDetails)
+		fragmentHandler http.Handler = s.errorHandler(s.serveDetailsFragment)
+		searchHandler   http.Handler = s.errorHandler(s.serveSearch)
+		recentHandler   http.Handler = s.errorHandler(s.serveRecent)
+		popularHandler  http.Handler = s.errorHandler(s.servePopular)
 	)
-	if redisClient != nil {
-		detailHandler = middleware.Cache("details", redisClient, detailsTTL)(detailHandler)
-		searchHandler = middleware.Cache("search", redisClient, middleware.TTL(defaultTTL))(searchHandler)
-	}
-	handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir(s.staticPath))))
+	if pageCache != nil {
+		// The cache stores only response bodies, by URL, so serve the JSON
+		// form of details pages (see prefersJSON) without it.
+		uncachedDetailHandler := detailHandler
+		cachedDetailHandler := middleware.Cache("details", pageCache, detailsTTL)(detailHandler)
+		detailHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if prefersJSON(r) {
+				uncachedDetailHandler.ServeHTTP(w, r)
+				return
+			}
+			cachedDetailHandler.ServeHTTP(w, r)
+		})
+		fragmentHandler = middleware.Cache("fragment", pageCache, fragmentTTL)(fragmentHandler)
+		searchHandler = middleware.Cache("search", pageCache, middleware.TTL(defaultTTL))(searchHandler)
+		recentHandler = middleware.Cache("recent", pageCache, middleware.TTL(shortTTL))(recentHandler)
+		popularHandler = middleware.Cache("popular", pageCache, middleware.TTL(s.popularCacheTTL))(popularHandler)
+	}
+	handle("/static/", http.StripPrefix("/static/", s.assets.handler(s.staticPath)))
 	handle("/third_party/", http.StripPrefix("/third_party", http.FileServer(http.Dir(s.thirdPartyPath))))
-	handle("/favicon.ico", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, fmt.Sprintf("%s/img/favicon.ico", http.Dir(s.staticPath)))
-	}))
-	handle("/fetch/", http.HandlerFunc(s.fetchHandler))
+	handle("/favicon.ico", http.HandlerFunc(s.handleFavicon))
+	handle(logoURLPath, s.errorHandler(s.handleLogo))
+	// /fetch/ is the only endpoint that writes, by scheduling a fetch that
+	// inserts a module. All others are read-only.
+	var fetchHandler http.Handler = http.HandlerFunc(s.fetchHandler)
+	if s.fetchQuota != nil {
+		fetchHandler = s.fetchQuota(fetchHandler)
+	}
+	handle("/fetch/", s.writeHandler(fetchHandler))
 	handle("/pkg/", http.HandlerFunc(s.handlePackageDetailsRedirect))
 	handle("/search", searchHandler)
+	handle("/api/search", http.HandlerFunc(s.handleSearchAPI))
+	handle(importersAPIPathPrefix, http.HandlerFunc(s.handleImportersAPI))
+	handle("/recent", recentHandler)
+	handle("/popular", popularHandler)
 	handle("/search-help", s.staticPageHandler("search_help.tmpl", "Search Help - go.dev"))
 	handle("/license-policy", s.licensePolicyHandler())
+	handle("/license-bundle/", s.errorHandler(s.handleLicenseBundle))
+	handle("/package-doc/", s.errorHandler(s.handlePackageDoc))
+	handle("/feed/", s.errorHandler(s.handleModuleFeed))
 	handle("/about", http.RedirectHandler("https://go.dev/about", http.StatusFound))
 	handle("/", detailHandler)
+	handle(fragmentPathPrefix+"/", fragmentHandler)
 	handle("/autocomplete", http.HandlerFunc(s.handleAutoCompletion))
-	handle("/robots.txt", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(`User-agent: *
+	handle("/robots.txt", http.HandlerFunc(s.handleRobotsTxt))
+	handle("/graphql", s.errorHandler(s.serveGraphQL))
+}
+
+// serveGraphQL serves the GraphQL API, if the graphql experiment is active.
+func (s *Server) serveGraphQL(w http.ResponseWriter, r *http.Request) error {
+	if !experiment.IsActive(r.Context(), internal.ExperimentGraphQL) {
+		return &serverError{status: http.StatusNotFound}
+	}
+	s.graphql.ServeHTTP(w, r)
+	return nil
+}
+
+// defaultRobotsTxt is the crawl policy served as /robots.txt unless
+// ServerConfig.RobotsTxtPath is set. It allows details pages, and keeps
+// crawlers away from search, which is expensive, and from endpoints that
+// only serve parts of pages or write to the database.
+const defaultRobotsTxt = `User-agent: *
 Disallow: /search?*
 Disallow: /fetch/*
-`))
-	}))
+Disallow: /fragment/
+Disallow: /autocomplete
+Disallow: /license-bundle/
+Disallow: /graphql
+Disallow: /api/
+`
+
+// handleRobotsTxt serves the robots.txt file.
+func (s *Server) handleRobotsTxt(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(s.robotsTxt))
+}
+
+// writeHandler returns h, or if the server is read-only, a handler that
+// responds with 405 Method Not Allowed.
+func (s *Server) writeHandler(h http.Handler) http.Handler {
+	if !s.readOnly {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "This server is read-only.", http.StatusMethodNotAllowed)
+	})
 }
 
 const (
@@ -134,6 +343,12 @@ func detailsTTL(r *http.Request) time.Duration {
 	return detailsTTLForPath(r.Context(), r.URL.Path, r.FormValue("tab"))
 }
 
+// fragmentTTL assigns the cache TTL for details page fragment requests, which
+// is that of the page.
+func fragmentTTL(r *http.Request) time.Duration {
+	return detailsTTLForPath(r.Context(), strings.TrimPrefix(r.URL.Path, fragmentPathPrefix), r.FormValue("tab"))
+}
+
 func detailsTTLForPath(ctx context.Context, urlPath, tab string) time.Duration {
 	if urlPath == "/" {
 		return defaultTTL
@@ -190,6 +405,8 @@ type basePage struct {
 	GodocURL        string
 	DevMode         bool
 	AppVersionLabel string
+	// CanonicalURL is the absolute URL of the page.
+	CanonicalURL string
 }
 
 // licensePolicyPage is used to generate the static license policy page.
@@ -220,7 +437,40 @@ func (s *Server) newBasePage(r *http.Request, title string) basePage {
 		GodocURL:        middleware.GodocURLPlaceholder,
 		DevMode:         s.devMode,
 		AppVersionLabel: s.appVersionLabel,
+		CanonicalURL:    s.absoluteURL(r, r.URL.Path),
+	}
+}
+
+// absoluteURL returns the absolute URL of urlPath, a path on this server
+// beginning with "/". If the server has a base URL, urlPath is relative to it.
+// Otherwise, the scheme and host are taken from r; the page cache keys on them
+// too, so that pages for one host are never served to another.
+func (s *Server) absoluteURL(r *http.Request, urlPath string) string {
+	if s.baseURL != nil {
+		u := *s.baseURL
+		u.Path += urlPath
+		return u.String()
+	}
+	u := url.URL{Scheme: middleware.Scheme(r), Host: r.Host, Path: urlPath}
+	return u.String()
+}
+
+// redirect replies to r with a redirect to urlPath, a path on this server
+// beginning with "/" and optionally followed by a query. If the server has a
+// base URL, the redirect is to the absolute URL under it. Redirects for
+// details page fragments are to the corresponding fragment.
+func (s *Server) redirect(w http.ResponseWriter, r *http.Request, urlPath string, code int) {
+	if isFragmentRequest(r) {
+		urlPath = fragmentPathPrefix + urlPath
+	}
+	if s.baseURL != nil {
+		p, query := urlPath, ""
+		if i := strings.IndexByte(urlPath, '?'); i >= 0 {
+			p, query = urlPath[:i], urlPath[i:]
+		}
+		urlPath = s.absoluteURL(r, p) + query
 	}
+	http.Redirect(w, r, urlPath, code)
 }
 
 // errorPage contains fields for rendering a HTTP error page.
@@ -275,7 +525,12 @@ func (s *Server) serveError(w http.ResponseWriter, r *http.Request, err error) {
 	ctx := r.Context()
 	var serr *serverError
 	if !errors.As(err, &serr) {
-		serr = &serverError{status: http.StatusInternalServerError, err: err}
+		status := errorStatus(err)
+		if status == http.StatusInternalServerError && ctx.Err() == context.DeadlineExceeded {
+			// The error may not say so, but the request timed out.
+			status = http.StatusServiceUnavailable
+		}
+		serr = &serverError{status: status, err: err}
 	}
 	if serr.status == http.StatusInternalServerError {
 		log.Error(ctx, err)
@@ -285,6 +540,23 @@ func (s *Server) serveError(w http.ResponseWriter, r *http.Request, err error) {
 	s.serveErrorPage(w, r, serr.status, serr.epage)
 }
 
+// errorStatus returns the HTTP status for err, an error that is not a
+// *serverError, from its derrors classification. Classifications that do not
+// correspond to an HTTP error status, like derrors.BadModule, and unclassified
+// errors result in http.StatusInternalServerError. An error from a request
+// that timed out results in http.StatusServiceUnavailable.
+func errorStatus(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) {
+		// The request ran out of time, usually because of middleware.Timeout.
+		return http.StatusServiceUnavailable
+	}
+	status := derrors.ToHTTPStatus(err)
+	if status < 400 || http.StatusText(status) == "" {
+		return http.StatusInternalServerError
+	}
+	return status
+}
+
 func (s *Server) serveErrorPage(w http.ResponseWriter, r *http.Request, status int, page *errorPage) {
 	template := "error.tmpl"
 	if page == nil {
@@ -307,7 +579,19 @@ func (s *Server) serveErrorPage(w http.ResponseWriter, r *http.Request, status i
 	}
 }
 
-// renderErrorPage executes error.tmpl with the given errorPage
+// errorTemplateName returns the name of the template for an error page with
+// the given status: error_<status>.tmpl for the statuses that have their own
+// content, and error.tmpl for all others.
+func errorTemplateName(status int) string {
+	switch status {
+	case http.StatusNotFound, http.StatusGone, http.StatusInternalServerError, http.StatusServiceUnavailable:
+		return fmt.Sprintf("error_%d.tmpl", status)
+	}
+	return "error.tmpl"
+}
+
+// renderErrorPage executes the error template for status, or templateName if
+// it is not error.tmpl, with the given errorPage.
 func (s *Server) renderErrorPage(ctx context.Context, status int, templateName string, page *errorPage) ([]byte, error) {
 	statusInfo := fmt.Sprintf("%d %s", status, http.StatusText(status))
 	if page == nil {
@@ -322,8 +606,8 @@ func (s *Server) renderErrorPage(ctx context.Context, status int, templateName s
 	if page.HTMLTitle == "" {
 		page.HTMLTitle = statusInfo
 	}
-	if templateName == "" {
-		templateName = "error.tmpl"
+	if templateName == "" || templateName == "error.tmpl" {
+		templateName = errorTemplateName(status)
 	}
 
 	etmpl, err := s.findTemplate(templateName)
@@ -356,8 +640,19 @@ func (s *Server) servePage(ctx context.Context, w http.ResponseWriter, templateN
 	}
 }
 
-// renderPage executes the given templateName with page.
+// renderPage executes the given templateName with page. If page is a
+// validator with missing fields, renderPage fails in development mode, and
+// otherwise logs an error and renders the page anyway.
 func (s *Server) renderPage(ctx context.Context, templateName string, page interface{}) ([]byte, error) {
+	if v, ok := page.(validator); ok {
+		if err := v.validate(); err != nil {
+			err = fmt.Errorf("invalid data for template %q: %v", templateName, err)
+			if s.devMode {
+				return nil, err
+			}
+			log.Error(ctx, err)
+		}
+	}
 	tmpl, err := s.findTemplate(templateName)
 	if err != nil {
 		return nil, err
@@ -370,7 +665,7 @@ func (s *Server) findTemplate(templateName string) (*template.Template, error) {
 		s.mu.Lock()
 		defer s.mu.Unlock()
 		var err error
-		s.templates, err = parsePageTemplates(s.templateDir)
+		s.templates, err = parsePageTemplates(s.templateDir, s.templateOverlayDir, s.assets, brandingLogoURL(s.logo))
 		if err != nil {
 			return nil, fmt.Errorf("error parsing templates: %v", err)
 		}
@@ -396,13 +691,31 @@ func executeTemplate(ctx context.Context, templateName string, tmpl *template.Te
 //
 // Separate templates are used so that certain contextual functions (e.g.
 // templateName) can be bound independently for each page.
-func parsePageTemplates(base string) (map[string]*template.Template, error) {
+//
+// If overlay is non-empty, it is a directory laid out like base whose files
+// take precedence: each file in overlay is used instead of the file at the
+// same path in base, and files missing from overlay are read from base.
+// Helpers in overlay that are not in base are parsed after the others, so
+// they can redefine templates such as "site_header" and "site_footer".
+//
+// The "static" template function returns the URL of a static file, given its
+// name relative to the static directory, using its fingerprinted name from
+// assets. The "logo" template function is like "static", but returns logoURL
+// instead if it is non-empty, for the Go logos that a configured logo
+// replaces.
+func parsePageTemplates(base, overlay string, assets *staticAssets, logoURL string) (map[string]*template.Template, error) {
 	htmlSets := [][]string{
 		{"index.tmpl"},
 		{"error.tmpl"},
+		{"error_404.tmpl"},
+		{"error_410.tmpl"},
+		{"error_500.tmpl"},
+		{"error_503.tmpl"},
 		{"fetch.tmpl"},
 		{"search.tmpl"},
 		{"search_help.tmpl"},
+		{"recent.tmpl"},
+		{"popular.tmpl"},
 		{"license_policy.tmpl"},
 		{"overview.tmpl", "details.tmpl"},
 		{"subdirectories.tmpl", "details.tmpl"},
@@ -411,6 +724,8 @@ func parsePageTemplates(base string) (map[string]*template.Template, error) {
 		{"pkg_imports.tmpl", "details.tmpl"},
 		{"licenses.tmpl", "details.tmpl"},
 		{"versions.tmpl", "details.tmpl"},
+		{"vendored.tmpl", "details.tmpl"},
+		{"changelog.tmpl", "details.tmpl"},
 		{"not_implemented.tmpl", "details.tmpl"},
 	}
 
@@ -427,18 +742,30 @@ func parsePageTemplates(base string) (map[string]*template.Template, error) {
 			"commaseparate": func(s []string) string {
 				return strings.Join(s, ", ")
 			},
-		}).ParseFiles(filepath.Join(base, "base.tmpl"))
+			"absoluteTime": absoluteTime,
+			"rfc3339Time":  rfc3339Time,
+			"static":       assets.url,
+			"logo": func(name string) string {
+				if logoURL != "" {
+					return logoURL
+				}
+				return assets.url(name)
+			},
+		}).ParseFiles(templateFile(base, overlay, "base.tmpl"))
 		if err != nil {
 			return nil, fmt.Errorf("ParseFiles: %v", err)
 		}
-		helperGlob := filepath.Join(base, "helpers", "*.tmpl")
-		if _, err := t.ParseGlob(helperGlob); err != nil {
-			return nil, fmt.Errorf("ParseGlob(%q): %v", helperGlob, err)
+		helpers, err := helperFiles(base, overlay)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := t.ParseFiles(helpers...); err != nil {
+			return nil, fmt.Errorf("ParseFiles(%v): %v", helpers, err)
 		}
 
 		var files []string
 		for _, f := range set {
-			files = append(files, filepath.Join(base, "pages", f))
+			files = append(files, templateFile(base, overlay, filepath.Join("pages", f)))
 		}
 		if _, err := t.ParseFiles(files...); err != nil {
 			return nil, fmt.Errorf("ParseFiles(%v): %v", files, err)
@@ -447,3 +774,61 @@ func parsePageTemplates(base string) (map[string]*template.Template, error) {
 	}
 	return templates, nil
 }
+
+// templateFile returns the path of the template file at the relative path rel
+// in overlay if it exists there, and in base otherwise.
+func templateFile(base, overlay, rel string) string {
+	if overlay != "" {
+		p := filepath.Join(overlay, rel)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return filepath.Join(base, rel)
+}
+
+// helperFiles returns the paths of the helper templates in base, each
+// replaced by the file of the same name in overlay if there is one, followed
+// by the helpers that are only in overlay.
+func helperFiles(base, overlay string) ([]string, error) {
+	glob := func(dir string) ([]string, error) {
+		pattern := filepath.Join(dir, "helpers", "*.tmpl")
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("Glob(%q): %v", pattern, err)
+		}
+		return files, nil
+	}
+	baseFiles, err := glob(base)
+	if err != nil {
+		return nil, err
+	}
+	if len(baseFiles) == 0 {
+		return nil, fmt.Errorf("no helper templates in %q", base)
+	}
+	var overlayFiles []string
+	if overlay != "" {
+		if overlayFiles, err = glob(overlay); err != nil {
+			return nil, err
+		}
+	}
+	overlaid := map[string]string{}
+	for _, f := range overlayFiles {
+		overlaid[filepath.Base(f)] = f
+	}
+	var files []string
+	for _, f := range baseFiles {
+		name := filepath.Base(f)
+		if o, ok := overlaid[name]; ok {
+			f = o
+			delete(overlaid, name)
+		}
+		files = append(files, f)
+	}
+	for _, f := range overlayFiles {
+		if _, ok := overlaid[filepath.Base(f)]; ok {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
@@ -5,11 +5,17 @@
 package frontend
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
@@ -17,6 +23,7 @@ import (
 
 	"golang.org/x/net/html"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/experiment"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/middleware"
@@ -53,6 +60,8 @@ type testModule struct {
 	redistributable bool
 	versions        []string
 	packages        []testPackage
+	vendored        []*internal.VendoredModule
+	changelog       *internal.Changelog
 }
 
 type testPackage struct {
@@ -76,6 +85,14 @@ var testModules = []testModule{
 				doc:    `<a href="/pkg/io#Writer">io.Writer</a>`,
 			},
 		},
+		vendored: []*internal.VendoredModule{
+			{ModulePath: "golang.org/x/text", Version: "v0.3.0"},
+			{ModulePath: "example.com/local", Replacement: "../local"},
+		},
+		changelog: &internal.Changelog{
+			Filepath: "CHANGELOG.md",
+			Contents: "# Changes\n\n* Fixed the bug.<script>alert(1)</script>\n",
+		},
 	},
 	{
 		// A non-redistributable module.
@@ -146,6 +163,8 @@ func insertTestModules(ctx context.Context, t *testing.T, mods []testModule) {
 			m := sample.Module(mod.path, ver)
 			m.SourceInfo = source.NewGitHubInfo(sample.RepositoryURL, "", ver)
 			m.IsRedistributable = mod.redistributable
+			m.VendoredModules = mod.vendored
+			m.Changelog = mod.changelog
 			if !m.IsRedistributable {
 				m.Licenses = nil
 			}
@@ -395,6 +414,126 @@ func testServer(t *testing.T, experimentNames ...string) {
 						href("/github.com/valid_module_name/foo"),
 						text("github.com/valid_module_name/foo")))),
 		},
+		{
+			name:           "search libraries",
+			urlPath:        fmt.Sprintf("/search?q=%s&kind=library", sample.PackageName),
+			wantStatusCode: http.StatusOK,
+			want:           in(".SearchResults-resultCount", text("2 results")),
+		},
+		{
+			name:           "search commands",
+			urlPath:        fmt.Sprintf("/search?q=%s&kind=command", sample.PackageName),
+			wantStatusCode: http.StatusOK,
+			want:           in(".SearchResults-emptyContentMessage", text("No results found.")),
+		},
+		{
+			name:           "search unknown kind",
+			urlPath:        fmt.Sprintf("/search?q=%s&kind=plugin", sample.PackageName),
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "search stdlib only",
+			urlPath:        fmt.Sprintf("/search?q=%s&deps=stdlib", sample.PackageName),
+			wantStatusCode: http.StatusOK,
+			want:           in(".SearchResults-resultCount", text("2 results")),
+		},
+		{
+			name:           "search unknown deps",
+			urlPath:        fmt.Sprintf("/search?q=%s&deps=none", sample.PackageName),
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "search phrase",
+			urlPath:        "/search?q=" + url.QueryEscape(`"`+sample.PackageName+`"`),
+			wantStatusCode: http.StatusOK,
+			want:           in(".SearchResults-resultCount", text("2 results")),
+		},
+		{
+			name:           "search excluding a term",
+			urlPath:        "/search?q=" + url.QueryEscape(sample.PackageName+" -nosuchterm"),
+			wantStatusCode: http.StatusOK,
+			want:           in(".SearchResults-resultCount", text("2 results")),
+		},
+		{
+			name:           "search OR",
+			urlPath:        "/search?q=" + url.QueryEscape("nosuchterm OR "+sample.PackageName),
+			wantStatusCode: http.StatusOK,
+			want:           in(".SearchResults-resultCount", text("2 results")),
+		},
+		{
+			name:           "search malformed operators",
+			urlPath:        "/search?q=" + url.QueryEscape(`"`+sample.PackageName),
+			wantStatusCode: http.StatusBadRequest,
+			want:           in(".Error-message", text("quotation mark is missing its partner")),
+		},
+		{
+			name:           "recent",
+			urlPath:        "/recent",
+			wantStatusCode: http.StatusOK,
+			want: in("",
+				in(".SearchResults-header", text("Recently Published Modules")),
+				in(".SearchSnippet-header",
+					in("a",
+						href("/mod/github.com/incompatible"),
+						text("github.com/incompatible")))),
+		},
+		{
+			name:           "popular",
+			urlPath:        "/popular",
+			wantStatusCode: http.StatusOK,
+			want:           in(".SearchResults-header", text("Popular Packages")),
+		},
+		{
+			name:           "license bundle",
+			urlPath:        "/license-bundle/github.com/valid_module_name@v1.0.0",
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "license bundle as text",
+			urlPath:        "/license-bundle/github.com/valid_module_name@v1.0.0?format=text",
+			wantStatusCode: http.StatusOK,
+			want:           in("", text("Lorem Ipsum")),
+		},
+		{
+			name:           "license bundle for module without licenses",
+			urlPath:        "/license-bundle/github.com/non_redistributable@v1.0.0",
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "license bundle without version",
+			urlPath:        "/license-bundle/github.com/valid_module_name",
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "package doc",
+			urlPath:        "/package-doc/" + sample.PackagePath,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "package doc as HTML",
+			urlPath:        "/package-doc/" + sample.PackagePath + "@v1.0.0?format=html",
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "package doc in unknown format",
+			urlPath:        "/package-doc/" + sample.PackagePath + "?format=pdf",
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "package doc for unknown package",
+			urlPath:        "/package-doc/github.com/no_such_module/pkg",
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "module feed",
+			urlPath:        "/feed/github.com/valid_module_name",
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "module feed for unknown module",
+			urlPath:        "/feed/github.com/no_such_module",
+			wantStatusCode: http.StatusNotFound,
+		},
 		{
 			name:           "package default",
 			urlPath:        fmt.Sprintf("/%s?tab=doc", sample.PackagePath),
@@ -405,6 +544,7 @@ func testServer(t *testing.T, experimentNames ...string) {
 					in("a",
 						href("/github.com/valid_module_name@v1.0.0"),
 						text("github.com/valid_module_name"))),
+				in(`[data-test-id="DetailsHeader-install"] code`, text("^go get github.com/valid_module_name$")),
 				in(".Documentation", text(`This is the documentation HTML`))),
 		},
 		{
@@ -413,6 +553,18 @@ func testServer(t *testing.T, experimentNames ...string) {
 			wantStatusCode: http.StatusFound,
 			wantLocation:   "/github.com/valid_module_name/foo?tab=doc",
 		},
+		{
+			name:           "escaped mixed-case package path redirect",
+			urlPath:        "/github.com/!mixed/!case/pkg?tab=doc",
+			wantStatusCode: http.StatusMovedPermanently,
+			wantLocation:   "/github.com/Mixed/Case/pkg?tab=doc",
+		},
+		{
+			name:           "escaped mixed-case module path redirect",
+			urlPath:        "/mod/github.com/!mixed/!case@v1.0.0-!r!c1",
+			wantStatusCode: http.StatusMovedPermanently,
+			wantLocation:   "/mod/github.com/Mixed/Case@v1.0.0-RC1",
+		},
 		{
 			name: "package default nonredistributable",
 			// For a non-redistributable package, the "latest" route goes to the overview tab.
@@ -426,6 +578,7 @@ func testServer(t *testing.T, experimentNames ...string) {
 			wantStatusCode: http.StatusOK,
 			want: in("",
 				pagecheck.PackageHeader(pkgV100, versioned),
+				in(`[data-test-id="DetailsHeader-install"] code`, text("^go get github.com/valid_module_name@v1.0.0$")),
 				in(".Documentation", text(`This is the documentation HTML`))),
 		},
 		{
@@ -527,6 +680,22 @@ func testServer(t *testing.T, experimentNames ...string) {
 					in("li:nth-child(1) a", href("/fmt"), text("fmt")),
 					in("li:nth-child(2) a", href("/path/to/bar"), text("path/to/bar")))),
 		},
+		{
+			name:           "package at version imports tab fragment",
+			urlPath:        fmt.Sprintf("/fragment/%s@%s/%s?tab=imports", sample.ModulePath, sample.VersionString, sample.Suffix),
+			wantStatusCode: http.StatusOK,
+			want: in("",
+				htmlcheck.NotIn(".DetailsHeader"),
+				htmlcheck.NotIn("li.selected"),
+				in(".Imports-heading", text(`Standard Library Imports`)),
+				in(".Imports-list",
+					in("li:nth-child(1) a", href("/fmt"), text("fmt")))),
+		},
+		{
+			name:           "fragment without a path",
+			urlPath:        "/fragment/",
+			wantStatusCode: http.StatusNotFound,
+		},
 		{
 			name:           "package at version imported by tab",
 			urlPath:        fmt.Sprintf("/%s@%s/%s?tab=importedby", sample.ModulePath, sample.VersionString, sample.Suffix),
@@ -723,6 +892,49 @@ func testServer(t *testing.T, experimentNames ...string) {
 				pagecheck.ModuleHeader(mod, unversioned),
 				in(".Directories", text(`This is a package synopsis`))),
 		},
+		{
+			name:           "module vendored tab",
+			urlPath:        fmt.Sprintf("/mod/%s@%s?tab=vendored", sample.ModulePath, sample.VersionString),
+			wantStatusCode: http.StatusOK,
+			want: in("",
+				pagecheck.ModuleHeader(mod, versioned),
+				in(".Vendored-list",
+					in("li:nth-child(1) a",
+						href("/mod/example.com/local"),
+						text("^example.com/local$")),
+					in("li:nth-child(1) .Vendored-replacement", text("=> ../local")),
+					in("li:nth-child(2) a",
+						href("/mod/golang.org/x/text@v0.3.0"),
+						text("^golang.org/x/text v0.3.0$")))),
+		},
+		{
+			name:           "module vendored tab without vendored modules",
+			urlPath:        "/mod/github.com/pseudo?tab=vendored",
+			wantStatusCode: http.StatusOK,
+			want: in("",
+				pagecheck.ModuleHeader(mod2, unversioned),
+				in(".Vendored", text("This module does not vendor any modules."))),
+		},
+		{
+			name:           "module changelog tab",
+			urlPath:        fmt.Sprintf("/mod/%s@%s?tab=changelog", sample.ModulePath, sample.VersionString),
+			wantStatusCode: http.StatusOK,
+			want: in("",
+				pagecheck.ModuleHeader(mod, versioned),
+				in(".Changelog-content",
+					in("h1", text("^Changes$")),
+					in("li", text("^Fixed the bug.$"))),
+				htmlcheck.NotIn(".Changelog-content script"),
+				in(".Changelog-source", text(fmt.Sprintf("Source: %s@%s/CHANGELOG.md", sample.ModulePath, sample.VersionString)))),
+		},
+		{
+			name:           "module changelog tab without changelog",
+			urlPath:        "/mod/github.com/pseudo?tab=changelog",
+			wantStatusCode: http.StatusOK,
+			want: in("",
+				pagecheck.ModuleHeader(mod2, unversioned),
+				in(".Changelog", text("No changelog found for this module."))),
+		},
 		{
 			name:           "module at version overview tab",
 			urlPath:        fmt.Sprintf("/mod/%s@%s?tab=overview", sample.ModulePath, sample.VersionString),
@@ -785,7 +997,9 @@ func testServer(t *testing.T, experimentNames ...string) {
 			name:           "cmd go package page",
 			urlPath:        "/cmd/go?tab=doc",
 			wantStatusCode: http.StatusOK,
-			want:           pagecheck.PackageHeader(cmdGo, unversioned),
+			want: in("",
+				pagecheck.PackageHeader(cmdGo, unversioned),
+				in(`[data-test-id="DetailsHeader-install"] code`, text("go install cmd/go"))),
 		},
 		{
 			name:           "cmd go package page at version",
@@ -813,6 +1027,18 @@ func testServer(t *testing.T, experimentNames ...string) {
 				in("h3.Error-message", text("v1-2 is not a valid semantic version.")),
 				in("p.Error-message a", href(`/search?q=github.com%2fvalid_module_name%2ffoo`))),
 		},
+		{
+			name:           "malformed path",
+			urlPath:        "/github.com/valid_module_name/.foo",
+			wantStatusCode: http.StatusBadRequest,
+			want:           in("h3.Error-message", text("github.com/valid_module_name/.foo is not a valid path.")),
+		},
+		{
+			name:           "malformed module path",
+			urlPath:        "/valid_module_name@v1.0.0/foo",
+			wantStatusCode: http.StatusBadRequest,
+			want:           in("h3.Error-message", text("valid_module_name@v1.0.0/foo is not a valid path.")),
+		},
 		{
 			name:           "unknown version",
 			urlPath:        fmt.Sprintf("/%s@%s/%s", sample.ModulePath, "v99.99.0", sample.Suffix),
@@ -891,6 +1117,137 @@ func TestServerErrors(t *testing.T) {
 	}
 }
 
+func TestExcludedPaths(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	defer postgres.ResetTestDB(testDB, t)
+	const modulePath = "github.com/excluded/module"
+	if err := testDB.InsertModule(ctx, sample.Module(modulePath, sample.VersionString, "pkg")); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.InsertExcludedPrefix(ctx, modulePath, "user", "for testing"); err != nil {
+		t.Fatal(err)
+	}
+	_, handler, _ := newTestServer(t, nil)
+
+	// Excluded paths are not found, as if they were not in the database.
+	for _, path := range []string{
+		"/package-doc/" + modulePath + "/pkg",
+		"/feed/" + modulePath,
+		"/license-bundle/" + modulePath + "@" + sample.VersionString,
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%q: got status code = %d, want %d", path, w.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		want int
+	}{
+		{derrors.NotFound, http.StatusNotFound},
+		{derrors.Gone, http.StatusGone},
+		{derrors.InvalidArgument, http.StatusBadRequest},
+		{derrors.Excluded, http.StatusForbidden},
+		{derrors.HasIncompletePackages, http.StatusInternalServerError},
+		{derrors.DBModuleInsertInvalid, http.StatusInternalServerError},
+		{derrors.BadModule, http.StatusInternalServerError},
+		{derrors.AlternativeModule, http.StatusInternalServerError},
+		{derrors.ProxyError, http.StatusInternalServerError},
+		{derrors.Unknown, http.StatusInternalServerError},
+		{derrors.ReprocessStatusOK, http.StatusInternalServerError},
+		{derrors.ReprocessHasIncompletePackages, http.StatusInternalServerError},
+		{derrors.ReprocessLicenseNotAllowed, http.StatusInternalServerError},
+		{derrors.ReprocessBadModule, http.StatusInternalServerError},
+		{derrors.ReprocessAlternative, http.StatusInternalServerError},
+		{derrors.PackageBuildContextNotSupported, http.StatusInternalServerError},
+		{derrors.PackageMaxImportsLimitExceeded, http.StatusInternalServerError},
+		{derrors.PackageMaxFileSizeLimitExceeded, http.StatusInternalServerError},
+		{derrors.PackageDocumentationHTMLTooLarge, http.StatusInternalServerError},
+		{derrors.PackageInvalidContents, http.StatusInternalServerError},
+		{derrors.PackageBadImportPath, http.StatusInternalServerError},
+		{fmt.Errorf("GetPathInfo: %w", derrors.NotFound), http.StatusNotFound},
+		{errors.New("database is down"), http.StatusInternalServerError},
+	} {
+		if got := errorStatus(test.err); got != test.want {
+			t.Errorf("errorStatus(%v) = %d, want %d", test.err, got, test.want)
+		}
+	}
+}
+
+func TestServeErrorStatus(t *testing.T) {
+	s, err := NewServer(ServerConfig{
+		StaticPath:     "../../content/static",
+		ThirdPartyPath: "../../third_party",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	timedOut, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	for _, test := range []struct {
+		name     string
+		ctx      context.Context
+		err      error
+		want     int
+		wantText string
+	}{
+		{"invalid", context.Background(), fmt.Errorf("bad kind: %w", derrors.InvalidArgument), http.StatusBadRequest, "400 Bad Request"},
+		{"not found", context.Background(), fmt.Errorf("fetching page: %w", derrors.NotFound), http.StatusNotFound, "Search for a package instead"},
+		{"gone", context.Background(), &serverError{status: http.StatusGone}, http.StatusGone, "no longer available"},
+		{"internal", context.Background(), errors.New("database is down"), http.StatusInternalServerError, "Something went wrong on our end"},
+		{"deadline", context.Background(), fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusServiceUnavailable, "Please try again"},
+		{"timed out", timedOut, errors.New("database is down"), http.StatusServiceUnavailable, "Please try again"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.serveError(w, httptest.NewRequest("GET", "/", nil).WithContext(test.ctx), test.err)
+			if w.Code != test.want {
+				t.Errorf("serveError(%v): got status %d, want %d", test.err, w.Code, test.want)
+			}
+			if !strings.Contains(w.Body.String(), test.wantText) {
+				t.Errorf("serveError(%v): body does not contain %q", test.err, test.wantText)
+			}
+		})
+	}
+}
+
+func TestErrorPageOverlay(t *testing.T) {
+	overlay, err := ioutil.TempDir("", "overlay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(overlay)
+	if err := os.Mkdir(filepath.Join(overlay, "pages"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	contents := `{{define "main_content"}}<p>Ask the platform team.</p>{{end}}`
+	if err := ioutil.WriteFile(filepath.Join(overlay, "pages", "error_404.tmpl"), []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewServer(ServerConfig{
+		StaticPath:          "../../content/static",
+		ThirdPartyPath:      "../../third_party",
+		TemplateOverlayPath: overlay,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	s.serveError(w, httptest.NewRequest("GET", "/", nil), derrors.NotFound)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "Ask the platform team.") || strings.Contains(body, "Search for a package instead") {
+		t.Errorf("body does not use the overlay's error_404.tmpl:\n%s", body)
+	}
+}
+
 func mustRequest(urlPath string, t *testing.T) *http.Request {
 	t.Helper()
 	r, err := http.NewRequest(http.MethodGet, "http://localhost"+urlPath, nil)
@@ -923,6 +1280,399 @@ func TestDetailsTTL(t *testing.T) {
 	}
 }
 
+func TestBaseURL(t *testing.T) {
+	s, err := NewServer(ServerConfig{
+		StaticPath:     "../../content/static",
+		ThirdPartyPath: "../../third_party",
+		BaseURL:        "https://example.com/pkgsite/",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	s.Install(mux.Handle, nil)
+
+	for _, test := range []struct {
+		urlPath, wantLocation string
+	}{
+		{"/pkg/github.com/a/b", "https://example.com/pkgsite/github.com/a/b"},
+		{"/mod/std", "https://example.com/pkgsite/std"},
+		{"/github.com/!a/b?tab=doc", "https://example.com/pkgsite/github.com/A/b?tab=doc"},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", test.urlPath, nil))
+		if got := w.Header().Get("Location"); got != test.wantLocation {
+			t.Errorf("%s: got Location %q, want %q", test.urlPath, got, test.wantLocation)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/search-help", nil))
+	want := `<link rel="canonical" href="https://example.com/pkgsite/search-help">`
+	if !strings.Contains(w.Body.String(), want) {
+		t.Errorf("/search-help: body does not contain %s", want)
+	}
+
+	if _, err := NewServer(ServerConfig{StaticPath: "../../content/static", BaseURL: "/pkgsite"}); err == nil {
+		t.Error("NewServer with a relative base URL: got nil error, want error")
+	}
+}
+
+func TestAbsoluteURL(t *testing.T) {
+	s := &Server{}
+	r := httptest.NewRequest("GET", "/foo", nil)
+	r.Host = "pkg.example.com"
+	if got, want := s.absoluteURL(r, "/foo"), "http://pkg.example.com/foo"; got != want {
+		t.Errorf("absoluteURL = %q, want %q", got, want)
+	}
+	r.Header.Set("X-Forwarded-Proto", "https")
+	if got, want := s.absoluteURL(r, "/foo"), "https://pkg.example.com/foo"; got != want {
+		t.Errorf("absoluteURL with X-Forwarded-Proto = %q, want %q", got, want)
+	}
+}
+
+func TestRobotsTxt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "robots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	const custom = "User-agent: *\nDisallow: /\n"
+	customPath := filepath.Join(dir, "robots.txt")
+	if err := ioutil.WriteFile(customPath, []byte(custom), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name, path, want string
+	}{
+		{"default", "", defaultRobotsTxt},
+		{"custom", customPath, custom},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := NewServer(ServerConfig{
+				StaticPath:     "../../content/static",
+				ThirdPartyPath: "../../third_party",
+				RobotsTxtPath:  test.path,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			mux := http.NewServeMux()
+			s.Install(mux.Handle, nil)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", "/robots.txt", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := w.Body.String(); got != test.want {
+				t.Errorf("got body %q, want %q", got, test.want)
+			}
+		})
+	}
+
+	if _, err := NewServer(ServerConfig{
+		StaticPath:    "../../content/static",
+		RobotsTxtPath: filepath.Join(dir, "missing.txt"),
+	}); err == nil {
+		t.Error("NewServer with a missing robots.txt: got nil error, want error")
+	}
+}
+
+func TestBranding(t *testing.T) {
+	dir, err := ioutil.TempDir("", "branding")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// The PNG signature, which is enough for content sniffing.
+	const png = "\x89PNG\r\n\x1a\n"
+	faviconPath := filepath.Join(dir, "favicon.png")
+	if err := ioutil.WriteFile(faviconPath, []byte(png), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Without an extension, the Content-Type is detected from the contents.
+	logoPath := filepath.Join(dir, "logo")
+	if err := ioutil.WriteFile(logoPath, []byte(png), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewServer(ServerConfig{
+		StaticPath:     "../../content/static",
+		ThirdPartyPath: "../../third_party",
+		FaviconPath:    faviconPath,
+		LogoPath:       logoPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	s.Install(mux.Handle, nil)
+	for _, urlPath := range []string{"/favicon.ico", logoURLPath} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", urlPath, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: got status %d, want %d", urlPath, w.Code, http.StatusOK)
+		}
+		if got, want := w.Header().Get("Content-Type"), "image/png"; got != want {
+			t.Errorf("%s: got Content-Type %q, want %q", urlPath, got, want)
+		}
+		if got := w.Body.String(); got != png {
+			t.Errorf("%s: got body %q, want %q", urlPath, got, png)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if got, want := w.Body.String(), `src="`+logoURLPath+`"`; !strings.Contains(got, want) {
+		t.Errorf("home page does not contain %s", want)
+	}
+	if got := w.Body.String(); strings.Contains(got, "go-logo") {
+		t.Error("home page contains a Go logo, want only the configured logo")
+	}
+
+	// Without a logo, logoURLPath is not found.
+	s, err = NewServer(ServerConfig{
+		StaticPath:     "../../content/static",
+		ThirdPartyPath: "../../third_party",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux = http.NewServeMux()
+	s.Install(mux.Handle, nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", logoURLPath, nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("%s without a logo: got status %d, want %d", logoURLPath, w.Code, http.StatusNotFound)
+	}
+
+	if _, err := NewServer(ServerConfig{
+		StaticPath:  "../../content/static",
+		FaviconPath: filepath.Join(dir, "missing.ico"),
+	}); err == nil {
+		t.Error("NewServer with a missing favicon: got nil error, want error")
+	}
+}
+
+func TestDefaultFavicon(t *testing.T) {
+	const staticPath = "../../content/static"
+	want, err := ioutil.ReadFile(filepath.Join(staticPath, "img", "favicon.ico"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{staticPath, staticPath + "/"} {
+		t.Run(path, func(t *testing.T) {
+			s, err := NewServer(ServerConfig{
+				StaticPath:     path,
+				ThirdPartyPath: "../../third_party",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			mux := http.NewServeMux()
+			s.Install(mux.Handle, nil)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", "/favicon.ico", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+			}
+			if !bytes.Equal(w.Body.Bytes(), want) {
+				t.Errorf("got %d bytes, want the %d bytes of img/favicon.ico", w.Body.Len(), len(want))
+			}
+		})
+	}
+}
+
+func TestGraphQL(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	m := sample.Module(sample.ModulePath, sample.VersionString, sample.Suffix)
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	target := "/graphql?query=" + url.QueryEscape(fmt.Sprintf(`{ module(path: %q) { packages { path imports } } }`, sample.ModulePath))
+
+	for _, test := range []struct {
+		name        string
+		experiments []string
+		wantStatus  int
+	}{
+		{"without experiment", nil, http.StatusNotFound},
+		{"with experiment", []string{internal.ExperimentGraphQL}, http.StatusOK},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, handler, _ := newTestServer(t, nil, test.experiments...)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+			if w.Code != test.wantStatus {
+				t.Fatalf("got status %d, want %d", w.Code, test.wantStatus)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			want := fmt.Sprintf(`{"data":{"module":{"packages":[{"path":%q,"imports":["fmt","path/to/bar"]}]}}}`, sample.PackagePath)
+			if got := w.Body.String(); got != want {
+				t.Errorf("got body %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	s, err := NewServer(ServerConfig{
+		StaticPath:     "../../content/static",
+		ThirdPartyPath: "../../third_party",
+		ReadOnly:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	s.Install(mux.Handle, nil)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/fetch/github.com/a/b@v1.0.0", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("/fetch/: got status %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/search-help", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("/search-help: got status %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestDetailsContentNegotiation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	insertTestModules(ctx, t, testModules)
+	_, handler, _ := newTestServer(t, nil)
+
+	for _, test := range []struct {
+		accept   string
+		wantJSON bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", false},
+		{"application/json", true},
+		{"application/json, */*;q=0.8", true},
+	} {
+		t.Run(test.accept, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/github.com/valid_module_name/foo?tab=doc", nil)
+			if test.accept != "" {
+				r.Header.Set("Accept", test.accept)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("Vary = %q, want %q", got, "Accept")
+			}
+			if !test.wantJSON {
+				if ct := w.Header().Get("Content-Type"); strings.HasPrefix(ct, "application/json") {
+					t.Errorf("Content-Type = %q, want HTML", ct)
+				}
+				return
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+			var got struct {
+				Title, PageType, Tab string
+				Header               struct{ Path string }
+				Details              interface{}
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Title != "package foo" || got.PageType != "pkg" || got.Tab != "doc" {
+				t.Errorf("got Title %q, PageType %q, Tab %q; want %q, %q, %q",
+					got.Title, got.PageType, got.Tab, "package foo", "pkg", "doc")
+			}
+			if want := "github.com/valid_module_name/foo"; got.Header.Path != want {
+				t.Errorf("got Header.Path %q, want %q", got.Header.Path, want)
+			}
+			if got.Details == nil {
+				t.Error("got no Details")
+			}
+		})
+	}
+}
+
+func TestTemplateOverlay(t *testing.T) {
+	overlay, err := ioutil.TempDir("", "overlay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(overlay)
+	for name, contents := range map[string]string{
+		// A new helper overrides a block in the base template.
+		"helpers/_branding.tmpl": `{{define "site_header"}}<header>Example Corp</header>{{end}}`,
+		// A page with the same name overrides the base page.
+		"pages/search_help.tmpl": `{{define "main_content"}}<p>Ask the platform team.</p>{{end}}`,
+	} {
+		p := filepath.Join(overlay, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := NewServer(ServerConfig{
+		StaticPath:          "../../content/static",
+		ThirdPartyPath:      "../../third_party",
+		TemplateOverlayPath: overlay,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	s.Install(mux.Handle, nil)
+
+	for _, test := range []struct {
+		path           string
+		want, dontWant []string
+	}{
+		{
+			path:     "/search-help",
+			want:     []string{"Example Corp", "Ask the platform team.", `class="Site-footer"`},
+			dontWant: []string{"Black Lives Matter", "Search for an exact match"},
+		},
+		{
+			// Pages missing from the overlay are read from the base templates.
+			path:     "/license-policy",
+			want:     []string{"Example Corp", "License Disclaimer", `class="Site-footer"`},
+			dontWant: []string{"Black Lives Matter"},
+		},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d", test.path, w.Code, http.StatusOK)
+		}
+		body := w.Body.String()
+		for _, want := range test.want {
+			if !strings.Contains(body, want) {
+				t.Errorf("%s: body does not contain %q", test.path, want)
+			}
+		}
+		for _, dw := range test.dontWant {
+			if strings.Contains(body, dw) {
+				t.Errorf("%s: body contains %q", test.path, dw)
+			}
+		}
+	}
+}
+
 func TestTagRoute(t *testing.T) {
 	mustRequest := func(url string) *http.Request {
 		req, err := http.NewRequest("GET", url, nil)
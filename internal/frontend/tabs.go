@@ -6,12 +6,14 @@ package frontend
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
 
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/licenses"
 	"golang.org/x/pkgsite/internal/postgres"
 )
@@ -103,6 +105,17 @@ var (
 			DisplayName:       "Versions",
 			TemplateName:      "versions.tmpl",
 		},
+		{
+			Name:              "vendored",
+			AlwaysShowDetails: true,
+			DisplayName:       "Vendored",
+			TemplateName:      "vendored.tmpl",
+		},
+		{
+			Name:         "changelog",
+			DisplayName:  "Changelog",
+			TemplateName: "changelog.tmpl",
+		},
 		{
 			Name:         "licenses",
 			DisplayName:  "Licenses",
@@ -158,7 +171,7 @@ func fetchDetailsForPackage(ctx context.Context, r *http.Request, tab string, ds
 			// The proxydatasource does not support the imported by page.
 			return nil, proxydatasourceNotSupportedErr()
 		}
-		return fetchImportedByDetails(ctx, db, pkg.Path, pkg.ModulePath)
+		return fetchImportedByDetails(ctx, db, pkg.Path, pkg.ModulePath, newPaginationParams(r, defaultImportedByLimit))
 	case "licenses":
 		return fetchPackageLicensesDetails(ctx, ds, pkg.Path, pkg.ModulePath, pkg.Version)
 	case "overview":
@@ -186,10 +199,25 @@ func fetchDetailsForVersionedDirectory(ctx context.Context, r *http.Request, tab
 			// The proxydatasource does not support the imported by page.
 			return nil, proxydatasourceNotSupportedErr()
 		}
-		return fetchImportedByDetails(ctx, db, vdir.Path, vdir.ModulePath)
+		return fetchImportedByDetails(ctx, db, vdir.Path, vdir.ModulePath, newPaginationParams(r, defaultImportedByLimit))
 	case "licenses":
 		return fetchPackageLicensesDetails(ctx, ds, vdir.Path, vdir.ModulePath, vdir.Version)
 	case "overview":
+		if vdir.Readme == nil && vdir.Path != vdir.ModulePath {
+			// The package directory has no README of its own, so show the
+			// module's README instead.
+			mdir, err := ds.GetDirectoryNew(ctx, vdir.ModulePath, vdir.ModulePath, vdir.Version)
+			if err != nil && !errors.Is(err, derrors.NotFound) {
+				return nil, err
+			}
+			if mdir != nil {
+				// vdir may be shared with other requests by the read cache,
+				// so change a copy of it.
+				v := *vdir
+				v.Readme = mdir.Readme
+				vdir = &v
+			}
+		}
 		return fetchPackageOverviewDetailsNew(ctx, vdir, urlIsVersioned(r.URL)), nil
 	}
 	return nil, fmt.Errorf("BUG: unable to fetch details: unknown tab %q", tab)
@@ -209,6 +237,20 @@ func fetchDetailsForModule(ctx context.Context, r *http.Request, tab string, ds
 		return &LicensesDetails{Licenses: transformLicenses(mi.ModulePath, mi.Version, licenses)}, nil
 	case "versions":
 		return fetchModuleVersionsDetails(ctx, ds, &mi.ModuleInfo)
+	case "vendored":
+		db, ok := ds.(*postgres.DB)
+		if !ok {
+			// The proxydatasource does not support the vendored page.
+			return nil, proxydatasourceNotSupportedErr()
+		}
+		return fetchVendoredDetails(ctx, db, mi.ModulePath, mi.Version)
+	case "changelog":
+		db, ok := ds.(*postgres.DB)
+		if !ok {
+			// The proxydatasource does not support the changelog page.
+			return nil, proxydatasourceNotSupportedErr()
+		}
+		return fetchChangelogDetails(ctx, db, &mi.ModuleInfo)
 	case "overview":
 		readme := &internal.Readme{Filepath: mi.LegacyReadmeFilePath, Contents: mi.LegacyReadmeContents}
 		return constructOverviewDetails(ctx, &mi.ModuleInfo, readme, mi.IsRedistributable, urlIsVersioned(r.URL)), nil
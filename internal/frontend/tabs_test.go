@@ -0,0 +1,68 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"golang.org/x/pkgsite/internal"
+)
+
+// sharedDirectoryDataSource is a DataSource whose GetDirectoryNew always
+// returns the same VersionedDirectory, as a cached read does.
+type sharedDirectoryDataSource struct {
+	internal.DataSource
+	dir *internal.VersionedDirectory
+}
+
+func (ds *sharedDirectoryDataSource) GetDirectoryNew(ctx context.Context, dirPath, modulePath, version string) (*internal.VersionedDirectory, error) {
+	return ds.dir, nil
+}
+
+// TestOverviewDoesNotModifySharedDirectory checks that serving the overview
+// tab of a package without a README, which shows the module's README, does
+// not change the directory, which other requests may share. Run it with
+// -race.
+func TestOverviewDoesNotModifySharedDirectory(t *testing.T) {
+	ctx := context.Background()
+	modInfo := internal.ModuleInfo{ModulePath: "example.com/m", Version: "v1.0.0"}
+	ds := &sharedDirectoryDataSource{dir: &internal.VersionedDirectory{
+		ModuleInfo: modInfo,
+		DirectoryNew: internal.DirectoryNew{
+			DirectoryMeta: internal.DirectoryMeta{Path: "example.com/m"},
+			Readme:        &internal.Readme{Filepath: "README.md", Contents: "module readme"},
+		},
+	}}
+	vdir := &internal.VersionedDirectory{
+		ModuleInfo: modInfo,
+		DirectoryNew: internal.DirectoryNew{
+			DirectoryMeta: internal.DirectoryMeta{Path: "example.com/m/pkg", IsRedistributable: true},
+		},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := httptest.NewRequest("GET", "/example.com/m@v1.0.0/pkg?tab=overview", nil)
+			details, err := fetchDetailsForVersionedDirectory(ctx, r, "overview", ds, vdir)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if od := details.(*OverviewDetails); od.ReadMe == "" {
+				t.Error("got no README, want the module's")
+			}
+		}()
+	}
+	wg.Wait()
+	if vdir.Readme != nil {
+		t.Errorf("directory README changed to %+v, want nil", vdir.Readme)
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// VendoredDetails contains information for the modules vendored by a module.
+type VendoredDetails struct {
+	ModulePath string
+
+	// Modules are the vendored modules, sorted by module path.
+	Modules []*internal.VendoredModule
+}
+
+// fetchVendoredDetails fetches the modules vendored by the module version
+// specified by modulePath and version from the database and returns a
+// VendoredDetails.
+func fetchVendoredDetails(ctx context.Context, db *postgres.DB, modulePath, version string) (*VendoredDetails, error) {
+	mods, err := db.GetVendoredModules(ctx, modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	return &VendoredDetails{ModulePath: modulePath, Modules: mods}, nil
+}
@@ -214,14 +214,18 @@ func buildVersionDetails(currentModulePath string, modInfos []*internal.ModuleIn
 // For pseudo versions, formatVersion uses a short commit hash to identify the
 // version. i.e.
 //   formatVersion("v1.2.3-20190311183353-d8887717615a") = "v.1.2.3 (d888771)"
+//
+// A "+incompatible" suffix stays with the base version. i.e.
+//   formatVersion("v2.0.0-rc.1+incompatible") = "v2.0.0+incompatible (rc.1)"
 func formatVersion(v string) string {
 	vType, err := version.ParseType(v)
 	if err != nil {
 		log.Errorf(context.TODO(), "Error parsing version %q: %v", v, err)
 		return v
 	}
+	build := semver.Build(v)
 	pre := semver.Prerelease(v)
-	base := strings.TrimSuffix(v, pre)
+	base := strings.TrimSuffix(strings.TrimSuffix(v, build), pre) + build
 	pre = strings.TrimPrefix(pre, "-")
 	switch vType {
 	case version.TypePrerelease:
@@ -310,6 +310,9 @@ func TestFormatVersion(t *testing.T) {
 		{"v1.2.3-pre.0.20190311183353-d8887717615a", "v1.2.3 (d888771)"},
 		{"v1.2.4-0.20190311183353-d8887717615a", "v1.2.4 (d888771)"},
 		{"v1.0.0-20190311183353-d88877", "v1.0.0 (d88877)"},
+		{"v2.0.0+incompatible", "v2.0.0+incompatible"},
+		{"v2.0.0-rc.1+incompatible", "v2.0.0+incompatible (rc.1)"},
+		{"v2.0.1-0.20190311183353-d8887717615a+incompatible", "v2.0.1+incompatible (d888771)"},
 	}
 
 	for _, test := range tests {
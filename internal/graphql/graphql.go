@@ -0,0 +1,126 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package graphql serves a read-only GraphQL API for the data in an
+// internal.DataSource: modules, packages, versions, imports and licenses.
+//
+// Queries are limited in depth, in length, and in the number of list items
+// they may return, so that one request cannot make the server do an
+// unbounded amount of work.
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+const (
+	// maxDepth is the maximum depth of a query's selections.
+	maxDepth = 6
+
+	// maxQueryBytes is the maximum length of a request body, or of the
+	// query in a GET request.
+	maxQueryBytes = 8 << 10
+
+	// maxListItems is the maximum total number of list items that a query
+	// may return.
+	maxListItems = 10000
+
+	// maxParallelism is the maximum number of resolvers that run at once for
+	// a query.
+	maxParallelism = 10
+)
+
+// Handler serves GraphQL queries, as POST requests with a JSON body holding
+// "query", "operationName" and "variables", or as GET requests with the same
+// names as URL query parameters.
+type Handler struct {
+	ds     internal.DataSource
+	schema *graphql.Schema
+}
+
+// NewHandler returns a Handler that answers queries with data from ds.
+func NewHandler(ds internal.DataSource) (_ *Handler, err error) {
+	defer derrors.Wrap(&err, "graphql.NewHandler")
+	s, err := graphql.ParseSchema(schema, &queryResolver{ds: ds},
+		graphql.MaxDepth(maxDepth),
+		graphql.MaxParallelism(maxParallelism))
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{ds: ds, schema: s}, nil
+}
+
+// request is a GraphQL request.
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, status, err := parseRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	ctx := newRequestContext(r.Context(), h.ds, maxListItems)
+	resp := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	body, err := json.Marshal(resp)
+	if err != nil {
+		log.Errorf(ctx, "graphql: json.Marshal: %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(body); err != nil {
+		log.Errorf(ctx, "graphql: w.Write: %v", err)
+	}
+}
+
+// parseRequest returns the GraphQL request in r. If r is not a valid request,
+// it returns the HTTP status to respond with, and an error describing the
+// problem.
+func parseRequest(r *http.Request) (_ *request, status int, err error) {
+	var req request
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.FormValue("query")
+		req.OperationName = r.FormValue("operationName")
+		if v := r.FormValue("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				return nil, http.StatusBadRequest, fmt.Errorf("invalid variables: %v", err)
+			}
+		}
+	case http.MethodPost:
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxQueryBytes+1))
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("reading request body: %v", err)
+		}
+		if len(body) > maxQueryBytes {
+			return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("request body is longer than %d bytes", maxQueryBytes)
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err)
+		}
+	default:
+		return nil, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method)
+	}
+	if req.Query == "" {
+		return nil, http.StatusBadRequest, errors.New("missing query")
+	}
+	if len(req.Query) > maxQueryBytes {
+		return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("query is longer than %d bytes", maxQueryBytes)
+	}
+	return &req, http.StatusOK, nil
+}
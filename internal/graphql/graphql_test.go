@@ -0,0 +1,365 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/licenses"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+// fakeDataSource serves one module version, and counts the calls that read
+// imports.
+type fakeDataSource struct {
+	internal.DataSource // unimplemented methods panic
+
+	mi       *internal.LegacyModuleInfo
+	pkgs     []*internal.LegacyPackage
+	excluded []string // excluded path prefixes
+
+	mu                 sync.Mutex
+	getImportsCalls    int
+	moduleImportsCalls int
+}
+
+func newFakeDataSource() *fakeDataSource {
+	mi := sample.LegacyModuleInfo(sample.ModulePath, sample.VersionString)
+	foo := sample.LegacyPackage(sample.ModulePath, "foo")
+	foo.Imports = []string{"fmt", "path/to/bar"}
+	bar := sample.LegacyPackage(sample.ModulePath, "bar")
+	bar.Imports = []string{foo.Path}
+	return &fakeDataSource{mi: mi, pkgs: []*internal.LegacyPackage{bar, foo}}
+}
+
+func (ds *fakeDataSource) found(modulePath, version string) bool {
+	return (modulePath == ds.mi.ModulePath || modulePath == internal.UnknownModulePath) &&
+		(version == ds.mi.Version || version == internal.LatestVersion)
+}
+
+func (ds *fakeDataSource) LegacyGetModuleInfo(_ context.Context, modulePath, version string) (*internal.LegacyModuleInfo, error) {
+	if !ds.found(modulePath, version) {
+		return nil, derrors.NotFound
+	}
+	return ds.mi, nil
+}
+
+func (ds *fakeDataSource) LegacyGetPackage(_ context.Context, pkgPath, modulePath, version string) (*internal.LegacyVersionedPackage, error) {
+	if ds.found(modulePath, version) {
+		for _, p := range ds.pkgs {
+			if p.Path == pkgPath {
+				return &internal.LegacyVersionedPackage{LegacyPackage: *p, LegacyModuleInfo: *ds.mi}, nil
+			}
+		}
+	}
+	return nil, derrors.NotFound
+}
+
+func (ds *fakeDataSource) LegacyGetPackagesInModule(_ context.Context, modulePath, version string) ([]*internal.LegacyPackage, error) {
+	var pkgs []*internal.LegacyPackage
+	for _, p := range ds.pkgs {
+		// Like the postgres data source, do not read imports.
+		p2 := *p
+		p2.Imports = nil
+		pkgs = append(pkgs, &p2)
+	}
+	return pkgs, nil
+}
+
+func (ds *fakeDataSource) LegacyGetModuleLicenses(context.Context, string, string) ([]*licenses.License, error) {
+	return sample.Licenses, nil
+}
+
+func (ds *fakeDataSource) GetTaggedVersionsForModule(context.Context, string) ([]*internal.ModuleInfo, error) {
+	mi := ds.mi.ModuleInfo
+	return []*internal.ModuleInfo{&mi}, nil
+}
+
+func (ds *fakeDataSource) GetImports(_ context.Context, pkgPath, modulePath, version string) ([]string, error) {
+	ds.mu.Lock()
+	ds.getImportsCalls++
+	ds.mu.Unlock()
+	for _, p := range ds.pkgs {
+		if p.Path == pkgPath {
+			return p.Imports, nil
+		}
+	}
+	return nil, derrors.NotFound
+}
+
+func (ds *fakeDataSource) GetImportsInModule(_ context.Context, modulePath, version string) (map[string][]string, error) {
+	ds.mu.Lock()
+	ds.moduleImportsCalls++
+	ds.mu.Unlock()
+	m := map[string][]string{}
+	for _, p := range ds.pkgs {
+		m[p.Path] = p.Imports
+	}
+	return m, nil
+}
+
+func (ds *fakeDataSource) IsExcluded(_ context.Context, path string) (bool, error) {
+	for _, prefix := range ds.excluded {
+		if strings.HasPrefix(path, prefix) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// graphqlResponse is the JSON response to a GraphQL request.
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func query(t *testing.T, h http.Handler, q string) *graphqlResponse {
+	t.Helper()
+	body, err := json.Marshal(request{Query: q})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body))))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusOK, w.Body)
+	}
+	var resp graphqlResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return &resp
+}
+
+// checkData checks that resp has no errors, and that its data is equal to the
+// JSON in want.
+func checkData(t *testing.T, resp *graphqlResponse, want string) {
+	t.Helper()
+	if len(resp.Errors) > 0 {
+		t.Fatalf("got errors %+v", resp.Errors)
+	}
+	var got, w interface{}
+	if err := json.Unmarshal(resp.Data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(w, got); diff != "" {
+		t.Errorf("data mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModule(t *testing.T) {
+	ds := newFakeDataSource()
+	h, err := NewHandler(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := query(t, h, fmt.Sprintf(`{
+		module(path: %q) {
+			path
+			version
+			isRedistributable
+			hasGoMod
+			versions { version }
+			licenses { types filePath }
+			packages {
+				path
+				name
+				imports
+				licenses { types }
+			}
+		}
+	}`, sample.ModulePath))
+	checkData(t, resp, fmt.Sprintf(`{
+		"module": {
+			"path": %[1]q,
+			"version": %[2]q,
+			"isRedistributable": true,
+			"hasGoMod": true,
+			"versions": [{"version": %[2]q}],
+			"licenses": [{"types": ["MIT"], "filePath": "LICENSE"}],
+			"packages": [
+				{"path": "%[1]s/bar", "name": "bar", "imports": ["%[1]s/foo"], "licenses": [{"types": ["MIT"]}]},
+				{"path": "%[1]s/foo", "name": "foo", "imports": ["fmt", "path/to/bar"], "licenses": [{"types": ["MIT"]}]}
+			]
+		}
+	}`, sample.ModulePath, sample.VersionString))
+
+	// The imports of all packages were read at once.
+	if ds.moduleImportsCalls != 1 || ds.getImportsCalls != 0 {
+		t.Errorf("got %d calls to GetImportsInModule and %d to GetImports, want 1 and 0",
+			ds.moduleImportsCalls, ds.getImportsCalls)
+	}
+}
+
+func TestPackage(t *testing.T) {
+	ds := newFakeDataSource()
+	h, err := NewHandler(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkgPath := sample.ModulePath + "/foo"
+	resp := query(t, h, fmt.Sprintf(`{
+		package(path: %q) {
+			path
+			synopsis
+			modulePath
+			version
+			imports
+			module { path repositoryURL }
+		}
+	}`, pkgPath))
+	checkData(t, resp, fmt.Sprintf(`{
+		"package": {
+			"path": %q,
+			"synopsis": %q,
+			"modulePath": %q,
+			"version": %q,
+			"imports": ["fmt", "path/to/bar"],
+			"module": {"path": %[3]q, "repositoryURL": %[5]q}
+		}
+	}`, pkgPath, sample.Synopsis, sample.ModulePath, sample.VersionString, sample.RepositoryURL))
+}
+
+func TestNotFound(t *testing.T) {
+	h, err := NewHandler(newFakeDataSource())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := query(t, h, `{
+		module(path: "example.com/unknown") { path }
+		package(path: "example.com/unknown/pkg") { path }
+	}`)
+	checkData(t, resp, `{"module": null, "package": null}`)
+}
+
+func TestExcluded(t *testing.T) {
+	ds := newFakeDataSource()
+	h, err := NewHandler(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An excluded package is left out of its module.
+	ds.excluded = []string{sample.ModulePath + "/foo"}
+	resp := query(t, h, fmt.Sprintf(`{
+		module(path: %[1]q) { packages { path } }
+		package(path: "%[1]s/foo") { path }
+	}`, sample.ModulePath))
+	checkData(t, resp, fmt.Sprintf(`{
+		"module": {"packages": [{"path": "%s/bar"}]},
+		"package": null
+	}`, sample.ModulePath))
+
+	// An excluded module and its packages are not found.
+	ds.excluded = []string{sample.ModulePath}
+	resp = query(t, h, fmt.Sprintf(`{
+		module(path: %[1]q) { path }
+		package(path: "%[1]s/bar") { path }
+	}`, sample.ModulePath))
+	checkData(t, resp, `{"module": null, "package": null}`)
+}
+
+func TestLimits(t *testing.T) {
+	h, err := NewHandler(newFakeDataSource())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name, query, wantError string
+	}{
+		{
+			name:      "too deep",
+			query:     fmt.Sprintf(`{ module(path: %q) { packages { module { packages { module { packages { path } } } } } } }`, sample.ModulePath),
+			wantError: "exceeds max depth",
+		},
+		{
+			name:      "unknown field",
+			query:     fmt.Sprintf(`{ module(path: %q) { documentation } }`, sample.ModulePath),
+			wantError: "Cannot query field",
+		},
+		{
+			name:      "mutation",
+			query:     `mutation { deleteModule(path: "x") }`,
+			wantError: "no mutations",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			resp := query(t, h, test.query)
+			if len(resp.Errors) == 0 || !strings.Contains(resp.Errors[0].Message, test.wantError) {
+				t.Errorf("got errors %+v, want one containing %q", resp.Errors, test.wantError)
+			}
+		})
+	}
+}
+
+func TestListItemBudget(t *testing.T) {
+	ds := newFakeDataSource()
+	ctx := newRequestContext(context.Background(), ds, 3)
+	if _, err := (&moduleResolver{ds: ds, mi: ds.mi}).Packages(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// Two of the three items are spent, and licenses need one for each
+	// package.
+	p := &packageResolver{ds: ds, pkg: ds.pkgs[0], mi: ds.mi}
+	if _, err := p.Licenses(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Licenses(ctx); err != errTooComplex {
+		t.Errorf("got error %v, want errTooComplex", err)
+	}
+}
+
+func TestParseRequest(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantQuery  string
+	}{
+		{"post", "POST", "/graphql", `{"query": "{ a }", "variables": {"x": 1}}`, http.StatusOK, "{ a }"},
+		{"get", "GET", "/graphql?query=" + url.QueryEscape("{ a }") + "&variables=" + url.QueryEscape(`{"x": 1}`), "", http.StatusOK, "{ a }"},
+		{"missing query", "GET", "/graphql", "", http.StatusBadRequest, ""},
+		{"bad variables", "GET", "/graphql?query=a&variables=x", "", http.StatusBadRequest, ""},
+		{"bad body", "POST", "/graphql", "{", http.StatusBadRequest, ""},
+		{"body too long", "POST", "/graphql", `{"query": "` + strings.Repeat("a", maxQueryBytes) + `"}`, http.StatusRequestEntityTooLarge, ""},
+		{"query too long", "GET", "/graphql?query=" + strings.Repeat("a", maxQueryBytes+1), "", http.StatusRequestEntityTooLarge, ""},
+		{"bad method", "PUT", "/graphql", "", http.StatusMethodNotAllowed, ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+			req, status, err := parseRequest(r)
+			if status != test.wantStatus {
+				t.Fatalf("got status %d (error %v), want %d", status, err, test.wantStatus)
+			}
+			if status != http.StatusOK {
+				return
+			}
+			if req.Query != test.wantQuery {
+				t.Errorf("got query %q, want %q", req.Query, test.wantQuery)
+			}
+			if diff := cmp.Diff(map[string]interface{}{"x": float64(1)}, req.Variables); diff != "" {
+				t.Errorf("variables mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
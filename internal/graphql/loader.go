@@ -0,0 +1,80 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphql
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/pkgsite/internal"
+)
+
+// A moduleVersion identifies a module version in a loader.
+type moduleVersion struct {
+	modulePath, version string
+}
+
+// A loader loads values by key at most once per request. Resolvers for the
+// items of a list run concurrently, and ask for the same values. The first
+// caller for a key loads the value, and the others wait for its result, so
+// that, for example, a query for the imports of every package in a module
+// reads them with one call instead of one call per package.
+type loader struct {
+	load func(ctx context.Context, key moduleVersion) (interface{}, error)
+
+	mu      sync.Mutex
+	results map[moduleVersion]*loaderResult
+}
+
+type loaderResult struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+func newLoader(load func(ctx context.Context, key moduleVersion) (interface{}, error)) *loader {
+	return &loader{load: load, results: map[moduleVersion]*loaderResult{}}
+}
+
+// get returns the value for key, loading it if no other call has.
+func (l *loader) get(ctx context.Context, key moduleVersion) (interface{}, error) {
+	l.mu.Lock()
+	r, ok := l.results[key]
+	if !ok {
+		r = &loaderResult{done: make(chan struct{})}
+		l.results[key] = r
+	}
+	l.mu.Unlock()
+	if !ok {
+		r.value, r.err = l.load(ctx, key)
+		close(r.done)
+		return r.value, r.err
+	}
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// moduleImportsGetter is implemented by data sources, like the postgres one,
+// that can return the imports of all packages in a module version at once.
+type moduleImportsGetter interface {
+	GetImportsInModule(ctx context.Context, modulePath, version string) (map[string][]string, error)
+}
+
+// newImportsLoader returns a loader whose values are the imports of each
+// package in a module version, as a map[string][]string keyed by package
+// path. It returns nil if ds cannot load them all at once.
+func newImportsLoader(ds internal.DataSource) *loader {
+	g, ok := ds.(moduleImportsGetter)
+	if !ok {
+		return nil
+	}
+	return newLoader(func(ctx context.Context, key moduleVersion) (interface{}, error) {
+		return g.GetImportsInModule(ctx, key.modulePath, key.version)
+	})
+}
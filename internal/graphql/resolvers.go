@@ -0,0 +1,315 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphql
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/licenses"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// requestState is the state of a single query. It is stored in the context
+// passed to resolvers.
+type requestState struct {
+	// imports loads the imports of the packages in a module version. It is
+	// nil if the data source cannot load them all at once.
+	imports *loader
+
+	mu sync.Mutex
+	// budget is the number of list items that resolvers may still return. It
+	// bounds the work of a query, which can otherwise grow exponentially with
+	// its depth, as in a query for the packages of the module of each package
+	// of a module.
+	budget int
+}
+
+type requestStateKey struct{}
+
+func newRequestContext(ctx context.Context, ds internal.DataSource, budget int) context.Context {
+	return context.WithValue(ctx, requestStateKey{}, &requestState{
+		imports: newImportsLoader(ds),
+		budget:  budget,
+	})
+}
+
+func stateFromContext(ctx context.Context) *requestState {
+	return ctx.Value(requestStateKey{}).(*requestState)
+}
+
+// errTooComplex is returned by resolvers when a query asks for more list
+// items than it is allowed.
+var errTooComplex = errors.New("query is too complex: it asks for too many list items")
+
+// spend charges n list items to the query's budget. It returns errTooComplex
+// if the budget is exhausted.
+func spend(ctx context.Context, n int) error {
+	s := stateFromContext(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n > s.budget {
+		s.budget = 0
+		return errTooComplex
+	}
+	s.budget -= n
+	return nil
+}
+
+// publicError returns the error to report to the client for err, an error
+// from the data source. Internal errors are logged, and reported without
+// their details.
+func publicError(ctx context.Context, err error) error {
+	if errors.Is(err, derrors.InvalidArgument) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, errTooComplex) {
+		return err
+	}
+	log.Error(ctx, err)
+	return errors.New("internal error")
+}
+
+// pathExcluder is implemented by data sources, like the postgres one, that
+// exclude paths from pkgsite.
+type pathExcluder interface {
+	IsExcluded(ctx context.Context, path string) (bool, error)
+}
+
+// isExcluded reports whether ds excludes path. Excluded modules stay in the
+// database, so they must be hidden here, as the frontend hides them: as if
+// they did not exist.
+func isExcluded(ctx context.Context, ds internal.DataSource, path string) (bool, error) {
+	e, ok := ds.(pathExcluder)
+	if !ok {
+		return false, nil
+	}
+	return e.IsExcluded(ctx, path)
+}
+
+// queryResolver resolves the fields of the Query type.
+type queryResolver struct {
+	ds internal.DataSource
+}
+
+func (q *queryResolver) Module(ctx context.Context, args struct {
+	Path    string
+	Version *string
+}) (*moduleResolver, error) {
+	excluded, err := isExcluded(ctx, q.ds, args.Path)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+	if excluded {
+		return nil, nil
+	}
+	version := internal.LatestVersion
+	if args.Version != nil {
+		version = *args.Version
+	}
+	mi, err := q.ds.LegacyGetModuleInfo(ctx, args.Path, version)
+	if errors.Is(err, derrors.NotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+	return &moduleResolver{ds: q.ds, mi: mi}, nil
+}
+
+func (q *queryResolver) Package(ctx context.Context, args struct {
+	Path       string
+	ModulePath *string
+	Version    *string
+}) (*packageResolver, error) {
+	// A package of an excluded module is excluded too, because the module
+	// path is a prefix of the package path.
+	excluded, err := isExcluded(ctx, q.ds, args.Path)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+	if excluded {
+		return nil, nil
+	}
+	modulePath := internal.UnknownModulePath
+	if args.ModulePath != nil {
+		modulePath = *args.ModulePath
+	}
+	version := internal.LatestVersion
+	if args.Version != nil {
+		version = *args.Version
+	}
+	vp, err := q.ds.LegacyGetPackage(ctx, args.Path, modulePath, version)
+	if errors.Is(err, derrors.NotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+	return &packageResolver{ds: q.ds, pkg: &vp.LegacyPackage, mi: &vp.LegacyModuleInfo}, nil
+}
+
+// moduleResolver resolves the fields of the Module type.
+type moduleResolver struct {
+	ds internal.DataSource
+	mi *internal.LegacyModuleInfo
+}
+
+func (m *moduleResolver) Path() string             { return m.mi.ModulePath }
+func (m *moduleResolver) Version() string          { return m.mi.Version }
+func (m *moduleResolver) CommitTime() graphql.Time { return graphql.Time{Time: m.mi.CommitTime} }
+func (m *moduleResolver) IsRedistributable() bool  { return m.mi.IsRedistributable }
+func (m *moduleResolver) HasGoMod() bool           { return m.mi.HasGoMod }
+
+func (m *moduleResolver) RepositoryURL() *string {
+	if u := m.mi.SourceInfo.RepoURL(); u != "" {
+		return &u
+	}
+	return nil
+}
+
+func (m *moduleResolver) Versions(ctx context.Context) ([]*versionResolver, error) {
+	mis, err := m.ds.GetTaggedVersionsForModule(ctx, m.mi.ModulePath)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+	// As on the versions tab, list pseudo-versions only if there are no
+	// tagged versions.
+	if len(mis) == 0 {
+		mis, err = m.ds.GetPseudoVersionsForModule(ctx, m.mi.ModulePath)
+		if err != nil {
+			return nil, publicError(ctx, err)
+		}
+	}
+	if err := spend(ctx, len(mis)); err != nil {
+		return nil, err
+	}
+	var vs []*versionResolver
+	for _, mi := range mis {
+		vs = append(vs, &versionResolver{mi})
+	}
+	return vs, nil
+}
+
+func (m *moduleResolver) Packages(ctx context.Context) ([]*packageResolver, error) {
+	pkgs, err := m.ds.LegacyGetPackagesInModule(ctx, m.mi.ModulePath, m.mi.Version)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+	if err := spend(ctx, len(pkgs)); err != nil {
+		return nil, err
+	}
+	// Packages can be excluded even if their module is not.
+	var prs []*packageResolver
+	for _, p := range pkgs {
+		excluded, err := isExcluded(ctx, m.ds, p.Path)
+		if err != nil {
+			return nil, publicError(ctx, err)
+		}
+		if !excluded {
+			prs = append(prs, &packageResolver{ds: m.ds, pkg: p, mi: m.mi, inModuleList: true})
+		}
+	}
+	return prs, nil
+}
+
+func (m *moduleResolver) Licenses(ctx context.Context) ([]*licenseResolver, error) {
+	lics, err := m.ds.LegacyGetModuleLicenses(ctx, m.mi.ModulePath, m.mi.Version)
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+	var lms []*licenses.Metadata
+	for _, l := range lics {
+		lms = append(lms, l.Metadata)
+	}
+	return newLicenseResolvers(ctx, lms)
+}
+
+// versionResolver resolves the fields of the Version type.
+type versionResolver struct {
+	mi *internal.ModuleInfo
+}
+
+func (v *versionResolver) Version() string          { return v.mi.Version }
+func (v *versionResolver) CommitTime() graphql.Time { return graphql.Time{Time: v.mi.CommitTime} }
+
+// packageResolver resolves the fields of the Package type.
+type packageResolver struct {
+	ds  internal.DataSource
+	pkg *internal.LegacyPackage
+	mi  *internal.LegacyModuleInfo
+	// inModuleList reports whether the package was listed with the other
+	// packages of its module, whose imports are then likely to be asked for
+	// too.
+	inModuleList bool
+}
+
+func (p *packageResolver) Path() string            { return p.pkg.Path }
+func (p *packageResolver) Name() string            { return p.pkg.Name }
+func (p *packageResolver) Synopsis() string        { return p.pkg.Synopsis }
+func (p *packageResolver) IsRedistributable() bool { return p.pkg.IsRedistributable }
+func (p *packageResolver) ModulePath() string      { return p.mi.ModulePath }
+func (p *packageResolver) Version() string         { return p.mi.Version }
+
+func (p *packageResolver) Module() *moduleResolver {
+	return &moduleResolver{ds: p.ds, mi: p.mi}
+}
+
+func (p *packageResolver) Imports(ctx context.Context) ([]string, error) {
+	var (
+		imports []string
+		err     error
+	)
+	if l := stateFromContext(ctx).imports; p.inModuleList && l != nil {
+		var v interface{}
+		v, err = l.get(ctx, moduleVersion{p.mi.ModulePath, p.mi.Version})
+		if err == nil {
+			imports = v.(map[string][]string)[p.pkg.Path]
+		}
+	} else {
+		imports, err = p.ds.GetImports(ctx, p.pkg.Path, p.mi.ModulePath, p.mi.Version)
+	}
+	if err != nil {
+		return nil, publicError(ctx, err)
+	}
+	if err := spend(ctx, len(imports)); err != nil {
+		return nil, err
+	}
+	if imports == nil {
+		imports = []string{}
+	}
+	return imports, nil
+}
+
+func (p *packageResolver) Licenses(ctx context.Context) ([]*licenseResolver, error) {
+	return newLicenseResolvers(ctx, p.pkg.Licenses)
+}
+
+// licenseResolver resolves the fields of the License type.
+type licenseResolver struct {
+	lm *licenses.Metadata
+}
+
+func newLicenseResolvers(ctx context.Context, lms []*licenses.Metadata) ([]*licenseResolver, error) {
+	if err := spend(ctx, len(lms)); err != nil {
+		return nil, err
+	}
+	var lrs []*licenseResolver
+	for _, lm := range lms {
+		lrs = append(lrs, &licenseResolver{lm})
+	}
+	return lrs, nil
+}
+
+func (l *licenseResolver) Types() []string {
+	if l.lm.Types == nil {
+		return []string{}
+	}
+	return l.lm.Types
+}
+
+func (l *licenseResolver) FilePath() string { return l.lm.FilePath }
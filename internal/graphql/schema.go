@@ -0,0 +1,70 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphql
+
+// schema is the GraphQL schema served by Handler. It is read-only: there are
+// no mutations or subscriptions.
+const schema = `
+schema {
+	query: Query
+}
+
+# Time is a time in RFC 3339 format.
+scalar Time
+
+type Query {
+	# The module at the given version, or at its latest version if version is
+	# omitted. It is null if the module version is not known.
+	module(path: String!, version: String): Module
+
+	# The package at the given version of the module that contains it. If
+	# modulePath is omitted, the module is the one with the longest path that
+	# contains the package, and if version is omitted, it is that module's
+	# latest version. It is null if the package is not known.
+	package(path: String!, modulePath: String, version: String): Package
+}
+
+type Module {
+	path: String!
+	version: String!
+	commitTime: Time!
+	isRedistributable: Boolean!
+	hasGoMod: Boolean!
+	# The URL of the module's repository, if it is known.
+	repositoryURL: String
+	# The known versions of the module, latest first. Pseudo-versions are
+	# listed only if the module has no tagged versions.
+	versions: [Version!]!
+	# The packages in this version of the module, sorted by path.
+	packages: [Package!]!
+	# The licenses at the root of the module.
+	licenses: [License!]!
+}
+
+type Version {
+	version: String!
+	commitTime: Time!
+}
+
+type Package {
+	path: String!
+	name: String!
+	synopsis: String!
+	isRedistributable: Boolean!
+	modulePath: String!
+	version: String!
+	# The module version that contains the package.
+	module: Module!
+	# The import paths of the packages that this package imports, sorted.
+	imports: [String!]!
+	# The licenses that apply to the package.
+	licenses: [License!]!
+}
+
+type License {
+	types: [String!]!
+	filePath: String!
+}
+`
@@ -0,0 +1,59 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// codesForErrors maps the errors of the derrors and context packages to the
+// gRPC status codes that report them. The first match wins.
+var codesForErrors = []struct {
+	err  error
+	code codes.Code
+}{
+	{derrors.NotFound, codes.NotFound},
+	{derrors.InvalidArgument, codes.InvalidArgument},
+	{derrors.Excluded, codes.PermissionDenied},
+	{derrors.BadModule, codes.FailedPrecondition},
+	{derrors.AlternativeModule, codes.FailedPrecondition},
+	{derrors.ChecksumMismatch, codes.DataLoss},
+	{derrors.ProxyError, codes.Unavailable},
+	{context.DeadlineExceeded, codes.DeadlineExceeded},
+	{context.Canceled, codes.Canceled},
+}
+
+// UnaryErrorInterceptor is a grpc.UnaryServerInterceptor that converts the
+// errors returned by handlers into gRPC status errors, with a code determined
+// by the derrors value they wrap. Errors that match no code are logged and
+// reported as Internal, without their details.
+func UnaryErrorInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err == nil {
+		return resp, nil
+	}
+	return nil, statusError(ctx, info.FullMethod, err)
+}
+
+// statusError returns the gRPC status error for err, an error from method.
+func statusError(ctx context.Context, method string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	for _, c := range codesForErrors {
+		if errors.Is(err, c.err) {
+			return status.Error(c.code, err.Error())
+		}
+	}
+	log.Errorf(ctx, "%s: %v", method, err)
+	return status.Error(codes.Internal, "internal error")
+}
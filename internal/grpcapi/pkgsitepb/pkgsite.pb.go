@@ -0,0 +1,951 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: pkgsite.proto
+
+// Package pkgsite defines a read-only gRPC API for the module and package
+// data served by the frontend. Its messages mirror the types in
+// golang.org/x/pkgsite/internal.
+
+package pkgsitepb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// ModuleInfo mirrors internal.ModuleInfo.
+type ModuleInfo struct {
+	ModulePath string               `protobuf:"bytes,1,opt,name=module_path,json=modulePath,proto3" json:"module_path,omitempty"`
+	Version    string               `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	CommitTime *timestamp.Timestamp `protobuf:"bytes,3,opt,name=commit_time,json=commitTime,proto3" json:"commit_time,omitempty"`
+	// version_type is "release", "prerelease" or "pseudo".
+	VersionType       string `protobuf:"bytes,4,opt,name=version_type,json=versionType,proto3" json:"version_type,omitempty"`
+	IsRedistributable bool   `protobuf:"varint,5,opt,name=is_redistributable,json=isRedistributable,proto3" json:"is_redistributable,omitempty"`
+	HasGoMod          bool   `protobuf:"varint,6,opt,name=has_go_mod,json=hasGoMod,proto3" json:"has_go_mod,omitempty"`
+	// repository_url is empty if the module's repository is unknown.
+	RepositoryUrl        string   `protobuf:"bytes,7,opt,name=repository_url,json=repositoryUrl,proto3" json:"repository_url,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ModuleInfo) Reset()         { *m = ModuleInfo{} }
+func (m *ModuleInfo) String() string { return proto.CompactTextString(m) }
+func (*ModuleInfo) ProtoMessage()    {}
+func (*ModuleInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84dcc56c5f6617e8, []int{0}
+}
+
+func (m *ModuleInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ModuleInfo.Unmarshal(m, b)
+}
+func (m *ModuleInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ModuleInfo.Marshal(b, m, deterministic)
+}
+func (m *ModuleInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ModuleInfo.Merge(m, src)
+}
+func (m *ModuleInfo) XXX_Size() int {
+	return xxx_messageInfo_ModuleInfo.Size(m)
+}
+func (m *ModuleInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_ModuleInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ModuleInfo proto.InternalMessageInfo
+
+func (m *ModuleInfo) GetModulePath() string {
+	if m != nil {
+		return m.ModulePath
+	}
+	return ""
+}
+
+func (m *ModuleInfo) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *ModuleInfo) GetCommitTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.CommitTime
+	}
+	return nil
+}
+
+func (m *ModuleInfo) GetVersionType() string {
+	if m != nil {
+		return m.VersionType
+	}
+	return ""
+}
+
+func (m *ModuleInfo) GetIsRedistributable() bool {
+	if m != nil {
+		return m.IsRedistributable
+	}
+	return false
+}
+
+func (m *ModuleInfo) GetHasGoMod() bool {
+	if m != nil {
+		return m.HasGoMod
+	}
+	return false
+}
+
+func (m *ModuleInfo) GetRepositoryUrl() string {
+	if m != nil {
+		return m.RepositoryUrl
+	}
+	return ""
+}
+
+// License holds the metadata of a license, as in licenses.Metadata.
+type License struct {
+	Types                []string `protobuf:"bytes,1,rep,name=types,proto3" json:"types,omitempty"`
+	FilePath             string   `protobuf:"bytes,2,opt,name=file_path,json=filePath,proto3" json:"file_path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *License) Reset()         { *m = License{} }
+func (m *License) String() string { return proto.CompactTextString(m) }
+func (*License) ProtoMessage()    {}
+func (*License) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84dcc56c5f6617e8, []int{1}
+}
+
+func (m *License) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_License.Unmarshal(m, b)
+}
+func (m *License) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_License.Marshal(b, m, deterministic)
+}
+func (m *License) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_License.Merge(m, src)
+}
+func (m *License) XXX_Size() int {
+	return xxx_messageInfo_License.Size(m)
+}
+func (m *License) XXX_DiscardUnknown() {
+	xxx_messageInfo_License.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_License proto.InternalMessageInfo
+
+func (m *License) GetTypes() []string {
+	if m != nil {
+		return m.Types
+	}
+	return nil
+}
+
+func (m *License) GetFilePath() string {
+	if m != nil {
+		return m.FilePath
+	}
+	return ""
+}
+
+// Package mirrors internal.LegacyVersionedPackage.
+type Package struct {
+	Path              string     `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Name              string     `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Synopsis          string     `protobuf:"bytes,3,opt,name=synopsis,proto3" json:"synopsis,omitempty"`
+	V1Path            string     `protobuf:"bytes,4,opt,name=v1_path,json=v1Path,proto3" json:"v1_path,omitempty"`
+	IsRedistributable bool       `protobuf:"varint,5,opt,name=is_redistributable,json=isRedistributable,proto3" json:"is_redistributable,omitempty"`
+	Licenses          []*License `protobuf:"bytes,6,rep,name=licenses,proto3" json:"licenses,omitempty"`
+	Imports           []string   `protobuf:"bytes,7,rep,name=imports,proto3" json:"imports,omitempty"`
+	// documentation_html is empty if the package is not redistributable.
+	DocumentationHtml    string      `protobuf:"bytes,8,opt,name=documentation_html,json=documentationHtml,proto3" json:"documentation_html,omitempty"`
+	Goos                 string      `protobuf:"bytes,9,opt,name=goos,proto3" json:"goos,omitempty"`
+	Goarch               string      `protobuf:"bytes,10,opt,name=goarch,proto3" json:"goarch,omitempty"`
+	Module               *ModuleInfo `protobuf:"bytes,11,opt,name=module,proto3" json:"module,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *Package) Reset()         { *m = Package{} }
+func (m *Package) String() string { return proto.CompactTextString(m) }
+func (*Package) ProtoMessage()    {}
+func (*Package) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84dcc56c5f6617e8, []int{2}
+}
+
+func (m *Package) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Package.Unmarshal(m, b)
+}
+func (m *Package) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Package.Marshal(b, m, deterministic)
+}
+func (m *Package) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Package.Merge(m, src)
+}
+func (m *Package) XXX_Size() int {
+	return xxx_messageInfo_Package.Size(m)
+}
+func (m *Package) XXX_DiscardUnknown() {
+	xxx_messageInfo_Package.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Package proto.InternalMessageInfo
+
+func (m *Package) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *Package) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Package) GetSynopsis() string {
+	if m != nil {
+		return m.Synopsis
+	}
+	return ""
+}
+
+func (m *Package) GetV1Path() string {
+	if m != nil {
+		return m.V1Path
+	}
+	return ""
+}
+
+func (m *Package) GetIsRedistributable() bool {
+	if m != nil {
+		return m.IsRedistributable
+	}
+	return false
+}
+
+func (m *Package) GetLicenses() []*License {
+	if m != nil {
+		return m.Licenses
+	}
+	return nil
+}
+
+func (m *Package) GetImports() []string {
+	if m != nil {
+		return m.Imports
+	}
+	return nil
+}
+
+func (m *Package) GetDocumentationHtml() string {
+	if m != nil {
+		return m.DocumentationHtml
+	}
+	return ""
+}
+
+func (m *Package) GetGoos() string {
+	if m != nil {
+		return m.Goos
+	}
+	return ""
+}
+
+func (m *Package) GetGoarch() string {
+	if m != nil {
+		return m.Goarch
+	}
+	return ""
+}
+
+func (m *Package) GetModule() *ModuleInfo {
+	if m != nil {
+		return m.Module
+	}
+	return nil
+}
+
+// SearchResult mirrors internal.SearchResult.
+type SearchResult struct {
+	Name                 string               `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	PackagePath          string               `protobuf:"bytes,2,opt,name=package_path,json=packagePath,proto3" json:"package_path,omitempty"`
+	ModulePath           string               `protobuf:"bytes,3,opt,name=module_path,json=modulePath,proto3" json:"module_path,omitempty"`
+	Version              string               `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	Synopsis             string               `protobuf:"bytes,5,opt,name=synopsis,proto3" json:"synopsis,omitempty"`
+	Licenses             []string             `protobuf:"bytes,6,rep,name=licenses,proto3" json:"licenses,omitempty"`
+	CommitTime           *timestamp.Timestamp `protobuf:"bytes,7,opt,name=commit_time,json=commitTime,proto3" json:"commit_time,omitempty"`
+	Score                float64              `protobuf:"fixed64,8,opt,name=score,proto3" json:"score,omitempty"`
+	NumImportedBy        uint64               `protobuf:"varint,9,opt,name=num_imported_by,json=numImportedBy,proto3" json:"num_imported_by,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *SearchResult) Reset()         { *m = SearchResult{} }
+func (m *SearchResult) String() string { return proto.CompactTextString(m) }
+func (*SearchResult) ProtoMessage()    {}
+func (*SearchResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84dcc56c5f6617e8, []int{3}
+}
+
+func (m *SearchResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SearchResult.Unmarshal(m, b)
+}
+func (m *SearchResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SearchResult.Marshal(b, m, deterministic)
+}
+func (m *SearchResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SearchResult.Merge(m, src)
+}
+func (m *SearchResult) XXX_Size() int {
+	return xxx_messageInfo_SearchResult.Size(m)
+}
+func (m *SearchResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_SearchResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SearchResult proto.InternalMessageInfo
+
+func (m *SearchResult) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *SearchResult) GetPackagePath() string {
+	if m != nil {
+		return m.PackagePath
+	}
+	return ""
+}
+
+func (m *SearchResult) GetModulePath() string {
+	if m != nil {
+		return m.ModulePath
+	}
+	return ""
+}
+
+func (m *SearchResult) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *SearchResult) GetSynopsis() string {
+	if m != nil {
+		return m.Synopsis
+	}
+	return ""
+}
+
+func (m *SearchResult) GetLicenses() []string {
+	if m != nil {
+		return m.Licenses
+	}
+	return nil
+}
+
+func (m *SearchResult) GetCommitTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.CommitTime
+	}
+	return nil
+}
+
+func (m *SearchResult) GetScore() float64 {
+	if m != nil {
+		return m.Score
+	}
+	return 0
+}
+
+func (m *SearchResult) GetNumImportedBy() uint64 {
+	if m != nil {
+		return m.NumImportedBy
+	}
+	return 0
+}
+
+type GetModuleInfoRequest struct {
+	ModulePath string `protobuf:"bytes,1,opt,name=module_path,json=modulePath,proto3" json:"module_path,omitempty"`
+	// version is a semantic version or "latest". If empty, it is "latest".
+	Version              string   `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetModuleInfoRequest) Reset()         { *m = GetModuleInfoRequest{} }
+func (m *GetModuleInfoRequest) String() string { return proto.CompactTextString(m) }
+func (*GetModuleInfoRequest) ProtoMessage()    {}
+func (*GetModuleInfoRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84dcc56c5f6617e8, []int{4}
+}
+
+func (m *GetModuleInfoRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetModuleInfoRequest.Unmarshal(m, b)
+}
+func (m *GetModuleInfoRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetModuleInfoRequest.Marshal(b, m, deterministic)
+}
+func (m *GetModuleInfoRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetModuleInfoRequest.Merge(m, src)
+}
+func (m *GetModuleInfoRequest) XXX_Size() int {
+	return xxx_messageInfo_GetModuleInfoRequest.Size(m)
+}
+func (m *GetModuleInfoRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetModuleInfoRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetModuleInfoRequest proto.InternalMessageInfo
+
+func (m *GetModuleInfoRequest) GetModulePath() string {
+	if m != nil {
+		return m.ModulePath
+	}
+	return ""
+}
+
+func (m *GetModuleInfoRequest) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+type GetPackageRequest struct {
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// module_path is the path of the module containing the package. If empty,
+	// it is the module with the longest path that contains the package.
+	ModulePath string `protobuf:"bytes,2,opt,name=module_path,json=modulePath,proto3" json:"module_path,omitempty"`
+	// version is a semantic version or "latest". If empty, it is "latest".
+	Version              string   `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetPackageRequest) Reset()         { *m = GetPackageRequest{} }
+func (m *GetPackageRequest) String() string { return proto.CompactTextString(m) }
+func (*GetPackageRequest) ProtoMessage()    {}
+func (*GetPackageRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84dcc56c5f6617e8, []int{5}
+}
+
+func (m *GetPackageRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetPackageRequest.Unmarshal(m, b)
+}
+func (m *GetPackageRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetPackageRequest.Marshal(b, m, deterministic)
+}
+func (m *GetPackageRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetPackageRequest.Merge(m, src)
+}
+func (m *GetPackageRequest) XXX_Size() int {
+	return xxx_messageInfo_GetPackageRequest.Size(m)
+}
+func (m *GetPackageRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetPackageRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetPackageRequest proto.InternalMessageInfo
+
+func (m *GetPackageRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *GetPackageRequest) GetModulePath() string {
+	if m != nil {
+		return m.ModulePath
+	}
+	return ""
+}
+
+func (m *GetPackageRequest) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+type SearchRequest struct {
+	Query string `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// limit is the maximum number of results to return. If zero, it is 10.
+	Limit                int32    `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset               int32    `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SearchRequest) Reset()         { *m = SearchRequest{} }
+func (m *SearchRequest) String() string { return proto.CompactTextString(m) }
+func (*SearchRequest) ProtoMessage()    {}
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84dcc56c5f6617e8, []int{6}
+}
+
+func (m *SearchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SearchRequest.Unmarshal(m, b)
+}
+func (m *SearchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SearchRequest.Marshal(b, m, deterministic)
+}
+func (m *SearchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SearchRequest.Merge(m, src)
+}
+func (m *SearchRequest) XXX_Size() int {
+	return xxx_messageInfo_SearchRequest.Size(m)
+}
+func (m *SearchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SearchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SearchRequest proto.InternalMessageInfo
+
+func (m *SearchRequest) GetQuery() string {
+	if m != nil {
+		return m.Query
+	}
+	return ""
+}
+
+func (m *SearchRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *SearchRequest) GetOffset() int32 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+type SearchResponse struct {
+	Results []*SearchResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	// total is the number of packages that match the query, which may be
+	// approximate.
+	Total                uint64   `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SearchResponse) Reset()         { *m = SearchResponse{} }
+func (m *SearchResponse) String() string { return proto.CompactTextString(m) }
+func (*SearchResponse) ProtoMessage()    {}
+func (*SearchResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84dcc56c5f6617e8, []int{7}
+}
+
+func (m *SearchResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SearchResponse.Unmarshal(m, b)
+}
+func (m *SearchResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SearchResponse.Marshal(b, m, deterministic)
+}
+func (m *SearchResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SearchResponse.Merge(m, src)
+}
+func (m *SearchResponse) XXX_Size() int {
+	return xxx_messageInfo_SearchResponse.Size(m)
+}
+func (m *SearchResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SearchResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SearchResponse proto.InternalMessageInfo
+
+func (m *SearchResponse) GetResults() []*SearchResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+func (m *SearchResponse) GetTotal() uint64 {
+	if m != nil {
+		return m.Total
+	}
+	return 0
+}
+
+type ListVersionsRequest struct {
+	ModulePath           string   `protobuf:"bytes,1,opt,name=module_path,json=modulePath,proto3" json:"module_path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListVersionsRequest) Reset()         { *m = ListVersionsRequest{} }
+func (m *ListVersionsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListVersionsRequest) ProtoMessage()    {}
+func (*ListVersionsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84dcc56c5f6617e8, []int{8}
+}
+
+func (m *ListVersionsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListVersionsRequest.Unmarshal(m, b)
+}
+func (m *ListVersionsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListVersionsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListVersionsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListVersionsRequest.Merge(m, src)
+}
+func (m *ListVersionsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListVersionsRequest.Size(m)
+}
+func (m *ListVersionsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListVersionsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListVersionsRequest proto.InternalMessageInfo
+
+func (m *ListVersionsRequest) GetModulePath() string {
+	if m != nil {
+		return m.ModulePath
+	}
+	return ""
+}
+
+type ListVersionsResponse struct {
+	// versions holds the module's tagged versions, or its pseudo-versions if
+	// it has no tagged versions, newest first.
+	Versions             []*ModuleInfo `protobuf:"bytes,1,rep,name=versions,proto3" json:"versions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *ListVersionsResponse) Reset()         { *m = ListVersionsResponse{} }
+func (m *ListVersionsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListVersionsResponse) ProtoMessage()    {}
+func (*ListVersionsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_84dcc56c5f6617e8, []int{9}
+}
+
+func (m *ListVersionsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListVersionsResponse.Unmarshal(m, b)
+}
+func (m *ListVersionsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListVersionsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListVersionsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListVersionsResponse.Merge(m, src)
+}
+func (m *ListVersionsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListVersionsResponse.Size(m)
+}
+func (m *ListVersionsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListVersionsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListVersionsResponse proto.InternalMessageInfo
+
+func (m *ListVersionsResponse) GetVersions() []*ModuleInfo {
+	if m != nil {
+		return m.Versions
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*ModuleInfo)(nil), "pkgsite.ModuleInfo")
+	proto.RegisterType((*License)(nil), "pkgsite.License")
+	proto.RegisterType((*Package)(nil), "pkgsite.Package")
+	proto.RegisterType((*SearchResult)(nil), "pkgsite.SearchResult")
+	proto.RegisterType((*GetModuleInfoRequest)(nil), "pkgsite.GetModuleInfoRequest")
+	proto.RegisterType((*GetPackageRequest)(nil), "pkgsite.GetPackageRequest")
+	proto.RegisterType((*SearchRequest)(nil), "pkgsite.SearchRequest")
+	proto.RegisterType((*SearchResponse)(nil), "pkgsite.SearchResponse")
+	proto.RegisterType((*ListVersionsRequest)(nil), "pkgsite.ListVersionsRequest")
+	proto.RegisterType((*ListVersionsResponse)(nil), "pkgsite.ListVersionsResponse")
+}
+
+func init() {
+	proto.RegisterFile("pkgsite.proto", fileDescriptor_84dcc56c5f6617e8)
+}
+
+var fileDescriptor_84dcc56c5f6617e8 = []byte{
+	// 800 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0xdd, 0x6e, 0xdb, 0x36,
+	0x14, 0x86, 0x7f, 0x65, 0x1f, 0xd9, 0xd9, 0xc2, 0x78, 0x89, 0xa0, 0x25, 0x88, 0x23, 0x60, 0x83,
+	0x81, 0x6d, 0x16, 0xe2, 0x01, 0x03, 0xd6, 0xf6, 0x2a, 0xbd, 0x70, 0x83, 0x26, 0x40, 0xaa, 0xa4,
+	0x2d, 0xd0, 0x1b, 0x41, 0xb6, 0x69, 0x99, 0x88, 0x24, 0x2a, 0x24, 0x15, 0xd4, 0xaf, 0xd1, 0xdb,
+	0x3e, 0x46, 0x9f, 0xa9, 0xef, 0x51, 0x88, 0x94, 0x64, 0xd9, 0x71, 0x83, 0x06, 0xbd, 0xe3, 0x39,
+	0x3c, 0x3c, 0x3f, 0xdf, 0xf7, 0x1d, 0x42, 0x37, 0xbe, 0xf5, 0x39, 0x11, 0x78, 0x18, 0x33, 0x2a,
+	0x28, 0xd2, 0x32, 0xd3, 0x3c, 0xf6, 0x29, 0xf5, 0x03, 0x6c, 0x4b, 0xf7, 0x24, 0x99, 0xdb, 0x82,
+	0x84, 0x98, 0x0b, 0x2f, 0x8c, 0x55, 0xa4, 0xf5, 0xb9, 0x0a, 0x70, 0x49, 0x67, 0x49, 0x80, 0xcf,
+	0xa3, 0x39, 0x45, 0xc7, 0xa0, 0x87, 0xd2, 0x72, 0x63, 0x4f, 0x2c, 0x8c, 0x4a, 0xbf, 0x32, 0x68,
+	0x3b, 0xa0, 0x5c, 0x57, 0x9e, 0x58, 0x20, 0x03, 0xb4, 0x7b, 0xcc, 0x38, 0xa1, 0x91, 0x51, 0x95,
+	0x97, 0xb9, 0x89, 0x9e, 0x83, 0x3e, 0xa5, 0x61, 0x48, 0x84, 0x9b, 0xd6, 0x30, 0x6a, 0xfd, 0xca,
+	0x40, 0x1f, 0x99, 0x43, 0xd5, 0xc0, 0x30, 0x6f, 0x60, 0x78, 0x93, 0x37, 0xe0, 0x80, 0x0a, 0x4f,
+	0x1d, 0xe8, 0x04, 0x3a, 0x59, 0x1e, 0x57, 0x2c, 0x63, 0x6c, 0xd4, 0x65, 0x6e, 0x3d, 0xf3, 0xdd,
+	0x2c, 0x63, 0x8c, 0xfe, 0x01, 0x44, 0xb8, 0xcb, 0xf0, 0x8c, 0x70, 0xc1, 0xc8, 0x24, 0x11, 0xde,
+	0x24, 0xc0, 0x46, 0xa3, 0x5f, 0x19, 0xb4, 0x9c, 0x5d, 0xc2, 0x9d, 0xf5, 0x0b, 0x74, 0x08, 0xb0,
+	0xf0, 0xb8, 0xeb, 0x53, 0x37, 0xa4, 0x33, 0xa3, 0x29, 0xc3, 0x5a, 0x0b, 0x8f, 0x8f, 0xe9, 0x25,
+	0x9d, 0xa1, 0x3f, 0x60, 0x87, 0xe1, 0x98, 0x72, 0x22, 0x28, 0x5b, 0xba, 0x09, 0x0b, 0x0c, 0x4d,
+	0x56, 0xec, 0xae, 0xbc, 0x6f, 0x59, 0x60, 0xbd, 0x00, 0xed, 0x82, 0x4c, 0x71, 0xc4, 0x31, 0xea,
+	0x41, 0x23, 0xed, 0x8c, 0x1b, 0x95, 0x7e, 0x6d, 0xd0, 0x76, 0x94, 0x81, 0x7e, 0x87, 0xf6, 0x9c,
+	0xe4, 0x68, 0x29, 0x40, 0x5a, 0x73, 0xa2, 0xb0, 0xb2, 0xbe, 0x56, 0x41, 0xbb, 0xf2, 0xa6, 0xb7,
+	0x9e, 0x8f, 0x11, 0x82, 0x7a, 0x09, 0x51, 0x79, 0x4e, 0x7d, 0x91, 0x17, 0xe2, 0xec, 0x9d, 0x3c,
+	0x23, 0x13, 0x5a, 0x7c, 0x19, 0xd1, 0x98, 0x13, 0x2e, 0x21, 0x6c, 0x3b, 0x85, 0x8d, 0x0e, 0x40,
+	0xbb, 0x3f, 0x55, 0xa5, 0x14, 0x3e, 0xcd, 0xfb, 0x53, 0x49, 0xca, 0x13, 0xa1, 0xf9, 0x1b, 0x5a,
+	0x81, 0x9a, 0x8a, 0x1b, 0xcd, 0x7e, 0x6d, 0xa0, 0x8f, 0x7e, 0x1d, 0xe6, 0xfa, 0xc9, 0xc6, 0x75,
+	0x8a, 0x88, 0x94, 0x71, 0x12, 0xc6, 0x94, 0x09, 0x6e, 0x68, 0x72, 0xf4, 0xdc, 0x4c, 0xcb, 0xce,
+	0xe8, 0x34, 0x09, 0x71, 0x24, 0x3c, 0x91, 0x52, 0xb7, 0x10, 0x61, 0x60, 0xb4, 0x64, 0x6b, 0xbb,
+	0x6b, 0x37, 0xaf, 0x44, 0x18, 0xa4, 0xe3, 0xfa, 0x94, 0x72, 0xa3, 0xad, 0xc6, 0x4d, 0xcf, 0x68,
+	0x1f, 0x9a, 0x3e, 0xf5, 0xd8, 0x74, 0x61, 0x80, 0x9a, 0x48, 0x59, 0xe8, 0x2f, 0x68, 0x2a, 0xd1,
+	0x19, 0xba, 0xd4, 0xd1, 0x5e, 0xd1, 0xe0, 0x4a, 0xac, 0x4e, 0x16, 0x62, 0x7d, 0xa9, 0x42, 0xe7,
+	0x1a, 0xa7, 0xef, 0x1c, 0xcc, 0x93, 0x40, 0x14, 0xc0, 0x56, 0x4a, 0xc0, 0x9e, 0x40, 0x27, 0x56,
+	0x5c, 0x94, 0xc9, 0xd2, 0x33, 0x9f, 0x84, 0x71, 0x43, 0xfc, 0xb5, 0xc7, 0xc4, 0x5f, 0x5f, 0x17,
+	0x7f, 0x99, 0xb6, 0xc6, 0x06, 0x6d, 0xe6, 0x06, 0xdc, 0xed, 0x12, 0xb8, 0x1b, 0x4b, 0xa3, 0x3d,
+	0x69, 0x69, 0x7a, 0xd0, 0xe0, 0x53, 0xca, 0xb0, 0x84, 0xbc, 0xe2, 0x28, 0x03, 0xfd, 0x09, 0xbf,
+	0x44, 0x49, 0xe8, 0x2a, 0x92, 0xf0, 0xcc, 0x9d, 0x2c, 0x25, 0xe2, 0x75, 0xa7, 0x1b, 0x25, 0xe1,
+	0x79, 0xe6, 0x3d, 0x5b, 0x5a, 0x6f, 0xa0, 0x37, 0xc6, 0xa2, 0x04, 0x27, 0xbe, 0x4b, 0x30, 0x17,
+	0x3f, 0xf1, 0x05, 0x58, 0x13, 0xd8, 0x1d, 0x63, 0x91, 0x49, 0x3e, 0xcf, 0xb7, 0x4d, 0xf9, 0x1b,
+	0x35, 0xaa, 0x8f, 0xd5, 0xa8, 0xad, 0xd7, 0xb8, 0x86, 0x6e, 0xce, 0xb5, 0xca, 0xdf, 0x83, 0xc6,
+	0x5d, 0x82, 0xd9, 0x32, 0x2b, 0xa0, 0x8c, 0xd4, 0x1b, 0x90, 0x90, 0x08, 0x99, 0xbb, 0xe1, 0x28,
+	0x23, 0x95, 0x1b, 0x9d, 0xcf, 0x39, 0x16, 0x32, 0x6b, 0xc3, 0xc9, 0x2c, 0xeb, 0x3d, 0xec, 0x14,
+	0x02, 0x8a, 0x69, 0xba, 0xee, 0x36, 0x68, 0x4c, 0x8a, 0x49, 0x2d, 0xbc, 0x3e, 0xfa, 0xad, 0x50,
+	0x60, 0x59, 0x6a, 0x4e, 0x1e, 0x95, 0x16, 0x14, 0x54, 0x78, 0x81, 0x2c, 0x58, 0x77, 0x94, 0x61,
+	0xfd, 0x07, 0x7b, 0x17, 0x84, 0x8b, 0x77, 0xaa, 0x79, 0xfe, 0xa3, 0x18, 0x5b, 0x63, 0xe8, 0xad,
+	0xbf, 0x2b, 0xda, 0x6a, 0x65, 0x40, 0xe4, 0x7d, 0x6d, 0xdd, 0x8c, 0x22, 0x68, 0xf4, 0x29, 0xfd,
+	0x83, 0x54, 0x00, 0x7a, 0x09, 0xdd, 0x35, 0xc6, 0xd1, 0x51, 0xf1, 0x76, 0x9b, 0x12, 0xcc, 0x6d,
+	0xa9, 0xd1, 0x33, 0x80, 0x15, 0xc7, 0xc8, 0x2c, 0x67, 0x58, 0x27, 0xde, 0x5c, 0x7d, 0x2a, 0x79,
+	0xf4, 0xff, 0xd0, 0x54, 0xe0, 0xa1, 0xfd, 0x07, 0x68, 0xaa, 0x37, 0x07, 0x0f, 0xfc, 0xd9, 0xe0,
+	0xaf, 0xa1, 0x53, 0x06, 0x04, 0x1d, 0x96, 0x7e, 0xac, 0x07, 0xf8, 0x9a, 0x47, 0xdf, 0xb9, 0x55,
+	0xc9, 0xce, 0x4e, 0x3f, 0xd8, 0x3e, 0x0d, 0xbc, 0xc8, 0x1f, 0x52, 0xe6, 0xdb, 0x1f, 0xed, 0x2c,
+	0xd8, 0x26, 0x91, 0xc0, 0x2c, 0xf2, 0x02, 0xdb, 0x67, 0xf1, 0xd4, 0x8b, 0x49, 0x7e, 0x11, 0x4f,
+	0x26, 0x4d, 0xb9, 0x8b, 0xff, 0x7e, 0x1b, 0x00, 0x84, 0x1d, 0x59, 0x3a, 0x69, 0x07, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// PkgsiteClient is the client API for Pkgsite service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type PkgsiteClient interface {
+	// GetModuleInfo returns information about a module version.
+	GetModuleInfo(ctx context.Context, in *GetModuleInfoRequest, opts ...grpc.CallOption) (*ModuleInfo, error)
+	// GetPackage returns a package at a module version.
+	GetPackage(ctx context.Context, in *GetPackageRequest, opts ...grpc.CallOption) (*Package, error)
+	// Search returns the packages that match a search query.
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	// ListVersions returns the known versions of a module.
+	ListVersions(ctx context.Context, in *ListVersionsRequest, opts ...grpc.CallOption) (*ListVersionsResponse, error)
+}
+
+type pkgsiteClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPkgsiteClient(cc grpc.ClientConnInterface) PkgsiteClient {
+	return &pkgsiteClient{cc}
+}
+
+func (c *pkgsiteClient) GetModuleInfo(ctx context.Context, in *GetModuleInfoRequest, opts ...grpc.CallOption) (*ModuleInfo, error) {
+	out := new(ModuleInfo)
+	err := c.cc.Invoke(ctx, "/pkgsite.Pkgsite/GetModuleInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pkgsiteClient) GetPackage(ctx context.Context, in *GetPackageRequest, opts ...grpc.CallOption) (*Package, error) {
+	out := new(Package)
+	err := c.cc.Invoke(ctx, "/pkgsite.Pkgsite/GetPackage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pkgsiteClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	out := new(SearchResponse)
+	err := c.cc.Invoke(ctx, "/pkgsite.Pkgsite/Search", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pkgsiteClient) ListVersions(ctx context.Context, in *ListVersionsRequest, opts ...grpc.CallOption) (*ListVersionsResponse, error) {
+	out := new(ListVersionsResponse)
+	err := c.cc.Invoke(ctx, "/pkgsite.Pkgsite/ListVersions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PkgsiteServer is the server API for Pkgsite service.
+type PkgsiteServer interface {
+	// GetModuleInfo returns information about a module version.
+	GetModuleInfo(context.Context, *GetModuleInfoRequest) (*ModuleInfo, error)
+	// GetPackage returns a package at a module version.
+	GetPackage(context.Context, *GetPackageRequest) (*Package, error)
+	// Search returns the packages that match a search query.
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+	// ListVersions returns the known versions of a module.
+	ListVersions(context.Context, *ListVersionsRequest) (*ListVersionsResponse, error)
+}
+
+// UnimplementedPkgsiteServer can be embedded to have forward compatible implementations.
+type UnimplementedPkgsiteServer struct {
+}
+
+func (*UnimplementedPkgsiteServer) GetModuleInfo(ctx context.Context, req *GetModuleInfoRequest) (*ModuleInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetModuleInfo not implemented")
+}
+func (*UnimplementedPkgsiteServer) GetPackage(ctx context.Context, req *GetPackageRequest) (*Package, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPackage not implemented")
+}
+func (*UnimplementedPkgsiteServer) Search(ctx context.Context, req *SearchRequest) (*SearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (*UnimplementedPkgsiteServer) ListVersions(ctx context.Context, req *ListVersionsRequest) (*ListVersionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVersions not implemented")
+}
+
+func RegisterPkgsiteServer(s *grpc.Server, srv PkgsiteServer) {
+	s.RegisterService(&_Pkgsite_serviceDesc, srv)
+}
+
+func _Pkgsite_GetModuleInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetModuleInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PkgsiteServer).GetModuleInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pkgsite.Pkgsite/GetModuleInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PkgsiteServer).GetModuleInfo(ctx, req.(*GetModuleInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pkgsite_GetPackage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPackageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PkgsiteServer).GetPackage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pkgsite.Pkgsite/GetPackage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PkgsiteServer).GetPackage(ctx, req.(*GetPackageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pkgsite_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PkgsiteServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pkgsite.Pkgsite/Search",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PkgsiteServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pkgsite_ListVersions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListVersionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PkgsiteServer).ListVersions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pkgsite.Pkgsite/ListVersions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PkgsiteServer).ListVersions(ctx, req.(*ListVersionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Pkgsite_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "pkgsite.Pkgsite",
+	HandlerType: (*PkgsiteServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetModuleInfo",
+			Handler:    _Pkgsite_GetModuleInfo_Handler,
+		},
+		{
+			MethodName: "GetPackage",
+			Handler:    _Pkgsite_GetPackage_Handler,
+		},
+		{
+			MethodName: "Search",
+			Handler:    _Pkgsite_Search_Handler,
+		},
+		{
+			MethodName: "ListVersions",
+			Handler:    _Pkgsite_ListVersions_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkgsite.proto",
+}
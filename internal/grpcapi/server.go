@@ -0,0 +1,239 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package grpcapi serves the read-only gRPC API defined in
+// pkgsitepb/pkgsite.proto, using the same data source as the frontend.
+package grpcapi
+
+//go:generate protoc -I pkgsitepb --go_out=plugins=grpc,paths=source_relative:pkgsitepb pkgsite.proto
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/protobuf/ptypes"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/grpcapi/pkgsitepb"
+	"golang.org/x/pkgsite/internal/licenses"
+	"golang.org/x/pkgsite/internal/searchquery"
+)
+
+const (
+	// defaultSearchLimit is the number of search results returned when a
+	// request does not set a limit.
+	defaultSearchLimit = 10
+
+	// maxSearchLimit is the maximum number of search results returned for a
+	// request.
+	maxSearchLimit = 100
+)
+
+// DataSource is the data used by the service. *postgres.DB implements it.
+type DataSource interface {
+	internal.DataSource
+	// Search returns the results of a search query; see
+	// postgres.DB.Search.
+	Search(ctx context.Context, q string, limit, offset int) ([]*internal.SearchResult, error)
+	// IsExcluded reports whether a path is excluded from pkgsite; see
+	// postgres.DB.IsExcluded.
+	IsExcluded(ctx context.Context, path string) (bool, error)
+}
+
+// Server implements pkgsitepb.PkgsiteServer.
+type Server struct {
+	ds DataSource
+}
+
+// NewServer returns a Server that reads from ds.
+func NewServer(ds DataSource) *Server {
+	return &Server{ds: ds}
+}
+
+var _ pkgsitepb.PkgsiteServer = (*Server)(nil)
+
+// GetModuleInfo implements pkgsitepb.PkgsiteServer.
+func (s *Server) GetModuleInfo(ctx context.Context, req *pkgsitepb.GetModuleInfoRequest) (_ *pkgsitepb.ModuleInfo, err error) {
+	defer derrors.Wrap(&err, "GetModuleInfo(%q, %q)", req.ModulePath, req.Version)
+	if req.ModulePath == "" {
+		return nil, fmt.Errorf("missing module path: %w", derrors.InvalidArgument)
+	}
+	if err := s.checkExcluded(ctx, req.ModulePath); err != nil {
+		return nil, err
+	}
+	mi, err := s.ds.LegacyGetModuleInfo(ctx, req.ModulePath, versionOrLatest(req.Version))
+	if err != nil {
+		return nil, err
+	}
+	return moduleInfoProto(&mi.ModuleInfo)
+}
+
+// GetPackage implements pkgsitepb.PkgsiteServer.
+func (s *Server) GetPackage(ctx context.Context, req *pkgsitepb.GetPackageRequest) (_ *pkgsitepb.Package, err error) {
+	defer derrors.Wrap(&err, "GetPackage(%q, %q, %q)", req.Path, req.ModulePath, req.Version)
+	if req.Path == "" {
+		return nil, fmt.Errorf("missing package path: %w", derrors.InvalidArgument)
+	}
+	// A package of an excluded module is excluded too, because the module
+	// path is a prefix of the package path.
+	if err := s.checkExcluded(ctx, req.Path); err != nil {
+		return nil, err
+	}
+	modulePath := req.ModulePath
+	if modulePath == "" {
+		modulePath = internal.UnknownModulePath
+	}
+	vp, err := s.ds.LegacyGetPackage(ctx, req.Path, modulePath, versionOrLatest(req.Version))
+	if err != nil {
+		return nil, err
+	}
+	mi, err := moduleInfoProto(&vp.ModuleInfo)
+	if err != nil {
+		return nil, err
+	}
+	p := &vp.LegacyPackage
+	pkg := &pkgsitepb.Package{
+		Path:              p.Path,
+		Name:              p.Name,
+		Synopsis:          p.Synopsis,
+		V1Path:            p.V1Path,
+		IsRedistributable: p.IsRedistributable,
+		Licenses:          licensesProto(p.Licenses),
+		Imports:           p.Imports,
+		Goos:              p.GOOS,
+		Goarch:            p.GOARCH,
+		Module:            mi,
+	}
+	// The data source removes the documentation of packages that are not
+	// redistributable.
+	if p.IsRedistributable {
+		pkg.DocumentationHtml = p.DocumentationHTML
+	}
+	return pkg, nil
+}
+
+// Search implements pkgsitepb.PkgsiteServer.
+func (s *Server) Search(ctx context.Context, req *pkgsitepb.SearchRequest) (_ *pkgsitepb.SearchResponse, err error) {
+	defer derrors.Wrap(&err, "Search(%q, %d, %d)", req.Query, req.Limit, req.Offset)
+	if req.Query == "" {
+		return nil, fmt.Errorf("missing query: %w", derrors.InvalidArgument)
+	}
+	if err := searchquery.Check(req.Query, searchquery.DefaultMaxLength); err != nil {
+		return nil, err
+	}
+	if req.Limit < 0 || req.Limit > maxSearchLimit || req.Offset < 0 {
+		return nil, fmt.Errorf("limit must be in [0, %d] and offset must not be negative: %w",
+			maxSearchLimit, derrors.InvalidArgument)
+	}
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+	results, err := s.ds.Search(ctx, req.Query, limit, int(req.Offset))
+	if err != nil {
+		return nil, err
+	}
+	resp := &pkgsitepb.SearchResponse{}
+	for _, r := range results {
+		t, err := ptypes.TimestampProto(r.CommitTime)
+		if err != nil {
+			return nil, err
+		}
+		resp.Results = append(resp.Results, &pkgsitepb.SearchResult{
+			Name:          r.Name,
+			PackagePath:   r.PackagePath,
+			ModulePath:    r.ModulePath,
+			Version:       r.Version,
+			Synopsis:      r.Synopsis,
+			Licenses:      r.Licenses,
+			CommitTime:    t,
+			Score:         r.Score,
+			NumImportedBy: r.NumImportedBy,
+		})
+		// Every result holds the total number of results.
+		resp.Total = r.NumResults
+	}
+	return resp, nil
+}
+
+// ListVersions implements pkgsitepb.PkgsiteServer.
+func (s *Server) ListVersions(ctx context.Context, req *pkgsitepb.ListVersionsRequest) (_ *pkgsitepb.ListVersionsResponse, err error) {
+	defer derrors.Wrap(&err, "ListVersions(%q)", req.ModulePath)
+	if req.ModulePath == "" {
+		return nil, fmt.Errorf("missing module path: %w", derrors.InvalidArgument)
+	}
+	if err := s.checkExcluded(ctx, req.ModulePath); err != nil {
+		return nil, err
+	}
+	mis, err := s.ds.GetTaggedVersionsForModule(ctx, req.ModulePath)
+	if err != nil {
+		return nil, err
+	}
+	// As on the versions tab, list pseudo-versions only if there are no
+	// tagged versions.
+	if len(mis) == 0 {
+		mis, err = s.ds.GetPseudoVersionsForModule(ctx, req.ModulePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(mis) == 0 {
+		return nil, fmt.Errorf("no versions: %w", derrors.NotFound)
+	}
+	resp := &pkgsitepb.ListVersionsResponse{}
+	for _, mi := range mis {
+		m, err := moduleInfoProto(mi)
+		if err != nil {
+			return nil, err
+		}
+		resp.Versions = append(resp.Versions, m)
+	}
+	return resp, nil
+}
+
+// checkExcluded returns an error wrapping derrors.NotFound if path is
+// excluded. Excluded modules stay in the database, so they must be hidden
+// here, as the frontend hides them; like the frontend, it does not reveal
+// that the path was excluded.
+func (s *Server) checkExcluded(ctx context.Context, path string) error {
+	excluded, err := s.ds.IsExcluded(ctx, path)
+	if err != nil {
+		return err
+	}
+	if excluded {
+		return fmt.Errorf("%s: %w", path, derrors.NotFound)
+	}
+	return nil
+}
+
+func versionOrLatest(v string) string {
+	if v == "" {
+		return internal.LatestVersion
+	}
+	return v
+}
+
+func moduleInfoProto(mi *internal.ModuleInfo) (*pkgsitepb.ModuleInfo, error) {
+	t, err := ptypes.TimestampProto(mi.CommitTime)
+	if err != nil {
+		return nil, err
+	}
+	return &pkgsitepb.ModuleInfo{
+		ModulePath:        mi.ModulePath,
+		Version:           mi.Version,
+		CommitTime:        t,
+		VersionType:       string(mi.VersionType),
+		IsRedistributable: mi.IsRedistributable,
+		HasGoMod:          mi.HasGoMod,
+		RepositoryUrl:     mi.SourceInfo.RepoURL(),
+	}, nil
+}
+
+func licensesProto(lms []*licenses.Metadata) []*pkgsitepb.License {
+	var ls []*pkgsitepb.License
+	for _, lm := range lms {
+		ls = append(ls, &pkgsitepb.License{Types: lm.Types, FilePath: lm.FilePath})
+	}
+	return ls
+}
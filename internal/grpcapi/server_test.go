@@ -0,0 +1,339 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/grpcapi/pkgsitepb"
+	"golang.org/x/pkgsite/internal/testing/sample"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeDataSource serves one module version.
+type fakeDataSource struct {
+	internal.DataSource // unimplemented methods panic
+
+	mi       *internal.LegacyModuleInfo
+	pkg      *internal.LegacyPackage
+	excluded []string // excluded path prefixes
+}
+
+func newFakeDataSource() *fakeDataSource {
+	return &fakeDataSource{
+		mi:  sample.LegacyModuleInfo(sample.ModulePath, sample.VersionString),
+		pkg: sample.LegacyPackage(sample.ModulePath, "foo"),
+	}
+}
+
+func (ds *fakeDataSource) found(modulePath, version string) bool {
+	return (modulePath == ds.mi.ModulePath || modulePath == internal.UnknownModulePath) &&
+		(version == ds.mi.Version || version == internal.LatestVersion)
+}
+
+func (ds *fakeDataSource) LegacyGetModuleInfo(_ context.Context, modulePath, version string) (*internal.LegacyModuleInfo, error) {
+	if !ds.found(modulePath, version) {
+		return nil, fmt.Errorf("%s@%s: %w", modulePath, version, derrors.NotFound)
+	}
+	return ds.mi, nil
+}
+
+func (ds *fakeDataSource) LegacyGetPackage(_ context.Context, pkgPath, modulePath, version string) (*internal.LegacyVersionedPackage, error) {
+	if pkgPath != ds.pkg.Path || !ds.found(modulePath, version) {
+		return nil, fmt.Errorf("%s: %w", pkgPath, derrors.NotFound)
+	}
+	return &internal.LegacyVersionedPackage{LegacyPackage: *ds.pkg, LegacyModuleInfo: *ds.mi}, nil
+}
+
+func (ds *fakeDataSource) GetTaggedVersionsForModule(_ context.Context, modulePath string) ([]*internal.ModuleInfo, error) {
+	if modulePath != ds.mi.ModulePath {
+		return nil, nil
+	}
+	return []*internal.ModuleInfo{&ds.mi.ModuleInfo}, nil
+}
+
+func (ds *fakeDataSource) GetPseudoVersionsForModule(context.Context, string) ([]*internal.ModuleInfo, error) {
+	return nil, nil
+}
+
+func (ds *fakeDataSource) Search(_ context.Context, q string, limit, offset int) ([]*internal.SearchResult, error) {
+	if q == "fail" {
+		return nil, errors.New("database is down")
+	}
+	return []*internal.SearchResult{{
+		Name:        ds.pkg.Name,
+		PackagePath: ds.pkg.Path,
+		ModulePath:  ds.mi.ModulePath,
+		Version:     ds.mi.Version,
+		Synopsis:    ds.pkg.Synopsis,
+		CommitTime:  ds.mi.CommitTime,
+		NumResults:  1,
+	}}, nil
+}
+
+func (ds *fakeDataSource) IsExcluded(_ context.Context, path string) (bool, error) {
+	for _, prefix := range ds.excluded {
+		if strings.HasPrefix(path, prefix) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// newClient starts a gRPC server for ds, and returns a client connected to
+// it.
+func newClient(t *testing.T, ds DataSource) pkgsitepb.PkgsiteClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer(grpc.UnaryInterceptor(UnaryErrorInterceptor))
+	pkgsitepb.RegisterPkgsiteServer(gs, NewServer(ds))
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pkgsitepb.NewPkgsiteClient(conn)
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	ds := newFakeDataSource()
+	client := newClient(t, ds)
+	commitTime, err := ptypes.TimestampProto(ds.mi.CommitTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantModule := &pkgsitepb.ModuleInfo{
+		ModulePath:        sample.ModulePath,
+		Version:           sample.VersionString,
+		CommitTime:        commitTime,
+		VersionType:       "release",
+		IsRedistributable: true,
+		HasGoMod:          true,
+		RepositoryUrl:     sample.RepositoryURL,
+	}
+
+	t.Run("GetModuleInfo", func(t *testing.T) {
+		got, err := client.GetModuleInfo(ctx, &pkgsitepb.GetModuleInfoRequest{ModulePath: sample.ModulePath})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(wantModule, got, cmp.Comparer(proto.Equal)); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("GetPackage", func(t *testing.T) {
+		got, err := client.GetPackage(ctx, &pkgsitepb.GetPackageRequest{
+			Path:    ds.pkg.Path,
+			Version: sample.VersionString,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := &pkgsitepb.Package{
+			Path:              ds.pkg.Path,
+			Name:              ds.pkg.Name,
+			Synopsis:          ds.pkg.Synopsis,
+			V1Path:            ds.pkg.V1Path,
+			IsRedistributable: true,
+			Licenses: []*pkgsitepb.License{{
+				Types:    sample.LicenseMetadata[0].Types,
+				FilePath: sample.LicenseMetadata[0].FilePath,
+			}},
+			Imports:           ds.pkg.Imports,
+			DocumentationHtml: ds.pkg.DocumentationHTML,
+			Goos:              ds.pkg.GOOS,
+			Goarch:            ds.pkg.GOARCH,
+			Module:            wantModule,
+		}
+		if diff := cmp.Diff(want, got, cmp.Comparer(proto.Equal)); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("Search", func(t *testing.T) {
+		got, err := client.Search(ctx, &pkgsitepb.SearchRequest{Query: "foo"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := &pkgsitepb.SearchResponse{
+			Results: []*pkgsitepb.SearchResult{{
+				Name:        ds.pkg.Name,
+				PackagePath: ds.pkg.Path,
+				ModulePath:  sample.ModulePath,
+				Version:     sample.VersionString,
+				Synopsis:    ds.pkg.Synopsis,
+				CommitTime:  commitTime,
+			}},
+			Total: 1,
+		}
+		if diff := cmp.Diff(want, got, cmp.Comparer(proto.Equal)); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("ListVersions", func(t *testing.T) {
+		got, err := client.ListVersions(ctx, &pkgsitepb.ListVersionsRequest{ModulePath: sample.ModulePath})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := &pkgsitepb.ListVersionsResponse{Versions: []*pkgsitepb.ModuleInfo{wantModule}}
+		if diff := cmp.Diff(want, got, cmp.Comparer(proto.Equal)); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestErrorCodes(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t, newFakeDataSource())
+	for _, test := range []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{
+			name: "module not found",
+			call: func() error {
+				_, err := client.GetModuleInfo(ctx, &pkgsitepb.GetModuleInfoRequest{ModulePath: "no/such/module"})
+				return err
+			},
+			want: codes.NotFound,
+		},
+		{
+			name: "missing module path",
+			call: func() error {
+				_, err := client.GetModuleInfo(ctx, &pkgsitepb.GetModuleInfoRequest{})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "package not found",
+			call: func() error {
+				_, err := client.GetPackage(ctx, &pkgsitepb.GetPackageRequest{Path: "no/such/package"})
+				return err
+			},
+			want: codes.NotFound,
+		},
+		{
+			name: "search limit too large",
+			call: func() error {
+				_, err := client.Search(ctx, &pkgsitepb.SearchRequest{Query: "foo", Limit: maxSearchLimit + 1})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "search query too long",
+			call: func() error {
+				_, err := client.Search(ctx, &pkgsitepb.SearchRequest{Query: strings.Repeat("a", 1001)})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "search query with unbalanced quote",
+			call: func() error {
+				_, err := client.Search(ctx, &pkgsitepb.SearchRequest{Query: `"foo`})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "internal error",
+			call: func() error {
+				_, err := client.Search(ctx, &pkgsitepb.SearchRequest{Query: "fail"})
+				return err
+			},
+			want: codes.Internal,
+		},
+		{
+			name: "no versions",
+			call: func() error {
+				_, err := client.ListVersions(ctx, &pkgsitepb.ListVersionsRequest{ModulePath: "no/such/module"})
+				return err
+			},
+			want: codes.NotFound,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := status.Code(test.call()); got != test.want {
+				t.Errorf("got code %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		err  error
+		want codes.Code
+	}{
+		{fmt.Errorf("x: %w", derrors.Excluded), codes.PermissionDenied},
+		{fmt.Errorf("x: %w", derrors.AlternativeModule), codes.FailedPrecondition},
+		{fmt.Errorf("x: %w", derrors.ChecksumMismatch), codes.DataLoss},
+		{fmt.Errorf("x: %w", derrors.ProxyError), codes.Unavailable},
+		{fmt.Errorf("x: %w", context.DeadlineExceeded), codes.DeadlineExceeded},
+		{status.Error(codes.Aborted, "aborted"), codes.Aborted},
+	} {
+		if got := status.Code(statusError(ctx, "/m", test.err)); got != test.want {
+			t.Errorf("statusError(%v): got code %s, want %s", test.err, got, test.want)
+		}
+	}
+	// Internal errors are not reported to clients.
+	err := statusError(ctx, "/m", errors.New("secret details"))
+	if got, want := status.Convert(err).Message(), "internal error"; got != want {
+		t.Errorf("got message %q, want %q", got, want)
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	ctx := context.Background()
+	ds := newFakeDataSource()
+	ds.excluded = []string{sample.ModulePath}
+	client := newClient(t, ds)
+
+	for _, test := range []struct {
+		name string
+		call func() error
+	}{
+		{"GetModuleInfo", func() error {
+			_, err := client.GetModuleInfo(ctx, &pkgsitepb.GetModuleInfoRequest{ModulePath: sample.ModulePath})
+			return err
+		}},
+		{"GetPackage", func() error {
+			_, err := client.GetPackage(ctx, &pkgsitepb.GetPackageRequest{Path: ds.pkg.Path})
+			return err
+		}},
+		{"ListVersions", func() error {
+			_, err := client.ListVersions(ctx, &pkgsitepb.ListVersionsRequest{ModulePath: sample.ModulePath})
+			return err
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := status.Code(test.call()), codes.NotFound; got != want {
+				t.Errorf("got code %s, want %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package licenses
+
+import (
+	"fmt"
+	"sort"
+)
+
+// A CompatibilityMatrix records pairs of license types, as reported by
+// licensecheck, whose terms conflict, so that code under both cannot be
+// distributed together.
+//
+// A CompatibilityMatrix is advisory. It covers common, well-known cases and is
+// not legal advice.
+//
+// A CompatibilityMatrix is safe for concurrent use by Compatible once all of
+// its pairs have been added with AddIncompatible.
+type CompatibilityMatrix struct {
+	incompatible map[[2]string]bool
+}
+
+// NewCompatibilityMatrix returns an empty CompatibilityMatrix, in which all
+// license types are compatible with each other.
+func NewCompatibilityMatrix() *CompatibilityMatrix {
+	return &CompatibilityMatrix{incompatible: map[[2]string]bool{}}
+}
+
+// AddIncompatible records that license types a and b are incompatible.
+func (m *CompatibilityMatrix) AddIncompatible(a, b string) {
+	m.incompatible[licensePair(a, b)] = true
+}
+
+// Compatible reports whether code under all of the given license types can be
+// distributed together: every type must be redistributable (see
+// Redistributable), and no two types may be incompatible according to m. It
+// also returns the incompatible pairs it found, in sorted order, each
+// formatted as "A and B".
+func (m *CompatibilityMatrix) Compatible(types []string) (bool, []string) {
+	var conflicts []string
+	seen := map[[2]string]bool{}
+	for i, a := range types {
+		for _, b := range types[i+1:] {
+			p := licensePair(a, b)
+			if m.incompatible[p] && !seen[p] {
+				seen[p] = true
+				conflicts = append(conflicts, fmt.Sprintf("%s and %s", p[0], p[1]))
+			}
+		}
+	}
+	sort.Strings(conflicts)
+	return Redistributable(types) && len(conflicts) == 0, conflicts
+}
+
+// licensePair returns a and b as a key for CompatibilityMatrix.incompatible,
+// in a canonical order.
+func licensePair(a, b string) [2]string {
+	if b < a {
+		a, b = b, a
+	}
+	return [2]string{a, b}
+}
+
+// defaultCompatibilityMatrix is the CompatibilityMatrix used by Compatible. It
+// is never modified after it is created, so it can be shared by all callers.
+var defaultCompatibilityMatrix = DefaultCompatibilityMatrix()
+
+// DefaultCompatibilityMatrix returns a new CompatibilityMatrix with the
+// well-known incompatibilities that Compatible uses, mostly between the GPL
+// family and licenses with terms the GPL does not allow. It may be extended
+// with AddIncompatible without affecting Compatible.
+func DefaultCompatibilityMatrix() *CompatibilityMatrix {
+	m := NewCompatibilityMatrix()
+	for _, p := range [][2]string{
+		// GPL-2.0 does not permit the patent and other terms of these licenses,
+		// and its "no further restrictions" clause conflicts with GPL-3.0 and
+		// its descendants.
+		{"GPL2", "Apache-2.0"},
+		{"GPL2", "GPL3"},
+		{"GPL2", "LGPL-3.0"},
+		{"GPL2", "AGPL-3.0"},
+		{"GPL2", "CC-BY-SA-3.0"},
+		{"GPL2", "CC-BY-SA-4.0"},
+		{"GPL2", "EPL-1.0"},
+		{"GPL2", "OpenSSL"},
+		{"GPL2", "OSL-3.0"},
+		// GPL-3.0 is compatible with Apache-2.0 and CC-BY-SA-4.0, but not with
+		// these.
+		{"GPL3", "CC-BY-SA-3.0"},
+		{"GPL3", "EPL-1.0"},
+		{"GPL3", "OpenSSL"},
+		{"GPL3", "OSL-3.0"},
+		{"AGPL-3.0", "EPL-1.0"},
+		{"AGPL-3.0", "OpenSSL"},
+		{"AGPL-3.0", "OSL-3.0"},
+	} {
+		m.AddIncompatible(p[0], p[1])
+	}
+	return m
+}
+
+// Compatible reports whether code under all of the given license types can be
+// distributed together, according to the pairs of DefaultCompatibilityMatrix,
+// and returns the incompatible pairs it found. See
+// CompatibilityMatrix.Compatible.
+//
+// The result is advisory and is not legal advice.
+func Compatible(types []string) (bool, []string) {
+	return defaultCompatibilityMatrix.Compatible(types)
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package licenses
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCompatible(t *testing.T) {
+	for _, test := range []struct {
+		types         []string
+		want          bool
+		wantConflicts []string
+	}{
+		{nil, false, nil}, // no licenses, so not redistributable
+		{[]string{"MIT"}, true, nil},
+		{[]string{"MIT", "BSD-3-Clause", "Apache-2.0"}, true, nil},
+		{[]string{"GPL3", "Apache-2.0", "MIT"}, true, nil},
+		{[]string{"MPL-2.0", "GPL2"}, true, nil},
+		{[]string{"Apache-2.0", "GPL2"}, false, []string{"Apache-2.0 and GPL2"}},
+		{[]string{"GPL2", "GPL3", "Apache-2.0", "GPL2"}, false, []string{"Apache-2.0 and GPL2", "GPL2 and GPL3"}},
+		{[]string{"MIT", "BUSL-1.1"}, false, nil},
+		{[]string{"MIT", "CC-Notice"}, true, nil},
+	} {
+		got, gotConflicts := Compatible(test.types)
+		if got != test.want {
+			t.Errorf("Compatible(%v) = %t, want %t", test.types, got, test.want)
+		}
+		if diff := cmp.Diff(test.wantConflicts, gotConflicts); diff != "" {
+			t.Errorf("Compatible(%v) conflicts mismatch (-want +got):\n%s", test.types, diff)
+		}
+	}
+}
+
+func TestCompatibilityMatrixAddIncompatible(t *testing.T) {
+	m := NewCompatibilityMatrix()
+	if ok, _ := m.Compatible([]string{"MIT", "ISC"}); !ok {
+		t.Fatal("empty matrix: got incompatible, want compatible")
+	}
+	m.AddIncompatible("MIT", "ISC")
+	ok, conflicts := m.Compatible([]string{"ISC", "MIT"})
+	if ok {
+		t.Error("got compatible, want incompatible")
+	}
+	if diff := cmp.Diff([]string{"ISC and MIT"}, conflicts); diff != "" {
+		t.Errorf("conflicts mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDefaultCompatibilityMatrix(t *testing.T) {
+	// Extending a default matrix does not change Compatible.
+	m := DefaultCompatibilityMatrix()
+	m.AddIncompatible("MIT", "ISC")
+	if ok, _ := m.Compatible([]string{"MIT", "ISC"}); ok {
+		t.Error("extended matrix: got compatible, want incompatible")
+	}
+	if ok, _ := Compatible([]string{"MIT", "ISC"}); !ok {
+		t.Error("Compatible after extending a default matrix: got incompatible, want compatible")
+	}
+	if ok, _ := m.Compatible([]string{"GPL2", "Apache-2.0"}); ok {
+		t.Error("default matrix: got GPL2 and Apache-2.0 compatible, want incompatible")
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package licenses
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/licensecheck"
+)
+
+// A Copyright is a copyright notice in a license file, like
+// "Copyright (c) 2019 The Go Authors. All rights reserved."
+type Copyright struct {
+	// Holder is the owner of the copyright, like "The Go Authors".
+	Holder string
+	// FirstYear and LastYear are the first and last years of the copyright.
+	// They are equal if the notice has a single year, and zero if it has
+	// none.
+	FirstYear, LastYear int
+}
+
+var (
+	// copyrightLineRegexp matches the start of a copyright notice, possibly in
+	// a comment. The word is capitalized, unlike in sentences that mention
+	// copyright.
+	copyrightLineRegexp = regexp.MustCompile(`^[\s/#*;-]*(?:(?:Copyright|COPYRIGHT)\b|\([cC]\)|©)`)
+	// copyrightSignRegexp matches a copyright symbol or word.
+	copyrightSignRegexp = regexp.MustCompile(`^(?i)(?:copyright\b|\(c\)|©)[\s:]*`)
+	// copyrightYearsRegexp matches the years of a copyright notice.
+	copyrightYearsRegexp = regexp.MustCompile(`^(?i)(\d{4}|present|now)(?:\s*(?:[-–,]|to)\s*(\d{4}|present|now))*[\s,]*`)
+	// yearRegexp matches a year.
+	yearRegexp = regexp.MustCompile(`\d{4}`)
+	// copyrightEndRegexp matches the end of a copyright notice that follows
+	// its holder, including a final period.
+	copyrightEndRegexp = regexp.MustCompile(`(?i)[\s.,;]*(all\s+rights\s+reserved[\s.]*)?$`)
+	// placeholderRegexp matches the placeholders of license templates, like
+	// "[yyyy]" and "<name of author>".
+	placeholderRegexp = regexp.MustCompile(`(?i)[\[<{](yyyy|year|name|copyright|owner|author|fullname)|name of (the )?(author|copyright owner)`)
+)
+
+// abbreviations are the words, in lower case, which end a copyright holder and
+// keep their final period, like "Inc.".
+var abbreviations = map[string]bool{
+	"co":    true,
+	"corp":  true,
+	"inc":   true,
+	"jr":    true,
+	"l.l.c": true,
+	"ltd":   true,
+}
+
+// notCopyrightHolders are the words that follow "Copyright" in sentences of
+// license texts that are not copyright notices, like "Copyright notice".
+var notCopyrightHolders = map[string]bool{
+	"and":     true,
+	"holder":  true,
+	"holders": true,
+	"law":     true,
+	"notice":  true,
+	"notices": true,
+	"owner":   true,
+	"owners":  true,
+}
+
+// licenseTextCopyrights are the copyright notices in the texts of the licenses
+// known to licensecheck, like the one of the Free Software Foundation in the
+// GPL, normalized by normalizeCopyright. They are notices for the license
+// text, not the licensed work, so they are not reported.
+var licenseTextCopyrights = map[string]bool{}
+
+func init() {
+	for _, l := range licensecheck.BuiltinLicenses() {
+		for _, line := range strings.Split(l.Text, "\n") {
+			if copyrightLineRegexp.MatchString(line) {
+				licenseTextCopyrights[normalizeCopyright(line)] = true
+			}
+		}
+	}
+}
+
+// normalizeCopyright returns line in lower case, with runs of white space
+// replaced by a single space.
+func normalizeCopyright(line string) string {
+	return strings.ToLower(strings.Join(strings.Fields(line), " "))
+}
+
+// parseCopyrights returns the copyright notices in contents, a license file, in
+// the order in which they first appear. Lines that begin like a notice but have
+// no holder, and the notices of license texts and templates, are skipped.
+func parseCopyrights(contents []byte) []Copyright {
+	var (
+		cs   []Copyright
+		seen = map[Copyright]bool{}
+	)
+	for _, line := range strings.Split(string(contents), "\n") {
+		if !copyrightLineRegexp.MatchString(line) || licenseTextCopyrights[normalizeCopyright(line)] || placeholderRegexp.MatchString(line) {
+			continue
+		}
+		c, ok := parseCopyright(line)
+		if ok && !seen[c] {
+			seen[c] = true
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+// parseCopyright parses line, which matches copyrightLineRegexp, as a
+// copyright notice. It reports false if line has no holder.
+func parseCopyright(line string) (Copyright, bool) {
+	var c Copyright
+	rest := strings.TrimLeft(line, " \t/#*;-")
+	// The copyright word and symbol may both be present, like "Copyright (C)".
+	for {
+		loc := copyrightSignRegexp.FindStringIndex(rest)
+		if loc == nil {
+			break
+		}
+		rest = rest[loc[1]:]
+	}
+	if loc := copyrightYearsRegexp.FindStringIndex(rest); loc != nil {
+		for _, y := range yearRegexp.FindAllString(rest[:loc[1]], -1) {
+			year, _ := strconv.Atoi(y)
+			if c.FirstYear == 0 || year < c.FirstYear {
+				c.FirstYear = year
+			}
+			if year > c.LastYear {
+				c.LastYear = year
+			}
+		}
+		rest = rest[loc[1]:]
+	}
+	rest = strings.TrimPrefix(rest, "by ")
+	rest = strings.TrimSpace(copyrightEndRegexp.ReplaceAllString(rest, ""))
+	if rest == "" {
+		return Copyright{}, false
+	}
+	fields := strings.Fields(rest)
+	if notCopyrightHolders[strings.ToLower(strings.Trim(fields[0], ".,;:"))] {
+		return Copyright{}, false
+	}
+	if abbreviations[strings.ToLower(fields[len(fields)-1])] {
+		rest += "."
+	}
+	c.Holder = rest
+	return c, true
+}
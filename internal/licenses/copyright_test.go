@@ -0,0 +1,109 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package licenses
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseCopyrights(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		in   string
+		want []Copyright
+	}{
+		{
+			name: "go",
+			in:   "Copyright (c) 2009 The Go Authors. All rights reserved.\n\nRedistribution and use ...",
+			want: []Copyright{{Holder: "The Go Authors", FirstYear: 2009, LastYear: 2009}},
+		},
+		{
+			name: "range to present",
+			in:   "Copyright 2013-present Docker, Inc.",
+			want: []Copyright{{Holder: "Docker, Inc.", FirstYear: 2013, LastYear: 2013}},
+		},
+		{
+			name: "range with email",
+			in:   "Copyright (C) 2014-2018 Jane Doe <jane@example.com>",
+			want: []Copyright{{Holder: "Jane Doe <jane@example.com>", FirstYear: 2014, LastYear: 2018}},
+		},
+		{
+			name: "list of years",
+			in:   "Copyright © 2012, 2015, 2017 Jane Doe",
+			want: []Copyright{{Holder: "Jane Doe", FirstYear: 2012, LastYear: 2017}},
+		},
+		{
+			name: "no year",
+			in:   "Copyright The Kubernetes Authors.",
+			want: []Copyright{{Holder: "The Kubernetes Authors"}},
+		},
+		{
+			name: "comment",
+			in:   "// Copyright 2012 Matt T. Proud (matt.proud@gmail.com)",
+			want: []Copyright{{Holder: "Matt T. Proud (matt.proud@gmail.com)", FirstYear: 2012, LastYear: 2012}},
+		},
+		{
+			name: "symbol only",
+			in:   "(c) 2020 Example Corp.",
+			want: []Copyright{{Holder: "Example Corp.", FirstYear: 2020, LastYear: 2020}},
+		},
+		{
+			name: "by",
+			in:   "COPYRIGHT 2016 by Jane Doe",
+			want: []Copyright{{Holder: "Jane Doe", FirstYear: 2016, LastYear: 2016}},
+		},
+		{
+			name: "multiple",
+			in: `The MIT License (MIT)
+
+Copyright (c) 2016 Yasuhiro Matsumoto
+Copyright (c) 2018-2019 The X Authors
+Copyright (c) 2016 Yasuhiro Matsumoto
+
+Permission is hereby granted, ...`,
+			want: []Copyright{
+				{Holder: "Yasuhiro Matsumoto", FirstYear: 2016, LastYear: 2016},
+				{Holder: "The X Authors", FirstYear: 2018, LastYear: 2019},
+			},
+		},
+		{
+			name: "missing",
+			in:   mitLicense[len("Copyright 2019 Google Inc"):],
+			want: nil,
+		},
+		{
+			name: "not notices",
+			in: `Copyright
+Copyright notice and this permission notice shall be included.
+copyright (c) is not capitalized
+   2. Redistributions in binary form must reproduce the above
+   copyright notice, this list of conditions and the following disclaimer`,
+			want: nil,
+		},
+		{
+			name: "template",
+			in: `Copyright [yyyy] [name of copyright owner]
+Copyright (C) <year>  <name of author>
+Copyright (c) {year} {fullname}`,
+			want: nil,
+		},
+		{
+			// The notice of the Free Software Foundation is part of the text of
+			// the license.
+			name: "license text",
+			in:   builtinLicenseText(t, "GPL2"),
+			want: nil,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := parseCopyrights([]byte(test.in))
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -16,42 +16,150 @@
 //   modRedist := d.ModuleIsRedistributable()
 //   lics := d.AllLicenses()
 //   pkgRedist, pkgMetas := d.PackageInfo(pkgSubdir)
+//
+// Example (zip file on disk, which is not read into memory):
+//   d, err := licenses.OpenDetector(modulePath, version, zipFilename, log.Infof, licenses.DefaultDetectOptions())
+//   if err != nil { ... }
+//   defer d.Close()
+//   lics := d.AllLicenses()
 package licenses
 
 import (
 	"archive/zip"
+	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/google/licensecheck"
 	"golang.org/x/mod/module"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
 )
 
 //go:generate rm -f exceptions.gen.go
 //go:generate go run gen_exceptions.go
 
 const (
-	// classifyThreshold is the minimum confidence percentage/threshold to
-	// classify a license
-	classifyThreshold = 90
+	// defaultClassifyThreshold is the default minimum confidence
+	// percentage/threshold to classify a license
+	defaultClassifyThreshold = 90
+
+	// defaultCoverageThreshold is the default minimum percentage of the file
+	// that must contain license text.
+	defaultCoverageThreshold = 75
 
-	// coverageThreshold is the minimum percentage of the file that must contain
-	// license text.
-	coverageThreshold = 75
+	// defaultMaxLicenseSize is the default maximum allowable size (in bytes)
+	// for a license file. There are some license files larger than 1 million
+	// bytes: https://github.com/vmware/vic/LICENSE and
+	// github.com/goharbor/harbor/LICENSE, for example.
+	defaultMaxLicenseSize = 1e7
 
 	// unknownLicenseType is for text in a license file that's not recognized.
 	unknownLicenseType = "UNKNOWN"
+
+	// proprietaryLicenseType is for an unrecognized license file that
+	// reserves all rights, like "Copyright 2020 Example Inc. All rights
+	// reserved.", without granting any license.
+	proprietaryLicenseType = "PROPRIETARY"
 )
 
-// maxLicenseSize is the maximum allowable size (in bytes) for a license file.
-// There are some license files larger than 1 million bytes: https://github.com/vmware/vic/LICENSE
-// and github.com/goharbor/harbor/LICENSE, for example.
-// var for testing
-var maxLicenseSize uint64 = 1e7
+// DetectOptions holds values that control license detection.
+type DetectOptions struct {
+	// ClassifyThreshold is the minimum confidence percentage/threshold to
+	// classify a license.
+	ClassifyThreshold float64
+	// CoverageThreshold is the minimum percentage of the file that must
+	// contain license text.
+	CoverageThreshold float64
+	// MaxLicenseSize is the maximum allowable size (in bytes) for a license
+	// file. Larger files are reported as having an unknown license, unless
+	// PartialScanSize is set.
+	MaxLicenseSize uint64
+	// PartialScanSize, if non-zero, is the number of bytes at the start of a
+	// license file to classify when the whole file is larger than
+	// MaxLicenseSize or has too little license text to meet
+	// CoverageThreshold. This finds a license header that is followed by
+	// other text, such as a changelog. Licenses detected this way have
+	// Metadata.PartialScan set.
+	PartialScanSize uint64
+	// ExcludeDirs lists patterns, in the syntax of path.Match, for
+	// directories whose license files are ignored, in addition to vendored
+	// ones. A pattern without a slash, like "testdata", matches a directory
+	// of that name anywhere in the module. A pattern with a slash, like
+	// "cmd/*/examples", matches a directory by its path relative to the
+	// module root. A trailing slash is ignored. Files in the module root are
+	// never excluded.
+	ExcludeDirs []string
+	// EmbeddedArchives controls what is done with archives embedded in the
+	// module zip, like a zip file in a testdata directory. See ArchiveMode.
+	EmbeddedArchives ArchiveMode
+}
+
+// ArchiveMode describes how a Detector treats archives embedded in a module
+// zip.
+type ArchiveMode int
+
+const (
+	// SkipArchives ignores embedded archives. A license file that holds an
+	// archive instead of text is skipped, rather than reported as an unknown
+	// license.
+	SkipArchives ArchiveMode = iota
+	// ScanArchives also detects the license files in embedded zip archives,
+	// like "testdata/dep.zip", without looking into archives nested in them.
+	// They are included in Detector.AllLicenses, with a FilePath that begins
+	// with the path of the archive, like "testdata/dep.zip/LICENSE", but do
+	// not affect the module or its packages. The embedded archives and the
+	// license files in them together may expand to at most MaxLicenseSize
+	// bytes; beyond that, they are skipped.
+	ScanArchives
+)
+
+// DefaultDetectOptions returns the DetectOptions used by NewDetector and
+// DetectFile.
+func DefaultDetectOptions() DetectOptions {
+	return DetectOptions{
+		ClassifyThreshold: defaultClassifyThreshold,
+		CoverageThreshold: defaultCoverageThreshold,
+		MaxLicenseSize:    defaultMaxLicenseSize,
+	}
+}
+
+// Validate reports whether the options are usable for license detection.
+func (o DetectOptions) Validate() error {
+	var errs []string
+	if o.ClassifyThreshold <= 0 || o.ClassifyThreshold > 100 {
+		errs = append(errs, fmt.Sprintf("ClassifyThreshold %g is not in (0, 100]", o.ClassifyThreshold))
+	}
+	if o.CoverageThreshold <= 0 || o.CoverageThreshold > 100 {
+		errs = append(errs, fmt.Sprintf("CoverageThreshold %g is not in (0, 100]", o.CoverageThreshold))
+	}
+	if o.MaxLicenseSize == 0 {
+		errs = append(errs, "MaxLicenseSize must be positive")
+	}
+	if o.PartialScanSize >= o.MaxLicenseSize && o.PartialScanSize != 0 {
+		errs = append(errs, fmt.Sprintf("PartialScanSize %d is not less than MaxLicenseSize %d", o.PartialScanSize, o.MaxLicenseSize))
+	}
+	if o.EmbeddedArchives != SkipArchives && o.EmbeddedArchives != ScanArchives {
+		errs = append(errs, fmt.Sprintf("EmbeddedArchives %d is not a valid ArchiveMode", o.EmbeddedArchives))
+	}
+	for _, p := range o.ExcludeDirs {
+		if _, err := path.Match(p, ""); err != nil || strings.TrimSuffix(p, "/") == "" {
+			errs = append(errs, fmt.Sprintf("ExcludeDirs pattern %q is malformed", p))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid DetectOptions: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
 
 // Metadata holds information extracted from a license file.
 type Metadata struct {
@@ -62,6 +170,28 @@ type Metadata struct {
 	FilePath string
 	// The output of licensecheck.Cover.
 	Coverage licensecheck.Coverage
+	// PartialScan reports whether Types and Coverage describe only the first
+	// DetectOptions.PartialScanSize bytes of the file.
+	PartialScan bool
+	// Copyrights are the copyright notices in the license file, in the order
+	// in which they appear. See parseCopyrights.
+	Copyrights []Copyright
+}
+
+// Category returns the category of the license types in the file: the
+// category shared by all of them (see Category), or the empty string if
+// they do not share one.
+func (m *Metadata) Category() string {
+	if len(m.Types) == 0 {
+		return ""
+	}
+	c := Category(m.Types[0])
+	for _, t := range m.Types[1:] {
+		if Category(t) != c {
+			return ""
+		}
+	}
+	return c
 }
 
 // A License is a classified license file path and its contents.
@@ -73,6 +203,7 @@ type License struct {
 var (
 	FileNames = []string{
 		"COPYING",
+		"COPYING.LESSER",
 		"COPYING.md",
 		"COPYING.markdown",
 		"COPYING.txt",
@@ -158,6 +289,50 @@ var (
 	}
 )
 
+// PublicDomainEquivalent is the category of license types that dedicate a
+// work to the public domain, rather than license it under conditions.
+const PublicDomainEquivalent = "public-domain-equivalent"
+
+// publicDomainLicenseTypes is the set of license types, as reported by
+// licensecheck, in the PublicDomainEquivalent category.
+var publicDomainLicenseTypes = map[string]bool{
+	"CC0-1.0":   true,
+	"Unlicense": true,
+}
+
+// Category returns the category of a license type, as reported by
+// licensecheck, for display: PublicDomainEquivalent, Proprietary, or the
+// empty string for an ordinary license.
+func Category(licenseType string) string {
+	if publicDomainLicenseTypes[licenseType] {
+		return PublicDomainEquivalent
+	}
+	if licenseType == proprietaryLicenseType {
+		return Proprietary
+	}
+	return ""
+}
+
+// Proprietary is the category of license files that reserve all rights to the
+// work and do not grant an open-source license.
+const Proprietary = "proprietary-no-grant"
+
+var (
+	// allRightsReservedRegexp matches the reservation of rights in a
+	// copyright notice.
+	allRightsReservedRegexp = regexp.MustCompile(`(?i)\ball\s+rights\s+reserved\b`)
+	// licenseGrantRegexp matches common wording that grants rights to the
+	// recipient of the work.
+	licenseGrantRegexp = regexp.MustCompile(`(?i)\b(permission\s+is\s+(hereby\s+)?granted|hereby\s+grants?|licen[cs]ed\s+under|redistribution\s+and\s+use|you\s+(may|can)\s+(use|copy|redistribute|modify)|free\s+software)\b`)
+)
+
+// isAllRightsReserved reports whether contents, which were not recognized as
+// a license, read like a bare copyright notice: they reserve all rights and do
+// not grant any.
+func isAllRightsReserved(contents []byte) bool {
+	return allRightsReservedRegexp.Match(contents) && !licenseGrantRegexp.Match(contents)
+}
+
 // osiNameOverrides maps a licensecheck license type to the corresponding OSI
 // name, if they differ.
 var osiNameOverrides = map[string]string{
@@ -185,6 +360,19 @@ var nonOSILicenses = map[string]bool{
 // for fast case-insensitive matching.
 var fileNamesLowercase = map[string]bool{}
 
+// suffixedFileNameRegexp matches downcased file names that follow the
+// convention, common in dual-licensed projects, of naming each license file
+// LICENSE-<suffix>, like LICENSE-BSD or LICENSE-ZLIB.txt.
+var suffixedFileNameRegexp = regexp.MustCompile(`^licen[cs]e-[a-z0-9][a-z0-9.+_-]*$`)
+
+// isLicenseFileName reports whether name, the base name of a file, is the name
+// of a license file: one of FileNames, ignoring case, or a name of the form
+// LICENSE-<suffix>.
+func isLicenseFileName(name string) bool {
+	name = strings.ToLower(name)
+	return fileNamesLowercase[name] || suffixedFileNameRegexp.MatchString(name)
+}
+
 func init() {
 	for _, f := range FileNames {
 		fileNamesLowercase[strings.ToLower(f)] = true
@@ -223,9 +411,12 @@ type Detector struct {
 	modulePath     string
 	version        string
 	zr             *zip.Reader
+	closer         io.Closer // closes the file behind zr, if OpenDetector opened it
 	logf           func(string, ...interface{})
+	opts           DetectOptions
 	moduleRedist   bool
 	moduleLicenses []*License // licenses at module root directory, or list from exceptions
+	moduleExpr     string     // see ModuleLicenseExpression
 	allLicenses    []*License
 	licsByDir      map[string][]*License // from directory to list of licenses
 }
@@ -234,6 +425,20 @@ type Detector struct {
 // zr should be the zip file for that module and version.
 // logf is for logging; if nil, no logging is done.
 func NewDetector(modulePath, version string, zr *zip.Reader, logf func(string, ...interface{})) *Detector {
+	d, err := NewDetectorWithOptions(modulePath, version, zr, logf, DefaultDetectOptions())
+	if err != nil {
+		// The default options are always valid.
+		panic(err)
+	}
+	return d
+}
+
+// NewDetectorWithOptions is like NewDetector, but uses opts to control
+// detection. It returns an error if opts is invalid.
+func NewDetectorWithOptions(modulePath, version string, zr *zip.Reader, logf func(string, ...interface{}), opts DetectOptions) (*Detector, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
 	if logf == nil {
 		logf = func(string, ...interface{}) {}
 	}
@@ -242,9 +447,51 @@ func NewDetector(modulePath, version string, zr *zip.Reader, logf func(string, .
 		version:    version,
 		zr:         zr,
 		logf:       logf,
+		opts:       opts,
 	}
 	d.computeModuleInfo()
-	return d
+	return d, nil
+}
+
+// NewDetectorFromReaderAt is like NewDetectorWithOptions, but reads the zip
+// for the module and version from r, which holds size bytes. Only the zip's
+// central directory and the license files that the Detector classifies are
+// read from r, so r may be backed by a file or memory map that is too large to
+// read into memory. r must remain readable for as long as the Detector is in
+// use.
+func NewDetectorFromReaderAt(modulePath, version string, r io.ReaderAt, size int64, logf func(string, ...interface{}), opts DetectOptions) (*Detector, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("zip.NewReader: %v", err)
+	}
+	return NewDetectorWithOptions(modulePath, version, zr, logf, opts)
+}
+
+// OpenDetector is like NewDetectorFromReaderAt, but reads the zip from the
+// file with the given name. The file stays open until Close is called on the
+// returned Detector.
+func OpenDetector(modulePath, version, filename string, logf func(string, ...interface{}), opts DetectOptions) (*Detector, error) {
+	zrc, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	d, err := NewDetectorWithOptions(modulePath, version, &zrc.Reader, logf, opts)
+	if err != nil {
+		zrc.Close()
+		return nil, err
+	}
+	d.closer = zrc
+	return d, nil
+}
+
+// Close closes the zip file opened by OpenDetector. It does nothing for a
+// Detector created in another way. Methods of d that read license files must
+// not be called after Close.
+func (d *Detector) Close() error {
+	if d.closer == nil {
+		return nil
+	}
+	return d.closer.Close()
 }
 
 // ModuleIsRedistributable reports whether the given module is redistributable.
@@ -257,6 +504,21 @@ func (d *Detector) ModuleLicenses() []*License {
 	return d.moduleLicenses
 }
 
+// ModuleLicenseExpression returns an SPDX-style expression for the licenses
+// that apply to the module, like "Apache-2.0 OR MIT". A project that ships
+// several license files at its root, such as LICENSE-APACHE and LICENSE-MIT,
+// usually offers a choice among them, so the distinct licenses of the root
+// files are joined with OR. The types detected in a single file are joined
+// with AND. A GPL that accompanies the LGPL built on it is omitted, since
+// together they grant the LGPL. The result is empty if no licenses were
+// detected.
+//
+// The expression is informational: ModuleIsRedistributable still requires
+// every license at the root to be redistributable.
+func (d *Detector) ModuleLicenseExpression() string {
+	return d.moduleExpr
+}
+
 // AllLicenses returns all the licenses detected in the entire module, including
 // package licenses.
 func (d *Detector) AllLicenses() []*License {
@@ -301,6 +563,55 @@ func (d *Detector) computeModuleInfo() {
 	// Check that all licenses in the contents directory are redistributable.
 	d.moduleLicenses = d.detectFiles(d.Files(RootFiles))
 	d.moduleRedist = Redistributable(types(d.moduleLicenses))
+	d.moduleExpr = licenseExpression(d.moduleLicenses)
+}
+
+// lesserLicenseBases maps an LGPL license type to the type of the GPL that
+// its text incorporates. GNU projects ship the GPL in COPYING alongside the
+// LGPL in COPYING.LESSER, and the two together grant the LGPL.
+var lesserLicenseBases = map[string]string{
+	"LGPL-3.0": "GPL3",
+}
+
+// licenseExpression returns an expression that offers a choice among lics,
+// in which each license contributes the conjunction of its types. The
+// exceptions of a license with a single type are attached to it with WITH.
+// See Detector.ModuleLicenseExpression.
+func licenseExpression(lics []*License) string {
+	terms := map[string]bool{}
+	for _, l := range lics {
+		var ts, excs []string
+		for _, t := range l.Types {
+			switch {
+			case licenseExceptionTypes[t]:
+				excs = append(excs, t)
+			case !ignorableLicenseTypes[t]:
+				ts = append(ts, t)
+			}
+		}
+		if len(ts) == 1 {
+			for _, e := range excs {
+				ts[0] += " WITH " + e
+			}
+		}
+		if len(ts) > 0 {
+			terms[strings.Join(ts, " AND ")] = true
+		}
+	}
+	for lesser, base := range lesserLicenseBases {
+		if terms[lesser] {
+			delete(terms, base)
+		}
+	}
+	sorted := setToSortedSlice(terms)
+	if len(sorted) > 1 {
+		for i, t := range sorted {
+			if strings.Contains(t, " AND ") {
+				sorted[i] = "(" + t + ")"
+			}
+		}
+	}
+	return strings.Join(sorted, " OR ")
 }
 
 // computeAllLicenseInfo collects all the detected licenses in the zip and
@@ -310,6 +621,9 @@ func (d *Detector) computeAllLicenseInfo() {
 	d.allLicenses = []*License{}
 	d.allLicenses = append(d.allLicenses, d.moduleLicenses...)
 	nonRootLicenses := d.detectFiles(d.Files(NonRootFiles))
+	if d.opts.EmbeddedArchives == ScanArchives {
+		nonRootLicenses = append(nonRootLicenses, d.detectEmbeddedArchives()...)
+	}
 	d.allLicenses = append(d.allLicenses, nonRootLicenses...)
 	d.licsByDir = map[string][]*License{}
 	for _, l := range nonRootLicenses {
@@ -337,7 +651,7 @@ func (d *Detector) Files(which WhichFiles) []*zip.File {
 	prefix := pathPrefix(cdir)
 	var files []*zip.File
 	for _, f := range d.zr.File {
-		if !fileNamesLowercase[strings.ToLower(path.Base(f.Name))] {
+		if !isLicenseFileName(path.Base(f.Name)) {
 			continue
 		}
 		if !strings.HasPrefix(f.Name, prefix) {
@@ -360,6 +674,9 @@ func (d *Detector) Files(which WhichFiles) []*zip.File {
 			// Skip if f is in the vendor directory.
 			continue
 		}
+		if isExcludedFile(strings.TrimPrefix(f.Name, prefix), d.opts.ExcludeDirs) {
+			continue
+		}
 		if err := module.CheckFilePath(f.Name); err != nil {
 			// Skip if the file path is bad.
 			d.logf("module.CheckFilePath(%q): %v", f.Name, err)
@@ -389,6 +706,34 @@ func isVendoredFile(name string) bool {
 	return strings.Contains(name[vendorOffset:], "/")
 }
 
+// isExcludedFile reports whether the file at name, a path relative to the
+// module root, is in a directory matched by one of the patterns. See
+// DetectOptions.ExcludeDirs.
+func isExcludedFile(name string, patterns []string) bool {
+	dir := path.Dir(name)
+	if dir == "." {
+		return false
+	}
+	elems := strings.Split(dir, "/")
+	for _, p := range patterns {
+		p = strings.TrimSuffix(p, "/")
+		for i, e := range elems {
+			var target string
+			if strings.Contains(p, "/") {
+				// Match against the path to each enclosing directory.
+				target = strings.Join(elems[:i+1], "/")
+			} else {
+				target = e
+			}
+			// Validate has checked that the pattern is well formed.
+			if ok, _ := path.Match(p, target); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // detectFiles runs DetectFile on each of the given files.
 // If a file cannot be read, the error is logged and a license
 // of type unknown is added.
@@ -396,34 +741,220 @@ func (d *Detector) detectFiles(files []*zip.File) []*License {
 	prefix := pathPrefix(contentsDir(d.modulePath, d.version))
 	var licenses []*License
 	for _, f := range files {
-		bytes, err := readZipFile(f)
-		if err != nil {
-			d.logf("reading zip file %s: %v", f.Name, err)
-			licenses = append(licenses, &License{
-				Metadata: &Metadata{
-					Types:    []string{unknownLicenseType},
-					FilePath: strings.TrimPrefix(f.Name, prefix),
-				},
-			})
-			continue
+		if lic := d.detectZipFile(f, strings.TrimPrefix(f.Name, prefix)); lic != nil {
+			licenses = append(licenses, lic)
+		}
+	}
+	return licenses
+}
+
+// detectZipFile runs DetectFile on f, whose path relative to the module root
+// is filePath. It returns nil if f should be ignored.
+func (d *Detector) detectZipFile(f *zip.File, filePath string) *License {
+	if d.opts.PartialScanSize > 0 && f.UncompressedSize64 > d.opts.MaxLicenseSize {
+		if lic, archive := d.detectFilePrefix(f, filePath); lic != nil || archive {
+			return lic
 		}
-		types, cov := DetectFile(bytes, f.Name, d.logf)
-		licenses = append(licenses, &License{
+	}
+	bytes, err := readZipFile(f, d.opts.MaxLicenseSize)
+	if err != nil {
+		d.logf("reading zip file %s: %v", f.Name, err)
+		return &License{
 			Metadata: &Metadata{
-				Types:    types,
-				FilePath: strings.TrimPrefix(f.Name, prefix),
-				Coverage: cov,
+				Types:    []string{unknownLicenseType},
+				FilePath: filePath,
 			},
-			Contents: bytes,
-		})
+		}
+	}
+	return d.detectContents(f.Name, filePath, bytes)
+}
+
+// detectContents runs DetectFile on bytes, the contents of the file with the
+// given name in a zip, at filePath relative to the module root. It returns nil
+// if the file should be ignored.
+func (d *Detector) detectContents(name, filePath string, bytes []byte) *License {
+	if isArchive(bytes) {
+		d.logf("%s is an archive, ignoring", name)
+		return nil
+	}
+	types, cov := detectFile(bytes, name, d.logf, d.opts)
+	partial := false
+	if isUnknown(types) && d.opts.PartialScanSize > 0 && uint64(len(bytes)) > d.opts.PartialScanSize {
+		// The license text may be diluted by what follows it.
+		if ptypes, pcov := detectFile(scanPrefix(bytes, d.opts.PartialScanSize), name, d.logf, d.opts); !isUnknown(ptypes) {
+			d.logf("%s classified by scanning its first %d bytes", name, d.opts.PartialScanSize)
+			types, cov, partial = ptypes, pcov, true
+		}
+	}
+	if (isUnknown(types) || isProprietary(types)) && !fileNamesLowercase[strings.ToLower(path.Base(name))] {
+		// A LICENSE-<suffix> file may hold something other than a license,
+		// like LICENSE-THIRD-PARTY notices, which often include copyright
+		// lines. Don't let an unrecognized one make the module
+		// non-redistributable.
+		d.logf("%s is not a recognized license, ignoring", name)
+		return nil
+	}
+	return &License{
+		Metadata: &Metadata{
+			Types:       types,
+			FilePath:    filePath,
+			Coverage:    cov,
+			PartialScan: partial,
+			Copyrights:  parseCopyrights(validUTF8(bytes)),
+		},
+		Contents: bytes,
+	}
+}
+
+// detectFilePrefix classifies the first d.opts.PartialScanSize bytes of f,
+// which is too large to read in full. It returns nil if f cannot be read or no
+// license is detected, and reports whether f holds an archive. The Contents of
+// the returned License are the bytes that were scanned.
+func (d *Detector) detectFilePrefix(f *zip.File, filePath string) (_ *License, archive bool) {
+	rc, err := f.Open()
+	if err != nil {
+		d.logf("reading zip file %s: %v", f.Name, err)
+		return nil, false
+	}
+	defer rc.Close()
+	contents, err := ioutil.ReadAll(io.LimitReader(rc, int64(d.opts.PartialScanSize)))
+	if err != nil {
+		d.logf("reading zip file %s: %v", f.Name, err)
+		return nil, false
+	}
+	if isArchive(contents) {
+		d.logf("%s is an archive, ignoring", f.Name)
+		return nil, true
+	}
+	contents = scanPrefix(contents, d.opts.PartialScanSize)
+	types, cov := detectFile(contents, f.Name, d.logf, d.opts)
+	if isUnknown(types) {
+		return nil, false
+	}
+	d.logf("%s is larger than %d bytes; classified by scanning its first %d bytes",
+		f.Name, d.opts.MaxLicenseSize, d.opts.PartialScanSize)
+	return &License{
+		Metadata: &Metadata{
+			Types:       types,
+			FilePath:    filePath,
+			Coverage:    cov,
+			PartialScan: true,
+			Copyrights:  parseCopyrights(validUTF8(contents)),
+		},
+		Contents: contents,
+	}, false
+}
+
+// detectEmbeddedArchives detects the license files in the zip archives
+// embedded in the module zip, as described for ScanArchives. Each archive and
+// license file is read with what remains of MaxLicenseSize as its limit.
+func (d *Detector) detectEmbeddedArchives() []*License {
+	prefix := pathPrefix(contentsDir(d.modulePath, d.version))
+	budget := d.opts.MaxLicenseSize
+	var licenses []*License
+	for _, f := range d.zr.File {
+		name := strings.TrimPrefix(f.Name, prefix)
+		if !strings.EqualFold(path.Ext(f.Name), ".zip") || !strings.HasPrefix(f.Name, prefix) ||
+			isVendoredFile(f.Name) || isExcludedFile(name, d.opts.ExcludeDirs) {
+			continue
+		}
+		contents, err := readZipFile(f, budget)
+		if err != nil {
+			d.logf("reading embedded archive %s: %v, skipping", f.Name, err)
+			continue
+		}
+		budget -= uint64(len(contents))
+		zr, err := zip.NewReader(bytes.NewReader(contents), int64(len(contents)))
+		if err != nil {
+			d.logf("embedded archive %s: %v", f.Name, err)
+			continue
+		}
+		for _, inner := range zr.File {
+			if !isLicenseFileName(path.Base(inner.Name)) || isVendoredFile(inner.Name) {
+				continue
+			}
+			if err := module.CheckFilePath(inner.Name); err != nil {
+				d.logf("embedded archive %s: module.CheckFilePath(%q): %v", f.Name, inner.Name, err)
+				continue
+			}
+			innerContents, err := readZipFile(inner, budget)
+			if err != nil {
+				d.logf("embedded archive %s: reading %s: %v, skipping", f.Name, inner.Name, err)
+				continue
+			}
+			budget -= uint64(len(innerContents))
+			if lic := d.detectContents(inner.Name, path.Join(name, inner.Name), innerContents); lic != nil {
+				licenses = append(licenses, lic)
+			}
+		}
 	}
 	return licenses
 }
 
+// archivePrefixes are the magic numbers at the start of common archive and
+// compressed file formats.
+var archivePrefixes = [][]byte{
+	[]byte("PK\x03\x04"),         // zip
+	[]byte("PK\x05\x06"),         // empty zip
+	[]byte("\x1f\x8b"),           // gzip
+	[]byte("BZh"),                // bzip2
+	[]byte("\xfd7zXZ\x00"),       // xz
+	[]byte("7z\xbc\xaf\x27\x1c"), // 7z
+}
+
+// isArchive reports whether contents is an archive or compressed file,
+// rather than text.
+func isArchive(contents []byte) bool {
+	for _, p := range archivePrefixes {
+		if bytes.HasPrefix(contents, p) {
+			return true
+		}
+	}
+	// A tar header has the magic "ustar" at offset 257.
+	return len(contents) >= 262 && string(contents[257:262]) == "ustar"
+}
+
+// scanPrefix returns at most the first n bytes of contents to classify,
+// ending at a line break if there is one, so that a word or multi-byte
+// character is not split.
+func scanPrefix(contents []byte, n uint64) []byte {
+	if uint64(len(contents)) <= n {
+		return contents
+	}
+	contents = contents[:n]
+	if i := bytes.LastIndexByte(contents, '\n'); i >= 0 {
+		contents = contents[:i+1]
+	}
+	return contents
+}
+
+// isUnknown reports whether types is the result of failing to detect a
+// license.
+func isUnknown(types []string) bool {
+	return len(types) == 1 && types[0] == unknownLicenseType
+}
+
+// isProprietary reports whether types is the result of detecting a file that
+// reserves all rights; see isAllRightsReserved.
+func isProprietary(types []string) bool {
+	return len(types) == 1 && types[0] == proprietaryLicenseType
+}
+
 // DetectFile return the set of license types for the given file contents. It
 // also returns the licensecheck coverage information. The filename is used
 // solely for logging.
+//
+// Contents that are not valid UTF-8 are transcoded to UTF-8 before they are
+// classified; see toUTF8.
+//
+// Contents that are not recognized as a license but reserve all rights to the
+// work, like a bare copyright notice, are reported as a single license type in
+// the Proprietary category rather than as unknown.
 func DetectFile(contents []byte, filename string, logf func(string, ...interface{})) ([]string, licensecheck.Coverage) {
+	return detectFile(contents, filename, logf, DefaultDetectOptions())
+}
+
+func detectFile(contents []byte, filename string, logf func(string, ...interface{}), opts DetectOptions) ([]string, licensecheck.Coverage) {
 	if logf == nil {
 		logf = func(string, ...interface{}) {}
 	}
@@ -431,18 +962,40 @@ func DetectFile(contents []byte, filename string, logf func(string, ...interface
 		logf("%s is an exception", filename)
 		return types, licensecheck.Coverage{}
 	}
+	if !utf8.Valid(contents) {
+		logf("%s is not valid UTF-8, transcoding", filename)
+		contents = toUTF8(contents)
+	}
+	types, cov := classifyFile(contents, filename, logf, opts)
+	if isUnknown(types) && isAllRightsReserved(contents) {
+		logf("%s reserves all rights without granting a license", filename)
+		return []string{proprietaryLicenseType}, cov
+	}
+	return types, cov
+}
+
+// classifyFile returns the license types that licensecheck finds in contents,
+// which must be valid UTF-8, or unknownLicenseType. If contents holds several
+// texts, they are classified one at a time; see classifySegments.
+func classifyFile(contents []byte, filename string, logf func(string, ...interface{}), opts DetectOptions) ([]string, licensecheck.Coverage) {
 	cov, ok := checker.Cover(contents, licensecheck.Options{})
+	if segments := splitSegments(contents); len(segments) > 1 {
+		if types, ok := classifySegments(segments, opts); ok {
+			logf("%s classified in %d segments", filename, len(segments))
+			return types, cov
+		}
+	}
 	if !ok {
 		logf("%s checker.Cover failed, skipping", filename)
 		return []string{unknownLicenseType}, licensecheck.Coverage{}
 	}
-	if cov.Percent < float64(coverageThreshold) {
+	if cov.Percent < opts.CoverageThreshold {
 		logf("%s license coverage too low (%+v), skipping", filename, cov)
 		return []string{unknownLicenseType}, cov
 	}
 	types := make(map[string]bool)
 	for _, m := range cov.Match {
-		if m.Percent >= classifyThreshold {
+		if m.Percent >= opts.ClassifyThreshold {
 			types[canonicalizeName(m.Name)] = true
 		}
 	}
@@ -460,7 +1013,7 @@ func Redistributable(licenseTypes []string) bool {
 		return false
 	}
 	for _, t := range licenseTypes {
-		if !redistributableLicenseTypes[t] && !ignorableLicenseTypes[t] {
+		if !redistributableLicenseTypes[t] && !ignorableLicenseTypes[t] && !licenseExceptionTypes[t] {
 			return false
 		}
 	}
@@ -500,16 +1053,79 @@ func setToSortedSlice(m map[string]bool) []string {
 	return s
 }
 
-func readZipFile(f *zip.File) ([]byte, error) {
-	if f.UncompressedSize64 > maxLicenseSize {
-		return nil, fmt.Errorf("file size %d exceeds max license size %d", f.UncompressedSize64, maxLicenseSize)
+func readZipFile(f *zip.File, maxSize uint64) ([]byte, error) {
+	if f.UncompressedSize64 > maxSize {
+		return nil, fmt.Errorf("file size %d exceeds max license size %d", f.UncompressedSize64, maxSize)
 	}
 	rc, err := f.Open()
 	if err != nil {
 		return nil, err
 	}
 	defer rc.Close()
-	return ioutil.ReadAll(rc)
+	return readAtMost(rc, maxSize)
+}
+
+// readAtMost reads all of r, or fails if it holds more than maxSize bytes. The
+// size that a zip declares for a file is not trusted: a crafted zip can
+// declare a small file that expands to far more.
+func readAtMost(r io.Reader, maxSize uint64) ([]byte, error) {
+	contents, err := ioutil.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(contents)) > maxSize {
+		return nil, fmt.Errorf("file contents exceed max license size %d", maxSize)
+	}
+	return contents, nil
+}
+
+// validUTF8 returns contents, transcoded by toUTF8 if it is not valid UTF-8.
+func validUTF8(contents []byte) []byte {
+	if utf8.Valid(contents) {
+		return contents
+	}
+	return toUTF8(contents)
+}
+
+// toUTF8 makes a best-effort attempt to transcode contents, which is not valid
+// UTF-8, to UTF-8. Contents that begin with a UTF-16 byte order mark, or that
+// have NUL bytes in most of their odd (or even) positions, are decoded as
+// UTF-16. Anything else is decoded as Latin-1 (ISO 8859-1), which maps every
+// byte to a rune. If decoding fails, contents is returned unchanged.
+func toUTF8(contents []byte) []byte {
+	var enc encoding.Encoding
+	switch {
+	case bytes.HasPrefix(contents, []byte{0xff, 0xfe}), bytes.HasPrefix(contents, []byte{0xfe, 0xff}):
+		// ExpectBOM uses the byte order mark to determine the endianness.
+		enc = unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+	default:
+		// ASCII text encoded as UTF-16 has a zero high byte in every code unit.
+		var evenZeroes, oddZeroes int
+		for i, b := range contents {
+			if b != 0 {
+				continue
+			}
+			if i%2 == 0 {
+				evenZeroes++
+			} else {
+				oddZeroes++
+			}
+		}
+		half := len(contents) / 2
+		switch {
+		case half > 0 && oddZeroes > half/2:
+			enc = unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+		case half > 0 && evenZeroes > half/2:
+			enc = unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)
+		default:
+			enc = charmap.ISO8859_1
+		}
+	}
+	out, err := enc.NewDecoder().Bytes(contents)
+	if err != nil {
+		return contents
+	}
+	return out
 }
 
 func contentsDir(modulePath, version string) string {
@@ -0,0 +1,93 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package licenses
+
+import (
+	"archive/zip"
+	"bytes"
+	"path"
+	"strings"
+	"testing"
+)
+
+// FuzzFilePaths checks that a Detector only considers license files inside the
+// module's contents directory, whatever the names of the entries in the zip.
+// Run it with
+//
+//	go test ./internal/licenses -run '^$' -fuzz FuzzFilePaths
+func FuzzFilePaths(f *testing.F) {
+	for _, name := range []string{
+		"m@v1/LICENSE",
+		"m@v1/foo/LICENSE",
+		"m@v1/../LICENSE",
+		"m@v1/foo/../../LICENSE",
+		"m@v1//LICENSE",
+		"m@v1/./LICENSE",
+		"/m@v1/LICENSE",
+		"/etc/LICENSE",
+		"../m@v1/LICENSE",
+		"m@v1\\..\\LICENSE",
+		"m@v1/LICENSE\x00",
+		"m@v1/\x00/LICENSE",
+		"m@v1x/LICENSE",
+		"LICENSE",
+		"m@v1/vendor/x/LICENSE",
+	} {
+		f.Add(name, "")
+	}
+	f.Fuzz(func(t *testing.T, name, dir string) {
+		if strings.HasSuffix(name, "/") {
+			// A directory, which has no contents.
+			return
+		}
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		// Use CreateHeader rather than Create so that the name is not
+		// altered.
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		if err != nil {
+			return
+		}
+		if _, err := fw.Write([]byte(mitLicense)); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			// The archive was rejected, so there is nothing to detect.
+			return
+		}
+		d := NewDetector("m", "v1", zr, nil)
+		for _, f := range d.Files(AllFiles) {
+			if !strings.HasPrefix(f.Name, "m@v1/") || !isLocalPath(strings.TrimPrefix(f.Name, "m@v1/")) {
+				t.Errorf("Files returned %q, which is outside the contents directory", f.Name)
+			}
+		}
+		check := func(method string, lics []*License) {
+			for _, l := range lics {
+				if !isLocalPath(l.FilePath) {
+					t.Errorf("%s returned a license with FilePath %q, which is outside the contents directory", method, l.FilePath)
+				}
+			}
+		}
+		check("AllLicenses", d.AllLicenses())
+		_, lics := d.PackageInfo(dir)
+		check("PackageInfo", lics)
+	})
+}
+
+// isLocalPath reports whether p is a clean, relative, slash-separated path
+// that does not refer to its parent directory, and so names a file within the
+// directory that p is relative to.
+func isLocalPath(p string) bool {
+	return p != "" && p != "." && path.Clean(p) == p && !path.IsAbs(p) &&
+		p != ".." && !strings.HasPrefix(p, "../") &&
+		!strings.ContainsAny(p, "\\\x00")
+}
@@ -7,8 +7,10 @@ package licenses
 import (
 	"archive/zip"
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"math"
 	"os"
@@ -22,6 +24,8 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	lc "github.com/google/licensecheck"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
 )
 
 const (
@@ -221,6 +225,11 @@ THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES WITH RE
 
 	// unknownLicense is not detectable by the licensecheck package.
 	unknownLicense = `THIS IS A LICENSE THAT I JUST MADE UP. YOU CAN DO WHATEVER YOU WANT WITH THIS CODE, TRUST ME.`
+
+	// allRightsReservedLicense reserves all rights and grants none.
+	allRightsReservedLicense = `Copyright (c) 2020 Example Corp. All rights reserved.
+
+This software is confidential and proprietary information of Example Corp.`
 )
 
 var mitCoverage = lc.Coverage{
@@ -251,18 +260,26 @@ func TestModuleIsRedistributable(t *testing.T) {
 		wantMetas []*Metadata
 	}{
 		{
-			filename:  "xtime",
-			module:    "golang.org/x/time",
-			version:   "v0.0.0-20191024005414-555d28b269f0",
-			want:      true,
-			wantMetas: []*Metadata{{Types: []string{"BSD-3-Clause"}, FilePath: "LICENSE"}},
+			filename: "xtime",
+			module:   "golang.org/x/time",
+			version:  "v0.0.0-20191024005414-555d28b269f0",
+			want:     true,
+			wantMetas: []*Metadata{{
+				Types:      []string{"BSD-3-Clause"},
+				FilePath:   "LICENSE",
+				Copyrights: []Copyright{{Holder: "The Go Authors", FirstYear: 2009, LastYear: 2009}},
+			}},
 		},
 		{
-			filename:  "smasher",
-			module:    "github.com/smasher164/mem",
-			version:   "v0.0.0-20191114064341-4e07bd0f0d69",
-			want:      true,
-			wantMetas: []*Metadata{{Types: []string{"BSD-0-Clause"}, FilePath: "LICENSE.md"}},
+			filename: "smasher",
+			module:   "github.com/smasher164/mem",
+			version:  "v0.0.0-20191114064341-4e07bd0f0d69",
+			want:     true,
+			wantMetas: []*Metadata{{
+				Types:      []string{"BSD-0-Clause"},
+				FilePath:   "LICENSE.md",
+				Copyrights: []Copyright{{Holder: "Akhil Indurti", FirstYear: 2019, LastYear: 2019}},
+			}},
 		},
 		{
 			filename: "gioui",
@@ -270,7 +287,11 @@ func TestModuleIsRedistributable(t *testing.T) {
 			version:  "v0.0.0-20200103103112-ccbcbdbfbd4f",
 			want:     true,
 			wantMetas: []*Metadata{
-				{Types: []string{"MIT"}, FilePath: "LICENSE-MIT"},
+				{
+					Types:      []string{"MIT"},
+					FilePath:   "LICENSE-MIT",
+					Copyrights: []Copyright{{Holder: "The Gio authors", FirstYear: 2019, LastYear: 2019}},
+				},
 				{Types: []string{"Unlicense"}, FilePath: "UNLICENSE"},
 			},
 		},
@@ -280,9 +301,21 @@ func TestModuleIsRedistributable(t *testing.T) {
 			version:  "v0.6.2",
 			want:     true,
 			wantMetas: []*Metadata{
-				{Types: []string{"BSD-3-Clause"}, FilePath: "LICENSE"},
-				{Types: []string{"MIT"}, FilePath: "graph/formats/cytoscapejs/testdata/LICENSE"},
-				{Types: []string{"MIT"}, FilePath: "graph/formats/sigmajs/testdata/LICENSE.txt"},
+				{
+					Types:      []string{"BSD-3-Clause"},
+					FilePath:   "LICENSE",
+					Copyrights: []Copyright{{Holder: "The Gonum Authors", FirstYear: 2013, LastYear: 2013}},
+				},
+				{
+					Types:      []string{"MIT"},
+					FilePath:   "graph/formats/cytoscapejs/testdata/LICENSE",
+					Copyrights: []Copyright{{Holder: "The Cytoscape Consortium", FirstYear: 2016, LastYear: 2018}},
+				},
+				{
+					Types:      []string{"MIT"},
+					FilePath:   "graph/formats/sigmajs/testdata/LICENSE.txt",
+					Copyrights: []Copyright{{Holder: "Alexis Jacomy, http://sigmajs.org", FirstYear: 2013, LastYear: 2014}},
+				},
 			},
 		},
 	} {
@@ -323,6 +356,241 @@ func TestModuleIsRedistributable(t *testing.T) {
 	}
 }
 
+func TestDetectOptionsValidate(t *testing.T) {
+	if err := DefaultDetectOptions().Validate(); err != nil {
+		t.Errorf("DefaultDetectOptions().Validate() = %v, want nil", err)
+	}
+	for _, modify := range []func(*DetectOptions){
+		func(o *DetectOptions) { o.MaxLicenseSize = 0 },
+		func(o *DetectOptions) { o.ClassifyThreshold = 0 },
+		func(o *DetectOptions) { o.CoverageThreshold = 101 },
+		func(o *DetectOptions) { o.PartialScanSize = o.MaxLicenseSize },
+		func(o *DetectOptions) { o.ExcludeDirs = []string{"test[data"} },
+		func(o *DetectOptions) { o.ExcludeDirs = []string{"/"} },
+		func(o *DetectOptions) { o.EmbeddedArchives = ScanArchives + 1 },
+	} {
+		opts := DefaultDetectOptions()
+		modify(&opts)
+		if err := opts.Validate(); err == nil {
+			t.Errorf("%+v: got nil error, want non-nil", opts)
+		}
+		if _, err := NewDetectorWithOptions("m", "v1", newZipReader(t, "m@v1", nil), nil, opts); err == nil {
+			t.Errorf("NewDetectorWithOptions(%+v): got nil error, want non-nil", opts)
+		}
+	}
+}
+
+func TestDetectFilesPartialScan(t *testing.T) {
+	const scanSize = 4096
+	opts := DefaultDetectOptions()
+	opts.MaxLicenseSize = uint64(len(mitLicense) * 10)
+	opts.PartialScanSize = scanSize
+
+	// The first 4KB of each file is the MIT license, padded with blank lines.
+	header := mitLicense + strings.Repeat("\n", scanSize-len(mitLicense))
+	changelog := strings.Repeat("v1.2.3: Fix the frobnicator when the widget is not yet initialized.\n", 300)
+	contents := map[string]string{
+		"LICENSE": header + changelog, // larger than MaxLicenseSize
+		"COPYING": header + changelog[:len(changelog)/10],
+	}
+	if len(contents["LICENSE"]) <= int(opts.MaxLicenseSize) {
+		t.Fatalf("test is broken: LICENSE is not larger than %d bytes", opts.MaxLicenseSize)
+	}
+	if len(contents["COPYING"]) > int(opts.MaxLicenseSize) {
+		t.Fatalf("test is broken: COPYING is larger than %d bytes", opts.MaxLicenseSize)
+	}
+	d, err := NewDetectorWithOptions("m", "v1", newZipReader(t, "m@v1", contents), log.Printf, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := d.detectFiles(d.Files(AllFiles))
+	sort.Slice(got, func(i, j int) bool { return got[i].FilePath < got[j].FilePath })
+	if len(got) != 2 {
+		t.Fatalf("got %d licenses, want 2", len(got))
+	}
+	for _, lic := range got {
+		if diff := cmp.Diff([]string{"MIT"}, lic.Types); diff != "" {
+			t.Errorf("%s: types mismatch (-want +got):\n%s", lic.FilePath, diff)
+		}
+		if !lic.PartialScan {
+			t.Errorf("%s: PartialScan = false, want true", lic.FilePath)
+		}
+	}
+	// The scanned prefix of the oversized file is kept as its contents.
+	if n := len(got[1].Contents); n == 0 || n > scanSize {
+		t.Errorf("LICENSE: got %d bytes of contents, want between 1 and %d", n, scanSize)
+	}
+
+	// Without a partial scan, neither file is classified.
+	opts.PartialScanSize = 0
+	d, err = NewDetectorWithOptions("m", "v1", newZipReader(t, "m@v1", contents), log.Printf, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, lic := range d.detectFiles(d.Files(AllFiles)) {
+		if !isUnknown(lic.Types) || lic.PartialScan {
+			t.Errorf("%s without partial scan: got types %v, PartialScan %t; want unknown, false", lic.FilePath, lic.Types, lic.PartialScan)
+		}
+	}
+}
+
+// countingReaderAt counts the bytes read through it.
+type countingReaderAt struct {
+	r io.ReaderAt
+	n int64
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	c.n += int64(n)
+	return n, err
+}
+
+// largeModuleZip returns a zip for m@v1 with an MIT LICENSE and a large,
+// uncompressed, non-license file.
+func largeModuleZip(t *testing.T, size int) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: "m@v1/data.bin", Method: zip.Store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fw.Write(bytes.Repeat([]byte{'x'}, size)); err != nil {
+		t.Fatal(err)
+	}
+	fw, err = zw.Create("m@v1/LICENSE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(fw, mitLicense); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestNewDetectorFromReaderAt(t *testing.T) {
+	const size = 1 << 20
+	data := largeModuleZip(t, size)
+	r := &countingReaderAt{r: bytes.NewReader(data)}
+	d, err := NewDetectorFromReaderAt("m", "v1", r, int64(len(data)), nil, DefaultDetectOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.ModuleIsRedistributable() {
+		t.Error("ModuleIsRedistributable() = false, want true")
+	}
+	if got := len(d.AllLicenses()); got != 1 {
+		t.Errorf("got %d licenses, want 1", got)
+	}
+	// Only the directory and the license file should have been read.
+	if r.n >= size {
+		t.Errorf("read %d bytes, want fewer than the %d bytes of the non-license file", r.n, size)
+	}
+}
+
+func TestOpenDetector(t *testing.T) {
+	dir, err := ioutil.TempDir("", "licenses")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "m.zip")
+	if err := ioutil.WriteFile(filename, largeModuleZip(t, 1<<10), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := OpenDetector("m", "v1", filename, nil, DefaultDetectOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, lics := d.PackageInfo("")
+	if len(lics) != 1 || !cmp.Equal(lics[0].Types, []string{"MIT"}) {
+		t.Errorf("PackageInfo: got %v, want one MIT license", lics)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := OpenDetector("m", "v1", filepath.Join(dir, "missing.zip"), nil, DefaultDetectOptions()); err == nil {
+		t.Error("OpenDetector of a missing file: got nil error, want non-nil")
+	}
+}
+
+func TestReadZipFileMaxSize(t *testing.T) {
+	zr := newZipReader(t, "m@v1", map[string]string{"LICENSE": mitLicense})
+	max := uint64(len(mitLicense) - 1)
+	_, err := readZipFile(zr.File[0], max)
+	if err == nil {
+		t.Fatal("got nil error, want non-nil")
+	}
+	if want := fmt.Sprint(max); !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %q, want it to mention the limit %s", err, want)
+	}
+}
+
+func TestReadZipFileUndeclaredSize(t *testing.T) {
+	// Make a zip entry whose declared size is smaller than its contents.
+	const declared, actual, max = 10, 1000, 100
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: "m@v1/LICENSE", Method: zip.Store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(fw, strings.Repeat("a", actual)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	// The reader takes the size from the central directory header, at offset
+	// 24 of it.
+	cdir := bytes.Index(b, []byte("PK\x01\x02"))
+	if cdir < 0 {
+		t.Fatal("no central directory header")
+	}
+	binary.LittleEndian.PutUint32(b[cdir+24:], declared)
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := zr.File[0].UncompressedSize64; got != declared {
+		t.Fatalf("got declared size %d, want %d", got, declared)
+	}
+	if got, err := readZipFile(zr.File[0], max); err == nil {
+		t.Errorf("got %d bytes and nil error, want error", len(got))
+	}
+}
+
+func TestReadAtMost(t *testing.T) {
+	for _, test := range []struct {
+		size    int
+		wantErr bool
+	}{
+		{99, false},
+		{100, false},
+		{101, true},
+		{1e6, true},
+	} {
+		got, err := readAtMost(strings.NewReader(strings.Repeat("a", test.size)), 100)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("size %d: got nil error, want error", test.size)
+			} else if !strings.Contains(err.Error(), "100") {
+				t.Errorf("size %d: got error %q, want it to mention the limit 100", test.size, err)
+			}
+			continue
+		}
+		if err != nil || len(got) != test.size {
+			t.Errorf("size %d: got %d bytes, %v; want %[1]d bytes, nil", test.size, len(got), err)
+		}
+	}
+}
+
 func TestRedistributable(t *testing.T) {
 	for _, test := range []struct {
 		types []string
@@ -400,9 +668,246 @@ func TestFiles(t *testing.T) {
 	}
 }
 
+func TestIsLicenseFileName(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		want bool
+	}{
+		{"LICENSE", true},
+		{"license.md", true},
+		{"LICENSE-MIT", true},
+		{"LICENSE-BSD", true},
+		{"licence-zlib.txt", true},
+		{"LICENSE-APACHE-2.0", true},
+		{"LICENSE-", false},
+		{"LICENSE-.txt", false},
+		{"LICENSES", false},
+		{"MY-LICENSE", false},
+		{"license.go", false},
+	} {
+		if got := isLicenseFileName(test.name); got != test.want {
+			t.Errorf("isLicenseFileName(%q) = %t, want %t", test.name, got, test.want)
+		}
+	}
+}
+
+func TestModuleLicenseExpression(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		contents   map[string]string
+		wantRedist bool
+		wantExpr   string
+		wantFiles  []string
+	}{
+		{
+			name: "dual license",
+			contents: map[string]string{
+				"LICENSE-MIT":    mitLicense,
+				"LICENSE-APACHE": apacheSansAppendix,
+			},
+			wantRedist: true,
+			wantExpr:   "Apache-2.0 OR MIT",
+			wantFiles:  []string{"LICENSE-APACHE", "LICENSE-MIT"},
+		},
+		{
+			name: "suffixed name",
+			contents: map[string]string{
+				"LICENSE-EXPAT": mitLicense,
+			},
+			wantRedist: true,
+			wantExpr:   "MIT",
+			wantFiles:  []string{"LICENSE-EXPAT"},
+		},
+		{
+			name: "same license twice",
+			contents: map[string]string{
+				"LICENSE":     mitLicense,
+				"LICENSE-MIT": mitLicense,
+			},
+			wantRedist: true,
+			wantExpr:   "MIT",
+			wantFiles:  []string{"LICENSE", "LICENSE-MIT"},
+		},
+		{
+			name: "unrecognized suffixed file",
+			contents: map[string]string{
+				"LICENSE":             mitLicense,
+				"LICENSE-THIRD-PARTY": "This product includes software developed by others.",
+			},
+			wantRedist: true,
+			wantExpr:   "MIT",
+			wantFiles:  []string{"LICENSE"},
+		},
+		{
+			name: "several types in one file",
+			contents: map[string]string{
+				"LICENSE":     mitLicense + "\n" + bsd0License,
+				"LICENSE-MIT": mitLicense,
+			},
+			wantRedist: true,
+			wantExpr:   "(BSD-0-Clause AND MIT) OR MIT",
+			wantFiles:  []string{"LICENSE", "LICENSE-MIT"},
+		},
+		{
+			// The canonical layout of a GNU project licensed under the LGPL.
+			name: "GNU lesser",
+			contents: map[string]string{
+				"COPYING":        builtinLicenseText(t, "GPL3"),
+				"COPYING.LESSER": builtinLicenseText(t, "LGPL-3.0"),
+			},
+			wantRedist: true,
+			wantExpr:   "LGPL-3.0",
+			wantFiles:  []string{"COPYING", "COPYING.LESSER"},
+		},
+		{
+			name:     "no license",
+			contents: map[string]string{"foo.go": "package foo"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			d := NewDetector("m", "v1", newZipReader(t, "m@v1", test.contents), nil)
+			if got := d.ModuleIsRedistributable(); got != test.wantRedist {
+				t.Errorf("ModuleIsRedistributable() = %t, want %t", got, test.wantRedist)
+			}
+			if got := d.ModuleLicenseExpression(); got != test.wantExpr {
+				t.Errorf("ModuleLicenseExpression() = %q, want %q", got, test.wantExpr)
+			}
+			var gotFiles []string
+			for _, l := range d.ModuleLicenses() {
+				gotFiles = append(gotFiles, l.FilePath)
+			}
+			sort.Strings(gotFiles)
+			if diff := cmp.Diff(test.wantFiles, gotFiles); diff != "" {
+				t.Errorf("ModuleLicenses() file paths mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPublicDomainLicenses(t *testing.T) {
+	for _, test := range []struct {
+		file, licenseType string
+	}{
+		{"UNLICENSE", "Unlicense"},
+		{"LICENSE", "CC0-1.0"},
+		{"COPYING", "CC0-1.0"},
+	} {
+		t.Run(test.file+" "+test.licenseType, func(t *testing.T) {
+			d := NewDetector("m", "v1", newZipReader(t, "m@v1", map[string]string{
+				test.file: builtinLicenseText(t, test.licenseType),
+			}), nil)
+			lics := d.ModuleLicenses()
+			if len(lics) != 1 {
+				t.Fatalf("got %d licenses, want 1", len(lics))
+			}
+			if got, want := lics[0].Types, []string{test.licenseType}; !cmp.Equal(got, want) {
+				t.Errorf("Types = %v, want %v", got, want)
+			}
+			if got := lics[0].Category(); got != PublicDomainEquivalent {
+				t.Errorf("Category() = %q, want %q", got, PublicDomainEquivalent)
+			}
+			if !d.ModuleIsRedistributable() {
+				t.Error("ModuleIsRedistributable() = false, want true")
+			}
+		})
+	}
+}
+
+func TestMetadataCategory(t *testing.T) {
+	for _, test := range []struct {
+		types []string
+		want  string
+	}{
+		{nil, ""},
+		{[]string{"MIT"}, ""},
+		{[]string{"Unlicense"}, PublicDomainEquivalent},
+		{[]string{"CC0-1.0", "Unlicense"}, PublicDomainEquivalent},
+		{[]string{"CC0-1.0", "MIT"}, ""},
+		{[]string{"PROPRIETARY"}, Proprietary},
+	} {
+		m := &Metadata{Types: test.types}
+		if got := m.Category(); got != test.want {
+			t.Errorf("%v: got %q, want %q", test.types, got, test.want)
+		}
+	}
+}
+
+func TestIsAllRightsReserved(t *testing.T) {
+	for _, test := range []struct {
+		contents string
+		want     bool
+	}{
+		{allRightsReservedLicense, true},
+		{"Copyright (c) 2020 Example Inc. ALL RIGHTS\nRESERVED.", true},
+		{"Copyright (c) 2020 Example Inc.", false},
+		{unknownLicense, false},
+		{"Copyright 2020 Example Inc. All rights reserved.\n\nLicensed under the Example License.", false},
+		{"Copyright 2020 Example Inc. All rights reserved.\n\nPermission is hereby granted to use this software.", false},
+	} {
+		if got := isAllRightsReserved([]byte(test.contents)); got != test.want {
+			t.Errorf("isAllRightsReserved(%q) = %t, want %t", test.contents, got, test.want)
+		}
+	}
+}
+
+func TestAllRightsReservedNotRedistributable(t *testing.T) {
+	d := NewDetector("m", "v1", newZipReader(t, "m@v1", map[string]string{
+		"LICENSE":             allRightsReservedLicense,
+		"LICENSE-THIRD-PARTY": allRightsReservedLicense,
+	}), nil)
+	lics := d.ModuleLicenses()
+	if len(lics) != 1 {
+		t.Fatalf("got %d licenses, want 1", len(lics))
+	}
+	if got := lics[0].Category(); got != Proprietary {
+		t.Errorf("Category() = %q, want %q", got, Proprietary)
+	}
+	if d.ModuleIsRedistributable() {
+		t.Error("ModuleIsRedistributable() = true, want false")
+	}
+}
+
+func TestFilesExcludeDirs(t *testing.T) {
+	zr := newZipReader(t, "m@v1", map[string]string{
+		"LICENSE":                      mitLicense,
+		"testdata/LICENSE":             bsd0License,
+		"pkg/testdata/x/LICENSE":       bsd0License,
+		"examples/LICENSE":             bsd0License,
+		"cmd/tool/examples/LICENSE":    bsd0License,
+		"pkg/examples/LICENSE":         mitLicense, // "examples/" matches at any depth
+		"pkg/mytestdata/LICENSE":       mitLicense,
+		"pkg/testdata.go/LICENSE":      mitLicense,
+		"internal/examples2/LICENSE":   mitLicense,
+		"internal/examples2/p/LICENSE": mitLicense,
+	})
+	opts := DefaultDetectOptions()
+	opts.ExcludeDirs = []string{"testdata", "examples/", "cmd/*/examples"}
+	d, err := NewDetectorWithOptions("m", "v1", zr, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, lic := range d.AllLicenses() {
+		got = append(got, lic.FilePath)
+	}
+	want := []string{
+		"LICENSE",
+		"internal/examples2/LICENSE",
+		"internal/examples2/p/LICENSE",
+		"pkg/mytestdata/LICENSE",
+		"pkg/testdata.go/LICENSE",
+	}
+	if diff := cmp.Diff(want, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+	if redist, _ := d.PackageInfo("testdata"); !redist {
+		t.Error("PackageInfo(testdata): got not redistributable, want redistributable")
+	}
+}
+
 func TestDetectFiles(t *testing.T) {
-	defer func(m uint64) { maxLicenseSize = m }(maxLicenseSize)
-	maxLicenseSize = uint64(len(mitLicense) * 10)
+	opts := DefaultDetectOptions()
+	opts.MaxLicenseSize = uint64(len(mitLicense) * 10)
 	testCases := []struct {
 		name     string
 		contents map[string]string
@@ -456,6 +961,15 @@ func TestDetectFiles(t *testing.T) {
 				{Types: []string{"UNKNOWN"}, FilePath: "LICENSE"},
 			},
 		},
+		{
+			name: "all rights reserved",
+			contents: map[string]string{
+				"LICENSE": allRightsReservedLicense,
+			},
+			want: []*Metadata{
+				{Types: []string{"PROPRIETARY"}, FilePath: "LICENSE"},
+			},
+		},
 		{
 			name: "low coverage license",
 			contents: map[string]string{
@@ -504,6 +1018,29 @@ func TestDetectFiles(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "UTF-16 license",
+			contents: map[string]string{
+				"LICENSE": mustEncode(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().String, mitLicense),
+			},
+			want: []*Metadata{{Types: []string{"MIT"}, FilePath: "LICENSE", Coverage: mitCoverage}},
+		},
+		{
+			name: "UTF-16 license without byte order mark",
+			contents: map[string]string{
+				"LICENSE": mustEncode(t, unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewEncoder().String,
+					strings.Replace(mitLicense, "Google Inc", "Société Générale", 1)),
+			},
+			want: []*Metadata{{Types: []string{"MIT"}, FilePath: "LICENSE", Coverage: mitCoverage}},
+		},
+		{
+			name: "Latin-1 license",
+			contents: map[string]string{
+				"LICENSE": mustEncode(t, charmap.ISO8859_1.NewEncoder().String,
+					strings.Replace(mitLicense, "Google Inc", "Société Générale", 1)),
+			},
+			want: []*Metadata{{Types: []string{"MIT"}, FilePath: "LICENSE", Coverage: mitCoverage}},
+		},
 		{
 			name: "apache sans appendix",
 			contents: map[string]string{
@@ -527,7 +1064,10 @@ func TestDetectFiles(t *testing.T) {
 	}
 	for _, test := range testCases {
 		t.Run(test.name, func(t *testing.T) {
-			d := NewDetector("m", "v1", newZipReader(t, "m@v1", test.contents), log.Printf)
+			d, err := NewDetectorWithOptions("m", "v1", newZipReader(t, "m@v1", test.contents), log.Printf, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
 			files := d.Files(AllFiles)
 			gotLics := d.detectFiles(files)
 			sort.Slice(gotLics, func(i, j int) bool {
@@ -541,6 +1081,9 @@ func TestDetectFiles(t *testing.T) {
 			opts := []cmp.Option{
 				cmp.Comparer(coveragePercentEqual),
 				cmpopts.IgnoreFields(lc.Match{}, "Start", "End"),
+				// Copyrights are tested by TestModuleIsRedistributable and
+				// TestParseCopyrights.
+				cmpopts.IgnoreFields(Metadata{}, "Copyrights"),
 			}
 			if diff := cmp.Diff(test.want, got, opts...); diff != "" {
 				t.Errorf("mismatch (-want +got):\n%s", diff)
@@ -648,7 +1191,7 @@ func TestPackageInfo(t *testing.T) {
 				gotMetas = append(gotMetas, l.Metadata)
 			}
 			opts := []cmp.Option{
-				cmpopts.IgnoreFields(Metadata{}, "Coverage"),
+				cmpopts.IgnoreFields(Metadata{}, "Coverage", "Copyrights"),
 				cmpopts.SortSlices(func(m1, m2 *Metadata) bool { return m1.FilePath < m2.FilePath }),
 			}
 			if diff := cmp.Diff(test.wantMetas, gotMetas, opts...); diff != "" {
@@ -691,8 +1234,59 @@ func TestExceptions(t *testing.T) {
 	}
 }
 
+func TestDetectFilePreservesContents(t *testing.T) {
+	// The stored license contents should be the original bytes, even when
+	// they had to be transcoded for classification.
+	utf16 := mustEncode(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().String, mitLicense)
+	d := NewDetector("m", "v1", newZipReader(t, "m@v1", map[string]string{"LICENSE": utf16}), nil)
+	lics := d.ModuleLicenses()
+	if len(lics) != 1 {
+		t.Fatalf("got %d licenses, want 1", len(lics))
+	}
+	if got := string(lics[0].Contents); got != utf16 {
+		t.Errorf("got contents %q, want original bytes %q", got, utf16)
+	}
+	if !d.ModuleIsRedistributable() {
+		t.Error("got not redistributable, want redistributable")
+	}
+}
+
+// mustEncode calls encode on s, failing the test on error.
+// builtinLicenseText returns the text of the license named name that is built
+// into the licensecheck package.
+func builtinLicenseText(t *testing.T, name string) string {
+	t.Helper()
+	for _, l := range lc.BuiltinLicenses() {
+		// Some entries have only a URL.
+		if l.Name == name && l.Text != "" {
+			return l.Text
+		}
+	}
+	t.Fatalf("no builtin license text for %q", name)
+	return ""
+}
+
+func mustEncode(t *testing.T, encode func(string) (string, error), s string) string {
+	t.Helper()
+	e, err := encode(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
 // newZipReader creates an in-memory zip of the given contents and returns a reader to it.
 func newZipReader(t *testing.T, contentsDir string, contents map[string]string) *zip.Reader {
+	b := newZipBytes(t, contentsDir, contents)
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zr
+}
+
+// newZipBytes returns the bytes of a zip of the given contents.
+func newZipBytes(t *testing.T, contentsDir string, contents map[string]string) []byte {
 	var buf bytes.Buffer
 	zw := zip.NewWriter(&buf)
 	for name, content := range contents {
@@ -708,11 +1302,7 @@ func newZipReader(t *testing.T, contentsDir string, contents map[string]string)
 	if err := zw.Close(); err != nil {
 		t.Fatal(err)
 	}
-	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
-	if err != nil {
-		t.Fatal(err)
-	}
-	return zr
+	return buf.Bytes()
 }
 
 // coveragePercentEqual considers two floats the same if they are within 4
@@ -723,3 +1313,123 @@ func coveragePercentEqual(a, b float64) bool {
 	}
 	return math.Abs(a-b) <= 4
 }
+
+func TestDetectFileSegments(t *testing.T) {
+	composite, err := ioutil.ReadFile(filepath.Join("testdata", "COPYING-gpl2-classpath"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gpl2 := builtinLicenseText(t, "GPL2")
+	for _, test := range []struct {
+		name       string
+		contents   string
+		want       []string
+		wantRedist bool
+		wantExpr   string
+	}{
+		{
+			name:       "license with exception",
+			contents:   string(composite),
+			want:       []string{"Classpath-exception-2.0", "GPL2"},
+			wantRedist: true,
+			wantExpr:   "GPL2 WITH Classpath-exception-2.0",
+		},
+		{
+			name:       "two licenses",
+			contents:   mitLicense + "\n\n----------\n\n" + bsd0License,
+			want:       []string{"BSD-0-Clause", "MIT"},
+			wantRedist: true,
+			wantExpr:   "BSD-0-Clause AND MIT",
+		},
+		{
+			name:       "notice segment",
+			contents:   "Copyright 2020 The Authors\n=====\n" + mitLicense,
+			want:       []string{"MIT"},
+			wantRedist: true,
+			wantExpr:   "MIT",
+		},
+		{
+			// An exception does not license anything by itself.
+			name:       "exception only",
+			contents:   string(composite[bytes.Index(composite, []byte("-----")):]),
+			want:       []string{unknownLicenseType},
+			wantRedist: false,
+			wantExpr:   unknownLicenseType,
+		},
+		{
+			// A segment that cannot be classified leaves the file to be
+			// classified as a whole.
+			name:       "unknown segment",
+			contents:   gpl2 + "\n-----\nThis license is not known to anyone at all.\n",
+			want:       []string{"GPL2"},
+			wantRedist: true,
+			wantExpr:   "GPL2",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, _ := DetectFile([]byte(test.contents), "COPYING", nil)
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("DetectFile: got %v, want %v", got, test.want)
+			}
+			d := NewDetector("m", "v1", newZipReader(t, "m@v1", map[string]string{"COPYING": test.contents}), nil)
+			if got := d.ModuleIsRedistributable(); got != test.wantRedist {
+				t.Errorf("ModuleIsRedistributable: got %t, want %t", got, test.wantRedist)
+			}
+			if got := d.ModuleLicenseExpression(); got != test.wantExpr {
+				t.Errorf("ModuleLicenseExpression: got %q, want %q", got, test.wantExpr)
+			}
+		})
+	}
+}
+
+func TestEmbeddedArchives(t *testing.T) {
+	dep := string(newZipBytes(t, "dep@v1", map[string]string{"LICENSE": bsd0License}))
+	// A license file that expands far beyond its compressed size.
+	bomb := string(newZipBytes(t, "bomb@v1", map[string]string{"LICENSE": strings.Repeat("a", 1e6)}))
+	contents := map[string]string{
+		"LICENSE":            mitLicense,
+		"pkg/LICENSE":        dep,
+		"testdata/dep.zip":   dep,
+		"testdata/bomb.zip":  bomb,
+		"vendor/x/y/dep.zip": dep,
+	}
+	for _, test := range []struct {
+		name string
+		mode ArchiveMode
+		want []string
+	}{
+		{
+			name: "skip",
+			mode: SkipArchives,
+			want: []string{"LICENSE"},
+		},
+		{
+			name: "scan",
+			mode: ScanArchives,
+			want: []string{"LICENSE", "testdata/dep.zip/dep@v1/LICENSE"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			opts := DefaultDetectOptions()
+			opts.EmbeddedArchives = test.mode
+			opts.MaxLicenseSize = 1e5
+			d, err := NewDetectorWithOptions("m", "v1", newZipReader(t, "m@v1", contents), t.Logf, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, l := range d.AllLicenses() {
+				got = append(got, l.FilePath)
+			}
+			sort.Strings(got)
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("got licenses %v, want %v", got, test.want)
+			}
+			// The archive in pkg/LICENSE does not make the package
+			// non-redistributable.
+			if redist, _ := d.PackageInfo("pkg"); !redist {
+				t.Error("PackageInfo(pkg): got not redistributable, want redistributable")
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package licenses
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/google/licensecheck"
+)
+
+// Some license files, notably GNU COPYING files, hold more than one text: a
+// license followed by an exception that grants additional permissions, or
+// several licenses one after the other, separated by a line of dashes. The
+// later texts dilute the coverage of the first one, and licensecheck does not
+// know the exceptions, so such files are classified one segment at a time.
+
+var (
+	// segmentSeparatorRegexp matches a line that separates the texts of a
+	// license file. None of the licenses known to licensecheck contain such a
+	// line.
+	segmentSeparatorRegexp = regexp.MustCompile(`^\s*(-{5,}|={5,}|\*{5,})\s*$`)
+
+	// noticeLineRegexp matches a line of a copyright notice.
+	noticeLineRegexp = regexp.MustCompile(`(?i)^\s*(copyright\b|\(c\)|©|all\s+rights\s+reserved)`)
+)
+
+// licenseExceptions are the license exceptions recognized in license file
+// segments, by SPDX identifier. A segment holds an exception if it contains
+// each of the phrases, ignoring case and differences in white space.
+var licenseExceptions = []struct {
+	typ     string
+	phrases []string
+}{
+	{"Classpath-exception-2.0", []string{
+		"linking this library statically or dynamically with other modules is making a combined work based on this library",
+		"as a special exception, the copyright holders of this library give you permission to link this library with independent modules",
+	}},
+	{"GCC-exception-3.1", []string{
+		"gcc runtime library exception",
+		"version 3.1",
+	}},
+	{"LLVM-exception", []string{
+		"llvm exceptions to the apache 2.0 license",
+	}},
+	{"Autoconf-exception-3.0", []string{
+		"autoconf configure script exception",
+	}},
+}
+
+// licenseExceptionTypes is the set of the types in licenseExceptions. They
+// only grant permissions in addition to those of a license, so they do not
+// affect whether a module is redistributable.
+var licenseExceptionTypes = map[string]bool{}
+
+func init() {
+	for _, e := range licenseExceptions {
+		licenseExceptionTypes[e.typ] = true
+	}
+}
+
+// splitSegments splits contents into the texts separated by lines that match
+// segmentSeparatorRegexp. Segments that hold only a copyright notice or a
+// heading are omitted.
+func splitSegments(contents []byte) [][]byte {
+	var (
+		segments [][]byte
+		cur      []byte
+	)
+	flush := func() {
+		if !isNoticeOnly(cur) {
+			segments = append(segments, cur)
+		}
+		cur = nil
+	}
+	for _, line := range bytes.SplitAfter(contents, []byte("\n")) {
+		if segmentSeparatorRegexp.Match(line) {
+			flush()
+			continue
+		}
+		cur = append(cur, line...)
+	}
+	flush()
+	return segments
+}
+
+// isNoticeOnly reports whether every non-blank line of segment is part of a
+// copyright notice or a short heading, like "License".
+func isNoticeOnly(segment []byte) bool {
+	for _, line := range bytes.Split(segment, []byte("\n")) {
+		if n := len(bytes.Fields(line)); n > 4 && !noticeLineRegexp.Match(line) {
+			return false
+		}
+	}
+	return true
+}
+
+// exceptionType returns the type of the license exception in segment, or the
+// empty string if it holds none of licenseExceptions.
+func exceptionType(segment []byte) string {
+	text := strings.ToLower(strings.Join(strings.Fields(string(segment)), " "))
+	for _, e := range licenseExceptions {
+		found := true
+		for _, p := range e.phrases {
+			if !strings.Contains(text, p) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return e.typ
+		}
+	}
+	return ""
+}
+
+// classifySegments classifies each of segments, as a license known to
+// licensecheck or one of licenseExceptions, and returns the union of their
+// types. It reports false if a segment cannot be classified, or if the
+// segments hold only exceptions, because an exception does not license
+// anything by itself.
+func classifySegments(segments [][]byte, opts DetectOptions) ([]string, bool) {
+	types := map[string]bool{}
+	hasLicense := false
+	for _, seg := range segments {
+		if typ := exceptionType(seg); typ != "" {
+			types[typ] = true
+			continue
+		}
+		cov, ok := checker.Cover(seg, licensecheck.Options{})
+		if !ok || cov.Percent < opts.CoverageThreshold {
+			return nil, false
+		}
+		found := false
+		for _, m := range cov.Match {
+			if m.Percent >= opts.ClassifyThreshold {
+				types[canonicalizeName(m.Name)] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, false
+		}
+		hasLicense = true
+	}
+	if !hasLicense {
+		return nil, false
+	}
+	return setToSortedSlice(types), true
+}
@@ -22,11 +22,17 @@ import (
 
 var (
 	mu     sync.Mutex
-	logger interface {
-		log(context.Context, logging.Severity, interface{})
-	} = stdlibLogger{}
+	logger Logger = stdlibLogger{}
+	// minLevel is the lowest severity that is logged.
+	minLevel = logging.Default
 )
 
+// A Logger writes log entries. The payload is a string or a struct, as for
+// Info. Entries below the level set by SetLevel are not passed to the Logger.
+type Logger interface {
+	Log(ctx context.Context, s logging.Severity, payload interface{})
+}
+
 type (
 	// traceIDKey is the type of the context key for trace IDs.
 	traceIDKey struct{}
@@ -58,7 +64,7 @@ type stackdriverLogger struct {
 	sdlogger *logging.Logger
 }
 
-func (l *stackdriverLogger) log(ctx context.Context, s logging.Severity, payload interface{}) {
+func (l *stackdriverLogger) Log(ctx context.Context, s logging.Severity, payload interface{}) {
 	// Convert errors to strings, or they may serialize as the empty JSON object.
 	if err, ok := payload.(error); ok {
 		payload = err.Error()
@@ -85,7 +91,7 @@ func (l *stackdriverLogger) log(ctx context.Context, s logging.Severity, payload
 // stdlibLogger uses the Go standard library logger.
 type stdlibLogger struct{}
 
-func (stdlibLogger) log(ctx context.Context, s logging.Severity, payload interface{}) {
+func (stdlibLogger) Log(ctx context.Context, s logging.Severity, payload interface{}) {
 	var extras []string
 	traceID, _ := ctx.Value(traceIDKey{}).(string) // if not present, traceID is ""
 	if traceID != "" {
@@ -103,7 +109,6 @@ func (stdlibLogger) log(ctx context.Context, s logging.Severity, payload interfa
 		extra = " (" + strings.Join(extras, ", ") + ")"
 	}
 	log.Printf("%s%s: %+v", s, extra, payload)
-
 }
 
 func experimentString(ctx context.Context) string {
@@ -141,6 +146,36 @@ func UseStackdriver(ctx context.Context, cfg *config.Config, logName string) (_
 	return parent, nil
 }
 
+// SetLogger replaces the logger that all logging functions in this package
+// write to. If l is nil, the default logger, which uses the Go standard
+// library logger, is restored. SetLogger should not be called after
+// UseStackdriver.
+func SetLogger(l Logger) {
+	mu.Lock()
+	defer mu.Unlock()
+	if l == nil {
+		l = stdlibLogger{}
+	}
+	logger = l
+}
+
+// SetLevel sets the lowest severity that is logged, from its name, such as
+// "debug", "info" or "error", as understood by logging.ParseSeverity. An empty
+// level logs everything, which is the default.
+func SetLevel(level string) error {
+	s := logging.Default
+	if level != "" {
+		s = logging.ParseSeverity(level)
+		if s == logging.Default && !strings.EqualFold(level, "default") {
+			return fmt.Errorf("log.SetLevel: unknown level %q", level)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	minLevel = s
+	return nil
+}
+
 // Infof logs a formatted string at the Info level.
 func Infof(ctx context.Context, format string, args ...interface{}) {
 	logf(ctx, logging.Info, format, args)
@@ -183,9 +218,12 @@ func Fatal(ctx context.Context, arg interface{}) {
 
 func doLog(ctx context.Context, s logging.Severity, payload interface{}) {
 	mu.Lock()
-	l := logger
+	l, min := logger, minLevel
 	mu.Unlock()
-	l.log(ctx, s, payload)
+	if s < min {
+		return
+	}
+	l.Log(ctx, s, payload)
 }
 
 func die() {
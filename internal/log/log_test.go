@@ -0,0 +1,56 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/logging"
+	"github.com/google/go-cmp/cmp"
+)
+
+type recordingLogger struct {
+	entries []string
+}
+
+func (l *recordingLogger) Log(_ context.Context, s logging.Severity, payload interface{}) {
+	l.entries = append(l.entries, fmt.Sprintf("%s: %v", s, payload))
+}
+
+func TestSetLoggerAndLevel(t *testing.T) {
+	defer SetLogger(nil)
+	defer SetLevel("")
+
+	ctx := context.Background()
+	l := &recordingLogger{}
+	SetLogger(l)
+	Debugf(ctx, "debug %d", 1)
+	Infof(ctx, "info %d", 2)
+	if err := SetLevel("info"); err != nil {
+		t.Fatal(err)
+	}
+	Debug(ctx, "dropped")
+	Info(ctx, "info 3")
+	Errorf(ctx, "error %d", 4)
+
+	want := []string{"Debug: debug 1", "Info: info 2", "Info: info 3", "Error: error 4"}
+	if diff := cmp.Diff(want, l.entries); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	defer SetLevel("")
+	for _, level := range []string{"", "default", "debug", "INFO", "Error"} {
+		if err := SetLevel(level); err != nil {
+			t.Errorf("SetLevel(%q): %v", level, err)
+		}
+	}
+	if err := SetLevel("loud"); err == nil {
+		t.Error(`SetLevel("loud"): got nil, want error`)
+	}
+}
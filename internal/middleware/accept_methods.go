@@ -8,12 +8,21 @@ import "net/http"
 
 // AcceptMethods serves 405 (Method Not Allowed) for any method not on the given list.
 func AcceptMethods(methods ...string) Middleware {
+	return AcceptPathMethods(nil, methods...)
+}
+
+// AcceptPathMethods is like AcceptMethods, but a request whose URL path is a
+// key of paths may also use the methods that paths maps it to, such as POST
+// for an API that takes requests in the body.
+func AcceptPathMethods(paths map[string][]string, methods ...string) Middleware {
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			for _, m := range methods {
-				if r.Method == m {
-					h.ServeHTTP(w, r)
-					return
+			for _, ms := range [][]string{methods, paths[r.URL.Path]} {
+				for _, m := range ms {
+					if r.Method == m {
+						h.ServeHTTP(w, r)
+						return
+					}
 				}
 			}
 			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
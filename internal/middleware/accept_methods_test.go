@@ -52,3 +52,26 @@ func TestAcceptMethods(t *testing.T) {
 		}
 	}
 }
+
+func TestAcceptPathMethods(t *testing.T) {
+	mw := AcceptPathMethods(map[string][]string{"/api": {"POST"}}, "GET")
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, test := range []struct {
+		method, path string
+		want         int
+	}{
+		{"GET", "/", http.StatusOK},
+		{"GET", "/api", http.StatusOK},
+		{"POST", "/api", http.StatusOK},
+		{"POST", "/", http.StatusMethodNotAllowed},
+		{"POST", "/api/x", http.StatusMethodNotAllowed},
+		{"DELETE", "/api", http.StatusMethodNotAllowed},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+		if w.Code != test.want {
+			t.Errorf("%s %s: got code %d, want %d", test.method, test.path, w.Code, test.want)
+		}
+	}
+}
@@ -14,10 +14,10 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/go-redis/redis/v7"
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
+	"golang.org/x/pkgsite/internal/cache"
 	"golang.org/x/pkgsite/internal/log"
 )
 
@@ -72,9 +72,9 @@ func recordCacheError(ctx context.Context, name, operation string) {
 	}, cacheErrors.M(1))
 }
 
-type cache struct {
+type cacheHandler struct {
 	name     string
-	client   *redis.Client
+	cache    cache.Cache
 	delegate http.Handler
 	expirer  Expirer
 }
@@ -89,14 +89,14 @@ func TTL(ttl time.Duration) Expirer {
 	}
 }
 
-// Cache returns a new Middleware that caches every request.
+// Cache returns a new Middleware that caches every request in c.
 // The name of the cache is used only for metrics.
 // The expirer is a func that is used to map a new request to its TTL.
-func Cache(name string, client *redis.Client, expirer Expirer) Middleware {
+func Cache(name string, c cache.Cache, expirer Expirer) Middleware {
 	return func(h http.Handler) http.Handler {
-		return &cache{
+		return &cacheHandler{
 			name:     name,
-			client:   client,
+			cache:    c,
 			delegate: h,
 			expirer:  expirer,
 		}
@@ -105,7 +105,7 @@ func Cache(name string, client *redis.Client, expirer Expirer) Middleware {
 
 const cacheBypassHeader = "x-go-discovery-bypass-cache"
 
-func (c *cache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+func (c *cacheHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// To facilitate load testing and debugging, we check for a magic header that
 	// bypasses the cache. This completely avoids the cached serving path, and
 	// does not write to the cache after success.
@@ -114,7 +114,7 @@ func (c *cache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	ctx := r.Context()
-	key := r.URL.String()
+	key := cacheKey(r)
 	if reader, ok := c.get(ctx, key); ok {
 		recordCacheResult(ctx, c.name, true)
 		if _, err := io.Copy(w, reader); err != nil {
@@ -135,15 +135,32 @@ func (c *cache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (c *cache) get(ctx context.Context, key string) (io.Reader, bool) {
-	// Set a short timeout for redis requests, so that we can quickly
-	// fall back to un-cached serving if redis is unavailable.
+// cacheKey returns the key of the response to r. Pages may contain absolute
+// URLs derived from the scheme and host of the request, so those are part of
+// the key along with the URL; otherwise a single request with forged headers
+// could put links to another host in the page served to everyone.
+func cacheKey(r *http.Request) string {
+	u := *r.URL
+	u.Scheme = Scheme(r)
+	u.Host = r.Host
+	return u.String()
+}
+
+// Scheme returns the scheme of the URL that r was sent to: "https" if it was
+// received over TLS or forwarded from a proxy that was, and "http" otherwise.
+func Scheme(r *http.Request) string {
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		return "https"
+	}
+	return "http"
+}
+
+func (c *cacheHandler) get(ctx context.Context, key string) (io.Reader, bool) {
+	// Set a short timeout for cache requests, so that we can quickly
+	// fall back to un-cached serving if the cache is unavailable.
 	getCtx, cancelGet := context.WithTimeout(ctx, 50*time.Millisecond)
 	defer cancelGet()
-	val, err := c.client.WithContext(getCtx).Get(key).Bytes()
-	if err == redis.Nil {
-		return nil, false
-	}
+	val, ok, err := c.cache.Get(getCtx, key)
 	if err != nil {
 		select {
 		case <-getCtx.Done():
@@ -154,6 +171,9 @@ func (c *cache) get(ctx context.Context, key string) (io.Reader, bool) {
 		recordCacheError(ctx, c.name, "GET")
 		return nil, false
 	}
+	if !ok {
+		return nil, false
+	}
 	zr, err := gzip.NewReader(bytes.NewReader(val))
 	if err != nil {
 		log.Errorf(ctx, "cache: gzip.NewReader: %v", err)
@@ -163,7 +183,7 @@ func (c *cache) get(ctx context.Context, key string) (io.Reader, bool) {
 	return zr, true
 }
 
-func (c *cache) put(ctx context.Context, key string, rec *cacheRecorder, ttl time.Duration) {
+func (c *cacheHandler) put(ctx context.Context, key string, rec *cacheRecorder, ttl time.Duration) {
 	if err := rec.zipWriter.Close(); err != nil {
 		log.Errorf(ctx, "cache: error closing zip for %q: %v", key, err)
 		return
@@ -171,8 +191,7 @@ func (c *cache) put(ctx context.Context, key string, rec *cacheRecorder, ttl tim
 	log.Infof(ctx, "caching response of length %d for %s", rec.buf.Len(), key)
 	setCtx, cancelSet := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancelSet()
-	_, err := c.client.WithContext(setCtx).Set(key, rec.buf.Bytes(), ttl).Result()
-	if err != nil {
+	if err := c.cache.Set(setCtx, key, rec.buf.Bytes(), ttl); err != nil {
 		recordCacheError(ctx, c.name, "SET")
 		log.Errorf(ctx, "cache set %q: %v", key, err)
 	}
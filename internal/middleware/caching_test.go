@@ -17,6 +17,7 @@ import (
 	"github.com/go-redis/redis/v7"
 	"github.com/google/go-cmp/cmp"
 	"go.opencensus.io/stats/view"
+	"golang.org/x/pkgsite/internal/cache"
 )
 
 func TestCache(t *testing.T) {
@@ -43,7 +44,7 @@ func TestCache(t *testing.T) {
 
 	c := redis.NewClient(&redis.Options{Addr: s.Addr()})
 	mux := http.NewServeMux()
-	mux.Handle("/A", Cache("A", c, TTL(1*time.Minute))(handler))
+	mux.Handle("/A", Cache("A", cache.NewRedis(c), TTL(1*time.Minute))(handler))
 	mux.Handle("/B", handler)
 	ts := httptest.NewServer(mux)
 	view.Register(CacheResultCount)
@@ -56,6 +57,8 @@ func TestCache(t *testing.T) {
 		body          string
 		status        int
 		bypass        bool
+		host          string
+		proto         string
 		wantHitCounts map[bool]int
 		wantBody      string
 		wantStatus    int
@@ -129,6 +132,32 @@ func TestCache(t *testing.T) {
 			wantBody:      "6",
 			wantStatus:    http.StatusOK,
 		},
+		{
+			label:         "another host is cached separately",
+			path:          "A",
+			body:          "7",
+			host:          "other.example.com",
+			wantHitCounts: map[bool]int{false: 4, true: 2},
+			wantBody:      "7",
+			wantStatus:    http.StatusOK,
+		},
+		{
+			label:         "another scheme is cached separately",
+			path:          "A",
+			body:          "8",
+			proto:         "https",
+			wantHitCounts: map[bool]int{false: 5, true: 2},
+			wantBody:      "8",
+			wantStatus:    http.StatusOK,
+		},
+		{
+			label:         "the original host is still cached",
+			path:          "A",
+			body:          "9",
+			wantHitCounts: map[bool]int{false: 5, true: 3},
+			wantBody:      "4",
+			wantStatus:    http.StatusOK,
+		},
 	}
 
 	for _, test := range tests {
@@ -142,6 +171,12 @@ func TestCache(t *testing.T) {
 		if test.bypass {
 			req.Header.Set(cacheBypassHeader, "yes")
 		}
+		if test.host != "" {
+			req.Host = test.host
+		}
+		if test.proto != "" {
+			req.Header.Set("X-Forwarded-Proto", test.proto)
+		}
 		resp, err := ts.Client().Do(req)
 		if err != nil {
 			t.Fatal(err)
@@ -0,0 +1,93 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TrustedProxies is a set of networks whose hosts are trusted to append the
+// address of the host they received a request from to the X-Forwarded-For
+// header.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses a list of CIDRs, such as "10.0.0.0/8", into a
+// TrustedProxies. A plain IP address is treated as a network containing only
+// that address.
+func ParseTrustedProxies(cidrs []string) (TrustedProxies, error) {
+	var tp TrustedProxies
+	for _, c := range cidrs {
+		c = strings.TrimSpace(c)
+		if !strings.Contains(c, "/") {
+			ip := net.ParseIP(c)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", c)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			tp = append(tp, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %v", c, err)
+		}
+		tp = append(tp, n)
+	}
+	return tp, nil
+}
+
+// contains reports whether ip is in one of the trusted networks.
+func (tp TrustedProxies) contains(ip net.IP) bool {
+	for _, n := range tp {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the IP address of the client that sent r, or nil if it
+// cannot be determined.
+//
+// The X-Forwarded-For header can be set to anything by a client, so it is
+// only consulted if the request came directly from a trusted proxy. In that
+// case, the chain of addresses in the header is walked from the right, and the
+// first address that is not a trusted proxy is returned. If every address in
+// the chain is trusted, the leftmost one is returned.
+func (tp TrustedProxies) ClientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		// RemoteAddr may not have a port, for example in tests.
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !tp.contains(ip) {
+		return ip
+	}
+
+	var hops []string
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			// A trusted proxy would not have added an unparseable address, so
+			// the chain cannot be trusted beyond this point.
+			return nil
+		}
+		ip = hop
+		if !tp.contains(ip) {
+			break
+		}
+	}
+	return ip
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tp, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1", "2001:db8::/32"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		want       string
+	}{
+		{
+			name:       "no header",
+			remoteAddr: "1.2.3.4:1234",
+			want:       "1.2.3.4",
+		},
+		{
+			name:       "untrusted remote with spoofed header",
+			remoteAddr: "1.2.3.4:1234",
+			xff:        []string{"5.6.7.8"},
+			want:       "1.2.3.4",
+		},
+		{
+			name:       "single trusted proxy",
+			remoteAddr: "10.0.0.1:1234",
+			xff:        []string{"1.2.3.4"},
+			want:       "1.2.3.4",
+		},
+		{
+			name:       "client spoofs header behind trusted proxy",
+			remoteAddr: "10.0.0.1:1234",
+			xff:        []string{"5.6.7.8, 1.2.3.4"},
+			want:       "1.2.3.4",
+		},
+		{
+			name:       "multiple trusted proxy hops",
+			remoteAddr: "10.0.0.1:1234",
+			xff:        []string{"5.6.7.8, 1.2.3.4, 192.168.1.1, 10.1.2.3"},
+			want:       "1.2.3.4",
+		},
+		{
+			name:       "multiple headers",
+			remoteAddr: "10.0.0.1:1234",
+			xff:        []string{"5.6.7.8", "1.2.3.4, 10.1.2.3"},
+			want:       "1.2.3.4",
+		},
+		{
+			name:       "all hops trusted",
+			remoteAddr: "10.0.0.1:1234",
+			xff:        []string{"10.0.0.3, 10.0.0.2"},
+			want:       "10.0.0.3",
+		},
+		{
+			name:       "trusted proxy with no header",
+			remoteAddr: "10.0.0.1:1234",
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "unparseable hop",
+			remoteAddr: "10.0.0.1:1234",
+			xff:        []string{"1.2.3.4, not.an.ip, 10.1.2.3"},
+			want:       "<nil>",
+		},
+		{
+			name:       "IPv6",
+			remoteAddr: "[2001:db8::1]:1234",
+			xff:        []string{"2001:4860::8888"},
+			want:       "2001:4860::8888",
+		},
+		{
+			name:       "remote address without port",
+			remoteAddr: "1.2.3.4",
+			want:       "1.2.3.4",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = test.remoteAddr
+			for _, v := range test.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if got := tp.ClientIP(r).String(); got != test.want {
+				t.Errorf("ClientIP() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	for _, test := range []struct {
+		cidrs   []string
+		ip      string
+		want    bool
+		wantErr bool
+	}{
+		{[]string{"10.0.0.0/8"}, "10.1.2.3", true, false},
+		{[]string{"10.0.0.0/8"}, "11.1.2.3", false, false},
+		{[]string{"1.2.3.4"}, "1.2.3.4", true, false},
+		{[]string{"1.2.3.4"}, "1.2.3.5", false, false},
+		{[]string{"::1"}, "::1", true, false},
+		{[]string{"10.0.0.0/33"}, "", false, true},
+		{[]string{"bad"}, "", false, true},
+	} {
+		tp, err := ParseTrustedProxies(test.cidrs)
+		if (err != nil) != test.wantErr {
+			t.Fatalf("ParseTrustedProxies(%q): got error %v, want error: %t", test.cidrs, err, test.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		if got := tp.contains(net.ParseIP(test.ip)); got != test.want {
+			t.Errorf("ParseTrustedProxies(%q).contains(%q) = %t, want %t", test.cidrs, test.ip, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import "net/http"
+
+// MaxBodySize limits request bodies to n bytes. It serves 413 (Request Entity
+// Too Large) for a request whose declared Content-Length exceeds n. For other
+// requests, reading more than n bytes of the body fails, so a handler that
+// reads the whole body reports an error instead of buffering it.
+func MaxBodySize(n int64) Middleware {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			h.ServeHTTP(w, r)
+		})
+	}
+}
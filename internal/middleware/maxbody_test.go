@@ -0,0 +1,60 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	const max = 10
+	mw := MaxBodySize(max)
+	ts := httptest.NewServer(mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := ioutil.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	})))
+	defer ts.Close()
+	c := ts.Client()
+
+	for _, test := range []struct {
+		name    string
+		size    int
+		chunked bool
+		want    int
+	}{
+		{"empty", 0, false, http.StatusOK},
+		{"at limit", max, false, http.StatusOK},
+		{"over limit", max + 1, false, http.StatusRequestEntityTooLarge},
+		{"chunked at limit", max, true, http.StatusOK},
+		{"chunked over limit", max + 1, true, http.StatusBadRequest},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var body io.Reader = strings.NewReader(strings.Repeat("x", test.size))
+			if test.chunked {
+				// Hide the length, so the request is sent without a
+				// Content-Length.
+				body = ioutil.NopCloser(body)
+			}
+			req, err := http.NewRequest("POST", ts.URL, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := c.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if got := res.StatusCode; got != test.want {
+				t.Errorf("got %d, want %d", got, test.want)
+			}
+		})
+	}
+}
@@ -40,11 +40,14 @@ var (
 
 // Quota implements a simple IP-based rate limiter. Each set of incoming IP
 // addresses with the same low-order byte gets qps requests per second, with the
-// given burst.
+// given burst. The IP address of a request is determined by tp.ClientIP, so
+// X-Forwarded-For is only trusted if it was set by a trusted proxy. With no
+// trusted proxies, every request that arrives through a proxy is limited as if
+// it came from the proxy itself.
 // Information is kept in an LRU cache of size maxEntries.
 //
 // If a request is disallowed, a 429 (TooManyRequests) will be served.
-func Quota(settings config.QuotaSettings) Middleware {
+func Quota(settings config.QuotaSettings, tp TrustedProxies) Middleware {
 	var mu sync.Mutex
 	cache := lru.New(settings.MaxEntries)
 
@@ -59,7 +62,7 @@ func Quota(settings config.QuotaSettings) Middleware {
 				}
 			}
 
-			key := ipKey(r.Header.Get("X-Forwarded-For"))
+			key := clientKey(r, tp)
 			// key is empty if we couldn't parse an IP, or there is no IP.
 			// Fail open in this case: allow serving.
 			var limiter *rate.Limiter
@@ -91,6 +94,20 @@ func recordQuotaMetric(blocked string) {
 	}, quotaResults.M(1))
 }
 
+// clientKey returns the key under which requests from the client that sent r
+// are limited. If tp cannot determine the client, because the X-Forwarded-For
+// chain is malformed, the host that sent r is used instead.
+func clientKey(r *http.Request, tp TrustedProxies) string {
+	if ip := tp.ClientIP(r); ip != nil {
+		return ipKey(ip.String())
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return ipKey(host)
+}
+
 func ipKey(s string) string {
 	fields := strings.SplitN(s, ",", 2)
 	// First field is the originating IP address.
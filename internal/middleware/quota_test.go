@@ -5,6 +5,7 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -17,7 +18,7 @@ import (
 )
 
 func TestQuota(t *testing.T) {
-	mw := Quota(config.QuotaSettings{QPS: 1, Burst: 2, MaxEntries: 1, RecordOnly: boolptr(false)})
+	mw := Quota(config.QuotaSettings{QPS: 1, Burst: 2, MaxEntries: 1, RecordOnly: boolptr(false)}, nil)
 	var npass int
 	h := func(w http.ResponseWriter, r *http.Request) {
 		npass++
@@ -72,7 +73,7 @@ func TestQuota(t *testing.T) {
 
 func TestQuotaRecordOnly(t *testing.T) {
 	// Like TestQuota, but with in RecordOnly mode nothing is actually blocked.
-	mw := Quota(config.QuotaSettings{QPS: 1, Burst: 2, MaxEntries: 1, RecordOnly: boolptr(true)})
+	mw := Quota(config.QuotaSettings{QPS: 1, Burst: 2, MaxEntries: 1, RecordOnly: boolptr(true)}, nil)
 	npass := 0
 	h := func(w http.ResponseWriter, r *http.Request) {
 		npass++
@@ -106,39 +107,79 @@ func TestQuotaRecordOnly(t *testing.T) {
 	}
 }
 
-func TestQuotaBadKey(t *testing.T) {
-	// Verify that invalid IP addresses are not blocked.
-	mw := Quota(config.QuotaSettings{QPS: 1, Burst: 2, MaxEntries: 1, RecordOnly: boolptr(true)})
-	npass := 0
-	h := func(w http.ResponseWriter, r *http.Request) {
-		npass++
+func TestQuotaXForwardedFor(t *testing.T) {
+	// The test server sees requests from 127.0.0.1.
+	trusted, err := ParseTrustedProxies([]string{"127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
 	}
-	ts := httptest.NewServer(mw(http.HandlerFunc(h)))
-	defer ts.Close()
-	c := ts.Client()
-	view.Register(QuotaResultCount)
-	defer view.Unregister(QuotaResultCount)
+	for _, test := range []struct {
+		name    string
+		tp      TrustedProxies
+		header  func(i int) string
+		blocked int
+	}{
+		{
+			// X-Forwarded-For from an untrusted host does not evade the
+			// quota.
+			name:    "spoofed",
+			tp:      nil,
+			header:  func(i int) string { return fmt.Sprintf("1.2.%d.4", i) },
+			blocked: 8,
+		},
+		{
+			// Clients behind a trusted proxy are limited separately.
+			name:    "trusted",
+			tp:      trusted,
+			header:  func(i int) string { return fmt.Sprintf("1.2.%d.4", i) },
+			blocked: 0,
+		},
+		{
+			// A malformed chain limits the host that sent the request.
+			name:    "malformed",
+			tp:      trusted,
+			header:  func(i int) string { return fmt.Sprintf("not.a.valid.ip.%d", i) },
+			blocked: 8,
+		},
+		{
+			name:    "no header",
+			tp:      trusted,
+			header:  func(int) string { return "" },
+			blocked: 8,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			mw := Quota(config.QuotaSettings{QPS: 1, Burst: 2, MaxEntries: 100, RecordOnly: boolptr(true)}, test.tp)
+			ts := httptest.NewServer(mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+			defer ts.Close()
+			c := ts.Client()
+			view.Register(QuotaResultCount)
+			defer view.Unregister(QuotaResultCount)
 
-	const nreq = 100
-	for i := 0; i < nreq; i++ {
-		req, err := http.NewRequest("GET", ts.URL, nil)
-		if err != nil {
-			t.Fatal(err)
-		}
-		req.Header.Add("X-Forwarded-For", "not.a.valid.ip, and more")
-		res, err := c.Do(req)
-		if err != nil {
-			t.Fatal(err)
-		}
-		res.Body.Close()
-	}
-	if npass != nreq {
-		t.Errorf("%d passed, want %d", npass, nreq)
-	}
-	got := collectViewData(t)
-	want := map[bool]int{false: nreq} // no blocking occurred
-	if diff := cmp.Diff(want, got); diff != "" {
-		t.Errorf("mismatch (-want +got):\n%s", diff)
+			const nreq = 10
+			for i := 0; i < nreq; i++ {
+				req, err := http.NewRequest("GET", ts.URL, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if h := test.header(i); h != "" {
+					req.Header.Add("X-Forwarded-For", h)
+				}
+				res, err := c.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				res.Body.Close()
+			}
+			got := collectViewData(t)
+			want := map[bool]int{true: test.blocked, false: nreq - test.blocked}
+			if test.blocked == 0 {
+				delete(want, true)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
 	}
 }
 
@@ -16,7 +16,7 @@ var scriptHashes = []string{
 	"'sha256-CCu0fuIQFBHSCEpfR6ZRzzcczJIS/VGMGrez8LR49WY='",
 	"'sha256-qPGTOKPn+niRiNKQIEX0Ktwuj+D+iPQWIxnlhPicw58='",
 	// From content/static/html/pages/details.tmpl
-	"'sha256-s16e7aT7Gsajq5UH1DbaEFEnNx2VjvS5Xixcxwm4+F8='",
+	"'sha256-mYpn1EugB44LDxAYIkAcdmidMj74nFKkdtFmczDMG7w='",
 	// From content/static/html/pages/pkg_doc.tmpl
 	"'sha256-AvMTqQ+22BA0Nsht+ajju4EQseFQsoG1RxW3Nh6M+wc='",
 	// From content/static/html/worker/index.tmpl
@@ -11,7 +11,8 @@ import (
 )
 
 // Timeout returns a new Middleware that times out each request after the given
-// duration.
+// duration. The frontend responds to a request that fails because it timed
+// out with a 503 Service Unavailable error page.
 func Timeout(d time.Duration) Middleware {
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
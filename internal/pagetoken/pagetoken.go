@@ -0,0 +1,131 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pagetoken encodes the positions of paginated results as opaque page
+// tokens.
+//
+// A token is the base64 encoding of a signed cursor, so a client can only pass
+// back tokens that the server gave it, and the cursor's representation can
+// change without breaking clients that treat tokens as opaque.
+package pagetoken
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// Direction is the direction in which a page continues from its cursor.
+type Direction int
+
+const (
+	// Forward pages hold the items after the cursor.
+	Forward Direction = iota
+	// Backward pages hold the items before the cursor.
+	Backward
+)
+
+// Cursor is the position in a sorted list of results at which a page starts.
+type Cursor struct {
+	// Key is the sort key of the last item before the page, for keyset
+	// pagination. Its elements are the values of the sort columns, in order.
+	Key []string
+	// Offset is the number of items before the page, for offset pagination.
+	Offset int
+	// Direction is the direction of the page from Key or Offset.
+	Direction Direction
+}
+
+// version is the version of the encoding of a cursor in a token. Decode
+// rejects tokens of other versions.
+const version = 1
+
+// payload is the signed part of a token.
+type payload struct {
+	Version   int       `json:"v"`
+	Key       []string  `json:"k,omitempty"`
+	Offset    int       `json:"o,omitempty"`
+	Direction Direction `json:"d,omitempty"`
+}
+
+// A Signer encodes cursors as tokens, and decodes the tokens that it or
+// another Signer with the same key has encoded.
+type Signer struct {
+	key []byte
+}
+
+// NewSigner returns a Signer that signs tokens with key, which should be
+// random and at least 32 bytes long, like a key returned by NewKey. Servers
+// whose tokens are interchangeable must use the same key.
+func NewSigner(key []byte) *Signer {
+	return &Signer{key: key}
+}
+
+// NewKey returns a random key for NewSigner.
+func NewKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Encode returns the token for c.
+func (s *Signer) Encode(c Cursor) (string, error) {
+	p, err := json.Marshal(payload{
+		Version:   version,
+		Key:       c.Key,
+		Offset:    c.Offset,
+		Direction: c.Direction,
+	})
+	if err != nil {
+		return "", err
+	}
+	return s.sign(p), nil
+}
+
+// sign returns the token for the encoded payload p: the base64 encoding of
+// its signature followed by p.
+func (s *Signer) sign(p []byte) string {
+	return base64.RawURLEncoding.EncodeToString(append(s.mac(p), p...))
+}
+
+func (s *Signer) mac(p []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(p)
+	return mac.Sum(nil)
+}
+
+// Decode returns the cursor of token. It returns an error wrapping
+// derrors.InvalidArgument if token was not returned by Encode with the same
+// key, or if it is from an incompatible version of this package.
+func (s *Signer) Decode(token string) (_ Cursor, err error) {
+	defer derrors.Wrap(&err, "Decode(%q)", token)
+
+	invalid := fmt.Errorf("invalid page token: %w", derrors.InvalidArgument)
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) < sha256.Size {
+		return Cursor{}, invalid
+	}
+	sig, p := data[:sha256.Size], data[sha256.Size:]
+	if !hmac.Equal(sig, s.mac(p)) {
+		return Cursor{}, invalid
+	}
+	var pl payload
+	if err := json.Unmarshal(p, &pl); err != nil {
+		return Cursor{}, invalid
+	}
+	if pl.Version != version {
+		return Cursor{}, fmt.Errorf("page token version %d, want %d: %w", pl.Version, version, derrors.InvalidArgument)
+	}
+	if pl.Offset < 0 || (pl.Direction != Forward && pl.Direction != Backward) {
+		return Cursor{}, invalid
+	}
+	return Cursor{Key: pl.Key, Offset: pl.Offset, Direction: pl.Direction}, nil
+}
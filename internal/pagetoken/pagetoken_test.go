@@ -0,0 +1,95 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pagetoken
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+func TestRoundTrip(t *testing.T) {
+	s := NewSigner([]byte("test key"))
+	for _, c := range []Cursor{
+		{},
+		{Offset: 40},
+		{Key: []string{"github.com/a/b", "v1.2.3"}},
+		{Key: []string{"2020-06-01T00:00:00Z", "golang.org/x/net"}, Direction: Backward},
+		{Key: []string{""}, Offset: 5, Direction: Backward},
+	} {
+		token, err := s.Encode(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.Decode(token)
+		if err != nil {
+			t.Fatalf("Decode(Encode(%+v)): %v", c, err)
+		}
+		if diff := cmp.Diff(c, got); diff != "" {
+			t.Errorf("Decode(Encode(%+v)) mismatch (-want +got):\n%s", c, diff)
+		}
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	s := NewSigner([]byte("test key"))
+	token, err := s.Encode(Cursor{Key: []string{"a"}, Offset: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flip := func(i int) string {
+		d := append([]byte(nil), data...)
+		d[i] ^= 1
+		return base64.RawURLEncoding.EncodeToString(d)
+	}
+	otherKey, err := NewSigner([]byte("other key")).Encode(Cursor{Key: []string{"a"}, Offset: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name, token string
+	}{
+		{"empty", ""},
+		{"not base64", "!!!"},
+		{"too short", base64.RawURLEncoding.EncodeToString(data[:10])},
+		{"signature changed", flip(0)},
+		{"payload changed", flip(len(data) - 2)},
+		{"payload removed", base64.RawURLEncoding.EncodeToString(data[:32])},
+		{"other key", otherKey},
+		{"unsigned payload", base64.RawURLEncoding.EncodeToString(data[32:])},
+		{"other version", s.sign([]byte(`{"v":2,"o":10}`))},
+		{"negative offset", s.sign([]byte(`{"v":1,"o":-1}`))},
+		{"unknown direction", s.sign([]byte(`{"v":1,"d":7}`))},
+		{"not JSON", s.sign([]byte(`offset=10`))},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if c, err := s.Decode(test.token); !errors.Is(err, derrors.InvalidArgument) {
+				t.Errorf("Decode(%q) = %+v, %v; want error wrapping %v", test.token, c, err, derrors.InvalidArgument)
+			}
+		})
+	}
+}
+
+func TestNewKey(t *testing.T) {
+	k1, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k2, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(k1) != 32 || string(k1) == string(k2) {
+		t.Errorf("NewKey() = %x, %x; want two different 32-byte keys", k1, k2)
+	}
+}
@@ -6,11 +6,13 @@ package postgres
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/config"
 	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/testing/sample"
 )
 
 var testQueries = []string{
@@ -58,3 +60,118 @@ func BenchmarkSearch(b *testing.B) {
 		}
 	}
 }
+
+// BenchmarkInsertModule measures InsertModule against an empty test database.
+// Like the tests in this package, it runs only if the test database is
+// available.
+func BenchmarkInsertModule(b *testing.B) {
+	ctx := context.Background()
+	for _, bench := range []struct {
+		name   string
+		module func() *internal.Module
+	}{
+		{"default", sample.DefaultModule},
+		{"large", func() *internal.Module { return largeModule(200, 50) }},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			defer ResetTestDB(testDB, b)
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				ResetTestDB(testDB, b)
+				m := bench.module()
+				b.StartTimer()
+				if err := testDB.InsertModule(ctx, m); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// largeModule returns a module with numPackages packages, each of which
+// imports numImports other packages.
+func largeModule(numPackages, numImports int) *internal.Module {
+	var suffixes []string
+	for i := 0; i < numPackages; i++ {
+		suffixes = append(suffixes, fmt.Sprintf("pkg%d", i))
+	}
+	m := sample.Module(sample.ModulePath, sample.VersionString, suffixes...)
+	for _, p := range m.LegacyPackages {
+		p.Imports = nil
+		for i := 0; i < numImports; i++ {
+			p.Imports = append(p.Imports, fmt.Sprintf("example.com/dep%d", i))
+		}
+	}
+	return m
+}
+
+// BenchmarkSearchQueries measures Search against a test database populated
+// with synthetic packages, for several common shapes of query. Unlike
+// BenchmarkSearch, it does not need a populated database, but like the tests
+// in this package, it runs only if the test database is available.
+func BenchmarkSearchQueries(b *testing.B) {
+	ctx := context.Background()
+	defer ResetTestDB(testDB, b)
+	ResetTestDB(testDB, b)
+	if err := insertSearchBenchmarkModules(ctx, 100, 30); err != nil {
+		b.Fatal(err)
+	}
+	for _, bench := range []struct {
+		name, query string
+	}{
+		{"single term", "cloud"},
+		{"path-like", "github.com/bench3/storage"},
+		{"multi-term", "cloud storage client"},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := testDB.Search(ctx, bench.query, 10, 0); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// searchBenchmarkWords are used to build the paths and synopses of the
+// packages inserted by insertSearchBenchmarkModules.
+var searchBenchmarkWords = []string{
+	"cloud", "storage", "client", "http", "json", "errors", "sql", "log",
+	"cache", "auth", "grpc", "yaml",
+}
+
+// insertSearchBenchmarkModules inserts numModules modules with numPackages
+// packages each. Each package imports packages of the modules inserted
+// before it, so that imported-by counts vary, and the search rankings are
+// recomputed afterwards.
+func insertSearchBenchmarkModules(ctx context.Context, numModules, numPackages int) error {
+	var prev []string
+	for i := 0; i < numModules; i++ {
+		var suffixes []string
+		for j := 0; j < numPackages; j++ {
+			suffixes = append(suffixes, fmt.Sprintf("%s%d",
+				searchBenchmarkWords[(i+j)%len(searchBenchmarkWords)], j))
+		}
+		m := sample.Module(fmt.Sprintf("github.com/bench%d/%s", i,
+			searchBenchmarkWords[i%len(searchBenchmarkWords)]), sample.VersionString, suffixes...)
+		for j, p := range m.LegacyPackages {
+			p.Synopsis = fmt.Sprintf("Package %s is a %s %s library.", p.Name,
+				searchBenchmarkWords[j%len(searchBenchmarkWords)],
+				searchBenchmarkWords[(i*j)%len(searchBenchmarkWords)])
+			p.Imports = prev
+		}
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			return err
+		}
+		if len(m.LegacyPackages) > 0 {
+			prev = append(prev, m.LegacyPackages[i%len(m.LegacyPackages)].Path)
+		}
+	}
+	if _, err := testDB.UpdateSearchDocumentsImportedByCount(ctx); err != nil {
+		return err
+	}
+	_, err := testDB.RecomputeSearchRankings(ctx)
+	return err
+}
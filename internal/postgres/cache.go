@@ -0,0 +1,195 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"golang.org/x/mod/semver"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/cache"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// sharedCacheKeyVersion is part of every key in the caches. It must be
+// changed whenever the types of cached values change, so that values encoded
+// by older servers are not decoded into the new types.
+const sharedCacheKeyVersion = "v4"
+
+// cacheKey identifies the result of a read method call.
+type cacheKey struct {
+	method, path, modulePath, version string
+}
+
+// String returns the key under which the result is stored in the caches.
+func (k cacheKey) String() string {
+	return fmt.Sprintf("postgres/%s/%s/%s@%s/%s", sharedCacheKeyVersion, k.method, k.modulePath, k.version, k.path)
+}
+
+// cacheable reports whether the results of reads for modulePath and version
+// can be cached. Reads for an unknown module path, or for a version like
+// "latest" or "master", can change when new versions are inserted.
+func cacheable(modulePath, version string) bool {
+	return modulePath != internal.UnknownModulePath && semver.IsValid(version)
+}
+
+// cachedRead returns the result for key, from the caches if possible and
+// otherwise by calling read, whose successful results are added to the caches.
+// Concurrent calls for the same key share a single call to read: the first
+// caller runs it, and the others wait for its result, so a burst of requests
+// for an uncached page results in one database query. The waiting callers get
+// the first caller's error too, unless it was caused by the end of the first
+// caller's context while theirs is still live; then they read again. dst is
+// as for getCached.
+func (db *DB) cachedRead(ctx context.Context, key cacheKey, dst interface{}, read func() (interface{}, error)) (interface{}, error) {
+	if v, ok := db.getCached(ctx, key, dst); ok {
+		return v, nil
+	}
+	for {
+		v, err, _ := db.flight.Do(key.String(), func() (interface{}, error) {
+			// Another call may have added the result just after our lookup.
+			if v, ok := db.getCached(ctx, key, dst); ok {
+				return v, nil
+			}
+			v, err := read()
+			if err != nil {
+				return nil, err
+			}
+			db.addCached(ctx, key, v)
+			return v, nil
+		})
+		if err != nil && ctx.Err() == nil &&
+			(errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+			continue
+		}
+		return v, err
+	}
+}
+
+// getCached returns the cached result for key, looking first in memory and then
+// in the shared cache. dst must be a pointer to a variable of the result's
+// type, into which the cached result is decoded. A result from the shared
+// cache is added to the in-memory cache.
+//
+// Errors from the caches are logged and treated as misses.
+func (db *DB) getCached(ctx context.Context, key cacheKey, dst interface{}) (interface{}, bool) {
+	if !cacheable(key.modulePath, key.version) {
+		return nil, false
+	}
+	if db.cache != nil {
+		if data, ok := getCachedData(ctx, "memory", db.cache, key); ok && decodeCached(ctx, key, data, dst) {
+			return reflect.ValueOf(dst).Elem().Interface(), true
+		}
+	}
+	if db.sharedCache == nil {
+		return nil, false
+	}
+	data, ok := getCachedData(ctx, "shared", db.sharedCache, key)
+	if !ok || !decodeCached(ctx, key, data, dst) {
+		return nil, false
+	}
+	if db.cache != nil {
+		_ = db.cache.Set(ctx, key.String(), data, db.cacheTTL)
+	}
+	return reflect.ValueOf(dst).Elem().Interface(), true
+}
+
+// getCachedData returns the encoded result for key from c, the cache called
+// name.
+func getCachedData(ctx context.Context, name string, c cache.Cache, key cacheKey) ([]byte, bool) {
+	data, ok, err := c.Get(ctx, key.String())
+	if err != nil {
+		log.Errorf(ctx, "%s cache get(%q): %v", name, key, err)
+		return nil, false
+	}
+	return data, ok
+}
+
+// decodeCached decodes data, the encoded result for key, into dst, and reports
+// whether it succeeded.
+func decodeCached(ctx context.Context, key cacheKey, data []byte, dst interface{}) bool {
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(dst); err != nil {
+		log.Errorf(ctx, "cache decode(%q): %v", key, err)
+		return false
+	}
+	return true
+}
+
+// addCached caches value, the result for key, in memory and in the shared
+// cache, encoded with encoding/gob.
+func (db *DB) addCached(ctx context.Context, key cacheKey, value interface{}) {
+	if (db.cache == nil && db.sharedCache == nil) || !cacheable(key.modulePath, key.version) {
+		return
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		log.Errorf(ctx, "cache encode(%q): %v", key, err)
+		return
+	}
+	if db.cache != nil {
+		_ = db.cache.Set(ctx, key.String(), buf.Bytes(), db.cacheTTL)
+	}
+	if db.sharedCache != nil {
+		if err := db.sharedCache.Set(ctx, key.String(), buf.Bytes(), db.cacheTTL); err != nil {
+			log.Errorf(ctx, "shared cache set(%q): %v", key, err)
+		}
+	}
+}
+
+// deleteCached removes the cached results for modulePath and version. The keys
+// to remove are computed from paths, the paths of the module version's
+// directories.
+func (db *DB) deleteCached(ctx context.Context, modulePath, version string, paths []string) {
+	keys := []cacheKey{{"LegacyGetModuleInfo", "", modulePath, version}}
+	for _, p := range paths {
+		keys = append(keys,
+			cacheKey{"LegacyGetPackage", p, modulePath, version},
+			cacheKey{"GetDirectoryNew", p, modulePath, version})
+	}
+	for _, key := range keys {
+		if db.cache != nil {
+			_ = db.cache.Delete(ctx, key.String())
+		}
+		if db.sharedCache != nil {
+			if err := db.sharedCache.Delete(ctx, key.String()); err != nil {
+				log.Errorf(ctx, "shared cache delete(%q): %v", key, err)
+			}
+		}
+	}
+}
+
+// cachedPaths returns the paths whose results deleteCached must remove from
+// the caches for modulePath and version. It returns nil if reads are not
+// cached.
+func (db *DB) cachedPaths(ctx context.Context, modulePath, version string) ([]string, error) {
+	if db.cache == nil && db.sharedCache == nil {
+		return nil, nil
+	}
+	query := `
+		SELECT p.path
+		FROM paths p
+		INNER JOIN modules m
+		ON p.module_id = m.id
+		WHERE m.module_path = $1 AND m.version = $2`
+	var paths []string
+	err := db.db.RunQuery(ctx, query, func(rows *sql.Rows) error {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return err
+		}
+		paths = append(paths, p)
+		return nil
+	}, modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
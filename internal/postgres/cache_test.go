@@ -0,0 +1,247 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/cache"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/source"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestCacheable(t *testing.T) {
+	ctx := context.Background()
+	db := &DB{cache: cache.NewMemory(10), cacheTTL: time.Hour}
+
+	// Reads that depend on the latest version are not cached.
+	for _, key := range []cacheKey{
+		{"LegacyGetModuleInfo", "", "a.com", internal.LatestVersion},
+		{"LegacyGetModuleInfo", "", internal.UnknownModulePath, "v1.0.0"},
+	} {
+		db.addCached(ctx, key, &internal.LegacyModuleInfo{})
+		if _, ok := db.getCached(ctx, key, new(*internal.LegacyModuleInfo)); ok {
+			t.Errorf("getCached(%q): got true, want false", key)
+		}
+	}
+
+	// A DB without caches caches nothing.
+	key := cacheKey{"LegacyGetModuleInfo", "", "a.com", "v1.0.0"}
+	nc := &DB{}
+	nc.addCached(ctx, key, &internal.LegacyModuleInfo{})
+	if _, ok := nc.getCached(ctx, key, new(*internal.LegacyModuleInfo)); ok {
+		t.Error("no caches: got true, want false")
+	}
+	nc.deleteCached(ctx, "a.com", "v1.0.0", nil)
+}
+
+func TestReadCacheDB(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	db := NewWithReadCache(testDB.db, ReadCacheOptions{Size: 100, TTL: time.Hour})
+	m := sample.DefaultModule()
+	if err := db.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	pkgPath := m.LegacyPackages[0].Path
+	for i := 0; i < 2; i++ {
+		if _, err := db.LegacyGetPackage(ctx, pkgPath, m.ModulePath, m.Version); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.LegacyGetModuleInfo(ctx, m.ModulePath, m.Version); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.GetDirectoryNew(ctx, pkgPath, m.ModulePath, m.Version); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys := []cacheKey{
+		{"LegacyGetPackage", pkgPath, m.ModulePath, m.Version},
+		{"LegacyGetModuleInfo", "", m.ModulePath, m.Version},
+		{"GetDirectoryNew", pkgPath, m.ModulePath, m.Version},
+	}
+	for _, key := range keys {
+		if _, ok, _ := db.cache.Get(ctx, key.String()); !ok {
+			t.Errorf("%q is not in the cache", key)
+		}
+	}
+
+	// A cache hit does not touch the database.
+	if _, err := testDB.db.Exec(ctx, `UPDATE modules SET commit_time = $1 WHERE module_path = $2`,
+		time.Time{}, m.ModulePath); err != nil {
+		t.Fatal(err)
+	}
+	mi, err := db.LegacyGetModuleInfo(ctx, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mi.CommitTime.IsZero() {
+		t.Error("got the updated commit time, want the cached one")
+	}
+
+	if err := db.DeleteModule(ctx, m.ModulePath, m.Version); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		if _, ok, _ := db.cache.Get(ctx, key.String()); ok {
+			t.Errorf("%q is still in the cache after DeleteModule", key)
+		}
+	}
+	if _, err := db.LegacyGetPackage(ctx, pkgPath, m.ModulePath, m.Version); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("LegacyGetPackage after DeleteModule: got error %v, want %v", err, derrors.NotFound)
+	}
+}
+
+func TestSharedCache(t *testing.T) {
+	ctx := context.Background()
+	shared := cache.NewMemory(0)
+
+	// Two servers share a cache, and one of them also caches in memory.
+	db1 := &DB{cache: cache.NewMemory(10), sharedCache: shared, cacheTTL: time.Hour}
+	db2 := &DB{sharedCache: shared, cacheTTL: time.Hour}
+
+	m := sample.DefaultModule()
+	want := &m.LegacyModuleInfo
+	key := cacheKey{"LegacyGetModuleInfo", "", m.ModulePath, m.Version}
+	db1.addCached(ctx, key, want)
+	if _, ok, _ := shared.Get(ctx, key.String()); !ok {
+		t.Fatalf("%q is not in the shared cache", key)
+	}
+	v, ok := db2.getCached(ctx, key, new(*internal.LegacyModuleInfo))
+	if !ok {
+		t.Fatal("getCached from the shared cache: got false, want true")
+	}
+	if diff := cmp.Diff(want, v, cmp.AllowUnexported(source.Info{})); diff != "" {
+		t.Errorf("getCached mismatch (-want +got):\n%s", diff)
+	}
+
+	pkgKey := cacheKey{"LegacyGetPackage", m.LegacyPackages[0].Path, m.ModulePath, m.Version}
+	db2.addCached(ctx, pkgKey, &internal.LegacyVersionedPackage{LegacyPackage: *m.LegacyPackages[0]})
+	db2.deleteCached(ctx, m.ModulePath, m.Version, []string{m.LegacyPackages[0].Path})
+	for _, k := range []cacheKey{key, pkgKey} {
+		if _, ok, _ := shared.Get(ctx, k.String()); ok {
+			t.Errorf("%q is still in the shared cache after deleteCached", k)
+		}
+	}
+	if _, ok := db1.getCached(ctx, key, new(*internal.LegacyModuleInfo)); !ok {
+		t.Error("deleteCached on one server removed another server's in-memory entry")
+	}
+
+	// Keys include a version, so results encoded for an older schema are
+	// ignored.
+	if got, want := key.String(), "postgres/"+sharedCacheKeyVersion+"/"; got[:len(want)] != want {
+		t.Errorf("key %q does not start with %q", got, want)
+	}
+}
+
+func TestCachedReadCoalescing(t *testing.T) {
+	ctx := context.Background()
+	db := &DB{cache: cache.NewMemory(10), cacheTTL: time.Hour}
+	key := cacheKey{"LegacyGetModuleInfo", "", "a.com", "v1.0.0"}
+	want := &internal.LegacyModuleInfo{LegacyReadmeFilePath: "README.md"}
+
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	read := func() (interface{}, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		// Give the other goroutines time to wait for this call.
+		time.Sleep(50 * time.Millisecond)
+		return want, nil
+	}
+
+	const n = 50
+	var wg sync.WaitGroup
+	results := make([]interface{}, n)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = db.cachedRead(ctx, key, new(*internal.LegacyModuleInfo), read)
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("read was called %d times, want 1", calls)
+	}
+	for i := range results {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		if diff := cmp.Diff(want, results[i], cmp.AllowUnexported(source.Info{})); diff != "" {
+			t.Fatalf("cachedRead mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	// Errors are shared by the waiting callers, but not cached.
+	wantErr := errors.New("bad")
+	key.version = "v1.1.0"
+	calls = 0
+	read = func() (interface{}, error) {
+		calls++
+		return nil, wantErr
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := db.cachedRead(ctx, key, new(*internal.LegacyModuleInfo), read); err != wantErr {
+			t.Errorf("got error %v, want %v", err, wantErr)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("read was called %d times, want 2", calls)
+	}
+}
+
+func TestCachedReadCanceled(t *testing.T) {
+	ctx := context.Background()
+	db := &DB{cache: cache.NewMemory(10), cacheTTL: time.Hour}
+	key := cacheKey{"LegacyGetModuleInfo", "", "a.com", "v1.0.0"}
+	want := &internal.LegacyModuleInfo{LegacyReadmeFilePath: "README.md"}
+
+	// The first caller's read fails when its context is canceled, while
+	// another caller is waiting for it.
+	ctx1, cancel := context.WithCancel(ctx)
+	started := make(chan struct{})
+	errc := make(chan error, 1)
+	go func() {
+		_, err := db.cachedRead(ctx1, key, new(*internal.LegacyModuleInfo), func() (interface{}, error) {
+			close(started)
+			<-ctx1.Done()
+			return nil, ctx1.Err()
+		})
+		errc <- err
+	}()
+	<-started
+	go func() {
+		// Give the second caller time to wait for the first.
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	got, err := db.cachedRead(ctx, key, new(*internal.LegacyModuleInfo), func() (interface{}, error) {
+		return want, nil
+	})
+	if err != nil {
+		t.Fatalf("waiting caller: got error %v, want nil", err)
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(source.Info{})); diff != "" {
+		t.Errorf("cachedRead mismatch (-want +got):\n%s", diff)
+	}
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("first caller: got error %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// GetChangelog returns the changelog of the module version, or nil if it has
+// none. It returns an error wrapping derrors.NotFound if the module version is
+// not in the database.
+func (db *DB) GetChangelog(ctx context.Context, modulePath, version string) (_ *internal.Changelog, err error) {
+	defer derrors.Wrap(&err, "GetChangelog(ctx, %q, %q)", modulePath, version)
+
+	var filePath, contents sql.NullString
+	err = db.db.QueryRow(ctx, `
+		SELECT changelog_file_path, changelog_contents
+		FROM modules
+		WHERE module_path = $1 AND version = $2`,
+		modulePath, version).Scan(&filePath, &contents)
+	switch err {
+	case nil:
+	case sql.ErrNoRows:
+		return nil, fmt.Errorf("%s@%s: %w", modulePath, version, derrors.NotFound)
+	default:
+		return nil, err
+	}
+	if !filePath.Valid {
+		return nil, nil
+	}
+	return &internal.Changelog{Filepath: filePath.String, Contents: contents.String}, nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+const (
+	// maxDependencyTreeDepth is the largest depth that GetDependencyTree
+	// accepts.
+	maxDependencyTreeDepth = 10
+
+	// maxDependencyTreePackages is the largest number of packages, including
+	// the root, that GetDependencyTree returns.
+	maxDependencyTreePackages = 2000
+
+	// maxDependencyTreeImports is the largest number of imports that
+	// GetDependencyTree reads. An import is counted once for each depth at
+	// which its importer is reached.
+	maxDependencyTreeImports = 50000
+)
+
+// GetDependencyTree returns the packages that the package with pkgPath,
+// modulePath and version imports, directly or through at most maxDepth imports.
+//
+// The imports table does not record the version of an imported package, so
+// the imports of a dependency are those of its latest version in the database,
+// the one shown on its package page when no version is requested.
+//
+// It returns an error wrapping derrors.InvalidArgument if maxDepth is not
+// between 1 and maxDependencyTreeDepth, or if the tree has more than
+// maxDependencyTreePackages packages or maxDependencyTreeImports imports.
+func (db *DB) GetDependencyTree(ctx context.Context, pkgPath, modulePath, version string, maxDepth int) (_ *internal.DependencyTree, err error) {
+	defer derrors.Wrap(&err, "GetDependencyTree(ctx, %q, %q, %q, %d)", pkgPath, modulePath, version, maxDepth)
+
+	if pkgPath == "" || modulePath == "" || version == "" {
+		return nil, fmt.Errorf("pkgPath, modulePath and version must all be non-empty: %w", derrors.InvalidArgument)
+	}
+	if maxDepth < 1 || maxDepth > maxDependencyTreeDepth {
+		return nil, fmt.Errorf("depth %d is not between 1 and %d: %w", maxDepth, maxDependencyTreeDepth, derrors.InvalidArgument)
+	}
+	var exists bool
+	err = db.db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM packages
+			WHERE path = $1 AND module_path = $2 AND version = $3
+		)`, pkgPath, modulePath, version).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("package %s in %s@%s: %w", pkgPath, modulePath, version, derrors.NotFound)
+	}
+
+	// Each row of deps is an import at the given depth. The recursive term
+	// follows the imports of the latest version of each imported package.
+	// Since UNION discards rows that are already in deps, the imports of a
+	// package are read once for each depth at which it is reached, and an
+	// import cycle is followed no further than maxDepth.
+	query := `
+		WITH RECURSIVE deps (from_path, from_module_path, from_version, to_path, depth) AS (
+			SELECT from_path, from_module_path, from_version, to_path, 1
+			FROM imports
+			WHERE
+				from_path = $1
+				AND from_module_path = $2
+				AND from_version = $3
+			UNION
+			SELECT i.from_path, i.from_module_path, i.from_version, i.to_path, d.depth + 1
+			FROM deps d
+			CROSS JOIN LATERAL (
+				SELECT p.module_path, p.version
+				FROM packages p
+				INNER JOIN modules m
+				ON p.module_path = m.module_path
+				AND p.version = m.version
+				WHERE p.path = d.to_path
+				ORDER BY
+					m.version_type = 'release' DESC,
+					m.sort_version DESC,
+					m.module_path DESC
+				LIMIT 1
+			) latest
+			INNER JOIN imports i
+			ON i.from_path = d.to_path
+			AND i.from_module_path = latest.module_path
+			AND i.from_version = latest.version
+			WHERE d.depth < $4
+		)
+		SELECT from_path, from_module_path, from_version, to_path, depth
+		FROM deps
+		LIMIT $5;`
+
+	root := &internal.Dependency{Path: pkgPath, ModulePath: modulePath, Version: version}
+	deps := map[string]*internal.Dependency{pkgPath: root}
+	imports := map[string]map[string]bool{}
+	nrows := 0
+	collect := func(rows *sql.Rows) error {
+		var (
+			from, to internal.Dependency
+			depth    int
+		)
+		if err := rows.Scan(&from.Path, &from.ModulePath, &from.Version, &to.Path, &depth); err != nil {
+			return fmt.Errorf("rows.Scan(): %v", err)
+		}
+		nrows++
+		d := deps[from.Path]
+		if d == nil {
+			d = &internal.Dependency{Path: from.Path, Depth: depth - 1}
+			deps[from.Path] = d
+		}
+		if d.ModulePath == "" {
+			d.ModulePath, d.Version = from.ModulePath, from.Version
+		} else if d.ModulePath != from.ModulePath || d.Version != from.Version {
+			// A cycle reached the latest version of the root, which is
+			// not the requested one.
+			return nil
+		}
+		if d, ok := deps[to.Path]; !ok {
+			deps[to.Path] = &internal.Dependency{Path: to.Path, Depth: depth}
+		} else if depth < d.Depth {
+			d.Depth = depth
+		}
+		if imports[from.Path] == nil {
+			imports[from.Path] = map[string]bool{}
+		}
+		imports[from.Path][to.Path] = true
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, pkgPath, modulePath, version, maxDepth, maxDependencyTreeImports+1); err != nil {
+		return nil, err
+	}
+	if err := checkDependencyTreeSize(nrows, len(deps), maxDepth); err != nil {
+		return nil, err
+	}
+
+	tree := &internal.DependencyTree{Root: root}
+	for _, d := range deps {
+		for p := range imports[d.Path] {
+			d.Imports = append(d.Imports, p)
+		}
+		sort.Strings(d.Imports)
+		tree.Packages = append(tree.Packages, d)
+	}
+	sort.Slice(tree.Packages, func(i, j int) bool {
+		pi, pj := tree.Packages[i], tree.Packages[j]
+		if pi.Depth != pj.Depth {
+			return pi.Depth < pj.Depth
+		}
+		return pi.Path < pj.Path
+	})
+	return tree, nil
+}
+
+// checkDependencyTreeSize returns an error wrapping derrors.InvalidArgument if
+// a dependency tree within maxDepth, for which nimports imports were read and
+// which has npackages packages, is too large to return.
+func checkDependencyTreeSize(nimports, npackages, maxDepth int) error {
+	if nimports > maxDependencyTreeImports {
+		return fmt.Errorf("more than %d imports within depth %d: %w", maxDependencyTreeImports, maxDepth, derrors.InvalidArgument)
+	}
+	if npackages > maxDependencyTreePackages {
+		return fmt.Errorf("%d packages within depth %d, more than %d: %w", npackages, maxDepth, maxDependencyTreePackages, derrors.InvalidArgument)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetDependencyTree(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	for _, m := range []struct {
+		path, version string
+		imports       []string
+	}{
+		{"a.com/a", "v1.0.0", []string{"b.com/b", "fmt"}},
+		{"b.com/b", "v1.0.0", []string{"c.com/c"}},
+		// The latest version of b.com/b is the one whose imports are followed.
+		{"b.com/b", "v1.1.0", []string{"c.com/c", "d.com/d"}},
+		// c.com/c completes the cycle a -> b -> c -> a.
+		{"c.com/c", "v1.0.0", []string{"a.com/a"}},
+	} {
+		mod := sample.Module(m.path, m.version, "")
+		mod.LegacyPackages[0].Imports = m.imports
+		if err := testDB.InsertModule(ctx, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root := &internal.Dependency{Path: "a.com/a", ModulePath: "a.com/a", Version: "v1.0.0", Imports: []string{"b.com/b", "fmt"}}
+	for _, test := range []struct {
+		maxDepth int
+		want     []*internal.Dependency
+	}{
+		{
+			maxDepth: 1,
+			want: []*internal.Dependency{
+				root,
+				{Path: "b.com/b", Depth: 1},
+				{Path: "fmt", Depth: 1},
+			},
+		},
+		{
+			maxDepth: 10,
+			want: []*internal.Dependency{
+				root,
+				{Path: "b.com/b", ModulePath: "b.com/b", Version: "v1.1.0", Depth: 1, Imports: []string{"c.com/c", "d.com/d"}},
+				{Path: "fmt", Depth: 1},
+				{Path: "c.com/c", ModulePath: "c.com/c", Version: "v1.0.0", Depth: 2, Imports: []string{"a.com/a"}},
+				{Path: "d.com/d", Depth: 2},
+			},
+		},
+	} {
+		got, err := testDB.GetDependencyTree(ctx, "a.com/a", "a.com/a", "v1.0.0", test.maxDepth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := &internal.DependencyTree{Root: test.want[0], Packages: test.want}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("maxDepth %d: mismatch (-want +got):\n%s", test.maxDepth, diff)
+		}
+	}
+}
+
+func TestGetDependencyTreeErrors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	m := sample.Module(sample.ModulePath, sample.VersionString, "")
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name                string
+		pkgPath, modulePath string
+		maxDepth            int
+		want                error
+	}{
+		{"zero depth", sample.ModulePath, sample.ModulePath, 0, derrors.InvalidArgument},
+		{"too deep", sample.ModulePath, sample.ModulePath, maxDependencyTreeDepth + 1, derrors.InvalidArgument},
+		{"not found", "not.found/pkg", "not.found/pkg", 1, derrors.NotFound},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := testDB.GetDependencyTree(ctx, test.pkgPath, test.modulePath, sample.VersionString, test.maxDepth)
+			if !errors.Is(err, test.want) {
+				t.Errorf("got %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestCheckDependencyTreeSize(t *testing.T) {
+	for _, test := range []struct {
+		name                string
+		nimports, npackages int
+		want                error
+	}{
+		{"within limits", maxDependencyTreeImports, maxDependencyTreePackages, nil},
+		{"too many imports", maxDependencyTreeImports + 1, 1, derrors.InvalidArgument},
+		{"too many packages", 1, maxDependencyTreePackages + 1, derrors.InvalidArgument},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if err := checkDependencyTreeSize(test.nimports, test.npackages, 1); !errors.Is(err, test.want) {
+				t.Errorf("got %v, want %v", err, test.want)
+			}
+		})
+	}
+}
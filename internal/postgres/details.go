@@ -11,12 +11,15 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/lib/pq"
+	"golang.org/x/mod/semver"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/database"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/stdlib"
 	"golang.org/x/pkgsite/internal/version"
 )
 
@@ -33,8 +36,10 @@ func (db *DB) LegacyGetPackagesInModule(ctx context.Context, modulePath, version
 		license_paths,
 		redistributable,
 		documentation,
+		documentation_ref,
 		goos,
-		goarch
+		goarch,
+		stdlib_only
 	FROM
 		packages
 	WHERE
@@ -42,15 +47,19 @@ func (db *DB) LegacyGetPackagesInModule(ctx context.Context, modulePath, version
 		AND version = $2
 	ORDER BY path;`
 
-	var packages []*internal.LegacyPackage
+	var (
+		packages []*internal.LegacyPackage
+		docRefs  []sql.NullString
+	)
 	collect := func(rows *sql.Rows) error {
 		var (
 			p                          internal.LegacyPackage
 			licenseTypes, licensePaths []string
+			docRef                     sql.NullString
 		)
 		if err := rows.Scan(&p.Path, &p.Name, &p.Synopsis, &p.V1Path, pq.Array(&licenseTypes),
 			pq.Array(&licensePaths), &p.IsRedistributable, database.NullIsEmpty(&p.DocumentationHTML),
-			&p.GOOS, &p.GOARCH); err != nil {
+			&docRef, &p.GOOS, &p.GOARCH, &p.StdlibOnly); err != nil {
 			return fmt.Errorf("row.Scan(): %v", err)
 		}
 		lics, err := zipLicenseMetadata(licenseTypes, licensePaths)
@@ -59,12 +68,16 @@ func (db *DB) LegacyGetPackagesInModule(ctx context.Context, modulePath, version
 		}
 		p.Licenses = lics
 		packages = append(packages, &p)
+		docRefs = append(docRefs, docRef)
 		return nil
 	}
 
 	if err := db.db.RunQuery(ctx, query, collect, modulePath, version); err != nil {
 		return nil, fmt.Errorf("DB.LegacyGetPackagesInModule(ctx, %q, %q): %w", modulePath, version, err)
 	}
+	if err := db.getPackagesDocumentation(ctx, packages, docRefs); err != nil {
+		return nil, fmt.Errorf("DB.LegacyGetPackagesInModule(ctx, %q, %q): %w", modulePath, version, err)
+	}
 	return packages, nil
 }
 
@@ -140,6 +153,78 @@ func getPackageVersions(ctx context.Context, db *DB, pkgPath string, versionType
 	return versionHistory, nil
 }
 
+// GetPackageVersions returns every module version that contains the package
+// with pkgPath, in any module, sorted in descending semver order. Each result
+// records whether its module path is canonical, using the alternative module
+// paths found in module_version_states.
+//
+// It returns an error wrapping derrors.NotFound if no module contains pkgPath.
+func (db *DB) GetPackageVersions(ctx context.Context, pkgPath string) (_ []*internal.VersionedPackage, err error) {
+	defer derrors.Wrap(&err, "GetPackageVersions(ctx, %q)", pkgPath)
+
+	query := `
+		SELECT
+			p.path,
+			p.module_path,
+			p.version,
+			m.commit_time,
+			m.version_type,
+			m.redistributable,
+			m.has_go_mod,
+			m.source_info,
+			s.go_mod_path
+		FROM
+			packages p
+		INNER JOIN
+			modules m
+		ON
+			p.module_path = m.module_path
+			AND p.version = m.version
+		LEFT JOIN LATERAL (
+			-- The latest later version of the module with an alternative
+			-- module path, if any.
+			SELECT go_mod_path
+			FROM module_version_states
+			WHERE
+				module_path = p.module_path
+				AND sort_version > m.sort_version
+				AND status = $2
+			ORDER BY sort_version DESC
+			LIMIT 1
+		) s ON true
+		WHERE
+			p.path = $1
+		ORDER BY
+			m.sort_version DESC,
+			p.module_path;`
+
+	var vps []*internal.VersionedPackage
+	collect := func(rows *sql.Rows) error {
+		var (
+			vp        internal.VersionedPackage
+			hasGoMod  sql.NullBool
+			goModPath sql.NullString
+		)
+		if err := rows.Scan(&vp.Path, &vp.ModulePath, &vp.Version, &vp.CommitTime,
+			&vp.VersionType, &vp.IsRedistributable, &hasGoMod, jsonbScanner{&vp.SourceInfo},
+			&goModPath); err != nil {
+			return fmt.Errorf("row.Scan(): %v", err)
+		}
+		setHasGoMod(&vp.ModuleInfo, hasGoMod)
+		vp.IsCanonical = !goModPath.Valid
+		vp.CanonicalModulePath = goModPath.String
+		vps = append(vps, &vp)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, pkgPath, derrors.ToHTTPStatus(derrors.AlternativeModule)); err != nil {
+		return nil, err
+	}
+	if len(vps) == 0 {
+		return nil, fmt.Errorf("package %q: %w", pkgPath, derrors.NotFound)
+	}
+	return vps, nil
+}
+
 // versionTypeExpr returns a comma-separated list of version types,
 // for use in a clause like "WHERE version_type IN (%s)"
 func versionTypeExpr(vts []version.Type) string {
@@ -201,6 +286,63 @@ func getModuleVersions(ctx context.Context, db *DB, modulePath string, versionTy
 	return vinfos, nil
 }
 
+// A SeriesMajor is a major version of a module series, with the versions of
+// the series that have it. See DB.GetSeries.
+type SeriesMajor struct {
+	// Major is the major version, like "v2".
+	Major string
+	// Versions are the versions of the major version, of all version types,
+	// sorted in descending semver order. A major version above v1 may have
+	// versions of two modules: those of the module whose path has the major
+	// version suffix, and +incompatible versions of the module at the series
+	// path.
+	Versions []*internal.ModuleInfo
+}
+
+// GetSeries returns all the versions of the modules in the series with
+// seriesPath, such as example.com/m for example.com/m and example.com/m/v2,
+// grouped by major version. The major versions are in ascending order.
+// It returns an error wrapping derrors.NotFound if the series has no
+// versions in the database.
+func (db *DB) GetSeries(ctx context.Context, seriesPath string) (_ []*SeriesMajor, err error) {
+	defer derrors.Wrap(&err, "DB.GetSeries(ctx, %q)", seriesPath)
+
+	query := `
+		SELECT module_path, version, commit_time, version_type
+		FROM modules
+		WHERE series_path = $1
+		ORDER BY
+			sort_version DESC,
+			module_path DESC;`
+
+	// The rows are in descending semver order, so the versions of each major
+	// version are adjacent, and the major versions are in descending order.
+	var majors []*SeriesMajor
+	collect := func(rows *sql.Rows) error {
+		var mi internal.ModuleInfo
+		if err := rows.Scan(&mi.ModulePath, &mi.Version, &mi.CommitTime, &mi.VersionType); err != nil {
+			return fmt.Errorf("row.Scan(): %v", err)
+		}
+		major := semver.Major(mi.Version)
+		if len(majors) == 0 || majors[len(majors)-1].Major != major {
+			majors = append(majors, &SeriesMajor{Major: major})
+		}
+		m := majors[len(majors)-1]
+		m.Versions = append(m.Versions, &mi)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, seriesPath); err != nil {
+		return nil, err
+	}
+	if len(majors) == 0 {
+		return nil, fmt.Errorf("series %q: %w", seriesPath, derrors.NotFound)
+	}
+	for i, j := 0, len(majors)-1; i < j; i, j = i+1, j-1 {
+		majors[i], majors[j] = majors[j], majors[i]
+	}
+	return majors, nil
+}
+
 // GetImports fetches and returns all of the imports for the package with
 // pkgPath, modulePath and version.
 //
@@ -238,6 +380,119 @@ func (db *DB) GetImports(ctx context.Context, pkgPath, modulePath, version strin
 	return imports, nil
 }
 
+// GetImportsInModule returns the import paths imported by each package in the
+// module version, keyed by package path. Packages without imports are not in
+// the map. It returns the same imports as calling GetImports for every package,
+// with one query.
+func (db *DB) GetImportsInModule(ctx context.Context, modulePath, version string) (_ map[string][]string, err error) {
+	defer derrors.Wrap(&err, "DB.GetImportsInModule(ctx, %q, %q)", modulePath, version)
+
+	query := `
+		SELECT from_path, to_path
+		FROM imports
+		WHERE
+			from_module_path = $1
+			AND from_version = $2
+		ORDER BY
+			from_path,
+			to_path;`
+
+	imports := map[string][]string{}
+	collect := func(rows *sql.Rows) error {
+		var fromPath, toPath string
+		if err := rows.Scan(&fromPath, &toPath); err != nil {
+			return fmt.Errorf("row.Scan(): %v", err)
+		}
+		imports[fromPath] = append(imports[fromPath], toPath)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, modulePath, version); err != nil {
+		return nil, err
+	}
+	return imports, nil
+}
+
+// ImportsDiff is the difference between the imports of two versions of a
+// module. See DB.GetImportsDiff.
+type ImportsDiff struct {
+	// Added are the import paths that only the second version imports, and
+	// Removed those that only the first one imports, in sorted order.
+	Added, Removed []string
+}
+
+// GetImportsDiff returns the import paths that were added and removed between
+// version v1 and version v2 of the module, across all of its packages. Imports
+// of packages whose paths are in the module, such as modulePath/internal/foo,
+// are left out, so that the diff shows the module's dependencies. It returns an
+// error wrapping derrors.NotFound if either version is not in the database.
+func (db *DB) GetImportsDiff(ctx context.Context, modulePath, v1, v2 string) (_ *ImportsDiff, err error) {
+	defer derrors.Wrap(&err, "DB.GetImportsDiff(ctx, %q, %q, %q)", modulePath, v1, v2)
+
+	if modulePath == "" || v1 == "" || v2 == "" {
+		return nil, fmt.Errorf("modulePath, v1 and v2 must all be non-empty: %w", derrors.InvalidArgument)
+	}
+	imports1, err := db.getModuleImports(ctx, modulePath, v1)
+	if err != nil {
+		return nil, err
+	}
+	imports2, err := db.getModuleImports(ctx, modulePath, v2)
+	if err != nil {
+		return nil, err
+	}
+	diff := &ImportsDiff{}
+	for p := range imports2 {
+		if !imports1[p] {
+			diff.Added = append(diff.Added, p)
+		}
+	}
+	for p := range imports1 {
+		if !imports2[p] {
+			diff.Removed = append(diff.Removed, p)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	return diff, nil
+}
+
+// getModuleImports returns the set of paths that the packages of the module at
+// version import, other than the paths in the module. It returns an error
+// wrapping derrors.NotFound if the module version is not in the database.
+func (db *DB) getModuleImports(ctx context.Context, modulePath, version string) (_ map[string]bool, err error) {
+	var x int
+	err = db.db.QueryRow(ctx, `SELECT 1 FROM modules WHERE module_path = $1 AND version = $2`,
+		modulePath, version).Scan(&x)
+	switch err {
+	case nil:
+	case sql.ErrNoRows:
+		return nil, fmt.Errorf("%s@%s is not indexed: %w", modulePath, version, derrors.NotFound)
+	default:
+		return nil, err
+	}
+
+	query := `
+		SELECT DISTINCT to_path
+		FROM imports
+		WHERE
+			from_module_path = $1
+			AND from_version = $2
+			AND to_path != $1
+			AND to_path NOT LIKE $3;`
+	imports := map[string]bool{}
+	collect := func(rows *sql.Rows) error {
+		var toPath string
+		if err := rows.Scan(&toPath); err != nil {
+			return fmt.Errorf("row.Scan(): %v", err)
+		}
+		imports[toPath] = true
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, modulePath, version, escapeLike(modulePath)+"/%"); err != nil {
+		return nil, err
+	}
+	return imports, nil
+}
+
 // GetImportedBy fetches and returns all of the packages that import the
 // package with path.
 // The returned error may be checked with derrors.IsInvalidArgument to
@@ -277,11 +532,201 @@ func (db *DB) GetImportedBy(ctx context.Context, pkgPath, modulePath string, lim
 	return importedby, nil
 }
 
+// importersWhere is the WHERE clause used by GetImporters and CountImporters.
+// It selects rows of imports_unique that import the package $1, excluding
+// importers that are in the same module as $1. As in
+// computeImportedByCounts, that check is approximated by seeing if the
+// importer's module path is a prefix of $1.
+const importersWhere = `
+		WHERE
+			to_path = $1
+		AND
+			to_path <> from_module_path
+		AND
+			left(to_path, length(from_module_path) + 1) <> from_module_path || '/'
+		AND
+			NOT ($2 AND from_module_path = 'std')`
+
+// GetImporters returns up to limit paths of packages that import the package
+// with importedPath, starting at offset and ordered by path. Importers in the
+// same module as importedPath are excluded.
+//
+// It returns an empty slice, not an error, if there are no known importers.
+func (db *DB) GetImporters(ctx context.Context, importedPath string, limit, offset int) (_ []string, err error) {
+	defer derrors.Wrap(&err, "GetImporters(ctx, %q, %d, %d)", importedPath, limit, offset)
+	if importedPath == "" {
+		return nil, fmt.Errorf("importedPath cannot be empty: %w", derrors.InvalidArgument)
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive: %w", derrors.InvalidArgument)
+	}
+	if offset < 0 {
+		return nil, fmt.Errorf("offset cannot be negative: %w", derrors.InvalidArgument)
+	}
+	query := `
+		SELECT
+			DISTINCT from_path
+		FROM
+			imports_unique` + importersWhere + `
+		ORDER BY
+			from_path
+		LIMIT $3
+		OFFSET $4`
+
+	importers := []string{}
+	collect := func(rows *sql.Rows) error {
+		var fromPath string
+		if err := rows.Scan(&fromPath); err != nil {
+			return fmt.Errorf("row.Scan(): %v", err)
+		}
+		importers = append(importers, fromPath)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, importedPath, stdlib.Contains(importedPath), limit, offset); err != nil {
+		return nil, err
+	}
+	return importers, nil
+}
+
+// CountImporters returns the number of packages that import the package with
+// importedPath, as defined by GetImporters. Since popular packages can have a
+// very large number of importers, it stops counting at limit.
+func (db *DB) CountImporters(ctx context.Context, importedPath string, limit int) (_ int, err error) {
+	defer derrors.Wrap(&err, "CountImporters(ctx, %q, %d)", importedPath, limit)
+	if importedPath == "" {
+		return 0, fmt.Errorf("importedPath cannot be empty: %w", derrors.InvalidArgument)
+	}
+	query := `
+		SELECT COUNT(*) FROM (
+			SELECT
+				DISTINCT from_path
+			FROM
+				imports_unique` + importersWhere + `
+			LIMIT $3
+		) i`
+
+	var n int
+	if err := db.db.QueryRow(ctx, query, importedPath, stdlib.Contains(importedPath), limit).Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// ForEachImporter calls f with the path of every package that imports the
+// package with importedPath, as defined by GetImporters, in order of path.
+// The paths are read from a cursor as f is called, so they are never all in
+// memory. If f returns an error, ForEachImporter stops and returns it.
+func (db *DB) ForEachImporter(ctx context.Context, importedPath string, f func(path string) error) (err error) {
+	defer derrors.Wrap(&err, "ForEachImporter(ctx, %q)", importedPath)
+	if importedPath == "" {
+		return fmt.Errorf("importedPath cannot be empty: %w", derrors.InvalidArgument)
+	}
+	query := `
+		SELECT
+			DISTINCT from_path
+		FROM
+			imports_unique` + importersWhere + `
+		ORDER BY
+			from_path`
+
+	collect := func(rows *sql.Rows) error {
+		var fromPath string
+		if err := rows.Scan(&fromPath); err != nil {
+			return fmt.Errorf("row.Scan(): %v", err)
+		}
+		return f(fromPath)
+	}
+	return db.db.RunQuery(ctx, query, collect, importedPath, stdlib.Contains(importedPath))
+}
+
+// An ImportingDirectory is a directory that imports a package, in one or more
+// versions of its module.
+type ImportingDirectory struct {
+	Path       string
+	ModulePath string
+	// Versions are the versions of the module in which the directory imports
+	// the package, from highest to lowest.
+	Versions []string
+}
+
+// GetImportingDirectories returns up to limit directories that import the
+// package with importedPath in any version of their module, starting at
+// offset and ordered by path and then module path. It uses the imports stored
+// for each module version, so unlike GetImporters it reports in which
+// versions each directory imports the package. A directory that imports the
+// package in several versions of its module is returned once. As in
+// GetImporters, directories in the same module as importedPath are excluded.
+//
+// It returns an empty slice, not an error, if there are no known importers.
+func (db *DB) GetImportingDirectories(ctx context.Context, importedPath string, limit, offset int) (_ []*ImportingDirectory, err error) {
+	defer derrors.Wrap(&err, "GetImportingDirectories(ctx, %q, %d, %d)", importedPath, limit, offset)
+	if importedPath == "" {
+		return nil, fmt.Errorf("importedPath cannot be empty: %w", derrors.InvalidArgument)
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive: %w", derrors.InvalidArgument)
+	}
+	if offset < 0 {
+		return nil, fmt.Errorf("offset cannot be negative: %w", derrors.InvalidArgument)
+	}
+	query := `
+		SELECT
+			p.path,
+			m.module_path,
+			array_agg(m.version ORDER BY m.sort_version DESC)
+		FROM
+			package_imports i
+		INNER JOIN paths p
+		ON p.id = i.path_id
+		INNER JOIN modules m
+		ON m.id = p.module_id
+		WHERE
+			i.to_path = $1
+		AND
+			i.to_path <> m.module_path
+		AND
+			left(i.to_path, length(m.module_path) + 1) <> m.module_path || '/'
+		AND
+			NOT ($2 AND m.module_path = 'std')
+		GROUP BY
+			p.path, m.module_path
+		ORDER BY
+			p.path, m.module_path
+		LIMIT $3
+		OFFSET $4`
+
+	dirs := []*ImportingDirectory{}
+	collect := func(rows *sql.Rows) error {
+		var d ImportingDirectory
+		if err := rows.Scan(&d.Path, &d.ModulePath, pq.Array(&d.Versions)); err != nil {
+			return fmt.Errorf("row.Scan(): %v", err)
+		}
+		dirs = append(dirs, &d)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, importedPath, stdlib.Contains(importedPath), limit, offset); err != nil {
+		return nil, err
+	}
+	return dirs, nil
+}
+
 // LegacyGetModuleInfo fetches a Version from the database with the primary key
 // (module_path, version).
 func (db *DB) LegacyGetModuleInfo(ctx context.Context, modulePath string, version string) (_ *internal.LegacyModuleInfo, err error) {
 	defer derrors.Wrap(&err, "LegacyGetModuleInfo(ctx, %q, %q)", modulePath, version)
 
+	key := cacheKey{"LegacyGetModuleInfo", "", modulePath, version}
+	v, err := db.cachedRead(ctx, key, new(*internal.LegacyModuleInfo), func() (interface{}, error) {
+		return db.legacyGetModuleInfo(ctx, modulePath, version)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*internal.LegacyModuleInfo), nil
+}
+
+// legacyGetModuleInfo implements LegacyGetModuleInfo, without caching.
+func (db *DB) legacyGetModuleInfo(ctx context.Context, modulePath string, version string) (_ *internal.LegacyModuleInfo, err error) {
 	query := `
 		SELECT
 			module_path,
@@ -292,7 +737,9 @@ func (db *DB) LegacyGetModuleInfo(ctx context.Context, modulePath string, versio
 			version_type,
 			source_info,
 			redistributable,
-			has_go_mod
+			has_go_mod,
+			min_go_version,
+			license_expression
 		FROM
 			modules`
 
@@ -320,7 +767,7 @@ func (db *DB) LegacyGetModuleInfo(ctx context.Context, modulePath string, versio
 	row := db.db.QueryRow(ctx, query, args...)
 	if err := row.Scan(&mi.ModulePath, &mi.Version, &mi.CommitTime,
 		database.NullIsEmpty(&mi.LegacyReadmeFilePath), database.NullIsEmpty(&mi.LegacyReadmeContents), &mi.VersionType,
-		jsonbScanner{&mi.SourceInfo}, &mi.IsRedistributable, &hasGoMod); err != nil {
+		jsonbScanner{&mi.SourceInfo}, &mi.IsRedistributable, &hasGoMod, &mi.MinGoVersion, &mi.LicenseExpression); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("module version %s@%s: %w", modulePath, version, derrors.NotFound)
 		}
@@ -330,6 +777,44 @@ func (db *DB) LegacyGetModuleInfo(ctx context.Context, modulePath string, versio
 	return &mi, nil
 }
 
+// GetLatestModuleInfo returns the latest version of the module with the given
+// path: the highest release version, or the highest prerelease or
+// pseudo-version if there are no release versions. It uses the is_latest
+// column of the modules table, which InsertModule and DeleteModule maintain,
+// rather than sorting the module's versions.
+func (db *DB) GetLatestModuleInfo(ctx context.Context, modulePath string) (_ *internal.ModuleInfo, err error) {
+	defer derrors.Wrap(&err, "GetLatestModuleInfo(ctx, %q)", modulePath)
+
+	query := `
+		SELECT
+			module_path,
+			version,
+			commit_time,
+			version_type,
+			source_info,
+			redistributable,
+			has_go_mod,
+			min_go_version,
+			license_expression
+		FROM
+			modules
+		WHERE module_path = $1 AND is_latest;`
+	var (
+		mi       internal.ModuleInfo
+		hasGoMod sql.NullBool
+	)
+	row := db.db.QueryRow(ctx, query, modulePath)
+	if err := row.Scan(&mi.ModulePath, &mi.Version, &mi.CommitTime, &mi.VersionType,
+		jsonbScanner{&mi.SourceInfo}, &mi.IsRedistributable, &hasGoMod, &mi.MinGoVersion, &mi.LicenseExpression); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("module %s: %w", modulePath, derrors.NotFound)
+		}
+		return nil, fmt.Errorf("row.Scan(): %v", err)
+	}
+	setHasGoMod(&mi, hasGoMod)
+	return &mi, nil
+}
+
 func setHasGoMod(mi *internal.ModuleInfo, nb sql.NullBool) {
 	// The safe default value for HasGoMod is true, because search will penalize modules that don't have one.
 	// This is temporary: when has_go_mod is fully populated, we'll make it NOT NULL.
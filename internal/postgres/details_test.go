@@ -10,7 +10,9 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -19,6 +21,7 @@ import (
 	"golang.org/x/pkgsite/internal/licenses"
 	"golang.org/x/pkgsite/internal/source"
 	"golang.org/x/pkgsite/internal/testing/sample"
+	"golang.org/x/pkgsite/internal/version"
 )
 
 func TestPostgres_GetVersionInfo_Latest(t *testing.T) {
@@ -89,6 +92,147 @@ func TestPostgres_GetVersionInfo_Latest(t *testing.T) {
 	}
 }
 
+func TestGetLatestModuleInfo(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	const modulePath = "example.com/latest"
+	check := func(want string) {
+		t.Helper()
+		mi, err := testDB.GetLatestModuleInfo(ctx, modulePath)
+		if want == "" {
+			if !errors.Is(err, derrors.NotFound) {
+				t.Fatalf("GetLatestModuleInfo: got %v, want %v", err, derrors.NotFound)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mi.Version != want {
+			t.Errorf("GetLatestModuleInfo: got version %q, want %q", mi.Version, want)
+		}
+		var n int
+		if err := testDB.db.QueryRow(ctx, `SELECT count(*) FROM modules WHERE module_path = $1 AND is_latest`,
+			modulePath).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Errorf("got %d latest versions, want 1", n)
+		}
+	}
+
+	// Versions are inserted out of order; a prerelease never replaces a
+	// release as the latest version.
+	for _, test := range []struct {
+		insert, wantLatest string
+	}{
+		{"v1.2.0-pre", "v1.2.0-pre"},
+		{"v1.0.0", "v1.0.0"},
+		{"v1.1.0", "v1.1.0"},
+		{"v1.0.1", "v1.1.0"},
+		{"v1.3.0-pre", "v1.1.0"},
+	} {
+		if err := testDB.InsertModule(ctx, sample.Module(modulePath, test.insert, "p")); err != nil {
+			t.Fatal(err)
+		}
+		check(test.wantLatest)
+	}
+
+	// Deleting the latest version makes the next highest version the latest.
+	for _, test := range []struct {
+		delete, wantLatest string
+	}{
+		{"v1.0.1", "v1.1.0"},
+		{"v1.1.0", "v1.0.0"},
+		{"v1.0.0", "v1.3.0-pre"},
+		{"v1.3.0-pre", "v1.2.0-pre"},
+		{"v1.2.0-pre", ""},
+	} {
+		if err := testDB.DeleteModule(ctx, modulePath, test.delete); err != nil {
+			t.Fatal(err)
+		}
+		check(test.wantLatest)
+	}
+}
+
+func TestGetImportsInModule(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	m := sample.Module("path.to/foo", "v1.1.0", "bar", "baz", "quux")
+	bar, baz, quux := m.LegacyPackages[0], m.LegacyPackages[1], m.LegacyPackages[2]
+	bar.Imports = []string{"fmt", "path.to/other"}
+	baz.Imports = []string{bar.Path}
+	quux.Imports = nil
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := testDB.GetImportsInModule(ctx, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		bar.Path: bar.Imports,
+		baz.Path: baz.Imports,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGetImportsDiff(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	m1 := sample.Module("path.to/foo", "v1.0.0", "bar", "baz")
+	m1.LegacyPackages[0].Imports = []string{"fmt", "github.com/old/dep", "path.to/foo/baz"}
+	m1.LegacyPackages[1].Imports = []string{"fmt", "golang.org/x/text"}
+	m2 := sample.Module("path.to/foo", "v1.1.0", "bar", "baz", "quux")
+	m2.LegacyPackages[0].Imports = []string{"fmt", "path.to/foo/baz", "path.to/foo/quux"}
+	m2.LegacyPackages[1].Imports = []string{"golang.org/x/text"}
+	m2.LegacyPackages[2].Imports = []string{"github.com/new/dep", "net/http"}
+	for _, m := range []*internal.Module{m1, m2} {
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := testDB.GetImportsDiff(ctx, "path.to/foo", "v1.0.0", "v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ImportsDiff{
+		Added:   []string{"github.com/new/dep", "net/http"},
+		Removed: []string{"github.com/old/dep"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// The diff in the other direction is the reverse.
+	got, err = testDB.GetImportsDiff(ctx, "path.to/foo", "v1.1.0", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.Added, want.Removed = want.Removed, want.Added
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("reversed: mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := testDB.GetImportsDiff(ctx, "path.to/foo", "v1.0.0", "v1.2.0"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("unindexed version: got error %v, want NotFound", err)
+	} else if !strings.Contains(err.Error(), "path.to/foo@v1.2.0 is not indexed") {
+		t.Errorf("unindexed version: got error %q, want it to name the version", err)
+	}
+	if _, err := testDB.GetImportsDiff(ctx, "path.to/foo", "", "v1.0.0"); !errors.Is(err, derrors.InvalidArgument) {
+		t.Errorf("empty version: got error %v, want InvalidArgument", err)
+	}
+}
+
 func TestPostgres_GetImportsAndImportedBy(t *testing.T) {
 	var (
 		m1          = sample.Module("path.to/foo", "v1.1.0", "bar")
@@ -172,6 +316,199 @@ func TestPostgres_GetImportsAndImportedBy(t *testing.T) {
 	}
 }
 
+func TestGetImporters(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	var (
+		m1 = sample.Module("path.to/foo", "v1.1.0", "bar")
+		m2 = sample.Module("path2.to/foo", "v1.2.0", "bar2", "baz2")
+		m3 = sample.Module("path3.to/foo", "v1.3.0", "bar3")
+
+		pkg1  = m1.LegacyPackages[0]
+		pkg2  = m2.LegacyPackages[0]
+		pkg2b = m2.LegacyPackages[1]
+		pkg3  = m3.LegacyPackages[0]
+	)
+	pkg1.Imports = nil
+	pkg2.Imports = []string{pkg1.Path}
+	// pkg2b is in the same module as pkg2, so it is not an importer of pkg2.
+	pkg2b.Imports = []string{pkg1.Path, pkg2.Path}
+	pkg3.Imports = []string{pkg2.Path, pkg1.Path}
+	for _, m := range []*internal.Module{m1, m2, m3} {
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, test := range []struct {
+		path          string
+		limit, offset int
+		want          []string
+		wantCount     int
+	}{
+		{pkg1.Path, 10, 0, []string{pkg2.Path, pkg2b.Path, pkg3.Path}, 3},
+		{pkg1.Path, 2, 0, []string{pkg2.Path, pkg2b.Path}, 3},
+		{pkg1.Path, 2, 2, []string{pkg3.Path}, 3},
+		{pkg2.Path, 10, 0, []string{pkg3.Path}, 1},
+		{pkg3.Path, 10, 0, []string{}, 0},
+		{"unknown.com/pkg", 10, 0, []string{}, 0},
+	} {
+		got, err := testDB.GetImporters(ctx, test.path, test.limit, test.offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("GetImporters(ctx, %q, %d, %d) mismatch (-want +got):\n%s", test.path, test.limit, test.offset, diff)
+		}
+		gotCount, err := testDB.CountImporters(ctx, test.path, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotCount != test.wantCount {
+			t.Errorf("CountImporters(ctx, %q, 100) = %d, want %d", test.path, gotCount, test.wantCount)
+		}
+		var all []string
+		if err := testDB.ForEachImporter(ctx, test.path, func(p string) error {
+			all = append(all, p)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != test.wantCount {
+			t.Errorf("ForEachImporter(ctx, %q): got %v, want %d importers", test.path, all, test.wantCount)
+		}
+	}
+
+	// ForEachImporter stops at the first error of f.
+	errStop := errors.New("stop")
+	var n int
+	err := testDB.ForEachImporter(ctx, pkg1.Path, func(string) error {
+		n++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || n != 1 {
+		t.Errorf("ForEachImporter with failing f: got %v after %d calls, want %v after 1", err, n, errStop)
+	}
+
+	// The count stops at the limit.
+	gotCount, err := testDB.CountImporters(ctx, pkg1.Path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotCount != 2 {
+		t.Errorf("CountImporters(ctx, %q, 2) = %d, want 2", pkg1.Path, gotCount)
+	}
+}
+
+func TestGetImportingDirectories(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	var (
+		m1  = sample.Module("path.to/foo", "v1.1.0", "bar")
+		m2a = sample.Module("path2.to/foo", "v1.2.0", "bar2", "baz2")
+		m2b = sample.Module("path2.to/foo", "v1.3.0", "bar2", "baz2")
+		m3  = sample.Module("path3.to/foo", "v1.3.0", "bar3")
+
+		pkg1 = m1.LegacyPackages[0]
+	)
+	pkg1.Imports = nil
+	// bar2 imports pkg1 in both versions of its module, baz2 only in the
+	// earlier one.
+	m2a.LegacyPackages[0].Imports = []string{pkg1.Path}
+	m2a.LegacyPackages[1].Imports = []string{pkg1.Path}
+	m2b.LegacyPackages[0].Imports = []string{pkg1.Path}
+	m2b.LegacyPackages[1].Imports = nil
+	// bar3 imports a package in its own module, which is not reported.
+	m3.LegacyPackages[0].Imports = []string{pkg1.Path, "path3.to/foo/other"}
+	for _, m := range []*internal.Module{m1, m2a, m2b, m3} {
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		bar2 = &ImportingDirectory{Path: "path2.to/foo/bar2", ModulePath: "path2.to/foo", Versions: []string{"v1.3.0", "v1.2.0"}}
+		baz2 = &ImportingDirectory{Path: "path2.to/foo/baz2", ModulePath: "path2.to/foo", Versions: []string{"v1.2.0"}}
+		bar3 = &ImportingDirectory{Path: "path3.to/foo/bar3", ModulePath: "path3.to/foo", Versions: []string{"v1.3.0"}}
+	)
+	for _, test := range []struct {
+		path          string
+		limit, offset int
+		want          []*ImportingDirectory
+	}{
+		{pkg1.Path, 10, 0, []*ImportingDirectory{bar2, baz2, bar3}},
+		{pkg1.Path, 2, 0, []*ImportingDirectory{bar2, baz2}},
+		{pkg1.Path, 2, 2, []*ImportingDirectory{bar3}},
+		{"path3.to/foo/other", 10, 0, []*ImportingDirectory{}},
+		{"unknown.com/pkg", 10, 0, []*ImportingDirectory{}},
+	} {
+		got, err := testDB.GetImportingDirectories(ctx, test.path, test.limit, test.offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("GetImportingDirectories(ctx, %q, %d, %d) mismatch (-want +got):\n%s", test.path, test.limit, test.offset, diff)
+		}
+	}
+
+	if _, err := testDB.GetImportingDirectories(ctx, "", 10, 0); !errors.Is(err, derrors.InvalidArgument) {
+		t.Errorf("GetImportingDirectories(ctx, \"\", 10, 0): got error %v, want %v", err, derrors.InvalidArgument)
+	}
+}
+
+func TestGetPackageVersions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	// The package example.com/m/sub/p is first in module example.com/m, then
+	// moves to the nested module example.com/m/sub.
+	const pkgPath = "example.com/m/sub/p"
+	for _, m := range []*internal.Module{
+		sample.Module("example.com/m", "v1.0.0", "sub/p"),
+		sample.Module("example.com/m/sub", "v1.1.0", "p"),
+		sample.Module("example.com/m", "v1.2.0", "other"),
+	} {
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A later version of example.com/m has an alternative module path.
+	if err := testDB.UpsertModuleVersionState(ctx, "example.com/m", "v1.3.0", "", time.Now(),
+		derrors.ToHTTPStatus(derrors.AlternativeModule), "example.com/new", derrors.AlternativeModule, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := testDB.GetPackageVersions(ctx, pkgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*internal.VersionedPackage{
+		{
+			ModuleInfo:  *sample.ModuleInfo("example.com/m/sub", "v1.1.0"),
+			Path:        pkgPath,
+			IsCanonical: true,
+		},
+		{
+			ModuleInfo:          *sample.ModuleInfo("example.com/m", "v1.0.0"),
+			Path:                pkgPath,
+			IsCanonical:         false,
+			CanonicalModulePath: "example.com/new",
+		},
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(source.Info{})); diff != "" {
+		t.Errorf("GetPackageVersions(ctx, %q) mismatch (-want +got):\n%s", pkgPath, diff)
+	}
+
+	if _, err := testDB.GetPackageVersions(ctx, "example.com/none"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("GetPackageVersions(ctx, %q): got error %v, want NotFound", "example.com/none", err)
+	}
+}
+
 func TestPostgres_GetTaggedAndPseudoVersions(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
 	defer cancel()
@@ -302,6 +639,61 @@ func TestPostgres_GetTaggedAndPseudoVersions(t *testing.T) {
 	}
 }
 
+func TestGetSeries(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	for _, mv := range []struct{ modulePath, version string }{
+		{"path.to/foo", "v0.9.0"},
+		{"path.to/foo", "v1.0.0-alpha.1"},
+		{"path.to/foo", "v1.0.0"},
+		{"path.to/foo", "v1.1.0"},
+		{"path.to/foo", "v1.1.1-0.20200101000000-abcdefabcdef"},
+		{"path.to/foo", "v2.0.0+incompatible"},
+		{"path.to/foo/v2", "v2.1.0"},
+		{"path.to/foo/v10", "v10.0.0"},
+		{"path.to/foobar", "v1.0.0"},
+	} {
+		if err := testDB.InsertModule(ctx, sample.Module(mv.modulePath, mv.version, "bar")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := testDB.GetSeries(ctx, "path.to/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Versions are written as module@version.
+	want := map[string][]string{
+		"v0":  {"path.to/foo@v0.9.0"},
+		"v1":  {"path.to/foo@v1.1.1-0.20200101000000-abcdefabcdef", "path.to/foo@v1.1.0", "path.to/foo@v1.0.0", "path.to/foo@v1.0.0-alpha.1"},
+		"v2":  {"path.to/foo/v2@v2.1.0", "path.to/foo@v2.0.0+incompatible"},
+		"v10": {"path.to/foo/v10@v10.0.0"},
+	}
+	var gotMajors []string
+	for _, m := range got {
+		gotMajors = append(gotMajors, m.Major)
+		var versions []string
+		for _, mi := range m.Versions {
+			versions = append(versions, mi.ModulePath+"@"+mi.Version)
+		}
+		if diff := cmp.Diff(want[m.Major], versions); diff != "" {
+			t.Errorf("%s: mismatch (-want +got):\n%s", m.Major, diff)
+		}
+	}
+	if diff := cmp.Diff([]string{"v0", "v1", "v2", "v10"}, gotMajors); diff != "" {
+		t.Errorf("majors mismatch (-want +got):\n%s", diff)
+	}
+	if got[1].Versions[0].VersionType != version.TypePseudo {
+		t.Errorf("got version type %q for %s, want %q", got[1].Versions[0].VersionType, got[1].Versions[0].Version, version.TypePseudo)
+	}
+
+	if _, err := testDB.GetSeries(ctx, "path.to/unknown"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("unknown series: got error %v, want NotFound", err)
+	}
+}
+
 func TestGetPackagesInVersion(t *testing.T) {
 	testVersion := sample.Module("test.module", "v1.2.3", "", "foo")
 
@@ -21,6 +21,18 @@ import (
 // data associated with that directory, including the package, imports, readme,
 // documentation, and licenses.
 func (db *DB) GetDirectoryNew(ctx context.Context, path, modulePath, version string) (_ *internal.VersionedDirectory, err error) {
+	key := cacheKey{"GetDirectoryNew", path, modulePath, version}
+	v, err := db.cachedRead(ctx, key, new(*internal.VersionedDirectory), func() (interface{}, error) {
+		return db.getDirectoryNew(ctx, path, modulePath, version)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*internal.VersionedDirectory), nil
+}
+
+// getDirectoryNew implements GetDirectoryNew, without caching.
+func (db *DB) getDirectoryNew(ctx context.Context, path, modulePath, version string) (_ *internal.VersionedDirectory, err error) {
 	query := `
 		SELECT
 			m.module_path,
@@ -29,6 +41,8 @@ func (db *DB) GetDirectoryNew(ctx context.Context, path, modulePath, version str
 			m.version_type,
 			m.redistributable,
 			m.has_go_mod,
+			m.min_go_version,
+			m.license_expression,
 			m.source_info,
 			p.id,
 			p.path,
@@ -66,6 +80,8 @@ func (db *DB) GetDirectoryNew(ctx context.Context, path, modulePath, version str
 		&mi.VersionType,
 		&mi.IsRedistributable,
 		&mi.HasGoMod,
+		&mi.MinGoVersion,
+		&mi.LicenseExpression,
 		jsonbScanner{&mi.SourceInfo},
 		&pathID,
 		&dir.Path,
@@ -110,21 +126,11 @@ func (db *DB) GetDirectoryNew(ctx context.Context, path, modulePath, version str
 		}
 	}
 
-	// TODO(golang/go#38513): remove and query the readmes table directly once
-	// we start displaying READMEs for directories instead of the top-level
-	// module.
 	var readme internal.Readme
 	row = db.db.QueryRow(ctx, `
 		SELECT file_path, contents
-		FROM modules m
-		INNER JOIN paths p
-		ON p.module_id = m.id
-		INNER JOIN readmes r
-		ON p.id = r.path_id
-		WHERE
-		    module_path=$1
-			AND m.version=$2
-			AND m.module_path=p.path`, modulePath, version)
+		FROM readmes
+		WHERE path_id = $1`, pathID)
 	if err := row.Scan(&readme.Filepath, &readme.Contents); err != nil && err != sql.ErrNoRows {
 		return nil, err
 	}
@@ -192,6 +198,7 @@ func (db *DB) LegacyGetDirectory(ctx context.Context, dirPath, modulePath, versi
 
 	var (
 		packages []*internal.LegacyPackage
+		docRefs  []sql.NullString
 		mi       = internal.LegacyModuleInfo{LegacyReadmeContents: internal.StringFieldMissing}
 	)
 	collect := func(rows *sql.Rows) error {
@@ -199,6 +206,7 @@ func (db *DB) LegacyGetDirectory(ctx context.Context, dirPath, modulePath, versi
 			pkg          = internal.LegacyPackage{DocumentationHTML: internal.StringFieldMissing}
 			licenseTypes []string
 			licensePaths []string
+			docRef       sql.NullString
 		)
 		scanArgs := []interface{}{
 			&pkg.Path,
@@ -207,7 +215,7 @@ func (db *DB) LegacyGetDirectory(ctx context.Context, dirPath, modulePath, versi
 			&pkg.V1Path,
 		}
 		if fields&internal.WithDocumentationHTML != 0 {
-			scanArgs = append(scanArgs, database.NullIsEmpty(&pkg.DocumentationHTML))
+			scanArgs = append(scanArgs, database.NullIsEmpty(&pkg.DocumentationHTML), &docRef)
 		}
 		scanArgs = append(scanArgs,
 			pq.Array(&licenseTypes),
@@ -215,6 +223,7 @@ func (db *DB) LegacyGetDirectory(ctx context.Context, dirPath, modulePath, versi
 			&pkg.IsRedistributable,
 			&pkg.GOOS,
 			&pkg.GOARCH,
+			&pkg.StdlibOnly,
 			&mi.Version,
 			&mi.ModulePath,
 			database.NullIsEmpty(&mi.LegacyReadmeFilePath))
@@ -227,7 +236,9 @@ func (db *DB) LegacyGetDirectory(ctx context.Context, dirPath, modulePath, versi
 			&mi.VersionType,
 			jsonbScanner{&mi.SourceInfo},
 			&mi.IsRedistributable,
-			&hasGoMod)
+			&hasGoMod,
+			&mi.MinGoVersion,
+			&mi.LicenseExpression)
 		if err := rows.Scan(scanArgs...); err != nil {
 			return fmt.Errorf("row.Scan(): %v", err)
 		}
@@ -238,6 +249,7 @@ func (db *DB) LegacyGetDirectory(ctx context.Context, dirPath, modulePath, versi
 		}
 		pkg.Licenses = lics
 		packages = append(packages, &pkg)
+		docRefs = append(docRefs, docRef)
 		return nil
 	}
 	if err := db.db.RunQuery(ctx, query, collect, args...); err != nil {
@@ -246,6 +258,11 @@ func (db *DB) LegacyGetDirectory(ctx context.Context, dirPath, modulePath, versi
 	if len(packages) == 0 {
 		return nil, fmt.Errorf("packages in directory not found: %w", derrors.NotFound)
 	}
+	if fields&internal.WithDocumentationHTML != 0 {
+		if err := db.getPackagesDocumentation(ctx, packages, docRefs); err != nil {
+			return nil, err
+		}
+	}
 	sort.Slice(packages, func(i, j int) bool {
 		return packages[i].Path < packages[j].Path
 	})
@@ -259,7 +276,7 @@ func (db *DB) LegacyGetDirectory(ctx context.Context, dirPath, modulePath, versi
 func directoryColumns(fields internal.FieldSet) string {
 	var doc, readme string
 	if fields&internal.WithDocumentationHTML != 0 {
-		doc = "p.documentation,"
+		doc = "p.documentation, p.documentation_ref,"
 	}
 	if fields&internal.WithReadmeContents != 0 {
 		readme = "m.readme_contents,"
@@ -275,6 +292,7 @@ func directoryColumns(fields internal.FieldSet) string {
 			p.redistributable,
 			p.goos,
 			p.goarch,
+			p.stdlib_only,
 			p.version,
 			p.module_path,
 			m.readme_file_path,
@@ -283,7 +301,9 @@ func directoryColumns(fields internal.FieldSet) string {
 			m.version_type,
 			m.source_info,
 			m.redistributable,
-			m.has_go_mod`
+			m.has_go_mod,
+			m.min_go_version,
+			m.license_expression`
 }
 
 const orderByLatest = `
@@ -0,0 +1,134 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/blob"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// SetDocumentationStore makes db keep the documentation HTML of packages in
+// store instead of the packages table, if it is at least minSize bytes long.
+// The table then holds only its key in store, and reads of the package fetch
+// the documentation from store. Shorter documentation is kept in the table.
+//
+// SetDocumentationStore must be called before db is used. Servers that read
+// the packages written by a DB with a documentation store must use the same
+// store.
+func (db *DB) SetDocumentationStore(store blob.Store, minSize int) {
+	db.docStore = store
+	db.docStoreMinSize = minSize
+}
+
+// documentationKey returns the key in the documentation store for the
+// documentation of the package pkgPath in the module modulePath at version.
+func documentationKey(modulePath, version, pkgPath string) string {
+	return fmt.Sprintf("documentation/%s@%s/%s.html", modulePath, version, pkgPath)
+}
+
+// putDocumentation stores the documentation of the packages of m that is too
+// large to keep in the packages table in the documentation store, as
+// truncated by truncateDocumentationHTML, and returns the keys it used, by
+// package path. It returns nil if db has no documentation store.
+func (db *DB) putDocumentation(ctx context.Context, m *internal.Module) (_ map[string]string, err error) {
+	defer derrors.Wrap(&err, "putDocumentation(ctx, %q, %q)", m.ModulePath, m.Version)
+	if db.docStore == nil {
+		return nil, nil
+	}
+	refs := map[string]string{}
+	for _, p := range m.LegacyPackages {
+		if p.DocumentationHTML == "" || len(p.DocumentationHTML) < db.docStoreMinSize {
+			continue
+		}
+		docHTML, _ := truncateDocumentationHTML(p.DocumentationHTML,
+			m.SourceInfo.DirectoryURL(packageSubdir(p.Path, m.ModulePath)))
+		key := documentationKey(m.ModulePath, m.Version, p.Path)
+		if err := db.docStore.Put(ctx, key, []byte(makeValidUnicode(docHTML))); err != nil {
+			return nil, err
+		}
+		refs[p.Path] = key
+	}
+	return refs, nil
+}
+
+// getDocumentation returns the documentation stored under ref, a value of the
+// packages.documentation_ref column, or docHTML, the value of the
+// documentation column, if ref is not valid.
+func (db *DB) getDocumentation(ctx context.Context, docHTML string, ref sql.NullString) (string, error) {
+	if !ref.Valid {
+		return docHTML, nil
+	}
+	if db.docStore == nil {
+		return "", fmt.Errorf("documentation is in an object store (%q), but the DB has none", ref.String)
+	}
+	val, err := db.docStore.Get(ctx, ref.String)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			// The package exists, so the missing documentation is our
+			// problem, not the caller's.
+			return "", fmt.Errorf("documentation %q is missing from the object store", ref.String)
+		}
+		return "", err
+	}
+	return string(val), nil
+}
+
+// getPackagesDocumentation sets the documentation HTML of each package in pkgs
+// to the result of getDocumentation for the corresponding element of refs. It
+// is called after the rows that pkgs and refs were read from are closed, so
+// that the database connection is not held while the store is read.
+func (db *DB) getPackagesDocumentation(ctx context.Context, pkgs []*internal.LegacyPackage, refs []sql.NullString) error {
+	for i, p := range pkgs {
+		var err error
+		p.DocumentationHTML, err = db.getDocumentation(ctx, p.DocumentationHTML, refs[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// documentationRefs returns the keys in the documentation store of the
+// documentation of the packages in the module modulePath at version.
+func (db *DB) documentationRefs(ctx context.Context, modulePath, version string) ([]string, error) {
+	if db.docStore == nil {
+		return nil, nil
+	}
+	query := `
+		SELECT documentation_ref
+		FROM packages
+		WHERE module_path = $1 AND version = $2 AND documentation_ref IS NOT NULL`
+	var refs []string
+	err := db.db.RunQuery(ctx, query, func(rows *sql.Rows) error {
+		var ref string
+		if err := rows.Scan(&ref); err != nil {
+			return err
+		}
+		refs = append(refs, ref)
+		return nil
+	}, modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// deleteDocumentation removes refs from the documentation store. Errors are
+// logged, because the documentation is unreachable once the packages that
+// refer to it are deleted.
+func (db *DB) deleteDocumentation(ctx context.Context, refs []string) {
+	for _, ref := range refs {
+		if err := db.docStore.Delete(ctx, ref); err != nil {
+			log.Errorf(ctx, "deleting documentation: %v", err)
+		}
+	}
+}
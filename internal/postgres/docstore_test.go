@@ -0,0 +1,110 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/blob"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestDocumentationStore(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	tmp, err := ioutil.TempDir("", "docstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store, err := blob.NewDir(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := New(testDB.db)
+	db.SetDocumentationStore(store, 100)
+
+	m := sample.Module(sample.ModulePath, sample.VersionString, "large", "small")
+	large, small := m.LegacyPackages[0], m.LegacyPackages[1]
+	large.DocumentationHTML = strings.Repeat("<p>Large.</p>\n", 100)
+	small.DocumentationHTML = "<p>Small.</p>"
+	if err := db.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the large documentation is kept in the store.
+	for _, test := range []struct {
+		pkg     *internal.LegacyPackage
+		wantRef bool
+	}{
+		{large, true},
+		{small, false},
+	} {
+		var (
+			doc sql.NullString
+			ref sql.NullString
+		)
+		row := testDB.db.QueryRow(ctx, `
+			SELECT documentation, documentation_ref
+			FROM packages
+			WHERE path = $1 AND module_path = $2 AND version = $3`,
+			test.pkg.Path, m.ModulePath, m.Version)
+		if err := row.Scan(&doc, &ref); err != nil {
+			t.Fatal(err)
+		}
+		if ref.Valid != test.wantRef || doc.Valid == test.wantRef {
+			t.Errorf("%s: documentation valid = %t, documentation_ref = %v; want ref %t",
+				test.pkg.Path, doc.Valid, ref, test.wantRef)
+		}
+	}
+
+	// Reads return the documentation wherever it is kept.
+	for _, pkg := range []*internal.LegacyPackage{large, small} {
+		got, err := db.LegacyGetPackage(ctx, pkg.Path, m.ModulePath, m.Version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.DocumentationHTML != pkg.DocumentationHTML {
+			t.Errorf("LegacyGetPackage(%q): got documentation %q, want %q", pkg.Path, got.DocumentationHTML, pkg.DocumentationHTML)
+		}
+	}
+	pkgs, err := db.LegacyGetPackagesInModule(ctx, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := db.LegacyGetDirectory(ctx, m.ModulePath, m.ModulePath, m.Version, internal.AllFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, got := range append(pkgs, dir.Packages...) {
+		if got.Path == large.Path && got.DocumentationHTML != large.DocumentationHTML {
+			t.Errorf("%s: got documentation %q, want %q", got.Path, got.DocumentationHTML, large.DocumentationHTML)
+		}
+	}
+
+	// A DB without the store cannot read the documentation.
+	if _, err := testDB.LegacyGetPackage(ctx, large.Path, m.ModulePath, m.Version); err == nil {
+		t.Error("LegacyGetPackage without a documentation store: got nil error, want error")
+	}
+
+	// Deleting the module deletes its documentation from the store.
+	if err := db.DeleteModule(ctx, m.ModulePath, m.Version); err != nil {
+		t.Fatal(err)
+	}
+	key := documentationKey(m.ModulePath, m.Version, large.Path)
+	if _, err := store.Get(ctx, key); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("store.Get(%q) after DeleteModule: got error %v, want NotFound", key, err)
+	}
+}
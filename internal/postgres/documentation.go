@@ -0,0 +1,216 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// UpdateDocumentation replaces the stored documentation of the packages of m,
+// a module version that is already in the database, with their documentation
+// in m. Nothing else about the module version changes. It is used to
+// re-render documentation after the rendering code changes, without
+// reprocessing modules.
+//
+// Packages of m that are not in the database are ignored.
+func (db *DB) UpdateDocumentation(ctx context.Context, m *internal.Module) (err error) {
+	defer derrors.Wrap(&err, "UpdateDocumentation(ctx, %q, %q)", m.ModulePath, m.Version)
+
+	removeNonDistributableData(m)
+	paths, err := db.cachedPaths(ctx, m.ModulePath, m.Version)
+	if err != nil {
+		return err
+	}
+	oldRefs, err := db.documentationRefs(ctx, m.ModulePath, m.Version)
+	if err != nil {
+		return err
+	}
+	docRefs, err := db.putDocumentation(ctx, m)
+	if err != nil {
+		return err
+	}
+	// Sort to ensure proper lock ordering, as insertPackages and
+	// insertDirectories do.
+	sort.Slice(m.LegacyPackages, func(i, j int) bool {
+		return m.LegacyPackages[i].Path < m.LegacyPackages[j].Path
+	})
+	sort.Slice(m.Directories, func(i, j int) bool {
+		return m.Directories[i].Path < m.Directories[j].Path
+	})
+	err = db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
+		for _, p := range m.LegacyPackages {
+			docHTML, truncated := truncateDocumentationHTML(p.DocumentationHTML,
+				m.SourceInfo.DirectoryURL(packageSubdir(p.Path, m.ModulePath)))
+			var doc, docRef interface{} = makeValidUnicode(docHTML), nil
+			if ref, ok := docRefs[p.Path]; ok {
+				doc, docRef = nil, ref
+			}
+			if _, err := tx.Exec(ctx, `
+				UPDATE packages
+				SET synopsis = $1, documentation = $2, documentation_ref = $3, documentation_truncated = $4,
+					documentation_text = $8
+				WHERE path = $5 AND module_path = $6 AND version = $7`,
+				p.Synopsis, doc, docRef, truncated, p.Path, m.ModulePath, m.Version,
+				makeValidUnicode(p.DocumentationText)); err != nil {
+				return err
+			}
+		}
+		for _, d := range m.Directories {
+			if d.Package == nil || d.Package.Documentation == nil {
+				continue
+			}
+			doc := d.Package.Documentation
+			docHTML, truncated := truncateDocumentationHTML(doc.HTML,
+				m.SourceInfo.DirectoryURL(packageSubdir(d.Path, m.ModulePath)))
+			if _, err := tx.Exec(ctx, `
+				UPDATE documentation d
+				SET synopsis = $1, html = $2, truncated = $3
+				FROM paths p
+				INNER JOIN modules m
+				ON p.module_id = m.id
+				WHERE d.path_id = p.id
+					AND p.path = $4 AND m.module_path = $5 AND m.version = $6
+					AND d.goos = $7 AND d.goarch = $8`,
+				doc.Synopsis, makeValidUnicode(docHTML), truncated,
+				d.Path, m.ModulePath, m.Version, doc.GOOS, doc.GOARCH); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	db.deleteCached(ctx, m.ModulePath, m.Version, paths)
+	// Documentation that no longer needs the store was overwritten in the
+	// table, so its old copy in the store is unreachable.
+	var stale []string
+	for _, ref := range oldRefs {
+		if !containsValue(docRefs, ref) {
+			stale = append(stale, ref)
+		}
+	}
+	db.deleteDocumentation(ctx, stale)
+	return nil
+}
+
+// Formats of package documentation, for GetPackageDoc.
+const (
+	DocFormatHTML = "html"
+	DocFormatText = "text"
+)
+
+// GetPackageDoc returns the documentation of the package pkgPath in the module
+// modulePath at version, in the given format: DocFormatHTML for the HTML
+// shown on the package page, or DocFormatText for plain text in the format of
+// "go doc -all". It returns an error wrapping derrors.NotFound if the package
+// does not exist, or if it has no documentation in the requested format
+// because it was inserted before plain-text documentation was stored.
+func (db *DB) GetPackageDoc(ctx context.Context, pkgPath, modulePath, version, format string) (_ string, err error) {
+	defer derrors.Wrap(&err, "GetPackageDoc(ctx, %q, %q, %q, %q)", pkgPath, modulePath, version, format)
+
+	if format != DocFormatHTML && format != DocFormatText {
+		return "", fmt.Errorf("unknown documentation format %q: %w", format, derrors.InvalidArgument)
+	}
+	query := `
+		SELECT documentation, documentation_ref, documentation_text
+		FROM packages
+		WHERE path = $1 AND module_path = $2 AND version = $3`
+	var (
+		docHTML, docText sql.NullString
+		docRef           sql.NullString
+	)
+	err = db.db.QueryRow(ctx, query, pkgPath, modulePath, version).Scan(&docHTML, &docRef, &docText)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("package %s in %s@%s: %w", pkgPath, modulePath, version, derrors.NotFound)
+	}
+	if err != nil {
+		return "", err
+	}
+	if format == DocFormatHTML {
+		return db.getDocumentation(ctx, docHTML.String, docRef)
+	}
+	if !docText.Valid {
+		return "", fmt.Errorf("no text documentation for %s in %s@%s: %w", pkgPath, modulePath, version, derrors.NotFound)
+	}
+	return docText.String, nil
+}
+
+func containsValue(m map[string]string, v string) bool {
+	for _, x := range m {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// GetModulesWithDocumentationBefore returns the paths and versions of up to
+// limit module versions that have a package whose row in the packages table,
+// including its documentation, was last written before the given time, the
+// least recently written first.
+//
+// Module versions whose re-rendering failed at or after the given time are
+// skipped (see RecordRerenderFailure), so that a module version that keeps
+// failing does not hold back the others. They are returned again for a later
+// time.
+func (db *DB) GetModulesWithDocumentationBefore(ctx context.Context, before time.Time, limit int) (_ []*internal.ModuleInfo, err error) {
+	defer derrors.Wrap(&err, "GetModulesWithDocumentationBefore(ctx, %s, %d)", before, limit)
+
+	query := `
+		SELECT p.module_path, p.version
+		FROM packages p
+		INNER JOIN modules m
+		ON p.module_path = m.module_path AND p.version = m.version
+		WHERE m.rerender_failed_at IS NULL OR m.rerender_failed_at < $1
+		GROUP BY p.module_path, p.version
+		HAVING MIN(p.updated_at) < $1
+		ORDER BY MIN(p.updated_at), p.module_path, p.version
+		LIMIT $2`
+	var mis []*internal.ModuleInfo
+	collect := func(rows *sql.Rows) error {
+		var mi internal.ModuleInfo
+		if err := rows.Scan(&mi.ModulePath, &mi.Version); err != nil {
+			return err
+		}
+		mis = append(mis, &mi)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, before, limit); err != nil {
+		return nil, err
+	}
+	return mis, nil
+}
+
+// RecordRerenderFailure records that re-rendering the documentation of the
+// module version failed now. See GetModulesWithDocumentationBefore.
+func (db *DB) RecordRerenderFailure(ctx context.Context, modulePath, version string) (err error) {
+	defer derrors.Wrap(&err, "RecordRerenderFailure(ctx, %q, %q)", modulePath, version)
+
+	res, err := db.db.Exec(ctx, `
+		UPDATE modules
+		SET rerender_failed_at = CURRENT_TIMESTAMP
+		WHERE module_path = $1 AND version = $2`,
+		modulePath, version)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("RowsAffected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s@%s: %w", modulePath, version, derrors.NotFound)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestUpdateDocumentation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	m := sample.Module(sample.ModulePath, sample.VersionString, "foo")
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	// A module version that has not been re-rendered is returned.
+	got, err := testDB.GetModulesWithDocumentationBefore(ctx, time.Now().Add(time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*internal.ModuleInfo{{ModulePath: m.ModulePath, Version: m.Version}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GetModulesWithDocumentationBefore mismatch (-want +got):\n%s", diff)
+	}
+
+	const (
+		newHTML     = "<p>Re-rendered documentation.</p>"
+		newSynopsis = "Re-rendered synopsis."
+	)
+	pkg := m.LegacyPackages[0]
+	pkg.DocumentationHTML = newHTML
+	pkg.Synopsis = newSynopsis
+	for _, d := range m.Directories {
+		if d.Path == pkg.Path {
+			d.Package.Documentation.HTML = newHTML
+			d.Package.Documentation.Synopsis = newSynopsis
+		}
+	}
+	start := time.Now()
+	if err := testDB.UpdateDocumentation(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	gotPkg, err := testDB.LegacyGetPackage(ctx, pkg.Path, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPkg.DocumentationHTML != newHTML || gotPkg.Synopsis != newSynopsis {
+		t.Errorf("LegacyGetPackage: got documentation %q, synopsis %q; want %q, %q",
+			gotPkg.DocumentationHTML, gotPkg.Synopsis, newHTML, newSynopsis)
+	}
+	gotDir, err := testDB.GetDirectoryNew(ctx, pkg.Path, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc := gotDir.Package.Documentation; doc.HTML != newHTML || doc.Synopsis != newSynopsis {
+		t.Errorf("GetDirectoryNew: got documentation %q, synopsis %q; want %q, %q",
+			doc.HTML, doc.Synopsis, newHTML, newSynopsis)
+	}
+
+	// The re-rendered module version is no longer returned.
+	got, err = testDB.GetModulesWithDocumentationBefore(ctx, start, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("GetModulesWithDocumentationBefore after UpdateDocumentation: got %v, want none", got)
+	}
+}
+
+func TestGetModulesWithDocumentationBeforeSkipsFailures(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	failing := sample.Module("failing.com/mod", "v1.0.0", "foo")
+	other := sample.Module("other.com/mod", "v1.0.0", "foo")
+	// Insert the failing module first, so that it is the least recently
+	// written.
+	for _, m := range []*internal.Module{failing, other} {
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(msg string, before time.Time, want *internal.Module) {
+		t.Helper()
+		got, err := testDB.GetModulesWithDocumentationBefore(ctx, before, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantInfos := []*internal.ModuleInfo{{ModulePath: want.ModulePath, Version: want.Version}}
+		if diff := cmp.Diff(wantInfos, got); diff != "" {
+			t.Errorf("%s: mismatch (-want +got):\n%s", msg, diff)
+		}
+	}
+	before := time.Now()
+	check("before failing", before, failing)
+
+	// Re-rendering the failing module version keeps failing, which does not
+	// stop the following batches from making progress.
+	for i := 0; i < 3; i++ {
+		if err := testDB.RecordRerenderFailure(ctx, failing.ModulePath, failing.Version); err != nil {
+			t.Fatal(err)
+		}
+		check("after failing", before, other)
+	}
+
+	// A pass for a later time tries it again.
+	check("later pass", time.Now(), failing)
+
+	if err := testDB.RecordRerenderFailure(ctx, "unknown.com/mod", "v1.0.0"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("RecordRerenderFailure(unknown module): got %v, want NotFound", err)
+	}
+}
+
+func TestGetPackageDoc(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	const docText = "package foo // import \"github.com/valid_module_name/foo\"\n\nPackage foo does things.\n"
+	m := sample.Module(sample.ModulePath, sample.VersionString, "foo")
+	pkg := m.LegacyPackages[0]
+	pkg.DocumentationText = docText
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		format, want string
+	}{
+		{DocFormatHTML, pkg.DocumentationHTML},
+		{DocFormatText, docText},
+	} {
+		got, err := testDB.GetPackageDoc(ctx, pkg.Path, m.ModulePath, m.Version, test.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("GetPackageDoc(%q) = %q, want %q", test.format, got, test.want)
+		}
+	}
+
+	if _, err := testDB.GetPackageDoc(ctx, pkg.Path, m.ModulePath, m.Version, "pdf"); !errors.Is(err, derrors.InvalidArgument) {
+		t.Errorf("unknown format: got %v, want InvalidArgument", err)
+	}
+	if _, err := testDB.GetPackageDoc(ctx, pkg.Path+"/nope", m.ModulePath, m.Version, DocFormatText); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("missing package: got %v, want NotFound", err)
+	}
+
+	// Packages inserted before the text was stored have none.
+	if _, err := testDB.db.Exec(ctx, `UPDATE packages SET documentation_text = NULL`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testDB.GetPackageDoc(ctx, pkg.Path, m.ModulePath, m.Version, DocFormatText); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("no text: got %v, want NotFound", err)
+	}
+}
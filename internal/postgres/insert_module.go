@@ -6,12 +6,15 @@ package postgres
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"hash/fnv"
 	"io"
+	"regexp"
 	"runtime"
 	"sort"
 	"strings"
@@ -21,6 +24,7 @@ import (
 	"go.opencensus.io/trace"
 	"golang.org/x/mod/module"
 	"golang.org/x/mod/semver"
+	"golang.org/x/net/html"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/database"
 	"golang.org/x/pkgsite/internal/derrors"
@@ -33,6 +37,10 @@ import (
 // InsertModule inserts a version into the database using
 // db.saveVersion, along with a search document corresponding to each of its
 // packages.
+//
+// If the licenses of the module are not allowed by the license allowlist of
+// db, the module is inserted without search documents. See
+// SetLicenseAllowlist.
 func (db *DB) InsertModule(ctx context.Context, m *internal.Module) (err error) {
 	defer func() {
 		if m == nil {
@@ -59,9 +67,59 @@ func (db *DB) InsertModule(ctx context.Context, m *internal.Module) (err error)
 		return err
 	}
 	removeNonDistributableData(m)
-	return db.saveModule(ctx, m)
+	m.MinGoVersion = minGoVersion(ctx, m)
+	// The module version may have been reprocessed, so previously cached
+	// reads for it are stale.
+	paths, err := db.cachedPaths(ctx, m.ModulePath, m.Version)
+	if err != nil {
+		return err
+	}
+	isNew, err := db.saveModule(ctx, m)
+	if err != nil {
+		return err
+	}
+	db.deleteCached(ctx, m.ModulePath, m.Version, paths)
+	// Reprocessing a module version does not make it new to subscribers.
+	if isNew && db.insertHook != nil {
+		db.insertHook(ctx, &m.ModuleInfo)
+	}
+	return nil
 }
 
+// minGoVersion returns the version in the go directive of m's go.mod file,
+// or the empty string if m has no go.mod file or the directive is missing. A
+// directive whose version is malformed is logged and treated like a missing
+// one, since the go directive is informational.
+//
+// The directive is found by scanning the file rather than with
+// modfile.ParseLax, because the version of golang.org/x/mod we use rejects
+// the three-part versions, like "1.21.0", that go.mod files have had since Go
+// 1.21.
+func minGoVersion(ctx context.Context, m *internal.Module) string {
+	if !m.HasGoMod || m.GoMod == nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(m.GoMod), "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != "go" {
+			continue
+		}
+		if len(fields) != 2 || !goVersionRegexp.MatchString(fields[1]) {
+			log.Infof(ctx, "minGoVersion(%q, %q): malformed go directive %q", m.ModulePath, m.Version, strings.TrimSpace(line))
+			return ""
+		}
+		return fields[1]
+	}
+	return ""
+}
+
+// goVersionRegexp matches the versions of Go that can appear in a go
+// directive, such as "1.14", "1.21.0" and "1.21rc1".
+var goVersionRegexp = regexp.MustCompile(`^[1-9][0-9]*\.(0|[1-9][0-9]*)(\.(0|[1-9][0-9]*))?((rc|beta)[1-9][0-9]*)?$`)
+
 // saveModule inserts a Module into the database along with its packages,
 // imports, and licenses.  If any of these rows already exist, the module and
 // corresponding will be deleted and reinserted.
@@ -69,17 +127,27 @@ func (db *DB) InsertModule(ctx context.Context, m *internal.Module) (err error)
 //
 // A derrors.InvalidArgument error will be returned if the given module and
 // licenses are invalid.
-func (db *DB) saveModule(ctx context.Context, m *internal.Module) (err error) {
+//
+// saveModule reports whether the module version was not in the database
+// before.
+func (db *DB) saveModule(ctx context.Context, m *internal.Module) (isNew bool, err error) {
 	defer derrors.Wrap(&err, "saveModule(ctx, tx, Module(%q, %q))", m.ModulePath, m.Version)
 	ctx, span := trace.StartSpan(ctx, "saveModule")
 	defer span.End()
 
 	logMemory(ctx, "at start of saveModule")
-	return db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
-		moduleID, err := insertModule(ctx, tx, m)
+	// Store large documentation before the transaction, so that the rows
+	// that refer to it are never inserted without it.
+	docRefs, err := db.putDocumentation(ctx, m)
+	if err != nil {
+		return false, err
+	}
+	err = db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
+		moduleID, inserted, err := insertModule(ctx, tx, m)
 		if err != nil {
 			return err
 		}
+		isNew = inserted
 		logMemory(ctx, "after insertModule")
 
 		if err := insertLicenses(ctx, tx, m, moduleID); err != nil {
@@ -87,7 +155,13 @@ func (db *DB) saveModule(ctx context.Context, m *internal.Module) (err error) {
 		}
 
 		logMemory(ctx, "after insertLicenses")
-		if err := insertPackages(ctx, tx, m); err != nil {
+		if err := insertModuleFiles(ctx, tx, m, moduleID); err != nil {
+			return err
+		}
+		if err := insertVendoredModules(ctx, tx, m, moduleID); err != nil {
+			return err
+		}
+		if err := insertPackages(ctx, tx, m, docRefs); err != nil {
 			return err
 		}
 		logMemory(ctx, "after insertPackages")
@@ -108,21 +182,26 @@ func (db *DB) saveModule(ctx context.Context, m *internal.Module) (err error) {
 		if err := lock(ctx, tx, m.ModulePath); err != nil {
 			return err
 		}
+		if err := updateLatestVersion(ctx, tx, m.ModulePath); err != nil {
+			return err
+		}
 
 		// We only insert into imports_unique and search_documents if this is
-		// the latest version of the module.
+		// the latest version of the module. With a license allowlist, an
+		// older version is searchable if the later ones are not allowed, so
+		// an allowed older version is also inserted into search_documents.
 		isLatest, err := isLatestVersion(ctx, tx, m.ModulePath, m.Version)
 		if err != nil {
 			return err
 		}
-		if !isLatest {
+		if isLatest {
+			if err := insertImportsUnique(ctx, tx, m); err != nil {
+				return err
+			}
+		} else if db.licenseAllowlist == nil || !db.LicensesAllowed(m) {
 			return nil
 		}
 
-		if err := insertImportsUnique(ctx, tx, m); err != nil {
-			return err
-		}
-
 		// If there is a more recent version of this module that has an alternative
 		// module path, then do not insert its packages into search_documents. This
 		// happens when a module that initially does not have a go.mod file is
@@ -152,20 +231,42 @@ func (db *DB) saveModule(ctx context.Context, m *internal.Module) (err error) {
 			log.Infof(ctx, "%s@%s: not inserting into search documents", m.ModulePath, m.Version)
 			return err
 		}
+		// A module version whose licenses are not allowed is not searchable,
+		// even if it was indexed before the allowlist was set. Its packages
+		// stay indexed at an older version that is allowed, if any.
+		if !db.LicensesAllowed(m) {
+			log.Infof(ctx, "%s@%s: licenses not allowed, not inserting into search documents", m.ModulePath, m.Version)
+			if err := deleteSearchDocuments(ctx, tx, m.ModulePath, m.Version); err != nil {
+				return err
+			}
+		}
 		// Insert the module's packages into search_documents.
-		return UpsertSearchDocuments(ctx, tx, m)
+		return UpsertSearchDocuments(ctx, tx, m, db.allowedLicenseTypes())
 	})
+	if err != nil {
+		return false, err
+	}
+	return isNew, nil
 }
 
-func insertModule(ctx context.Context, db *database.DB, m *internal.Module) (_ int, err error) {
+// insertModule inserts or updates the modules row of m, and returns its ID
+// and whether the row was inserted rather than updated.
+func insertModule(ctx context.Context, db *database.DB, m *internal.Module) (_ int, inserted bool, err error) {
 	ctx, span := trace.StartSpan(ctx, "insertModule")
 	defer span.End()
 	defer derrors.Wrap(&err, "insertModule(ctx, %q, %q)", m.ModulePath, m.Version)
 	sourceInfoJSON, err := json.Marshal(m.SourceInfo)
 	if err != nil {
-		return 0, err
+		return 0, false, err
+	}
+	var changelogFilePath, changelogContents interface{}
+	if m.Changelog != nil {
+		changelogFilePath = m.Changelog.Filepath
+		changelogContents = makeValidUnicode(m.Changelog.Contents)
 	}
 	var moduleID int
+	// xmax is zero only for a row version that no transaction has updated,
+	// so after the upsert it is zero if and only if the row was inserted.
 	err = db.QueryRow(ctx,
 		`INSERT INTO modules(
 			module_path,
@@ -178,16 +279,24 @@ func insertModule(ctx context.Context, db *database.DB, m *internal.Module) (_ i
 			series_path,
 			source_info,
 			redistributable,
-			has_go_mod)
-		VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10, $11)
+			has_go_mod,
+			min_go_version,
+			license_expression,
+			changelog_file_path,
+			changelog_contents)
+		VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10, $11, $12, $13, $14, $15)
 		ON CONFLICT
 			(module_path, version)
 		DO UPDATE SET
 			readme_file_path=excluded.readme_file_path,
 			readme_contents=excluded.readme_contents,
 			source_info=excluded.source_info,
-			redistributable=excluded.redistributable
-		RETURNING id`,
+			redistributable=excluded.redistributable,
+			min_go_version=excluded.min_go_version,
+			license_expression=excluded.license_expression,
+			changelog_file_path=excluded.changelog_file_path,
+			changelog_contents=excluded.changelog_contents
+		RETURNING id, xmax = 0`,
 		m.ModulePath,
 		m.Version,
 		m.CommitTime,
@@ -199,25 +308,86 @@ func insertModule(ctx context.Context, db *database.DB, m *internal.Module) (_ i
 		sourceInfoJSON,
 		m.IsRedistributable,
 		m.HasGoMod,
-	).Scan(&moduleID)
+		m.MinGoVersion,
+		m.LicenseExpression,
+		changelogFilePath,
+		changelogContents,
+	).Scan(&moduleID, &inserted)
 	if err != nil {
-		return 0, err
+		return 0, false, err
+	}
+	return moduleID, inserted, nil
+}
+
+// insertModuleFiles records the paths of the files in m.
+func insertModuleFiles(ctx context.Context, db *database.DB, m *internal.Module, moduleID int) (err error) {
+	defer derrors.Wrap(&err, "insertModuleFiles(ctx, %q, %q)", m.ModulePath, m.Version)
+	files := m.Files
+	if files == nil {
+		// A nil slice would be stored as NULL.
+		files = []string{}
 	}
-	return moduleID, nil
+	_, err = db.Exec(ctx, `
+		INSERT INTO module_files (module_id, paths)
+		VALUES ($1, $2)
+		ON CONFLICT (module_id)
+		DO UPDATE SET paths = excluded.paths`,
+		moduleID, pq.Array(files))
+	return err
+}
+
+// insertVendoredModules replaces the recorded vendored modules of m with
+// m.VendoredModules.
+func insertVendoredModules(ctx context.Context, db *database.DB, m *internal.Module, moduleID int) (err error) {
+	defer derrors.Wrap(&err, "insertVendoredModules(ctx, %q, %q)", m.ModulePath, m.Version)
+	if _, err := db.Exec(ctx, `DELETE FROM vendored_modules WHERE module_id = $1`, moduleID); err != nil {
+		return err
+	}
+	if len(m.VendoredModules) == 0 {
+		return nil
+	}
+	var values []interface{}
+	for _, vm := range m.VendoredModules {
+		values = append(values, moduleID, vm.ModulePath, vm.Version, vm.Replacement)
+	}
+	cols := []string{"module_id", "vendored_module_path", "vendored_version", "replacement"}
+	// A malformed modules.txt file may list a module more than once.
+	return db.BulkInsert(ctx, "vendored_modules", cols, values, database.OnConflictDoNothing)
 }
 
 func insertLicenses(ctx context.Context, db *database.DB, m *internal.Module, moduleID int) (err error) {
 	ctx, span := trace.StartSpan(ctx, "insertLicenses")
 	defer span.End()
 	defer derrors.Wrap(&err, "insertLicenses(ctx, %q, %q)", m.ModulePath, m.Version)
-	var licenseValues []interface{}
+	var (
+		licenseValues, contentsValues []interface{}
+		seenHashes                    = map[string]bool{}
+	)
 	for _, l := range m.Licenses {
 		covJSON, err := json.Marshal(l.Coverage)
 		if err != nil {
 			return fmt.Errorf("marshalling %+v: %v", l.Coverage, err)
 		}
+		// The contents are stored once in license_contents, keyed by their
+		// hash, and referenced from licenses.
+		var hash sql.NullString
+		if contents := makeValidUnicode(string(l.Contents)); contents != "" {
+			hash = sql.NullString{String: licenseContentsHash(contents), Valid: true}
+			if !seenHashes[hash.String] {
+				seenHashes[hash.String] = true
+				contentsValues = append(contentsValues, hash.String, contents)
+			}
+		}
 		licenseValues = append(licenseValues, m.ModulePath, m.Version,
-			l.FilePath, makeValidUnicode(string(l.Contents)), pq.Array(l.Types), covJSON, moduleID)
+			l.FilePath, "", hash, pq.Array(l.Types), covJSON, l.PartialScan, moduleID)
+	}
+	if len(contentsValues) > 0 {
+		// Contents that are already present are shared with other modules,
+		// and are left alone.
+		if err := db.BulkInsert(ctx, "license_contents", []string{"hash", "contents"},
+			contentsValues, database.OnConflictDoNothing); err != nil {
+			return err
+		}
 	}
 	if len(licenseValues) > 0 {
 		licenseCols := []string{
@@ -225,8 +395,10 @@ func insertLicenses(ctx context.Context, db *database.DB, m *internal.Module, mo
 			"version",
 			"file_path",
 			"contents",
+			"contents_hash",
 			"types",
 			"coverage",
+			"partial_scan",
 			"module_id",
 		}
 		return db.BulkUpsert(ctx, "licenses", licenseCols, licenseValues,
@@ -235,7 +407,17 @@ func insertLicenses(ctx context.Context, db *database.DB, m *internal.Module, mo
 	return nil
 }
 
-func insertPackages(ctx context.Context, db *database.DB, m *internal.Module) (err error) {
+// licenseContentsHash returns the key of contents in the license_contents
+// table: the hex-encoded SHA-256 hash of contents.
+func licenseContentsHash(contents string) string {
+	h := sha256.Sum256([]byte(contents))
+	return hex.EncodeToString(h[:])
+}
+
+// insertPackages inserts the packages of m and their imports. The
+// documentation of the packages in docRefs is stored as a reference to its key
+// in the documentation store, instead of inline.
+func insertPackages(ctx context.Context, db *database.DB, m *internal.Module, docRefs map[string]string) (err error) {
 	ctx, span := trace.StartSpan(ctx, "insertPackages")
 	defer span.End()
 	defer derrors.Wrap(&err, "insertPackages(ctx, %q, %q)", m.ModulePath, m.Version)
@@ -254,6 +436,7 @@ func insertPackages(ctx context.Context, db *database.DB, m *internal.Module) (e
 	})
 	for _, p := range m.LegacyPackages {
 		sort.Strings(p.Imports)
+		p.StdlibOnly = stdlibOnly(p.Imports)
 	}
 	var pkgValues, importValues []interface{}
 	for _, p := range m.LegacyPackages {
@@ -276,6 +459,12 @@ func insertPackages(ctx context.Context, db *database.DB, m *internal.Module) (e
 				}
 			}
 		}
+		docHTML, truncated := truncateDocumentationHTML(p.DocumentationHTML,
+			m.SourceInfo.DirectoryURL(packageSubdir(p.Path, m.ModulePath)))
+		var doc, docRef interface{} = makeValidUnicode(docHTML), nil
+		if ref, ok := docRefs[p.Path]; ok {
+			doc, docRef = nil, ref
+		}
 		pkgValues = append(pkgValues,
 			p.Path,
 			p.Synopsis,
@@ -284,15 +473,20 @@ func insertPackages(ctx context.Context, db *database.DB, m *internal.Module) (e
 			m.ModulePath,
 			p.V1Path,
 			p.IsRedistributable,
-			makeValidUnicode(p.DocumentationHTML),
+			doc,
+			docRef,
+			truncated,
 			pq.Array(licenseTypes),
 			pq.Array(licensePaths),
 			p.GOOS,
 			p.GOARCH,
 			m.CommitTime,
+			p.StdlibOnly,
+			makeValidUnicode(p.DocumentationText),
+			pq.Array(p.Signatures),
 		)
 		for _, i := range p.Imports {
-			importValues = append(importValues, p.Path, m.ModulePath, m.Version, i)
+			importValues = append(importValues, p.Path, m.ModulePath, m.Version, i, pq.Array(p.ImportBuildContexts[i]))
 		}
 	}
 	if len(pkgValues) > 0 {
@@ -306,11 +500,16 @@ func insertPackages(ctx context.Context, db *database.DB, m *internal.Module) (e
 			"v1_path",
 			"redistributable",
 			"documentation",
+			"documentation_ref",
+			"documentation_truncated",
 			"license_types",
 			"license_paths",
 			"goos",
 			"goarch",
 			"commit_time",
+			"stdlib_only",
+			"documentation_text",
+			"signatures",
 		}
 		if err := db.BulkUpsert(ctx, "packages", pkgCols, pkgValues, uniqueCols); err != nil {
 			return err
@@ -318,19 +517,31 @@ func insertPackages(ctx context.Context, db *database.DB, m *internal.Module) (e
 	}
 
 	if len(importValues) > 0 {
-		importCols := []string{
+		uniqueCols := []string{
 			"from_path",
 			"from_module_path",
 			"from_version",
 			"to_path",
 		}
-		if err := db.BulkUpsert(ctx, "imports", importCols, importValues, importCols); err != nil {
+		importCols := append(uniqueCols, "build_contexts")
+		if err := db.BulkUpsert(ctx, "imports", importCols, importValues, uniqueCols); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// stdlibOnly reports whether every path in imports is the path of a standard
+// library package.
+func stdlibOnly(imports []string) bool {
+	for _, i := range imports {
+		if !stdlib.Contains(i) {
+			return false
+		}
+	}
+	return true
+}
+
 // insertImportsUnique inserts and removes rows from the imports_unique table. It should only
 // be called if the given module's version is the latest.
 func insertImportsUnique(ctx context.Context, tx *database.DB, m *internal.Module) (err error) {
@@ -493,10 +704,12 @@ func insertDirectories(ctx context.Context, db *database.DB, m *internal.Module,
 				continue
 			}
 			id := pathToID[path]
-			docValues = append(docValues, id, doc.GOOS, doc.GOARCH, doc.Synopsis, makeValidUnicode(doc.HTML))
+			docHTML, truncated := truncateDocumentationHTML(doc.HTML,
+				m.SourceInfo.DirectoryURL(packageSubdir(path, m.ModulePath)))
+			docValues = append(docValues, id, doc.GOOS, doc.GOARCH, doc.Synopsis, makeValidUnicode(docHTML), truncated)
 		}
 		uniqueCols := []string{"path_id", "goos", "goarch"}
-		docCols := append(uniqueCols, "synopsis", "html")
+		docCols := append(uniqueCols, "synopsis", "html", "truncated")
 		if err := db.BulkUpsert(ctx, "documentation", docCols, docValues, uniqueCols); err != nil {
 			return err
 		}
@@ -564,47 +777,108 @@ func isLatestVersion(ctx context.Context, db *database.DB, modulePath, version s
 	return version == v, nil
 }
 
-// validateModule checks that fields needed to insert a module into the
-// database are present. Otherwise, it returns an error listing the reasons the
-// module cannot be inserted.
-func validateModule(m *internal.Module) (err error) {
-	defer func() {
-		if err != nil {
-			err = fmt.Errorf("%v: %w", err, derrors.DBModuleInsertInvalid)
-			if m != nil {
-				derrors.Wrap(&err, "validateModule(%q, %q)", m.ModulePath, m.Version)
-			}
-		}
-	}()
+// updateLatestVersion sets the is_latest column of the modules table so that
+// it is true for the latest version of the module, as determined by
+// isLatestVersion, and false for all others. It should be called while
+// holding the lock on modulePath.
+func updateLatestVersion(ctx context.Context, tx *database.DB, modulePath string) (err error) {
+	defer derrors.Wrap(&err, "updateLatestVersion(ctx, tx, %q)", modulePath)
 
+	_, err = tx.Exec(ctx, `
+		UPDATE modules m
+		SET is_latest = (m.version = l.version)
+		FROM (
+			SELECT version FROM modules WHERE module_path = $1
+			ORDER BY version_type = 'release' DESC, sort_version DESC
+			LIMIT 1
+		) l
+		WHERE m.module_path = $1 AND m.is_latest <> (m.version = l.version)`,
+		modulePath)
+	return err
+}
+
+// ValidateModule checks that fields needed to insert a module into the
+// database are present. It returns an error for every problem it finds, or nil
+// if the module can be inserted.
+func ValidateModule(m *internal.Module) []error {
 	if m == nil {
-		return fmt.Errorf("nil module")
+		return []error{errors.New("nil module")}
 	}
-	var errReasons []string
+	var errs []error
 	if m.Version == "" {
-		errReasons = append(errReasons, "no specified version")
+		errs = append(errs, errors.New("no specified version"))
 	}
 	if m.ModulePath == "" {
-		errReasons = append(errReasons, "no module path")
+		errs = append(errs, errors.New("no module path"))
 	}
 	if m.ModulePath != stdlib.ModulePath {
 		if err := module.CheckPath(m.ModulePath); err != nil {
-			errReasons = append(errReasons, fmt.Sprintf("invalid module path (%s)", err))
+			errs = append(errs, fmt.Errorf("invalid module path (%s)", err))
 		}
 		if !semver.IsValid(m.Version) {
-			errReasons = append(errReasons, "invalid version")
+			errs = append(errs, errors.New("invalid version"))
 		}
 	}
 	if len(m.LegacyPackages) == 0 {
-		errReasons = append(errReasons, "module does not have any packages")
+		errs = append(errs, errors.New("module does not have any packages"))
+	}
+	for _, p := range m.LegacyPackages {
+		if len(p.Imports) > internal.MaxImportsPerPackage {
+			errs = append(errs, fmt.Errorf("package %q has %d imports; exceeds limit %d: %w",
+				p.Path, len(p.Imports), internal.MaxImportsPerPackage, derrors.PackageMaxImportsLimitExceeded))
+		}
 	}
 	if m.CommitTime.IsZero() {
-		errReasons = append(errReasons, "empty commit time")
+		errs = append(errs, errors.New("empty commit time"))
 	}
-	if len(errReasons) != 0 {
-		return fmt.Errorf("cannot insert module %q: %s", m.Version, strings.Join(errReasons, ", "))
+	return errs
+}
+
+// A ModuleValidationError describes all the reasons a module cannot be
+// inserted into the database. It wraps derrors.DBModuleInsertInvalid.
+type ModuleValidationError struct {
+	Version string
+	Errors  []error // as returned by ValidateModule
+}
+
+func (e *ModuleValidationError) Error() string {
+	var reasons []string
+	for _, err := range e.Errors {
+		reasons = append(reasons, err.Error())
 	}
-	return nil
+	return fmt.Sprintf("cannot insert module %q: %s", e.Version, strings.Join(reasons, ", "))
+}
+
+func (e *ModuleValidationError) Unwrap() error {
+	return derrors.DBModuleInsertInvalid
+}
+
+// Is reports whether any of the errors in e matches target, so that callers
+// can check for a specific problem, such as
+// derrors.PackageMaxImportsLimitExceeded.
+func (e *ModuleValidationError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateModule checks that fields needed to insert a module into the
+// database are present. Otherwise, it returns a *ModuleValidationError listing
+// the reasons the module cannot be inserted.
+func validateModule(m *internal.Module) (err error) {
+	errs := ValidateModule(m)
+	if len(errs) == 0 {
+		return nil
+	}
+	if m == nil {
+		return &ModuleValidationError{Errors: errs}
+	}
+	err = &ModuleValidationError{Version: m.Version, Errors: errs}
+	derrors.Wrap(&err, "validateModule(%q, %q)", m.ModulePath, m.Version)
+	return err
 }
 
 // compareLicenses compares m.Licenses with the existing licenses for
@@ -679,36 +953,62 @@ func removeNonDistributableData(m *internal.Module) {
 			// Prune derived information that can't be stored.
 			p.Synopsis = ""
 			p.DocumentationHTML = ""
+			p.DocumentationText = ""
 		}
 	}
 	if !m.IsRedistributable {
 		m.LegacyReadmeFilePath = ""
 		m.LegacyReadmeContents = ""
+		m.Changelog = nil
 	}
 }
 
 // DeleteModule deletes a Version from the database.
 func (db *DB) DeleteModule(ctx context.Context, modulePath, version string) (err error) {
 	defer derrors.Wrap(&err, "DeleteModule(ctx, db, %q, %q)", modulePath, version)
+	paths, err := db.cachedPaths(ctx, modulePath, version)
+	if err != nil {
+		return err
+	}
+	defer db.deleteCached(ctx, modulePath, version, paths)
+	docRefs, err := db.documentationRefs(ctx, modulePath, version)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == nil {
+			db.deleteDocumentation(ctx, docRefs)
+		}
+	}()
 	return db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
+		// Lock the module path, as saveModule does, so that is_latest is
+		// updated consistently.
+		if err := lock(ctx, tx, modulePath); err != nil {
+			return err
+		}
 		// We only need to delete from the modules table. Thanks to ON DELETE
 		// CASCADE constraints, that will trigger deletions from all other tables.
 		const stmt = `DELETE FROM modules WHERE module_path=$1 AND version=$2`
-		if _, err := db.db.Exec(ctx, stmt, modulePath, version); err != nil {
+		if _, err := tx.Exec(ctx, stmt, modulePath, version); err != nil {
+			return err
+		}
+		// If the deleted version was the latest, another version becomes the
+		// latest.
+		if err := updateLatestVersion(ctx, tx, modulePath); err != nil {
 			return err
 		}
 
-		if _, err = db.db.Exec(ctx, `DELETE FROM version_map WHERE module_path = $1 AND resolved_version = $2`, modulePath, version); err != nil {
+		if _, err = tx.Exec(ctx, `DELETE FROM version_map WHERE module_path = $1 AND resolved_version = $2`, modulePath, version); err != nil {
 			return err
 		}
 
 		var x int
-		err = db.db.QueryRow(ctx, `SELECT 1 FROM modules WHERE module_path=$1 LIMIT 1`, modulePath).Scan(&x)
+		err = tx.QueryRow(ctx, `SELECT 1 FROM modules WHERE module_path=$1 LIMIT 1`, modulePath).Scan(&x)
 		if err != sql.ErrNoRows || err == nil {
 			return err
 		}
 		// No versions of this module exist; remove it from imports_unique.
-		_, err = db.db.Exec(ctx, `DELETE FROM imports_unique WHERE from_module_path = $1`, modulePath)
+		_, err = tx.Exec(ctx, `DELETE FROM imports_unique WHERE from_module_path = $1`, modulePath)
 		return err
 	})
 }
@@ -739,6 +1039,132 @@ func makeValidUnicode(s string) string {
 	return b.String()
 }
 
+// maxStoredDocumentationHTML is the maximum size, in bytes, of the
+// documentation HTML that InsertModule will store for a package. Longer
+// documentation is truncated by truncateDocumentationHTML.
+const maxStoredDocumentationHTML = 10 * 1000 * 1000
+
+// truncateDocumentationHTML returns truncateDocumentationHTMLTo(docHTML,
+// sourceURL, maxStoredDocumentationHTML).
+func truncateDocumentationHTML(docHTML, sourceURL string) (string, bool) {
+	return truncateDocumentationHTMLTo(docHTML, sourceURL, maxStoredDocumentationHTML)
+}
+
+// truncateDocumentationHTMLTo returns docHTML unchanged if it is no longer
+// than limit bytes. Otherwise it cuts docHTML at the last point that fits,
+// closes the elements that are open there, appends a notice that the
+// documentation was truncated, with a link to sourceURL if it is not empty,
+// and reports true.
+func truncateDocumentationHTMLTo(docHTML, sourceURL string, limit int) (string, bool) {
+	if len(docHTML) <= limit {
+		return docHTML, false
+	}
+	notice := `<p class="Documentation-truncated">Documentation truncated.`
+	if sourceURL != "" {
+		notice += fmt.Sprintf(` <a href="%s">View the source</a>.`, html.EscapeString(sourceURL))
+	}
+	notice += `</p>`
+
+	n := limit - len(notice)
+	if n < 0 {
+		n = 0
+	}
+	// Walk the tokens of docHTML, keeping the elements that are open, until
+	// the closing tags of those elements no longer fit after the token.
+	var (
+		open     []string // names of the open elements, innermost last
+		closeLen int      // length of the closing tags of open
+		end      int      // offset of the end of the last token that fits
+	)
+	z := html.NewTokenizer(strings.NewReader(docHTML))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		tokenEnd := end + len(z.Raw())
+		switch tt {
+		case html.TextToken:
+			if tokenEnd+closeLen > n {
+				// Keep as much of the text as fits, without splitting a
+				// UTF-8 sequence or a character reference.
+				cut := n - closeLen
+				for cut > end && !utf8.RuneStart(docHTML[cut]) {
+					cut--
+				}
+				if i := strings.LastIndexByte(docHTML[end:cut], '&'); i >= 0 && !strings.Contains(docHTML[end+i:cut], ";") {
+					cut = end + i
+				}
+				if cut > end {
+					end = cut
+				}
+				return docHTML[:end] + closingTags(open) + notice, true
+			}
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			if voidElements[string(name)] {
+				break
+			}
+			if tokenEnd+closeLen+len(name)+len("</>") > n {
+				return docHTML[:end] + closingTags(open) + notice, true
+			}
+			open = append(open, string(name))
+			closeLen += len(name) + len("</>")
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != string(name) {
+					continue
+				}
+				// The closing tag also closes every element inside this one.
+				l := closeLen
+				for _, o := range open[i:] {
+					l -= len(o) + len("</>")
+				}
+				if tokenEnd+l > n {
+					return docHTML[:end] + closingTags(open) + notice, true
+				}
+				open, closeLen = open[:i], l
+				break
+			}
+		}
+		if tokenEnd+closeLen > n {
+			break
+		}
+		end = tokenEnd
+	}
+	return docHTML[:end] + closingTags(open) + notice, true
+}
+
+// voidElements is the set of HTML elements that have no closing tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"param": true, "source": true, "track": true, "wbr": true,
+}
+
+// closingTags returns the closing tags of the open elements, innermost first.
+func closingTags(open []string) string {
+	var b strings.Builder
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String()
+}
+
+// packageSubdir returns the directory of pkgPath relative to the root of
+// the module modulePath.
+func packageSubdir(pkgPath, modulePath string) string {
+	switch {
+	case pkgPath == modulePath:
+		return ""
+	case modulePath == stdlib.ModulePath:
+		return pkgPath
+	default:
+		return strings.TrimPrefix(pkgPath, modulePath+"/")
+	}
+}
+
 var MemoryLoggingDisabled = true
 
 func logMemory(ctx context.Context, msg string) {
@@ -8,14 +8,17 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/lib/pq"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/database"
 	"golang.org/x/pkgsite/internal/derrors"
@@ -115,12 +118,6 @@ func checkModule(ctx context.Context, t *testing.T, want *internal.Module) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		// TODO(golang/go#38513): remove once we start displaying
-		// READMEs for directories instead of the top-level module.
-		dir.Readme = &internal.Readme{
-			Filepath: sample.ReadmeFilePath,
-			Contents: sample.ReadmeContents,
-		}
 		wantd := internal.VersionedDirectory{
 			DirectoryNew: *dir,
 			ModuleInfo:   want.ModuleInfo,
@@ -161,9 +158,7 @@ func TestUpsertModule(t *testing.T) {
 	// Change the module, and re-insert.
 	m.IsRedistributable = !m.IsRedistributable
 	m.Licenses[0].Contents = append(m.Licenses[0].Contents, " and more"...)
-	// TODO(golang/go#38513): uncomment line below once we start displaying
-	// READMEs for directories instead of the top-level module.
-	// m.Directories[0].Readme.Contents += " and more"
+	m.Directories[0].Readme.Contents += " and more"
 	m.LegacyPackages[0].Synopsis = "New synopsis"
 	if err := testDB.InsertModule(ctx, m); err != nil {
 		t.Fatal(err)
@@ -249,6 +244,168 @@ func TestInsertModuleErrors(t *testing.T) {
 	}
 }
 
+func TestValidateModule(t *testing.T) {
+	m := sample.DefaultModule()
+	m.Version = ""
+	m.CommitTime = time.Time{}
+	var got []string
+	for _, err := range ValidateModule(m) {
+		got = append(got, err.Error())
+	}
+	want := []string{"no specified version", "invalid version", "empty commit time"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ValidateModule mismatch (-want +got):\n%s", diff)
+	}
+
+	if errs := ValidateModule(sample.DefaultModule()); errs != nil {
+		t.Errorf("ValidateModule(sample.DefaultModule()) = %v, want nil", errs)
+	}
+
+	// InsertModule reports all of the problems at once.
+	err := validateModule(m)
+	var verr *ModuleValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("validateModule: got %v, want a *ModuleValidationError", err)
+	}
+	if len(verr.Errors) != len(want) {
+		t.Errorf("got %d errors, want %d", len(verr.Errors), len(want))
+	}
+	if !errors.Is(err, derrors.DBModuleInsertInvalid) {
+		t.Errorf("got %v, want %v", err, derrors.DBModuleInsertInvalid)
+	}
+	if errors.Is(err, derrors.PackageMaxImportsLimitExceeded) {
+		t.Errorf("got %v, want it not to match %v", err, derrors.PackageMaxImportsLimitExceeded)
+	}
+}
+
+func TestInsertModuleMaxImports(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	var imports []string
+	for i := 0; i <= internal.MaxImportsPerPackage; i++ {
+		imports = append(imports, fmt.Sprintf("example.com/import%d", i))
+	}
+	m := sample.DefaultModule()
+	m.LegacyPackages[0].Imports = imports
+	err := testDB.InsertModule(ctx, m)
+	if !errors.Is(err, derrors.PackageMaxImportsLimitExceeded) {
+		t.Fatalf("InsertModule: got error %v, want %v", err, derrors.PackageMaxImportsLimitExceeded)
+	}
+	if !errors.Is(err, derrors.DBModuleInsertInvalid) {
+		t.Errorf("InsertModule: got error %v, want %v", err, derrors.DBModuleInsertInvalid)
+	}
+	if _, err := testDB.LegacyGetModuleInfo(ctx, m.ModulePath, m.Version); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("LegacyGetModuleInfo: got error %v, want %v", err, derrors.NotFound)
+	}
+
+	// A package at the limit can be inserted.
+	m.LegacyPackages[0].Imports = imports[:internal.MaxImportsPerPackage]
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInsertModuleDocumentationTruncated(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	const line = "<p>Line.</p>\n"
+	m := sample.DefaultModule()
+	m.LegacyPackages[0].DocumentationHTML = strings.Repeat(line, maxStoredDocumentationHTML/len(line)+1)
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		doc       string
+		truncated bool
+	)
+	row := testDB.db.QueryRow(ctx, `
+		SELECT documentation, documentation_truncated
+		FROM packages
+		WHERE path = $1 AND module_path = $2 AND version = $3`,
+		m.LegacyPackages[0].Path, m.ModulePath, m.Version)
+	if err := row.Scan(&doc, &truncated); err != nil {
+		t.Fatal(err)
+	}
+	if !truncated {
+		t.Error("documentation_truncated = false, want true")
+	}
+	if len(doc) > maxStoredDocumentationHTML {
+		t.Errorf("got %d bytes of documentation, want at most %d", len(doc), maxStoredDocumentationHTML)
+	}
+	if !strings.Contains(doc, "Documentation truncated.") {
+		t.Errorf("documentation does not contain the truncation notice:\n%s", doc)
+	}
+}
+
+func TestTruncateDocumentationHTML(t *testing.T) {
+	const (
+		sourceURL = "https://github.com/a/b/tree/v1.0.0/c"
+		notice    = `<p class="Documentation-truncated">Documentation truncated. <a href="` + sourceURL + `">View the source</a>.</p>`
+		// shortNotice is the notice when there is no source URL.
+		shortNotice = `<p class="Documentation-truncated">Documentation truncated.</p>`
+	)
+	for _, test := range []struct {
+		name, in, sourceURL, want string
+		wantTruncated             bool
+	}{
+		{
+			name: "short",
+			in:   "<p>doc</p>",
+			want: "<p>doc</p>",
+		},
+		{
+			name:          "cut in text",
+			in:            "<p>one</p>\n<p>two</p>\n" + strings.Repeat("x", 200),
+			sourceURL:     sourceURL,
+			want:          "<p>one</p>\n<p>two</p>\n" + strings.Repeat("x", 200-len("<p>one</p>\n<p>two</p>\n")-len(notice)) + notice,
+			wantTruncated: true,
+		},
+		{
+			name:          "no source URL",
+			in:            "<p>one</p>\n" + strings.Repeat("x", 200),
+			want:          "<p>one</p>\n" + strings.Repeat("x", 126) + shortNotice,
+			wantTruncated: true,
+		},
+		{
+			name:          "no line break",
+			in:            strings.Repeat("é", 200),
+			want:          strings.Repeat("é", 68) + shortNotice,
+			wantTruncated: true,
+		},
+		{
+			name:          "close open elements",
+			in:            "<div><p>one</p><br><pre>" + strings.Repeat("x", 200) + "</pre></div>",
+			want:          "<div><p>one</p><br><pre>" + strings.Repeat("x", 101) + "</pre></div>" + shortNotice,
+			wantTruncated: true,
+		},
+		{
+			name:          "cut between elements",
+			in:            "<div>" + strings.Repeat("<p>abc</p>", 20) + "</div>",
+			want:          "<div>" + strings.Repeat("<p>abc</p>", 12) + "</div>" + shortNotice,
+			wantTruncated: true,
+		},
+		{
+			name:          "character reference",
+			in:            "<pre>" + strings.Repeat("x", 124) + "&amp;" + strings.Repeat("x", 100) + "</pre>",
+			want:          "<pre>" + strings.Repeat("x", 124) + "</pre>" + shortNotice,
+			wantTruncated: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, truncated := truncateDocumentationHTMLTo(test.in, test.sourceURL, 200)
+			if got != test.want || truncated != test.wantTruncated {
+				t.Errorf("truncateDocumentationHTMLTo(%q, %q, 200) = %q, %t; want %q, %t",
+					test.in, test.sourceURL, got, truncated, test.want, test.wantTruncated)
+			}
+		})
+	}
+}
+
 func TestPostgres_ReadAndWriteModuleOtherColumns(t *testing.T) {
 	// Verify that InsertModule correctly populates the columns in the versions
 	// table that are not in the LegacyModuleInfo struct.
@@ -285,6 +442,170 @@ func TestPostgres_ReadAndWriteModuleOtherColumns(t *testing.T) {
 	}
 }
 
+func TestInsertModuleMinGoVersion(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx := context.Background()
+
+	for _, test := range []struct {
+		name     string
+		hasGoMod bool
+		goMod    string
+		want     string
+	}{
+		{"go directive", true, "module github.com/go/directive\n\ngo 1.18\n", "1.18"},
+		{"three part go directive", true, "module github.com/three/part\n\ngo 1.21.0\n", "1.21.0"},
+		{"no go directive", true, "module github.com/no/directive\n", ""},
+		{"no go.mod", false, "", ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			m := sample.Module("github.com/"+strings.ReplaceAll(test.name, " ", "/"), sample.VersionString, "")
+			m.HasGoMod = test.hasGoMod
+			if test.hasGoMod {
+				m.GoMod = []byte(test.goMod)
+			}
+			if err := testDB.InsertModule(ctx, m); err != nil {
+				t.Fatal(err)
+			}
+			got, err := testDB.LegacyGetModuleInfo(ctx, m.ModulePath, m.Version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.MinGoVersion != test.want {
+				t.Errorf("MinGoVersion = %q, want %q", got.MinGoVersion, test.want)
+			}
+		})
+	}
+}
+
+func TestMinGoVersion(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		hasGoMod bool
+		goMod    []byte
+		want     string
+	}{
+		{true, []byte("module m\n\ngo 1.18\n"), "1.18"},
+		{true, []byte("module m\n\ngo 1.14\n\nrequire golang.org/x/mod v0.3.0\n"), "1.14"},
+		{true, []byte("module m\n\ngo 1.21.0\n\ntoolchain go1.21.3\n"), "1.21.0"},
+		{true, []byte("module m\n\ngo 1.22rc1 // prerelease\n"), "1.22rc1"},
+		{true, []byte("module m\n\nrequire (\n\tgolang.org/x/mod v0.3.0\n)\n\n\tgo   1.16\r\n"), "1.16"},
+		{true, []byte("module m\n\n// go 1.18\n"), ""},
+		{true, []byte("module m\n\ngo 1.x\n"), ""},
+		{true, []byte("module m\n\ngo\n"), ""},
+		{true, []byte("module m\n"), ""},
+		{true, []byte{}, ""},
+		{true, []byte("not a go.mod file ("), ""},
+		{true, nil, ""},
+		{false, []byte("module m\n\ngo 1.18\n"), ""},
+	} {
+		m := &internal.Module{LegacyModuleInfo: internal.LegacyModuleInfo{ModuleInfo: internal.ModuleInfo{HasGoMod: test.hasGoMod}}, GoMod: test.goMod}
+		if got := minGoVersion(ctx, m); got != test.want {
+			t.Errorf("minGoVersion(HasGoMod=%t, %q) = %q, want %q", test.hasGoMod, test.goMod, got, test.want)
+		}
+	}
+}
+
+func TestInsertModuleStdlibOnly(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx := context.Background()
+
+	m := sample.Module(sample.ModulePath, sample.VersionString, "std", "third", "none")
+	for _, p := range m.LegacyPackages {
+		switch p.Path {
+		case sample.ModulePath + "/std":
+			p.Imports = []string{"fmt", "net/http", "vendor/golang.org/x/net/http2/hpack"}
+		case sample.ModulePath + "/third":
+			p.Imports = []string{"fmt", "golang.org/x/mod/semver"}
+		case sample.ModulePath + "/none":
+			p.Imports = nil
+		}
+	}
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{
+		sample.ModulePath + "/std":   true,
+		sample.ModulePath + "/third": false,
+		sample.ModulePath + "/none":  true,
+	} {
+		got, err := testDB.LegacyGetPackage(ctx, path, m.ModulePath, m.Version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.StdlibOnly != want {
+			t.Errorf("%s: StdlibOnly = %t, want %t", path, got.StdlibOnly, want)
+		}
+	}
+}
+
+func TestStdlibOnly(t *testing.T) {
+	for _, test := range []struct {
+		imports []string
+		want    bool
+	}{
+		{nil, true},
+		{[]string{"C", "fmt", "net/http"}, true},
+		{[]string{"fmt", "github.com/a/b"}, false},
+		{[]string{"example.com"}, false},
+	} {
+		if got := stdlibOnly(test.imports); got != test.want {
+			t.Errorf("stdlibOnly(%q) = %t, want %t", test.imports, got, test.want)
+		}
+	}
+}
+
+func TestInsertModuleImportBuildContexts(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx := context.Background()
+
+	m := sample.Module(sample.ModulePath, sample.VersionString, "file")
+	p := m.LegacyPackages[0]
+	p.Imports = []string{"golang.org/x/sys/unix", "golang.org/x/sys/windows", "os"}
+	p.ImportBuildContexts = map[string][]string{
+		"golang.org/x/sys/unix":    {"linux/amd64", "darwin/amd64"},
+		"golang.org/x/sys/windows": {"windows/amd64"},
+	}
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string][]string{}
+	err := testDB.db.RunQuery(ctx, `
+		SELECT to_path, build_contexts
+		FROM imports
+		WHERE from_path = $1 AND from_module_path = $2 AND from_version = $3`,
+		func(rows *sql.Rows) error {
+			var (
+				to       string
+				contexts []string
+			)
+			if err := rows.Scan(&to, pq.Array(&contexts)); err != nil {
+				return err
+			}
+			got[to] = contexts
+			return nil
+		}, p.Path, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"golang.org/x/sys/unix":    {"linux/amd64", "darwin/amd64"},
+		"golang.org/x/sys/windows": {"windows/amd64"},
+		"os":                       nil,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("build contexts mismatch (-want +got):\n%s", diff)
+	}
+
+	importedBy, err := testDB.GetImportedBy(ctx, "golang.org/x/sys/windows", "golang.org/x/sys", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{p.Path}, importedBy); diff != "" {
+		t.Errorf("GetImportedBy mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestDeleteModule(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
 	defer cancel()
@@ -0,0 +1,78 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"sort"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// SetLicenseAllowlist restricts the module versions that are searchable to
+// those whose licenses all have types in allowed, such as "MIT" and
+// "BSD-3-Clause". Other versions are still inserted, but are left out of
+// search_documents: a package is indexed at its latest version that is
+// allowed, if any. A module version without licenses is not allowed. If
+// allowed is empty, every module version is allowed.
+//
+// SetLicenseAllowlist must be called before db is used.
+func (db *DB) SetLicenseAllowlist(allowed []string) {
+	if len(allowed) == 0 {
+		db.licenseAllowlist = nil
+		return
+	}
+	db.licenseAllowlist = map[string]bool{}
+	for _, t := range allowed {
+		db.licenseAllowlist[t] = true
+	}
+}
+
+// LicensesAllowed reports whether the licenses of m are allowed by the
+// license allowlist of db, so that m can be searchable. See
+// SetLicenseAllowlist.
+func (db *DB) LicensesAllowed(m *internal.Module) bool {
+	if db.licenseAllowlist == nil {
+		return true
+	}
+	if len(m.Licenses) == 0 {
+		return false
+	}
+	for _, l := range m.Licenses {
+		if len(l.Types) == 0 {
+			return false
+		}
+		for _, t := range l.Types {
+			if !db.licenseAllowlist[t] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// allowedLicenseTypes returns the sorted license types of the license
+// allowlist of db, or nil if there is no allowlist.
+func (db *DB) allowedLicenseTypes() []string {
+	if db.licenseAllowlist == nil {
+		return nil
+	}
+	var types []string
+	for t := range db.licenseAllowlist {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// deleteSearchDocuments deletes the packages of modulePath at version from
+// search_documents. Rows for other versions of the module are left alone.
+func deleteSearchDocuments(ctx context.Context, db *database.DB, modulePath, version string) (err error) {
+	defer derrors.Wrap(&err, "deleteSearchDocuments(ctx, db, %q, %q)", modulePath, version)
+	_, err = db.Exec(ctx, `DELETE FROM search_documents WHERE module_path = $1 AND version = $2`, modulePath, version)
+	return err
+}
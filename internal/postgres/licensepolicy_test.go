@@ -0,0 +1,123 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/licenses"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestInsertModuleLicenseAllowlist(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	mitModule := func(modulePath, version string) *internal.Module {
+		return sample.Module(modulePath, version, "p")
+	}
+	gplModule := func(modulePath, version string) *internal.Module {
+		m := sample.Module(modulePath, version, "p")
+		m.Licenses = []*licenses.License{{
+			Metadata: &licenses.Metadata{Types: []string{"GPL2"}, FilePath: "LICENSE"},
+			Contents: []byte("GPL"),
+		}}
+		return m
+	}
+	insert := func(m *internal.Module) {
+		t.Helper()
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatalf("InsertModule(%q, %q): %v", m.ModulePath, m.Version, err)
+		}
+	}
+	// checkIndexed checks that the package of modulePath is searchable at
+	// version, or not at all if version is empty.
+	checkIndexed := func(modulePath, version string) {
+		t.Helper()
+		_, got, found := GetFromSearchDocuments(ctx, t, testDB, modulePath+"/p")
+		if got != version {
+			t.Errorf("%s/p: got version %q (found = %t), want %q", modulePath, got, found, version)
+		}
+	}
+
+	// Without an allowlist, every module is searchable.
+	reprocessed := gplModule("example.com/reprocessed", "v1.0.0")
+	insert(reprocessed)
+	checkIndexed(reprocessed.ModulePath, "v1.0.0")
+
+	testDB.SetLicenseAllowlist([]string{"MIT", "BSD-3-Clause"})
+	defer testDB.SetLicenseAllowlist(nil)
+
+	// A module version indexed before the allowlist was set is removed when
+	// it is processed again.
+	insert(reprocessed)
+	checkIndexed(reprocessed.ModulePath, "")
+	if testDB.LicensesAllowed(reprocessed) {
+		t.Error("LicensesAllowed(GPL module) = true, want false")
+	}
+	// It is still inserted.
+	if _, err := testDB.LegacyGetModuleInfo(ctx, reprocessed.ModulePath, reprocessed.Version); err != nil {
+		t.Fatal(err)
+	}
+
+	mit := mitModule("example.com/mit", "v1.0.0")
+	insert(mit)
+	checkIndexed(mit.ModulePath, "v1.0.0")
+
+	// Whatever the order in which the versions are inserted, a package is
+	// searchable at its latest version that is allowed.
+	insert(mitModule("example.com/newer", "v1.0.0"))
+	insert(gplModule("example.com/newer", "v1.1.0"))
+	checkIndexed("example.com/newer", "v1.0.0")
+
+	insert(gplModule("example.com/older", "v1.1.0"))
+	checkIndexed("example.com/older", "")
+	insert(mitModule("example.com/older", "v1.0.0"))
+	checkIndexed("example.com/older", "v1.0.0")
+
+	insert(mitModule("example.com/both", "v1.0.0"))
+	insert(gplModule("example.com/both", "v1.1.0"))
+	insert(mitModule("example.com/both", "v1.2.0"))
+	checkIndexed("example.com/both", "v1.2.0")
+}
+
+func TestLicensesAllowed(t *testing.T) {
+	db := &DB{}
+	db.SetLicenseAllowlist([]string{"MIT", "BSD-3-Clause"})
+	withTypes := func(types ...[]string) *internal.Module {
+		m := sample.DefaultModule()
+		m.Licenses = nil
+		for _, ts := range types {
+			m.Licenses = append(m.Licenses, &licenses.License{Metadata: &licenses.Metadata{Types: ts}})
+		}
+		return m
+	}
+	for _, test := range []struct {
+		name string
+		m    *internal.Module
+		want bool
+	}{
+		{"allowed", withTypes([]string{"MIT"}), true},
+		{"all allowed", withTypes([]string{"MIT"}, []string{"BSD-3-Clause"}), true},
+		{"not allowed", withTypes([]string{"GPL2"}), false},
+		{"one not allowed", withTypes([]string{"MIT"}, []string{"GPL2"}), false},
+		{"no types", withTypes([]string{}), false},
+		{"no licenses", withTypes(), false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := db.LicensesAllowed(test.m); got != test.want {
+				t.Errorf("got %t, want %t", got, test.want)
+			}
+		})
+	}
+
+	db.SetLicenseAllowlist(nil)
+	if !db.LicensesAllowed(withTypes()) {
+		t.Error("without an allowlist: got not allowed, want allowed")
+	}
+}
@@ -28,11 +28,13 @@ func (db *DB) LegacyGetModuleLicenses(ctx context.Context, modulePath, version s
 	}
 	query := `
 	SELECT
-		types, file_path, contents, coverage
+		l.types, l.file_path, COALESCE(c.contents, l.contents) AS contents, l.coverage, l.partial_scan
 	FROM
-		licenses
+		licenses l
+	LEFT JOIN license_contents c
+	ON l.contents_hash = c.hash
 	WHERE
-		module_path = $1 AND version = $2 AND position('/' in file_path) = 0
+		l.module_path = $1 AND l.version = $2 AND position('/' in l.file_path) = 0
     `
 	rows, err := db.db.Query(ctx, query, modulePath, version)
 	if err != nil {
@@ -42,6 +44,65 @@ func (db *DB) LegacyGetModuleLicenses(ctx context.Context, modulePath, version s
 	return collectLicenses(rows)
 }
 
+// GetLicenseContents returns every license file in the given module version,
+// including those in subdirectories, along with its contents.
+// It returns an InvalidArgument error if the module path or version is
+// invalid, and a NotFound error if the module version has no stored license
+// contents.
+func (db *DB) GetLicenseContents(ctx context.Context, modulePath, version string) (_ []*licenses.License, err error) {
+	defer derrors.Wrap(&err, "GetLicenseContents(ctx, %q, %q)", modulePath, version)
+
+	if modulePath == "" || version == "" {
+		return nil, fmt.Errorf("neither modulePath nor version can be empty: %w", derrors.InvalidArgument)
+	}
+	query := `
+		SELECT
+			l.types, l.file_path, COALESCE(c.contents, l.contents) AS contents, l.coverage, l.partial_scan
+		FROM
+			licenses l
+		LEFT JOIN license_contents c
+		ON l.contents_hash = c.hash
+		WHERE
+			l.module_path = $1
+			AND l.version = $2
+			AND COALESCE(c.contents, l.contents) <> ''`
+	rows, err := db.db.Query(ctx, query, modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	lics, err := collectLicenses(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(lics) == 0 {
+		return nil, fmt.Errorf("no license contents for %s@%s: %w", modulePath, version, derrors.NotFound)
+	}
+	return lics, nil
+}
+
+// GetLicenseByHash returns the license contents whose hash is hash, as stored
+// in the license_contents table by InsertModule. Identical license files in
+// different modules share the same hash.
+// It returns a NotFound error if there are no contents with that hash.
+func (db *DB) GetLicenseByHash(ctx context.Context, hash string) (_ []byte, err error) {
+	defer derrors.Wrap(&err, "GetLicenseByHash(ctx, %q)", hash)
+
+	if hash == "" {
+		return nil, fmt.Errorf("empty hash: %w", derrors.InvalidArgument)
+	}
+	var contents []byte
+	err = db.db.QueryRow(ctx, `SELECT contents FROM license_contents WHERE hash = $1`, hash).Scan(&contents)
+	switch err {
+	case sql.ErrNoRows:
+		return nil, fmt.Errorf("no license contents with hash %q: %w", hash, derrors.NotFound)
+	case nil:
+		return contents, nil
+	default:
+		return nil, err
+	}
+}
+
 // LegacyGetPackageLicenses returns all licenses associated with the given package path and
 // version.
 // It returns an InvalidArgument error if the module path or version is invalid.
@@ -55,10 +116,13 @@ func (db *DB) LegacyGetPackageLicenses(ctx context.Context, pkgPath, modulePath,
 		SELECT
 			l.types,
 			l.file_path,
-			l.contents,
-			l.coverage
+			COALESCE(c.contents, l.contents) AS contents,
+			l.coverage,
+			l.partial_scan
 		FROM
 			licenses l
+		LEFT JOIN license_contents c
+		ON l.contents_hash = c.hash
 		INNER JOIN (
 			SELECT DISTINCT ON (license_file_path)
 				module_path,
@@ -84,17 +148,66 @@ func (db *DB) LegacyGetPackageLicenses(ctx context.Context, pkgPath, modulePath,
 	return collectLicenses(rows)
 }
 
+// UnlicensedStatsKey is the key in the result of GetLicenseStats for packages
+// that have no detected licenses.
+const UnlicensedStatsKey = "unlicensed"
+
+// GetLicenseStats returns the number of distinct package paths in the
+// database under each license type. A package with several license types is
+// counted once for each of them. Packages without any detected license types,
+// including those whose license files have no recognized license, are counted
+// under UnlicensedStatsKey.
+//
+// The query scans the entire packages table, so callers should cache the
+// result.
+func (db *DB) GetLicenseStats(ctx context.Context) (_ map[string]int, err error) {
+	defer derrors.Wrap(&err, "GetLicenseStats(ctx)")
+
+	query := `
+		SELECT
+			COALESCE(license_type, $1),
+			COUNT(DISTINCT path)
+		FROM (
+			SELECT
+				path,
+				unnest(CASE
+					WHEN cardinality(array_remove(license_types, '')) > 0
+					THEN array_remove(license_types, '')
+					ELSE ARRAY[NULL]::text[]
+				END) AS license_type
+			FROM packages
+		) p
+		GROUP BY 1;`
+	stats := map[string]int{}
+	err = db.db.RunQuery(ctx, query, func(rows *sql.Rows) error {
+		var (
+			licenseType string
+			count       int
+		)
+		if err := rows.Scan(&licenseType, &count); err != nil {
+			return err
+		}
+		stats[licenseType] = count
+		return nil
+	}, UnlicensedStatsKey)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
 // collectLicenses converts the sql rows to a list of licenses. The columns
-// must be types, file_path and contents, in that order.
+// must be types, file_path, contents, coverage and partial_scan, in that
+// order.
 func collectLicenses(rows *sql.Rows) ([]*licenses.License, error) {
-	mustHaveColumns(rows, "types", "file_path", "contents", "coverage")
+	mustHaveColumns(rows, "types", "file_path", "contents", "coverage", "partial_scan")
 	var lics []*licenses.License
 	for rows.Next() {
 		var (
 			lic          = &licenses.License{Metadata: &licenses.Metadata{}}
 			licenseTypes []string
 		)
-		if err := rows.Scan(pq.Array(&licenseTypes), &lic.FilePath, &lic.Contents, jsonbScanner{&lic.Coverage}); err != nil {
+		if err := rows.Scan(pq.Array(&licenseTypes), &lic.FilePath, &lic.Contents, jsonbScanner{&lic.Coverage}, &lic.PartialScan); err != nil {
 			return nil, fmt.Errorf("row.Scan(): %v", err)
 		}
 		lic.Types = licenseTypes
@@ -6,9 +6,11 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/licenses"
 	"golang.org/x/pkgsite/internal/testing/sample"
 )
@@ -16,7 +18,8 @@ import (
 func TestLegacyGetModuleLicenses(t *testing.T) {
 	modulePath := "test.module"
 	testModule := sample.Module(modulePath, "v1.2.3", "", "foo", "bar")
-	testModule.LegacyPackages[0].Licenses = []*licenses.Metadata{{Types: []string{"ISC"}, FilePath: "LICENSE"}}
+	// PartialScan is stored along with the rest of the license metadata.
+	testModule.LegacyPackages[0].Licenses = []*licenses.Metadata{{Types: []string{"ISC"}, FilePath: "LICENSE", PartialScan: true}}
 	testModule.LegacyPackages[1].Licenses = []*licenses.Metadata{{Types: []string{"MIT"}, FilePath: "foo/LICENSE"}}
 	testModule.LegacyPackages[2].Licenses = []*licenses.Metadata{{Types: []string{"GPL2"}, FilePath: "bar/LICENSE.txt"}}
 
@@ -47,6 +50,106 @@ func TestLegacyGetModuleLicenses(t *testing.T) {
 	}
 }
 
+func TestGetLicenseContents(t *testing.T) {
+	modulePath := "test.module"
+	testModule := sample.Module(modulePath, "v1.2.3", "", "foo")
+	testModule.LegacyPackages[0].Licenses = []*licenses.Metadata{{Types: []string{"ISC"}, FilePath: "LICENSE"}}
+	testModule.LegacyPackages[1].Licenses = []*licenses.Metadata{{Types: []string{"MIT"}, FilePath: "foo/LICENSE"}}
+
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	testModule.Licenses = nil
+	for _, p := range testModule.LegacyPackages {
+		testModule.Licenses = append(testModule.Licenses, &licenses.License{
+			Metadata: p.Licenses[0],
+			Contents: []byte(`Lorem Ipsum`),
+		})
+	}
+	if err := testDB.InsertModule(ctx, testModule); err != nil {
+		t.Fatal(err)
+	}
+	noLicenses := sample.Module("no.licenses", "v1.0.0", "p")
+	noLicenses.Licenses = nil
+	noLicenses.LegacyPackages[0].Licenses = nil
+	if err := testDB.InsertModule(ctx, noLicenses); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := testDB.GetLicenseContents(ctx, modulePath, testModule.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// We want the licenses in subdirectories too.
+	want := []*licenses.License{testModule.Licenses[1], testModule.Licenses[0]}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("testDB.GetLicenseContents(ctx, %q, %q) mismatch (-want +got):\n%s", modulePath, testModule.Version, diff)
+	}
+
+	for _, test := range []struct {
+		modulePath, version string
+		wantErr             error
+	}{
+		{"no.licenses", "v1.0.0", derrors.NotFound},
+		{modulePath, "v9.9.9", derrors.NotFound},
+		{modulePath, "", derrors.InvalidArgument},
+	} {
+		if _, err := testDB.GetLicenseContents(ctx, test.modulePath, test.version); !errors.Is(err, test.wantErr) {
+			t.Errorf("testDB.GetLicenseContents(ctx, %q, %q): got error %v, want %v", test.modulePath, test.version, err, test.wantErr)
+		}
+	}
+}
+
+func TestLicenseContentsDeduplication(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	const contents = "Permission is hereby granted, free of charge, ..."
+	for _, modulePath := range []string{"a.com/m", "b.com/m"} {
+		m := sample.Module(modulePath, sample.VersionString, "p")
+		m.Licenses = []*licenses.License{{
+			Metadata: &licenses.Metadata{Types: []string{"MIT"}, FilePath: "LICENSE"},
+			Contents: []byte(contents),
+		}}
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var n int
+	if err := testDB.db.QueryRow(ctx, `SELECT COUNT(*) FROM license_contents`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("got %d rows in license_contents, want 1", n)
+	}
+
+	got, err := testDB.GetLicenseByHash(ctx, licenseContentsHash(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != contents {
+		t.Errorf("GetLicenseByHash: got %q, want %q", got, contents)
+	}
+	// Both modules still see the contents.
+	for _, modulePath := range []string{"a.com/m", "b.com/m"} {
+		lics, err := testDB.GetLicenseContents(ctx, modulePath, sample.VersionString)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(lics) != 1 || string(lics[0].Contents) != contents {
+			t.Errorf("GetLicenseContents(ctx, %q, %q) = %v, want one license with contents %q",
+				modulePath, sample.VersionString, lics, contents)
+		}
+	}
+
+	if _, err := testDB.GetLicenseByHash(ctx, licenseContentsHash("other")); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("GetLicenseByHash: got error %v, want %v", err, derrors.NotFound)
+	}
+}
+
 func TestLegacyGetPackageLicenses(t *testing.T) {
 	modulePath := "test.module"
 	testModule := sample.Module(modulePath, "v1.2.3", "", "foo")
@@ -88,3 +191,38 @@ func TestLegacyGetPackageLicenses(t *testing.T) {
 		})
 	}
 }
+
+func TestGetLicenseStats(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, version := range []string{"v1.0.0", "v1.1.0"} {
+		m := sample.Module("test.module", version, "", "foo", "bar", "baz")
+		m.Licenses = []*licenses.License{
+			{Metadata: &licenses.Metadata{Types: []string{"MIT"}, FilePath: "foo/LICENSE"}, Contents: []byte("mit")},
+			{Metadata: &licenses.Metadata{Types: []string{"Apache-2.0"}, FilePath: "bar/LICENSE"}, Contents: []byte("apache")},
+			{Metadata: &licenses.Metadata{FilePath: "baz/LICENSE"}, Contents: []byte("unknown")},
+		}
+		m.LegacyPackages[0].Licenses = nil
+		m.LegacyPackages[1].Licenses = []*licenses.Metadata{m.Licenses[0].Metadata}
+		m.LegacyPackages[2].Licenses = []*licenses.Metadata{m.Licenses[0].Metadata, m.Licenses[1].Metadata}
+		m.LegacyPackages[3].Licenses = []*licenses.Metadata{m.Licenses[2].Metadata}
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := testDB.GetLicenseStats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{
+		"MIT":              2,
+		"Apache-2.0":       1,
+		UnlicensedStatsKey: 2,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GetLicenseStats mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// GetModuleFiles returns the paths of the files in the module version,
+// relative to the module root, in sorted order. It returns an error wrapping
+// derrors.NotFound if the module version is not in the database, or was
+// inserted before file paths were recorded.
+func (db *DB) GetModuleFiles(ctx context.Context, modulePath, version string) (_ []string, err error) {
+	defer derrors.Wrap(&err, "GetModuleFiles(ctx, %q, %q)", modulePath, version)
+
+	query := `
+		SELECT f.paths
+		FROM module_files f
+		INNER JOIN modules m
+		ON f.module_id = m.id
+		WHERE m.module_path = $1 AND m.version = $2`
+	var files []string
+	if err := db.db.QueryRow(ctx, query, modulePath, version).Scan(pq.Array(&files)); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("files of module version %s@%s: %w", modulePath, version, derrors.NotFound)
+		}
+		return nil, err
+	}
+	return files, nil
+}
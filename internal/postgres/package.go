@@ -47,6 +47,18 @@ func (db *DB) LegacyGetPackage(ctx context.Context, pkgPath, modulePath, version
 		return nil, fmt.Errorf("none of pkgPath, modulePath, or version can be empty: %w", derrors.InvalidArgument)
 	}
 
+	key := cacheKey{"LegacyGetPackage", pkgPath, modulePath, version}
+	v, err := db.cachedRead(ctx, key, new(*internal.LegacyVersionedPackage), func() (interface{}, error) {
+		return db.legacyGetPackage(ctx, pkgPath, modulePath, version)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*internal.LegacyVersionedPackage), nil
+}
+
+// legacyGetPackage implements LegacyGetPackage, without caching.
+func (db *DB) legacyGetPackage(ctx context.Context, pkgPath, modulePath, version string) (_ *internal.LegacyVersionedPackage, err error) {
 	args := []interface{}{pkgPath}
 	query := `
 		SELECT
@@ -58,8 +70,10 @@ func (db *DB) LegacyGetPackage(ctx context.Context, pkgPath, modulePath, version
 			p.license_paths,
 			p.redistributable,
 			p.documentation,
+			p.documentation_ref,
 			p.goos,
 			p.goarch,
+			p.stdlib_only,
 			m.version,
 			m.commit_time,
 			m.readme_file_path,
@@ -68,7 +82,9 @@ func (db *DB) LegacyGetPackage(ctx context.Context, pkgPath, modulePath, version
 			m.version_type,
 		    m.source_info,
 			m.redistributable,
-			m.has_go_mod
+			m.has_go_mod,
+			m.min_go_version,
+			m.license_expression
 		FROM
 			modules m
 		INNER JOIN
@@ -135,14 +151,15 @@ func (db *DB) LegacyGetPackage(ctx context.Context, pkgPath, modulePath, version
 		pkg                        internal.LegacyVersionedPackage
 		licenseTypes, licensePaths []string
 		hasGoMod                   sql.NullBool
+		docRef                     sql.NullString
 	)
 	row := db.db.QueryRow(ctx, query, args...)
 	err = row.Scan(&pkg.Path, &pkg.Name, &pkg.Synopsis,
 		&pkg.V1Path, pq.Array(&licenseTypes), pq.Array(&licensePaths), &pkg.LegacyPackage.IsRedistributable,
-		database.NullIsEmpty(&pkg.DocumentationHTML), &pkg.GOOS, &pkg.GOARCH, &pkg.Version,
+		database.NullIsEmpty(&pkg.DocumentationHTML), &docRef, &pkg.GOOS, &pkg.GOARCH, &pkg.StdlibOnly, &pkg.Version,
 		&pkg.CommitTime, database.NullIsEmpty(&pkg.LegacyReadmeFilePath), database.NullIsEmpty(&pkg.LegacyReadmeContents),
 		&pkg.ModulePath, &pkg.VersionType, jsonbScanner{&pkg.SourceInfo}, &pkg.LegacyModuleInfo.IsRedistributable,
-		&hasGoMod)
+		&hasGoMod, &pkg.MinGoVersion, &pkg.LicenseExpression)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("package %s@%s: %w", pkgPath, version, derrors.NotFound)
@@ -150,6 +167,10 @@ func (db *DB) LegacyGetPackage(ctx context.Context, pkgPath, modulePath, version
 		return nil, fmt.Errorf("row.Scan(): %v", err)
 	}
 	setHasGoMod(&pkg.ModuleInfo, hasGoMod)
+	pkg.DocumentationHTML, err = db.getDocumentation(ctx, pkg.DocumentationHTML, docRef)
+	if err != nil {
+		return nil, err
+	}
 	lics, err := zipLicenseMetadata(licenseTypes, licensePaths)
 	if err != nil {
 		return nil, err
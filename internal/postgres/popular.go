@@ -0,0 +1,84 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// GetPopularPackages returns up to limit packages, starting at offset, ordered
+// by the number of packages that import them.
+//
+// Imported-by counts are computed from imports_unique, counting only importers
+// that are in search_documents and that are not in the same module as the
+// imported package. Since rows for deleted modules are removed from both
+// tables (see DeleteModule), they are never counted.
+func (db *DB) GetPopularPackages(ctx context.Context, limit, offset int) (_ []*internal.SearchResult, err error) {
+	defer derrors.Wrap(&err, "GetPopularPackages(ctx, %d, %d)", limit, offset)
+
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive: %w", derrors.InvalidArgument)
+	}
+	if offset < 0 {
+		return nil, fmt.Errorf("offset cannot be negative: %w", derrors.InvalidArgument)
+	}
+	query := `
+		SELECT
+			sd.package_path,
+			sd.module_path,
+			sd.version,
+			sd.commit_time,
+			sd.name,
+			sd.synopsis,
+			sd.license_types,
+			c.num_imported_by
+		FROM (
+			SELECT
+				i.to_path,
+				COUNT(DISTINCT i.from_path) AS num_imported_by
+			FROM
+				imports_unique i
+			INNER JOIN
+				search_documents f
+			ON
+				f.package_path = i.from_path
+			WHERE
+				i.to_path <> i.from_module_path
+				AND left(i.to_path, length(i.from_module_path) + 1) <> i.from_module_path || '/'
+			GROUP BY
+				i.to_path
+		) c
+		INNER JOIN
+			search_documents sd
+		ON
+			sd.package_path = c.to_path
+		ORDER BY
+			c.num_imported_by DESC,
+			sd.package_path
+		LIMIT $1
+		OFFSET $2;`
+
+	var results []*internal.SearchResult
+	collect := func(rows *sql.Rows) error {
+		var r internal.SearchResult
+		if err := rows.Scan(&r.PackagePath, &r.ModulePath, &r.Version, &r.CommitTime,
+			&r.Name, database.NullIsEmpty(&r.Synopsis), pq.Array(&r.Licenses), &r.NumImportedBy); err != nil {
+			return fmt.Errorf("rows.Scan(): %v", err)
+		}
+		results = append(results, &r)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, limit, offset); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetPopularPackages(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	var (
+		m1 = sample.Module("path.to/foo", "v1.1.0", "bar")
+		m2 = sample.Module("path2.to/foo", "v1.2.0", "bar2", "baz2")
+		m3 = sample.Module("path3.to/foo", "v1.3.0", "bar3")
+
+		pkg1  = m1.LegacyPackages[0]
+		pkg2  = m2.LegacyPackages[0]
+		pkg2b = m2.LegacyPackages[1]
+		pkg3  = m3.LegacyPackages[0]
+	)
+	pkg1.Imports = nil
+	pkg2.Imports = []string{pkg1.Path}
+	// Imports from the same module are not counted.
+	pkg2b.Imports = []string{pkg2.Path}
+	pkg3.Imports = []string{pkg2.Path, pkg1.Path}
+	for _, m := range []*internal.Module{m1, m2, m3} {
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type popular struct {
+		path          string
+		numImportedBy uint64
+	}
+	check := func(limit, offset int, want []popular) {
+		t.Helper()
+		results, err := testDB.GetPopularPackages(ctx, limit, offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []popular
+		for _, r := range results {
+			got = append(got, popular{r.PackagePath, r.NumImportedBy})
+		}
+		if diff := cmp.Diff(want, got, cmp.AllowUnexported(popular{})); diff != "" {
+			t.Errorf("GetPopularPackages(ctx, %d, %d) mismatch (-want +got):\n%s", limit, offset, diff)
+		}
+	}
+
+	check(10, 0, []popular{{pkg1.Path, 2}, {pkg2.Path, 1}})
+	check(1, 0, []popular{{pkg1.Path, 2}})
+	check(1, 1, []popular{{pkg2.Path, 1}})
+
+	// Deleting the only importer of pkg2 removes it from the results.
+	if err := testDB.DeleteModule(ctx, m3.ModulePath, m3.Version); err != nil {
+		t.Fatal(err)
+	}
+	check(10, 0, []popular{{pkg1.Path, 1}})
+}
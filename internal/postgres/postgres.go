@@ -5,16 +5,73 @@
 package postgres
 
 import (
+	"context"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/blob"
+	"golang.org/x/pkgsite/internal/cache"
 	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/sync/singleflight"
 )
 
 type DB struct {
 	db *database.DB
+	// cache holds results in process memory, and sharedCache holds them for
+	// several servers. Either is nil if reads are not cached there.
+	cache       *cache.Memory
+	sharedCache cache.Cache
+	// cacheTTL is how long results are cached.
+	cacheTTL time.Duration
+
+	// flight coalesces concurrent identical reads. See cachedRead.
+	flight singleflight.Group
+
+	// docStore, if non-nil, holds the documentation of packages whose
+	// documentation is at least docStoreMinSize bytes long. See
+	// SetDocumentationStore.
+	docStore        blob.Store
+	docStoreMinSize int
+
+	// insertHook, if non-nil, is called after each module version is
+	// inserted. See SetInsertHook.
+	insertHook func(context.Context, *internal.ModuleInfo)
+
+	// licenseAllowlist, if non-nil, is the set of license types of the
+	// modules that are made searchable. See SetLicenseAllowlist.
+	licenseAllowlist map[string]bool
 }
 
 // New returns a new postgres DB.
 func New(db *database.DB) *DB {
-	return &DB{db}
+	return &DB{db: db}
+}
+
+// ReadCacheOptions configures the caching of database reads by
+// NewWithReadCache.
+type ReadCacheOptions struct {
+	// Size is the number of results to cache in memory. If zero, results are
+	// not cached in memory.
+	Size int
+	// Shared, if non-nil, is a cache shared with other servers, such as a
+	// cache.Redis. It is consulted after the in-memory cache. Results are
+	// stored in it encoded with encoding/gob.
+	Shared cache.Cache
+	// TTL is how long each result is cached.
+	TTL time.Duration
+}
+
+// NewWithReadCache returns a new postgres DB that caches the results of
+// LegacyGetPackage, LegacyGetModuleInfo and GetDirectoryNew for specific
+// module versions, as configured by opts. Results for a module version are
+// removed from the caches when it is inserted with InsertModule or deleted
+// with DeleteModule.
+func NewWithReadCache(db *database.DB, opts ReadCacheOptions) *DB {
+	pdb := &DB{db: db, sharedCache: opts.Shared, cacheTTL: opts.TTL}
+	if opts.Size > 0 {
+		pdb.cache = cache.NewMemory(opts.Size)
+	}
+	return pdb
 }
 
 // Close closes a DB.
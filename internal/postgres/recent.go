@@ -0,0 +1,70 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// GetRecentModules returns up to limit module versions, starting at offset,
+// ordered by descending commit time. Modules that are not redistributable,
+// and modules that have been marked as having an alternative module path, are
+// excluded.
+//
+// The query is served by the idx_modules_commit_time index.
+func (db *DB) GetRecentModules(ctx context.Context, limit, offset int) (_ []*internal.ModuleInfo, err error) {
+	defer derrors.Wrap(&err, "GetRecentModules(ctx, %d, %d)", limit, offset)
+	if limit <= 0 || offset < 0 {
+		return nil, fmt.Errorf("limit must be positive and offset non-negative: %w", derrors.InvalidArgument)
+	}
+
+	query := `
+		SELECT
+			m.module_path,
+			m.version,
+			m.commit_time,
+			m.version_type,
+			m.source_info,
+			m.redistributable,
+			m.has_go_mod
+		FROM modules m
+		WHERE
+			m.redistributable
+			AND NOT EXISTS (
+				SELECT 1
+				FROM module_version_states s
+				WHERE
+					s.module_path = m.module_path
+					AND s.status = $1
+			)
+		ORDER BY m.commit_time DESC, m.module_path, m.sort_version DESC
+		LIMIT $2
+		OFFSET $3`
+
+	var mis []*internal.ModuleInfo
+	collect := func(rows *sql.Rows) error {
+		var (
+			mi       internal.ModuleInfo
+			hasGoMod sql.NullBool
+		)
+		if err := rows.Scan(&mi.ModulePath, &mi.Version, &mi.CommitTime, &mi.VersionType,
+			jsonbScanner{&mi.SourceInfo}, &mi.IsRedistributable, &hasGoMod); err != nil {
+			return fmt.Errorf("row.Scan(): %v", err)
+		}
+		setHasGoMod(&mi, hasGoMod)
+		mis = append(mis, &mi)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect,
+		derrors.ToHTTPStatus(derrors.AlternativeModule), limit, offset); err != nil {
+		return nil, err
+	}
+	return mis, nil
+}
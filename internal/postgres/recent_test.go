@@ -0,0 +1,74 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetRecentModules(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	newModule := func(modulePath string, age time.Duration, redistributable bool) *internal.Module {
+		m := sample.Module(modulePath, sample.VersionString, "p")
+		m.CommitTime = sample.CommitTime.Add(-age)
+		if !redistributable {
+			m.IsRedistributable = false
+			m.Licenses = nil
+			for _, p := range m.LegacyPackages {
+				p.IsRedistributable = false
+				p.Licenses = nil
+			}
+		}
+		return m
+	}
+	for _, m := range []*internal.Module{
+		newModule("a.com/newest", 0, true),
+		newModule("b.com/middle", time.Hour, true),
+		newModule("c.com/oldest", 2*time.Hour, true),
+		newModule("d.com/unlicensed", 0, false),
+		newModule("e.com/Alternative", 0, true),
+	} {
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A later version of e.com/Alternative has an alternative module path.
+	if err := testDB.UpsertModuleVersionState(ctx, "e.com/Alternative", "v1.2.0", "", time.Now(),
+		derrors.ToHTTPStatus(derrors.AlternativeModule), "e.com/alternative", derrors.AlternativeModule, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		limit, offset int
+		want          []string
+	}{
+		{10, 0, []string{"a.com/newest", "b.com/middle", "c.com/oldest"}},
+		{2, 0, []string{"a.com/newest", "b.com/middle"}},
+		{2, 2, []string{"c.com/oldest"}},
+		{2, 4, nil},
+	} {
+		mis, err := testDB.GetRecentModules(ctx, test.limit, test.offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, mi := range mis {
+			got = append(got, mi.ModulePath)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("GetRecentModules(ctx, %d, %d) mismatch (-want +got):\n%s", test.limit, test.offset, diff)
+		}
+	}
+}
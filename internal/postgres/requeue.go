@@ -24,6 +24,7 @@ func (db *DB) UpdateModuleVersionStatesForReprocessing(ctx context.Context, appV
 	for _, status := range []int{
 		http.StatusOK,
 		derrors.ToHTTPStatus(derrors.HasIncompletePackages),
+		derrors.ToHTTPStatus(derrors.LicenseNotAllowed),
 		derrors.ToHTTPStatus(derrors.BadModule),
 		derrors.ToHTTPStatus(derrors.AlternativeModule),
 	} {
@@ -152,15 +153,16 @@ const nextModulesToProcessQuery = `
 				CASE
 					WHEN latest THEN	-- latest version
 						CASE
-							-- with ReprocessStatusOK or ReprocessHasIncompletePackages
-							WHEN status = 520 OR status = 521 THEN 1
+							-- with ReprocessStatusOK, ReprocessHasIncompletePackages
+							-- or ReprocessLicenseNotAllowed
+							WHEN status >= 520 AND status <= 522 THEN 1
 							-- with ReprocessBadModule or ReprocessAlternative
 							WHEN status = 540 OR status = 541 THEN 2
 							ELSE 9
 						END
 					ELSE				-- non-latest version
 						CASE
-							WHEN status = 520 OR status = 521 THEN 3
+							WHEN status >= 520 AND status <= 522 THEN 3
 							WHEN status = 540 OR status = 541 THEN 4
 							ELSE 9
 						END
@@ -169,13 +171,13 @@ const nextModulesToProcessQuery = `
 				CASE
 					WHEN latest THEN	-- latest version
 						CASE
-							WHEN status = 520 OR status = 521 THEN 5
+							WHEN status >= 520 AND status <= 522 THEN 5
 							WHEN status = 540 OR status = 541 THEN 6
 							ELSE 9
 						END
 					ELSE				-- non-latest version
 						CASE
-							WHEN status = 520 OR status = 521 THEN 7
+							WHEN status >= 520 AND status <= 522 THEN 7
 							WHEN status = 540 OR status = 541 THEN 8
 							ELSE 9
 						END
@@ -39,6 +39,7 @@ func TestGetNextModulesToFetchAndUpdateModuleVersionStatesForReprocessing(t *tes
 		statuses  = []int{
 			http.StatusOK,
 			derrors.ToHTTPStatus(derrors.HasIncompletePackages),
+			derrors.ToHTTPStatus(derrors.LicenseNotAllowed),
 			derrors.ToHTTPStatus(derrors.AlternativeModule),
 			derrors.ToHTTPStatus(derrors.BadModule),
 			http.StatusInternalServerError,
@@ -149,8 +150,9 @@ func TestGetNextModulesToFetchAndUpdateModuleVersionStatesForReprocessing(t *tes
 	}
 
 	// The first modules to requeue should be the latest version of not-large modules with errors
-	// ReprocessStatusOK ReprocessHasIncompletePackages, ReprocessAlternative, and ReprocessBadModule.
-	statuses = []int{200, 290, 490, 491}
+	// ReprocessStatusOK ReprocessHasIncompletePackages, ReprocessLicenseNotAllowed, ReprocessAlternative,
+	// and ReprocessBadModule.
+	statuses = []int{200, 290, 291, 490, 491}
 	want = generateMods([]string{latest}, []int{small}, statuses)
 
 	// The next modules to requeue should be the small non-latest versions.
@@ -0,0 +1,49 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// SchemaVersion is the version of the database schema that this code expects:
+// the number of the latest migration in the migrations directory. It must be
+// updated whenever a migration is added.
+const SchemaVersion = 43
+
+// CheckSchemaVersion returns an error if the version of the database schema,
+// as recorded by the migrate tool in the schema_migrations table, is older
+// than expected, or if the last migration did not complete. Servers should
+// call it at startup, so that they do not run against a schema that lacks
+// migrations their code depends on. A newer schema is accepted, because
+// migrations are applied before a release is rolled out, and servers built
+// from the previous release keep running against it during the rollout.
+func (db *DB) CheckSchemaVersion(ctx context.Context, expected int) (err error) {
+	defer derrors.Wrap(&err, "CheckSchemaVersion(ctx, %d)", expected)
+
+	var (
+		version int
+		dirty   bool
+	)
+	err = db.db.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("no migrations have been applied")
+	}
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("migration %d did not complete; the schema is dirty", version)
+	}
+	if version < expected {
+		return fmt.Errorf("schema version is %d, want at least %d; apply the missing migrations", version, expected)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/testing/testhelper"
+)
+
+func TestSchemaVersionMatchesMigrations(t *testing.T) {
+	files, err := ioutil.ReadDir(testhelper.TestDataPath("../../migrations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := 0
+	for _, f := range files {
+		i := strings.IndexByte(f.Name(), '_')
+		if i < 0 {
+			continue
+		}
+		n, err := strconv.Atoi(f.Name()[:i])
+		if err != nil {
+			continue
+		}
+		if n > latest {
+			latest = n
+		}
+	}
+	if latest != SchemaVersion {
+		t.Errorf("latest migration is %d, but SchemaVersion is %d", latest, SchemaVersion)
+	}
+}
+
+func TestCheckSchemaVersion(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	// A schema at or newer than the expected version is accepted.
+	for _, expected := range []int{SchemaVersion, SchemaVersion - 1} {
+		if err := testDB.CheckSchemaVersion(ctx, expected); err != nil {
+			t.Errorf("CheckSchemaVersion(ctx, %d): %v", expected, err)
+		}
+	}
+	if err := testDB.CheckSchemaVersion(ctx, SchemaVersion+1); err == nil {
+		t.Errorf("CheckSchemaVersion(ctx, %d): got nil, want error", SchemaVersion+1)
+	}
+}
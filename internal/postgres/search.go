@@ -7,6 +7,8 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"sort"
@@ -105,10 +107,10 @@ var searchers = map[string]searcher{
 // complete.
 //
 // Because 0 <= ts_rank() <= 1, we know that the highest score of any unscanned
-// package is ln(e+N), where N is imported_by_count of the package we are
-// currently considering.  Therefore if the lowest scoring result of popular
-// search is greater than ln(e+N), we know that we haven't missed any results
-// and can return the search result immediately, cancelling other searches.
+// package is R, the ranking_score of the package we are currently considering.
+// Therefore if the lowest scoring result of popular search is greater than R,
+// we know that we haven't missed any results and can return the search result
+// immediately, cancelling other searches.
 //
 // On the other hand, if the popular search is slow, it is likely that the
 // search term is infrequent, and deep search will be fast due to our inverted
@@ -123,18 +125,136 @@ func (db *DB) Search(ctx context.Context, q string, limit, offset int) (_ []*int
 	if err != nil {
 		return nil, err
 	}
-	// Filter out excluded paths.
-	var results []*internal.SearchResult
-	for _, r := range resp.results {
+	return db.removeExcluded(ctx, resp.results)
+}
+
+// SearchFilter restricts the results of SearchFiltered. The zero value
+// restricts nothing.
+type SearchFilter struct {
+	// Kind, if non-empty, is the only kind of package returned.
+	Kind internal.PackageKind
+	// StdlibOnly restricts the results to packages that import only standard
+	// library packages.
+	StdlibOnly bool
+	// Signature, if non-empty, restricts the results to packages with an
+	// exported function or method of this signature, in the normal form of
+	// fetch.NormalizeSignature.
+	Signature string
+}
+
+// SearchPackageKind is like Search, but only returns packages of the given
+// kind. Since the popular search cannot filter by kind, it always performs a
+// deep search.
+func (db *DB) SearchPackageKind(ctx context.Context, q string, kind internal.PackageKind, limit, offset int) (_ []*internal.SearchResult, err error) {
+	defer derrors.Wrap(&err, "DB.SearchPackageKind(ctx, %q, %q, %d, %d)", q, kind, limit, offset)
+	if kind != internal.PackageKindCommand && kind != internal.PackageKindLibrary {
+		return nil, fmt.Errorf("unknown package kind %q: %w", kind, derrors.InvalidArgument)
+	}
+	return db.SearchFiltered(ctx, q, SearchFilter{Kind: kind}, limit, offset)
+}
+
+// SearchFiltered is like Search, but only returns packages that pass filter.
+// Since the popular search cannot filter, it always performs a deep search.
+// If filter has a Signature, q may be empty, to return all packages with that
+// signature, ordered by their ranking score.
+func (db *DB) SearchFiltered(ctx context.Context, q string, filter SearchFilter, limit, offset int) (_ []*internal.SearchResult, err error) {
+	defer derrors.Wrap(&err, "DB.SearchFiltered(ctx, %q, %+v, %d, %d)", q, filter, limit, offset)
+	if err := checkSearchFilter(q, filter); err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`
+		SELECT *, COUNT(*) OVER() AS total
+		FROM (
+			SELECT
+				package_path,
+				version,
+				module_path,
+				commit_time,
+				imported_by_count,
+				%s AS score
+				FROM
+					search_documents
+				WHERE %s
+				ORDER BY
+					score DESC,
+					commit_time DESC,
+					package_path
+		) r
+		WHERE r.score > 0.1
+		LIMIT $6
+		OFFSET $7`, filteredScoreExpr, filteredSearchCond)
+	args := append(filteredSearchArgs(q, filter), limit, offset)
+	results, err := db.runDeepSearch(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.addPackageDataToSearchResults(ctx, results); err != nil {
+		return nil, err
+	}
+	return db.removeExcluded(ctx, results)
+}
+
+// checkSearchFilter returns an error wrapping derrors.InvalidArgument if q and
+// filter cannot be searched for.
+func checkSearchFilter(q string, filter SearchFilter) error {
+	switch filter.Kind {
+	case "", internal.PackageKindCommand, internal.PackageKindLibrary:
+	default:
+		return fmt.Errorf("unknown package kind %q: %w", filter.Kind, derrors.InvalidArgument)
+	}
+	if q == "" && filter.Signature == "" {
+		return fmt.Errorf("empty query without a signature: %w", derrors.InvalidArgument)
+	}
+	return nil
+}
+
+// filteredSearchCond is the condition on search_documents that SearchFiltered
+// and CountSearchResults select the matches of a query and a filter with.
+// Its parameters are filteredSearchArgs.
+var filteredSearchCond = `($1 = '' OR tsv_search_tokens @@ websearch_to_tsquery($1))
+				AND ($2 = '' OR kind = $2)
+				AND (stdlib_only OR NOT $3)
+				AND ($4 = '' OR signatures @> ARRAY[$4])
+				AND ` + internalCond("$5")
+
+// filteredScoreExpr is the score of a search document for SearchFiltered and
+// CountSearchResults. A search with only a signature has no text to rank the
+// documents by, so they are ranked by their ranking_score.
+var filteredScoreExpr = fmt.Sprintf(`(CASE WHEN $1 = '' THEN ranking_score ELSE %s END)`, scoreExpr)
+
+// filteredSearchArgs returns the arguments for the parameters of
+// filteredSearchCond and filteredScoreExpr.
+func filteredSearchArgs(q string, filter SearchFilter) []interface{} {
+	return []interface{}{q, string(filter.Kind), filter.StdlibOnly, filter.Signature, includeInternal(q)}
+}
+
+// includeInternal reports whether the results of a search for q include
+// internal packages. Internal packages cannot be imported from outside their
+// module, so they are only included if q itself mentions "internal".
+func includeInternal(q string) bool {
+	return strings.Contains(strings.ToLower(q), "internal")
+}
+
+// internalCond returns a condition on the package_path column of
+// search_documents that is true if the package is not internal, or if the
+// boolean query parameter param, like "$4", is true. See includeInternal.
+func internalCond(param string) string {
+	return fmt.Sprintf(`(%s OR package_path !~ '(^|/)internal(/|$)')`, param)
+}
+
+// removeExcluded returns the results whose package paths are not excluded.
+func (db *DB) removeExcluded(ctx context.Context, results []*internal.SearchResult) ([]*internal.SearchResult, error) {
+	var rs []*internal.SearchResult
+	for _, r := range results {
 		ex, err := db.IsExcluded(ctx, r.PackagePath)
 		if err != nil {
 			return nil, err
 		}
 		if !ex {
-			results = append(results, r)
+			rs = append(rs, r)
 		}
 	}
-	return results, nil
+	return rs, nil
 }
 
 // Penalties to search scores, applied as multipliers to the score.
@@ -145,26 +265,48 @@ const (
 	// Start this off gently (close to 1), but consider lowering
 	// it as time goes by and more of the ecosystem converts to modules.
 	noGoModPenalty = 0.8
+	// Module version has major version zero, so the module makes no stability
+	// guarantees.
+	unstablePenalty = 0.9
 )
 
 // scoreExpr is the expression that computes the search score.
 // It is the product of:
 // - The Postgres ts_rank score, based the relevance of the document to the query.
+// - The precomputed ranking_score of the document; see rankingScoreExpr.
+// The first argument to ts_rank is an array of weights for the four tsvector sections,
+// in the order D, C, B, A.
+// The weights below match the defaults except for B.
+const scoreExpr = `
+		ts_rank('{0.1, 0.2, 1.0, 1.0}', tsv_search_tokens, websearch_to_tsquery($1)) *
+		ranking_score
+	`
+
+// rankingScoreExpr is the expression that computes the ranking_score column of
+// search_documents: the part of the search score that does not depend on the
+// query. It is the product of:
 // - The log of the module's popularity, estimated by the number of importing packages.
 //   The log factor contains exp(1) so that it is always >= 1. Taking the log
 //   of imported_by_count instead of using it directly makes the effect less
 //   dramatic: being 2x as popular only has an additive effect.
 // - A penalty factor for non-redistributable modules, since a lot of
 //   details cannot be displayed.
-// The first argument to ts_rank is an array of weights for the four tsvector sections,
-// in the order D, C, B, A.
-// The weights below match the defaults except for B.
-var scoreExpr = fmt.Sprintf(`
-		ts_rank('{0.1, 0.2, 1.0, 1.0}', tsv_search_tokens, websearch_to_tsquery($1)) *
-		ln(exp(1)+imported_by_count) *
-		CASE WHEN redistributable THEN 1 ELSE %f END *
-		CASE WHEN COALESCE(has_go_mod, true) THEN 1 ELSE %f END
-	`, nonRedistributablePenalty, noGoModPenalty)
+// - A penalty factor for modules without a go.mod file.
+// - A penalty factor for v0 versions.
+//
+// The column names are formatted with the %[1]s through %[4]s verbs, so that
+// the expression can refer to the columns of different rows.
+var rankingScoreExpr = fmt.Sprintf(`
+		ln(exp(1)+%%[1]s) *
+		CASE WHEN %%[2]s THEN 1 ELSE %f END *
+		CASE WHEN COALESCE(%%[3]s, true) THEN 1 ELSE %f END *
+		CASE WHEN left(%%[4]s, 3) = 'v0.' THEN %f ELSE 1 END
+	`, nonRedistributablePenalty, noGoModPenalty, unstablePenalty)
+
+// rankingScore returns rankingScoreExpr for the given columns.
+func rankingScore(importedByCount, redistributable, hasGoMod, version string) string {
+	return fmt.Sprintf(rankingScoreExpr, importedByCount, redistributable, hasGoMod, version)
+}
 
 // hedgedSearch executes multiple search methods and returns the first
 // available result.
@@ -311,6 +453,7 @@ var hllQuery = fmt.Sprintf(`
 					%[2]s *
 					CASE WHEN tsv_search_tokens @@ websearch_to_tsquery($1) THEN 1 ELSE 0 END
 				) > 0.1
+				AND %[3]s
 				AND hll_register=generate_series
 				ORDER BY hll_leading_zeros DESC
 			) t
@@ -335,7 +478,7 @@ var hllQuery = fmt.Sprintf(`
 			)::int AS result_count,
 			%[1]d - count(1) AS empty_register_count
 		FROM nonempty_registers
-	) d`, hllRegisterCount, scoreExpr)
+	) d`, hllRegisterCount, scoreExpr, internalCond("$2"))
 
 type estimateResponse struct {
 	estimate uint64
@@ -345,7 +488,7 @@ type estimateResponse struct {
 // EstimateResultsCount uses the hyperloglog algorithm to estimate the number
 // of results for the given search term.
 func (db *DB) estimateResultsCount(ctx context.Context, q string) estimateResponse {
-	row := db.db.QueryRow(ctx, hllQuery, q)
+	row := db.db.QueryRow(ctx, hllQuery, q, includeInternal(q))
 	var estimate sql.NullInt64
 	if err := row.Scan(&estimate); err != nil {
 		return estimateResponse{err: fmt.Errorf("row.Scan(): %v", err)}
@@ -355,6 +498,68 @@ func (db *DB) estimateResultsCount(ctx context.Context, q string) estimateRespon
 	return estimateResponse{estimate: uint64(estimate.Int64)}
 }
 
+// CountSearchResults returns the number of results of SearchFiltered for q
+// and filter, or of Search for q if filter is the zero SearchFilter, without
+// fetching them. The count uses the same conditions as the search queries,
+// including the omission of internal packages, but it does not omit excluded
+// packages.
+//
+// An exact count can take a long time for a common query, so callers should
+// bound ctx, and fall back to EstimateSearchResults if it is done first.
+func (db *DB) CountSearchResults(ctx context.Context, q string, filter SearchFilter) (_ int, err error) {
+	defer derrors.Wrap(&err, "DB.CountSearchResults(ctx, %q, %+v)", q, filter)
+	if err := checkSearchFilter(q, filter); err != nil {
+		return 0, err
+	}
+	query := fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM search_documents
+		WHERE %s
+		AND %s > 0.1`, filteredSearchCond, filteredScoreExpr)
+	var count int
+	if err := db.db.QueryRow(ctx, query, filteredSearchArgs(q, filter)...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// EstimateSearchResults returns the query planner's estimate of the number of
+// results that CountSearchResults counts for q and filter.
+func (db *DB) EstimateSearchResults(ctx context.Context, q string, filter SearchFilter) (_ int, err error) {
+	defer derrors.Wrap(&err, "DB.EstimateSearchResults(ctx, %q, %+v)", q, filter)
+	if err := checkSearchFilter(q, filter); err != nil {
+		return 0, err
+	}
+	query := fmt.Sprintf(`
+		EXPLAIN (FORMAT JSON)
+		SELECT 1
+		FROM search_documents
+		WHERE %s
+		AND %s > 0.1`, filteredSearchCond, filteredScoreExpr)
+	var plan []byte
+	if err := db.db.QueryRow(ctx, query, filteredSearchArgs(q, filter)...).Scan(&plan); err != nil {
+		return 0, err
+	}
+	return planRows(plan)
+}
+
+// planRows returns the estimated number of rows of the top-level plan node in
+// the output of EXPLAIN (FORMAT JSON).
+func planRows(plan []byte) (int, error) {
+	var explained []struct {
+		Plan struct {
+			PlanRows float64 `json:"Plan Rows"`
+		}
+	}
+	if err := json.Unmarshal(plan, &explained); err != nil {
+		return 0, fmt.Errorf("json.Unmarshal: %v", err)
+	}
+	if len(explained) == 0 {
+		return 0, errors.New("empty query plan")
+	}
+	return int(explained[0].Plan.PlanRows), nil
+}
+
 // deepSearch searches all packages for the query. It is slower, but results
 // are always valid.
 func (db *DB) deepSearch(ctx context.Context, q string, limit, offset int) searchResponse {
@@ -371,6 +576,7 @@ func (db *DB) deepSearch(ctx context.Context, q string, limit, offset int) searc
 				FROM
 					search_documents
 				WHERE tsv_search_tokens @@ websearch_to_tsquery($1)
+				AND %s
 				ORDER BY
 					score DESC,
 					commit_time DESC,
@@ -378,7 +584,18 @@ func (db *DB) deepSearch(ctx context.Context, q string, limit, offset int) searc
 		) r
 		WHERE r.score > 0.1
 		LIMIT $2
-		OFFSET $3`, scoreExpr)
+		OFFSET $3`, scoreExpr, internalCond("$4"))
+	results, err := db.runDeepSearch(ctx, query, q, limit, offset, includeInternal(q))
+	return searchResponse{
+		source:  "deep",
+		results: results,
+		err:     err,
+	}
+}
+
+// runDeepSearch runs a deep search query, which must select the columns of
+// search_documents used by deepSearch followed by the total result count.
+func (db *DB) runDeepSearch(ctx context.Context, query string, args ...interface{}) ([]*internal.SearchResult, error) {
 	var results []*internal.SearchResult
 	collect := func(rows *sql.Rows) error {
 		var r internal.SearchResult
@@ -389,15 +606,10 @@ func (db *DB) deepSearch(ctx context.Context, q string, limit, offset int) searc
 		results = append(results, &r)
 		return nil
 	}
-	err := db.db.RunQuery(ctx, query, collect, q, limit, offset)
-	if err != nil {
-		results = nil
-	}
-	return searchResponse{
-		source:  "deep",
-		results: results,
-		err:     err,
+	if err := db.db.RunQuery(ctx, query, collect, args...); err != nil {
+		return nil, err
 	}
+	return results, nil
 }
 
 func (db *DB) popularSearch(ctx context.Context, searchQuery string, limit, offset int) searchResponse {
@@ -409,7 +621,7 @@ func (db *DB) popularSearch(ctx context.Context, searchQuery string, limit, offs
 			commit_time,
 			imported_by_count,
 			score
-		FROM popular_search($1, $2, $3, $4, $5)`
+		FROM popular_search_ranking_score($1, $2, $3, $4)`
 	var results []*internal.SearchResult
 	collect := func(rows *sql.Rows) error {
 		var r internal.SearchResult
@@ -420,7 +632,7 @@ func (db *DB) popularSearch(ctx context.Context, searchQuery string, limit, offs
 		results = append(results, &r)
 		return nil
 	}
-	err := db.db.RunQuery(ctx, query, collect, searchQuery, limit, offset, nonRedistributablePenalty, noGoModPenalty)
+	err := db.db.RunQuery(ctx, query, collect, searchQuery, limit, offset, includeInternal(searchQuery))
 	if err != nil {
 		results = nil
 	}
@@ -491,27 +703,35 @@ var upsertSearchStatement = fmt.Sprintf(`
 		version,
 		module_path,
 		name,
+		kind,
 		synopsis,
 		license_types,
 		redistributable,
 		version_updated_at,
 		commit_time,
 		has_go_mod,
+		stdlib_only,
+		signatures,
 		tsv_search_tokens,
 		hll_register,
-		hll_leading_zeros
+		hll_leading_zeros,
+		ranking_score,
+		ranking_score_updated_at
 	)
 	SELECT
 		p.path,
 		p.version,
 		p.module_path,
 		p.name,
+		%[4]s,
 		p.synopsis,
 		p.license_types,
 		p.redistributable,
 		CURRENT_TIMESTAMP,
 		m.commit_time,
 		m.has_go_mod,
+		p.stdlib_only,
+		p.signatures,
 		(
 			SETWEIGHT(TO_TSVECTOR('path_tokens', $2), 'A') ||
 			SETWEIGHT(TO_TSVECTOR($3), 'B') ||
@@ -519,7 +739,9 @@ var upsertSearchStatement = fmt.Sprintf(`
 			SETWEIGHT(TO_TSVECTOR($5), 'D')
 		),
 		hll_hash(p.path) & (%[1]d - 1),
-		hll_zeros(hll_hash(p.path))
+		hll_zeros(hll_hash(p.path)),
+		%[2]s,
+		CURRENT_TIMESTAMP
 	FROM
 		packages p
 	INNER JOIN
@@ -530,6 +752,16 @@ var upsertSearchStatement = fmt.Sprintf(`
 		AND p.version = m.version
 	WHERE
 		p.path = $1
+		-- If there is a license allowlist, only versions whose
+		-- licenses all have allowed types are searchable.
+		AND ($6::text[] IS NULL OR (
+			EXISTS (
+				SELECT 1 FROM licenses l
+				WHERE l.module_path = m.module_path AND l.version = m.version)
+			AND NOT EXISTS (
+				SELECT 1 FROM licenses l
+				WHERE l.module_path = m.module_path AND l.version = m.version
+				AND NOT COALESCE(CARDINALITY(l.types) > 0 AND l.types <@ $6::text[], false))))
 	ORDER BY
 		-- Order the versions by release then prerelease.
 		-- The default version should be the first release
@@ -544,35 +776,52 @@ var upsertSearchStatement = fmt.Sprintf(`
 		version=excluded.version,
 		module_path=excluded.module_path,
 		name=excluded.name,
+		kind=excluded.kind,
 		synopsis=excluded.synopsis,
 		license_types=excluded.license_types,
 		redistributable=excluded.redistributable,
 		commit_time=excluded.commit_time,
 		has_go_mod=excluded.has_go_mod,
+		stdlib_only=excluded.stdlib_only,
+		signatures=excluded.signatures,
 		tsv_search_tokens=excluded.tsv_search_tokens,
+		ranking_score=%[3]s,
+		ranking_score_updated_at=CURRENT_TIMESTAMP,
 		-- the hll fields are functions of path, so they don't change
 		version_updated_at=(
 			CASE WHEN excluded.version = search_documents.version
 			THEN search_documents.version_updated_at
 			ELSE CURRENT_TIMESTAMP
 			END)
-	;`, hllRegisterCount)
+	;`, hllRegisterCount,
+	rankingScore("0", "p.redistributable", "m.has_go_mod", "p.version"),
+	rankingScore("search_documents.imported_by_count", "excluded.redistributable", "excluded.has_go_mod", "excluded.version"),
+	kindExpr("p.name"))
+
+// kindExpr returns an expression for the kind of a package, as computed by
+// internal.PackageKindForName, from the expression for its name. It is
+// stored in the kind column of search_documents, so that searches can filter
+// by kind without computing it.
+func kindExpr(name string) string {
+	return fmt.Sprintf("(CASE WHEN %s = 'main' THEN '%s' ELSE '%s' END)", name, internal.PackageKindCommand, internal.PackageKindLibrary)
+}
 
 // UpsertSearchDocuments adds search information for mod ot the search_documents table.
-func UpsertSearchDocuments(ctx context.Context, db *database.DB, mod *internal.Module) (err error) {
+// If allowedLicenseTypes is non-nil, a package is only indexed at its latest
+// version whose licenses all have types in allowedLicenseTypes.
+func UpsertSearchDocuments(ctx context.Context, db *database.DB, mod *internal.Module, allowedLicenseTypes []string) (err error) {
 	defer derrors.Wrap(&err, "UpsertSearchDocuments(ctx, %q)", mod.ModulePath)
 	ctx, span := trace.StartSpan(ctx, "UpsertSearchDocuments")
 	defer span.End()
 	for _, pkg := range mod.LegacyPackages {
-		if isInternalPackage(pkg.Path) {
-			continue
-		}
 		err := UpsertSearchDocument(ctx, db, upsertSearchDocumentArgs{
 			PackagePath:    pkg.Path,
 			ModulePath:     mod.ModulePath,
 			Synopsis:       pkg.Synopsis,
 			ReadmeFilePath: mod.LegacyReadmeFilePath,
 			ReadmeContents: mod.LegacyReadmeContents,
+
+			AllowedLicenseTypes: allowedLicenseTypes,
 		})
 		if err != nil {
 			return err
@@ -587,10 +836,16 @@ type upsertSearchDocumentArgs struct {
 	Synopsis       string
 	ReadmeFilePath string
 	ReadmeContents string
+
+	// AllowedLicenseTypes, if non-nil, restricts the versions of the package
+	// that can be indexed to those whose licenses all have these types. See
+	// DB.SetLicenseAllowlist.
+	AllowedLicenseTypes []string
 }
 
 // UpsertSearchDocument inserts a row for each package in the module, if that
-// package is the latest version and is not internal.
+// package is the latest version. Internal packages are inserted too, but
+// searches only return them if the query asks for them; see includeInternal.
 //
 // The given module should have already been validated via a call to
 // validateModule.
@@ -604,7 +859,7 @@ func UpsertSearchDocument(ctx context.Context, db *database.DB, args upsertSearc
 	}
 	pathTokens := strings.Join(GeneratePathTokens(args.PackagePath), " ")
 	sectionB, sectionC, sectionD := SearchDocumentSections(args.Synopsis, args.ReadmeFilePath, args.ReadmeContents)
-	_, err = db.Exec(ctx, upsertSearchStatement, args.PackagePath, pathTokens, sectionB, sectionC, sectionD)
+	_, err = db.Exec(ctx, upsertSearchStatement, args.PackagePath, pathTokens, sectionB, sectionC, sectionD, pq.Array(args.AllowedLicenseTypes))
 	return err
 }
 
@@ -626,6 +881,7 @@ func (db *DB) GetPackagesForSearchDocumentUpsert(ctx context.Context, before tim
 		if err := rows.Scan(&a.PackagePath, &a.ModulePath, &a.Synopsis, &a.ReadmeFilePath, &a.ReadmeContents); err != nil {
 			return err
 		}
+		a.AllowedLicenseTypes = db.allowedLicenseTypes()
 		argsList = append(argsList, a)
 		return nil
 	}
@@ -822,6 +1078,59 @@ func updateImportedByCounts(ctx context.Context, db *database.DB) (int64, error)
 	return n, nil
 }
 
+// searchRankingBatchSize is the number of search documents whose
+// ranking_score is updated in each statement by RecomputeSearchRankings.
+const searchRankingBatchSize = 1000
+
+// RecomputeSearchRankings recomputes the ranking_score column of every row in
+// search_documents from its other columns, in batches so that no single
+// statement locks the whole table. It is intended to be run periodically,
+// after UpdateSearchDocumentsImportedByCount.
+//
+// RecomputeSearchRankings returns the number of rows updated.
+func (db *DB) RecomputeSearchRankings(ctx context.Context) (nUpdated int64, err error) {
+	defer derrors.Wrap(&err, "RecomputeSearchRankings(ctx)")
+
+	// Find the end of each batch first, so that batches follow the database's
+	// ordering of package paths.
+	const batchEndQuery = `
+		SELECT max(package_path)
+		FROM (
+			SELECT package_path
+			FROM search_documents
+			WHERE package_path > $1
+			ORDER BY package_path
+			LIMIT $2
+		) b`
+	updateStmt := fmt.Sprintf(`
+		UPDATE search_documents
+		SET
+			ranking_score = %s,
+			ranking_score_updated_at = CURRENT_TIMESTAMP
+		WHERE package_path > $1 AND package_path <= $2`,
+		rankingScore("imported_by_count", "redistributable", "has_go_mod", "version"))
+	start := ""
+	for {
+		var end sql.NullString
+		if err := db.db.QueryRow(ctx, batchEndQuery, start, searchRankingBatchSize).Scan(&end); err != nil {
+			return nUpdated, err
+		}
+		if !end.Valid {
+			return nUpdated, nil
+		}
+		res, err := db.db.Exec(ctx, updateStmt, start, end.String)
+		if err != nil {
+			return nUpdated, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nUpdated, fmt.Errorf("RowsAffected: %v", err)
+		}
+		nUpdated += n
+		start = end.String
+	}
+}
+
 var (
 	commonHostnames = map[string]bool{
 		"bitbucket.org":         true,
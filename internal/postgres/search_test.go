@@ -7,6 +7,7 @@ package postgres
 import (
 	"context"
 	"crypto/md5"
+	"database/sql"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -298,6 +299,9 @@ func TestSearch(t *testing.T) {
 			if _, err := testDB.UpdateSearchDocumentsImportedByCount(ctx); err != nil {
 				t.Fatal(err)
 			}
+			if _, err := testDB.RecomputeSearchRankings(ctx); err != nil {
+				t.Fatal(err)
+			}
 			guardTestResult := resultGuard(test.resultOrder)
 			resp, err := testDB.hedgedSearch(ctx, "foo", 2, 0, searchers, guardTestResult)
 			if err != nil {
@@ -393,6 +397,9 @@ func TestSearchErrors(t *testing.T) {
 			if _, err := testDB.UpdateSearchDocumentsImportedByCount(ctx); err != nil {
 				t.Fatal(err)
 			}
+			if _, err := testDB.RecomputeSearchRankings(ctx); err != nil {
+				t.Fatal(err)
+			}
 			guardTestResult := resultGuard(test.resultOrder)
 			resp, err := testDB.hedgedSearch(ctx, "foo", 2, 0, test.searchers, guardTestResult)
 			if (err != nil) != test.wantErr {
@@ -571,8 +578,8 @@ func TestInsertSearchDocumentAndSearch(t *testing.T) {
 }
 
 func TestSearchPenalties(t *testing.T) {
-	// Verify that the penalties for non-redistributable modules and modules without
-	// go.mod files are applied correctly.
+	// Verify that the penalties for non-redistributable modules, modules without
+	// go.mod files and v0 modules are applied correctly.
 	defer ResetTestDB(testDB, t)
 
 	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
@@ -583,16 +590,18 @@ func TestSearchPenalties(t *testing.T) {
 	modules := map[string]struct {
 		redist     bool
 		hasGoMod   bool
+		version    string
 		multiplier float64 // applied to base score
 	}{
-		"both.com/foo":      {true, true, 1},
-		"nogomod.com/foo":   {true, false, noGoModPenalty},
-		"nonredist.com/foo": {false, true, nonRedistributablePenalty},
-		"neither.com/foo":   {false, false, noGoModPenalty * nonRedistributablePenalty},
+		"both.com/foo":      {true, true, sample.VersionString, 1},
+		"nogomod.com/foo":   {true, false, sample.VersionString, noGoModPenalty},
+		"nonredist.com/foo": {false, true, sample.VersionString, nonRedistributablePenalty},
+		"neither.com/foo":   {false, false, sample.VersionString, noGoModPenalty * nonRedistributablePenalty},
+		"unstable.com/foo":  {true, true, "v0.3.1", unstablePenalty},
 	}
 
 	for path, m := range modules {
-		v := sample.Module(path, sample.VersionString, "p")
+		v := sample.Module(path, m.version, "p")
 		v.LegacyPackages[0].IsRedistributable = m.redist
 		v.IsRedistributable = m.redist
 		v.HasGoMod = m.hasGoMod
@@ -652,6 +661,299 @@ func TestExcludedFromSearch(t *testing.T) {
 	}
 }
 
+func TestInternalPackagesFromSearch(t *testing.T) {
+	// Verify that internal packages are omitted from search results, unless
+	// the query mentions them.
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	const domain = "visible.com"
+	sm := sample.Module(domain, "v1.2.3", "pkg", "pkg2", "internal/ffoo", "a/internal/b")
+	if err := testDB.InsertModule(ctx, sm); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		q    string
+		want []string
+	}{
+		{domain, []string{domain + "/pkg", domain + "/pkg2"}},
+		{domain + " internal", []string{domain + "/a/internal/b", domain + "/internal/ffoo"}},
+	} {
+		gotResults, err := testDB.Search(ctx, test.q, 10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, g := range gotResults {
+			got = append(got, g.PackagePath)
+		}
+		sort.Strings(got)
+		if !cmp.Equal(got, test.want) {
+			t.Errorf("Search(ctx, %q, 10, 0): got %v, want %v", test.q, got, test.want)
+		}
+	}
+
+	// Internal packages are left out before the results are paginated and
+	// counted, so every page is full and the total does not include them.
+	var got []string
+	for offset := 0; offset < 2; offset++ {
+		for name, search := range map[string]func() ([]*internal.SearchResult, error){
+			"Search": func() ([]*internal.SearchResult, error) {
+				return testDB.Search(ctx, domain, 1, offset)
+			},
+			"SearchFiltered": func() ([]*internal.SearchResult, error) {
+				return testDB.SearchFiltered(ctx, domain, SearchFilter{}, 1, offset)
+			},
+		} {
+			results, err := search()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != 1 {
+				t.Fatalf("%s(ctx, %q, 1, %d): got %d results, want 1", name, domain, offset, len(results))
+			}
+			if name == "SearchFiltered" {
+				if n := results[0].NumResults; n != 2 {
+					t.Errorf("%s(ctx, %q, 1, %d): got %d total results, want 2", name, domain, offset, n)
+				}
+				got = append(got, results[0].PackagePath)
+			}
+		}
+	}
+	sort.Strings(got)
+	if want := []string{domain + "/pkg", domain + "/pkg2"}; !cmp.Equal(got, want) {
+		t.Errorf("SearchFiltered pages: got %v, want %v", got, want)
+	}
+}
+
+func TestSearchPackageKind(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	const domain = "kind.com"
+	m := sample.Module(domain, sample.VersionString, "lib")
+	cmd := sample.LegacyPackage(domain, "cmd/tool")
+	cmd.Name = "main"
+	sample.AddPackage(m, cmd)
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	// The kind of each package is stored with its search document.
+	gotKinds := map[string]internal.PackageKind{}
+	err := testDB.db.RunQuery(ctx, `SELECT package_path, kind FROM search_documents WHERE module_path = $1`,
+		func(rows *sql.Rows) error {
+			var (
+				path string
+				kind internal.PackageKind
+			)
+			if err := rows.Scan(&path, &kind); err != nil {
+				return err
+			}
+			gotKinds[path] = kind
+			return nil
+		}, domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantKinds := map[string]internal.PackageKind{
+		domain + "/lib":      internal.PackageKindLibrary,
+		domain + "/cmd/tool": internal.PackageKindCommand,
+	}
+	if diff := cmp.Diff(wantKinds, gotKinds); diff != "" {
+		t.Errorf("search_documents kinds mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, test := range []struct {
+		kind internal.PackageKind
+		want []string
+	}{
+		{internal.PackageKindCommand, []string{domain + "/cmd/tool"}},
+		{internal.PackageKindLibrary, []string{domain + "/lib"}},
+	} {
+		t.Run(string(test.kind), func(t *testing.T) {
+			results, err := testDB.SearchPackageKind(ctx, domain, test.kind, 10, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range results {
+				got = append(got, r.PackagePath)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("SearchPackageKind(ctx, %q, %q, 10, 0) mismatch (-want +got):\n%s", domain, test.kind, diff)
+			}
+		})
+	}
+
+	if _, err := testDB.SearchPackageKind(ctx, domain, "other", 10, 0); !errors.Is(err, derrors.InvalidArgument) {
+		t.Errorf("SearchPackageKind with unknown kind: got error %v, want %v", err, derrors.InvalidArgument)
+	}
+}
+
+func TestSearchFiltered(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	const domain = "filter.com"
+	m := sample.Module(domain, sample.VersionString, "lib", "dep")
+	for _, p := range m.LegacyPackages {
+		if p.Path == domain+"/dep" {
+			p.Imports = []string{"fmt", "github.com/some/dependency"}
+		}
+	}
+	cmd := sample.LegacyPackage(domain, "cmd/tool")
+	cmd.Name = "main"
+	cmd.Imports = []string{"os"}
+	sample.AddPackage(m, cmd)
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		filter SearchFilter
+		want   []string
+	}{
+		{SearchFilter{StdlibOnly: true}, []string{domain + "/cmd/tool", domain + "/lib"}},
+		{SearchFilter{Kind: internal.PackageKindLibrary}, []string{domain + "/dep", domain + "/lib"}},
+		{SearchFilter{Kind: internal.PackageKindLibrary, StdlibOnly: true}, []string{domain + "/lib"}},
+	} {
+		t.Run(fmt.Sprintf("%+v", test.filter), func(t *testing.T) {
+			results, err := testDB.SearchFiltered(ctx, domain, test.filter, 10, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range results {
+				got = append(got, r.PackagePath)
+			}
+			sort.Strings(got)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("SearchFiltered(ctx, %q, %+v, 10, 0) mismatch (-want +got):\n%s", domain, test.filter, diff)
+			}
+		})
+	}
+
+	if _, err := testDB.SearchFiltered(ctx, domain, SearchFilter{Kind: "other"}, 10, 0); !errors.Is(err, derrors.InvalidArgument) {
+		t.Errorf("SearchFiltered with unknown kind: got error %v, want %v", err, derrors.InvalidArgument)
+	}
+}
+
+func TestSearchSignature(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	const domain = "signature.com"
+	m := sample.Module(domain, sample.VersionString, "client", "copy", "none")
+	for _, p := range m.LegacyPackages {
+		switch p.Path {
+		case domain + "/client":
+			p.Signatures = []string{"func() error", "func(Context, string) ([]byte, error)"}
+		case domain + "/copy":
+			p.Signatures = []string{"func(Writer, Reader) (int64, error)", "func() error"}
+		}
+	}
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		q, sig string
+		want   []string
+	}{
+		{"", "func() error", []string{domain + "/client", domain + "/copy"}},
+		{"", "func(Context, string) ([]byte, error)", []string{domain + "/client"}},
+		{"copy", "func() error", []string{domain + "/copy"}},
+		{domain, "func(Writer, Reader) (int64, error)", []string{domain + "/copy"}},
+		{"", "func(Reader) error", nil},
+		{"client", "func(Writer, Reader) (int64, error)", nil},
+	} {
+		t.Run(test.q+" "+test.sig, func(t *testing.T) {
+			filter := SearchFilter{Signature: test.sig}
+			results, err := testDB.SearchFiltered(ctx, test.q, filter, 10, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range results {
+				got = append(got, r.PackagePath)
+			}
+			sort.Strings(got)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("SearchFiltered(ctx, %q, %+v, 10, 0) mismatch (-want +got):\n%s", test.q, filter, diff)
+			}
+		})
+	}
+
+	if _, err := testDB.SearchFiltered(ctx, "", SearchFilter{}, 10, 0); !errors.Is(err, derrors.InvalidArgument) {
+		t.Errorf("SearchFiltered with empty query and no signature: got error %v, want %v", err, derrors.InvalidArgument)
+	}
+}
+
+func TestCountSearchResults(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	const domain = "count.com"
+	m := sample.Module(domain, sample.VersionString, "a", "b", "internal/c")
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := testDB.Search(ctx, domain, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Search(ctx, %q, 10, 0) returned %d results, want 2", domain, len(results))
+	}
+	for _, test := range []struct {
+		q      string
+		filter SearchFilter
+		want   int
+	}{
+		{domain, SearchFilter{}, len(results)},
+		{domain, SearchFilter{Kind: internal.PackageKindLibrary}, 2},
+		{domain, SearchFilter{Kind: internal.PackageKindCommand}, 0},
+		{"nomatches", SearchFilter{}, 0},
+	} {
+		got, err := testDB.CountSearchResults(ctx, test.q, test.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("CountSearchResults(ctx, %q, %+v) = %d, want %d", test.q, test.filter, got, test.want)
+		}
+		if _, err := testDB.EstimateSearchResults(ctx, test.q, test.filter); err != nil {
+			t.Errorf("EstimateSearchResults(ctx, %q, %+v): %v", test.q, test.filter, err)
+		}
+	}
+
+	if _, err := testDB.CountSearchResults(ctx, "", SearchFilter{}); !errors.Is(err, derrors.InvalidArgument) {
+		t.Errorf("CountSearchResults with empty query and no signature: got error %v, want %v", err, derrors.InvalidArgument)
+	}
+}
+
+func TestPlanRows(t *testing.T) {
+	got, err := planRows([]byte(`[{"Plan": {"Node Type": "Seq Scan", "Plan Rows": 42, "Plan Width": 4}}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 42 {
+		t.Errorf("got %d, want 42", got)
+	}
+	for _, bad := range []string{``, `[]`, `{"Plan": {}}`} {
+		if _, err := planRows([]byte(bad)); err == nil {
+			t.Errorf("planRows(%q): got nil error, want non-nil", bad)
+		}
+	}
+}
+
 type searchDocument struct {
 	packagePath              string
 	modulePath               string
@@ -906,6 +1208,73 @@ func TestUpdateSearchDocumentsImportedByCount(t *testing.T) {
 	})
 }
 
+func TestRecomputeSearchRankings(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	insertModule := func(suffix string, redist bool, imports ...string) {
+		t.Helper()
+		m := sample.Module("mod.com/"+suffix, sample.VersionString, suffix)
+		if !redist {
+			m.IsRedistributable = false
+			m.LegacyPackages[0].IsRedistributable = false
+		}
+		pkg := m.LegacyPackages[0]
+		pkg.Imports = nil
+		for _, imp := range imports {
+			pkg.Imports = append(pkg.Imports, fmt.Sprintf("mod.com/%s/%[1]s", imp))
+		}
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkRankingScores := func(want map[string]float64) {
+		t.Helper()
+		for path, w := range want {
+			var got float64
+			if err := testDB.db.QueryRow(ctx, `SELECT ranking_score FROM search_documents WHERE package_path = $1`, path).Scan(&got); err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-w) > 1e-9 {
+				t.Errorf("ranking_score of %q = %f, want %f", path, got, w)
+			}
+		}
+	}
+
+	insertModule("A", true)
+	insertModule("B", false, "A")
+	// New packages get a ranking score before any recomputation.
+	checkRankingScores(map[string]float64{
+		"mod.com/A/A": 1,
+		"mod.com/B/B": nonRedistributablePenalty,
+	})
+
+	// New importers change the imported-by counts, but not the ranking
+	// scores until they are recomputed.
+	insertModule("C", true, "A", "B")
+	if _, err := testDB.UpdateSearchDocumentsImportedByCount(ctx); err != nil {
+		t.Fatal(err)
+	}
+	checkRankingScores(map[string]float64{
+		"mod.com/A/A": 1,
+		"mod.com/B/B": nonRedistributablePenalty,
+	})
+	n, err := testDB.RecomputeSearchRankings(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(3); n != want {
+		t.Errorf("RecomputeSearchRankings: updated %d rows, want %d", n, want)
+	}
+	checkRankingScores(map[string]float64{
+		"mod.com/A/A": math.Log(math.E + 2),
+		"mod.com/B/B": math.Log(math.E+1) * nonRedistributablePenalty,
+		"mod.com/C/C": 1,
+	})
+
+}
+
 func TestGetPackagesForSearchDocumentUpsert(t *testing.T) {
 	defer ResetTestDB(testDB, t)
 
@@ -919,7 +1288,7 @@ func TestGetPackagesForSearchDocumentUpsert(t *testing.T) {
 	}
 
 	// We are asking for all packages in search_documents updated before now, which is
-	// all the non-internal packages.
+	// all the packages.
 	got, err := testDB.GetPackagesForSearchDocumentUpsert(ctx, time.Now(), 10)
 	if err != nil {
 		t.Fatal(err)
@@ -933,6 +1302,20 @@ func TestGetPackagesForSearchDocumentUpsert(t *testing.T) {
 			ReadmeContents: "readme",
 			Synopsis:       "This is a package synopsis",
 		},
+		{
+			PackagePath:    "mod.com/A/internal",
+			ModulePath:     "mod.com",
+			ReadmeFilePath: "README.md",
+			ReadmeContents: "readme",
+			Synopsis:       "This is a package synopsis",
+		},
+		{
+			PackagePath:    "mod.com/A/internal/B",
+			ModulePath:     "mod.com",
+			ReadmeFilePath: "README.md",
+			ReadmeContents: "readme",
+			Synopsis:       "This is a package synopsis",
+		},
 		{
 			PackagePath:    "mod.com/A/notinternal",
 			ModulePath:     "mod.com",
@@ -0,0 +1,83 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// updateModuleCheck records that modulePath was checked with the module proxy
+// just now.
+func updateModuleCheck(ctx context.Context, db *database.DB, modulePath string) (err error) {
+	defer derrors.Wrap(&err, "updateModuleCheck(ctx, %q)", modulePath)
+
+	_, err = db.Exec(ctx, `
+		INSERT INTO module_checks (module_path, last_checked)
+		VALUES ($1, CURRENT_TIMESTAMP)
+		ON CONFLICT (module_path)
+		DO UPDATE SET last_checked=excluded.last_checked`,
+		modulePath)
+	return err
+}
+
+// GetStaleModules returns the paths of up to limit modules that were last
+// checked with the module proxy before olderThan, least recently checked
+// first. A module is checked whenever one of its versions is processed, so
+// these are the modules to poll for new versions.
+func (db *DB) GetStaleModules(ctx context.Context, olderThan time.Time, limit int) (_ []string, err error) {
+	defer derrors.Wrap(&err, "GetStaleModules(ctx, %s, %d)", olderThan, limit)
+
+	query := `
+		SELECT module_path
+		FROM module_checks
+		WHERE last_checked < $1
+		ORDER BY last_checked, module_path
+		LIMIT $2`
+	var paths []string
+	err = db.db.RunQuery(ctx, query, func(rows *sql.Rows) error {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return err
+		}
+		paths = append(paths, p)
+		return nil
+	}, olderThan, limit)
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// UpdateModuleCheck records that modulePath was checked with the module proxy
+// just now, for example to discover new versions.
+func (db *DB) UpdateModuleCheck(ctx context.Context, modulePath string) error {
+	return updateModuleCheck(ctx, db.db, modulePath)
+}
+
+// GetStoredVersions returns the versions of modulePath in the modules table,
+// in no particular order.
+func (db *DB) GetStoredVersions(ctx context.Context, modulePath string) (_ []string, err error) {
+	defer derrors.Wrap(&err, "GetStoredVersions(ctx, %q)", modulePath)
+
+	query := `SELECT version FROM modules WHERE module_path = $1`
+	var versions []string
+	err = db.db.RunQuery(ctx, query, func(rows *sql.Rows) error {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return err
+		}
+		versions = append(versions, v)
+		return nil
+	}, modulePath)
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
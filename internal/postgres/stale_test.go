@@ -0,0 +1,100 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetStaleModules(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	for _, m := range []struct {
+		path, version string
+	}{
+		{"example.com/old", "v1.0.0"},
+		{"example.com/old", "v1.1.0"},
+		{"example.com/older", "v1.0.0"},
+		{"example.com/new", "v1.0.0"},
+	} {
+		if err := testDB.UpsertModuleVersionState(ctx, m.path, m.version, "", time.Now(), http.StatusOK, "", nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for path, age := range map[string]string{
+		"example.com/old":   "2 days",
+		"example.com/older": "3 days",
+	} {
+		if _, err := testDB.db.Exec(ctx, `
+			UPDATE module_checks
+			SET last_checked = CURRENT_TIMESTAMP - $2::interval
+			WHERE module_path = $1`, path, age); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, test := range []struct {
+		olderThan time.Time
+		limit     int
+		want      []string
+	}{
+		{time.Now().Add(-24 * time.Hour), 10, []string{"example.com/older", "example.com/old"}},
+		{time.Now().Add(-24 * time.Hour), 1, []string{"example.com/older"}},
+		{time.Now().Add(-60 * time.Hour), 10, []string{"example.com/older"}},
+		{time.Now().Add(-96 * time.Hour), 10, nil},
+	} {
+		got, err := testDB.GetStaleModules(ctx, test.olderThan, test.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("GetStaleModules(ctx, %s, %d) mismatch (-want +got):\n%s", test.olderThan, test.limit, diff)
+		}
+	}
+
+	// Processing a version of a stale module makes it fresh.
+	if err := testDB.UpsertModuleVersionState(ctx, "example.com/older", "v1.2.0", "", time.Now(), http.StatusOK, "", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err := testDB.GetStaleModules(ctx, time.Now().Add(-24*time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"example.com/old"}, got); diff != "" {
+		t.Errorf("GetStaleModules after processing mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGetStoredVersions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	for _, v := range []string{"v1.0.0", "v1.1.0"} {
+		if err := testDB.InsertModule(ctx, sample.Module("example.com/m", v, "p")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := testDB.InsertModule(ctx, sample.Module("example.com/other", "v1.2.0", "p")); err != nil {
+		t.Fatal(err)
+	}
+	got, err := testDB.GetStoredVersions(ctx, "example.com/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	if diff := cmp.Diff([]string{"v1.0.0", "v1.1.0"}, got); diff != "" {
+		t.Errorf("GetStoredVersions mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// SuppressedPackage is a package that is left out of search_documents because
+// a later version of its module has an alternative module path.
+type SuppressedPackage struct {
+	PackagePath string
+	ModulePath  string
+	Version     string
+
+	// AlternativeVersion is the later version of the module that was found to
+	// have an alternative module path, and AlternativeModulePath is the module
+	// path from its go.mod file.
+	AlternativeVersion    string
+	AlternativeModulePath string
+}
+
+// GetSuppressedPackages returns up to limit packages whose paths contain q and
+// that are not in search_documents because their module has a newer version
+// with an alternative module path (see InsertModule). For each package path,
+// only the highest such version is returned.
+//
+// It is intended for admin use, to investigate why a package does not appear
+// in search results. It should not be used to serve public search requests.
+func (db *DB) GetSuppressedPackages(ctx context.Context, q string, limit int) (_ []*SuppressedPackage, err error) {
+	defer derrors.Wrap(&err, "GetSuppressedPackages(ctx, %q, %d)", q, limit)
+	if q == "" {
+		return nil, fmt.Errorf("empty query: %w", derrors.InvalidArgument)
+	}
+
+	query := `
+		SELECT DISTINCT ON (p.path)
+			p.path,
+			p.module_path,
+			p.version,
+			s.version,
+			s.go_mod_path
+		FROM packages p
+		INNER JOIN modules m
+		ON p.module_path = m.module_path AND p.version = m.version
+		INNER JOIN module_version_states s
+		ON s.module_path = m.module_path
+		WHERE
+			strpos(p.path, $1) > 0
+			AND s.status = 491
+			AND s.sort_version > m.sort_version
+			AND NOT EXISTS (
+				SELECT 1 FROM search_documents sd WHERE sd.package_path = p.path
+			)
+		ORDER BY p.path, m.sort_version DESC, s.sort_version DESC
+		LIMIT $2`
+	var sps []*SuppressedPackage
+	collect := func(rows *sql.Rows) error {
+		var sp SuppressedPackage
+		if err := rows.Scan(&sp.PackagePath, &sp.ModulePath, &sp.Version,
+			&sp.AlternativeVersion, database.NullIsEmpty(&sp.AlternativeModulePath)); err != nil {
+			return fmt.Errorf("rows.Scan(): %v", err)
+		}
+		sps = append(sps, &sp)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, q, limit); err != nil {
+		return nil, err
+	}
+	return sps, nil
+}
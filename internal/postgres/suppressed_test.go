@@ -0,0 +1,64 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetSuppressedPackages(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	const (
+		modulePath = "example.com/Mod"
+		altVersion = "v1.2.0"
+		okVersion  = "v1.0.0"
+	)
+	if err := testDB.UpsertModuleVersionState(ctx, modulePath, altVersion, "appVersion", time.Now(),
+		derrors.ToHTTPStatus(derrors.AlternativeModule), "example.com/mod", derrors.AlternativeModule, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []struct {
+		path, version string
+	}{
+		{modulePath, okVersion},
+		{modulePath, "v0.9.0"},
+		{"example.com/other", okVersion},
+	} {
+		if err := testDB.InsertModule(ctx, sample.Module(m.path, m.version, "p")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := testDB.GetSuppressedPackages(ctx, "example.com", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*SuppressedPackage{
+		{
+			PackagePath:           modulePath + "/p",
+			ModulePath:            modulePath,
+			Version:               okVersion,
+			AlternativeVersion:    altVersion,
+			AlternativeModulePath: "example.com/mod",
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GetSuppressedPackages mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := testDB.GetSuppressedPackages(ctx, "", 10); !errors.Is(err, derrors.InvalidArgument) {
+		t.Errorf("GetSuppressedPackages(ctx, \"\", 10): got error %v, want %v", err, derrors.InvalidArgument)
+	}
+}
@@ -100,8 +100,8 @@ func SetupTestDB(dbName string) (_ *DB, err error) {
 }
 
 // ResetTestDB truncates all data from the given test DB.  It should be called
-// after every test that mutates the database.
-func ResetTestDB(db *DB, t *testing.T) {
+// after every test or benchmark that mutates the database.
+func ResetTestDB(db *DB, t testing.TB) {
 	ctx := context.Background()
 	t.Helper()
 	if err := db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
@@ -109,7 +109,10 @@ func ResetTestDB(db *DB, t *testing.T) {
 			TRUNCATE modules CASCADE;
 			TRUNCATE version_map;
 			TRUNCATE imports_unique;
-			TRUNCATE experiments;`); err != nil {
+			TRUNCATE experiments;
+			TRUNCATE license_contents CASCADE;
+			TRUNCATE module_checks;
+			TRUNCATE webhook_subscriptions;`); err != nil {
 			return err
 		}
 		if _, err := tx.Exec(ctx, `TRUNCATE module_version_states CASCADE;`); err != nil {
@@ -0,0 +1,49 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package postgres
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// FuzzMakeValidUnicode checks that makeValidUnicode always returns valid UTF-8
+// without NUL bytes, which Postgres rejects in text columns, and that applying
+// it again changes nothing. Run it with
+//
+//	go test ./internal/postgres -run '^$' -fuzz FuzzMakeValidUnicode
+//
+// Like the other tests in this package, it is skipped if there is no test
+// database.
+func FuzzMakeValidUnicode(f *testing.F) {
+	for _, filename := range []string{"final-nulls", "gin-gonic", "subchord"} {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", filename))
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	for _, s := range []string{"", "abc", "\x00", "a\x00b", "\xff", "\xed\xa0\x80", "\xe2\x82", "�", "日本\x00語\x80"} {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		got := makeValidUnicode(string(data))
+		if !utf8.ValidString(got) {
+			t.Errorf("makeValidUnicode(%q) = %q, which is not valid UTF-8", data, got)
+		}
+		if strings.IndexByte(got, 0) >= 0 {
+			t.Errorf("makeValidUnicode(%q) = %q, which contains a NUL byte", data, got)
+		}
+		if again := makeValidUnicode(got); again != got {
+			t.Errorf("makeValidUnicode is not idempotent: makeValidUnicode(%q) = %q, but makeValidUnicode(%q) = %q", data, got, got, again)
+		}
+	})
+}
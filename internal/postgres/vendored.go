@@ -0,0 +1,40 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// GetVendoredModules returns the modules that the module version vendors, as
+// listed in its vendor/modules.txt file, sorted by module path.
+func (db *DB) GetVendoredModules(ctx context.Context, modulePath, version string) (_ []*internal.VendoredModule, err error) {
+	defer derrors.Wrap(&err, "GetVendoredModules(ctx, %q, %q)", modulePath, version)
+
+	query := `
+		SELECT v.vendored_module_path, v.vendored_version, v.replacement
+		FROM vendored_modules v
+		INNER JOIN modules m
+		ON v.module_id = m.id
+		WHERE m.module_path = $1 AND m.version = $2
+		ORDER BY v.vendored_module_path`
+	var vms []*internal.VendoredModule
+	collect := func(rows *sql.Rows) error {
+		var vm internal.VendoredModule
+		if err := rows.Scan(&vm.ModulePath, &vm.Version, &vm.Replacement); err != nil {
+			return err
+		}
+		vms = append(vms, &vm)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, modulePath, version); err != nil {
+		return nil, err
+	}
+	return vms, nil
+}
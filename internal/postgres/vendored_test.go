@@ -0,0 +1,55 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetVendoredModules(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	m := sample.Module(sample.ModulePath, sample.VersionString, "foo")
+	m.VendoredModules = []*internal.VendoredModule{
+		{ModulePath: "golang.org/x/text", Version: "v0.3.0"},
+		{ModulePath: "example.com/b", Version: "v1.1.0", Replacement: "example.com/fork/b v1.1.1"},
+		{ModulePath: "example.com/c", Replacement: "../c"},
+	}
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := testDB.GetVendoredModules(ctx, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*internal.VendoredModule{
+		{ModulePath: "example.com/b", Version: "v1.1.0", Replacement: "example.com/fork/b v1.1.1"},
+		{ModulePath: "example.com/c", Replacement: "../c"},
+		{ModulePath: "golang.org/x/text", Version: "v0.3.0"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// Inserting the module version again replaces its vendored modules.
+	m.VendoredModules = nil
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err = testDB.GetVendoredModules(ctx, m.ModulePath, m.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("after reinsert: got %v, want none", got)
+	}
+}
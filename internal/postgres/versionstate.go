@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
@@ -50,9 +51,7 @@ func (db *DB) UpsertModuleVersionState(ctx context.Context, modulePath, vers, ap
 	defer span.End()
 
 	var numPackages *int
-	if !(status >= http.StatusBadRequest && status <= http.StatusNotFound) {
-		// If a module was fetched a 40x error in this range, we won't know how
-		// many packages it has.
+	if packagesKnown(status) {
 		n := len(packageVersionStates)
 		numPackages = &n
 	}
@@ -68,6 +67,14 @@ func (db *DB) UpsertModuleVersionState(ctx context.Context, modulePath, vers, ap
 	})
 }
 
+// packagesKnown reports whether the number of packages of a module version is
+// known after fetching it resulted in status. If the fetch failed with a 40x
+// error up to 404, or with 410 because the module proxy no longer serves the
+// module version, we won't know how many packages it has.
+func packagesKnown(status int) bool {
+	return !(status >= http.StatusBadRequest && status <= http.StatusNotFound) && status != http.StatusGone
+}
+
 func upsertModuleVersionState(ctx context.Context, db *database.DB, modulePath, vers, appVersion string, numPackages *int, timestamp time.Time, status int, goModPath string, fetchErr error) (err error) {
 	defer derrors.Wrap(&err, "upsertModuleVersionState(ctx, %q, %q, %q, %s, %d, %q, %v",
 		modulePath, vers, appVersion, timestamp, status, goModPath, fetchErr)
@@ -79,6 +86,9 @@ func upsertModuleVersionState(ctx context.Context, db *database.DB, modulePath,
 		sqlErrorMsg = fetchErr.Error()
 	}
 
+	// The new backoff for a retryable failure is twice the old one, between 1
+	// minute and 1 hour.
+	const nextRetryBackoff = `LEAST(GREATEST(2*mvs.retry_backoff, INTERVAL '1 minute'), INTERVAL '1 hour')`
 	result, err := db.Exec(ctx, `
 			INSERT INTO module_version_states AS mvs (
 				module_path,
@@ -89,8 +99,12 @@ func upsertModuleVersionState(ctx context.Context, db *database.DB, modulePath,
 				status,
 				go_mod_path,
 				error,
-				num_packages)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+				num_packages,
+				retry_backoff,
+				next_processed_after)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9,
+				CASE WHEN $10 THEN INTERVAL '1 minute' ELSE INTERVAL '0' END,
+				CURRENT_TIMESTAMP + CASE WHEN $10 THEN INTERVAL '1 minute' ELSE INTERVAL '0' END)
 			ON CONFLICT (module_path, version)
 			DO UPDATE
 			SET
@@ -101,8 +115,12 @@ func upsertModuleVersionState(ctx context.Context, db *database.DB, modulePath,
 				num_packages=excluded.num_packages,
 				try_count=mvs.try_count+1,
 				last_processed_at=CURRENT_TIMESTAMP,
+				retry_backoff=CASE WHEN $10 THEN `+nextRetryBackoff+` ELSE INTERVAL '0' END,
+			    -- retry failures after retry_backoff; otherwise
 			    -- back off exponentially until 1 hour, then at constant 1-hour intervals
 				next_processed_after=CASE
+					WHEN $10 THEN
+						CURRENT_TIMESTAMP + `+nextRetryBackoff+`
 					WHEN mvs.last_processed_at IS NULL THEN
 						CURRENT_TIMESTAMP + INTERVAL '1 minute'
 					WHEN 2*(mvs.next_processed_after - mvs.last_processed_at) < INTERVAL '1 hour' THEN
@@ -111,7 +129,7 @@ func upsertModuleVersionState(ctx context.Context, db *database.DB, modulePath,
 						CURRENT_TIMESTAMP + INTERVAL '1 hour'
 					END;`,
 		modulePath, vers, version.ForSorting(vers),
-		appVersion, timestamp, status, goModPath, sqlErrorMsg, numPackages)
+		appVersion, timestamp, status, goModPath, sqlErrorMsg, numPackages, isRetryableStatus(status))
 	if err != nil {
 		return err
 	}
@@ -122,7 +140,7 @@ func upsertModuleVersionState(ctx context.Context, db *database.DB, modulePath,
 	if affected != 1 {
 		return fmt.Errorf("module version state update affected %d rows, expected exactly 1", affected)
 	}
-	return nil
+	return updateModuleCheck(ctx, db, modulePath)
 }
 
 func upsertPackageVersionStates(ctx context.Context, db *database.DB, packageVersionStates []*internal.PackageVersionState) (err error) {
@@ -265,6 +283,134 @@ func (db *DB) GetRecentVersions(ctx context.Context, limit int) (_ []*internal.M
 	return db.queryModuleVersionStates(ctx, queryFormat, limit)
 }
 
+// isRetryableStatus reports whether status, the status of a processed module
+// version, is the result of a transient failure that should be retried: a 5xx
+// server error, other than the 52x and 54x statuses that mark modules for
+// reprocessing (see derrors).
+func isRetryableStatus(status int) bool {
+	return status >= 500 && status < 520
+}
+
+// GetRetryableVersions returns up to limit module versions whose last attempt
+// at processing failed with a retryable status (see isRetryableStatus), and
+// whose next attempt is due before the given time. Versions that are due
+// soonest are returned first.
+//
+// Each retryable failure doubles the delay before the next attempt, up to an
+// hour.
+func (db *DB) GetRetryableVersions(ctx context.Context, before time.Time, limit int) (_ []*internal.ModuleVersionState, err error) {
+	defer derrors.Wrap(&err, "GetRetryableVersions(ctx, %s, %d)", before, limit)
+
+	queryFormat := `
+		SELECT %s
+		FROM
+			module_version_states
+		WHERE status >= 500 AND status < 520 -- see isRetryableStatus
+			AND next_processed_after < $1
+		ORDER BY next_processed_after, module_path, sort_version DESC
+		LIMIT $2`
+	return db.queryModuleVersionStates(ctx, queryFormat, before, limit)
+}
+
+// GetPermanentlyFailedVersions returns the versions of modulePath whose last
+// attempt at processing failed with a 4xx status, such as a version the proxy
+// does not serve or an alternative module, which fetching it again will not
+// change. They are returned in no particular order.
+func (db *DB) GetPermanentlyFailedVersions(ctx context.Context, modulePath string) (_ []string, err error) {
+	defer derrors.Wrap(&err, "GetPermanentlyFailedVersions(ctx, %q)", modulePath)
+
+	query := `
+		SELECT version
+		FROM module_version_states
+		WHERE module_path = $1 AND status >= 400 AND status < 500`
+	var versions []string
+	err = db.db.RunQuery(ctx, query, func(rows *sql.Rows) error {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return err
+		}
+		versions = append(versions, v)
+		return nil
+	}, modulePath)
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// A ModuleVersionStateFilter selects module version states for
+// ListModuleVersionStates. The zero value selects all states.
+type ModuleVersionStateFilter struct {
+	// StatusCodes and ErrorKinds select states by the result of processing.
+	// If either is non-empty, a state is selected if its status is one of
+	// StatusCodes, or its error is of one of ErrorKinds.
+	StatusCodes []int
+	// ErrorKinds are derrors values, such as derrors.AlternativeModule. A kind
+	// with its own status code (see derrors.ToHTTPStatus) selects states with
+	// that status. Other kinds, such as derrors.ProxyError, select states whose
+	// error message contains the kind's message.
+	ErrorKinds []error
+	// ModulePathPrefix, if non-empty, selects states for module paths that
+	// begin with it.
+	ModulePathPrefix string
+}
+
+// ListModuleVersionStates returns the module version states selected by
+// filter, most recently processed first, skipping offset states and returning
+// at most limit. States that have never been processed come last.
+func (db *DB) ListModuleVersionStates(ctx context.Context, filter ModuleVersionStateFilter, limit, offset int) (_ []*internal.ModuleVersionState, err error) {
+	defer derrors.Wrap(&err, "ListModuleVersionStates(ctx, %+v, %d, %d)", filter, limit, offset)
+
+	var (
+		conds []string
+		args  []interface{}
+	)
+	// arg adds v to args and returns its placeholder.
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	var (
+		statuses    = append([]int(nil), filter.StatusCodes...)
+		resultConds []string
+	)
+	for _, kind := range filter.ErrorKinds {
+		if code := derrors.ToHTTPStatus(kind); code != http.StatusInternalServerError {
+			statuses = append(statuses, code)
+		} else {
+			resultConds = append(resultConds, "error LIKE "+arg("%"+escapeLike(kind.Error())+"%"))
+		}
+	}
+	if len(statuses) > 0 {
+		resultConds = append(resultConds, "status = ANY("+arg(pq.Array(statuses))+")")
+	}
+	if len(resultConds) > 0 {
+		conds = append(conds, "("+strings.Join(resultConds, " OR ")+")")
+	}
+	if filter.ModulePathPrefix != "" {
+		conds = append(conds, "module_path LIKE "+arg(escapeLike(filter.ModulePathPrefix)+"%"))
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+	queryFormat := `
+		SELECT %s
+		FROM
+			module_version_states
+		` + where + `
+		ORDER BY last_processed_at DESC NULLS LAST, module_path, sort_version DESC
+		LIMIT ` + arg(limit) + `
+		OFFSET ` + arg(offset)
+	return db.queryModuleVersionStates(ctx, queryFormat, args...)
+}
+
+// escapeLike escapes the characters of s that are special in the pattern of
+// a LIKE expression.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
 // GetModuleVersionState returns the current module version state for
 // modulePath and version.
 func (db *DB) GetModuleVersionState(ctx context.Context, modulePath, version string) (_ *internal.ModuleVersionState, err error) {
@@ -402,3 +548,51 @@ func (db *DB) GetVersionStats(ctx context.Context) (_ *VersionStats, err error)
 	}
 	return stats, nil
 }
+
+// IndexingLag describes how far processing of module versions is behind the
+// module index.
+type IndexingLag struct {
+	// LatestIndexTimestamp is the index timestamp of the most recently
+	// indexed module version.
+	LatestIndexTimestamp time.Time
+	// LatestProcessedAt is when a module version was last processed.
+	LatestProcessedAt time.Time
+	// OldestPendingIndexTimestamp is the index timestamp of the earliest
+	// indexed module version that has not been processed.
+	OldestPendingIndexTimestamp time.Time
+	// NumPending is the number of module versions that have not been
+	// processed.
+	NumPending int
+	// NumRetryable is the number of module versions whose processing failed
+	// with a retryable status (see isRetryableStatus).
+	NumRetryable int
+}
+
+// GetIndexingLag returns the IndexingLag computed from the
+// module_version_states table. Times are zero if there is no module version
+// they could come from.
+func (db *DB) GetIndexingLag(ctx context.Context) (_ *IndexingLag, err error) {
+	defer derrors.Wrap(&err, "GetIndexingLag(ctx)")
+
+	query := `
+		SELECT
+			max(index_timestamp),
+			max(last_processed_at),
+			min(index_timestamp) FILTER (WHERE status = 0),
+			count(*) FILTER (WHERE status = 0),
+			count(*) FILTER (WHERE status >= 500 AND status < 520) -- see isRetryableStatus
+		FROM
+			module_version_states;`
+	var (
+		lag                                       IndexingLag
+		latestIndex, latestProcessed, oldestIndex pq.NullTime
+	)
+	row := db.db.QueryRow(ctx, query)
+	if err := row.Scan(&latestIndex, &latestProcessed, &oldestIndex, &lag.NumPending, &lag.NumRetryable); err != nil {
+		return nil, fmt.Errorf("row.Scan(): %v", err)
+	}
+	lag.LatestIndexTimestamp = latestIndex.Time
+	lag.LatestProcessedAt = latestProcessed.Time
+	lag.OldestPendingIndexTimestamp = oldestIndex.Time
+	return &lag, nil
+}
@@ -7,12 +7,16 @@ package postgres
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/testing/sample"
 )
 
@@ -136,3 +140,296 @@ func TestModuleVersionState(t *testing.T) {
 		t.Errorf("testDB.GetVersionStats(ctx) mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestListModuleVersionStates(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	// States are upserted in order, so later ones were processed more recently.
+	states := []struct {
+		modulePath, version string
+		status              int
+		err                 error
+	}{
+		{"example.com/a", "v1.0.0", 200, nil},
+		{"example.com/a", "v1.1.0", derrors.ToHTTPStatus(derrors.AlternativeModule), derrors.AlternativeModule},
+		{"example.com/b", "v1.0.0", 404, derrors.NotFound},
+		{"example.com/b_c", "v1.0.0", 500, fmt.Errorf("fetching: %w", derrors.ProxyError)},
+		{"example.com/bc", "v1.0.0", derrors.ToHTTPStatus(derrors.AlternativeModule), derrors.AlternativeModule},
+	}
+	for _, s := range states {
+		if err := testDB.UpsertModuleVersionState(ctx, s.modulePath, s.version, "", time.Now(), s.status, "", s.err, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unprocessed := &internal.IndexVersion{Path: "example.com/new", Version: "v1.0.0", Timestamp: time.Now()}
+	if err := testDB.InsertIndexVersions(ctx, []*internal.IndexVersion{unprocessed}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name          string
+		filter        ModuleVersionStateFilter
+		limit, offset int
+		want          []string
+	}{
+		{
+			name:   "all",
+			filter: ModuleVersionStateFilter{},
+			limit:  10,
+			want: []string{
+				"example.com/bc@v1.0.0",
+				"example.com/b_c@v1.0.0",
+				"example.com/b@v1.0.0",
+				"example.com/a@v1.1.0",
+				"example.com/a@v1.0.0",
+				"example.com/new@v1.0.0",
+			},
+		},
+		{
+			name:   "paged",
+			filter: ModuleVersionStateFilter{},
+			limit:  2,
+			offset: 2,
+			want:   []string{"example.com/b@v1.0.0", "example.com/a@v1.1.0"},
+		},
+		{
+			name:   "status codes",
+			filter: ModuleVersionStateFilter{StatusCodes: []int{200, 404}},
+			limit:  10,
+			want:   []string{"example.com/b@v1.0.0", "example.com/a@v1.0.0"},
+		},
+		{
+			name:   "error kind with status",
+			filter: ModuleVersionStateFilter{ErrorKinds: []error{derrors.AlternativeModule}},
+			limit:  10,
+			want:   []string{"example.com/bc@v1.0.0", "example.com/a@v1.1.0"},
+		},
+		{
+			name:   "error kind without status",
+			filter: ModuleVersionStateFilter{ErrorKinds: []error{derrors.ProxyError}},
+			limit:  10,
+			want:   []string{"example.com/b_c@v1.0.0"},
+		},
+		{
+			name: "status codes or error kinds",
+			filter: ModuleVersionStateFilter{
+				StatusCodes: []int{404},
+				ErrorKinds:  []error{derrors.ProxyError},
+			},
+			limit: 10,
+			want:  []string{"example.com/b_c@v1.0.0", "example.com/b@v1.0.0"},
+		},
+		{
+			name: "prefix",
+			// The underscore must match only itself.
+			filter: ModuleVersionStateFilter{ModulePathPrefix: "example.com/b_"},
+			limit:  10,
+			want:   []string{"example.com/b_c@v1.0.0"},
+		},
+		{
+			name: "prefix and error kind",
+			filter: ModuleVersionStateFilter{
+				ModulePathPrefix: "example.com/b",
+				ErrorKinds:       []error{derrors.AlternativeModule},
+			},
+			limit: 10,
+			want:  []string{"example.com/bc@v1.0.0"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := testDB.ListModuleVersionStates(ctx, test.filter, test.limit, test.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotIDs []string
+			for _, s := range got {
+				gotIDs = append(gotIDs, s.ModulePath+"@"+s.Version)
+			}
+			if diff := cmp.Diff(test.want, gotIDs); diff != "" {
+				t.Errorf("ListModuleVersionStates(ctx, %+v, %d, %d) mismatch (-want +got):\n%s", test.filter, test.limit, test.offset, diff)
+			}
+		})
+	}
+}
+
+func TestGetRetryableVersions(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	upsert := func(modulePath string, status int) {
+		t.Helper()
+		if err := testDB.UpsertModuleVersionState(ctx, modulePath, "v1.0.0", "", time.Now(), status, "",
+			derrors.FromHTTPStatus(status, "test"), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkBackoff := func(modulePath string, want time.Duration) {
+		t.Helper()
+		var secs float64
+		if err := testDB.db.QueryRow(ctx,
+			`SELECT EXTRACT(EPOCH FROM retry_backoff) FROM module_version_states WHERE module_path = $1`,
+			modulePath).Scan(&secs); err != nil {
+			t.Fatal(err)
+		}
+		if got := time.Duration(secs) * time.Second; got != want {
+			t.Errorf("%s: retry_backoff = %s, want %s", modulePath, got, want)
+		}
+	}
+	checkRetryable := func(before time.Time, want ...string) {
+		t.Helper()
+		got, err := testDB.GetRetryableVersions(ctx, before, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var gotPaths []string
+		for _, s := range got {
+			gotPaths = append(gotPaths, s.ModulePath)
+		}
+		if diff := cmp.Diff(want, gotPaths); diff != "" {
+			t.Errorf("GetRetryableVersions(ctx, %s, 10) mismatch (-want +got):\n%s", before, diff)
+		}
+	}
+
+	for _, s := range []struct {
+		modulePath string
+		status     int
+	}{
+		{"example.com/ok", http.StatusOK},
+		{"example.com/notfound", http.StatusNotFound},
+		{"example.com/alternative", derrors.ToHTTPStatus(derrors.AlternativeModule)},
+		{"example.com/reprocess", derrors.ToHTTPStatus(derrors.ReprocessStatusOK)},
+		{"example.com/failed", http.StatusInternalServerError},
+		{"example.com/unavailable", http.StatusServiceUnavailable},
+	} {
+		upsert(s.modulePath, s.status)
+	}
+	checkBackoff("example.com/ok", 0)
+	checkBackoff("example.com/failed", time.Minute)
+	// Failed versions are not due for a minute.
+	checkRetryable(time.Now())
+	checkRetryable(time.Now().Add(2*time.Minute), "example.com/failed", "example.com/unavailable")
+
+	// Each failure doubles the backoff, which makes the next attempt due later.
+	upsert("example.com/failed", http.StatusInternalServerError)
+	checkBackoff("example.com/failed", 2*time.Minute)
+	checkRetryable(time.Now().Add(90*time.Second), "example.com/unavailable")
+	for i := 0; i < 10; i++ {
+		upsert("example.com/failed", http.StatusInternalServerError)
+	}
+	checkBackoff("example.com/failed", time.Hour)
+
+	// A success resets the backoff.
+	upsert("example.com/failed", http.StatusOK)
+	checkBackoff("example.com/failed", 0)
+	checkRetryable(time.Now().Add(2*time.Hour), "example.com/unavailable")
+}
+
+func TestGetIndexingLag(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	got, err := testDB.GetIndexingLag(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&IndexingLag{}, got); diff != "" {
+		t.Errorf("empty table: mismatch (-want +got):\n%s", diff)
+	}
+
+	start := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	var versions []*internal.IndexVersion
+	for i, path := range []string{"example.com/a", "example.com/b", "example.com/c", "example.com/d"} {
+		versions = append(versions, &internal.IndexVersion{Path: path, Version: "v1.0.0", Timestamp: start.Add(time.Duration(i) * time.Hour)})
+	}
+	if err := testDB.InsertIndexVersions(ctx, versions); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []struct {
+		index  int
+		status int
+	}{
+		{0, http.StatusOK},
+		{3, http.StatusInternalServerError},
+	} {
+		iv := versions[v.index]
+		if err := testDB.UpsertModuleVersionState(ctx, iv.Path, iv.Version, "", iv.Timestamp, v.status, "",
+			derrors.FromHTTPStatus(v.status, "test"), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err = testDB.GetIndexingLag(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.LatestIndexTimestamp.Equal(versions[3].Timestamp) {
+		t.Errorf("LatestIndexTimestamp = %s, want %s", got.LatestIndexTimestamp, versions[3].Timestamp)
+	}
+	if !got.OldestPendingIndexTimestamp.Equal(versions[1].Timestamp) {
+		t.Errorf("OldestPendingIndexTimestamp = %s, want %s", got.OldestPendingIndexTimestamp, versions[1].Timestamp)
+	}
+	if got.LatestProcessedAt.IsZero() {
+		t.Error("LatestProcessedAt is zero, want the time of the last upsert")
+	}
+	if got.NumPending != 2 || got.NumRetryable != 1 {
+		t.Errorf("NumPending, NumRetryable = %d, %d; want 2, 1", got.NumPending, got.NumRetryable)
+	}
+}
+
+func TestPackagesKnown(t *testing.T) {
+	for _, test := range []struct {
+		status int
+		want   bool
+	}{
+		{http.StatusOK, true},
+		{http.StatusBadRequest, false},
+		{http.StatusNotFound, false},
+		{http.StatusGone, false},
+		{http.StatusInternalServerError, true},
+		{derrors.ToHTTPStatus(derrors.AlternativeModule), true},
+	} {
+		if got := packagesKnown(test.status); got != test.want {
+			t.Errorf("packagesKnown(%d) = %t, want %t", test.status, got, test.want)
+		}
+	}
+}
+
+func TestGetPermanentlyFailedVersions(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	const modulePath = "example.com/m"
+	for _, s := range []struct {
+		modulePath, version string
+		status              int
+	}{
+		{modulePath, "v1.0.0", http.StatusOK},
+		{modulePath, "v1.1.0", http.StatusNotFound},
+		{modulePath, "v1.2.0", derrors.ToHTTPStatus(derrors.AlternativeModule)},
+		{modulePath, "v1.3.0", http.StatusInternalServerError},
+		{modulePath, "v1.4.0", derrors.ToHTTPStatus(derrors.ReprocessStatusOK)},
+		{"example.com/other", "v1.1.0", http.StatusNotFound},
+	} {
+		if err := testDB.UpsertModuleVersionState(ctx, s.modulePath, s.version, "", time.Now(), s.status, "",
+			derrors.FromHTTPStatus(s.status, "test"), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := testDB.GetPermanentlyFailedVersions(ctx, modulePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	if diff := cmp.Diff([]string{"v1.1.0", "v1.2.0"}, got); diff != "" {
+		t.Errorf("GetPermanentlyFailedVersions mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// SetInsertHook arranges for hook to be called with the module version after
+// each successful call to InsertModule that inserts a module version that was
+// not already in the database. Reprocessing a module version does not call
+// hook. The hook runs before InsertModule returns, so it should not block for
+// long.
+//
+// SetInsertHook must be called before db is used.
+func (db *DB) SetInsertHook(hook func(context.Context, *internal.ModuleInfo)) {
+	db.insertHook = hook
+}
+
+// InsertWebhookSubscription adds a subscription for the module versions
+// matching pattern to be sent to callbackURL, signed with secret, and returns
+// its ID. If there is already a subscription for pattern and callbackURL, its
+// secret is replaced.
+func (db *DB) InsertWebhookSubscription(ctx context.Context, pattern, callbackURL, secret string) (id int, err error) {
+	defer derrors.Wrap(&err, "DB.InsertWebhookSubscription(ctx, %q, %q)", pattern, callbackURL)
+
+	query := `
+		INSERT INTO webhook_subscriptions (module_path_pattern, callback_url, secret)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (module_path_pattern, callback_url)
+		DO UPDATE SET secret = excluded.secret
+		RETURNING id`
+	if err := db.db.QueryRow(ctx, query, pattern, callbackURL, secret).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// DeleteWebhookSubscription deletes the subscription with the given ID.
+func (db *DB) DeleteWebhookSubscription(ctx context.Context, id int) (err error) {
+	defer derrors.Wrap(&err, "DB.DeleteWebhookSubscription(ctx, %d)", id)
+
+	res, err := db.db.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("webhook subscription %d: %w", id, derrors.NotFound)
+	}
+	return nil
+}
+
+// GetWebhookSubscriptions returns all webhook subscriptions, in the order in
+// which they were added.
+func (db *DB) GetWebhookSubscriptions(ctx context.Context) (_ []*internal.WebhookSubscription, err error) {
+	defer derrors.Wrap(&err, "DB.GetWebhookSubscriptions(ctx)")
+
+	query := `
+		SELECT id, module_path_pattern, callback_url, secret, created_at
+		FROM webhook_subscriptions
+		ORDER BY id`
+	var subs []*internal.WebhookSubscription
+	collect := func(rows *sql.Rows) error {
+		var s internal.WebhookSubscription
+		if err := rows.Scan(&s.ID, &s.ModulePathPattern, &s.CallbackURL, &s.Secret, &s.CreatedAt); err != nil {
+			return err
+		}
+		subs = append(subs, &s)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
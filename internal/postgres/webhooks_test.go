@@ -0,0 +1,93 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestWebhookSubscriptions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	id1, err := testDB.InsertWebhookSubscription(ctx, "example.com/a/...", "https://example.org/hook", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id2, err := testDB.InsertWebhookSubscription(ctx, "example.com/b", "https://example.org/hook", "s2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Subscribing again replaces the secret.
+	id, err := testDB.InsertWebhookSubscription(ctx, "example.com/a/...", "https://example.org/hook", "s3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != id1 {
+		t.Errorf("resubscribing: got ID %d, want %d", id, id1)
+	}
+
+	got, err := testDB.GetWebhookSubscriptions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*internal.WebhookSubscription{
+		{ID: id1, ModulePathPattern: "example.com/a/...", CallbackURL: "https://example.org/hook", Secret: "s3"},
+		{ID: id2, ModulePathPattern: "example.com/b", CallbackURL: "https://example.org/hook", Secret: "s2"},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(internal.WebhookSubscription{}, "CreatedAt")); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := testDB.DeleteWebhookSubscription(ctx, id1); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.DeleteWebhookSubscription(ctx, id1); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("deleting twice: got error %v, want NotFound", err)
+	}
+	got, err = testDB.GetWebhookSubscriptions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want[1:], got, cmpopts.IgnoreFields(internal.WebhookSubscription{}, "CreatedAt")); diff != "" {
+		t.Errorf("after delete: mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestInsertHook(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	db := New(testDB.db)
+	var inserted []string
+	db.SetInsertHook(func(_ context.Context, mi *internal.ModuleInfo) {
+		inserted = append(inserted, mi.ModulePath+"@"+mi.Version)
+	})
+	if err := db.InsertModule(ctx, sample.Module(sample.ModulePath, sample.VersionString, "foo")); err != nil {
+		t.Fatal(err)
+	}
+	// A failed insert does not call the hook.
+	if err := db.InsertModule(ctx, nil); err == nil {
+		t.Fatal("InsertModule(nil): got nil error")
+	}
+	// Neither does reprocessing a module version.
+	if err := db.InsertModule(ctx, sample.Module(sample.ModulePath, sample.VersionString, "foo")); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{sample.ModulePath + "@" + sample.VersionString}
+	if diff := cmp.Diff(want, inserted); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
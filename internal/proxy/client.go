@@ -29,32 +29,91 @@ import (
 // A Client is used by the fetch service to communicate with a module
 // proxy. It handles all methods defined by go help goproxy.
 type Client struct {
-	// URL of the module proxy web server
-	url string
+	// The module proxies to try, in order.
+	proxies []proxyEntry
 
 	// client used for HTTP requests. It is mutable for testing purposes.
 	httpClient *http.Client
 }
 
+// A proxyEntry is an element of a GOPROXY list.
+type proxyEntry struct {
+	// url is the URL of the module proxy web server, or one of the keywords
+	// "direct" and "off".
+	url string
+	// fallBackOnError reports whether the next entry is tried after any
+	// error from this one, rather than only after a "not found" response.
+	// It is true when this entry is followed by a pipe rather than a comma.
+	fallBackOnError bool
+}
+
 // A VersionInfo contains metadata about a given version of a module.
 type VersionInfo struct {
 	Version string
 	Time    time.Time
 }
 
-// New constructs a *Client using the provided rawurl, which is expected to
-// be an absolute URI that can be directly passed to http.Get.
-func New(rawurl string) (_ *Client, err error) {
-	defer derrors.Wrap(&err, "proxy.New(%q)", rawurl)
-	url, err := url.Parse(rawurl)
+// New constructs a *Client using the provided goproxy, which is a list of
+// module proxies with the syntax of the GOPROXY environment variable (see go
+// help goproxy). Each proxy is an absolute https URL that can be directly
+// passed to http.Get. The simplest list is a single URL.
+//
+// Proxies are tried in order. If a proxy is followed by a comma, the next one
+// is tried only if it responds with 404 Not Found or 410 Gone; if it is
+// followed by a pipe, the next one is tried after any error. The keywords
+// "direct" and "off" end the list: the Client cannot download modules
+// directly from version control systems.
+//
+// An error returned by a Client method wraps derrors.NotFound if every proxy
+// that was tried reported that the module or version does not exist, and
+// derrors.ProxyError if the request could not be served for another reason,
+// such as a proxy being unreachable. A 410 Gone response is reported as
+// derrors.Gone, which is also a derrors.NotFound.
+func New(goproxy string) (_ *Client, err error) {
+	defer derrors.Wrap(&err, "proxy.New(%q)", goproxy)
+	proxies, err := parseGOPROXY(goproxy)
 	if err != nil {
-		return nil, fmt.Errorf("url.Parse: %v", err)
+		return nil, err
 	}
-	if url.Scheme != "https" {
-		return nil, fmt.Errorf("scheme must be https (got %s)", url.Scheme)
+	return &Client{proxies: proxies, httpClient: &http.Client{Transport: &ochttp.Transport{}}}, nil
+}
+
+// parseGOPROXY parses a GOPROXY list.
+func parseGOPROXY(goproxy string) ([]proxyEntry, error) {
+	var proxies []proxyEntry
+	for goproxy != "" {
+		var (
+			rawurl          string
+			fallBackOnError bool
+		)
+		if i := strings.IndexAny(goproxy, ",|"); i >= 0 {
+			rawurl = goproxy[:i]
+			fallBackOnError = goproxy[i] == '|'
+			goproxy = goproxy[i+1:]
+		} else {
+			rawurl, goproxy = goproxy, ""
+		}
+		rawurl = strings.TrimSpace(rawurl)
+		switch rawurl {
+		case "":
+			continue
+		case "direct", "off":
+			proxies = append(proxies, proxyEntry{url: rawurl})
+			continue
+		}
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			return nil, fmt.Errorf("url.Parse: %v", err)
+		}
+		if u.Scheme != "https" {
+			return nil, fmt.Errorf("scheme must be https (got %s)", u.Scheme)
+		}
+		proxies = append(proxies, proxyEntry{url: strings.TrimRight(rawurl, "/"), fallBackOnError: fallBackOnError})
+	}
+	if len(proxies) == 0 {
+		return nil, errors.New("no module proxies")
 	}
-	cleanURL := strings.TrimRight(rawurl, "/")
-	return &Client{url: cleanURL, httpClient: &http.Client{Transport: &ochttp.Transport{}}}, nil
+	return proxies, nil
 }
 
 // GetInfo makes a request to $GOPROXY/<module>/@v/<requestedVersion>.info and
@@ -100,6 +159,8 @@ func (c *Client) GetZip(ctx context.Context, requestedPath, requestedVersion str
 	return zipReader, nil
 }
 
+// escapedURL returns the path of the proxy endpoint for the given module
+// path, version and suffix, relative to the proxy URL.
 func (c *Client) escapedURL(modulePath, version, suffix string) (_ string, err error) {
 	defer func() {
 		derrors.Wrap(&err, "Client.escapedURL(%q, %q, %q)", modulePath, version, suffix)
@@ -116,13 +177,13 @@ func (c *Client) escapedURL(modulePath, version, suffix string) (_ string, err e
 		if suffix != "info" {
 			return "", fmt.Errorf("cannot ask for latest with suffix %q", suffix)
 		}
-		return fmt.Sprintf("%s/%s/@latest", c.url, escapedPath), nil
+		return fmt.Sprintf("/%s/@latest", escapedPath), nil
 	}
 	escapedVersion, err := module.EscapeVersion(version)
 	if err != nil {
 		return "", fmt.Errorf("version: %v: %w", err, derrors.InvalidArgument)
 	}
-	return fmt.Sprintf("%s/%s/@v/%s.%s", c.url, escapedPath, escapedVersion, suffix), nil
+	return fmt.Sprintf("/%s/@v/%s.%s", escapedPath, escapedVersion, suffix), nil
 }
 
 func (c *Client) readBody(ctx context.Context, modulePath, version, suffix string) (_ []byte, err error) {
@@ -151,7 +212,7 @@ func (c *Client) ListVersions(ctx context.Context, modulePath string) ([]string,
 	if err != nil {
 		return nil, fmt.Errorf("module.EscapePath(%q): %w", modulePath, derrors.InvalidArgument)
 	}
-	u := fmt.Sprintf("%s/%s/@v/list", c.url, escapedPath)
+	u := fmt.Sprintf("/%s/@v/list", escapedPath)
 	var versions []string
 	collect := func(body io.Reader) error {
 		scanner := bufio.NewScanner(body)
@@ -166,24 +227,72 @@ func (c *Client) ListVersions(ctx context.Context, modulePath string) ([]string,
 	return versions, nil
 }
 
-// executeRequest executes an HTTP GET request for u, then calls the bodyFunc
-// on the response body, if no error occurred.
-func (c *Client) executeRequest(ctx context.Context, u string, bodyFunc func(body io.Reader) error) error {
+// executeRequest executes an HTTP GET request for urlPath on each of the
+// client's proxies in turn, as described at New, then calls the bodyFunc on
+// the first successful response body.
+func (c *Client) executeRequest(ctx context.Context, urlPath string, bodyFunc func(body io.Reader) error) error {
+	var lastErr error
+	for _, p := range c.proxies {
+		switch p.url {
+		case "direct", "off":
+			if lastErr != nil {
+				return lastErr
+			}
+			return fmt.Errorf("GOPROXY=%s: module proxy lookup disabled: %w", p.url, derrors.ProxyError)
+		}
+		err := c.executeRequestOnProxy(ctx, p.url+urlPath, bodyFunc)
+		if err == nil {
+			return nil
+		}
+		// The module does not exist only if every proxy tried says so.
+		if lastErr == nil || errorRank(err) > errorRank(lastErr) {
+			lastErr = err
+		}
+		if !p.fallBackOnError && !errors.Is(err, derrors.NotFound) {
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+// errorRank orders the errors from different proxies for the same request, so
+// that executeRequest can return the most significant one: any failure
+// outranks a module having been removed, which outranks a module never
+// having existed.
+func errorRank(err error) int {
+	switch {
+	case errors.Is(err, derrors.Gone):
+		return 1
+	case errors.Is(err, derrors.NotFound):
+		return 0
+	default:
+		return 2
+	}
+}
+
+// executeRequestOnProxy executes an HTTP GET request for u, then calls the
+// bodyFunc on the response body, if no error occurred.
+func (c *Client) executeRequestOnProxy(ctx context.Context, u string, bodyFunc func(body io.Reader) error) error {
 	r, err := ctxhttp.Get(ctx, c.httpClient, u)
 	if err != nil {
-		return fmt.Errorf("ctxhttp.Get(ctx, client, %q): %v", u, err)
+		return fmt.Errorf("ctxhttp.Get(ctx, client, %q): %v: %w", u, err, derrors.ProxyError)
 	}
 	defer r.Body.Close()
 	switch {
 	case 200 <= r.StatusCode && r.StatusCode < 300:
 		// OK.
-	case r.StatusCode == http.StatusNotFound,
-		r.StatusCode == http.StatusGone:
-		// Treat both 404 Not Found and 410 Gone responses
-		// from the proxy as a "not found" error category.
+	case r.StatusCode == http.StatusNotFound:
 		return fmt.Errorf("ctxhttp.Get(ctx, client, %q): %w", u, derrors.NotFound)
+	case r.StatusCode == http.StatusGone:
+		// The proxy serves 410 Gone for module versions that it no longer
+		// serves, or that do not exist in the origin. derrors.Gone is also a
+		// "not found" error.
+		return fmt.Errorf("ctxhttp.Get(ctx, client, %q): %w", u, derrors.Gone)
 	default:
-		return fmt.Errorf("ctxhttp.Get(ctx, client, %q): unexpected status %d %s", u, r.StatusCode, r.Status)
+		return fmt.Errorf("ctxhttp.Get(ctx, client, %q): unexpected status %d %s: %w", u, r.StatusCode, r.Status, derrors.ProxyError)
+	}
+	if err := bodyFunc(r.Body); err != nil {
+		return fmt.Errorf("reading response for %q: %v: %w", u, err, derrors.ProxyError)
 	}
-	return bodyFunc(r.Body)
+	return nil
 }
@@ -7,6 +7,8 @@ package proxy
 import (
 	"context"
 	"errors"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -244,14 +246,14 @@ func TestGetZipNonExist(t *testing.T) {
 }
 
 func TestEncodedURL(t *testing.T) {
-	c := &Client{url: "u"}
+	c := &Client{}
 	for _, test := range []struct {
 		path, version, suffix string
 		want                  string // empty => error
 	}{
 		{
 			"mod.com", "v1.0.0", "info",
-			"u/mod.com/@v/v1.0.0.info",
+			"/mod.com/@v/v1.0.0.info",
 		},
 		{
 			"mod", "v1.0.0", "info",
@@ -259,11 +261,11 @@ func TestEncodedURL(t *testing.T) {
 		},
 		{
 			"mod.com", "v1.0.0-rc1", "info",
-			"u/mod.com/@v/v1.0.0-rc1.info",
+			"/mod.com/@v/v1.0.0-rc1.info",
 		},
 		{
 			"mod.com/Foo", "v1.0.0-RC1", "info",
-			"u/mod.com/!foo/@v/v1.0.0-!r!c1.info",
+			"/mod.com/!foo/@v/v1.0.0-!r!c1.info",
 		},
 		{
 			"mod.com", ".", "info",
@@ -271,7 +273,7 @@ func TestEncodedURL(t *testing.T) {
 		},
 		{
 			"mod.com", "v1.0.0", "zip",
-			"u/mod.com/@v/v1.0.0.zip",
+			"/mod.com/@v/v1.0.0.zip",
 		},
 		{
 			"mod", "v1.0.0", "zip",
@@ -279,11 +281,11 @@ func TestEncodedURL(t *testing.T) {
 		},
 		{
 			"mod.com", "v1.0.0-rc1", "zip",
-			"u/mod.com/@v/v1.0.0-rc1.zip",
+			"/mod.com/@v/v1.0.0-rc1.zip",
 		},
 		{
 			"mod.com/Foo", "v1.0.0-RC1", "zip",
-			"u/mod.com/!foo/@v/v1.0.0-!r!c1.zip",
+			"/mod.com/!foo/@v/v1.0.0-!r!c1.zip",
 		},
 		{
 			"mod.com", ".", "zip",
@@ -291,7 +293,7 @@ func TestEncodedURL(t *testing.T) {
 		},
 		{
 			"mod.com", internal.LatestVersion, "info",
-			"u/mod.com/@latest",
+			"/mod.com/@latest",
 		},
 		{
 			"mod.com", internal.LatestVersion, "zip",
@@ -308,3 +310,127 @@ func TestEncodedURL(t *testing.T) {
 		}
 	}
 }
+
+func TestMixedCaseModulePath(t *testing.T) {
+	// Uppercase letters in module paths and versions are escaped with '!' in the
+	// proxy protocol. Requests use the escaped forms, and the client returns
+	// the unescaped ones.
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	const (
+		path    = "github.com/Mixed/Case"
+		version = "v1.0.0-RC1"
+	)
+	client, teardownProxy := SetupTestProxy(t, []*TestModule{
+		{
+			ModulePath: path,
+			Version:    version,
+			Files:      map[string]string{"case.go": "package mixed"},
+		},
+	})
+	defer teardownProxy()
+
+	versions, err := client.ListVersions(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{version}, versions); diff != "" {
+		t.Errorf("ListVersions(ctx, %q) mismatch (-want +got):\n%s", path, diff)
+	}
+	info, err := client.GetInfo(ctx, path, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != version {
+		t.Errorf("GetInfo(ctx, %q, %q).Version = %q, want %q", path, version, info.Version, version)
+	}
+	zr, err := client.GetZip(ctx, path, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := path + "@" + version + "/case.go"
+	found := false
+	for _, f := range zr.File {
+		if f.Name == want {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("GetZip(ctx, %q, %q): no file %q", path, version, want)
+	}
+}
+
+func TestGOPROXYList(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	// One server plays several proxies, distinguished by URL path prefix.
+	mux := http.NewServeMux()
+	mux.Handle("/good/", http.StripPrefix("/good", TestProxy([]*TestModule{cleanTestModule(t, sampleModule)})))
+	mux.HandleFunc("/notfound/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+	mux.HandleFunc("/gone/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	})
+	mux.HandleFunc("/broken/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "oops", http.StatusInternalServerError)
+	})
+	httpClient, server, serverClose := testhelper.SetupTestClientAndServer(mux)
+	defer serverClose()
+	toURLs := strings.NewReplacer(
+		"good", server.URL+"/good",
+		"notfound", server.URL+"/notfound",
+		"gone", server.URL+"/gone",
+		"broken", server.URL+"/broken")
+
+	for _, test := range []struct {
+		goproxy string
+		wantErr error // nil for success
+	}{
+		{"good", nil},
+		{"notfound,good", nil},
+		{"broken|good", nil},
+		{"notfound|broken|good", nil},
+		{"broken,good", derrors.ProxyError},
+		{"notfound", derrors.NotFound},
+		{"notfound,notfound", derrors.NotFound},
+		{"notfound,direct", derrors.NotFound},
+		{"gone", derrors.Gone},
+		{"gone,good", nil},
+		{"notfound,gone", derrors.Gone},
+		{"gone,notfound", derrors.Gone},
+		{"gone,broken", derrors.ProxyError},
+		{"notfound,broken", derrors.ProxyError},
+		{"broken|notfound", derrors.ProxyError},
+		{"off", derrors.ProxyError},
+		{"direct,good", derrors.ProxyError},
+	} {
+		t.Run(test.goproxy, func(t *testing.T) {
+			client, err := New(toURLs.Replace(test.goproxy))
+			if err != nil {
+				t.Fatal(err)
+			}
+			client.httpClient = httpClient
+			_, err = client.GetInfo(ctx, sampleModule.ModulePath, sampleModule.Version)
+			if test.wantErr == nil {
+				if err != nil {
+					t.Errorf("got error %v, want nil", err)
+				}
+			} else if !errors.Is(err, test.wantErr) {
+				t.Errorf("got error %v, want %v", err, test.wantErr)
+			} else if test.wantErr == derrors.NotFound && errors.Is(err, derrors.Gone) {
+				t.Errorf("got error %v, want one that is not %v", err, derrors.Gone)
+			}
+		})
+	}
+}
+
+func TestNewInvalidGOPROXY(t *testing.T) {
+	for _, goproxy := range []string{"", ",", "http://proxy.golang.org", "https://proxy.golang.org,::"} {
+		if _, err := New(goproxy); err == nil {
+			t.Errorf("New(%q): got nil error, want error", goproxy)
+		}
+	}
+}
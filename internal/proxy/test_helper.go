@@ -14,6 +14,7 @@ import (
 	"testing"
 	"time"
 
+	"golang.org/x/mod/module"
 	"golang.org/x/mod/semver"
 	"golang.org/x/pkgsite/internal/testing/testhelper"
 )
@@ -74,6 +75,18 @@ func TestProxy(modules []*TestModule) *http.ServeMux {
 		byModule[m.ModulePath] = append(byModule[m.ModulePath], m)
 	}
 
+	// The proxy protocol escapes uppercase letters in module paths and
+	// versions, so the handlers must be registered at the escaped paths.
+	// Paths that cannot be escaped, like "std", which is not a valid module
+	// path, are registered as they are.
+	escape := func(escapeFunc func(string) (string, error), s string) string {
+		e, err := escapeFunc(s)
+		if err != nil {
+			return s
+		}
+		return e
+	}
+
 	mux := http.NewServeMux()
 	for modPath, modVersions := range byModule {
 		sort.Slice(modVersions, func(i, j int) bool {
@@ -93,13 +106,15 @@ func TestProxy(modules []*TestModule) *http.ServeMux {
 			// latest version according to semver.
 			return modVersions[len(modVersions)-1].Version
 		}
-		handle(fmt.Sprintf("/%s/@v/list", modPath), strings.NewReader(versionList(modVersions)))
-		handle(fmt.Sprintf("/%s/@latest", modPath), strings.NewReader(defaultInfo(latest(modVersions))))
-		handle(fmt.Sprintf("/%s/@v/master.info", modPath), strings.NewReader(defaultInfo(master(modVersions))))
+		escapedPath := escape(module.EscapePath, modPath)
+		handle(fmt.Sprintf("/%s/@v/list", escapedPath), strings.NewReader(versionList(modVersions)))
+		handle(fmt.Sprintf("/%s/@latest", escapedPath), strings.NewReader(defaultInfo(latest(modVersions))))
+		handle(fmt.Sprintf("/%s/@v/master.info", escapedPath), strings.NewReader(defaultInfo(master(modVersions))))
 		for _, m := range modVersions {
-			handle(fmt.Sprintf("/%s/@v/%s.info", m.ModulePath, m.Version), strings.NewReader(defaultInfo(m.Version)))
-			handle(fmt.Sprintf("/%s/@v/%s.mod", m.ModulePath, m.Version), strings.NewReader(goMod(m)))
-			handle(fmt.Sprintf("/%s/@v/%s.zip", m.ModulePath, m.Version), bytes.NewReader(m.zip))
+			escapedVersion := escape(module.EscapeVersion, m.Version)
+			handle(fmt.Sprintf("/%s/@v/%s.info", escapedPath, escapedVersion), strings.NewReader(defaultInfo(m.Version)))
+			handle(fmt.Sprintf("/%s/@v/%s.mod", escapedPath, escapedVersion), strings.NewReader(goMod(m)))
+			handle(fmt.Sprintf("/%s/@v/%s.zip", escapedPath, escapedVersion), bytes.NewReader(m.zip))
 		}
 	}
 	return mux
@@ -96,6 +96,14 @@ func (ds *DataSource) GetDirectoryNew(ctx context.Context, dirPath, modulePath,
 	if err != nil {
 		return nil, err
 	}
+	for _, d := range m.Directories {
+		if d.Path == dirPath {
+			return &internal.VersionedDirectory{
+				ModuleInfo:   m.ModuleInfo,
+				DirectoryNew: *d,
+			}, nil
+		}
+	}
 	return &internal.VersionedDirectory{
 		ModuleInfo: m.ModuleInfo,
 		DirectoryNew: internal.DirectoryNew{
@@ -67,14 +67,16 @@ var (
 		VersionType:       version.TypeRelease,
 		IsRedistributable: true,
 		HasGoMod:          true,
+		LicenseExpression: "MIT",
 	}
 	wantVersionedPackage = &internal.LegacyVersionedPackage{
 		LegacyModuleInfo: internal.LegacyModuleInfo{ModuleInfo: wantModuleInfo},
 		LegacyPackage:    wantPackage,
 	}
 	cmpOpts = append([]cmp.Option{
-		cmpopts.IgnoreFields(internal.LegacyPackage{}, "DocumentationHTML"),
+		cmpopts.IgnoreFields(internal.LegacyPackage{}, "DocumentationHTML", "DocumentationText"),
 		cmpopts.IgnoreFields(licenses.License{}, "Contents"),
+		cmpopts.IgnoreFields(licenses.Metadata{}, "Copyrights"),
 	}, sample.LicenseCmpOpts...)
 )
 
@@ -196,7 +198,7 @@ func TestDataSource_GetTaggedVersionsForModule(t *testing.T) {
 		&wantModuleInfo,
 		&v110,
 	}
-	ignore := cmpopts.IgnoreFields(internal.ModuleInfo{}, "CommitTime", "VersionType", "IsRedistributable", "HasGoMod")
+	ignore := cmpopts.IgnoreFields(internal.ModuleInfo{}, "CommitTime", "VersionType", "IsRedistributable", "HasGoMod", "LicenseExpression")
 	if diff := cmp.Diff(want, got, ignore); diff != "" {
 		t.Errorf("GetTaggedVersionsForPackageSeries diff (-want +got):\n%s", diff)
 	}
@@ -220,7 +222,7 @@ func TestDataSource_GetTaggedVersionsForPackageSeries(t *testing.T) {
 		&wantModuleInfo,
 		&v110,
 	}
-	ignore := cmpopts.IgnoreFields(internal.ModuleInfo{}, "CommitTime", "VersionType", "IsRedistributable", "HasGoMod")
+	ignore := cmpopts.IgnoreFields(internal.ModuleInfo{}, "CommitTime", "VersionType", "IsRedistributable", "HasGoMod", "LicenseExpression")
 	if diff := cmp.Diff(want, got, ignore); diff != "" {
 		t.Errorf("GetTaggedVersionsForPackageSeries diff (-want +got):\n%s", diff)
 	}
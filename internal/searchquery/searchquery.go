@@ -0,0 +1,207 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package searchquery validates search queries before they are run against the
+// database, for every server that accepts them: the search page and API of
+// the frontend, and the gRPC API.
+package searchquery
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/fetch"
+)
+
+// DefaultMaxLength is the default maximum number of characters in a search
+// query. It is much longer than any useful query, and keeps the work of
+// parsing a query bounded.
+const DefaultMaxLength = 1000
+
+// Kind is the reason that a search query is invalid.
+type Kind int
+
+const (
+	// TooLong is the kind of a query with more characters than allowed.
+	TooLong Kind = iota + 1
+	// InvalidSignature is the kind of a query with a signature qualifier that
+	// is not followed by a function type.
+	InvalidSignature
+	// InvalidOperators is the kind of a query that uses search operators
+	// incorrectly.
+	InvalidOperators
+)
+
+// An Error describes an invalid search query. It matches
+// derrors.InvalidArgument with errors.Is.
+type Error struct {
+	Kind  Kind
+	Query string
+	// MaxLength is the maximum number of characters, for TooLong.
+	MaxLength int
+	// Signature is the signature after the qualifier, for InvalidSignature.
+	Signature string
+	// Err describes what is wrong with the query.
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Kind == TooLong {
+		// Don't repeat a long query.
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("search query %q: %v", e.Query, e.Err)
+}
+
+// Is reports whether target is derrors.InvalidArgument.
+func (e *Error) Is(target error) bool {
+	return target == derrors.InvalidArgument
+}
+
+// Check returns an *Error if query is longer than maxLength characters, if it
+// has a signature qualifier that is not followed by a function type (see
+// SplitSignature), or if it uses search operators incorrectly (see
+// checkOperators).
+func Check(query string, maxLength int) error {
+	if n := utf8.RuneCountInString(query); n > maxLength {
+		return &Error{
+			Kind:      TooLong,
+			Query:     query,
+			MaxLength: maxLength,
+			Err:       fmt.Errorf("search query has %d characters; the maximum is %d", n, maxLength),
+		}
+	}
+	text, sig, hasSignature := SplitSignature(query)
+	if hasSignature {
+		if _, err := fetch.NormalizeSignature(sig); err != nil {
+			return &Error{Kind: InvalidSignature, Query: query, Signature: sig, Err: err}
+		}
+		if text == "" {
+			// A signature alone is a valid query.
+			return nil
+		}
+	}
+	if err := checkOperators(text); err != nil {
+		return &Error{Kind: InvalidOperators, Query: query, Err: err}
+	}
+	return nil
+}
+
+// signatureQualifier introduces the signature of a function in a search
+// query, restricting the results to packages with an exported function or
+// method of that type, as in "json signature:func([]byte) error".
+const signatureQualifier = "signature:"
+
+// SplitSignature splits query into the text before its signature qualifier
+// and the signature after it, which extends to the end of the query. The
+// qualifier must begin the query or a word of it. If there is no qualifier,
+// it returns query and false.
+func SplitSignature(query string) (text, sig string, ok bool) {
+	for i := 0; ; {
+		j := strings.Index(query[i:], signatureQualifier)
+		if j < 0 {
+			return query, "", false
+		}
+		i += j
+		if i == 0 || query[i-1] == ' ' {
+			return strings.TrimSpace(query[:i]), strings.TrimSpace(query[i+len(signatureQualifier):]), true
+		}
+		i += len(signatureQualifier)
+	}
+}
+
+// token is a term, quoted phrase or OR operator in a search query.
+type token struct {
+	text    string
+	phrase  bool // text was quoted
+	exclude bool // text was preceded by "-"
+	or      bool // the OR operator
+}
+
+// tokenize splits query into tokens the way Postgres's websearch_to_tsquery
+// does, which the database uses to translate them into a tsquery: quoted
+// phrases become phrase matches, "-" excludes the term or phrase that follows
+// it, and the word OR (in any case) combines the terms on either side of it.
+// It returns an error for a quotation mark without a partner, an empty
+// phrase, or a "-" that is not followed by a term.
+func tokenize(query string) ([]token, error) {
+	var (
+		tokens  []token
+		exclude bool
+	)
+	for i := 0; i < len(query); {
+		switch c := query[i]; {
+		case c == ' ':
+			if exclude {
+				return nil, errors.New(`"-" must be followed by a word or phrase to exclude`)
+			}
+			i++
+		case c == '-' && !exclude:
+			exclude = true
+			i++
+		case c == '"':
+			n := strings.IndexByte(query[i+1:], '"')
+			if n < 0 {
+				return nil, errors.New("a quotation mark is missing its partner")
+			}
+			text := query[i+1 : i+1+n]
+			if strings.TrimSpace(text) == "" {
+				return nil, errors.New("quotation marks must enclose a word or phrase")
+			}
+			tokens = append(tokens, token{text: text, phrase: true, exclude: exclude})
+			exclude = false
+			i += n + 2
+		default:
+			n := strings.IndexAny(query[i:], ` "`)
+			if n < 0 {
+				n = len(query) - i
+			}
+			text := query[i : i+n]
+			if strings.EqualFold(text, "or") && !exclude {
+				tokens = append(tokens, token{text: text, or: true})
+			} else {
+				tokens = append(tokens, token{text: text, exclude: exclude})
+			}
+			exclude = false
+			i += n
+		}
+	}
+	if exclude {
+		return nil, errors.New(`"-" must be followed by a word or phrase to exclude`)
+	}
+	return tokens, nil
+}
+
+// checkOperators reports whether the search operators in query are used
+// correctly. In addition to the errors from tokenize, OR must be between two
+// terms, and at least one term must not be excluded; a query of only
+// excluded terms would match, and rank, nearly every package.
+//
+// Postgres accepts all of these without complaint, by ignoring the parts it
+// cannot use, but the results would not be what the user asked for.
+func checkOperators(query string) error {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return err
+	}
+	included := false
+	for i, t := range tokens {
+		if t.or {
+			if i == 0 || i == len(tokens)-1 || tokens[i-1].or {
+				return errors.New("OR must be between two words or phrases")
+			}
+			continue
+		}
+		if !t.exclude {
+			included = true
+		}
+	}
+	if len(tokens) > 0 && !included {
+		return errors.New("at least one word or phrase must not be excluded")
+	}
+	return nil
+}
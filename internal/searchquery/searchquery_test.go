@@ -0,0 +1,139 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package searchquery
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+func TestCheck(t *testing.T) {
+	const max = 40
+	for _, test := range []struct {
+		query    string
+		wantKind Kind // zero if the query is valid
+	}{
+		{"", 0},
+		{strings.Repeat("a", max), 0},
+		{strings.Repeat("é", max), 0},
+		{strings.Repeat("a", max+1), TooLong},
+		{`"a b"`, 0},
+		{`a -b`, 0},
+		{`a OR b`, 0},
+		{`"a`, InvalidOperators},
+		{`-a`, InvalidOperators},
+		{`signature:func(io.Reader) error`, 0},
+		{`json signature:func([]byte) error`, 0},
+		{`-a signature:func()`, InvalidOperators},
+		{`signature:`, InvalidSignature},
+		{`signature:io.Reader`, InvalidSignature},
+		{`signature:func(`, InvalidSignature},
+	} {
+		err := Check(test.query, max)
+		if test.wantKind == 0 {
+			if err != nil {
+				t.Errorf("Check(%q, %d): got error %v, want nil", test.query, max, err)
+			}
+			continue
+		}
+		var qerr *Error
+		if !errors.As(err, &qerr) || qerr.Kind != test.wantKind {
+			t.Errorf("Check(%q, %d): got error %v, want kind %d", test.query, max, err, test.wantKind)
+			continue
+		}
+		if !errors.Is(err, derrors.InvalidArgument) {
+			t.Errorf("Check(%q, %d): error %v is not InvalidArgument", test.query, max, err)
+		}
+	}
+}
+
+func TestSplitSignature(t *testing.T) {
+	for _, test := range []struct {
+		query, wantText, wantSig string
+		wantOK                   bool
+	}{
+		{"json", "json", "", false},
+		{"signature:func() error", "", "func() error", true},
+		{"json signature:func([]byte) error", "json", "func([]byte) error", true},
+		{"signature:", "", "", true},
+		{"nosignature:func()", "nosignature:func()", "", false},
+		{"nosignature: signature:func()", "nosignature:", "func()", true},
+	} {
+		text, sig, ok := SplitSignature(test.query)
+		if text != test.wantText || sig != test.wantSig || ok != test.wantOK {
+			t.Errorf("SplitSignature(%q) = %q, %q, %t; want %q, %q, %t",
+				test.query, text, sig, ok, test.wantText, test.wantSig, test.wantOK)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	for _, test := range []struct {
+		query string
+		want  []token
+	}{
+		{"", nil},
+		{"foo", []token{{text: "foo"}}},
+		{"foo-bar golang.org/x/tools", []token{{text: "foo-bar"}, {text: "golang.org/x/tools"}}},
+		{`"exact phrase"`, []token{{text: "exact phrase", phrase: true}}},
+		{`yaml -"go cloud" -json`, []token{
+			{text: "yaml"},
+			{text: "go cloud", phrase: true, exclude: true},
+			{text: "json", exclude: true},
+		}},
+		{"yaml OR json or toml", []token{
+			{text: "yaml"}, {text: "OR", or: true}, {text: "json"}, {text: "or", or: true}, {text: "toml"},
+		}},
+		{"a -or", []token{{text: "a"}, {text: "or", exclude: true}}},
+		{`a"b c"d`, []token{{text: "a"}, {text: "b c", phrase: true}, {text: "d"}}},
+	} {
+		got, err := tokenize(test.query)
+		if err != nil {
+			t.Errorf("tokenize(%q): %v", test.query, err)
+			continue
+		}
+		if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(token{})); diff != "" {
+			t.Errorf("tokenize(%q) mismatch (-want +got):\n%s", test.query, diff)
+		}
+	}
+}
+
+func TestCheckOperators(t *testing.T) {
+	for _, test := range []struct {
+		query   string
+		wantErr string // empty if the query is valid
+	}{
+		{"foo", ""},
+		{`"exact phrase"`, ""},
+		{"foo -bar", ""},
+		{"foo OR bar", ""},
+		{`"foo bar" OR baz -qux`, ""},
+		{`"unterminated`, "missing its partner"},
+		{`foo ""`, "must enclose a word or phrase"},
+		{`foo " "`, "must enclose a word or phrase"},
+		{"foo -", `"-" must be followed`},
+		{"foo - bar", `"-" must be followed`},
+		{"OR foo", "OR must be between"},
+		{"foo OR", "OR must be between"},
+		{"foo OR OR bar", "OR must be between"},
+		{"-foo", "must not be excluded"},
+		{`-foo -"bar baz"`, "must not be excluded"},
+	} {
+		err := checkOperators(test.query)
+		if test.wantErr == "" {
+			if err != nil {
+				t.Errorf("checkOperators(%q): got error %v, want nil", test.query, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("checkOperators(%q): got error %v, want error containing %q", test.query, err, test.wantErr)
+		}
+	}
+}
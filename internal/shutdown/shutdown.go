@@ -0,0 +1,83 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package shutdown provides a registry of functions to run when a server shuts
+// down, such as closing the database and flushing buffered metrics.
+package shutdown
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// A Hook is a function that is run during shutdown. It should return promptly
+// once ctx is done.
+type Hook func(ctx context.Context) error
+
+type namedHook struct {
+	name string
+	fn   Hook
+}
+
+// Hooks is a registry of shutdown hooks. The zero value is ready to use.
+type Hooks struct {
+	mu    sync.Mutex
+	hooks []namedHook
+}
+
+// Register adds a hook with the given name, which is used in logs and errors.
+// Hooks are run in the reverse of the order in which they are registered, so
+// that, as with defer, resources acquired first are released last.
+func (h *Hooks) Register(name string, fn Hook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks = append(h.hooks, namedHook{name, fn})
+}
+
+// Run runs each registered hook, giving each one at most timeout to complete.
+// A hook that does not finish in time is abandoned, so that a stuck hook cannot
+// prevent the others from running or hang the shutdown.
+//
+// Run returns an error describing every hook that failed or timed out.
+func (h *Hooks) Run(ctx context.Context, timeout time.Duration) error {
+	h.mu.Lock()
+	hooks := make([]namedHook, len(h.hooks))
+	copy(hooks, h.hooks)
+	h.mu.Unlock()
+
+	var errs []string
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hk := hooks[i]
+		if err := runHook(ctx, hk.fn, timeout); err != nil {
+			log.Errorf(ctx, "shutdown hook %q: %v", hk.name, err)
+			errs = append(errs, fmt.Sprintf("%s: %v", hk.name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("shutdown hooks failed: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// runHook runs fn with a context that is cancelled after timeout, and returns
+// without waiting for fn if it has not finished by then.
+func runHook(ctx context.Context, fn Hook, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Buffered so that an abandoned hook can still send and exit.
+	errc := make(chan error, 1)
+	go func() { errc <- fn(ctx) }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("did not finish: %v", ctx.Err())
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRun(t *testing.T) {
+	var (
+		h   Hooks
+		ran []string
+	)
+	record := func(name string, err error) Hook {
+		return func(context.Context) error {
+			ran = append(ran, name)
+			return err
+		}
+	}
+	h.Register("db", record("db", nil))
+	h.Register("metrics", record("metrics", errors.New("bad metrics")))
+	h.Register("stuck", func(ctx context.Context) error {
+		// Ignore ctx, like an exporter that hangs.
+		time.Sleep(time.Minute)
+		return nil
+	})
+	h.Register("traces", record("traces", nil))
+
+	start := time.Now()
+	err := h.Run(context.Background(), 50*time.Millisecond)
+	if d := time.Since(start); d > 10*time.Second {
+		t.Errorf("Run took %s; stuck hook was not abandoned", d)
+	}
+	// Hooks run in reverse order, and the others run despite the stuck hook.
+	if diff := cmp.Diff([]string{"traces", "metrics", "db"}, ran); diff != "" {
+		t.Errorf("hooks run mismatch (-want +got):\n%s", diff)
+	}
+	if err == nil {
+		t.Fatal("got nil error, want error")
+	}
+	for _, want := range []string{"stuck: did not finish", "metrics: bad metrics"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("got error %q, want it to contain %q", err, want)
+		}
+	}
+}
+
+func TestRunNoHooks(t *testing.T) {
+	var h Hooks
+	if err := h.Run(context.Background(), time.Second); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+}
@@ -194,6 +194,17 @@ func (i *Info) UnmarshalJSON(data []byte) (err error) {
 	return nil
 }
 
+// GobEncode encodes the Info with MarshalJSON, so that it can be encoded with
+// encoding/gob despite having no exported fields.
+func (i *Info) GobEncode() ([]byte, error) {
+	return i.MarshalJSON()
+}
+
+// GobDecode decodes an Info encoded by GobEncode.
+func (i *Info) GobDecode(data []byte) error {
+	return i.UnmarshalJSON(data)
+}
+
 type Client struct {
 	// client used for HTTP requests. It is mutable for testing purposes.
 	httpClient *http.Client
@@ -5,7 +5,9 @@
 package source
 
 import (
+	"bytes"
 	"context"
+	"encoding/gob"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -781,3 +783,23 @@ func TestJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestGob(t *testing.T) {
+	for _, in := range []*Info{
+		{repoURL: "r", moduleDir: "m", commit: "c"},
+		{repoURL: "r", moduleDir: "m", commit: "c", templates: githubURLTemplates},
+		{repoURL: "r", moduleDir: "m", commit: "c", templates: urlTemplates{File: "f"}},
+	} {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+			t.Fatal(err)
+		}
+		var out Info
+		if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+			t.Fatal(err)
+		}
+		if out != *in {
+			t.Errorf("got  %#v\nwant %#v", out, *in)
+		}
+	}
+}
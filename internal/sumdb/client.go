@@ -0,0 +1,245 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sumdb provides a client for a checksum database, which the fetch
+// service uses to verify that the modules it downloads from the module proxy
+// have not been tampered with. See go help module-auth.
+package sumdb
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opencensus.io/plugin/ochttp"
+	xsumdb "golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/dirhash"
+	"golang.org/x/mod/sumdb/note"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// sumGolangOrgKey is the public key of sum.golang.org, the default checksum
+// database of the go command.
+const sumGolangOrgKey = "sum.golang.org+033de0ae+Ac4zctda0e5eza+HJyk9SxEdh+s3Ux18htTTAD8OuAn8"
+
+// requestTimeout is the timeout of each request to the checksum database.
+const requestTimeout = time.Minute
+
+// A Client verifies the hashes of module versions against a checksum
+// database. A nil *Client verifies nothing.
+type Client struct {
+	// name is the name of the checksum database, like "sum.golang.org".
+	name   string
+	client *xsumdb.Client
+}
+
+// New constructs a *Client for the checksum database gosumdb, which has the
+// syntax of the GOSUMDB environment variable: "off", the name of a database
+// known to the go command, like "sum.golang.org", or a public key, optionally
+// followed by the URL of the database. The modules whose paths match one of
+// the comma-separated glob patterns of gonosumdb, which has the syntax of the
+// GONOSUMDB environment variable, are not verified.
+//
+// If gosumdb is "off", New returns a nil *Client.
+func New(gosumdb, gonosumdb string) (_ *Client, err error) {
+	defer derrors.Wrap(&err, "sumdb.New(%q, %q)", gosumdb, gonosumdb)
+	if gosumdb == "off" {
+		return nil, nil
+	}
+	vkey, url, err := parseGOSUMDB(gosumdb)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(vkey, url, gonosumdb, &http.Client{
+		Transport: &ochttp.Transport{},
+		Timeout:   requestTimeout,
+	})
+}
+
+func newClient(vkey, url, gonosumdb string, httpClient *http.Client) (*Client, error) {
+	verifier, err := note.NewVerifier(vkey)
+	if err != nil {
+		return nil, err
+	}
+	c := xsumdb.NewClient(&clientOps{
+		vkey:       vkey,
+		url:        url,
+		httpClient: httpClient,
+		config:     map[string][]byte{},
+	})
+	c.SetGONOSUMDB(gonosumdb)
+	return &Client{name: verifier.Name(), client: c}, nil
+}
+
+// parseGOSUMDB returns the public key and URL of the checksum database described
+// by gosumdb. The URL defaults to https://<name>, where name is the name of
+// the key.
+func parseGOSUMDB(gosumdb string) (vkey, url string, err error) {
+	if gosumdb == "sum.golang.google.cn" {
+		// A mirror of sum.golang.org, as in the go command.
+		gosumdb = "sum.golang.org https://sum.golang.google.cn"
+	}
+	fields := strings.Fields(gosumdb)
+	if len(fields) == 0 || len(fields) > 2 {
+		return "", "", fmt.Errorf("invalid GOSUMDB %q: %w", gosumdb, derrors.InvalidArgument)
+	}
+	vkey = fields[0]
+	if vkey == "sum.golang.org" {
+		vkey = sumGolangOrgKey
+	}
+	verifier, err := note.NewVerifier(vkey)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid GOSUMDB %q: %v: %w", gosumdb, err, derrors.InvalidArgument)
+	}
+	url = "https://" + verifier.Name()
+	if len(fields) == 2 {
+		url = strings.TrimSuffix(fields[1], "/")
+		if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+			return "", "", fmt.Errorf("invalid GOSUMDB URL %q: %w", fields[1], derrors.InvalidArgument)
+		}
+	}
+	return vkey, url, nil
+}
+
+// Verify checks that the hashes of the go.mod file and the zip of the given
+// module version match those recorded in the checksum database. If one of
+// them does not, the returned error wraps derrors.ChecksumMismatch. If
+// modulePath matches a pattern of the GONOSUMDB list of c, or c is nil,
+// nothing is checked.
+func (c *Client) Verify(modulePath, version string, goMod []byte, zipReader *zip.Reader) (err error) {
+	if c == nil {
+		return nil
+	}
+	defer derrors.Wrap(&err, "sumdb.Client.Verify(%q, %q)", modulePath, version)
+
+	modHash, err := HashGoMod(goMod)
+	if err != nil {
+		return err
+	}
+	if err := c.check(modulePath, version+"/go.mod", modHash); err != nil {
+		return err
+	}
+	zipHash, err := HashZip(zipReader)
+	if err != nil {
+		return err
+	}
+	return c.check(modulePath, version, zipHash)
+}
+
+// check looks up the go.sum lines of modulePath at vers, which may end in
+// "/go.mod", and checks that one of them has the given hash.
+func (c *Client) check(modulePath, vers, hash string) error {
+	lines, err := c.client.Lookup(modulePath, vers)
+	if err == xsumdb.ErrGONOSUMDB {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	want := fmt.Sprintf("%s %s %s", modulePath, vers, hash)
+	for _, line := range lines {
+		if line == want {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s %s: hash %s does not match %s: %w", modulePath, vers, hash, c.name, derrors.ChecksumMismatch)
+}
+
+// HashGoMod returns the hash of a go.mod file with the given contents, as it
+// appears in a go.sum file.
+func HashGoMod(goMod []byte) (string, error) {
+	return dirhash.Hash1([]string{"go.mod"}, func(string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(goMod)), nil
+	})
+}
+
+// HashZip returns the hash of a module zip, as it appears in a go.sum file.
+func HashZip(zipReader *zip.Reader) (string, error) {
+	var names []string
+	files := map[string]*zip.File{}
+	for _, f := range zipReader.File {
+		if _, ok := files[f.Name]; ok {
+			return "", fmt.Errorf("duplicate file %q in module zip: %w", f.Name, derrors.BadModule)
+		}
+		names = append(names, f.Name)
+		files[f.Name] = f
+	}
+	return dirhash.Hash1(names, func(name string) (io.ReadCloser, error) {
+		return files[name].Open()
+	})
+}
+
+// clientOps implements xsumdb.ClientOps. It keeps the latest signed tree
+// head of the database in memory, and does not cache tiles or records
+// itself: the xsumdb.Client keeps the tiles that it reads in memory.
+type clientOps struct {
+	vkey       string
+	url        string
+	httpClient *http.Client
+
+	mu     sync.Mutex
+	config map[string][]byte
+}
+
+func (o *clientOps) ReadRemote(path string) (_ []byte, err error) {
+	defer derrors.Wrap(&err, "ReadRemote(%q)", path)
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	req, err := http.NewRequest("GET", o.url+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := o.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", o.url+path, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (o *clientOps) ReadConfig(file string) ([]byte, error) {
+	if file == "key" {
+		return []byte(o.vkey), nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	// An empty tree head, at first, is what the xsumdb.Client expects.
+	return o.config[file], nil
+}
+
+func (o *clientOps) WriteConfig(file string, old, new []byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !bytes.Equal(o.config[file], old) {
+		return xsumdb.ErrWriteConflict
+	}
+	o.config[file] = new
+	return nil
+}
+
+func (o *clientOps) ReadCache(file string) ([]byte, error) {
+	return nil, fmt.Errorf("%s: %w", file, derrors.NotFound)
+}
+
+func (o *clientOps) WriteCache(file string, data []byte) {}
+
+func (o *clientOps) Log(msg string) {
+	log.Info(context.Background(), msg)
+}
+
+func (o *clientOps) SecurityError(msg string) {
+	// The xsumdb.Client returns xsumdb.ErrSecurity after this.
+	log.Error(context.Background(), msg)
+}
@@ -0,0 +1,130 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sumdb
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/testhelper"
+)
+
+func TestVerify(t *testing.T) {
+	const (
+		modulePath = "example.com/m"
+		version    = "v1.0.0"
+		goMod      = "module example.com/m\n"
+	)
+	zipBytes, err := testhelper.ZipContents(map[string]string{
+		modulePath + "@" + version + "/go.mod": goMod,
+		modulePath + "@" + version + "/m.go":   "package m\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zipReader, err := zip.NewReader(bytes.NewReader(zipBytes), int64(len(zipBytes)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zipHash, err := HashZip(zipReader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modHash, err := HashGoMod([]byte(goMod))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Hashes of other contents.
+	otherHash, err := HashGoMod([]byte("module example.com/other\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gosum := func(zipHash, modHash string) string {
+		return fmt.Sprintf("%[1]s %[2]s %[3]s\n%[1]s %[2]s/go.mod %[4]s\n", modulePath, version, zipHash, modHash)
+	}
+
+	for _, test := range []struct {
+		name      string
+		gosum     string
+		gonosumdb string
+		wantErr   error
+	}{
+		{
+			name:  "match",
+			gosum: gosum(zipHash, modHash),
+		},
+		{
+			name:    "zip mismatch",
+			gosum:   gosum(otherHash, modHash),
+			wantErr: derrors.ChecksumMismatch,
+		},
+		{
+			name:    "go.mod mismatch",
+			gosum:   gosum(zipHash, otherHash),
+			wantErr: derrors.ChecksumMismatch,
+		},
+		{
+			name:      "not verified",
+			gosum:     gosum(otherHash, otherHash),
+			gonosumdb: "example.com",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c, teardown := SetupTestClient(t, map[string]string{
+				modulePath + "@" + version: test.gosum,
+			}, test.gonosumdb)
+			defer teardown()
+
+			err := c.Verify(modulePath, version, []byte(goMod), zipReader)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("Verify(%q, %q): %v, want error %v", modulePath, version, err, test.wantErr)
+			}
+			if test.wantErr == nil && err != nil {
+				t.Errorf("Verify(%q, %q): %v, want no error", modulePath, version, err)
+			}
+		})
+	}
+
+	t.Run("nil client", func(t *testing.T) {
+		var c *Client
+		if err := c.Verify(modulePath, version, []byte(goMod), zipReader); err != nil {
+			t.Errorf("Verify(%q, %q): %v, want no error", modulePath, version, err)
+		}
+	})
+}
+
+func TestParseGOSUMDB(t *testing.T) {
+	for _, test := range []struct {
+		in, wantKey, wantURL string
+	}{
+		{"sum.golang.org", sumGolangOrgKey, "https://sum.golang.org"},
+		{"sum.golang.google.cn", sumGolangOrgKey, "https://sum.golang.google.cn"},
+		{sumGolangOrgKey, sumGolangOrgKey, "https://sum.golang.org"},
+		{sumGolangOrgKey + " https://sumdb.example.com/", sumGolangOrgKey, "https://sumdb.example.com"},
+	} {
+		key, url, err := parseGOSUMDB(test.in)
+		if err != nil {
+			t.Fatalf("parseGOSUMDB(%q): %v", test.in, err)
+		}
+		if key != test.wantKey || url != test.wantURL {
+			t.Errorf("parseGOSUMDB(%q) = %q, %q, want %q, %q", test.in, key, url, test.wantKey, test.wantURL)
+		}
+	}
+
+	for _, in := range []string{
+		"",
+		"sum.example.com",
+		sumGolangOrgKey + " sumdb.example.com",
+		sumGolangOrgKey + " https://sumdb.example.com extra",
+	} {
+		if _, _, err := parseGOSUMDB(in); !errors.Is(err, derrors.InvalidArgument) {
+			t.Errorf("parseGOSUMDB(%q): %v, want InvalidArgument", in, err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sumdb
+
+import (
+	"crypto/rand"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	xsumdb "golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// SetupTestClient creates a fake checksum database for testing, which serves
+// the go.sum lines in gosum, keyed by module path and version, like
+// "example.com/m@v1.0.0". The module paths that match a pattern of gonosumdb
+// are not verified.
+//
+// It returns a Client for the database and a function for tearing it down
+// after the test is completed.
+func SetupTestClient(t *testing.T, gosum map[string]string, gonosumdb string) (*Client, func()) {
+	t.Helper()
+	skey, vkey, err := note.GenerateKey(rand.Reader, "sum.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(xsumdb.NewServer(xsumdb.NewTestServer(skey, func(path, vers string) ([]byte, error) {
+		lines, ok := gosum[path+"@"+vers]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return []byte(lines), nil
+	})))
+	c, err := newClient(vkey, server.URL, gonosumdb, server.Client())
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return c, server.Close
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis/v7"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/cache"
 	"golang.org/x/pkgsite/internal/config"
 	"golang.org/x/pkgsite/internal/frontend"
 	"golang.org/x/pkgsite/internal/index"
@@ -111,7 +112,7 @@ func TestEndToEndProcessing(t *testing.T) {
 		t.Fatal(err)
 	}
 	frontendMux := http.NewServeMux()
-	frontendServer.Install(frontendMux.Handle, redisCacheClient)
+	frontendServer.Install(frontendMux.Handle, cache.NewRedis(redisCacheClient))
 	frontendHTTP := httptest.NewServer(frontendMux)
 
 	if _, err := doGet(workerHTTP.URL + "/poll"); err != nil {
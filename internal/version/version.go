@@ -57,6 +57,13 @@ func ParseType(version string) (Type, error) {
 	}
 }
 
+// IsUnstable reports whether a valid version v has major version zero, like
+// v0.3.1 or a v0.0.0 pseudo-version. Modules make no compatibility guarantees
+// at major version zero.
+func IsUnstable(v string) bool {
+	return semver.Major(v) == "v0"
+}
+
 // ForSorting returns a string that encodes version, so that comparing two such
 // strings follows SemVer precedence, https://semver.org clause 11. It assumes
 // version is valid. The returned string ends in '~' if and only if the version
@@ -19,6 +19,8 @@ func TestForSorting(t *testing.T) {
 		{"v0.9.3-alpha.1", "0,9,3,~alpha,1"},
 		{"v1.2.3-rc.20150901.-", "1,2,3,~rc,g20150901,~-"},
 		{"v1.2.3-alpha.789+build", "1,2,3,~alpha,b789"},
+		{"v2.0.0+incompatible", "2,0,0~"},
+		{"v2.0.0-rc.1+incompatible", "2,0,0,~rc,1"},
 	} {
 		got := ForSorting(test.in)
 		if got != test.want {
@@ -151,3 +153,49 @@ func TestParseVersionType(t *testing.T) {
 		})
 	}
 }
+
+func TestForSortingIncompatible(t *testing.T) {
+	// Versions of a module without a go.mod file, in order. The +incompatible
+	// suffix is build metadata, so it does not affect precedence.
+	versions := []string{
+		"v1.9.9",
+		"v2.0.0-rc.1+incompatible",
+		"v2.0.0+incompatible",
+		"v2.0.1-0.20190311183353-d8887717615a+incompatible",
+		"v2.1.0+incompatible",
+		"v10.0.0+incompatible",
+	}
+	for i := 1; i < len(versions); i++ {
+		prev, v := versions[i-1], versions[i]
+		if semver.Compare(prev, v) >= 0 {
+			t.Fatalf("test is broken: %s is not less than %s", prev, v)
+		}
+		if ForSorting(prev) >= ForSorting(v) {
+			t.Errorf("ForSorting(%s) = %s >= ForSorting(%s) = %s, want less than", prev, ForSorting(prev), v, ForSorting(v))
+		}
+	}
+	// A +incompatible version has the same precedence as the version without
+	// the suffix.
+	if got, want := ForSorting("v2.0.0+incompatible"), ForSorting("v2.0.0"); got != want {
+		t.Errorf("ForSorting(v2.0.0+incompatible) = %s, want %s", got, want)
+	}
+}
+
+func TestIsUnstable(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want bool
+	}{
+		{"v0.1.0", true},
+		{"v0.0.0-20190124233150-8f7fa2680c82", true},
+		{"v0.9.3-alpha.1", true},
+		{"v1.0.0", false},
+		{"v1.0.0-rc.1", false},
+		{"v1.3.0-0.20190124233150-8f7fa2680c82", false},
+		{"v2.0.0+incompatible", false},
+	} {
+		if got := IsUnstable(test.in); got != test.want {
+			t.Errorf("IsUnstable(%q) = %t, want %t", test.in, got, test.want)
+		}
+	}
+}
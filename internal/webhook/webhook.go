@@ -0,0 +1,272 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package webhook notifies subscribers when versions of the modules they are
+// interested in are inserted into the database.
+//
+// Each notification is a POST of a JSON Payload. Its SignatureHeader holds
+// "sha256=" followed by the hex-encoded HMAC-SHA256 of the request body,
+// keyed with the subscription's secret, so that subscribers can check that
+// the notification came from pkgsite.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opencensus.io/plugin/ochttp"
+	"golang.org/x/mod/module"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/xcontext"
+)
+
+// SignatureHeader is the header of a notification that holds the signature
+// of its body.
+const SignatureHeader = "Pkgsite-Signature"
+
+const (
+	// maxAttempts is the number of times a notification is sent before
+	// giving up on it.
+	maxAttempts = 5
+
+	// initialBackoff is the time to wait before sending a notification
+	// again. It doubles after each failed attempt.
+	initialBackoff = 2 * time.Second
+
+	// deliveryTimeout bounds each attempt to send a notification.
+	deliveryTimeout = 10 * time.Second
+)
+
+// Payload is the body of a notification.
+type Payload struct {
+	ModulePath string    `json:"module_path"`
+	Version    string    `json:"version"`
+	CommitTime time.Time `json:"commit_time"`
+	// SubscriptionID is the ID of the subscription that the notification is
+	// for.
+	SubscriptionID int `json:"subscription_id"`
+}
+
+// SubscriptionSource is a source of webhook subscriptions. *postgres.DB
+// implements it.
+type SubscriptionSource interface {
+	GetWebhookSubscriptions(ctx context.Context) ([]*internal.WebhookSubscription, error)
+}
+
+// A Dispatcher sends notifications to the subscribers of module versions.
+type Dispatcher struct {
+	subs    SubscriptionSource
+	client  *http.Client
+	backoff time.Duration // initialBackoff, except in tests
+
+	mu     sync.Mutex
+	closed bool          // set by Shutdown
+	done   chan struct{} // closed by Shutdown
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher returns a Dispatcher that notifies the subscribers in subs.
+func NewDispatcher(subs SubscriptionSource) *Dispatcher {
+	return &Dispatcher{
+		subs:    subs,
+		client:  &http.Client{Transport: &ochttp.Transport{}},
+		backoff: initialBackoff,
+		done:    make(chan struct{}),
+	}
+}
+
+// ModuleInserted notifies the subscribers whose patterns match the module
+// path of mi. It is meant for postgres.DB.SetInsertHook.
+//
+// The notifications are sent in the background, and retried with exponential
+// backoff when they fail, so ModuleInserted returns without waiting for them.
+// Errors are logged. After Shutdown has been called, ModuleInserted does
+// nothing.
+func (d *Dispatcher) ModuleInserted(ctx context.Context, mi *internal.ModuleInfo) {
+	subs, err := d.subs.GetWebhookSubscriptions(ctx)
+	if err != nil {
+		log.Errorf(ctx, "webhook: %s@%s: %v", mi.ModulePath, mi.Version, err)
+		return
+	}
+	// Hold the lock while adding to the WaitGroup, so that Shutdown cannot
+	// start waiting in between.
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		log.Errorf(ctx, "webhook: %s@%s: dispatcher is shut down", mi.ModulePath, mi.Version)
+		return
+	}
+	// The notifications outlive the request that inserted the module.
+	ctx = xcontext.Detach(ctx)
+	for _, s := range subs {
+		if !Match(s.ModulePathPattern, mi.ModulePath) {
+			continue
+		}
+		body, err := json.Marshal(&Payload{
+			ModulePath:     mi.ModulePath,
+			Version:        mi.Version,
+			CommitTime:     mi.CommitTime,
+			SubscriptionID: s.ID,
+		})
+		if err != nil {
+			log.Errorf(ctx, "webhook: %s@%s: %v", mi.ModulePath, mi.Version, err)
+			continue
+		}
+		d.wg.Add(1)
+		go func(s *internal.WebhookSubscription) {
+			defer d.wg.Done()
+			if err := d.deliver(ctx, s, body); err != nil {
+				log.Errorf(ctx, "webhook: giving up on %s@%s for subscription %d: %v", mi.ModulePath, mi.Version, s.ID, err)
+			}
+		}(s)
+	}
+}
+
+// Wait waits for the notifications that are being sent to be delivered or
+// given up on.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// Shutdown stops the Dispatcher from sending new notifications or retrying
+// failed ones, and waits for the attempts in progress to finish or for ctx to
+// be done. Each attempt takes at most deliveryTimeout.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.done)
+	}
+	d.mu.Unlock()
+
+	waited := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// deliver posts body to the callback URL of s, trying up to maxAttempts
+// times. It stops waiting to retry when ctx is done or the Dispatcher is shut
+// down.
+func (d *Dispatcher) deliver(ctx context.Context, s *internal.WebhookSubscription, body []byte) error {
+	wait := d.backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		retry, err = d.post(ctx, s, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == maxAttempts {
+			return err
+		}
+		log.Infof(ctx, "webhook: attempt %d for subscription %d failed, retrying in %s: %v", attempt, s.ID, wait, err)
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return fmt.Errorf("%v; last attempt: %v", ctx.Err(), err)
+		case <-d.done:
+			t.Stop()
+			return fmt.Errorf("shutting down; last attempt: %v", err)
+		}
+		wait *= 2
+	}
+}
+
+// post makes one attempt to post body to the callback URL of s. If it fails,
+// it reports whether the failure may be temporary.
+func (d *Dispatcher) post(ctx context.Context, s *internal.WebhookSubscription, body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+	req, err := http.NewRequest(http.MethodPost, s.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(s.Secret, body))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	// Read the body so that the connection can be reused.
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1<<16))
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("%s: %s", s.CallbackURL, resp.Status)
+	default:
+		return false, fmt.Errorf("%s: %s", s.CallbackURL, resp.Status)
+	}
+}
+
+// Sign returns the value of the SignatureHeader of a notification with the
+// given body, for a subscription with the given secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret returns a random secret for a new subscription.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Match reports whether modulePath matches pattern, which is either a module
+// path, or a path followed by "/..." that matches itself and every path it
+// is a prefix of.
+func Match(pattern, modulePath string) bool {
+	if prefix := strings.TrimSuffix(pattern, "/..."); prefix != pattern {
+		return modulePath == prefix || strings.HasPrefix(modulePath, prefix+"/")
+	}
+	return modulePath == pattern
+}
+
+// CheckSubscription returns an error wrapping derrors.InvalidArgument if
+// pattern or callbackURL cannot be used for a subscription.
+func CheckSubscription(pattern, callbackURL string) (err error) {
+	defer derrors.Wrap(&err, "CheckSubscription(%q, %q)", pattern, callbackURL)
+	if err := module.CheckPath(strings.TrimSuffix(pattern, "/...")); err != nil {
+		return fmt.Errorf("%v: %w", err, derrors.InvalidArgument)
+	}
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return fmt.Errorf("%v: %w", err, derrors.InvalidArgument)
+	}
+	if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("%w: callback URL must be an absolute http or https URL", derrors.InvalidArgument)
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+type fakeSubscriptions []*internal.WebhookSubscription
+
+func (f fakeSubscriptions) GetWebhookSubscriptions(context.Context) ([]*internal.WebhookSubscription, error) {
+	return f, nil
+}
+
+// recorder is a subscriber that records the notifications it receives, and
+// responds to the first len(statuses) of them with the given statuses.
+type recorder struct {
+	mu       sync.Mutex
+	statuses []int
+	payloads []Payload
+	attempts int
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts++
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if got, want := req.Header.Get(SignatureHeader), Sign("secret", body); got != want {
+		http.Error(w, "bad signature", http.StatusUnauthorized)
+		return
+	}
+	if len(r.statuses) > 0 {
+		status := r.statuses[0]
+		r.statuses = r.statuses[1:]
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+	}
+	var p Payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.payloads = append(r.payloads, p)
+}
+
+func TestModuleInserted(t *testing.T) {
+	commitTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	mi := &internal.ModuleInfo{ModulePath: "example.com/a/b", Version: "v1.2.3", CommitTime: commitTime}
+	for _, test := range []struct {
+		name         string
+		patterns     []string
+		statuses     []int
+		want         []Payload
+		wantAttempts int
+	}{
+		{
+			name:     "matching patterns",
+			patterns: []string{"example.com/a/b", "example.com/a/...", "example.com/a/b/c", "example.com/ab/..."},
+			want: []Payload{
+				{ModulePath: mi.ModulePath, Version: mi.Version, CommitTime: commitTime, SubscriptionID: 1},
+				{ModulePath: mi.ModulePath, Version: mi.Version, CommitTime: commitTime, SubscriptionID: 2},
+			},
+			wantAttempts: 2,
+		},
+		{
+			name:     "retried after server errors",
+			patterns: []string{"example.com/a/b"},
+			statuses: []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusOK},
+			want: []Payload{
+				{ModulePath: mi.ModulePath, Version: mi.Version, CommitTime: commitTime, SubscriptionID: 1},
+			},
+			wantAttempts: 3,
+		},
+		{
+			name:         "not retried after client error",
+			patterns:     []string{"example.com/a/b"},
+			statuses:     []int{http.StatusGone},
+			wantAttempts: 1,
+		},
+		{
+			name:     "gives up",
+			patterns: []string{"example.com/a/b"},
+			statuses: []int{500, 500, 500, 500, 500, 500},
+			// The recorder still has a status for a sixth attempt.
+			wantAttempts: maxAttempts,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			rec := &recorder{statuses: test.statuses}
+			srv := httptest.NewServer(rec)
+			defer srv.Close()
+			var subs fakeSubscriptions
+			for i, p := range test.patterns {
+				subs = append(subs, &internal.WebhookSubscription{
+					ID:                i + 1,
+					ModulePathPattern: p,
+					CallbackURL:       srv.URL,
+					Secret:            "secret",
+				})
+			}
+			d := NewDispatcher(subs)
+			d.client = srv.Client()
+			d.backoff = time.Millisecond
+			d.ModuleInserted(context.Background(), mi)
+			d.Wait()
+
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+			// Notifications for different subscriptions are sent concurrently.
+			less := func(a, b Payload) bool { return a.SubscriptionID < b.SubscriptionID }
+			if diff := cmp.Diff(test.want, rec.payloads, cmpopts.SortSlices(less)); diff != "" {
+				t.Errorf("payloads mismatch (-want +got):\n%s", diff)
+			}
+			if rec.attempts != test.wantAttempts {
+				t.Errorf("got %d attempts, want %d", rec.attempts, test.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	mi := &internal.ModuleInfo{ModulePath: "example.com/a", Version: "v1.0.0"}
+	rec := &recorder{statuses: []int{500, 500}}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+	subs := fakeSubscriptions{{ID: 1, ModulePathPattern: mi.ModulePath, CallbackURL: srv.URL, Secret: "secret"}}
+	d := NewDispatcher(subs)
+	d.client = srv.Client()
+	// Long enough that the test would time out if Shutdown waited for it.
+	d.backoff = time.Hour
+	d.ModuleInserted(context.Background(), mi)
+
+	attempts := func() int {
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		return rec.attempts
+	}
+	for attempts() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	// Notifications are not sent after Shutdown.
+	d.ModuleInserted(context.Background(), mi)
+	d.Wait()
+	if got := attempts(); got != 1 {
+		t.Errorf("got %d attempts, want 1", got)
+	}
+}
+
+func TestDeliverContextDone(t *testing.T) {
+	rec := &recorder{statuses: []int{500, 500}}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+	d := NewDispatcher(fakeSubscriptions{})
+	d.client = srv.Client()
+	d.backoff = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	s := &internal.WebhookSubscription{ID: 1, CallbackURL: srv.URL, Secret: "secret"}
+	if err := d.deliver(ctx, s, []byte("{}")); err == nil {
+		t.Fatal("got nil error, want one")
+	}
+	if rec.attempts != 1 {
+		t.Errorf("got %d attempts, want 1", rec.attempts)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	for _, test := range []struct {
+		pattern, modulePath string
+		want                bool
+	}{
+		{"example.com/a", "example.com/a", true},
+		{"example.com/a", "example.com/a/b", false},
+		{"example.com/a/...", "example.com/a", true},
+		{"example.com/a/...", "example.com/a/b/c", true},
+		{"example.com/a/...", "example.com/ab", false},
+		{"example.com/a/...", "example.com", false},
+	} {
+		if got := Match(test.pattern, test.modulePath); got != test.want {
+			t.Errorf("Match(%q, %q) = %t, want %t", test.pattern, test.modulePath, got, test.want)
+		}
+	}
+}
+
+func TestCheckSubscription(t *testing.T) {
+	for _, test := range []struct {
+		pattern, callbackURL string
+		wantErr              bool
+	}{
+		{"example.com/a", "https://example.org/hook", false},
+		{"example.com/a/...", "http://localhost:8080/hook", false},
+		{"example.com/a/...", "/hook", true},
+		{"example.com/a/...", "ftp://example.org/hook", true},
+		{"", "https://example.org/hook", true},
+		{"...", "https://example.org/hook", true},
+		{"example.com/a b", "https://example.org/hook", true},
+	} {
+		err := CheckSubscription(test.pattern, test.callbackURL)
+		if got := err != nil; got != test.wantErr {
+			t.Errorf("CheckSubscription(%q, %q) = %v, want error: %t", test.pattern, test.callbackURL, err, test.wantErr)
+		}
+		if err != nil && !errors.Is(err, derrors.InvalidArgument) {
+			t.Errorf("CheckSubscription(%q, %q) = %v, want InvalidArgument", test.pattern, test.callbackURL, err)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	// From the example in RFC 4231, section 4.3.
+	got := Sign("Jefe", []byte("what do ya want for nothing?"))
+	want := "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -159,9 +159,12 @@ func updateRedisIndexes(ctx context.Context, db *database.DB, redisClient *redis
 	//    document data used here.
 	//  - hold two copies of all search results in memory while building the
 	//    redis pipeline below.
+	// Internal packages cannot be imported from outside their module, so
+	// they are not suggested.
 	query := `
 		SELECT package_path, module_path, version, imported_by_count
-		FROM search_documents`
+		FROM search_documents
+		WHERE package_path !~ '(^|/)internal(/|$)'`
 	if err := db.RunQuery(ctx, query, processRow); err != nil {
 		return err
 	}
@@ -157,6 +157,13 @@ func fetchAndInsertModule(ctx context.Context, modulePath, requestedVersion stri
 		return ft
 	}
 	log.Infof(ctx, "db.InsertModule succeeded for %s@%s", ft.ModulePath, ft.RequestedVersion)
+	if ft.Status == http.StatusOK && !db.LicensesAllowed(ft.Module) {
+		// The module was inserted, but is not searchable. A status such as
+		// derrors.HasIncompletePackages is kept, because it says more about
+		// the module, and reprocessing applies the allowlist again.
+		log.Infof(ctx, "licenses of %s@%s are not on the allowlist", ft.ModulePath, ft.ResolvedVersion)
+		ft.Status = derrors.ToHTTPStatus(derrors.LicenseNotAllowed)
+	}
 	return ft
 }
 
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -46,8 +47,17 @@ var buildConstraintsMod = &proxy.TestModule{
 }
 
 // Check that when the proxy says it does not have module@version,
-// we delete it from the database.
+// we delete it from the database, and record whether it never existed (404)
+// or is not available (410).
 func TestFetchAndUpdateState_NotFound(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusGone} {
+		t.Run(strconv.Itoa(status), func(t *testing.T) {
+			testFetchAndUpdateStateTakenDown(t, status)
+		})
+	}
+}
+
+func testFetchAndUpdateStateTakenDown(t *testing.T, takedownStatus int) {
 	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
 	defer cancel()
 
@@ -80,7 +90,7 @@ func TestFetchAndUpdateState_NotFound(t *testing.T) {
 		if vs.Status != want {
 			t.Fatalf("testDB.GetModuleVersionState(ctx, %q, %q): status = %v, want = %d", modulePath, version, vs.Status, want)
 		}
-		if want != http.StatusNotFound {
+		if want != takedownStatus {
 			vm, err := testDB.GetVersionMap(ctx, modulePath, version)
 			if err != nil {
 				t.Fatal(err)
@@ -121,20 +131,20 @@ func TestFetchAndUpdateState_NotFound(t *testing.T) {
 
 	teardownProxy()
 
-	// Take down the module, by having the proxy serve a 404/410 for it.
+	// Take down the module, by having the proxy serve takedownStatus for it.
 	proxyMux := proxy.TestProxy([]*proxy.TestModule{}) // serve no versions, not even the defaults.
 	proxyMux.HandleFunc(fmt.Sprintf("/%s/@v/%s.info", modulePath, version),
-		func(w http.ResponseWriter, r *http.Request) { http.Error(w, "taken down", http.StatusGone) })
+		func(w http.ResponseWriter, r *http.Request) { http.Error(w, "taken down", takedownStatus) })
 	proxyClient, teardownProxy2 := proxy.TestProxyServer(t, proxyMux)
 	defer teardownProxy2()
 
 	// Now fetch it again.
-	if code, _ := FetchAndUpdateState(ctx, modulePath, version, proxyClient, sourceClient, testDB, "appVersionLabel"); code != http.StatusNotFound {
-		t.Fatalf("FetchAndUpdateState(ctx, %q, %q, proxyClient, sourceClient, testDB): got code %d, want 404/410", modulePath, version, code)
+	if code, _ := FetchAndUpdateState(ctx, modulePath, version, proxyClient, sourceClient, testDB, "appVersionLabel"); code != takedownStatus {
+		t.Fatalf("FetchAndUpdateState(ctx, %q, %q, proxyClient, sourceClient, testDB): got code %d, want %d", modulePath, version, code, takedownStatus)
 	}
 
-	// The new state should have a status of Not Found.
-	checkStatus(http.StatusNotFound)
+	// The new state should have the status that the proxy served.
+	checkStatus(takedownStatus)
 
 	gotStates, err = testDB.GetPackageVersionStatesForModule(ctx, modulePath, version)
 	if err != nil {
@@ -308,6 +318,40 @@ func TestFetchAndUpdateState_Incomplete(t *testing.T) {
 	}
 }
 
+func TestFetchAndUpdateState_LicenseNotAllowed(t *testing.T) {
+	// Check that a disallowed license does not replace the "incomplete" status.
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	testDB.SetLicenseAllowlist([]string{"MIT"})
+	defer testDB.SetLicenseAllowlist(nil)
+
+	proxyClient, teardownProxy := proxy.SetupTestProxy(t, []*proxy.TestModule{buildConstraintsMod})
+	defer teardownProxy()
+	sourceClient := source.NewClient(sourceTimeout)
+
+	const (
+		modulePath = "build.constraints/module"
+		version    = "v1.0.0"
+		want       = hasIncompletePackagesCode
+	)
+	code, err := FetchAndUpdateState(ctx, modulePath, version, proxyClient, sourceClient, testDB, "appVersionLabel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != want {
+		t.Fatalf("got code %d, want %d", code, want)
+	}
+	vs, err := testDB.GetModuleVersionState(ctx, modulePath, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vs.Status != want {
+		t.Fatalf("testDB.GetModuleVersionState(ctx, %q, %q): status=%v, want %d", modulePath, version, vs.Status, want)
+	}
+}
+
 func TestFetchAndUpdateState_Mismatch(t *testing.T) {
 	// Check that an excluded module is not processed, and is marked excluded in module_version_states.
 	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
@@ -416,6 +460,41 @@ func TestFetchAndUpdateState_DeleteOlder(t *testing.T) {
 	}
 }
 
+func TestFetchAndUpdateState_LicenseExpression(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	const (
+		modulePath = "github.com/dual/license"
+		version    = "v1.0.0"
+	)
+	proxyClient, teardownProxy := proxy.SetupTestProxy(t, []*proxy.TestModule{
+		{
+			ModulePath: modulePath,
+			Version:    version,
+			Files: map[string]string{
+				"foo/foo.go":  "// Package foo\npackage foo\n\nconst Foo = 42",
+				"LICENSE-MIT": testhelper.MITLicense,
+				"LICENSE-BSD": testhelper.BSD0License,
+			},
+		},
+	})
+	defer teardownProxy()
+	sourceClient := source.NewClient(sourceTimeout)
+
+	if _, err := FetchAndUpdateState(ctx, modulePath, version, proxyClient, sourceClient, testDB, "appVersionLabel"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := testDB.LegacyGetModuleInfo(ctx, modulePath, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "BSD-0-Clause OR MIT"; got.LicenseExpression != want {
+		t.Errorf("got LicenseExpression %q, want %q", got.LicenseExpression, want)
+	}
+}
+
 func TestSkipIncompletePackage(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
 	defer cancel()
@@ -775,6 +854,8 @@ func TestFetchAndInsertModule(t *testing.T) {
 				VersionType:       "release",
 				IsRedistributable: true,
 				HasGoMod:          true,
+				MinGoVersion:      "1.12",
+				LicenseExpression: "BSD-0-Clause",
 			},
 			LegacyReadmeFilePath: "README.md",
 			LegacyReadmeContents: "README FILE FOR TESTING.",
@@ -831,6 +912,8 @@ func TestFetchAndInsertModule(t *testing.T) {
 						SourceInfo:        nil,
 						IsRedistributable: true,
 						HasGoMod:          true,
+						MinGoVersion:      "1.13",
+						LicenseExpression: "BSD-0-Clause",
 					},
 					LegacyReadmeFilePath: "README.md",
 					LegacyReadmeContents: "README FILE FOR TESTING.",
@@ -865,6 +948,8 @@ func TestFetchAndInsertModule(t *testing.T) {
 						SourceInfo:        nil,
 						IsRedistributable: true,
 						HasGoMod:          true,
+						MinGoVersion:      "1.13",
+						LicenseExpression: "BSD-0-Clause",
 					},
 					LegacyReadmeFilePath: "README.md",
 					LegacyReadmeContents: "README FILE FOR TESTING.",
@@ -897,6 +982,7 @@ func TestFetchAndInsertModule(t *testing.T) {
 						SourceInfo:        source.NewGitHubInfo(goRepositoryURLPrefix+"/go", "src", "go1.12.5"),
 						IsRedistributable: true,
 						HasGoMod:          true,
+						LicenseExpression: "BSD-3-Clause",
 					},
 					LegacyReadmeFilePath: "README.md",
 					LegacyReadmeContents: "# The Go Programming Language\n",
@@ -932,6 +1018,7 @@ func TestFetchAndInsertModule(t *testing.T) {
 						SourceInfo:        source.NewGitHubInfo(goRepositoryURLPrefix+"/go", "src", "go1.12.5"),
 						IsRedistributable: true,
 						HasGoMod:          true,
+						LicenseExpression: "BSD-3-Clause",
 					},
 
 					LegacyReadmeFilePath: "README.md",
@@ -968,6 +1055,7 @@ func TestFetchAndInsertModule(t *testing.T) {
 						SourceInfo:        source.NewGitHubInfo(goRepositoryURLPrefix+"/go", "src", "go1.12.5"),
 						IsRedistributable: true,
 						HasGoMod:          true,
+						LicenseExpression: "BSD-3-Clause",
 					},
 					LegacyReadmeFilePath: "README.md",
 					LegacyReadmeContents: "# The Go Programming Language\n",
@@ -1016,6 +1104,7 @@ func TestFetchAndInsertModule(t *testing.T) {
 						SourceInfo:        nil,
 						IsRedistributable: true,
 						HasGoMod:          false,
+						LicenseExpression: "BSD-0-Clause",
 					},
 				},
 				LegacyPackage: internal.LegacyPackage{
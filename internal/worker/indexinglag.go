@@ -0,0 +1,126 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+var (
+	keyVersionState = tag.MustNewKey("worker.version_state")
+
+	latestIndexAge = stats.Float64(
+		"go-discovery/worker/latest_index_age",
+		"The age of the index timestamp of the most recently indexed module version.",
+		stats.UnitSeconds,
+	)
+	oldestPendingAge = stats.Float64(
+		"go-discovery/worker/oldest_pending_age",
+		"The age of the index timestamp of the earliest indexed module version that has not been processed.",
+		stats.UnitSeconds,
+	)
+	versionStateCount = stats.Int64(
+		"go-discovery/worker/version_state_count",
+		"The number of module versions in a state.",
+		stats.UnitDimensionless,
+	)
+
+	// LatestIndexAge is the last recorded age of the most recently indexed
+	// module version. It grows when polling the module index falls behind.
+	LatestIndexAge = &view.View{
+		Name:        "go-discovery/worker/latest_index_age",
+		Measure:     latestIndexAge,
+		Aggregation: view.LastValue(),
+		Description: "age of the most recently indexed module version",
+	}
+	// OldestPendingAge is the last recorded age of the earliest indexed module
+	// version that has not been processed. It grows when processing falls
+	// behind.
+	OldestPendingAge = &view.View{
+		Name:        "go-discovery/worker/oldest_pending_age",
+		Measure:     oldestPendingAge,
+		Aggregation: view.LastValue(),
+		Description: "age of the oldest unprocessed module version",
+	}
+	// VersionStateCount is the last recorded number of module versions that
+	// are pending and that are retryable, by state.
+	VersionStateCount = &view.View{
+		Name:        "go-discovery/worker/version_state_count",
+		Measure:     versionStateCount,
+		Aggregation: view.LastValue(),
+		Description: "number of pending and retryable module versions, by state",
+		TagKeys:     []tag.Key{keyVersionState},
+	}
+
+	// IndexingLagViews are the views of the metrics recorded by
+	// /record-indexing-lag.
+	IndexingLagViews = []*view.View{LatestIndexAge, OldestPendingAge, VersionStateCount}
+)
+
+// indexingLagSummary is the indexing lag as shown on the status page and
+// returned by /record-indexing-lag.
+type indexingLagSummary struct {
+	LatestIndexTimestamp        *time.Time // nil if zero
+	LatestIndexAge              time.Duration
+	LatestProcessedAt           *time.Time // nil if zero
+	OldestPendingIndexTimestamp *time.Time // nil if zero
+	OldestPendingAge            time.Duration
+	NumPending, NumRetryable    int
+}
+
+// summarizeIndexingLag returns the summary of lag at now. The age of a zero
+// time is zero.
+func summarizeIndexingLag(lag *postgres.IndexingLag, now time.Time) *indexingLagSummary {
+	timeAndAge := func(t time.Time) (*time.Time, time.Duration) {
+		if t.IsZero() {
+			return nil, 0
+		}
+		return &t, now.Sub(t).Round(time.Second)
+	}
+	s := &indexingLagSummary{
+		NumPending:   lag.NumPending,
+		NumRetryable: lag.NumRetryable,
+	}
+	s.LatestIndexTimestamp, s.LatestIndexAge = timeAndAge(lag.LatestIndexTimestamp)
+	s.LatestProcessedAt, _ = timeAndAge(lag.LatestProcessedAt)
+	s.OldestPendingIndexTimestamp, s.OldestPendingAge = timeAndAge(lag.OldestPendingIndexTimestamp)
+	return s
+}
+
+// recordIndexingLag records the metrics of the IndexingLagViews for s.
+func recordIndexingLag(ctx context.Context, s *indexingLagSummary) {
+	stats.Record(ctx,
+		latestIndexAge.M(s.LatestIndexAge.Seconds()),
+		oldestPendingAge.M(s.OldestPendingAge.Seconds()))
+	for state, n := range map[string]int{"pending": s.NumPending, "retryable": s.NumRetryable} {
+		stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(keyVersionState, state)}, versionStateCount.M(int64(n)))
+	}
+}
+
+// handleRecordIndexingLag computes the indexing lag, records it as metrics,
+// and writes a summary of it.
+func (s *Server) handleRecordIndexingLag(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "handleRecordIndexingLag")
+	ctx := r.Context()
+	lag, err := s.db.GetIndexingLag(ctx)
+	if err != nil {
+		return err
+	}
+	sum := summarizeIndexingLag(lag, time.Now())
+	recordIndexingLag(ctx, sum)
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintf(w, "latest index age: %s\noldest pending age: %s\npending: %d\nretryable: %d\n",
+		sum.LatestIndexAge, sum.OldestPendingAge, sum.NumPending, sum.NumRetryable)
+	return nil
+}
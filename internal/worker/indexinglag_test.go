@@ -0,0 +1,86 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opencensus.io/stats/view"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+func TestSummarizeIndexingLag(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	latest := now.Add(-90 * time.Second)
+	processed := now.Add(-time.Minute)
+	oldest := now.Add(-3*time.Hour - 400*time.Millisecond)
+
+	got := summarizeIndexingLag(&postgres.IndexingLag{
+		LatestIndexTimestamp:        latest,
+		LatestProcessedAt:           processed,
+		OldestPendingIndexTimestamp: oldest,
+		NumPending:                  7,
+		NumRetryable:                2,
+	}, now)
+	want := &indexingLagSummary{
+		LatestIndexTimestamp:        &latest,
+		LatestIndexAge:              90 * time.Second,
+		LatestProcessedAt:           &processed,
+		OldestPendingIndexTimestamp: &oldest,
+		OldestPendingAge:            3 * time.Hour,
+		NumPending:                  7,
+		NumRetryable:                2,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// Zero times, from an empty module_version_states table, have no age.
+	got = summarizeIndexingLag(&postgres.IndexingLag{}, now)
+	if diff := cmp.Diff(&indexingLagSummary{}, got); diff != "" {
+		t.Errorf("zero lag: mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRecordIndexingLag(t *testing.T) {
+	if err := view.Register(IndexingLagViews...); err != nil {
+		t.Fatal(err)
+	}
+	defer view.Unregister(IndexingLagViews...)
+
+	recordIndexingLag(context.Background(), &indexingLagSummary{
+		LatestIndexAge:   time.Minute,
+		OldestPendingAge: time.Hour,
+		NumPending:       7,
+		NumRetryable:     2,
+	})
+	for _, test := range []struct {
+		view *view.View
+		want map[string]float64 // from the value of the state tag, if any
+	}{
+		{LatestIndexAge, map[string]float64{"": 60}},
+		{OldestPendingAge, map[string]float64{"": 3600}},
+		{VersionStateCount, map[string]float64{"pending": 7, "retryable": 2}},
+	} {
+		rows, err := view.RetrieveData(test.view.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]float64{}
+		for _, r := range rows {
+			var state string
+			if len(r.Tags) > 0 {
+				state = r.Tags[0].Value
+			}
+			got[state] = r.Data.(*view.LastValueData).Value
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%s mismatch (-want +got):\n%s", test.view.Name, diff)
+		}
+	}
+}
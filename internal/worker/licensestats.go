@@ -0,0 +1,106 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// licenseStatsTTL is how long the result of postgres.DB.GetLicenseStats is
+// cached. The query scans every package, and the distribution changes slowly.
+const licenseStatsTTL = 6 * time.Hour
+
+// licenseStatsCache holds the most recent result of
+// postgres.DB.GetLicenseStats.
+type licenseStatsCache struct {
+	mu      sync.Mutex
+	stats   map[string]int
+	expires time.Time
+}
+
+// licenseStatsRow is a bar in the license stats chart.
+type licenseStatsRow struct {
+	Type  string
+	Count int
+	// Width is the width of the bar, as a percentage of the widest one.
+	Width string
+}
+
+// getLicenseStats returns the license stats, from the cache if they have not
+// expired. The lock is held during the query, so that concurrent requests do
+// not each run it.
+func (s *Server) getLicenseStats(ctx context.Context) (map[string]int, error) {
+	c := &s.licenseStats
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stats != nil && time.Now().Before(c.expires) {
+		return c.stats, nil
+	}
+	stats, err := s.db.GetLicenseStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.stats = stats
+	c.expires = time.Now().Add(licenseStatsTTL)
+	return stats, nil
+}
+
+// handleLicenseStats renders a bar chart of the number of packages under each
+// license type.
+func (s *Server) handleLicenseStats(w http.ResponseWriter, r *http.Request) error {
+	if s.licenseStatsTemplate == nil {
+		return errors.New("handleLicenseStats: no template; is the static path set?")
+	}
+	ctx := r.Context()
+	stats, err := s.getLicenseStats(ctx)
+	if err != nil {
+		return err
+	}
+	page := struct {
+		Rows []*licenseStatsRow
+	}{
+		Rows: licenseStatsRows(stats),
+	}
+	var buf bytes.Buffer
+	if err := s.licenseStatsTemplate.Execute(&buf, page); err != nil {
+		return fmt.Errorf("handleLicenseStats: %v", err)
+	}
+	if _, err := io.Copy(w, &buf); err != nil {
+		log.Errorf(ctx, "Error copying buffer to ResponseWriter: %v", err)
+	}
+	return nil
+}
+
+// licenseStatsRows returns the bars for stats, largest first.
+func licenseStatsRows(stats map[string]int) []*licenseStatsRow {
+	var rows []*licenseStatsRow
+	max := 0
+	for typ, n := range stats {
+		rows = append(rows, &licenseStatsRow{Type: typ, Count: n})
+		if n > max {
+			max = n
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Count != rows[j].Count {
+			return rows[i].Count > rows[j].Count
+		}
+		return rows[i].Type < rows[j].Type
+	})
+	for _, row := range rows {
+		row.Width = fmt.Sprintf("%.1f", 100*float64(row.Count)/float64(max))
+	}
+	return rows
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+func TestLicenseStatsRows(t *testing.T) {
+	got := licenseStatsRows(map[string]int{
+		"MIT":                       40,
+		"Apache-2.0":                80,
+		"BSD-3-Clause":              40,
+		postgres.UnlicensedStatsKey: 10,
+	})
+	want := []*licenseStatsRow{
+		{Type: "Apache-2.0", Count: 80, Width: "100.0"},
+		{Type: "BSD-3-Clause", Count: 40, Width: "50.0"},
+		{Type: "MIT", Count: 40, Width: "50.0"},
+		{Type: postgres.UnlicensedStatsKey, Count: 10, Width: "12.5"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("licenseStatsRows mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLicenseStatsTemplate(t *testing.T) {
+	tmpl, err := parseTemplate("../../content/static", "license_stats.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := struct{ Rows []*licenseStatsRow }{
+		Rows: licenseStatsRows(map[string]int{"MIT": 3, postgres.UnlicensedStatsKey: 1}),
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, page); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		`<td>MIT</td>`,
+		`<div style="width: 100.0%">`,
+		`<td>unlicensed</td>`,
+		`<div style="width: 33.3%">`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("rendered page does not contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/fetch"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/proxy"
+	"golang.org/x/pkgsite/internal/source"
+)
+
+// rerenderDocumentation renders the documentation of the packages in the
+// module version again with the current rendering code, and replaces the
+// documentation in the database with it. Nothing else about the module
+// version changes.
+//
+// The database does not keep the source of the module version, so its zip is
+// downloaded from the proxy again. It is not processed any further than
+// rendering.
+func rerenderDocumentation(ctx context.Context, modulePath, version string, proxyClient *proxy.Client, sourceClient *source.Client, db *postgres.DB) (err error) {
+	defer derrors.Wrap(&err, "rerenderDocumentation(%q, %q)", modulePath, version)
+
+	fr := fetch.FetchModule(ctx, modulePath, version, proxyClient, sourceClient)
+	if fr.Error != nil {
+		return fr.Error
+	}
+	return db.UpdateDocumentation(ctx, fr.Module)
+}
+
+// handleRerenderDocumentation re-renders the documentation of the module
+// version in the request path.
+func (s *Server) handleRerenderDocumentation(w http.ResponseWriter, r *http.Request) error {
+	modulePath, version, err := parseModulePathAndVersion(r.URL.Path)
+	if err != nil {
+		return &serverError{http.StatusBadRequest, err}
+	}
+	if err := rerenderDocumentation(r.Context(), modulePath, version, s.proxyClient, s.sourceClient, s.db); err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return &serverError{http.StatusNotFound, err}
+		}
+		return err
+	}
+	fmt.Fprintf(w, "Re-rendered documentation for %s@%s", modulePath, version)
+	return nil
+}
+
+// handleRerenderAllDocumentation re-renders the documentation of up to limit
+// module versions whose documentation was last written before the given
+// time. The module versions are chosen before responding, and re-rendered
+// one at a time in the background afterwards, so a batch can take longer than
+// a request is allowed to. Only one batch runs at a time. A module version
+// that fails to re-render is recorded, and left out of later batches for the
+// same time.
+func (s *Server) handleRerenderAllDocumentation(w http.ResponseWriter, r *http.Request) error {
+	limit := parseLimitParam(r, 100)
+	beforeParam := r.FormValue("before")
+	if beforeParam == "" {
+		return &serverError{
+			http.StatusBadRequest,
+			errors.New("must provide 'before' query param as an RFC3339 datetime"),
+		}
+	}
+	before, err := time.Parse(time.RFC3339, beforeParam)
+	if err != nil {
+		return &serverError{http.StatusBadRequest, err}
+	}
+	if !atomic.CompareAndSwapInt32(&s.rerendering, 0, 1) {
+		return &serverError{http.StatusConflict, errors.New("documentation is already being re-rendered")}
+	}
+	mis, err := s.db.GetModulesWithDocumentationBefore(r.Context(), before, limit)
+	if err != nil {
+		atomic.StoreInt32(&s.rerendering, 0)
+		return err
+	}
+
+	// The request's context is canceled when the response is written.
+	ctx := experiment.NewContext(context.Background(), experiment.FromContext(r.Context()))
+	go func() {
+		defer atomic.StoreInt32(&s.rerendering, 0)
+		var failed int
+		for _, mi := range mis {
+			if err := rerenderDocumentation(ctx, mi.ModulePath, mi.Version, s.proxyClient, s.sourceClient, s.db); err != nil {
+				log.Error(ctx, err)
+				failed++
+				// Skip the module version in the following batches, so
+				// that they make progress.
+				if err := s.db.RecordRerenderFailure(ctx, mi.ModulePath, mi.Version); err != nil {
+					log.Error(ctx, err)
+				}
+			}
+		}
+		log.Infof(ctx, "Re-rendered documentation for %d module versions; %d failed", len(mis)-failed, failed)
+	}()
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, "Re-rendering documentation for %d module versions", len(mis))
+	return nil
+}
@@ -0,0 +1,33 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRerenderDocumentationBadRequests(t *testing.T) {
+	s := &Server{}
+	for _, test := range []struct {
+		name    string
+		handler func(http.ResponseWriter, *http.Request) error
+		url     string
+		want    int
+	}{
+		{"no version", s.handleRerenderDocumentation, "/example.com/module", http.StatusBadRequest},
+		{"no before", s.handleRerenderAllDocumentation, "/rerender-all-documentation", http.StatusBadRequest},
+		{"bad before", s.handleRerenderAllDocumentation, "/rerender-all-documentation?before=yesterday", http.StatusBadRequest},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.errorHandler(test.handler)(w, httptest.NewRequest("GET", test.url, nil))
+			if w.Code != test.want {
+				t.Errorf("got status %d, want %d", w.Code, test.want)
+			}
+		})
+	}
+}
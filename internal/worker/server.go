@@ -24,6 +24,7 @@ import (
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/config"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/fetch"
 	"golang.org/x/pkgsite/internal/index"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/middleware"
@@ -48,7 +49,14 @@ type Server struct {
 	reportingClient      *errorreporting.Client
 	taskIDChangeInterval time.Duration
 
-	indexTemplate *template.Template
+	indexTemplate        *template.Template
+	licenseStatsTemplate *template.Template
+
+	licenseStats licenseStatsCache
+
+	// rerendering is 1 while handleRerenderAllDocumentation is re-rendering
+	// a batch of module versions, and 0 otherwise.
+	rerendering int32
 }
 
 // ServerConfig contains everything needed by a Server.
@@ -73,6 +81,10 @@ func NewServer(cfg *config.Config, scfg ServerConfig) (_ *Server, err error) {
 	if err != nil {
 		return nil, err
 	}
+	licenseStatsTemplate, err := parseTemplate(scfg.StaticPath, "license_stats.tmpl")
+	if err != nil {
+		return nil, err
+	}
 
 	return &Server{
 		cfg:                  cfg,
@@ -86,6 +98,7 @@ func NewServer(cfg *config.Config, scfg ServerConfig) (_ *Server, err error) {
 		reportingClient:      scfg.ReportingClient,
 		taskIDChangeInterval: scfg.TaskIDChangeInterval,
 		indexTemplate:        indexTemplate,
+		licenseStatsTemplate: licenseStatsTemplate,
 	}, nil
 }
 
@@ -121,6 +134,20 @@ func (s *Server) Install(handle func(string, http.Handler)) {
 	// This endpoint is intended to be invoked periodically by a scheduler.
 	handle("/update-imported-by-count", rmw(s.errorHandler(s.handleUpdateImportedByCount)))
 
+	// scheduled: recompute-search-rankings recomputes the ranking scores of
+	// all packages in search_documents from their imported_by_count and other
+	// signals, so that search does not have to compute them for each query.
+	// This endpoint is intended to be invoked nightly by a scheduler, after
+	// update-imported-by-count.
+	handle("/recompute-search-rankings", rmw(s.errorHandler(s.handleRecomputeSearchRankings)))
+
+	// scheduled: record-indexing-lag records metrics describing how far
+	// indexing is behind: the ages of the most recently indexed and oldest
+	// unprocessed module versions, and the numbers of pending and retryable
+	// versions. See IndexingLagViews.
+	// This endpoint is intended to be invoked every few minutes by a scheduler.
+	handle("/record-indexing-lag", rmw(s.errorHandler(s.handleRecordIndexingLag)))
+
 	// scheduled: download search document data and update the redis sorted
 	// set(s) used in auto-completion.
 	handle("/update-redis-indexes", rmw(s.errorHandler(s.handleUpdateRedisIndexes)))
@@ -173,12 +200,46 @@ func (s *Server) Install(handle func(string, http.Handler)) {
 	// "before" query parameter.
 	handle("/repopulate-search-documents", rmw(s.errorHandler(s.handleRepopulateSearchDocuments)))
 
+	// manual: rerender-documentation renders the documentation of the
+	// specified module version again, and replaces only its documentation.
+	handle("/rerender-documentation/", http.StripPrefix("/rerender-documentation", rmw(s.errorHandler(s.handleRerenderDocumentation))))
+
+	// manual: rerender-all-documentation re-renders, in the background, the
+	// documentation of up to "limit" module versions whose documentation was
+	// last written before the time in the "before" query parameter.
+	handle("/rerender-all-documentation", rmw(s.errorHandler(s.handleRerenderAllDocumentation)))
+
+	// manual: add-webhook subscribes the URL in the "url" query parameter to
+	// notifications of new versions of the modules matching the "pattern"
+	// query parameter.
+	handle("/add-webhook", rmw(s.errorHandler(s.handleAddWebhook)))
+
+	// manual: delete-webhook deletes the webhook subscription with the ID in
+	// the "id" query parameter.
+	handle("/delete-webhook", rmw(s.errorHandler(s.handleDeleteWebhook)))
+
 	// manual: clear-cache clears the redis cache.
 	handle("/clear-cache", rmw(s.errorHandler(s.clearCache)))
 
 	// manual: delete the specified module version.
 	handle("/delete/", http.StripPrefix("/delete", rmw(s.errorHandler(s.handleDelete))))
 
+	// manual: discover asks the module proxy for the versions of the
+	// specified module, and enqueues the ones that are not yet in the
+	// database for processing.
+	// See the comments on duplicate tasks for "/requeue", above.
+	handle("/discover/", http.StripPrefix("/discover", rmw(s.errorHandler(s.handleDiscover))))
+
+	// manual: search runs the search query in the "q" query parameter and also
+	// lists matching packages that are suppressed from search because a later
+	// version of their module has an alternative module path.
+	handle("/search", rmw(s.errorHandler(s.handleSearch)))
+
+	// manual: license-stats shows a chart of the number of packages under
+	// each license type, including those with no detected license. The counts
+	// are cached for a few hours.
+	handle("/license-stats", rmw(s.errorHandler(s.handleLicenseStats)))
+
 	// returns the Worker homepage.
 	handle("/", http.HandlerFunc(s.handleStatusPage))
 }
@@ -193,6 +254,17 @@ func (s *Server) handleUpdateImportedByCount(w http.ResponseWriter, r *http.Requ
 	return nil
 }
 
+// handleRecomputeSearchRankings recomputes the search ranking scores of all
+// packages.
+func (s *Server) handleRecomputeSearchRankings(w http.ResponseWriter, r *http.Request) error {
+	n, err := s.db.RecomputeSearchRankings(r.Context())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "updated %d packages", n)
+	return nil
+}
+
 // handleRepopulateSearchDocuments repopulates every row in the search_documents table
 // that was last updated before the given time.
 func (s *Server) handleRepopulateSearchDocuments(w http.ResponseWriter, r *http.Request) error {
@@ -387,6 +459,7 @@ func (s *Server) doStatusPage(w http.ResponseWriter, r *http.Request) (_ string,
 	var (
 		next, failures, recents []*internal.ModuleVersionState
 		stats                   *postgres.VersionStats
+		lag                     *postgres.IndexingLag
 		experiments             []*internal.Experiment
 		excluded                []string
 	)
@@ -427,6 +500,14 @@ func (s *Server) doStatusPage(w http.ResponseWriter, r *http.Request) (_ string,
 		}
 		return nil
 	})
+	g.Go(func() error {
+		var err error
+		lag, err = s.db.GetIndexingLag(ctx)
+		if err != nil {
+			return annotation{err, "error fetching indexing lag"}
+		}
+		return nil
+	})
 	g.Go(func() error {
 		var err error
 		experiments, err = s.db.GetExperiments(ctx)
@@ -483,6 +564,7 @@ func (s *Server) doStatusPage(w http.ResponseWriter, r *http.Request) (_ string,
 		ResourcePrefix               string
 		LatestTimestamp              *time.Time
 		Counts                       []*count
+		Lag                          *indexingLagSummary
 		Next, Recent, RecentFailures []*internal.ModuleVersionState
 		Experiments                  []*internal.Experiment
 		Excluded                     []string
@@ -492,6 +574,7 @@ func (s *Server) doStatusPage(w http.ResponseWriter, r *http.Request) (_ string,
 		ResourcePrefix:  strings.ToLower(env) + "-",
 		LatestTimestamp: &stats.LatestTimestamp,
 		Counts:          counts,
+		Lag:             summarizeIndexingLag(lag, time.Now()),
 		Next:            next,
 		Recent:          recents,
 		RecentFailures:  failures,
@@ -572,6 +655,99 @@ func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+// handleDiscover enqueues the versions of the module in the request path that
+// the module proxy knows about but the database does not have, and writes
+// the versions it enqueued.
+func (s *Server) handleDiscover(w http.ResponseWriter, r *http.Request) error {
+	modulePath := strings.Trim(r.URL.Path, "/")
+	if modulePath == "" {
+		return &serverError{http.StatusBadRequest, errors.New("module path was not specified")}
+	}
+	versions, err := s.discoverNewVersions(r.Context(), modulePath, r.FormValue("suffix"))
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return &serverError{http.StatusNotFound, err}
+		}
+		return err
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	for _, v := range versions {
+		fmt.Fprintf(w, "scheduled %s@%s\n", modulePath, v)
+	}
+	return nil
+}
+
+// discoverNewVersions schedules fetches for the versions of modulePath that
+// the module proxy knows about but that are not in the modules table, and
+// returns them in increasing semver order. Versions that already failed
+// permanently are skipped, so that they are not fetched again on every poll.
+func (s *Server) discoverNewVersions(ctx context.Context, modulePath, suffix string) (_ []string, err error) {
+	defer derrors.Wrap(&err, "discoverNewVersions(ctx, %q)", modulePath)
+
+	discovered, err := fetch.DiscoverVersions(ctx, modulePath, s.proxyClient)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.db.GetStoredVersions(ctx, modulePath)
+	if err != nil {
+		return nil, err
+	}
+	failed, err := s.db.GetPermanentlyFailedVersions(ctx, modulePath)
+	if err != nil {
+		return nil, err
+	}
+	have := map[string]bool{}
+	for _, v := range append(stored, failed...) {
+		have[v] = true
+	}
+	var versions []string
+	for _, v := range discovered {
+		if have[v] {
+			continue
+		}
+		if err := s.queue.ScheduleFetch(ctx, modulePath, v, suffix, s.taskIDChangeInterval); err != nil {
+			return nil, fmt.Errorf("error scheduling fetch for %s: %w", v, err)
+		}
+		versions = append(versions, v)
+	}
+	log.Infof(ctx, "discoverNewVersions: scheduled %d of %d versions of %s", len(versions), len(discovered), modulePath)
+	if err := s.db.UpdateModuleCheck(ctx, modulePath); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// handleSearch writes the search results for the query in the "q" parameter,
+// followed by the packages matching the query that are left out of search
+// because their module has a newer version with an alternative module path.
+// Public search is unaffected.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) error {
+	q := strings.TrimSpace(r.FormValue("q"))
+	if q == "" {
+		return &serverError{http.StatusBadRequest, errors.New("q was not specified")}
+	}
+	limit := parseLimitParam(r, 10)
+	ctx := r.Context()
+	results, err := s.db.Search(ctx, q, limit, 0)
+	if err != nil {
+		return err
+	}
+	suppressed, err := s.db.GetSuppressedPackages(ctx, q, limit)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Search results for %q:\n", q)
+	for _, r := range results {
+		fmt.Fprintf(w, "  %s (%s@%s)\n", r.PackagePath, r.ModulePath, r.Version)
+	}
+	fmt.Fprintf(w, "\nSUPPRESSED (not shown in public search) because of a newer alternative module version:\n")
+	for _, sp := range suppressed {
+		fmt.Fprintf(w, "  %s (%s@%s): %s@%s has go.mod module path %q\n",
+			sp.PackagePath, sp.ModulePath, sp.Version, sp.ModulePath, sp.AlternativeVersion, sp.AlternativeModulePath)
+	}
+	return nil
+}
+
 // Parse the template for the status page.
 func parseTemplate(staticPath, filename string) (*template.Template, error) {
 	if staticPath == "" {
@@ -161,6 +161,16 @@ func TestWorker(t *testing.T) {
 			},
 			wantFoo: fooState(http.StatusOK, 1),
 			wantBar: barState(http.StatusNotFound, 1),
+		}, {
+			label: "discover",
+			index: []*internal.IndexVersion{fooIndex, barIndex},
+			proxy: []*proxy.TestModule{fooProxy, barProxy},
+			requests: []*http.Request{
+				httptest.NewRequest("POST", "/poll", nil),
+				httptest.NewRequest("POST", "/discover/foo.com/foo", nil),
+			},
+			wantFoo: fooState(http.StatusOK, 1),
+			wantBar: barState(0, 0),
 		},
 	}
 	for _, test := range tests {
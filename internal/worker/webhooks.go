@@ -0,0 +1,55 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/webhook"
+)
+
+// handleAddWebhook subscribes the URL in the "url" query parameter to the
+// module versions matching the pattern in the "pattern" query parameter, and
+// writes the ID of the subscription and the secret that signs its
+// notifications. Subscribing an existing pattern and URL again replaces the
+// secret.
+func (s *Server) handleAddWebhook(w http.ResponseWriter, r *http.Request) error {
+	pattern, callbackURL := r.FormValue("pattern"), r.FormValue("url")
+	if err := webhook.CheckSubscription(pattern, callbackURL); err != nil {
+		return &serverError{http.StatusBadRequest, err}
+	}
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return err
+	}
+	id, err := s.db.InsertWebhookSubscription(r.Context(), pattern, callbackURL, secret)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintf(w, "Added webhook subscription %d for %s\nsecret: %s\n", id, pattern, secret)
+	return nil
+}
+
+// handleDeleteWebhook deletes the subscription whose ID is in the "id" query
+// parameter.
+func (s *Server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		return &serverError{http.StatusBadRequest, fmt.Errorf("invalid id: %v", err)}
+	}
+	if err := s.db.DeleteWebhookSubscription(r.Context(), id); err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return &serverError{http.StatusNotFound, err}
+		}
+		return err
+	}
+	fmt.Fprintf(w, "Deleted webhook subscription %d", id)
+	return nil
+}
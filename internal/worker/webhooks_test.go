@@ -0,0 +1,67 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+func TestWebhookBadRequests(t *testing.T) {
+	s := &Server{}
+	for _, test := range []struct {
+		name    string
+		handler func(http.ResponseWriter, *http.Request) error
+		url     string
+	}{
+		{"no pattern", s.handleAddWebhook, "/add-webhook?url=https://example.org/hook"},
+		{"bad pattern", s.handleAddWebhook, "/add-webhook?pattern=a+b&url=https://example.org/hook"},
+		{"no url", s.handleAddWebhook, "/add-webhook?pattern=example.com/..."},
+		{"relative url", s.handleAddWebhook, "/add-webhook?pattern=example.com/...&url=/hook"},
+		{"bad id", s.handleDeleteWebhook, "/delete-webhook?id=one"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.errorHandler(test.handler)(w, httptest.NewRequest("GET", test.url, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("got status %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	defer postgres.ResetTestDB(testDB, t)
+
+	s := &Server{db: testDB}
+	w := httptest.NewRecorder()
+	q := url.Values{"pattern": {"example.com/..."}, "url": {"https://example.org/hook"}}
+	s.errorHandler(s.handleAddWebhook)(w, httptest.NewRequest("GET", "/add-webhook?"+q.Encode(), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("add-webhook: got status %d, want 200; body:\n%s", w.Code, w.Body)
+	}
+	subs, err := testDB.GetWebhookSubscriptions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 1 || !strings.Contains(w.Body.String(), subs[0].Secret) {
+		t.Fatalf("got subscriptions %+v, want one whose secret is in %q", subs, w.Body)
+	}
+
+	for _, want := range []int{http.StatusOK, http.StatusNotFound} {
+		w = httptest.NewRecorder()
+		s.errorHandler(s.handleDeleteWebhook)(w, httptest.NewRequest("GET", "/delete-webhook?id="+strconv.Itoa(subs[0].ID), nil))
+		if w.Code != want {
+			t.Errorf("delete-webhook: got status %d, want %d", w.Code, want)
+		}
+	}
+}
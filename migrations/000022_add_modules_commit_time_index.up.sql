@@ -0,0 +1,11 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE INDEX idx_modules_commit_time ON modules (commit_time DESC);
+COMMENT ON INDEX idx_modules_commit_time IS
+'INDEX idx_modules_commit_time is used to list the most recently published modules.';
+
+END;
@@ -0,0 +1,23 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE modules ADD COLUMN is_latest boolean NOT NULL DEFAULT false;
+COMMENT ON COLUMN modules.is_latest IS
+'COLUMN is_latest reports whether this is the latest version of the module: the highest release version, or the highest prerelease or pseudo-version if there are no releases.';
+
+UPDATE modules m SET is_latest = true
+FROM (
+    SELECT DISTINCT ON (module_path) module_path, version
+    FROM modules
+    ORDER BY module_path, version_type = 'release' DESC, sort_version DESC
+) l
+WHERE m.module_path = l.module_path AND m.version = l.version;
+
+CREATE INDEX idx_modules_is_latest ON modules (module_path) WHERE is_latest;
+COMMENT ON INDEX idx_modules_is_latest IS
+'INDEX idx_modules_is_latest is used to look up the latest version of a module.';
+
+END;
@@ -0,0 +1,15 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE packages ADD COLUMN documentation_truncated boolean NOT NULL DEFAULT false;
+COMMENT ON COLUMN packages.documentation_truncated IS
+'COLUMN documentation_truncated reports whether the documentation column was truncated because it exceeded the maximum stored size.';
+
+ALTER TABLE documentation ADD COLUMN truncated boolean NOT NULL DEFAULT false;
+COMMENT ON COLUMN documentation.truncated IS
+'COLUMN truncated reports whether the html column was truncated because it exceeded the maximum stored size.';
+
+END;
@@ -0,0 +1,15 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+UPDATE licenses l
+SET contents = c.contents
+FROM license_contents c
+WHERE l.contents_hash = c.hash;
+
+ALTER TABLE licenses DROP COLUMN contents_hash;
+DROP TABLE license_contents;
+
+END;
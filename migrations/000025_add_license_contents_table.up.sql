@@ -0,0 +1,30 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE license_contents (
+    hash text PRIMARY KEY,
+    contents text NOT NULL
+);
+COMMENT ON TABLE license_contents IS
+'TABLE license_contents contains the contents of license files, stored once for each distinct contents.';
+COMMENT ON COLUMN license_contents.hash IS
+'COLUMN hash is the hex-encoded SHA-256 hash of contents.';
+
+ALTER TABLE licenses ADD COLUMN contents_hash text REFERENCES license_contents(hash);
+COMMENT ON COLUMN licenses.contents_hash IS
+'COLUMN contents_hash is the hash of the license file contents in license_contents. If it is set, the contents column is empty.';
+
+INSERT INTO license_contents (hash, contents)
+SELECT DISTINCT encode(sha256(convert_to(contents, 'UTF8')), 'hex'), contents
+FROM licenses
+WHERE contents <> ''
+ON CONFLICT DO NOTHING;
+
+UPDATE licenses
+SET contents_hash = encode(sha256(convert_to(contents, 'UTF8')), 'hex'), contents = ''
+WHERE contents <> '';
+
+END;
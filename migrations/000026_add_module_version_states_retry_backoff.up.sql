@@ -0,0 +1,11 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE module_version_states ADD COLUMN retry_backoff interval NOT NULL DEFAULT '0';
+COMMENT ON COLUMN module_version_states.retry_backoff IS
+'COLUMN retry_backoff is the delay before the next attempt to process a module version whose last attempt failed with a retryable status. It doubles with each consecutive retryable failure, and is zero if the last attempt did not fail that way.';
+
+END;
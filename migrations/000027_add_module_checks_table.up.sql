@@ -0,0 +1,26 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE module_checks (
+    module_path text PRIMARY KEY,
+    last_checked timestamp with time zone NOT NULL
+);
+COMMENT ON TABLE module_checks IS
+'TABLE module_checks records when each module was last checked with the module proxy, so that modules can be rechecked for new versions.';
+COMMENT ON COLUMN module_checks.last_checked IS
+'COLUMN last_checked is the last time any version of the module was processed.';
+
+CREATE INDEX idx_module_checks_last_checked ON module_checks (last_checked);
+COMMENT ON INDEX idx_module_checks_last_checked IS
+'INDEX idx_module_checks_last_checked is used to find the modules that have not been checked recently.';
+
+INSERT INTO module_checks (module_path, last_checked)
+SELECT module_path, max(last_processed_at)
+FROM module_version_states
+WHERE last_processed_at IS NOT NULL
+GROUP BY module_path;
+
+END;
@@ -0,0 +1,84 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE search_documents
+    ADD COLUMN ranking_score double precision NOT NULL DEFAULT 1,
+    ADD COLUMN ranking_score_updated_at timestamp with time zone;
+COMMENT ON COLUMN search_documents.ranking_score IS
+'COLUMN ranking_score is the part of the search score that does not depend on the query: the product of the popularity and penalty factors. It is recomputed periodically from the other columns.';
+COMMENT ON COLUMN search_documents.ranking_score_updated_at IS
+'COLUMN ranking_score_updated_at is when ranking_score was last recomputed.';
+
+-- These factors match the penalties in internal/postgres/search.go.
+UPDATE search_documents SET
+    ranking_score =
+        ln(exp(1)+imported_by_count) *
+        CASE WHEN redistributable THEN 1 ELSE 0.5 END *
+        CASE WHEN COALESCE(has_go_mod, true) THEN 1 ELSE 0.8 END,
+    ranking_score_updated_at = CURRENT_TIMESTAMP;
+
+CREATE INDEX idx_search_documents_ranking_score_desc ON search_documents (ranking_score DESC);
+COMMENT ON INDEX idx_search_documents_ranking_score_desc IS
+'INDEX idx_search_documents_ranking_score_desc is used by popular_search_ranking_score to execute a partial scan of popular search documents.';
+
+CREATE FUNCTION popular_search_ranking_score(rawquery text, lim integer, off integer) RETURNS SETOF search_result
+    LANGUAGE plpgsql
+    AS $$
+	DECLARE cur CURSOR(query TSQUERY) FOR
+		SELECT
+			package_path,
+			module_path,
+			version,
+			commit_time,
+			imported_by_count,
+			(
+				-- default D, C, B, A weights are {0.1, 0.2, 0.4, 1.0}
+				ts_rank('{0.1, 0.2, 1.0, 1.0}', tsv_search_tokens, query) *
+				ranking_score *
+				CASE WHEN tsv_search_tokens @@ query THEN 1 ELSE 0 END
+			) score,
+			ranking_score
+			FROM search_documents
+			ORDER BY ranking_score DESC;
+	top search_result[];
+	res search_result;
+	res_ranking_score double precision;
+	last_idx INT;
+BEGIN
+	last_idx := lim+off;
+	top := array_fill(NULL::search_result, array[last_idx]);
+	OPEN cur(query := websearch_to_tsquery(rawquery));
+	FETCH cur INTO res.package_path, res.module_path, res.version, res.commit_time,
+		res.imported_by_count, res.score, res_ranking_score;
+	WHILE found LOOP
+		IF top[last_idx] IS NULL OR res.score >= top[last_idx].score THEN
+			FOR i IN 1..last_idx LOOP
+				IF top[i] IS NULL OR
+					(res.score > top[i].score) OR
+					(res.score = top[i].score AND res.commit_time > top[i].commit_time) OR
+					(res.score = top[i].score AND res.commit_time = top[i].commit_time AND
+					 res.package_path < top[i].package_path) THEN
+					top := (top[1:i-1] || res) || top[i:last_idx-1];
+					EXIT;
+				END IF;
+			END LOOP;
+		END IF;
+		-- ts_rank is at most 1, so no later document can score more than its
+		-- ranking_score.
+		IF top[last_idx].score > res_ranking_score THEN
+			EXIT;
+		END IF;
+		FETCH cur INTO res.package_path, res.module_path, res.version, res.commit_time,
+			res.imported_by_count, res.score, res_ranking_score;
+	END LOOP;
+	CLOSE cur;
+	RETURN QUERY SELECT * FROM UNNEST(top[off+1:last_idx])
+		WHERE package_path IS NOT NULL AND score > 0.1;
+END; $$;
+COMMENT ON FUNCTION popular_search_ranking_score(rawquery text, lim integer, off integer) IS
+'FUNCTION popular_search_ranking_score is like popular_search, but it uses the precomputed ranking_score of each search document instead of computing the popularity and penalty factors.';
+
+END;
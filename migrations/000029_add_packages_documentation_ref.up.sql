@@ -0,0 +1,11 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE packages ADD COLUMN documentation_ref text;
+COMMENT ON COLUMN packages.documentation_ref IS
+'COLUMN documentation_ref, if not NULL, is the key under which the documentation HTML is kept in an object store, in place of the documentation column.';
+
+END;
@@ -0,0 +1,14 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE module_files (
+    module_id INTEGER PRIMARY KEY REFERENCES modules (id) ON DELETE CASCADE,
+    paths     text[] NOT NULL
+);
+COMMENT ON TABLE module_files IS
+'TABLE module_files contains the paths of the files in each module version, relative to the module root, in sorted order.';
+
+END;
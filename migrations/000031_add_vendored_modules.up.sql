@@ -0,0 +1,18 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE vendored_modules (
+    module_id            INTEGER NOT NULL REFERENCES modules (id) ON DELETE CASCADE,
+    vendored_module_path text NOT NULL,
+    vendored_version     text NOT NULL, -- empty if the module is replaced by a directory
+    replacement          text NOT NULL, -- empty if the module is not replaced
+
+    PRIMARY KEY (module_id, vendored_module_path)
+);
+COMMENT ON TABLE vendored_modules IS
+'TABLE vendored_modules contains the modules listed in the vendor/modules.txt file at the root of each module version.';
+
+END;
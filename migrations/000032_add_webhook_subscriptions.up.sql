@@ -0,0 +1,19 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE webhook_subscriptions (
+    id                  SERIAL PRIMARY KEY,
+    module_path_pattern text NOT NULL, -- a module path, or a path followed by "/..."
+    callback_url        text NOT NULL,
+    secret              text NOT NULL, -- key for the HMAC signature of each payload
+    created_at          timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (module_path_pattern, callback_url)
+);
+COMMENT ON TABLE webhook_subscriptions IS
+'TABLE webhook_subscriptions contains the URLs to notify when a version of a module matching a pattern is inserted.';
+
+END;
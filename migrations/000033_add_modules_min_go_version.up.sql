@@ -0,0 +1,11 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE modules ADD COLUMN min_go_version text NOT NULL DEFAULT '';
+COMMENT ON COLUMN modules.min_go_version IS
+'COLUMN min_go_version is the Go version in the go directive of the module''s go.mod file, like "1.18". It is empty if there is no go.mod file or the file has no go directive, or if the module version was inserted before the column was added.';
+
+END;
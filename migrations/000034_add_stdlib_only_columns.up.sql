@@ -0,0 +1,15 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE packages ADD COLUMN stdlib_only boolean NOT NULL DEFAULT false;
+COMMENT ON COLUMN packages.stdlib_only IS
+'COLUMN stdlib_only is true if the package imports only standard library packages, or nothing. It is false for packages inserted before the column was added.';
+
+ALTER TABLE search_documents ADD COLUMN stdlib_only boolean NOT NULL DEFAULT false;
+COMMENT ON COLUMN search_documents.stdlib_only IS
+'COLUMN stdlib_only is the stdlib_only column of the package in the packages table.';
+
+END;
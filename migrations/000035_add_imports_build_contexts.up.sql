@@ -0,0 +1,11 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE imports ADD COLUMN build_contexts text[];
+COMMENT ON COLUMN imports.build_contexts IS
+'COLUMN build_contexts lists the build contexts, as GOOS/GOARCH, in which from_path imports to_path. It is NULL if from_path imports to_path in every build context in which it builds, and for rows inserted before the column was added.';
+
+END;
@@ -0,0 +1,11 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE packages ADD COLUMN documentation_text text;
+COMMENT ON COLUMN packages.documentation_text IS
+'COLUMN documentation_text is the documentation of the package as plain text, in the format of "go doc -all". It is NULL for packages inserted before the column was added.';
+
+END;
@@ -0,0 +1,11 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+DROP INDEX idx_search_documents_signatures;
+ALTER TABLE search_documents DROP COLUMN signatures;
+ALTER TABLE packages DROP COLUMN signatures;
+
+END;
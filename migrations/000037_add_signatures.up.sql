@@ -0,0 +1,17 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE packages ADD COLUMN signatures text[];
+COMMENT ON COLUMN packages.signatures IS
+'COLUMN signatures are the normalized signatures of the exported functions and methods of the package, like "func(Context, int) error". It is NULL for packages inserted before the column was added.';
+
+ALTER TABLE search_documents ADD COLUMN signatures text[];
+COMMENT ON COLUMN search_documents.signatures IS
+'COLUMN signatures is the signatures column of the package in the packages table.';
+
+CREATE INDEX idx_search_documents_signatures ON search_documents USING gin (signatures);
+
+END;
@@ -0,0 +1,15 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE modules ADD COLUMN changelog_file_path text;
+COMMENT ON COLUMN modules.changelog_file_path IS
+'COLUMN changelog_file_path is the path of the CHANGELOG, HISTORY or CHANGES file at the root of the module, relative to the module root. It is NULL if the module has none, or was inserted before the column was added.';
+
+ALTER TABLE modules ADD COLUMN changelog_contents text;
+COMMENT ON COLUMN modules.changelog_contents IS
+'COLUMN changelog_contents are the contents of the file at changelog_file_path.';
+
+END;
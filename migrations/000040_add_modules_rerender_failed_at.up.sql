@@ -0,0 +1,11 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE modules ADD COLUMN rerender_failed_at timestamp with time zone;
+COMMENT ON COLUMN modules.rerender_failed_at IS
+'COLUMN rerender_failed_at is when re-rendering the documentation of the module version last failed. It is NULL if it never failed.';
+
+END;
@@ -0,0 +1,11 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE modules ADD COLUMN license_expression text NOT NULL DEFAULT '';
+COMMENT ON COLUMN modules.license_expression IS
+'COLUMN license_expression describes the licenses at the root of the module as an SPDX-style expression, like "Apache-2.0 OR MIT". It is empty if no licenses were detected, or if the module version was inserted before the column was added.';
+
+END;
@@ -0,0 +1,11 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE licenses ADD COLUMN partial_scan boolean NOT NULL DEFAULT false;
+COMMENT ON COLUMN licenses.partial_scan IS
+'COLUMN partial_scan records whether types and coverage describe only the start of the license file, because the whole file was too large or had too little license text to classify.';
+
+END;
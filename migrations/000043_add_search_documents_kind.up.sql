@@ -0,0 +1,12 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE search_documents ADD COLUMN kind text NOT NULL DEFAULT 'library';
+UPDATE search_documents SET kind = 'command' WHERE name = 'main';
+COMMENT ON COLUMN search_documents.kind IS
+'COLUMN kind is the kind of the package: "command" for a main package, and "library" otherwise.';
+
+END;